@@ -22,6 +22,15 @@ const (
 // the operator has synced into a ConfigMap.
 const RenovateConfigMapAnnotationKey = GroupName + "/renovate-config-configmap"
 
+// Annotations the UI API stamps on the RenovateJobs it creates and edits, so
+// a change made outside of Git can be traced to who made it.
+const (
+	// ModifiedByAnnotationKey holds the email of the user who made the change.
+	ModifiedByAnnotationKey = GroupName + "/modified-by"
+	// ModifiedAtAnnotationKey holds the time of the change in RFC 3339.
+	ModifiedAtAnnotationKey = GroupName + "/modified-at"
+)
+
 // Annotations users apply to a RenovateJob to trigger a run. The operator removes
 // each one once it has acted on it.
 const (
@@ -33,6 +42,9 @@ const (
 	// TriggerScheduleAnnotationKey sets the listed non-running projects to
 	// Scheduled. Its value is a comma-separated list of project names.
 	TriggerScheduleAnnotationKey = GroupName + "/schedule"
+	// TriggerApproveAnnotationKey approves the listed pending-approval projects
+	// and schedules them. Its value is a comma-separated list of project names.
+	TriggerApproveAnnotationKey = GroupName + "/approve"
 )
 
 // TokenExpiresAtAnnotationKey records an RFC3339 expiry on a Secret holding a
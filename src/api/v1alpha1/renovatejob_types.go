@@ -15,6 +15,11 @@ import (
 type RenovateJobSpec struct {
 	// Cron schedule in standard cron format
 	Schedule string `json:"schedule"`
+	// Optional cron schedule for refreshing the project list. When set, discovery
+	// runs only on this schedule and the main schedule marks the projects found by
+	// the last successful discovery as scheduled, without starting a discovery pod.
+	// +optional
+	DiscoverySchedule string `json:"discoverySchedule,omitempty"`
 	// Renovate Docker image to use
 	Image string `json:"image"`
 	// Renovate Provider Information to fill "RENOVATE_ENDPOINT" and "RENOVATE_PLATFORM" environment variables in the renovate container
@@ -23,10 +28,49 @@ type RenovateJobSpec struct {
 	DiscoveryFilters []string `json:"discoveryFilters,omitempty"`
 	// Topics to discover projects from, will be concatenated using , separator
 	DiscoverTopics []string `json:"discoverTopics,omitempty"`
+	// How projects are discovered: "renovate" (default) starts a Renovate discovery pod,
+	// "native" lists the repositories through the platform API from within the operator.
+	// Native discovery uses the platform token and honours discoveryFilters,
+	// discoverTopics and discoveryNamespaces.
+	// +kubebuilder:validation:Enum=renovate;native
+	// +optional
+	DiscoveryMode DiscoveryMode `json:"discoveryMode,omitempty"`
+	// Organisations, groups, users or workspaces to list in native discovery. When
+	// empty, every repository the token can access is listed.
+	// +optional
+	DiscoveryNamespaces []string `json:"discoveryNamespaces,omitempty"`
+	// Repositories to always process, in addition to any discovered ones. When set
+	// without discoveryFilters or discoverTopics, no discovery pod is started and
+	// this list is the complete project list.
+	// +optional
+	Repositories []string `json:"repositories,omitempty"`
+	// Repositories to drop from the discovered list. Entries are globs ("org/legacy-*",
+	// "group/**") or regular expressions wrapped in slashes ("/^org/tmp-.*$/").
+	// Repositories listed in repositories are never excluded.
+	// +optional
+	ExcludeRepositories []string `json:"excludeRepositories,omitempty"`
+	// Approval gate for newly discovered projects
+	// +optional
+	Onboarding *RenovateJobOnboarding `json:"onboarding,omitempty"`
 	// If true, forked repositories discovered during autodiscovery will be excluded by querying the platform API
 	SkipForks bool `json:"skipForks,omitempty"`
 	// If true, repositories marked for delayed deletion (pending deletion) will be excluded by querying the platform API. Only GitLab exposes this state.
 	SkipPendingDeletion bool `json:"skipPendingDeletion,omitempty"`
+	// If true, archived repositories will be excluded by querying the platform API
+	// +optional
+	SkipArchived bool `json:"skipArchived,omitempty"`
+	// If true, repositories without any commits will be excluded by querying the platform API
+	// +optional
+	SkipEmpty bool `json:"skipEmpty,omitempty"`
+	// Excludes repositories without a push for this long, e.g. "180d" or "72h". Platforms
+	// without a push timestamp use their last activity or update time instead.
+	// +kubebuilder:validation:Pattern=`^([0-9]+d)?([0-9]+h)?([0-9]+m)?$`
+	// +optional
+	SkipInactiveFor string `json:"skipInactiveFor,omitempty"`
+	// If set, only repositories with one of these visibilities are kept
+	// +kubebuilder:validation:items:Enum=public;internal;private
+	// +optional
+	Visibility []string `json:"visibility,omitempty"`
 	// Reference to the secret containing the renovate config
 	SecretRef string `json:"secretRef,omitempty"`
 	// Renovate configuration file for the job pods
@@ -88,6 +132,9 @@ type RenovateJobSpec struct {
 	// RuntimeClassName for the resulting pod, used to select a non-default container runtime
 	// +optional
 	RuntimeClassName *string `json:"runtimeClassName,omitempty"`
+	// Chat and webhook notifications about the projects of this job
+	// +optional
+	Notifications *RenovateJobNotifications `json:"notifications,omitempty"`
 }
 
 // Renovate configuration file source for the job pods
@@ -155,6 +202,28 @@ type RenovateJobAccess struct {
 	// logs are unredacted, so this is opt-in separately from AnonymousRead.
 	// +optional
 	AnonymousReadLogs *bool `json:"anonymousReadLogs,omitempty"`
+	// Roles granted to groups and users on this RenovateJob, in addition to
+	// the reader and admin lists. A role is one of the operator-wide custom
+	// roles (AUTHORIZATION_ROLES) or the built-in reader or admin. Bindings
+	// have no operator-wide default, and a binding naming an unknown role
+	// grants nothing.
+	// +optional
+	RoleBindings []RenovateJobRoleBinding `json:"roleBindings,omitempty"`
+}
+
+// RenovateJobRoleBinding grants a role on a RenovateJob to groups and users.
+// +kubebuilder:validation:XValidation:rule="has(self.groups) || has(self.users)",message="a role binding needs groups or users"
+type RenovateJobRoleBinding struct {
+	// Name of the role.
+	// +kubebuilder:validation:MinLength=1
+	// +kubebuilder:validation:MaxLength=63
+	Role string `json:"role"`
+	// Groups granted the role.
+	// +optional
+	Groups []string `json:"groups,omitempty"`
+	// Individual users granted the role, matched like ReaderUsers.
+	// +optional
+	Users []string `json:"users,omitempty"`
 }
 
 type RenovateJobScratchVolume struct {
@@ -203,6 +272,78 @@ type RenovateJobSecurityContext struct {
 	Container *corev1.SecurityContext `json:"container,omitempty"`
 }
 
+// approval gate for projects that discovery adds to a RenovateJob
+type RenovateJobOnboarding struct {
+	// If true, newly discovered projects are added as pending-approval and are
+	// not run until an admin approves them
+	RequireApproval bool `json:"requireApproval"`
+	// Newly discovered projects matching one of these patterns are approved
+	// automatically. Same syntax as excludeRepositories.
+	// +optional
+	AutoApprove []string `json:"autoApprove,omitempty"`
+}
+
+// chat and webhook notifications about the projects of a RenovateJob
+type RenovateJobNotifications struct {
+	// Routes decide which target hears about which events. Every matching
+	// route sends its own notification.
+	// +optional
+	Routes []NotificationRoute `json:"routes,omitempty"`
+	// Projects that never send a notification, e.g. while a known breakage is
+	// being fixed. Same syntax as excludeRepositories.
+	// +optional
+	MutedProjects []string `json:"mutedProjects,omitempty"`
+}
+
+// NotificationEvent is something a notification route can be subscribed to.
+type NotificationEvent string
+
+const (
+	// NotificationEventFailed is a run failing after the previous run completed.
+	NotificationEventFailed NotificationEvent = "failed"
+	// NotificationEventRecovered is a run completing after the previous run failed.
+	NotificationEventRecovered NotificationEvent = "recovered"
+	// NotificationEventNeedsApproval is a run leaving more PRs awaiting
+	// approval than the run before.
+	NotificationEventNeedsApproval NotificationEvent = "needsApproval"
+	// NotificationEventPolicyDenied is the operator's policy refusing the job.
+	NotificationEventPolicyDenied NotificationEvent = "policyDenied"
+)
+
+// NotificationTargetType is the kind of endpoint a route notifies.
+type NotificationTargetType string
+
+const (
+	// NotificationTargetSlack posts to a Slack-compatible incoming webhook
+	// (Slack, Mattermost, Rocket.Chat).
+	NotificationTargetSlack NotificationTargetType = "slack"
+	// NotificationTargetTeams posts an Adaptive Card to a Microsoft Teams
+	// incoming webhook or workflow.
+	NotificationTargetTeams NotificationTargetType = "teams"
+	// NotificationTargetWebhook posts the notification as JSON.
+	NotificationTargetWebhook NotificationTargetType = "webhook"
+)
+
+// a route sending notifications about some events and projects to a target
+type NotificationRoute struct {
+	// Events sent to the target. When empty, all events are sent.
+	// +kubebuilder:validation:items:Enum=failed;recovered;needsApproval;policyDenied
+	// +optional
+	Events []NotificationEvent `json:"events,omitempty"`
+	// Projects the route is limited to. Same syntax as excludeRepositories.
+	// When empty, the route covers all projects. Policy denials concern the
+	// whole job and ignore this list.
+	// +optional
+	Projects []string `json:"projects,omitempty"`
+	// Type of the target
+	// +kubebuilder:validation:Enum=slack;teams;webhook
+	Type NotificationTargetType `json:"type"`
+	// Secret key holding the URL notifications are posted to. The secret is
+	// subject to the same opt-in as every other secret a RenovateJob
+	// references, and the URL's host must be allowed by the policy.
+	SecretRef RenovateSecretKeyReference `json:"secretRef"`
+}
+
 // configuration for webhooks that can be used to trigger renovate runs
 type RenovateWebhook struct {
 	Enabled bool `json:"enabled"`
@@ -217,6 +358,23 @@ type RenovateWebhook struct {
 	BaseURL        string               `json:"baseUrl,omitempty"`
 	Authentication *RenovateWebhookAuth `json:"authentication,omitempty"`
 	Sync           *RenovateWebhookSync `json:"sync,omitempty"`
+	// Triggers a run when a push to a project's default branch touches the
+	// Renovate config or a package file
+	// +optional
+	Push *RenovateWebhookPush `json:"push,omitempty"`
+}
+
+// configuration for triggering renovate runs on pushes to the default branch
+type RenovateWebhookPush struct {
+	// Flag to enable the push trigger. Renovate's config file names
+	// (renovate.json, .github/renovate.json5, .renovaterc, ...) always trigger.
+	Enabled bool `json:"enabled"`
+	// Additional files that trigger a run when a push touches them, typically
+	// package manifests and lock files ("package.json", "**/go.mod"). Same
+	// syntax as excludeRepositories; a pattern without a slash matches the
+	// file name in any directory.
+	// +optional
+	Paths []string `json:"paths,omitempty"`
 }
 
 // configuration for syncing webhooks onto the repositories discovered for this
@@ -230,8 +388,27 @@ type RenovateWebhookSync struct {
 	// the common Renovate token key names are tried.
 	// +optional
 	SecretRef *RenovateSecretKeyReference `json:"secretRef,omitempty"`
+	// Where the hooks are managed: "repository" (default) keeps one hook on
+	// every project, "organization" one hook per organization (GitHub and
+	// Gitea/Forgejo organization, GitLab top-level group, Bitbucket workspace)
+	// that receives the events of all its repositories.
+	// +kubebuilder:validation:Enum=repository;organization
+	// +optional
+	Scope WebhookSyncScope `json:"scope,omitempty"`
+	// Organizations that carry the hooks in the organization scope. When
+	// empty, they are the first path segments of the job's projects.
+	// +optional
+	Organizations []string `json:"organizations,omitempty"`
 }
 
+// WebhookSyncScope selects where the webhook sync manages the hooks.
+type WebhookSyncScope string
+
+const (
+	WebhookSyncScopeRepository   WebhookSyncScope = "repository"
+	WebhookSyncScopeOrganization WebhookSyncScope = "organization"
+)
+
 // authentication configuration for webhooks
 type RenovateWebhookAuth struct {
 	Enabled   bool                        `json:"enabled"`
@@ -268,6 +445,14 @@ type RenovateProvider struct {
 	PublicEndpoint string `json:"publicEndpoint,omitempty"`
 }
 
+// DiscoveryMode selects how a RenovateJob discovers its projects.
+type DiscoveryMode string
+
+const (
+	DiscoveryModeRenovate DiscoveryMode = "renovate"
+	DiscoveryModeNative   DiscoveryMode = "native"
+)
+
 // PRAction represents what happened to a PR in a Renovate run.
 type PRAction string
 
@@ -326,6 +511,13 @@ type ProjectStatus struct {
 	PRActivity           *PRActivity               `json:"prActivity,omitempty"`
 	LogIssues            *LogIssues                `json:"logIssues,omitempty"`
 	ExecutionOptions     *RenovateExecutionOptions `json:"executionOptions,omitempty"`
+	// RerunAfterCurrent is set when a webhook asked for a run while the project
+	// was running. The project is scheduled again once that run ends, so changes
+	// made during the run are still processed.
+	RerunAfterCurrent bool `json:"rerunAfterCurrent,omitempty"`
+	// LastRunStatus is how the last finished run ended, completed or failed. A
+	// cancelled run leaves it unchanged.
+	LastRunStatus RenovateProjectStatus `json:"lastRunStatus,omitempty"`
 }
 
 type RenovateProjectStatus string
@@ -336,12 +528,19 @@ const (
 	JobStatusCompleted RenovateProjectStatus = "completed"
 	JobStatusFailed    RenovateProjectStatus = "failed"
 	JobStatusCancelled RenovateProjectStatus = "cancelled"
+	// JobStatusPendingApproval marks a newly discovered project that waits for
+	// an admin's approval before its first run.
+	JobStatusPendingApproval RenovateProjectStatus = "pending-approval"
 )
 
 // RenovateJobStatus defines the observed state of RenovateJob
 // +kubebuilder:object:root=true
 type RenovateJobStatus struct {
 	Projects []ProjectStatus `json:"projects,omitempty"`
+	// LastDiscoveryTime records when a discovery run last refreshed the project
+	// list. A failed discovery leaves it, and the project list, untouched.
+	// +optional
+	LastDiscoveryTime *metav1.Time `json:"lastDiscoveryTime,omitempty"`
 	// Conditions holds the observed state of the RenovateJob. The operator sets the
 	// "Accepted" condition to False when the job violates the operator's policy, with
 	// a reason and a message naming the value to fix; nothing runs while it is False.
@@ -351,6 +550,36 @@ type RenovateJobStatus struct {
 	// +listType=map
 	// +listMapKey=type
 	Conditions []metav1.Condition `json:"conditions,omitempty" patchStrategy:"merge" patchMergeKey:"type"`
+	// WebhookSync records the hooks the webhook sync manages beyond the
+	// current projects.
+	// +optional
+	WebhookSync *WebhookSyncStatus `json:"webhookSync,omitempty"`
+}
+
+// WebhookSyncStatus is the state the webhook sync keeps between cycles.
+type WebhookSyncStatus struct {
+	// Scope the hooks were last synced in. The per-project hooks are removed
+	// once when the scope becomes "organization".
+	// +optional
+	Scope WebhookSyncScope `json:"scope,omitempty"`
+	// Organizations that carry the job's organization hooks.
+	// +optional
+	Organizations []string `json:"organizations,omitempty"`
+	// PendingRemovals are hooks whose removal failed and is retried on the
+	// next sync, up to a limit.
+	// +optional
+	PendingRemovals []PendingWebhookRemoval `json:"pendingRemovals,omitempty"`
+}
+
+// PendingWebhookRemoval is a hook whose removal is retried.
+type PendingWebhookRemoval struct {
+	// Name of the project, or of the organization for organization hooks.
+	Name string `json:"name"`
+	// Organization marks an organization hook.
+	// +optional
+	Organization bool `json:"organization,omitempty"`
+	// Attempts is the number of removals that failed.
+	Attempts int `json:"attempts"`
 }
 
 // ConditionAccepted reports whether the RenovateJob passes the operator's policy.
@@ -388,6 +617,39 @@ func (in *RenovateJobScratchVolume) DeepCopyInto(out *RenovateJobScratchVolume)
 	}
 }
 
+// DeepCopyInto deep copies a RenovateWebhook into out.
+func (in *RenovateWebhook) DeepCopyInto(out *RenovateWebhook) {
+	*out = *in
+	if in.Authentication != nil {
+		out.Authentication = new(RenovateWebhookAuth)
+		*out.Authentication = *in.Authentication
+		if in.Authentication.SecretRef != nil {
+			out.Authentication.SecretRef = new(RenovateSecretKeyReference)
+			*out.Authentication.SecretRef = *in.Authentication.SecretRef
+		}
+	}
+	if in.Sync != nil {
+		out.Sync = new(RenovateWebhookSync)
+		*out.Sync = *in.Sync
+		if in.Sync.SecretRef != nil {
+			out.Sync.SecretRef = new(RenovateSecretKeyReference)
+			*out.Sync.SecretRef = *in.Sync.SecretRef
+		}
+		if in.Sync.Organizations != nil {
+			out.Sync.Organizations = make([]string, len(in.Sync.Organizations))
+			copy(out.Sync.Organizations, in.Sync.Organizations)
+		}
+	}
+	if in.Push != nil {
+		out.Push = new(RenovateWebhookPush)
+		*out.Push = *in.Push
+		if in.Push.Paths != nil {
+			out.Push.Paths = make([]string, len(in.Push.Paths))
+			copy(out.Push.Paths, in.Push.Paths)
+		}
+	}
+}
+
 // DeepCopyInto deep copies a RenovateJobAccess into out.
 func (in *RenovateJobAccess) DeepCopyInto(out *RenovateJobAccess) {
 	*out = *in
@@ -415,6 +677,48 @@ func (in *RenovateJobAccess) DeepCopyInto(out *RenovateJobAccess) {
 		out.AnonymousReadLogs = new(bool)
 		*out.AnonymousReadLogs = *in.AnonymousReadLogs
 	}
+	if in.RoleBindings != nil {
+		out.RoleBindings = make([]RenovateJobRoleBinding, len(in.RoleBindings))
+		for i := range in.RoleBindings {
+			in.RoleBindings[i].DeepCopyInto(&out.RoleBindings[i])
+		}
+	}
+}
+
+// DeepCopyInto deep copies a RenovateJobRoleBinding into out.
+func (in *RenovateJobRoleBinding) DeepCopyInto(out *RenovateJobRoleBinding) {
+	*out = *in
+	if in.Groups != nil {
+		out.Groups = make([]string, len(in.Groups))
+		copy(out.Groups, in.Groups)
+	}
+	if in.Users != nil {
+		out.Users = make([]string, len(in.Users))
+		copy(out.Users, in.Users)
+	}
+}
+
+// DeepCopyInto deep copies a RenovateJobNotifications into out.
+func (in *RenovateJobNotifications) DeepCopyInto(out *RenovateJobNotifications) {
+	*out = *in
+	if in.Routes != nil {
+		out.Routes = make([]NotificationRoute, len(in.Routes))
+		for i := range in.Routes {
+			out.Routes[i] = in.Routes[i]
+			if in.Routes[i].Events != nil {
+				out.Routes[i].Events = make([]NotificationEvent, len(in.Routes[i].Events))
+				copy(out.Routes[i].Events, in.Routes[i].Events)
+			}
+			if in.Routes[i].Projects != nil {
+				out.Routes[i].Projects = make([]string, len(in.Routes[i].Projects))
+				copy(out.Routes[i].Projects, in.Routes[i].Projects)
+			}
+		}
+	}
+	if in.MutedProjects != nil {
+		out.MutedProjects = make([]string, len(in.MutedProjects))
+		copy(out.MutedProjects, in.MutedProjects)
+	}
 }
 
 // DeepCopyInto deep copies a RenovateJob into out.
@@ -425,6 +729,34 @@ func (in *RenovateJob) DeepCopyInto(out *RenovateJob) {
 		out.Spec.AllowedGroups = make([]string, len(in.Spec.AllowedGroups))
 		copy(out.Spec.AllowedGroups, in.Spec.AllowedGroups)
 	}
+	if in.Spec.Repositories != nil {
+		out.Spec.Repositories = make([]string, len(in.Spec.Repositories))
+		copy(out.Spec.Repositories, in.Spec.Repositories)
+	}
+	if in.Spec.Visibility != nil {
+		out.Spec.Visibility = make([]string, len(in.Spec.Visibility))
+		copy(out.Spec.Visibility, in.Spec.Visibility)
+	}
+	if in.Spec.DiscoveryNamespaces != nil {
+		out.Spec.DiscoveryNamespaces = make([]string, len(in.Spec.DiscoveryNamespaces))
+		copy(out.Spec.DiscoveryNamespaces, in.Spec.DiscoveryNamespaces)
+	}
+	if in.Spec.ExcludeRepositories != nil {
+		out.Spec.ExcludeRepositories = make([]string, len(in.Spec.ExcludeRepositories))
+		copy(out.Spec.ExcludeRepositories, in.Spec.ExcludeRepositories)
+	}
+	if in.Spec.Onboarding != nil {
+		out.Spec.Onboarding = new(RenovateJobOnboarding)
+		*out.Spec.Onboarding = *in.Spec.Onboarding
+		if in.Spec.Onboarding.AutoApprove != nil {
+			out.Spec.Onboarding.AutoApprove = make([]string, len(in.Spec.Onboarding.AutoApprove))
+			copy(out.Spec.Onboarding.AutoApprove, in.Spec.Onboarding.AutoApprove)
+		}
+	}
+	if in.Spec.Webhook != nil {
+		out.Spec.Webhook = new(RenovateWebhook)
+		in.Spec.Webhook.DeepCopyInto(out.Spec.Webhook)
+	}
 	if in.Spec.Access != nil {
 		out.Spec.Access = new(RenovateJobAccess)
 		in.Spec.Access.DeepCopyInto(out.Spec.Access)
@@ -437,6 +769,10 @@ func (in *RenovateJob) DeepCopyInto(out *RenovateJob) {
 		out.Spec.RuntimeClassName = new(string)
 		*out.Spec.RuntimeClassName = *in.Spec.RuntimeClassName
 	}
+	if in.Spec.Notifications != nil {
+		out.Spec.Notifications = new(RenovateJobNotifications)
+		in.Spec.Notifications.DeepCopyInto(out.Spec.Notifications)
+	}
 	if in.Spec.RenovateConfig != nil {
 		out.Spec.RenovateConfig = new(RenovateJobConfig)
 		*out.Spec.RenovateConfig = *in.Spec.RenovateConfig
@@ -452,10 +788,17 @@ func (in *RenovateJob) DeepCopyInto(out *RenovateJob) {
 			in.Status.Projects[i].DeepCopyInto(&out.Status.Projects[i])
 		}
 	}
+	if in.Status.LastDiscoveryTime != nil {
+		out.Status.LastDiscoveryTime = in.Status.LastDiscoveryTime.DeepCopy()
+	}
 	if in.Status.Conditions != nil {
 		out.Status.Conditions = make([]metav1.Condition, len(in.Status.Conditions))
 		copy(out.Status.Conditions, in.Status.Conditions)
 	}
+	if in.Status.WebhookSync != nil {
+		out.Status.WebhookSync = new(WebhookSyncStatus)
+		in.Status.WebhookSync.DeepCopyInto(out.Status.WebhookSync)
+	}
 }
 
 func (in *RenovateJob) DeepCopyObject() runtime.Object {
@@ -500,6 +843,18 @@ func (in *ProjectStatus) DeepCopyInto(out *ProjectStatus) {
 	}
 }
 
+// UsesAutodiscovery reports whether projects are discovered at all. A job that
+// only lists static repositories does not need discovery.
+func (in *RenovateJob) UsesAutodiscovery() bool {
+	return len(in.Spec.Repositories) == 0 || len(in.Spec.DiscoveryFilters) > 0 || len(in.Spec.DiscoverTopics) > 0
+}
+
+// UsesNativeDiscovery reports whether projects are discovered through the
+// platform API instead of a Renovate discovery pod.
+func (in *RenovateJob) UsesNativeDiscovery() bool {
+	return in.Spec.DiscoveryMode == DiscoveryModeNative && in.UsesAutodiscovery()
+}
+
 // unique name for a renovatejob ${name}-${namespace}
 func (in *RenovateJob) Fullname() string {
 	return in.Name + "-" + in.Namespace
@@ -525,3 +880,16 @@ func (in *RenovateJobList) DeepCopyObject() runtime.Object {
 	}
 	return out
 }
+
+// DeepCopyInto deep copies a WebhookSyncStatus into out.
+func (in *WebhookSyncStatus) DeepCopyInto(out *WebhookSyncStatus) {
+	*out = *in
+	if in.Organizations != nil {
+		out.Organizations = make([]string, len(in.Organizations))
+		copy(out.Organizations, in.Organizations)
+	}
+	if in.PendingRemovals != nil {
+		out.PendingRemovals = make([]PendingWebhookRemoval, len(in.PendingRemovals))
+		copy(out.PendingRemovals, in.PendingRemovals)
+	}
+}
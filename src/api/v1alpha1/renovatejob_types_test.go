@@ -84,6 +84,25 @@ func TestRenovateJob_DeepCopyObject(t *testing.T) {
 		}
 	})
 
+	t.Run("webhook is not shared", func(t *testing.T) {
+		original := &RenovateJob{
+			Spec: RenovateJobSpec{
+				Webhook: &RenovateWebhook{
+					Enabled: true,
+					Push:    &RenovateWebhookPush{Enabled: true, Paths: []string{"package.json"}},
+				},
+			},
+		}
+
+		copied := original.DeepCopyObject().(*RenovateJob)
+		copied.Spec.Webhook.Enabled = false
+		copied.Spec.Webhook.Push.Paths[0] = "go.mod"
+
+		if !original.Spec.Webhook.Enabled || original.Spec.Webhook.Push.Paths[0] != "package.json" {
+			t.Errorf("modifying the copy changed the original: %+v", original.Spec.Webhook)
+		}
+	})
+
 	t.Run("nil object", func(t *testing.T) {
 		var original *RenovateJob = nil
 		copied := original.DeepCopyObject()
@@ -5,6 +5,10 @@ import (
 	"encoding/json"
 	"flag"
 	"fmt"
+	"maps"
+	"net/url"
+	"os"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
@@ -24,19 +28,26 @@ import (
 	gitProviderClientFactory "renovate-operator/gitProviderClients/factory"
 	"renovate-operator/github"
 	"renovate-operator/health"
+	"renovate-operator/internal/apiTokens"
+	"renovate-operator/internal/audit"
 	crdManager "renovate-operator/internal/crdManager"
+	"renovate-operator/internal/deliveryLog"
+	"renovate-operator/internal/eventStream"
 	"renovate-operator/internal/kvstore"
 	"renovate-operator/internal/logStore"
+	"renovate-operator/internal/notifications"
 	"renovate-operator/internal/objectstore"
 	"renovate-operator/internal/podLogs"
 	"renovate-operator/internal/policy"
 	"renovate-operator/internal/renovate"
+	"renovate-operator/internal/reports"
 	"renovate-operator/internal/telemetry"
 	"renovate-operator/metricStore"
 	"renovate-operator/scheduler"
 	"renovate-operator/ui"
 	"renovate-operator/webhook"
 
+	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
 
 	ctrlmetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
@@ -79,12 +90,16 @@ func initAuth(valkeyConf kvstore.ValkeyConfig) authSetup {
 	oidcClientSecret := config.GetValue("OIDC_CLIENT_SECRET")
 	githubClientID := config.GetValue("GITHUB_CLIENT_ID")
 	githubClientSecret := config.GetValue("GITHUB_CLIENT_SECRET")
+	gitlabClientID := config.GetValue("GITLAB_CLIENT_ID")
+	gitlabClientSecret := config.GetValue("GITLAB_CLIENT_SECRET")
 
 	var sessionSecret string
 	if oidcIssuer != "" && oidcClientID != "" && oidcClientSecret != "" {
 		sessionSecret = config.GetValue("OIDC_SESSION_SECRET")
 	} else if githubClientID != "" && githubClientSecret != "" {
 		sessionSecret = config.GetValue("GITHUB_SESSION_SECRET")
+	} else if gitlabClientID != "" && gitlabClientSecret != "" {
+		sessionSecret = config.GetValue("GITLAB_SESSION_SECRET")
 	}
 
 	encryptionKey, encKeyErr := ui.ComputeEncryptionKey(sessionSecret)
@@ -119,7 +134,7 @@ func initAuth(valkeyConf kvstore.ValkeyConfig) authSetup {
 		}
 	}
 
-	// Initialize authentication provider (OIDC or GitHub OAuth)
+	// Initialize authentication provider (OIDC, GitHub OAuth, GitLab OAuth or trusted headers)
 	var authProvider ui.AuthProvider
 
 	if oidcIssuer != "" && oidcClientID != "" && oidcClientSecret != "" {
@@ -163,6 +178,44 @@ func initAuth(valkeyConf kvstore.ValkeyConfig) authSetup {
 		assert.NoError(ghErr, "failed to initialize GitHub OAuth provider")
 		authProvider = ghAuth
 		log.Info("GitHub OAuth authentication enabled", "orgGroups", config.GetValue("GITHUB_ORG_GROUPS") == "true")
+	} else if gitlabClientID != "" && gitlabClientSecret != "" {
+		minAccessLevel, _ := ui.ParseGitLabAccessLevel(config.GetValue("GITLAB_MIN_ACCESS_LEVEL"))
+		glAuth, glErr := ui.NewGitLabOAuth(ui.GitLabOAuthConfig{
+			URL:                 config.GetValue("GITLAB_URL"),
+			ClientID:            gitlabClientID,
+			ClientSecret:        gitlabClientSecret,
+			RedirectURL:         config.GetValue("GITLAB_REDIRECT_URL"),
+			MinAccessLevel:      minAccessLevel,
+			AllowedGroupPrefix:  config.GetValue("GITLAB_ALLOWED_GROUP_PREFIX"),
+			AllowedGroupPattern: config.GetValue("GITLAB_ALLOWED_GROUP_PATTERN"),
+		}, cookieKey, ctrl.Log.WithName("gitlab-oauth"), sessionStore)
+		assert.NoError(glErr, "failed to initialize GitLab OAuth provider")
+		authProvider = glAuth
+		log.Info("GitLab OAuth authentication enabled",
+			"url", config.GetValue("GITLAB_URL"),
+			"minAccessLevel", config.GetValue("GITLAB_MIN_ACCESS_LEVEL"))
+	} else if config.GetValue("HEADER_AUTH_ENABLED") == "true" {
+		trustedProxies, _ := ui.ParseTrustedProxies(splitAndTrim(config.GetValue("HEADER_AUTH_TRUSTED_PROXIES"), ","))
+		headerAuth, headerErr := ui.NewHeaderAuth(ui.HeaderAuthConfig{
+			EmailHeader:         config.GetValue("HEADER_AUTH_EMAIL_HEADER"),
+			UsernameHeader:      config.GetValue("HEADER_AUTH_USERNAME_HEADER"),
+			NameHeader:          config.GetValue("HEADER_AUTH_NAME_HEADER"),
+			GroupsHeader:        config.GetValue("HEADER_AUTH_GROUPS_HEADER"),
+			GroupsSeparator:     config.GetValue("HEADER_AUTH_GROUPS_SEPARATOR"),
+			TrustedProxies:      trustedProxies,
+			SecretHeader:        config.GetValue("HEADER_AUTH_SECRET_HEADER"),
+			Secret:              config.GetValue("HEADER_AUTH_SECRET"),
+			LogoutURL:           config.GetValue("HEADER_AUTH_LOGOUT_URL"),
+			AllowedGroupPrefix:  config.GetValue("HEADER_AUTH_ALLOWED_GROUP_PREFIX"),
+			AllowedGroupPattern: config.GetValue("HEADER_AUTH_ALLOWED_GROUP_PATTERN"),
+		}, cookieKey, ctrl.Log.WithName("header-auth"))
+		assert.NoError(headerErr, "failed to initialize header authentication")
+		authProvider = headerAuth
+		log.Info("Header authentication enabled",
+			"emailHeader", config.GetValue("HEADER_AUTH_EMAIL_HEADER"),
+			"groupsHeader", config.GetValue("HEADER_AUTH_GROUPS_HEADER"),
+			"trustedProxies", config.GetValue("HEADER_AUTH_TRUSTED_PROXIES"),
+			"sharedSecret", config.GetValue("HEADER_AUTH_SECRET") != "")
 	} else {
 		log.Info("No authentication configured, UI access is unauthenticated")
 	}
@@ -189,6 +242,7 @@ func parseAccessDefaults(log logr.Logger) ui.AccessDefaults {
 		AnonymousReadLogs:     config.GetValue("AUTHORIZATION_DEFAULT_ANONYMOUS_READ_LOGS") == "true",
 		AuthorizationDisabled: config.GetValue("AUTHORIZATION_ENABLED") == "false",
 	}
+	defaults.Roles, _ = ui.ParseRoles(config.GetValue("AUTHORIZATION_ROLES"))
 
 	// The deprecated DEFAULT_ALLOWED_GROUPS granted what is now admin access.
 	if legacy := parseGroupList(config.GetValue("DEFAULT_ALLOWED_GROUPS")); len(legacy) > 0 {
@@ -204,7 +258,8 @@ func parseAccessDefaults(log logr.Logger) ui.AccessDefaults {
 		"readerUsers", defaults.ReaderUsers,
 		"adminUsers", defaults.AdminUsers,
 		"anonymousRead", defaults.AnonymousRead,
-		"anonymousReadLogs", defaults.AnonymousReadLogs)
+		"anonymousReadLogs", defaults.AnonymousReadLogs,
+		"roles", slices.Sorted(maps.Keys(defaults.Roles)))
 
 	return defaults
 }
@@ -282,6 +337,152 @@ func main() {
 			Optional: true,
 			Default:  "false",
 		},
+		{
+			Key:      "WEBHOOK_DEBOUNCE_SECONDS",
+			Optional: true,
+			Default:  "5",
+			Validate: func(value string) error {
+				seconds, err := strconv.Atoi(value)
+				if err != nil {
+					return fmt.Errorf("'WEBHOOK_DEBOUNCE_SECONDS' needs to be an integer: %s", err.Error())
+				}
+				if seconds < 0 {
+					return fmt.Errorf("'WEBHOOK_DEBOUNCE_SECONDS' must not be negative")
+				}
+				return nil
+			},
+		},
+		{
+			Key:      "WEBHOOK_DELIVERY_LOG_MODE",
+			Optional: true,
+			Default:  "memory",
+			Validate: func(value string) error {
+				switch value {
+				case "disabled", "memory", "valkey":
+					return nil
+				}
+				return fmt.Errorf("'WEBHOOK_DELIVERY_LOG_MODE' must be one of: disabled, memory, valkey")
+			},
+		},
+		{
+			Key:      "WEBHOOK_DELIVERY_LOG_SIZE",
+			Optional: true,
+			Default:  "50",
+			Validate: func(value string) error {
+				size, err := strconv.Atoi(value)
+				if err != nil {
+					return fmt.Errorf("'WEBHOOK_DELIVERY_LOG_SIZE' needs to be an integer: %s", err.Error())
+				}
+				if size < 1 {
+					return fmt.Errorf("'WEBHOOK_DELIVERY_LOG_SIZE' must be at least 1")
+				}
+				return nil
+			},
+		},
+		{
+			Key:      "EVENT_SINKS",
+			Optional: true,
+			Default:  "",
+			Validate: func(value string) error {
+				if _, err := eventStream.ParseSinks(value, os.Getenv); err != nil {
+					return fmt.Errorf("'EVENT_SINKS' is invalid: %s", err.Error())
+				}
+				return nil
+			},
+		},
+		{
+			Key:      "EVENT_OUTBOX_MODE",
+			Optional: true,
+			Default:  "memory",
+			Validate: func(value string) error {
+				switch value {
+				case "memory", "valkey":
+					return nil
+				}
+				return fmt.Errorf("'EVENT_OUTBOX_MODE' must be one of: memory, valkey")
+			},
+		},
+		{
+			Key:      "NOTIFICATIONS_UI_URL",
+			Optional: true,
+			Default:  "",
+			Validate: func(value string) error {
+				if value == "" {
+					return nil
+				}
+				if u, err := url.Parse(value); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+					return fmt.Errorf("'NOTIFICATIONS_UI_URL' must be an http or https URL")
+				}
+				return nil
+			},
+		},
+		{
+			Key:      "NOTIFICATIONS_RATE_LIMIT",
+			Optional: true,
+			Default:  "10",
+			Validate: func(value string) error {
+				limit, err := strconv.Atoi(value)
+				if err != nil {
+					return fmt.Errorf("'NOTIFICATIONS_RATE_LIMIT' needs to be an integer: %s", err.Error())
+				}
+				if limit < 0 {
+					return fmt.Errorf("'NOTIFICATIONS_RATE_LIMIT' must not be negative")
+				}
+				return nil
+			},
+		},
+		{
+			Key:      "REPORT_HISTORY_MODE",
+			Optional: true,
+			Default:  "memory",
+			Validate: func(value string) error {
+				switch value {
+				case "disabled", "memory", "valkey":
+					return nil
+				}
+				return fmt.Errorf("'REPORT_HISTORY_MODE' must be one of: disabled, memory, valkey")
+			},
+		},
+		{
+			Key:      "REPORT_SCHEDULE",
+			Optional: true,
+			Default:  "",
+			Validate: func(value string) error {
+				if value == "" {
+					return nil
+				}
+				if err := scheduler.ValidateSchedule(value); err != nil {
+					return fmt.Errorf("'REPORT_SCHEDULE' is not a valid cron expression: %s", err.Error())
+				}
+				return nil
+			},
+		},
+		{
+			Key:      "REPORT_PERIOD_DAYS",
+			Optional: true,
+			Default:  strconv.Itoa(reports.DefaultDays),
+			Validate: func(value string) error {
+				days, err := strconv.Atoi(value)
+				if err != nil {
+					return fmt.Errorf("'REPORT_PERIOD_DAYS' needs to be an integer: %s", err.Error())
+				}
+				if days < 1 || days > reports.MaxDays {
+					return fmt.Errorf("'REPORT_PERIOD_DAYS' must be between 1 and %d", reports.MaxDays)
+				}
+				return nil
+			},
+		},
+		{
+			Key:      "REPORT_SINKS",
+			Optional: true,
+			Default:  "",
+			Validate: func(value string) error {
+				if _, err := reports.ParseSinks(value, os.Getenv); err != nil {
+					return fmt.Errorf("'REPORT_SINKS' is invalid: %s", err.Error())
+				}
+				return nil
+			},
+		},
 		{
 			Key:      "BASE_PATH",
 			Optional: true,
@@ -417,6 +618,120 @@ func main() {
 			Optional: true,
 			Default:  "false",
 		},
+		{
+			Key:      "GITLAB_URL",
+			Optional: true,
+			Default:  "https://gitlab.com",
+		},
+		{
+			Key:      "GITLAB_CLIENT_ID",
+			Optional: true,
+		},
+		{
+			Key:      "GITLAB_CLIENT_SECRET",
+			Optional: true,
+		},
+		{
+			Key:      "GITLAB_REDIRECT_URL",
+			Optional: true,
+		},
+		{
+			Key:      "GITLAB_SESSION_SECRET",
+			Optional: true,
+		},
+		{
+			Key:      "GITLAB_MIN_ACCESS_LEVEL",
+			Optional: true,
+			Default:  "guest",
+			Validate: func(value string) error {
+				if _, err := ui.ParseGitLabAccessLevel(value); err != nil {
+					return fmt.Errorf("'GITLAB_MIN_ACCESS_LEVEL' is invalid: %s", err.Error())
+				}
+				return nil
+			},
+		},
+		{
+			Key:      "GITLAB_ALLOWED_GROUP_PREFIX",
+			Optional: true,
+			Default:  "",
+		},
+		{
+			Key:      "GITLAB_ALLOWED_GROUP_PATTERN",
+			Optional: true,
+			Default:  "",
+		},
+		{
+			Key:      "HEADER_AUTH_ENABLED",
+			Optional: true,
+			Default:  "false",
+			Validate: func(value string) error {
+				if value != "true" && value != "false" {
+					return fmt.Errorf("'HEADER_AUTH_ENABLED' must be 'true' or 'false'")
+				}
+				return nil
+			},
+		},
+		{
+			Key:      "HEADER_AUTH_EMAIL_HEADER",
+			Optional: true,
+			Default:  "X-Auth-Request-Email",
+		},
+		{
+			Key:      "HEADER_AUTH_USERNAME_HEADER",
+			Optional: true,
+			Default:  "X-Auth-Request-Preferred-Username",
+		},
+		{
+			Key:      "HEADER_AUTH_NAME_HEADER",
+			Optional: true,
+			Default:  "",
+		},
+		{
+			Key:      "HEADER_AUTH_GROUPS_HEADER",
+			Optional: true,
+			Default:  "X-Auth-Request-Groups",
+		},
+		{
+			Key:      "HEADER_AUTH_GROUPS_SEPARATOR",
+			Optional: true,
+			Default:  ",",
+		},
+		{
+			Key:      "HEADER_AUTH_TRUSTED_PROXIES",
+			Optional: true,
+			Default:  "",
+			Validate: func(value string) error {
+				if _, err := ui.ParseTrustedProxies(splitAndTrim(value, ",")); err != nil {
+					return fmt.Errorf("'HEADER_AUTH_TRUSTED_PROXIES' is invalid: %s", err.Error())
+				}
+				return nil
+			},
+		},
+		{
+			Key:      "HEADER_AUTH_SECRET_HEADER",
+			Optional: true,
+			Default:  "X-Auth-Proxy-Secret",
+		},
+		{
+			Key:      "HEADER_AUTH_SECRET",
+			Optional: true,
+			Default:  "",
+		},
+		{
+			Key:      "HEADER_AUTH_LOGOUT_URL",
+			Optional: true,
+			Default:  "",
+		},
+		{
+			Key:      "HEADER_AUTH_ALLOWED_GROUP_PREFIX",
+			Optional: true,
+			Default:  "",
+		},
+		{
+			Key:      "HEADER_AUTH_ALLOWED_GROUP_PATTERN",
+			Optional: true,
+			Default:  "",
+		},
 		{
 			// Deprecated: use DEFAULT_ADMIN_GROUPS
 			Key:      "DEFAULT_ALLOWED_GROUPS",
@@ -464,6 +779,151 @@ func main() {
 				return nil
 			},
 		},
+		{
+			Key:      "AUTHORIZATION_ROLES",
+			Optional: true,
+			Default:  "",
+			Validate: func(value string) error {
+				if _, err := ui.ParseRoles(value); err != nil {
+					return fmt.Errorf("'AUTHORIZATION_ROLES' is invalid: %s", err.Error())
+				}
+				return nil
+			},
+		},
+		{
+			Key:      "AUTHORIZATION_MODE",
+			Optional: true,
+			Default:  "rules",
+			Validate: func(value string) error {
+				if value != "rules" && value != "rbac" {
+					return fmt.Errorf("'AUTHORIZATION_MODE' must be 'rules' or 'rbac'")
+				}
+				return nil
+			},
+		},
+		{
+			Key:      "AUTHORIZATION_RBAC_USER_CLAIM",
+			Optional: true,
+			Default:  "email",
+			Validate: func(value string) error {
+				if value != "email" && value != "username" {
+					return fmt.Errorf("'AUTHORIZATION_RBAC_USER_CLAIM' must be 'email' or 'username'")
+				}
+				return nil
+			},
+		},
+		{
+			Key:      "AUTHORIZATION_RBAC_USER_PREFIX",
+			Optional: true,
+			Default:  "",
+		},
+		{
+			Key:      "AUTHORIZATION_RBAC_GROUP_PREFIX",
+			Optional: true,
+			Default:  "",
+		},
+		{
+			Key:      "AUTHORIZATION_JOB_EDITING_ENABLED",
+			Optional: true,
+			Default:  "false",
+		},
+		{
+			Key:      "AUTHORIZATION_JOB_EDITING_NAMESPACES",
+			Optional: true,
+			Default:  "",
+			Validate: func(value string) error {
+				if _, err := ui.ParseEditableNamespaces(value); err != nil {
+					return fmt.Errorf("'AUTHORIZATION_JOB_EDITING_NAMESPACES' is invalid: %s", err.Error())
+				}
+				return nil
+			},
+		},
+		{
+			Key:      "API_TOKENS_MODE",
+			Optional: true,
+			Default:  "disabled",
+			Validate: func(value string) error {
+				switch value {
+				case "disabled", "memory", "valkey", "secret":
+					return nil
+				}
+				return fmt.Errorf("'API_TOKENS_MODE' must be one of: disabled, memory, valkey, secret")
+			},
+		},
+		{
+			Key:      "API_TOKENS_SECRET_NAME",
+			Optional: true,
+			Default:  "renovate-operator-api-tokens",
+		},
+		{
+			Key:      "API_TOKENS_MAX_LIFETIME_DAYS",
+			Optional: true,
+			Default:  "90",
+			Validate: func(value string) error {
+				days, err := strconv.Atoi(value)
+				if err != nil {
+					return fmt.Errorf("'API_TOKENS_MAX_LIFETIME_DAYS' needs to be an integer: %s", err.Error())
+				}
+				if days < 1 {
+					return fmt.Errorf("'API_TOKENS_MAX_LIFETIME_DAYS' must be at least 1")
+				}
+				return nil
+			},
+		},
+		{
+			Key:      "AUDIT_MODE",
+			Optional: true,
+			Default:  "log",
+			Validate: func(value string) error {
+				switch value {
+				case "disabled", "log", "memory", "valkey", "s3":
+					return nil
+				}
+				return fmt.Errorf("'AUDIT_MODE' must be one of: disabled, log, memory, valkey, s3")
+			},
+		},
+		{
+			Key:      "AUDIT_RETENTION_DAYS",
+			Optional: true,
+			Default:  "30",
+			Validate: func(value string) error {
+				days, err := strconv.Atoi(value)
+				if err != nil {
+					return fmt.Errorf("'AUDIT_RETENTION_DAYS' needs to be an integer: %s", err.Error())
+				}
+				if days < 1 || days > 365 {
+					return fmt.Errorf("'AUDIT_RETENTION_DAYS' must be between 1 and 365")
+				}
+				return nil
+			},
+		},
+		{
+			Key:      "SERVICE_ACCOUNT_AUTH_ENABLED",
+			Optional: true,
+			Default:  "false",
+			Validate: func(value string) error {
+				if value != "true" && value != "false" {
+					return fmt.Errorf("'SERVICE_ACCOUNT_AUTH_ENABLED' must be 'true' or 'false'")
+				}
+				return nil
+			},
+		},
+		{
+			Key:      "SERVICE_ACCOUNT_AUTH_AUDIENCES",
+			Optional: true,
+			Default:  "renovate-operator",
+		},
+		{
+			Key:      "SERVICE_ACCOUNT_AUTH_GROUPS",
+			Optional: true,
+			Default:  "false",
+			Validate: func(value string) error {
+				if value != "true" && value != "false" {
+					return fmt.Errorf("'SERVICE_ACCOUNT_AUTH_GROUPS' must be 'true' or 'false'")
+				}
+				return nil
+			},
+		},
 		{
 			Key:      "OIDC_ALLOWED_GROUP_PREFIX",
 			Optional: true,
@@ -576,6 +1036,11 @@ func main() {
 			Optional: true,
 			Default:  "renovate-cache",
 		},
+		{
+			Key:      "S3_AUDIT_PREFIX",
+			Optional: true,
+			Default:  "renovate-audit",
+		},
 		{
 			Key:      "S3_FORWARD_CACHE_TO_JOBS",
 			Optional: true,
@@ -795,6 +1260,7 @@ func main() {
 	gitProviderClientFactory := gitProviderClientFactory.NewGitProviderClientFactory(mgr.GetClient(), guardRails)
 
 	valkeyConf := kvstore.ConfigFromEnv(config.GetValue)
+	assert.NoError(valkeyConf.Validate(), "invalid Valkey configuration")
 
 	s3Cfg := objectstore.S3Config{
 		Bucket:          config.GetValue("S3_BUCKET"),
@@ -808,12 +1274,25 @@ func main() {
 	ls, err := logStore.NewLogStore(ctrl.Log.WithName("logStore"), config.GetValue("LOG_STORE_MODE"), valkeyConf, s3Cfg, config.GetValue("S3_LOG_PREFIX"))
 	assert.NoError(err, "failed to initialize logStore")
 
+	auditRetentionDays, _ := strconv.Atoi(config.GetValue("AUDIT_RETENTION_DAYS"))
+	auditSink, err := audit.NewSink(ctrl.Log.WithName("audit"), config.GetValue("AUDIT_MODE"), valkeyConf, s3Cfg, config.GetValue("S3_AUDIT_PREFIX"), time.Duration(auditRetentionDays)*24*time.Hour)
+	assert.NoError(err, "failed to initialize the audit log")
+
+	publisher := initEventStream(mgr, valkeyConf)
+
+	notificationRateLimit, _ := strconv.Atoi(config.GetValue("NOTIFICATIONS_RATE_LIMIT"))
+	notifier := notifications.NewSender(ctrl.Log.WithName("notifications"), mgr.GetClient(), guardRails, config.GetValue("NOTIFICATIONS_UI_URL"), notificationRateLimit)
+	assert.NoError(mgr.Add(notifier), "failed to add the notification sender")
+
 	cp := clientProvider.StaticClientProvider()
 	clientset, err := cp.K8sClientSet()
 	assert.NoError(err, "failed to get Kubernetes clientset for pod log reader")
 	podLogReader := podLogs.New(clientset)
 
-	jobMgr := crdManager.NewRenovateJobManager(mgr.GetClient(), gitProviderClientFactory, ctrl.Log.WithName("job-manager"), ls, podLogReader, guardRails)
+	history, err := reports.NewHistory(ctrl.Log.WithName("reports"), config.GetValue("REPORT_HISTORY_MODE"), valkeyConf)
+	assert.NoError(err, "failed to initialize the run history")
+
+	jobMgr := crdManager.NewRenovateJobManager(mgr.GetClient(), gitProviderClientFactory, ctrl.Log.WithName("job-manager"), ls, podLogReader, guardRails, mgr.GetEventRecorder("renovate-operator"), publisher, notifier, history)
 
 	discovery := renovate.NewDiscoveryAgent(
 		mgr.GetScheme(),
@@ -822,6 +1301,7 @@ func main() {
 		jobMgr,
 		podLogReader,
 		guardRails,
+		publisher,
 	)
 
 	cronManager := scheduler.NewScheduler(ctrl.Log.WithName("scheduler"), health)
@@ -829,13 +1309,71 @@ func main() {
 	auth := initAuth(valkeyConf)
 	defer auth.cleanup()
 
-	warnAccessRulesEnforceable(ctrl.Log.WithName("auth"), auth.provider, auth.accessDefaults)
+	rbacAuthorizer := initRBACAuthorization(ctrl.Log.WithName("auth"), auth, clientset)
+	if rbacAuthorizer == nil {
+		warnAccessRulesEnforceable(ctrl.Log.WithName("auth"), auth.provider, auth.accessDefaults)
+	}
 
 	// UI and webhook servers run on all replicas
 	uiServer := ui.NewServer(jobMgr, discovery, cronManager, ctrl.Log.WithName("ui-server"), health, Version, auth.provider, auth.accessDefaults)
+	if rbacAuthorizer != nil {
+		uiServer.SetRBACAuthorizer(rbacAuthorizer)
+	}
+	uiServer.SetAudit(auditSink)
+	initReports(mgr, cronManager, history)
+	if history != nil {
+		uiServer.SetReports(history)
+	}
+	statusInformer, err := mgr.GetCache().GetInformer(ctx, &api.RenovateJob{})
+	assert.NoError(err, "failed to get the RenovateJob informer")
+	assert.NoError(uiServer.SetStatusInformer(statusInformer), "failed to stream RenovateJob status changes")
+	if config.GetValue("AUTHORIZATION_JOB_EDITING_ENABLED") == "true" {
+		editableNamespaces, _ := ui.ParseEditableNamespaces(config.GetValue("AUTHORIZATION_JOB_EDITING_NAMESPACES"))
+		if len(editableNamespaces) == 0 {
+			ctrl.Log.WithName("auth").Info("job editing is enabled, but AUTHORIZATION_JOB_EDITING_NAMESPACES allows no namespace")
+		}
+		uiServer.SetJobEditing(ui.JobEditing{
+			Reader:     mgr.GetAPIReader(),
+			Writer:     mgr.GetClient(),
+			Policy:     guardRails,
+			Recorder:   mgr.GetEventRecorder("renovate-operator"),
+			Namespaces: editableNamespaces,
+		})
+	}
+	if mode := config.GetValue("API_TOKENS_MODE"); mode != "disabled" {
+		if auth.provider == nil {
+			ctrl.Log.WithName("auth").Info("API tokens are disabled: they act as the user who minted them and need an authentication provider", "mode", mode)
+		} else {
+			tokens, err := apiTokens.NewStore(ctrl.Log.WithName("api-tokens"), mode, valkeyConf, mgr.GetClient(), config.GetValue("POD_NAMESPACE"), config.GetValue("API_TOKENS_SECRET_NAME"))
+			assert.NoError(err, "failed to initialize the API token store")
+			maxLifetimeDays, _ := strconv.Atoi(config.GetValue("API_TOKENS_MAX_LIFETIME_DAYS"))
+			uiServer.SetAPITokens(tokens, time.Duration(maxLifetimeDays)*24*time.Hour)
+		}
+	}
+	if config.GetValue("SERVICE_ACCOUNT_AUTH_ENABLED") == "true" {
+		if auth.provider == nil {
+			ctrl.Log.WithName("auth").Info("ServiceAccount authentication is disabled: without an authentication provider the API is open anyway")
+		} else {
+			uiServer.SetServiceAccountAuth(ui.NewServiceAccountAuth(
+				clientset.AuthenticationV1().TokenReviews(),
+				splitAndTrim(config.GetValue("SERVICE_ACCOUNT_AUTH_AUDIENCES"), ","),
+				config.GetValue("SERVICE_ACCOUNT_AUTH_GROUPS") == "true",
+			))
+		}
+	}
 
 	if config.GetValue("WEBHOOK_SERVER_ENABLED") != "false" {
-		webhookServer := webhook.NewWebookServer(jobMgr, ctrl.Log.WithName("webhook"))
+		debounceSeconds, _ := strconv.Atoi(config.GetValue("WEBHOOK_DEBOUNCE_SECONDS"))
+		deliveryLogSize, _ := strconv.Atoi(config.GetValue("WEBHOOK_DELIVERY_LOG_SIZE"))
+		deliveries, err := deliveryLog.NewDeliveryLog(ctrl.Log.WithName("webhook-deliveries"), config.GetValue("WEBHOOK_DELIVERY_LOG_MODE"), valkeyConf, deliveryLogSize)
+		assert.NoError(err, "failed to initialize the webhook delivery log")
+
+		webhookServer := webhook.NewWebookServer(jobMgr, ctrl.Log.WithName("webhook"), time.Duration(debounceSeconds)*time.Second, deliveries)
+		webhookServer.SetDiscovery(discovery)
+		webhookServer.SetAudit(auditSink)
+		if deliveries != nil {
+			uiServer.SetWebhookDeliveries(webhookServer)
+		}
 
 		if config.GetValue("WEBHOOK_SERVER_UNIFIED_HOST") == "false" {
 			webhookServer.Run()
@@ -883,6 +1421,7 @@ func main() {
 		K8sClient: mgr.GetClient(),
 		GithubApp: githubAppToken,
 		Policy:    guardRails,
+		Audit:     auditSink,
 	}).SetupWithManager(mgr)
 	assert.NoError(err, "failed to setup manager")
 
@@ -890,6 +1429,74 @@ func main() {
 	assert.NoError(err, "failed to start manager")
 }
 
+// initEventStream delivers the operator's events to the sinks in EVENT_SINKS.
+// The dispatcher runs on every replica with an in-memory outbox, and on the
+// leader only with the outbox shared in Valkey. It returns the publisher of
+// the events, nil when there are no sinks.
+func initEventStream(mgr ctrl.Manager, valkeyConf kvstore.ValkeyConfig) eventStream.Publisher {
+	sinks, err := eventStream.ParseSinks(config.GetValue("EVENT_SINKS"), os.Getenv)
+	assert.NoError(err, "failed to parse the event sinks")
+	if len(sinks) == 0 {
+		return nil
+	}
+
+	mode := config.GetValue("EVENT_OUTBOX_MODE")
+	outbox, err := eventStream.NewOutbox(mode, valkeyConf)
+	assert.NoError(err, "failed to initialize the event outbox")
+
+	dispatcher := eventStream.NewDispatcher(ctrl.Log.WithName("events"), sinks, outbox, mode == "valkey")
+	assert.NoError(mgr.Add(dispatcher), "failed to add the event dispatcher")
+	return dispatcher
+}
+
+// initRBACAuthorization returns the authorizer deciding access with
+// SubjectAccessReviews when AUTHORIZATION_MODE=rbac, nil when the access rules
+// decide.
+func initRBACAuthorization(log logr.Logger, auth authSetup, clientset kubernetes.Interface) *ui.RBACAuthorizer {
+	if config.GetValue("AUTHORIZATION_MODE") != "rbac" {
+		return nil
+	}
+	if auth.provider == nil {
+		log.Info("AUTHORIZATION_MODE=rbac has no effect: without an authentication provider every request is an admin")
+		return nil
+	}
+	if auth.accessDefaults.AuthorizationDisabled {
+		log.Info("AUTHORIZATION_MODE=rbac has no effect: AUTHORIZATION_ENABLED=false makes every authenticated request an admin")
+		return nil
+	}
+
+	identity := ui.RBACIdentity{
+		UserClaim:   config.GetValue("AUTHORIZATION_RBAC_USER_CLAIM"),
+		UserPrefix:  config.GetValue("AUTHORIZATION_RBAC_USER_PREFIX"),
+		GroupPrefix: config.GetValue("AUTHORIZATION_RBAC_GROUP_PREFIX"),
+	}
+	log.Info("Access is decided by Kubernetes RBAC, access rules of RenovateJobs and AUTHORIZATION_DEFAULT_* are ignored",
+		"userClaim", identity.UserClaim,
+		"userPrefix", identity.UserPrefix,
+		"groupPrefix", identity.GroupPrefix)
+	return ui.NewRBACAuthorizer(clientset.AuthorizationV1().SubjectAccessReviews(), identity, log.WithName("rbac"))
+}
+
+// initReports schedules the delivery of the digest reports summing up history
+// to the sinks in REPORT_SINKS. The schedule fires on the leader only, as the
+// scheduler is started there.
+func initReports(mgr ctrl.Manager, cronManager scheduler.Scheduler, history reports.History) {
+	sinks, err := reports.ParseSinks(config.GetValue("REPORT_SINKS"), os.Getenv)
+	assert.NoError(err, "failed to parse the report sinks")
+	schedule := config.GetValue("REPORT_SCHEDULE")
+	if schedule == "" || len(sinks) == 0 {
+		return
+	}
+	assert.Assert(history != nil, "REPORT_SCHEDULE needs the run history, set REPORT_HISTORY_MODE to memory or valkey")
+
+	days, _ := strconv.Atoi(config.GetValue("REPORT_PERIOD_DAYS"))
+	deliverer := reports.NewDeliverer(ctrl.Log.WithName("reports"), mgr.GetClient(), history, sinks, days)
+	err = cronManager.AddOperatorScheduleReplaceExisting(schedule, "reports", func() {
+		deliverer.Deliver(context.Background(), time.Now())
+	})
+	assert.NoError(err, "failed to schedule reports")
+}
+
 // initObservability sets up OpenTelemetry (traces, metrics, logs), configures the
 // controller-runtime logger with an OTel tee when enabled, and registers Prometheus
 // metrics. Returns a cleanup function that flushes OTel providers.
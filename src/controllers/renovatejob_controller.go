@@ -2,14 +2,17 @@ package controllers
 
 import (
 	context "context"
+	"maps"
 	api "renovate-operator/api/v1alpha1"
 	"renovate-operator/github"
+	"renovate-operator/internal/audit"
 	"renovate-operator/internal/policy"
 	"renovate-operator/internal/renovate"
 	"renovate-operator/internal/telemetry"
 	"renovate-operator/internal/types"
 	"renovate-operator/metricStore"
 	"renovate-operator/scheduler"
+	"slices"
 	"strings"
 	"time"
 
@@ -43,6 +46,8 @@ type RenovateJobReconciler struct {
 	K8sClient client.Client
 	GithubApp github.GithubAppToken
 	Policy    policy.Policy
+	// Audit records the actions trigger annotations take; nil disables it
+	Audit audit.Sink
 }
 
 func (r *RenovateJobReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
@@ -73,6 +78,7 @@ func (r *RenovateJobReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 
 		r.resetOrphanedRunning(ctx, renovateJob)
 		createScheduler(logger, renovateJob, r)
+		createDiscoveryScheduler(logger, renovateJob, r)
 		if err := r.GithubApp.EnsureToken(ctx, renovateJob); err != nil {
 			logger.Error(err, "failed to ensure github app token")
 		}
@@ -86,6 +92,7 @@ func (r *RenovateJobReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 		// renovatejob cannot be found -> delete the schedule
 		// the github app token secret is owned by the RenovateJob and cleaned up by Kubernetes GC
 		r.Scheduler.RemoveSchedule(req.Namespace, req.Name)
+		r.Scheduler.RemoveDiscoverySchedule(req.Namespace, req.Name)
 		span.SetStatus(codes.Ok, "")
 		return ctrl.Result{RequeueAfter: 1 * time.Minute}, nil
 	} else {
@@ -123,6 +130,7 @@ func (r *RenovateJobReconciler) acceptJob(ctx context.Context, logger logr.Logge
 		"renovateJob", renovateJob.Name, "namespace", renovateJob.Namespace, "reason", reason)
 
 	r.Scheduler.RemoveSchedule(renovateJob.Namespace, renovateJob.Name)
+	r.Scheduler.RemoveDiscoverySchedule(renovateJob.Namespace, renovateJob.Name)
 
 	if condErr := r.Manager.SetAcceptedCondition(ctx, jobID, false, reason, err.Error()); condErr != nil {
 		logger.Error(condErr, "failed to record the Accepted condition")
@@ -192,6 +200,22 @@ func createScheduler(logger logr.Logger, renovateJob *api.RenovateJob, reconcile
 			return
 		}
 
+		// With a separate discovery schedule the run reuses the project list of the
+		// last successful discovery. Discovery only runs here until there is one.
+		if currentJob.Spec.DiscoverySchedule != "" && currentJob.Status.LastDiscoveryTime != nil {
+			jobId := crdManager.RenovateJobIdentifier{Name: jobName, Namespace: jobNamespace}
+			isNotRunning := func(p api.ProjectStatus) bool { return p.Status != api.JobStatusRunning }
+			if err := reconciler.Manager.UpdateProjectStatusBatched(ctx, isNotRunning, jobId, &types.RenovateStatusUpdate{Status: api.JobStatusScheduled}); err != nil {
+				span.RecordError(err)
+				span.SetStatus(codes.Error, err.Error())
+				logger.Error(err, "Failed to schedule known projects for RenovateJob")
+				return
+			}
+			span.SetStatus(codes.Ok, "")
+			logger.V(2).Info("Scheduled known projects, discovery runs on its own schedule")
+			return
+		}
+
 		_, err = reconciler.Discovery.CreateDiscoveryJob(ctx, *currentJob, renovate.DiscoveryJobOptions{TriggerAllProjects: true})
 		if err != nil {
 			span.RecordError(err)
@@ -213,6 +237,55 @@ func createScheduler(logger logr.Logger, renovateJob *api.RenovateJob, reconcile
 	logger.V(2).Info("Added schedule for RenovateJob", "schedule", expr)
 }
 
+// createDiscoveryScheduler registers the optional discovery schedule, which only
+// refreshes the project list, and removes it again once spec.discoverySchedule is unset.
+func createDiscoveryScheduler(logger logr.Logger, renovateJob *api.RenovateJob, reconciler *RenovateJobReconciler) {
+	expr := renovateJob.Spec.DiscoverySchedule
+	if expr == "" {
+		reconciler.Scheduler.RemoveDiscoverySchedule(renovateJob.Namespace, renovateJob.Name)
+		return
+	}
+
+	name := renovateJob.Fullname()
+	jobName := renovateJob.Name
+	jobNamespace := renovateJob.Namespace
+	f := func() {
+		ctx := context.Background()
+		ctx, span := telemetry.StartSpan(ctx, reconcilerTracer, "RenovateJob.ScheduledDiscovery",
+			logger.WithName(name),
+			trace.WithAttributes(
+				semconv.K8SNamespaceName(jobNamespace),
+				attribute.String("renovate_operator.renovatejob.name", jobName),
+			),
+		)
+		defer span.End()
+		logger := log.FromContext(ctx)
+
+		currentJob, err := reconciler.Manager.GetRenovateJob(ctx, jobName, jobNamespace)
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+			logger.Error(err, "Failed to get current RenovateJob")
+			return
+		}
+
+		if _, err := reconciler.Discovery.CreateDiscoveryJob(ctx, *currentJob, renovate.DiscoveryJobOptions{}); err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+			logger.Error(err, "Failed to create discovery job for RenovateJob")
+			return
+		}
+		span.SetStatus(codes.Ok, "")
+		logger.V(2).Info("Discovery job created, completion handled by job controller")
+	}
+
+	if err := reconciler.Scheduler.AddDiscoveryScheduleReplaceExisting(expr, jobNamespace, jobName, f); err != nil {
+		logger.Error(err, "Failed to add discovery schedule for RenovateJob")
+		return
+	}
+	logger.V(2).Info("Added discovery schedule for RenovateJob", "discoverySchedule", expr)
+}
+
 // resetOrphanedRunning resets Running projects whose k8s Job no longer exists (e.g. deleted
 // while the operator was scaled down). Uses a single list call to avoid per-project API calls.
 func (r *RenovateJobReconciler) resetOrphanedRunning(ctx context.Context, renovateJob *api.RenovateJob) {
@@ -264,6 +337,7 @@ func (r *RenovateJobReconciler) resetOrphanedRunning(ctx context.Context, renova
 //   - renovate-operator.mogenius.com/discovery: "true"           → start a discovery run
 //   - renovate-operator.mogenius.com/schedule-all: "true"        → set all non-running projects to Scheduled
 //   - renovate-operator.mogenius.com/schedule: "org/a,org/b"     → set specific non-running projects to Scheduled
+//   - renovate-operator.mogenius.com/approve: "org/a,org/b"      → approve specific pending-approval projects
 //
 // Each annotation is removed once its action succeeds, making triggers idempotent one-shots.
 // Note: these are annotations (not labels) because project names may contain slashes.
@@ -273,11 +347,13 @@ func (r *RenovateJobReconciler) handleAnnotationTriggers(ctx context.Context, lo
 		return
 	}
 
-	toRemove := make([]string, 0, 3)
+	toRemove := make([]string, 0, 4)
 	jobId := crdManager.RenovateJobIdentifier{Name: renovateJob.Name, Namespace: renovateJob.Namespace}
 
 	if annotations[api.TriggerDiscoveryAnnotationKey] == "true" {
-		if _, err := r.Discovery.CreateDiscoveryJob(ctx, *renovateJob, renovate.DiscoveryJobOptions{}); err != nil {
+		_, err := r.Discovery.CreateDiscoveryJob(ctx, *renovateJob, renovate.DiscoveryJobOptions{})
+		r.recordAnnotationAudit(ctx, audit.ActionDiscovery, api.TriggerDiscoveryAnnotationKey, jobId, nil, err)
+		if err != nil {
 			logger.Error(err, "failed to trigger discovery")
 		} else {
 			logger.V(1).Info("discovery triggered via annotation")
@@ -287,7 +363,9 @@ func (r *RenovateJobReconciler) handleAnnotationTriggers(ctx context.Context, lo
 
 	if annotations[api.TriggerScheduleAllAnnotationKey] == "true" {
 		isNotRunning := func(p api.ProjectStatus) bool { return p.Status != api.JobStatusRunning }
-		if err := r.Manager.UpdateProjectStatusBatched(ctx, isNotRunning, jobId, &types.RenovateStatusUpdate{Status: api.JobStatusScheduled}); err != nil {
+		err := r.Manager.UpdateProjectStatusBatched(ctx, isNotRunning, jobId, &types.RenovateStatusUpdate{Status: api.JobStatusScheduled})
+		r.recordAnnotationAudit(ctx, audit.ActionTriggerAll, api.TriggerScheduleAllAnnotationKey, jobId, nil, err)
+		if err != nil {
 			logger.Error(err, "failed to schedule all projects")
 		} else {
 			logger.V(1).Info("all projects scheduled via annotation")
@@ -301,7 +379,9 @@ func (r *RenovateJobReconciler) handleAnnotationTriggers(ctx context.Context, lo
 			_, ok := projectSet[p.Name]
 			return ok && p.Status != api.JobStatusRunning
 		}
-		if err := r.Manager.UpdateProjectStatusBatched(ctx, isTargeted, jobId, &types.RenovateStatusUpdate{Status: api.JobStatusScheduled}); err != nil {
+		err := r.Manager.UpdateProjectStatusBatched(ctx, isTargeted, jobId, &types.RenovateStatusUpdate{Status: api.JobStatusScheduled})
+		r.recordAnnotationAudit(ctx, audit.ActionTrigger, api.TriggerScheduleAnnotationKey, jobId, slices.Sorted(maps.Keys(projectSet)), err)
+		if err != nil {
 			logger.Error(err, "failed to schedule projects from annotation")
 		} else {
 			logger.V(1).Info("projects scheduled via annotation", "projects", projectsStr)
@@ -309,6 +389,18 @@ func (r *RenovateJobReconciler) handleAnnotationTriggers(ctx context.Context, lo
 		}
 	}
 
+	if projectsStr := annotations[api.TriggerApproveAnnotationKey]; projectsStr != "" {
+		projects := slices.Sorted(maps.Keys(parseAnnotationProjectList(projectsStr)))
+		err := r.Manager.ApproveProjects(ctx, jobId, projects)
+		r.recordAnnotationAudit(ctx, audit.ActionApprove, api.TriggerApproveAnnotationKey, jobId, projects, err)
+		if err != nil {
+			logger.Error(err, "failed to approve projects from annotation")
+		} else {
+			logger.V(1).Info("projects approved via annotation", "projects", projectsStr)
+			toRemove = append(toRemove, api.TriggerApproveAnnotationKey)
+		}
+	}
+
 	if len(toRemove) == 0 {
 		return
 	}
@@ -318,6 +410,35 @@ func (r *RenovateJobReconciler) handleAnnotationTriggers(ctx context.Context, lo
 	}
 }
 
+// recordAnnotationAudit records the action a trigger annotation took, once
+// per project it names. Who set the annotation is only known to the
+// Kubernetes audit log, so the entries have no actor.
+func (r *RenovateJobReconciler) recordAnnotationAudit(ctx context.Context, action, annotation string, jobId crdManager.RenovateJobIdentifier, projects []string, err error) {
+	if r.Audit == nil {
+		return
+	}
+	entry := audit.Entry{
+		Source:      audit.SourceAnnotation,
+		Action:      action,
+		Namespace:   jobId.Namespace,
+		RenovateJob: jobId.Name,
+		Result:      audit.ResultSuccess,
+		Details:     map[string]string{"annotation": annotation},
+	}
+	if err != nil {
+		entry.Result = audit.ResultFailed
+		entry.Reason = err.Error()
+	}
+	if len(projects) == 0 {
+		r.Audit.Record(ctx, entry)
+		return
+	}
+	for _, project := range projects {
+		entry.Project = project
+		r.Audit.Record(ctx, entry)
+	}
+}
+
 func parseAnnotationProjectList(s string) map[string]struct{} {
 	parts := strings.Split(s, ",")
 	result := make(map[string]struct{}, len(parts))
@@ -10,7 +10,10 @@ import (
 	"time"
 
 	api "renovate-operator/api/v1alpha1"
+	"renovate-operator/internal/audit"
 	crdManager "renovate-operator/internal/crdManager"
+	"renovate-operator/internal/kvstore"
+	"renovate-operator/internal/objectstore"
 	"renovate-operator/internal/renovate"
 
 	"renovate-operator/internal/types"
@@ -37,6 +40,7 @@ type fakeManager struct {
 	reconcileProjectsFn          func(ctx context.Context, job *api.RenovateJob, projects []string) error
 	cleanupWebhooksFn            func(ctx context.Context, job crdManager.RenovateJobIdentifier) error
 	updateProjectStatusBatchedFn func(ctx context.Context, fn func(p api.ProjectStatus) bool, job crdManager.RenovateJobIdentifier, status *types.RenovateStatusUpdate) error
+	approveProjectsFn            func(ctx context.Context, job crdManager.RenovateJobIdentifier, projects []string) error
 }
 
 func (f *fakeManager) ListRenovateJobs(ctx context.Context) ([]crdManager.RenovateJobIdentifier, error) {
@@ -77,6 +81,21 @@ func (m *fakeManager) SetAcceptedCondition(ctx context.Context, jobId crdManager
 func (f *fakeManager) CancelProjectJob(ctx context.Context, project string, job crdManager.RenovateJobIdentifier) error {
 	return nil
 }
+func (f *fakeManager) ApproveProjects(ctx context.Context, job crdManager.RenovateJobIdentifier, projects []string) error {
+	if f.approveProjectsFn != nil {
+		return f.approveProjectsFn(ctx, job, projects)
+	}
+	return nil
+}
+func (f *fakeManager) DiscoverProjects(ctx context.Context, job *api.RenovateJob) ([]string, error) {
+	return nil, nil
+}
+func (f *fakeManager) GetDefaultBranch(ctx context.Context, job *api.RenovateJob, project string) (string, error) {
+	return "", fmt.Errorf("not implemented")
+}
+func (f *fakeManager) ListChangedFiles(ctx context.Context, job *api.RenovateJob, project, base, head string) ([]string, error) {
+	return nil, fmt.Errorf("not implemented")
+}
 func (f *fakeManager) GetProjectsByStatus(ctx context.Context, job crdManager.RenovateJobIdentifier, status api.RenovateProjectStatus) ([]crdManager.RenovateProjectStatus, error) {
 	return nil, fmt.Errorf("not implemented")
 }
@@ -151,6 +170,10 @@ type fakeScheduler struct {
 	removeCalled bool
 	storedFn     func()
 	addErr       error
+
+	discoveryExpr    string
+	discoveryFn      func()
+	discoveryRemoved bool
 }
 
 func (f *fakeScheduler) AddScheduleReplaceExisting(expr string, namespace, job string, fct func()) error {
@@ -165,6 +188,15 @@ func (f *fakeScheduler) RemoveSchedule(namespace, job string) {
 	f.removeCalled = true
 }
 
+func (f *fakeScheduler) AddDiscoveryScheduleReplaceExisting(expr string, namespace, job string, fct func()) error {
+	f.discoveryExpr = expr
+	f.discoveryFn = fct
+	return nil
+}
+func (f *fakeScheduler) RemoveDiscoverySchedule(namespace, job string) {
+	f.discoveryRemoved = true
+}
+
 // implement remaining methods of scheduler.Scheduler as no-ops for tests
 func (f *fakeScheduler) Start() {}
 func (f *fakeScheduler) Stop()  {}
@@ -173,6 +205,9 @@ func (f *fakeScheduler) AddSchedule(expr string, namespace, job string, fn func(
 	return f.AddScheduleReplaceExisting(expr, namespace, job, fn)
 }
 func (f *fakeScheduler) GetNextRunOnSchedule(schedule, key string) time.Time { return time.Time{} }
+func (f *fakeScheduler) AddOperatorScheduleReplaceExisting(expr string, name string, fn func()) error {
+	return nil
+}
 
 // Test createScheduler: ensure the scheduled function creates a discovery job
 func TestCreateScheduler_DiscoveryAndManagerInteraction(t *testing.T) {
@@ -215,6 +250,100 @@ func TestCreateScheduler_DiscoveryAndManagerInteraction(t *testing.T) {
 	}
 }
 
+// Test: with a discovery schedule and a previous discovery, the run schedule only
+// schedules the known projects and leaves discovery to its own schedule.
+func TestCreateScheduler_DiscoveryScheduleReusesKnownProjects(t *testing.T) {
+	discoveredAt := metav1.Now()
+	mgr := &fakeManager{}
+	mgr.getFn = func(ctx context.Context, name, namespace string) (*api.RenovateJob, error) {
+		return &api.RenovateJob{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+			Spec:       api.RenovateJobSpec{Schedule: "*/1 * * * *", DiscoverySchedule: "0 0 * * 1"},
+			Status:     api.RenovateJobStatus{LastDiscoveryTime: &discoveredAt},
+		}, nil
+	}
+	var scheduledStatus *types.RenovateStatusUpdate
+	mgr.updateProjectStatusBatchedFn = func(ctx context.Context, fn func(p api.ProjectStatus) bool, job crdManager.RenovateJobIdentifier, status *types.RenovateStatusUpdate) error {
+		scheduledStatus = status
+		return nil
+	}
+
+	calledCreate := false
+	disc := &fakeDiscovery{}
+	disc.createDiscoveryJobFn = func(ctx context.Context, job api.RenovateJob) (string, error) {
+		calledCreate = true
+		return "gen-1", nil
+	}
+
+	sched := &fakeScheduler{}
+	reconciler := &RenovateJobReconciler{Manager: mgr, Scheduler: sched, Discovery: disc}
+	renovateJob := &api.RenovateJob{ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "default"}, Spec: api.RenovateJobSpec{Schedule: "*/1 * * * *", DiscoverySchedule: "0 0 * * 1"}}
+
+	createScheduler(logr.Discard(), renovateJob, reconciler)
+	sched.storedFn()
+
+	if calledCreate {
+		t.Fatalf("expected no discovery job from the run schedule")
+	}
+	if scheduledStatus == nil || scheduledStatus.Status != api.JobStatusScheduled {
+		t.Fatalf("expected known projects to be scheduled, got %+v", scheduledStatus)
+	}
+
+	createDiscoveryScheduler(logr.Discard(), renovateJob, reconciler)
+	if sched.discoveryExpr != "0 0 * * 1" || sched.discoveryFn == nil {
+		t.Fatalf("expected discovery schedule to be registered, got %q", sched.discoveryExpr)
+	}
+	sched.discoveryFn()
+	if !calledCreate {
+		t.Fatalf("expected the discovery schedule to create a discovery job")
+	}
+}
+
+// Test: without any previous discovery the run schedule still discovers first.
+func TestCreateScheduler_DiscoveryScheduleBootstrapsWithDiscovery(t *testing.T) {
+	mgr := &fakeManager{}
+	mgr.getFn = func(ctx context.Context, name, namespace string) (*api.RenovateJob, error) {
+		return &api.RenovateJob{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+			Spec:       api.RenovateJobSpec{Schedule: "*/1 * * * *", DiscoverySchedule: "0 0 * * 1"},
+		}, nil
+	}
+
+	calledCreate := false
+	disc := &fakeDiscovery{}
+	disc.createDiscoveryJobFn = func(ctx context.Context, job api.RenovateJob) (string, error) {
+		calledCreate = true
+		return "gen-1", nil
+	}
+
+	sched := &fakeScheduler{}
+	reconciler := &RenovateJobReconciler{Manager: mgr, Scheduler: sched, Discovery: disc}
+	renovateJob := &api.RenovateJob{ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "default"}, Spec: api.RenovateJobSpec{Schedule: "*/1 * * * *", DiscoverySchedule: "0 0 * * 1"}}
+
+	createScheduler(logr.Discard(), renovateJob, reconciler)
+	sched.storedFn()
+
+	if !calledCreate {
+		t.Fatalf("expected a discovery job while no discovery result exists yet")
+	}
+}
+
+// Test: clearing spec.discoverySchedule removes the discovery schedule.
+func TestCreateDiscoveryScheduler_RemovedWhenUnset(t *testing.T) {
+	sched := &fakeScheduler{}
+	reconciler := &RenovateJobReconciler{Manager: &fakeManager{}, Scheduler: sched, Discovery: &fakeDiscovery{}}
+	renovateJob := &api.RenovateJob{ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "default"}, Spec: api.RenovateJobSpec{Schedule: "*/1 * * * *"}}
+
+	createDiscoveryScheduler(logr.Discard(), renovateJob, reconciler)
+
+	if !sched.discoveryRemoved {
+		t.Fatalf("expected the discovery schedule to be removed")
+	}
+	if sched.discoveryFn != nil {
+		t.Fatalf("expected no discovery schedule to be registered")
+	}
+}
+
 // Test: when CreateDiscoveryJob returns an error, the scheduled function should abort
 func TestCreateScheduler_DiscoveryErrorAborts(t *testing.T) {
 	mgr := &fakeManager{}
@@ -508,6 +637,76 @@ func TestHandleAnnotationTriggers_Schedule(t *testing.T) {
 	}
 }
 
+// TestHandleAnnotationTriggers_Approve verifies that the approve annotation hands the
+// listed projects to the manager and is removed from the RenovateJob on success.
+func TestHandleAnnotationTriggers_Approve(t *testing.T) {
+	var approved []string
+	mgr := &fakeManager{
+		approveProjectsFn: func(_ context.Context, _ crdManager.RenovateJobIdentifier, projects []string) error {
+			approved = projects
+			return nil
+		},
+	}
+
+	renovateJob := makeRenovateJob("test", "default", map[string]string{
+		api.TriggerApproveAnnotationKey: "org/p1, org/p2",
+	})
+	reconciler := &RenovateJobReconciler{
+		Discovery: &fakeDiscovery{},
+		Manager:   mgr,
+		K8sClient: buildFakeK8sClient(t, renovateJob),
+	}
+
+	reconciler.handleAnnotationTriggers(context.Background(), logr.Discard(), renovateJob)
+
+	slices.Sort(approved)
+	if !slices.Equal(approved, []string{"org/p1", "org/p2"}) {
+		t.Fatalf("expected org/p1 and org/p2 to be approved, got %v", approved)
+	}
+	if _, ok := renovateJob.Annotations[api.TriggerApproveAnnotationKey]; ok {
+		t.Fatal("expected approve annotation to be removed after processing")
+	}
+}
+
+// TestHandleAnnotationTriggers_Audit verifies that the actions of trigger annotations are
+// recorded, once per named project, including those that fail.
+func TestHandleAnnotationTriggers_Audit(t *testing.T) {
+	sink, err := audit.NewSink(logr.Discard(), "memory", kvstore.ValkeyConfig{}, objectstore.S3Config{}, "", time.Hour)
+	if err != nil {
+		t.Fatalf("NewSink returned error: %v", err)
+	}
+	renovateJob := makeRenovateJob("test", "default", map[string]string{
+		api.TriggerApproveAnnotationKey:   "org/p2, org/p1",
+		api.TriggerDiscoveryAnnotationKey: "true",
+	})
+	reconciler := &RenovateJobReconciler{
+		Discovery: &fakeDiscovery{
+			createDiscoveryJobFn: func(ctx context.Context, job api.RenovateJob) (string, error) {
+				return "", fmt.Errorf("boom")
+			},
+		},
+		Manager:   &fakeManager{},
+		K8sClient: buildFakeK8sClient(t, renovateJob),
+		Audit:     sink,
+	}
+
+	reconciler.handleAnnotationTriggers(context.Background(), logr.Discard(), renovateJob)
+
+	entries, _ := sink.List(context.Background(), audit.Filter{Source: audit.SourceAnnotation})
+	if len(entries) != 3 {
+		t.Fatalf("expected the discovery and an approval per project, got %+v", entries)
+	}
+	if e := entries[2]; e.Action != audit.ActionDiscovery || e.Result != audit.ResultFailed || e.Reason != "boom" || e.RenovateJob != "test" {
+		t.Errorf("expected the failed discovery, got %+v", e)
+	}
+	if entries[1].Project != "org/p1" || entries[0].Project != "org/p2" || entries[0].Action != audit.ActionApprove || entries[0].Result != audit.ResultSuccess {
+		t.Errorf("expected an approval per project, got %+v", entries[:2])
+	}
+	if entries[0].Details["annotation"] != api.TriggerApproveAnnotationKey || entries[0].Actor != "" {
+		t.Errorf("expected the annotation without an actor, got %+v", entries[0])
+	}
+}
+
 // Test: when the manager returns an error (not NotFound), Reconcile should return the error
 func TestReconcile_ReturnsErrorOnManagerFailure(t *testing.T) {
 	mgr := &fakeManager{}
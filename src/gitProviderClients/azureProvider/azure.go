@@ -0,0 +1,511 @@
+package azureProvider
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+
+	"renovate-operator/gitProviderClients"
+)
+
+// AzureClient implements GitProviderClient for the Azure DevOps Services (and
+// Server) REST API. Endpoint is the organisation URL Renovate is configured
+// with (https://dev.azure.com/{organization}/), and projects are named
+// "{project}/{repository}" as in Renovate.
+type AzureClient struct {
+	Endpoint   string
+	Token      string
+	HTTPClient *http.Client
+}
+
+// apiVersion is the REST API version requested on every call.
+const apiVersion = "7.1"
+
+// azureRepository is the subset of a Git repository resource the client reads.
+type azureRepository struct {
+	ID            string `json:"id"`
+	Name          string `json:"name"`
+	DefaultBranch string `json:"defaultBranch"`
+	Size          int64  `json:"size"`
+	IsFork        bool   `json:"isFork"`
+	IsDisabled    bool   `json:"isDisabled"`
+	Project       struct {
+		ID         string `json:"id"`
+		Name       string `json:"name"`
+		Visibility string `json:"visibility"`
+	} `json:"project"`
+}
+
+func (c *AzureClient) GetRepositoryInfo(ctx context.Context, project string) (gitProviderClients.RepositoryInfo, error) {
+	repo, err := c.getRepository(ctx, project)
+	if err != nil {
+		return gitProviderClients.RepositoryInfo{}, err
+	}
+
+	info := gitProviderClients.RepositoryInfo{
+		Fork: repo.IsFork,
+		// a disabled repository can no longer be read or pushed to, which is
+		// the closest Azure DevOps gets to an archived state
+		Archived: repo.IsDisabled,
+		// a repository without commits has no default branch yet
+		Empty:         repo.DefaultBranch == "",
+		DefaultBranch: strings.TrimPrefix(repo.DefaultBranch, "refs/heads/"),
+	}
+	// visibility is a property of the Azure DevOps project, not the repository
+	switch repo.Project.Visibility {
+	case "public":
+		info.Visibility = gitProviderClients.VisibilityPublic
+	case "private":
+		info.Visibility = gitProviderClients.VisibilityPrivate
+	}
+	// Azure DevOps has no pending-deletion state and the repository resource
+	// exposes no push timestamp.
+	return info, nil
+}
+
+// ListRepositories lists the repositories of the given Azure DevOps project, or
+// of every project in the organisation when namespace is empty. Disabled
+// repositories are left out like archived ones on the other platforms. Azure
+// DevOps repositories have no topics, and the listing is not paginated.
+func (c *AzureClient) ListRepositories(ctx context.Context, namespace string) ([]gitProviderClients.RepositoryListing, error) {
+	path := "/_apis/git/repositories"
+	if namespace != "" {
+		path = fmt.Sprintf("/%s/_apis/git/repositories", url.PathEscape(namespace))
+	}
+
+	resp, err := c.doRequest(ctx, http.MethodGet, path, nil)
+	if err != nil {
+		return nil, fmt.Errorf("listing repositories: %w", err)
+	}
+
+	var result struct {
+		Value []azureRepository `json:"value"`
+	}
+	if err := decodeResponse(resp, &result); err != nil {
+		return nil, fmt.Errorf("listing repositories: %w", err)
+	}
+
+	listings := make([]gitProviderClients.RepositoryListing, 0, len(result.Value))
+	for _, repo := range result.Value {
+		if repo.IsDisabled {
+			continue
+		}
+		listings = append(listings, gitProviderClients.RepositoryListing{FullName: repo.Project.Name + "/" + repo.Name})
+	}
+	return listings, nil
+}
+
+// getRepository resolves a "{project}/{repository}" name to its repository
+// resource, which carries the project and repository IDs service hooks are
+// scoped by.
+func (c *AzureClient) getRepository(ctx context.Context, project string) (azureRepository, error) {
+	projectName, repoName, ok := strings.Cut(project, "/")
+	if !ok || projectName == "" || repoName == "" {
+		return azureRepository{}, fmt.Errorf("invalid azure repository %q: expected {project}/{repository}", project)
+	}
+
+	// Azure DevOps API: GET /{project}/_apis/git/repositories/{repositoryId}
+	path := fmt.Sprintf("/%s/_apis/git/repositories/%s", url.PathEscape(projectName), url.PathEscape(repoName))
+	resp, err := c.doRequest(ctx, http.MethodGet, path, nil)
+	if err != nil {
+		return azureRepository{}, err
+	}
+
+	var repo azureRepository
+	if err := decodeResponse(resp, &repo); err != nil {
+		return azureRepository{}, fmt.Errorf("azure API request for %s failed: %w", project, err)
+	}
+	return repo, nil
+}
+
+// ListChangedFiles lists the changes between the two commits. Azure Repos
+// reports paths with a leading slash, and folders as changes of their own.
+func (c *AzureClient) ListChangedFiles(ctx context.Context, project, base, head string) ([]string, error) {
+	projectName, repoName, ok := strings.Cut(project, "/")
+	if !ok || projectName == "" || repoName == "" {
+		return nil, fmt.Errorf("invalid azure repository %q: expected {project}/{repository}", project)
+	}
+
+	var files []string
+	skip := 0
+	limit := 500
+
+	for {
+		// Azure DevOps API: GET /{project}/_apis/git/repositories/{repositoryId}/diffs/commits
+		path := fmt.Sprintf("/%s/_apis/git/repositories/%s/diffs/commits?baseVersion=%s&baseVersionType=commit&targetVersion=%s&targetVersionType=commit&$top=%d&$skip=%d",
+			url.PathEscape(projectName), url.PathEscape(repoName), url.QueryEscape(base), url.QueryEscape(head), limit, skip)
+		resp, err := c.doRequest(ctx, http.MethodGet, path, nil)
+		if err != nil {
+			return nil, fmt.Errorf("comparing commits: %w", err)
+		}
+
+		var result struct {
+			Changes []struct {
+				Item struct {
+					Path     string `json:"path"`
+					IsFolder bool   `json:"isFolder"`
+				} `json:"item"`
+				// SourceServerItem is the previous path of a renamed file
+				SourceServerItem string `json:"sourceServerItem"`
+			} `json:"changes"`
+		}
+		if err := decodeResponse(resp, &result); err != nil {
+			return nil, fmt.Errorf("comparing commits: %w", err)
+		}
+
+		for _, change := range result.Changes {
+			if change.Item.IsFolder {
+				continue
+			}
+			files = append(files, strings.TrimPrefix(change.Item.Path, "/"))
+			if change.SourceServerItem != "" && change.SourceServerItem != change.Item.Path {
+				files = append(files, strings.TrimPrefix(change.SourceServerItem, "/"))
+			}
+		}
+		if len(result.Changes) < limit {
+			break
+		}
+		skip += limit
+	}
+
+	return files, nil
+}
+
+func (c *AzureClient) doRequest(ctx context.Context, method, path string, body io.Reader) (*http.Response, error) {
+	separator := "?"
+	if strings.Contains(path, "?") {
+		separator = "&"
+	}
+	target := strings.TrimSuffix(c.Endpoint, "/") + path + separator + "api-version=" + apiVersion
+
+	req, err := http.NewRequestWithContext(ctx, method, target, body)
+	if err != nil {
+		return nil, fmt.Errorf("creating request: %w", err)
+	}
+	// personal access tokens are sent as the password of basic auth with an
+	// empty user name
+	req.Header.Set("Authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte(":"+c.Token)))
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	return c.HTTPClient.Do(req)
+}
+
+// wire format of the Azure DevOps service hook subscriptions API. A
+// subscription delivers exactly one event type, so the operator's hook on a
+// repository is the set of subscriptions that share one delivery URL. Its ID is
+// the comma-separated list of their subscription IDs.
+type azureSubscription struct {
+	ID               string            `json:"id,omitempty"`
+	PublisherID      string            `json:"publisherId"`
+	EventType        string            `json:"eventType"`
+	ResourceVersion  string            `json:"resourceVersion,omitempty"`
+	ConsumerID       string            `json:"consumerId"`
+	ConsumerActionID string            `json:"consumerActionId"`
+	Status           string            `json:"status,omitempty"`
+	PublisherInputs  map[string]string `json:"publisherInputs"`
+	ConsumerInputs   map[string]string `json:"consumerInputs"`
+}
+
+const (
+	// azurePublisherID is the publisher of Azure Repos events.
+	azurePublisherID = "tfs"
+	// azureConsumerID and azureConsumerActionID select the generic "Web Hooks"
+	// consumer posting the event as JSON.
+	azureConsumerID       = "webHooks"
+	azureConsumerActionID = "httpRequest"
+	// azureStatusEnabled is the status of a subscription that delivers events.
+	azureStatusEnabled = "enabled"
+	// azureStatusDisabled is the status of a subscription disabled on purpose.
+	azureStatusDisabled = "disabledByUser"
+)
+
+// azureWebhookEvents is the fixed subscription for operator-managed hooks: pull
+// request updates (checkbox interactions, abandoning) and merges, plus code
+// pushes for the push trigger. Renovate's Dependency Dashboard is an issue,
+// which Azure Repos does not have, so no work item events are needed.
+var azureWebhookEvents = []string{"git.pullrequest.updated", "git.pullrequest.merged", "git.push"}
+
+func (c *AzureClient) ListRepoWebhooks(ctx context.Context, project string) ([]gitProviderClients.Webhook, error) {
+	repo, err := c.getRepository(ctx, project)
+	if err != nil {
+		return nil, fmt.Errorf("listing webhooks: %w", err)
+	}
+	subscriptions, err := c.listRepoSubscriptions(ctx, repo)
+	if err != nil {
+		return nil, fmt.Errorf("listing webhooks: %w", err)
+	}
+
+	// group the subscriptions into one hook per delivery URL, in the order the
+	// URLs were first seen
+	var urls []string
+	byURL := make(map[string][]azureSubscription)
+	for _, sub := range subscriptions {
+		hookURL := sub.ConsumerInputs["url"]
+		if _, ok := byURL[hookURL]; !ok {
+			urls = append(urls, hookURL)
+		}
+		byURL[hookURL] = append(byURL[hookURL], sub)
+	}
+
+	hooks := make([]gitProviderClients.Webhook, 0, len(urls))
+	for _, hookURL := range urls {
+		hooks = append(hooks, toWebhook(hookURL, byURL[hookURL]))
+	}
+	return hooks, nil
+}
+
+// listRepoSubscriptions returns the organisation's web hook subscriptions that
+// publish events of the given repository.
+func (c *AzureClient) listRepoSubscriptions(ctx context.Context, repo azureRepository) ([]azureSubscription, error) {
+	path := fmt.Sprintf("/_apis/hooks/subscriptions?publisherId=%s&consumerId=%s", azurePublisherID, azureConsumerID)
+	resp, err := c.doRequest(ctx, http.MethodGet, path, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var result struct {
+		Value []azureSubscription `json:"value"`
+	}
+	if err := decodeResponse(resp, &result); err != nil {
+		return nil, err
+	}
+
+	var subscriptions []azureSubscription
+	for _, sub := range result.Value {
+		if sub.PublisherInputs["projectId"] != repo.Project.ID || !strings.EqualFold(sub.PublisherInputs["repository"], repo.ID) {
+			continue
+		}
+		subscriptions = append(subscriptions, sub)
+	}
+	return subscriptions, nil
+}
+
+func toWebhook(hookURL string, subscriptions []azureSubscription) gitProviderClients.Webhook {
+	ids := make([]string, 0, len(subscriptions))
+	events := make([]string, 0, len(subscriptions))
+	active := true
+	for _, sub := range subscriptions {
+		ids = append(ids, sub.ID)
+		events = append(events, sub.EventType)
+		if sub.Status != azureStatusEnabled {
+			active = false
+		}
+	}
+	return gitProviderClients.Webhook{
+		ID:             strings.Join(ids, ","),
+		URL:            hookURL,
+		Active:         active,
+		EventsUpToDate: eventsEqual(events, azureWebhookEvents),
+	}
+}
+
+// eventsEqual compares two event name lists as sets.
+func eventsEqual(actual, expected []string) bool {
+	if len(actual) != len(expected) {
+		return false
+	}
+	set := make(map[string]struct{}, len(expected))
+	for _, event := range expected {
+		set[event] = struct{}{}
+	}
+	for _, event := range actual {
+		if _, ok := set[event]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// newSubscription builds the subscription delivering eventType of repo to the
+// hook described by opts. Azure DevOps cannot sign deliveries, so the auth
+// token is sent as a bearer token in a custom header.
+func newSubscription(repo azureRepository, eventType string, opts gitProviderClients.CreateWebhookOptions) azureSubscription {
+	status := azureStatusEnabled
+	if !opts.Active {
+		status = azureStatusDisabled
+	}
+	consumerInputs := map[string]string{
+		"url":                    opts.URL,
+		"resourceDetailsToSend":  "all",
+		"messagesToSend":         "none",
+		"detailedMessagesToSend": "none",
+	}
+	if opts.AuthToken != "" {
+		consumerInputs["httpHeaders"] = "Authorization: Bearer " + opts.AuthToken
+	}
+	return azureSubscription{
+		PublisherID:      azurePublisherID,
+		EventType:        eventType,
+		ResourceVersion:  "1.0",
+		ConsumerID:       azureConsumerID,
+		ConsumerActionID: azureConsumerActionID,
+		Status:           status,
+		PublisherInputs: map[string]string{
+			"projectId":  repo.Project.ID,
+			"repository": repo.ID,
+		},
+		ConsumerInputs: consumerInputs,
+	}
+}
+
+func (c *AzureClient) CreateRepoWebhook(ctx context.Context, project string, opts gitProviderClients.CreateWebhookOptions) (*gitProviderClients.Webhook, error) {
+	repo, err := c.getRepository(ctx, project)
+	if err != nil {
+		return nil, fmt.Errorf("creating webhook: %w", err)
+	}
+
+	created := make([]azureSubscription, 0, len(azureWebhookEvents))
+	for _, eventType := range azureWebhookEvents {
+		sub, err := c.writeSubscription(ctx, http.MethodPost, "", newSubscription(repo, eventType, opts))
+		if err != nil {
+			// do not leave a hook behind that only delivers some of the events
+			for _, done := range created {
+				_ = c.deleteSubscription(ctx, done.ID)
+			}
+			return nil, fmt.Errorf("creating webhook: %w", err)
+		}
+		created = append(created, sub)
+	}
+
+	result := toWebhook(opts.URL, created)
+	return &result, nil
+}
+
+// UpdateRepoWebhook rewrites the subscriptions making up the hook in place,
+// creates the ones for missing events and deletes those for events the
+// operator no longer needs.
+func (c *AzureClient) UpdateRepoWebhook(ctx context.Context, project string, hookID string, opts gitProviderClients.CreateWebhookOptions) (*gitProviderClients.Webhook, error) {
+	repo, err := c.getRepository(ctx, project)
+	if err != nil {
+		return nil, fmt.Errorf("updating webhook: %w", err)
+	}
+	subscriptions, err := c.listRepoSubscriptions(ctx, repo)
+	if err != nil {
+		return nil, fmt.Errorf("updating webhook: %w", err)
+	}
+
+	ids := strings.Split(hookID, ",")
+	existing := make(map[string]string)
+	var obsolete []string
+	for _, sub := range subscriptions {
+		if !slices.Contains(ids, sub.ID) {
+			continue
+		}
+		if _, seen := existing[sub.EventType]; seen || !slices.Contains(azureWebhookEvents, sub.EventType) {
+			obsolete = append(obsolete, sub.ID)
+			continue
+		}
+		existing[sub.EventType] = sub.ID
+	}
+
+	updated := make([]azureSubscription, 0, len(azureWebhookEvents))
+	for _, eventType := range azureWebhookEvents {
+		method, id := http.MethodPost, ""
+		if existingID, ok := existing[eventType]; ok {
+			method, id = http.MethodPut, existingID
+		}
+		sub, err := c.writeSubscription(ctx, method, id, newSubscription(repo, eventType, opts))
+		if err != nil {
+			return nil, fmt.Errorf("updating webhook: %w", err)
+		}
+		updated = append(updated, sub)
+	}
+	for _, id := range obsolete {
+		if err := c.deleteSubscription(ctx, id); err != nil {
+			return nil, fmt.Errorf("updating webhook: %w", err)
+		}
+	}
+
+	result := toWebhook(opts.URL, updated)
+	return &result, nil
+}
+
+func (c *AzureClient) DeleteRepoWebhook(ctx context.Context, project string, hookID string) error {
+	for _, id := range strings.Split(hookID, ",") {
+		if err := c.deleteSubscription(ctx, id); err != nil {
+			return fmt.Errorf("deleting webhook: %w", err)
+		}
+	}
+	return nil
+}
+
+// Azure DevOps service hook subscriptions are scoped per repository by the
+// operator; organization webhooks are not supported.
+
+func (c *AzureClient) ListOrgWebhooks(ctx context.Context, org string) ([]gitProviderClients.Webhook, error) {
+	return nil, gitProviderClients.ErrOrgWebhooksUnsupported
+}
+
+func (c *AzureClient) CreateOrgWebhook(ctx context.Context, org string, opts gitProviderClients.CreateWebhookOptions) (*gitProviderClients.Webhook, error) {
+	return nil, gitProviderClients.ErrOrgWebhooksUnsupported
+}
+
+func (c *AzureClient) UpdateOrgWebhook(ctx context.Context, org string, hookID string, opts gitProviderClients.CreateWebhookOptions) (*gitProviderClients.Webhook, error) {
+	return nil, gitProviderClients.ErrOrgWebhooksUnsupported
+}
+
+func (c *AzureClient) DeleteOrgWebhook(ctx context.Context, org string, hookID string) error {
+	return gitProviderClients.ErrOrgWebhooksUnsupported
+}
+
+// writeSubscription creates (POST) or replaces (PUT) a subscription.
+func (c *AzureClient) writeSubscription(ctx context.Context, method, id string, payload azureSubscription) (azureSubscription, error) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return azureSubscription{}, fmt.Errorf("marshalling subscription: %w", err)
+	}
+
+	path := "/_apis/hooks/subscriptions"
+	if id != "" {
+		path += "/" + url.PathEscape(id)
+	}
+	resp, err := c.doRequest(ctx, method, path, bytes.NewReader(body))
+	if err != nil {
+		return azureSubscription{}, err
+	}
+
+	var sub azureSubscription
+	if err := decodeResponse(resp, &sub); err != nil {
+		return azureSubscription{}, err
+	}
+	return sub, nil
+}
+
+func (c *AzureClient) deleteSubscription(ctx context.Context, id string) error {
+	resp, err := c.doRequest(ctx, http.MethodDelete, "/_apis/hooks/subscriptions/"+url.PathEscape(id), nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	// A missing subscription is the desired end state, so treat 404 as success.
+	if resp.StatusCode == http.StatusNotFound {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		return nil
+	}
+	if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("unexpected status %d: %s", resp.StatusCode, string(body))
+	}
+	return nil
+}
+
+func decodeResponse(resp *http.Response, target any) error {
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("unexpected status %d: %s", resp.StatusCode, string(body))
+	}
+
+	return json.NewDecoder(resp.Body).Decode(target)
+}
@@ -0,0 +1,285 @@
+package azureProvider
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sync"
+	"testing"
+
+	"renovate-operator/gitProviderClients"
+)
+
+const (
+	projectID = "6ce954b1-ce1f-45d1-b94d-e6bf2464ba2c"
+	repoID    = "5febef5a-833d-4e14-b9c0-14cb638f91e6"
+)
+
+func newTestClient(url string) *AzureClient {
+	return &AzureClient{Endpoint: url + "/org/", Token: "test-token", HTTPClient: http.DefaultClient}
+}
+
+const repoJSON = `{"id": "` + repoID + `", "name": "repo1", "defaultBranch": "refs/heads/develop", "size": 1024, "isFork": true, "isDisabled": false, "project": {"id": "` + projectID + `", "name": "proj", "visibility": "private"}}`
+
+// fakeAzure is an in-memory Azure DevOps serving one repository and the
+// organisation's service hook subscriptions.
+type fakeAzure struct {
+	t             *testing.T
+	mu            sync.Mutex
+	subscriptions map[string]azureSubscription
+	nextID        int
+}
+
+func newFakeAzure(t *testing.T) (*fakeAzure, *httptest.Server) {
+	f := &fakeAzure{t: t, subscriptions: map[string]azureSubscription{}}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/org/proj/_apis/git/repositories/repo1", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(repoJSON))
+	})
+	mux.HandleFunc("/org/_apis/hooks/subscriptions", f.handleCollection)
+	mux.HandleFunc("/org/_apis/hooks/subscriptions/{id}", f.handleItem)
+	return f, httptest.NewServer(mux)
+}
+
+func (f *fakeAzure) checkAPIVersion(r *http.Request) {
+	if r.URL.Query().Get("api-version") != apiVersion {
+		f.t.Errorf("expected api-version %s, got %q", apiVersion, r.URL.RawQuery)
+	}
+}
+
+func (f *fakeAzure) handleCollection(w http.ResponseWriter, r *http.Request) {
+	f.checkAPIVersion(r)
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	switch r.Method {
+	case http.MethodGet:
+		values := make([]azureSubscription, 0, len(f.subscriptions))
+		for _, sub := range f.subscriptions {
+			values = append(values, sub)
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"count": len(values), "value": values})
+	case http.MethodPost:
+		var sub azureSubscription
+		_ = json.NewDecoder(r.Body).Decode(&sub)
+		f.nextID++
+		sub.ID = string(rune('a' + f.nextID - 1))
+		f.subscriptions[sub.ID] = sub
+		_ = json.NewEncoder(w).Encode(sub)
+	}
+}
+
+func (f *fakeAzure) handleItem(w http.ResponseWriter, r *http.Request) {
+	f.checkAPIVersion(r)
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	id := r.PathValue("id")
+	if _, ok := f.subscriptions[id]; !ok {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	switch r.Method {
+	case http.MethodPut:
+		var sub azureSubscription
+		_ = json.NewDecoder(r.Body).Decode(&sub)
+		sub.ID = id
+		f.subscriptions[id] = sub
+		_ = json.NewEncoder(w).Encode(sub)
+	case http.MethodDelete:
+		delete(f.subscriptions, id)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func TestGetRepositoryInfo(t *testing.T) {
+	handler := http.NewServeMux()
+	handler.HandleFunc("/org/proj/_apis/git/repositories/repo1", func(w http.ResponseWriter, r *http.Request) {
+		want := "Basic " + base64.StdEncoding.EncodeToString([]byte(":test-token"))
+		if r.Header.Get("Authorization") != want {
+			t.Errorf("expected PAT basic auth, got %q", r.Header.Get("Authorization"))
+		}
+		_, _ = w.Write([]byte(repoJSON))
+	})
+	handler.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		// project names may contain spaces and must arrive path-escaped
+		if r.URL.EscapedPath() != "/org/My%20Project/_apis/git/repositories/empty" {
+			t.Errorf("unexpected path %q", r.URL.EscapedPath())
+		}
+		_, _ = w.Write([]byte(`{"id": "x", "name": "empty", "size": 0, "isDisabled": true, "project": {"name": "My Project", "visibility": "public"}}`))
+	})
+
+	srv := httptest.NewServer(handler)
+	defer srv.Close()
+	c := newTestClient(srv.URL)
+
+	info, err := c.GetRepositoryInfo(context.Background(), "proj/repo1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !info.Fork || info.Empty || info.Archived || info.Visibility != gitProviderClients.VisibilityPrivate || info.DefaultBranch != "develop" {
+		t.Errorf("unexpected repository info: %+v", info)
+	}
+
+	info, err = c.GetRepositoryInfo(context.Background(), "My Project/empty")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !info.Empty || !info.Archived || info.Visibility != gitProviderClients.VisibilityPublic {
+		t.Errorf("unexpected repository info: %+v", info)
+	}
+
+	if _, err := c.GetRepositoryInfo(context.Background(), "repo-without-project"); err == nil {
+		t.Error("expected an error for a name without project")
+	}
+}
+
+func TestListRepositories(t *testing.T) {
+	handler := http.NewServeMux()
+	handler.HandleFunc("/org/proj/_apis/git/repositories", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"count": 2, "value": [
+			{"name": "one", "project": {"name": "proj"}},
+			{"name": "disabled", "isDisabled": true, "project": {"name": "proj"}}
+		]}`))
+	})
+	handler.HandleFunc("/org/_apis/git/repositories", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"count": 2, "value": [
+			{"name": "one", "project": {"name": "proj"}},
+			{"name": "two", "project": {"name": "other"}}
+		]}`))
+	})
+
+	srv := httptest.NewServer(handler)
+	defer srv.Close()
+	c := newTestClient(srv.URL)
+
+	repos, err := c.ListRepositories(context.Background(), "proj")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(repos) != 1 || repos[0].FullName != "proj/one" {
+		t.Errorf("unexpected repositories: %+v", repos)
+	}
+
+	repos, err = c.ListRepositories(context.Background(), "")
+	if err != nil || len(repos) != 2 || repos[1].FullName != "other/two" {
+		t.Errorf("unexpected organisation listing: %+v, %v", repos, err)
+	}
+}
+
+func TestRepoWebhookLifecycle(t *testing.T) {
+	fake, srv := newFakeAzure(t)
+	defer srv.Close()
+	c := newTestClient(srv.URL)
+	ctx := context.Background()
+
+	// a subscription of another repository must not show up
+	fake.subscriptions["other"] = azureSubscription{
+		ID:              "other",
+		EventType:       "git.pullrequest.merged",
+		Status:          azureStatusEnabled,
+		PublisherInputs: map[string]string{"projectId": projectID, "repository": "another-repo"},
+		ConsumerInputs:  map[string]string{"url": "https://example.com/webhook"},
+	}
+
+	hook, err := c.CreateRepoWebhook(ctx, "proj/repo1", gitProviderClients.CreateWebhookOptions{
+		URL:       "https://example.com/webhook",
+		AuthToken: "secret",
+		Active:    true,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if hook.ID != "a,b,c" || !hook.EventsUpToDate || !hook.Active {
+		t.Errorf("unexpected created hook: %+v", hook)
+	}
+	for _, id := range []string{"a", "b", "c"} {
+		sub := fake.subscriptions[id]
+		if sub.PublisherID != "tfs" || sub.ConsumerID != "webHooks" || sub.PublisherInputs["repository"] != repoID {
+			t.Errorf("unexpected subscription: %+v", sub)
+		}
+		if sub.ConsumerInputs["httpHeaders"] != "Authorization: Bearer secret" {
+			t.Errorf("expected the token as bearer header, got %q", sub.ConsumerInputs["httpHeaders"])
+		}
+	}
+
+	hooks, err := c.ListRepoWebhooks(ctx, "proj/repo1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(hooks) != 1 || hooks[0].URL != "https://example.com/webhook" || !hooks[0].EventsUpToDate {
+		t.Fatalf("expected one grouped hook, got %+v", hooks)
+	}
+
+	// an extra event subscribed by hand is drift, and is removed on update
+	fake.subscriptions["d"] = azureSubscription{
+		ID:              "d",
+		EventType:       "git.pullrequest.created",
+		Status:          azureStatusEnabled,
+		PublisherInputs: map[string]string{"projectId": projectID, "repository": repoID},
+		ConsumerInputs:  map[string]string{"url": "https://example.com/webhook"},
+	}
+	hooks, _ = c.ListRepoWebhooks(ctx, "proj/repo1")
+	if len(hooks) != 1 || hooks[0].EventsUpToDate {
+		t.Fatalf("expected the extra event to be reported as drift, got %+v", hooks)
+	}
+
+	hook, err = c.UpdateRepoWebhook(ctx, "proj/repo1", hooks[0].ID, gitProviderClients.CreateWebhookOptions{
+		URL:    "https://new.example.com/webhook",
+		Active: true,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !hook.EventsUpToDate || hook.URL != "https://new.example.com/webhook" {
+		t.Errorf("unexpected updated hook: %+v", hook)
+	}
+	if _, ok := fake.subscriptions["d"]; ok {
+		t.Error("expected the extra subscription to be deleted")
+	}
+	if fake.subscriptions["a"].ConsumerInputs["url"] != "https://new.example.com/webhook" {
+		t.Errorf("expected subscription to be updated in place, got %+v", fake.subscriptions["a"])
+	}
+
+	if err := c.DeleteRepoWebhook(ctx, "proj/repo1", hook.ID); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(fake.subscriptions) != 1 {
+		t.Errorf("expected only the other repository's subscription to remain, got %+v", fake.subscriptions)
+	}
+
+	// deleting again is a no-op: the subscriptions are already gone
+	if err := c.DeleteRepoWebhook(ctx, "proj/repo1", hook.ID); err != nil {
+		t.Fatalf("expected nil error for already-deleted webhook, got: %v", err)
+	}
+}
+
+func TestListChangedFiles(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/org/proj/_apis/git/repositories/repo1/diffs/commits" {
+			t.Errorf("unexpected path %q", r.URL.Path)
+		}
+		query := r.URL.Query()
+		if query.Get("baseVersion") != "abc" || query.Get("targetVersion") != "def" || query.Get("api-version") != apiVersion {
+			t.Errorf("unexpected query %q", r.URL.RawQuery)
+		}
+		_, _ = w.Write([]byte(`{"changes": [
+			{"item": {"path": "/src", "isFolder": true}, "changeType": "edit"},
+			{"item": {"path": "/renovate.json"}, "changeType": "edit"},
+			{"item": {"path": "/src/new.csproj"}, "changeType": "rename", "sourceServerItem": "/src/old.csproj"}
+		]}`))
+	}))
+	defer srv.Close()
+
+	files, err := newTestClient(srv.URL).ListChangedFiles(context.Background(), "proj/repo1", "abc", "def")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// folders are skipped and the leading slash is trimmed
+	if want := []string{"renovate.json", "src/new.csproj", "src/old.csproj"}; !slices.Equal(files, want) {
+		t.Errorf("expected %v, got %v", want, files)
+	}
+}
@@ -9,6 +9,7 @@ import (
 	"net/http"
 	"net/url"
 	"strings"
+	"time"
 
 	"renovate-operator/gitProviderClients"
 )
@@ -42,13 +43,124 @@ func (c *BitbucketClient) GetRepositoryInfo(ctx context.Context, project string)
 	}
 
 	var repo struct {
-		Parent *json.RawMessage `json:"parent"`
+		Parent     *json.RawMessage `json:"parent"`
+		IsPrivate  bool             `json:"is_private"`
+		MainBranch *struct {
+			Name string `json:"name"`
+		} `json:"mainbranch"`
+		UpdatedOn *time.Time `json:"updated_on"`
 	}
 	if err := json.NewDecoder(resp.Body).Decode(&repo); err != nil {
 		return gitProviderClients.RepositoryInfo{}, fmt.Errorf("failed to decode bitbucket API response for %s: %w", project, err)
 	}
-	// Bitbucket Cloud has no pending-deletion state.
-	return gitProviderClients.RepositoryInfo{Fork: repo.Parent != nil}, nil
+	info := gitProviderClients.RepositoryInfo{
+		Fork:       repo.Parent != nil,
+		Visibility: gitProviderClients.VisibilityPublic,
+		// A repository without commits has no main branch yet.
+		Empty: repo.MainBranch == nil,
+	}
+	if repo.IsPrivate {
+		info.Visibility = gitProviderClients.VisibilityPrivate
+	}
+	if repo.MainBranch != nil {
+		info.DefaultBranch = repo.MainBranch.Name
+	}
+	// The API exposes no push timestamp; updated_on also moves on pushes.
+	if repo.UpdatedOn != nil {
+		info.LastPushAt = *repo.UpdatedOn
+	}
+	// Bitbucket Cloud has no pending-deletion or archived state.
+	return info, nil
+}
+
+// ListRepositories lists the workspace's repositories, or every repository the
+// token's user is a member of when namespace is empty. Bitbucket Cloud has no
+// archived state or topics.
+func (c *BitbucketClient) ListRepositories(ctx context.Context, namespace string) ([]gitProviderClients.RepositoryListing, error) {
+	path := "/2.0/repositories?role=member&"
+	if namespace != "" {
+		path = fmt.Sprintf("/2.0/repositories/%s?", url.PathEscape(namespace))
+	}
+
+	var listings []gitProviderClients.RepositoryListing
+	page := 1
+	limit := 100
+
+	for {
+		resp, err := c.doRequest(ctx, http.MethodGet, fmt.Sprintf("%spagelen=%d&page=%d", path, limit, page), nil)
+		if err != nil {
+			return nil, fmt.Errorf("listing repositories: %w", err)
+		}
+
+		var result struct {
+			Values []struct {
+				FullName string `json:"full_name"`
+			} `json:"values"`
+			Next string `json:"next"`
+		}
+		if err := decodeResponse(resp, &result); err != nil {
+			return nil, fmt.Errorf("listing repositories: %w", err)
+		}
+
+		for _, repo := range result.Values {
+			listings = append(listings, gitProviderClients.RepositoryListing{FullName: repo.FullName})
+		}
+		if result.Next == "" {
+			break
+		}
+		page++
+	}
+
+	return listings, nil
+}
+
+// ListChangedFiles lists the diffstat between the two commits. Bitbucket
+// Cloud's "head..base" spec diffs head against its merge base with base.
+func (c *BitbucketClient) ListChangedFiles(ctx context.Context, project, base, head string) ([]string, error) {
+	spec := url.PathEscape(head + ".." + base)
+
+	var files []string
+	page := 1
+	limit := 500
+
+	for {
+		path := fmt.Sprintf("/2.0/repositories/%s/diffstat/%s?pagelen=%d&page=%d", project, spec, limit, page)
+		resp, err := c.doRequest(ctx, http.MethodGet, path, nil)
+		if err != nil {
+			return nil, fmt.Errorf("comparing commits: %w", err)
+		}
+
+		var result struct {
+			Values []struct {
+				Old *struct {
+					Path string `json:"path"`
+				} `json:"old"`
+				New *struct {
+					Path string `json:"path"`
+				} `json:"new"`
+			} `json:"values"`
+			Next string `json:"next"`
+		}
+		if err := decodeResponse(resp, &result); err != nil {
+			return nil, fmt.Errorf("comparing commits: %w", err)
+		}
+
+		for _, diff := range result.Values {
+			// old is null for added files, new for removed ones
+			if diff.New != nil {
+				files = append(files, diff.New.Path)
+			}
+			if diff.Old != nil && (diff.New == nil || diff.Old.Path != diff.New.Path) {
+				files = append(files, diff.Old.Path)
+			}
+		}
+		if result.Next == "" {
+			break
+		}
+		page++
+	}
+
+	return files, nil
 }
 
 func (c *BitbucketClient) doRequest(ctx context.Context, method, path string, body io.Reader) (*http.Response, error) {
@@ -77,9 +189,10 @@ type bitbucketHook struct {
 
 // bitbucketWebhookEvents is the fixed subscription for operator-managed hooks:
 // pull request description edits (checkbox interactions), merges, and
-// declines. Bitbucket Cloud has no Dependency Dashboard (Renovate does not
-// support it there), so no issue events are needed.
-var bitbucketWebhookEvents = []string{"pullrequest:updated", "pullrequest:fulfilled", "pullrequest:rejected"}
+// declines, plus pushes for the push trigger. Bitbucket Cloud has no
+// Dependency Dashboard (Renovate does not support it there), so no issue
+// events are needed.
+var bitbucketWebhookEvents = []string{"pullrequest:updated", "pullrequest:fulfilled", "pullrequest:rejected", "repo:push"}
 
 func (h bitbucketHook) toWebhook() gitProviderClients.Webhook {
 	return gitProviderClients.Webhook{
@@ -108,12 +221,49 @@ func eventsEqual(actual, expected []string) bool {
 }
 
 func (c *BitbucketClient) ListRepoWebhooks(ctx context.Context, project string) ([]gitProviderClients.Webhook, error) {
+	return c.listHooks(ctx, fmt.Sprintf("/2.0/repositories/%s/hooks", project))
+}
+
+func (c *BitbucketClient) CreateRepoWebhook(ctx context.Context, project string, opts gitProviderClients.CreateWebhookOptions) (*gitProviderClients.Webhook, error) {
+	return c.createHook(ctx, fmt.Sprintf("/2.0/repositories/%s/hooks", project), opts)
+}
+
+// hook UUIDs include curly braces and must be path-escaped
+
+func (c *BitbucketClient) UpdateRepoWebhook(ctx context.Context, project string, hookID string, opts gitProviderClients.CreateWebhookOptions) (*gitProviderClients.Webhook, error) {
+	return c.updateHook(ctx, fmt.Sprintf("/2.0/repositories/%s/hooks/%s", project, url.PathEscape(hookID)), opts)
+}
+
+func (c *BitbucketClient) DeleteRepoWebhook(ctx context.Context, project string, hookID string) error {
+	return c.deleteHook(ctx, fmt.Sprintf("/2.0/repositories/%s/hooks/%s", project, url.PathEscape(hookID)))
+}
+
+// Workspace hooks receive the events of every repository of the workspace.
+// They take the same payload as repository hooks.
+
+func (c *BitbucketClient) ListOrgWebhooks(ctx context.Context, org string) ([]gitProviderClients.Webhook, error) {
+	return c.listHooks(ctx, fmt.Sprintf("/2.0/workspaces/%s/hooks", url.PathEscape(org)))
+}
+
+func (c *BitbucketClient) CreateOrgWebhook(ctx context.Context, org string, opts gitProviderClients.CreateWebhookOptions) (*gitProviderClients.Webhook, error) {
+	return c.createHook(ctx, fmt.Sprintf("/2.0/workspaces/%s/hooks", url.PathEscape(org)), opts)
+}
+
+func (c *BitbucketClient) UpdateOrgWebhook(ctx context.Context, org string, hookID string, opts gitProviderClients.CreateWebhookOptions) (*gitProviderClients.Webhook, error) {
+	return c.updateHook(ctx, fmt.Sprintf("/2.0/workspaces/%s/hooks/%s", url.PathEscape(org), url.PathEscape(hookID)), opts)
+}
+
+func (c *BitbucketClient) DeleteOrgWebhook(ctx context.Context, org string, hookID string) error {
+	return c.deleteHook(ctx, fmt.Sprintf("/2.0/workspaces/%s/hooks/%s", url.PathEscape(org), url.PathEscape(hookID)))
+}
+
+func (c *BitbucketClient) listHooks(ctx context.Context, hooksPath string) ([]gitProviderClients.Webhook, error) {
 	var allHooks []gitProviderClients.Webhook
 	page := 1
 	limit := 50
 
 	for {
-		path := fmt.Sprintf("/2.0/repositories/%s/hooks?pagelen=%d&page=%d", project, limit, page)
+		path := fmt.Sprintf("%s?pagelen=%d&page=%d", hooksPath, limit, page)
 		resp, err := c.doRequest(ctx, http.MethodGet, path, nil)
 		if err != nil {
 			return nil, fmt.Errorf("listing webhooks: %w", err)
@@ -139,7 +289,7 @@ func (c *BitbucketClient) ListRepoWebhooks(ctx context.Context, project string)
 	return allHooks, nil
 }
 
-func (c *BitbucketClient) CreateRepoWebhook(ctx context.Context, project string, opts gitProviderClients.CreateWebhookOptions) (*gitProviderClients.Webhook, error) {
+func (c *BitbucketClient) createHook(ctx context.Context, hooksPath string, opts gitProviderClients.CreateWebhookOptions) (*gitProviderClients.Webhook, error) {
 	payload := bitbucketHook{
 		URL:         opts.URL,
 		Description: "renovate-operator",
@@ -153,7 +303,7 @@ func (c *BitbucketClient) CreateRepoWebhook(ctx context.Context, project string,
 		return nil, fmt.Errorf("marshalling webhook options: %w", err)
 	}
 
-	resp, err := c.doRequest(ctx, http.MethodPost, fmt.Sprintf("/2.0/repositories/%s/hooks", project), bytes.NewReader(body))
+	resp, err := c.doRequest(ctx, http.MethodPost, hooksPath, bytes.NewReader(body))
 	if err != nil {
 		return nil, fmt.Errorf("creating webhook: %w", err)
 	}
@@ -166,7 +316,7 @@ func (c *BitbucketClient) CreateRepoWebhook(ctx context.Context, project string,
 	return &result, nil
 }
 
-func (c *BitbucketClient) UpdateRepoWebhook(ctx context.Context, project string, hookID string, opts gitProviderClients.CreateWebhookOptions) (*gitProviderClients.Webhook, error) {
+func (c *BitbucketClient) updateHook(ctx context.Context, hookPath string, opts gitProviderClients.CreateWebhookOptions) (*gitProviderClients.Webhook, error) {
 	payload := bitbucketHook{
 		URL:         opts.URL,
 		Description: "renovate-operator",
@@ -180,9 +330,7 @@ func (c *BitbucketClient) UpdateRepoWebhook(ctx context.Context, project string,
 		return nil, fmt.Errorf("marshalling webhook options: %w", err)
 	}
 
-	// hook UUIDs include curly braces and must be path-escaped
-	path := fmt.Sprintf("/2.0/repositories/%s/hooks/%s", project, url.PathEscape(hookID))
-	resp, err := c.doRequest(ctx, http.MethodPut, path, bytes.NewReader(body))
+	resp, err := c.doRequest(ctx, http.MethodPut, hookPath, bytes.NewReader(body))
 	if err != nil {
 		return nil, fmt.Errorf("updating webhook: %w", err)
 	}
@@ -195,9 +343,8 @@ func (c *BitbucketClient) UpdateRepoWebhook(ctx context.Context, project string,
 	return &result, nil
 }
 
-func (c *BitbucketClient) DeleteRepoWebhook(ctx context.Context, project string, hookID string) error {
-	path := fmt.Sprintf("/2.0/repositories/%s/hooks/%s", project, url.PathEscape(hookID))
-	resp, err := c.doRequest(ctx, http.MethodDelete, path, nil)
+func (c *BitbucketClient) deleteHook(ctx context.Context, hookPath string) error {
+	resp, err := c.doRequest(ctx, http.MethodDelete, hookPath, nil)
 	if err != nil {
 		return fmt.Errorf("deleting webhook: %w", err)
 	}
@@ -5,7 +5,9 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"slices"
 	"testing"
+	"time"
 
 	"renovate-operator/gitProviderClients"
 )
@@ -16,6 +18,39 @@ func newTestClient(url string) *BitbucketClient {
 	return &BitbucketClient{Endpoint: url, Token: "test-token", HTTPClient: http.DefaultClient}
 }
 
+func TestGetRepositoryInfo(t *testing.T) {
+	handler := http.NewServeMux()
+	handler.HandleFunc("/2.0/repositories/ws/repo1", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"parent": {"full_name": "other/repo1"}, "is_private": true, "mainbranch": {"name": "develop"}, "updated_on": "2026-03-01T10:00:00.123456+00:00"}`))
+	})
+	handler.HandleFunc("/2.0/repositories/ws/empty", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"is_private": false, "mainbranch": null}`))
+	})
+
+	srv := httptest.NewServer(handler)
+	defer srv.Close()
+	c := newTestClient(srv.URL)
+
+	info, err := c.GetRepositoryInfo(context.Background(), "ws/repo1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !info.Fork || info.Empty || info.Visibility != gitProviderClients.VisibilityPrivate || info.DefaultBranch != "develop" {
+		t.Errorf("unexpected repository info: %+v", info)
+	}
+	if !info.LastPushAt.Equal(time.Date(2026, 3, 1, 10, 0, 0, 123456000, time.UTC)) {
+		t.Errorf("unexpected last push time: %v", info.LastPushAt)
+	}
+
+	info, err = c.GetRepositoryInfo(context.Background(), "ws/empty")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !info.Empty || info.Visibility != gitProviderClients.VisibilityPublic {
+		t.Errorf("unexpected repository info: %+v", info)
+	}
+}
+
 func TestListRepoWebhooks(t *testing.T) {
 	handler := http.NewServeMux()
 	handler.HandleFunc("/2.0/repositories/ws/repo1/hooks", func(w http.ResponseWriter, r *http.Request) {
@@ -24,7 +59,7 @@ func TestListRepoWebhooks(t *testing.T) {
 		}
 		_ = json.NewEncoder(w).Encode(map[string]any{
 			"values": []bitbucketHook{
-				{UUID: hookUUID, URL: "https://example.com/webhook", Active: true, Events: []string{"pullrequest:updated", "pullrequest:fulfilled", "pullrequest:rejected"}},
+				{UUID: hookUUID, URL: "https://example.com/webhook", Active: true, Events: []string{"pullrequest:updated", "pullrequest:fulfilled", "pullrequest:rejected", "repo:push"}},
 			},
 		})
 	})
@@ -42,9 +77,9 @@ func TestListRepoWebhooks(t *testing.T) {
 	if hooks[0].ID != hookUUID {
 		t.Errorf("expected UUID hook ID, got %s", hooks[0].ID)
 	}
-	// exactly the fixed PR events means the subscription is up to date
+	// exactly the fixed PR and push events means the subscription is up to date
 	if !hooks[0].EventsUpToDate {
-		t.Error("expected hook with the fixed PR events to report EventsUpToDate")
+		t.Error("expected hook with the fixed events to report EventsUpToDate")
 	}
 }
 
@@ -64,8 +99,8 @@ func TestCreateRepoWebhook(t *testing.T) {
 			t.Errorf("expected hook secret, got %s", payload.Secret)
 		}
 		// the provider applies its fixed subscription
-		if len(payload.Events) != 3 || payload.Events[0] != "pullrequest:updated" {
-			t.Errorf("expected the fixed PR subscription, got %v", payload.Events)
+		if len(payload.Events) != 4 || payload.Events[0] != "pullrequest:updated" || payload.Events[3] != "repo:push" {
+			t.Errorf("expected the fixed subscription, got %v", payload.Events)
 		}
 
 		w.WriteHeader(http.StatusCreated)
@@ -102,8 +137,8 @@ func TestUpdateRepoWebhook(t *testing.T) {
 
 		var payload bitbucketHook
 		_ = json.NewDecoder(r.Body).Decode(&payload)
-		if len(payload.Events) != 3 {
-			t.Errorf("expected the fixed PR subscription, got %v", payload.Events)
+		if len(payload.Events) != 4 {
+			t.Errorf("expected the fixed subscription, got %v", payload.Events)
 		}
 
 		payload.UUID = hookUUID
@@ -148,3 +183,109 @@ func TestDeleteRepoWebhook_NotFoundIsSuccess(t *testing.T) {
 		t.Fatalf("expected nil error for already-deleted webhook, got: %v", err)
 	}
 }
+
+func TestListRepositories_FollowsNextPage(t *testing.T) {
+	var srvURL string
+	handler := http.NewServeMux()
+	handler.HandleFunc("/2.0/repositories/workspace", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("page") == "1" {
+			_ = json.NewEncoder(w).Encode(map[string]any{
+				"values": []map[string]string{{"full_name": "workspace/one"}},
+				"next":   srvURL + "/2.0/repositories/workspace?page=2",
+			})
+			return
+		}
+		_, _ = w.Write([]byte(`{"values": [{"full_name": "workspace/two"}]}`))
+	})
+	handler.HandleFunc("/2.0/repositories", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("role") != "member" {
+			t.Errorf("expected member repositories, got %s", r.URL.RawQuery)
+		}
+		_, _ = w.Write([]byte(`{"values": [{"full_name": "workspace/member"}]}`))
+	})
+
+	srv := httptest.NewServer(handler)
+	defer srv.Close()
+	srvURL = srv.URL
+	c := newTestClient(srv.URL)
+
+	repos, err := c.ListRepositories(context.Background(), "workspace")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(repos) != 2 || repos[0].FullName != "workspace/one" || repos[1].FullName != "workspace/two" {
+		t.Errorf("unexpected repositories: %+v", repos)
+	}
+
+	repos, err = c.ListRepositories(context.Background(), "")
+	if err != nil || len(repos) != 1 || repos[0].FullName != "workspace/member" {
+		t.Errorf("unexpected member listing: %+v, %v", repos, err)
+	}
+}
+
+func TestListChangedFiles_FollowsNextPage(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// head..base diffs head against its merge base with base
+		if r.URL.Path != "/2.0/repositories/ws/repo1/diffstat/def..abc" {
+			t.Errorf("unexpected path %q", r.URL.Path)
+		}
+		if r.URL.Query().Get("page") == "1" {
+			_, _ = w.Write([]byte(`{"values": [{"old": null, "new": {"path": "renovate.json"}}, {"old": {"path": "a.txt"}, "new": {"path": "b.txt"}}], "next": "page2"}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"values": [{"old": {"path": "go.sum"}, "new": null}]}`))
+	}))
+	defer srv.Close()
+
+	files, err := newTestClient(srv.URL).ListChangedFiles(context.Background(), "ws/repo1", "abc", "def")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := []string{"renovate.json", "b.txt", "a.txt", "go.sum"}; !slices.Equal(files, want) {
+		t.Errorf("expected %v, got %v", want, files)
+	}
+}
+
+func TestOrgWebhooks(t *testing.T) {
+	var requests []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.EscapedPath())
+		switch r.Method {
+		case http.MethodGet:
+			_, _ = w.Write([]byte(`{"values":[{"uuid":"{abc}","url":"https://example.com/webhook","active":true}]}`))
+		case http.MethodDelete:
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			_, _ = w.Write([]byte(`{"uuid":"{abc}"}`))
+		}
+	}))
+	defer srv.Close()
+
+	c := newTestClient(srv.URL)
+	ctx := context.Background()
+	opts := gitProviderClients.CreateWebhookOptions{URL: "https://example.com/webhook", AuthToken: "secret", Active: true}
+
+	hooks, err := c.ListOrgWebhooks(ctx, "ws")
+	if err != nil || len(hooks) != 1 || hooks[0].URL != "https://example.com/webhook" {
+		t.Fatalf("unexpected hooks %+v: %v", hooks, err)
+	}
+	if _, err := c.CreateOrgWebhook(ctx, "ws", opts); err != nil {
+		t.Fatalf("unexpected error creating: %v", err)
+	}
+	if _, err := c.UpdateOrgWebhook(ctx, "ws", "{abc}", opts); err != nil {
+		t.Fatalf("unexpected error updating: %v", err)
+	}
+	if err := c.DeleteOrgWebhook(ctx, "ws", "{abc}"); err != nil {
+		t.Fatalf("unexpected error deleting: %v", err)
+	}
+
+	want := []string{
+		"GET /2.0/workspaces/ws/hooks",
+		"POST /2.0/workspaces/ws/hooks",
+		"PUT /2.0/workspaces/ws/hooks/%7Babc%7D",
+		"DELETE /2.0/workspaces/ws/hooks/%7Babc%7D",
+	}
+	if !slices.Equal(requests, want) {
+		t.Errorf("expected requests %v, got %v", want, requests)
+	}
+}
@@ -0,0 +1,413 @@
+package bitbucketServerProvider
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"renovate-operator/gitProviderClients"
+)
+
+// BitbucketServerClient implements GitProviderClient for the Bitbucket Server
+// and Data Center REST API (/rest/api/1.0). Projects are named
+// "{projectKey}/{repositorySlug}" as in Renovate.
+type BitbucketServerClient struct {
+	Endpoint   string
+	Token      string
+	HTTPClient *http.Client
+}
+
+func (c *BitbucketServerClient) GetRepositoryInfo(ctx context.Context, project string) (gitProviderClients.RepositoryInfo, error) {
+	path, err := repoPath(project)
+	if err != nil {
+		return gitProviderClients.RepositoryInfo{}, err
+	}
+
+	// Bitbucket Server API: GET /rest/api/1.0/projects/{projectKey}/repos/{repositorySlug}
+	resp, err := c.doRequest(ctx, http.MethodGet, path, nil)
+	if err != nil {
+		return gitProviderClients.RepositoryInfo{}, err
+	}
+
+	var repo struct {
+		Origin   *json.RawMessage `json:"origin"`
+		Public   bool             `json:"public"`
+		Archived bool             `json:"archived"`
+	}
+	if err := decodeResponse(resp, &repo); err != nil {
+		return gitProviderClients.RepositoryInfo{}, fmt.Errorf("bitbucket server API request for %s failed: %w", project, err)
+	}
+	info := gitProviderClients.RepositoryInfo{
+		Fork:       repo.Origin != nil,
+		Archived:   repo.Archived,
+		Visibility: gitProviderClients.VisibilityPrivate,
+	}
+	if repo.Public {
+		info.Visibility = gitProviderClients.VisibilityPublic
+	}
+
+	// The default branch is a resource of its own. A repository without
+	// commits has none.
+	resp, err = c.doRequest(ctx, http.MethodGet, path+"/default-branch", nil)
+	if err != nil {
+		return gitProviderClients.RepositoryInfo{}, err
+	}
+	if resp.StatusCode == http.StatusNoContent || resp.StatusCode == http.StatusNotFound {
+		_ = resp.Body.Close()
+		info.Empty = true
+		return info, nil
+	}
+	var branch struct {
+		DisplayID string `json:"displayId"`
+	}
+	if err := decodeResponse(resp, &branch); err != nil {
+		return gitProviderClients.RepositoryInfo{}, fmt.Errorf("bitbucket server API request for the default branch of %s failed: %w", project, err)
+	}
+	info.DefaultBranch = branch.DisplayID
+	// Bitbucket Server exposes no push timestamp and has no pending-deletion
+	// state.
+	return info, nil
+}
+
+// ListRepositories lists the repositories of the given project key (or of a
+// user's personal project, "~username"), or every repository the token may
+// write to when namespace is empty, as Renovate's autodiscovery does.
+// Bitbucket Server has no topics.
+func (c *BitbucketServerClient) ListRepositories(ctx context.Context, namespace string) ([]gitProviderClients.RepositoryListing, error) {
+	path := "/rest/api/1.0/repos?permission=REPO_WRITE&state=AVAILABLE"
+	if namespace != "" {
+		path = fmt.Sprintf("/rest/api/1.0/projects/%s/repos", url.PathEscape(namespace))
+	}
+	return c.listRepositoryPages(ctx, path)
+}
+
+// listRepositoryPages fetches every page of a repository listing. Bitbucket
+// Server pages by start offset rather than page number.
+func (c *BitbucketServerClient) listRepositoryPages(ctx context.Context, path string) ([]gitProviderClients.RepositoryListing, error) {
+	var listings []gitProviderClients.RepositoryListing
+	start := 0
+	limit := 100
+	separator := "?"
+	if strings.Contains(path, "?") {
+		separator = "&"
+	}
+
+	for {
+		resp, err := c.doRequest(ctx, http.MethodGet, fmt.Sprintf("%s%slimit=%d&start=%d", path, separator, limit, start), nil)
+		if err != nil {
+			return nil, fmt.Errorf("listing repositories: %w", err)
+		}
+
+		var result struct {
+			Values []struct {
+				Slug     string `json:"slug"`
+				Archived bool   `json:"archived"`
+				Project  struct {
+					Key string `json:"key"`
+				} `json:"project"`
+			} `json:"values"`
+			IsLastPage    bool `json:"isLastPage"`
+			NextPageStart int  `json:"nextPageStart"`
+		}
+		if err := decodeResponse(resp, &result); err != nil {
+			return nil, fmt.Errorf("listing repositories: %w", err)
+		}
+
+		for _, repo := range result.Values {
+			if repo.Archived {
+				continue
+			}
+			listings = append(listings, gitProviderClients.RepositoryListing{FullName: repo.Project.Key + "/" + repo.Slug})
+		}
+		if result.IsLastPage || len(result.Values) == 0 {
+			break
+		}
+		start = result.NextPageStart
+	}
+
+	return listings, nil
+}
+
+// ListChangedFiles lists the changes between the two commits, as shown for a
+// push in the repository's activity.
+func (c *BitbucketServerClient) ListChangedFiles(ctx context.Context, project, base, head string) ([]string, error) {
+	repo, err := repoPath(project)
+	if err != nil {
+		return nil, fmt.Errorf("comparing commits: %w", err)
+	}
+
+	var files []string
+	start := 0
+	limit := 500
+
+	for {
+		path := fmt.Sprintf("%s/changes?since=%s&until=%s&limit=%d&start=%d", repo, url.QueryEscape(base), url.QueryEscape(head), limit, start)
+		resp, err := c.doRequest(ctx, http.MethodGet, path, nil)
+		if err != nil {
+			return nil, fmt.Errorf("comparing commits: %w", err)
+		}
+
+		var result struct {
+			Values []struct {
+				Path struct {
+					ToString string `json:"toString"`
+				} `json:"path"`
+				SrcPath *struct {
+					ToString string `json:"toString"`
+				} `json:"srcPath"`
+			} `json:"values"`
+			IsLastPage    bool `json:"isLastPage"`
+			NextPageStart int  `json:"nextPageStart"`
+		}
+		if err := decodeResponse(resp, &result); err != nil {
+			return nil, fmt.Errorf("comparing commits: %w", err)
+		}
+
+		for _, change := range result.Values {
+			files = append(files, change.Path.ToString)
+			// srcPath is only set for moves and copies
+			if change.SrcPath != nil {
+				files = append(files, change.SrcPath.ToString)
+			}
+		}
+		if result.IsLastPage || len(result.Values) == 0 {
+			break
+		}
+		start = result.NextPageStart
+	}
+
+	return files, nil
+}
+
+// repoPath returns the REST path of a "{projectKey}/{repositorySlug}" project.
+func repoPath(project string) (string, error) {
+	key, slug, ok := strings.Cut(project, "/")
+	if !ok || key == "" || slug == "" {
+		return "", fmt.Errorf("invalid bitbucket server repository %q: expected {projectKey}/{repositorySlug}", project)
+	}
+	return fmt.Sprintf("/rest/api/1.0/projects/%s/repos/%s", url.PathEscape(key), url.PathEscape(slug)), nil
+}
+
+func (c *BitbucketServerClient) doRequest(ctx context.Context, method, path string, body io.Reader) (*http.Response, error) {
+	// Renovate's endpoint is the server's base URL; tolerate one that already
+	// includes the REST prefix
+	endpoint := strings.TrimSuffix(c.Endpoint, "/")
+	endpoint = strings.TrimSuffix(endpoint, "/rest/api/1.0")
+
+	req, err := http.NewRequestWithContext(ctx, method, endpoint+path, body)
+	if err != nil {
+		return nil, fmt.Errorf("creating request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+c.Token)
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	return c.HTTPClient.Do(req)
+}
+
+// wire format of the Bitbucket Server repository webhooks API. The secret is
+// write-only; Bitbucket Server uses it to sign each delivery into the
+// X-Hub-Signature header.
+type bitbucketServerHook struct {
+	ID            int64                      `json:"id,omitempty"`
+	Name          string                     `json:"name"`
+	URL           string                     `json:"url"`
+	Active        bool                       `json:"active"`
+	Events        []string                   `json:"events"`
+	Configuration *bitbucketServerHookConfig `json:"configuration,omitempty"`
+}
+
+type bitbucketServerHookConfig struct {
+	Secret string `json:"secret,omitempty"`
+}
+
+// bitbucketServerWebhookEvents is the fixed subscription for operator-managed
+// hooks: pull request edits (checkbox interactions), merges and declines,
+// plus ref changes (pushes) for the push trigger. Bitbucket Server has no
+// issues, so there is no Dependency Dashboard to listen to.
+var bitbucketServerWebhookEvents = []string{"pr:modified", "pr:merged", "pr:declined", "repo:refs_changed"}
+
+func (h bitbucketServerHook) toWebhook() gitProviderClients.Webhook {
+	return gitProviderClients.Webhook{
+		ID:             strconv.FormatInt(h.ID, 10),
+		URL:            h.URL,
+		Active:         h.Active,
+		EventsUpToDate: eventsEqual(h.Events, bitbucketServerWebhookEvents),
+	}
+}
+
+// eventsEqual compares two event name lists as sets.
+func eventsEqual(actual, expected []string) bool {
+	if len(actual) != len(expected) {
+		return false
+	}
+	set := make(map[string]struct{}, len(expected))
+	for _, event := range expected {
+		set[event] = struct{}{}
+	}
+	for _, event := range actual {
+		if _, ok := set[event]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+func newHook(opts gitProviderClients.CreateWebhookOptions) bitbucketServerHook {
+	hook := bitbucketServerHook{
+		Name:   "renovate-operator",
+		URL:    opts.URL,
+		Active: opts.Active,
+		Events: bitbucketServerWebhookEvents,
+	}
+	if opts.AuthToken != "" {
+		hook.Configuration = &bitbucketServerHookConfig{Secret: opts.AuthToken}
+	}
+	return hook
+}
+
+func (c *BitbucketServerClient) ListRepoWebhooks(ctx context.Context, project string) ([]gitProviderClients.Webhook, error) {
+	base, err := repoPath(project)
+	if err != nil {
+		return nil, fmt.Errorf("listing webhooks: %w", err)
+	}
+
+	var allHooks []gitProviderClients.Webhook
+	start := 0
+	limit := 50
+
+	for {
+		resp, err := c.doRequest(ctx, http.MethodGet, fmt.Sprintf("%s/webhooks?limit=%d&start=%d", base, limit, start), nil)
+		if err != nil {
+			return nil, fmt.Errorf("listing webhooks: %w", err)
+		}
+
+		var result struct {
+			Values        []bitbucketServerHook `json:"values"`
+			IsLastPage    bool                  `json:"isLastPage"`
+			NextPageStart int                   `json:"nextPageStart"`
+		}
+		if err := decodeResponse(resp, &result); err != nil {
+			return nil, fmt.Errorf("listing webhooks: %w", err)
+		}
+
+		for _, hook := range result.Values {
+			allHooks = append(allHooks, hook.toWebhook())
+		}
+		if result.IsLastPage || len(result.Values) == 0 {
+			break
+		}
+		start = result.NextPageStart
+	}
+
+	return allHooks, nil
+}
+
+func (c *BitbucketServerClient) CreateRepoWebhook(ctx context.Context, project string, opts gitProviderClients.CreateWebhookOptions) (*gitProviderClients.Webhook, error) {
+	base, err := repoPath(project)
+	if err != nil {
+		return nil, fmt.Errorf("creating webhook: %w", err)
+	}
+
+	body, err := json.Marshal(newHook(opts))
+	if err != nil {
+		return nil, fmt.Errorf("marshalling webhook options: %w", err)
+	}
+
+	resp, err := c.doRequest(ctx, http.MethodPost, base+"/webhooks", bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("creating webhook: %w", err)
+	}
+
+	var hook bitbucketServerHook
+	if err := decodeResponse(resp, &hook); err != nil {
+		return nil, fmt.Errorf("creating webhook: %w", err)
+	}
+	result := hook.toWebhook()
+	return &result, nil
+}
+
+func (c *BitbucketServerClient) UpdateRepoWebhook(ctx context.Context, project string, hookID string, opts gitProviderClients.CreateWebhookOptions) (*gitProviderClients.Webhook, error) {
+	base, err := repoPath(project)
+	if err != nil {
+		return nil, fmt.Errorf("updating webhook: %w", err)
+	}
+
+	body, err := json.Marshal(newHook(opts))
+	if err != nil {
+		return nil, fmt.Errorf("marshalling webhook options: %w", err)
+	}
+
+	resp, err := c.doRequest(ctx, http.MethodPut, fmt.Sprintf("%s/webhooks/%s", base, url.PathEscape(hookID)), bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("updating webhook: %w", err)
+	}
+
+	var hook bitbucketServerHook
+	if err := decodeResponse(resp, &hook); err != nil {
+		return nil, fmt.Errorf("updating webhook: %w", err)
+	}
+	result := hook.toWebhook()
+	return &result, nil
+}
+
+func (c *BitbucketServerClient) DeleteRepoWebhook(ctx context.Context, project string, hookID string) error {
+	base, err := repoPath(project)
+	if err != nil {
+		return fmt.Errorf("deleting webhook: %w", err)
+	}
+
+	resp, err := c.doRequest(ctx, http.MethodDelete, fmt.Sprintf("%s/webhooks/%s", base, url.PathEscape(hookID)), nil)
+	if err != nil {
+		return fmt.Errorf("deleting webhook: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	// A missing webhook is the desired end state, so treat 404 as success.
+	if resp.StatusCode == http.StatusNotFound {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		return nil
+	}
+	if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("unexpected status %d: %s", resp.StatusCode, string(body))
+	}
+	return nil
+}
+
+// Bitbucket Server has no webhooks above the repository level that the
+// operator manages.
+
+func (c *BitbucketServerClient) ListOrgWebhooks(ctx context.Context, org string) ([]gitProviderClients.Webhook, error) {
+	return nil, gitProviderClients.ErrOrgWebhooksUnsupported
+}
+
+func (c *BitbucketServerClient) CreateOrgWebhook(ctx context.Context, org string, opts gitProviderClients.CreateWebhookOptions) (*gitProviderClients.Webhook, error) {
+	return nil, gitProviderClients.ErrOrgWebhooksUnsupported
+}
+
+func (c *BitbucketServerClient) UpdateOrgWebhook(ctx context.Context, org string, hookID string, opts gitProviderClients.CreateWebhookOptions) (*gitProviderClients.Webhook, error) {
+	return nil, gitProviderClients.ErrOrgWebhooksUnsupported
+}
+
+func (c *BitbucketServerClient) DeleteOrgWebhook(ctx context.Context, org string, hookID string) error {
+	return gitProviderClients.ErrOrgWebhooksUnsupported
+}
+
+func decodeResponse(resp *http.Response, target any) error {
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("unexpected status %d: %s", resp.StatusCode, string(body))
+	}
+
+	return json.NewDecoder(resp.Body).Decode(target)
+}
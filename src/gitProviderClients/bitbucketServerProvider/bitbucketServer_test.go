@@ -0,0 +1,267 @@
+package bitbucketServerProvider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"sync"
+	"testing"
+
+	"renovate-operator/gitProviderClients"
+)
+
+func newTestClient(url string) *BitbucketServerClient {
+	return &BitbucketServerClient{Endpoint: url, Token: "test-token", HTTPClient: http.DefaultClient}
+}
+
+// fakeBitbucketServer is an in-memory Bitbucket Data Center serving the
+// webhooks of one repository, PRJ/repo1, paged two at a time.
+type fakeBitbucketServer struct {
+	t      *testing.T
+	mu     sync.Mutex
+	hooks  map[int64]bitbucketServerHook
+	nextID int64
+}
+
+func newFakeBitbucketServer(t *testing.T) (*fakeBitbucketServer, *httptest.Server) {
+	f := &fakeBitbucketServer{t: t, hooks: map[int64]bitbucketServerHook{}}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/rest/api/1.0/projects/PRJ/repos/repo1/webhooks", f.handleCollection)
+	mux.HandleFunc("/rest/api/1.0/projects/PRJ/repos/repo1/webhooks/{id}", f.handleItem)
+	return f, httptest.NewServer(mux)
+}
+
+func (f *fakeBitbucketServer) checkAuth(r *http.Request) {
+	if r.Header.Get("Authorization") != "Bearer test-token" {
+		f.t.Errorf("expected bearer auth, got %q", r.Header.Get("Authorization"))
+	}
+}
+
+func (f *fakeBitbucketServer) handleCollection(w http.ResponseWriter, r *http.Request) {
+	f.checkAuth(r)
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	switch r.Method {
+	case http.MethodGet:
+		start, _ := strconv.Atoi(r.URL.Query().Get("start"))
+		var ids []int64
+		for id := int64(1); id <= f.nextID; id++ {
+			if _, ok := f.hooks[id]; ok {
+				ids = append(ids, id)
+			}
+		}
+		end := min(start+2, len(ids))
+		values := []bitbucketServerHook{}
+		for _, id := range ids[start:end] {
+			values = append(values, f.hooks[id])
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"values":        values,
+			"isLastPage":    end == len(ids),
+			"nextPageStart": end,
+		})
+	case http.MethodPost:
+		var hook bitbucketServerHook
+		_ = json.NewDecoder(r.Body).Decode(&hook)
+		f.nextID++
+		hook.ID = f.nextID
+		f.hooks[hook.ID] = hook
+		w.WriteHeader(http.StatusCreated)
+		hook.Configuration = nil // the secret is never returned
+		_ = json.NewEncoder(w).Encode(hook)
+	}
+}
+
+func (f *fakeBitbucketServer) handleItem(w http.ResponseWriter, r *http.Request) {
+	f.checkAuth(r)
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	id, _ := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if _, ok := f.hooks[id]; !ok {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	switch r.Method {
+	case http.MethodPut:
+		var hook bitbucketServerHook
+		_ = json.NewDecoder(r.Body).Decode(&hook)
+		hook.ID = id
+		f.hooks[id] = hook
+		_ = json.NewEncoder(w).Encode(hook)
+	case http.MethodDelete:
+		delete(f.hooks, id)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func TestGetRepositoryInfo(t *testing.T) {
+	handler := http.NewServeMux()
+	handler.HandleFunc("/rest/api/1.0/projects/PRJ/repos/fork", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"slug": "fork", "public": false, "archived": true, "origin": {"slug": "upstream"}}`))
+	})
+	handler.HandleFunc("/rest/api/1.0/projects/PRJ/repos/public", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"slug": "public", "public": true}`))
+	})
+	handler.HandleFunc("/rest/api/1.0/projects/PRJ/repos/public/default-branch", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"id": "refs/heads/main", "displayId": "main", "type": "BRANCH"}`))
+	})
+	handler.HandleFunc("/rest/api/1.0/projects/PRJ/repos/fork/default-branch", func(w http.ResponseWriter, r *http.Request) {
+		// a repository without commits has no default branch
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	srv := httptest.NewServer(handler)
+	defer srv.Close()
+	// the REST prefix in the endpoint is tolerated
+	c := newTestClient(srv.URL + "/rest/api/1.0/")
+
+	info, err := c.GetRepositoryInfo(context.Background(), "PRJ/fork")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !info.Fork || !info.Archived || !info.Empty || info.Visibility != gitProviderClients.VisibilityPrivate {
+		t.Errorf("unexpected repository info: %+v", info)
+	}
+
+	info, err = c.GetRepositoryInfo(context.Background(), "PRJ/public")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if info.Fork || info.Archived || info.Empty || info.Visibility != gitProviderClients.VisibilityPublic || info.DefaultBranch != "main" {
+		t.Errorf("unexpected repository info: %+v", info)
+	}
+
+	if _, err := c.GetRepositoryInfo(context.Background(), "no-project"); err == nil {
+		t.Error("expected an error for a name without project key")
+	}
+}
+
+func TestListRepositories_FollowsNextPageStart(t *testing.T) {
+	handler := http.NewServeMux()
+	handler.HandleFunc("/rest/api/1.0/projects/PRJ/repos", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("start") == "0" {
+			_, _ = w.Write([]byte(`{"values": [{"slug": "one", "project": {"key": "PRJ"}}, {"slug": "old", "archived": true, "project": {"key": "PRJ"}}], "isLastPage": false, "nextPageStart": 2}`))
+			return
+		}
+		if r.URL.Query().Get("start") != "2" {
+			t.Errorf("expected the next page to start at 2, got %s", r.URL.RawQuery)
+		}
+		_, _ = w.Write([]byte(`{"values": [{"slug": "two", "project": {"key": "PRJ"}}], "isLastPage": true}`))
+	})
+	handler.HandleFunc("/rest/api/1.0/repos", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("permission") != "REPO_WRITE" {
+			t.Errorf("expected writable repositories, got %s", r.URL.RawQuery)
+		}
+		_, _ = w.Write([]byte(`{"values": [{"slug": "mine", "project": {"key": "~jdoe"}}], "isLastPage": true}`))
+	})
+
+	srv := httptest.NewServer(handler)
+	defer srv.Close()
+	c := newTestClient(srv.URL)
+
+	repos, err := c.ListRepositories(context.Background(), "PRJ")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(repos) != 2 || repos[0].FullName != "PRJ/one" || repos[1].FullName != "PRJ/two" {
+		t.Errorf("unexpected repositories: %+v", repos)
+	}
+
+	repos, err = c.ListRepositories(context.Background(), "")
+	if err != nil || len(repos) != 1 || repos[0].FullName != "~jdoe/mine" {
+		t.Errorf("unexpected listing: %+v, %v", repos, err)
+	}
+
+	if _, err := c.ListRepositories(context.Background(), "UNKNOWN"); err == nil {
+		t.Error("expected an error for an unknown project")
+	}
+}
+
+func TestRepoWebhookLifecycle(t *testing.T) {
+	fake, srv := newFakeBitbucketServer(t)
+	defer srv.Close()
+	c := newTestClient(srv.URL)
+	ctx := context.Background()
+
+	// fill more than one page with hooks that are not the operator's
+	for i := range 3 {
+		fake.nextID++
+		fake.hooks[fake.nextID] = bitbucketServerHook{ID: fake.nextID, URL: fmt.Sprintf("https://other.example.com/%d", i), Events: []string{"repo:refs_changed"}}
+	}
+
+	hook, err := c.CreateRepoWebhook(ctx, "PRJ/repo1", gitProviderClients.CreateWebhookOptions{
+		URL:       "https://example.com/webhook",
+		AuthToken: "secret",
+		Active:    true,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if hook.ID != "4" || !hook.EventsUpToDate {
+		t.Errorf("unexpected created hook: %+v", hook)
+	}
+	created := fake.hooks[4]
+	if created.Configuration == nil || created.Configuration.Secret != "secret" {
+		t.Errorf("expected the token as hook secret, got %+v", created.Configuration)
+	}
+
+	hooks, err := c.ListRepoWebhooks(ctx, "PRJ/repo1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(hooks) != 4 || hooks[3].URL != "https://example.com/webhook" || !hooks[3].EventsUpToDate || hooks[0].EventsUpToDate {
+		t.Fatalf("expected all hooks across pages, got %+v", hooks)
+	}
+
+	hook, err = c.UpdateRepoWebhook(ctx, "PRJ/repo1", "4", gitProviderClients.CreateWebhookOptions{
+		URL:    "https://new.example.com/webhook",
+		Active: true,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if hook.ID != "4" || fake.hooks[4].URL != "https://new.example.com/webhook" {
+		t.Errorf("expected the hook to be updated in place, got %+v", fake.hooks[4])
+	}
+
+	if err := c.DeleteRepoWebhook(ctx, "PRJ/repo1", "4"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, ok := fake.hooks[4]; ok {
+		t.Error("expected the hook to be deleted")
+	}
+	if err := c.DeleteRepoWebhook(ctx, "PRJ/repo1", "4"); err != nil {
+		t.Fatalf("expected nil error for already-deleted webhook, got: %v", err)
+	}
+}
+
+func TestListChangedFiles_FollowsNextPageStart(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/rest/api/1.0/projects/PRJ/repos/repo1/changes" {
+			t.Errorf("unexpected path %q", r.URL.Path)
+		}
+		if r.URL.Query().Get("since") != "abc" || r.URL.Query().Get("until") != "def" {
+			t.Errorf("unexpected query %q", r.URL.RawQuery)
+		}
+		if r.URL.Query().Get("start") == "0" {
+			_, _ = w.Write([]byte(`{"values": [{"path": {"toString": "renovate.json"}}, {"path": {"toString": "b/pom.xml"}, "srcPath": {"toString": "a/pom.xml"}}], "isLastPage": false, "nextPageStart": 2}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"values": [{"path": {"toString": "go.sum"}}], "isLastPage": true}`))
+	}))
+	defer srv.Close()
+
+	files, err := newTestClient(srv.URL).ListChangedFiles(context.Background(), "PRJ/repo1", "abc", "def")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := []string{"renovate.json", "b/pom.xml", "a/pom.xml", "go.sum"}; !slices.Equal(files, want) {
+		t.Errorf("expected %v, got %v", want, files)
+	}
+}
@@ -0,0 +1,56 @@
+package gitProviderClients
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// DiscoverRepositories lists the repositories in each of the namespaces, or
+// every repository the token can access when namespaces is empty. When topics
+// are given, only repositories carrying at least one of them are kept. The
+// result is deduplicated and sorted.
+func DiscoverRepositories(ctx context.Context, providerClient GitProviderClient, namespaces, topics []string) ([]string, error) {
+	if len(namespaces) == 0 {
+		namespaces = []string{""}
+	}
+
+	wanted := make(map[string]struct{}, len(topics))
+	for _, topic := range topics {
+		wanted[strings.ToLower(topic)] = struct{}{}
+	}
+
+	seen := make(map[string]struct{})
+	for _, namespace := range namespaces {
+		listings, err := providerClient.ListRepositories(ctx, namespace)
+		if err != nil {
+			if namespace == "" {
+				return nil, fmt.Errorf("listing repositories: %w", err)
+			}
+			return nil, fmt.Errorf("listing repositories in %s: %w", namespace, err)
+		}
+		for _, listing := range listings {
+			if len(wanted) > 0 && !hasAnyTopic(listing.Topics, wanted) {
+				continue
+			}
+			seen[listing.FullName] = struct{}{}
+		}
+	}
+
+	projects := make([]string, 0, len(seen))
+	for project := range seen {
+		projects = append(projects, project)
+	}
+	slices.Sort(projects)
+	return projects, nil
+}
+
+func hasAnyTopic(topics []string, wanted map[string]struct{}) bool {
+	for _, topic := range topics {
+		if _, ok := wanted[strings.ToLower(topic)]; ok {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,83 @@
+package gitProviderClients
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestDiscoverRepositories(t *testing.T) {
+	listings := map[string][]RepositoryListing{
+		"org": {
+			{FullName: "org/api", Topics: []string{"Renovate"}},
+			{FullName: "org/web"},
+			{FullName: "org/shared", Topics: []string{"renovate"}},
+		},
+		"team": {
+			{FullName: "org/shared", Topics: []string{"renovate"}},
+			{FullName: "team/tool", Topics: []string{"tools", "renovate"}},
+		},
+	}
+	client := &mockGitProviderClient{
+		listRepositoriesFunc: func(_ context.Context, namespace string) ([]RepositoryListing, error) {
+			return listings[namespace], nil
+		},
+	}
+
+	tests := []struct {
+		name       string
+		namespaces []string
+		topics     []string
+		want       []string
+	}{
+		{name: "all namespaces", namespaces: []string{"team", "org"}, want: []string{"org/api", "org/shared", "org/web", "team/tool"}},
+		{name: "topics match case-insensitively", namespaces: []string{"org", "team"}, topics: []string{"RENOVATE"}, want: []string{"org/api", "org/shared", "team/tool"}},
+		{name: "any topic is enough", namespaces: []string{"team"}, topics: []string{"tools"}, want: []string{"team/tool"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := DiscoverRepositories(context.Background(), client, tt.namespaces, tt.topics)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDiscoverRepositories_NoNamespacesListsEverything(t *testing.T) {
+	var listed []string
+	client := &mockGitProviderClient{
+		listRepositoriesFunc: func(_ context.Context, namespace string) ([]RepositoryListing, error) {
+			listed = append(listed, namespace)
+			return []RepositoryListing{{FullName: "org/repo"}}, nil
+		},
+	}
+
+	got, err := DiscoverRepositories(context.Background(), client, nil, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !slices.Equal(listed, []string{""}) || !slices.Equal(got, []string{"org/repo"}) {
+		t.Errorf("listed %q, got %v", listed, got)
+	}
+}
+
+func TestDiscoverRepositories_ErrorFailsWholeDiscovery(t *testing.T) {
+	client := &mockGitProviderClient{
+		listRepositoriesFunc: func(_ context.Context, namespace string) ([]RepositoryListing, error) {
+			if namespace == "broken" {
+				return nil, errors.New("boom")
+			}
+			return []RepositoryListing{{FullName: "org/repo"}}, nil
+		},
+	}
+
+	// a partial list would remove the projects of the failed namespace
+	if _, err := DiscoverRepositories(context.Background(), client, []string{"org", "broken"}, nil); err == nil {
+		t.Fatal("expected an error")
+	}
+}
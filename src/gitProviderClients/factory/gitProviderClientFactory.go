@@ -6,7 +6,9 @@ import (
 	"net/http"
 	api "renovate-operator/api/v1alpha1"
 	"renovate-operator/gitProviderClients"
+	"renovate-operator/gitProviderClients/azureProvider"
 	"renovate-operator/gitProviderClients/bitbucketProvider"
+	"renovate-operator/gitProviderClients/bitbucketServerProvider"
 	"renovate-operator/gitProviderClients/forgejoProvider"
 	"renovate-operator/gitProviderClients/giteaProvider"
 	"renovate-operator/gitProviderClients/githubProvider"
@@ -63,7 +65,14 @@ func (f *gitProviderClientFactory) NewClient(ctx context.Context, job *api.Renov
 		return nil, fmt.Errorf("failed to read platform token for fork filtering: %w", err)
 	}
 
-	return buildClient(platform, endpoint, token)
+	providerClient, err := buildClient(platform, endpoint, token)
+	if err != nil {
+		return nil, err
+	}
+	if gh, ok := providerClient.(*githubProvider.GitHubClient); ok && job.Spec.GithubAppReference != nil {
+		gh.Installation = true
+	}
+	return providerClient, nil
 }
 
 func (f *gitProviderClientFactory) NewClientWithTokenRef(ctx context.Context, job *api.RenovateJob, ref *api.RenovateSecretKeyReference) (gitProviderClients.GitProviderClient, error) {
@@ -143,6 +152,10 @@ func buildClient(platform, endpoint, token string) (gitProviderClients.GitProvid
 		return &forgejoProvider.ForgejoClient{Endpoint: endpoint, Token: token, HTTPClient: httpClient}, nil
 	case "bitbucket":
 		return &bitbucketProvider.BitbucketClient{Endpoint: endpoint, Token: token, HTTPClient: httpClient}, nil
+	case "bitbucket-server":
+		return &bitbucketServerProvider.BitbucketServerClient{Endpoint: endpoint, Token: token, HTTPClient: httpClient}, nil
+	case "azure":
+		return &azureProvider.AzureClient{Endpoint: endpoint, Token: token, HTTPClient: httpClient}, nil
 	default:
 		return nil, fmt.Errorf("skipForks is not supported for platform %q", platform)
 	}
@@ -6,7 +6,9 @@ import (
 	"testing"
 
 	api "renovate-operator/api/v1alpha1"
+	"renovate-operator/gitProviderClients/azureProvider"
 	"renovate-operator/gitProviderClients/githubProvider"
+	"renovate-operator/github"
 	"renovate-operator/internal/policy"
 
 	corev1 "k8s.io/api/core/v1"
@@ -201,3 +203,43 @@ func TestNewClient_AllowsAllowlistedEndpoint(t *testing.T) {
 		t.Fatalf("expected the platform token, got %q", gh.Token)
 	}
 }
+
+func TestNewClient_GithubAppUsesInstallationListing(t *testing.T) {
+	job := newTestJob()
+	job.Spec.GithubAppReference = &api.GithubAppReference{SecretName: "app"}
+	secret := newUnlabeledSecret(github.GetNameForGithubAppSecret(job), map[string][]byte{"RENOVATE_TOKEN": []byte("installation-token")})
+	cl := fake.NewClientBuilder().WithScheme(newTestScheme(t)).WithObjects(secret).Build()
+	factory := NewGitProviderClientFactory(cl, testPolicy())
+
+	client, err := factory.NewClient(context.Background(), job)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	gh, ok := client.(*githubProvider.GitHubClient)
+	if !ok {
+		t.Fatalf("expected a GitHub client, got %T", client)
+	}
+	if !gh.Installation || gh.Token != "installation-token" {
+		t.Fatalf("expected an installation client with the app token, got installation=%v token=%q", gh.Installation, gh.Token)
+	}
+}
+
+func TestNewClient_Azure(t *testing.T) {
+	job := newTestJob()
+	job.Spec.Provider = &api.RenovateProvider{Name: "azure", Endpoint: "https://dev.azure.com/org"}
+	secret := newSecret("renovate-secret", map[string][]byte{"RENOVATE_TOKEN": []byte("pat")})
+	cl := fake.NewClientBuilder().WithScheme(newTestScheme(t)).WithObjects(secret).Build()
+	factory := NewGitProviderClientFactory(cl, policy.Policy{AllowedHosts: []string{"dev.azure.com"}})
+
+	client, err := factory.NewClient(context.Background(), job)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	az, ok := client.(*azureProvider.AzureClient)
+	if !ok {
+		t.Fatalf("expected an Azure DevOps client, got %T", client)
+	}
+	if az.Endpoint != "https://dev.azure.com/org/" || az.Token != "pat" {
+		t.Fatalf("unexpected client endpoint %q / token %q", az.Endpoint, az.Token)
+	}
+}
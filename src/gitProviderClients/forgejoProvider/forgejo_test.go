@@ -3,9 +3,11 @@ package forgejoProvider
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"renovate-operator/gitProviderClients"
+	"slices"
 	"testing"
 )
 
@@ -51,8 +53,8 @@ func TestCreateRepoWebhook(t *testing.T) {
 			t.Errorf("expected hook type forgejo, got %s", payload.Type)
 		}
 		// the provider applies its fixed subscription
-		if len(payload.Events) != 2 || payload.Events[0] != "issues_only" || payload.Events[1] != "pull_request_only" {
-			t.Errorf("expected events [issues_only pull_request_only], got %v", payload.Events)
+		if len(payload.Events) != 3 || payload.Events[0] != "issues_only" || payload.Events[1] != "pull_request_only" || payload.Events[2] != "push" {
+			t.Errorf("expected events [issues_only pull_request_only push], got %v", payload.Events)
 		}
 		if payload.Config.URL != "https://example.com/webhook" {
 			t.Errorf("expected webhook URL, got %s", payload.Config.URL)
@@ -100,7 +102,7 @@ func TestUpdateRepoWebhook(t *testing.T) {
 		}
 
 		events, _ := raw["events"].([]any)
-		if len(events) != 2 || events[0] != "issues_only" || events[1] != "pull_request_only" {
+		if len(events) != 3 || events[0] != "issues_only" || events[1] != "pull_request_only" || events[2] != "push" {
 			t.Errorf("expected the fixed subscription, got %v", events)
 		}
 
@@ -161,3 +163,127 @@ func TestDeleteRepoWebhook_NotFoundIsSuccess(t *testing.T) {
 		t.Fatalf("expected nil error for already-deleted webhook, got: %v", err)
 	}
 }
+
+func TestListRepositories_UserSearch(t *testing.T) {
+	handler := http.NewServeMux()
+	handler.HandleFunc("/api/v1/user", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"id": 7}`))
+	})
+	handler.HandleFunc("/api/v1/repos/search", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("uid") != "7" || r.URL.Query().Get("archived") != "false" {
+			t.Errorf("expected the token user's unarchived repositories, got %s", r.URL.RawQuery)
+		}
+		var repos []forgejoListedRepo
+		if r.URL.Query().Get("page") == "1" {
+			for i := range 50 {
+				repos = append(repos, forgejoListedRepo{FullName: fmt.Sprintf("org/repo%d", i)})
+			}
+		} else {
+			repos = []forgejoListedRepo{{FullName: "org/last", Topics: []string{"renovate"}}}
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"ok": true, "data": repos})
+	})
+
+	srv := httptest.NewServer(handler)
+	defer srv.Close()
+
+	repos, err := NewClient(srv.URL, "test-token").ListRepositories(context.Background(), "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(repos) != 51 {
+		t.Fatalf("expected 51 repositories, got %d", len(repos))
+	}
+	if last := repos[50]; last.FullName != "org/last" || len(last.Topics) != 1 {
+		t.Errorf("unexpected last repository: %+v", last)
+	}
+}
+
+func TestListRepositories_OrgAndUserFallback(t *testing.T) {
+	handler := http.NewServeMux()
+	handler.HandleFunc("/api/v1/orgs/org/repos", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`[{"full_name": "org/kept"}, {"full_name": "org/old", "archived": true}]`))
+	})
+	handler.HandleFunc("/api/v1/orgs/someone/repos", func(w http.ResponseWriter, r *http.Request) {
+		http.NotFound(w, r)
+	})
+	handler.HandleFunc("/api/v1/users/someone/repos", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`[{"full_name": "someone/dotfiles"}]`))
+	})
+
+	srv := httptest.NewServer(handler)
+	defer srv.Close()
+	c := NewClient(srv.URL, "test-token")
+
+	repos, err := c.ListRepositories(context.Background(), "org")
+	if err != nil || len(repos) != 1 || repos[0].FullName != "org/kept" {
+		t.Fatalf("unexpected org listing: %+v, %v", repos, err)
+	}
+	repos, err = c.ListRepositories(context.Background(), "someone")
+	if err != nil || len(repos) != 1 || repos[0].FullName != "someone/dotfiles" {
+		t.Fatalf("unexpected user listing: %+v, %v", repos, err)
+	}
+}
+
+func TestListChangedFiles(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/repos/org/repo1/compare/abc...def" {
+			t.Errorf("unexpected path %q", r.URL.Path)
+		}
+		_, _ = w.Write([]byte(`{"total_commits": 2, "commits": [{"files": [{"filename": "renovate.json", "status": "modified"}]}, {"files": [{"filename": "go.sum", "status": "added"}]}]}`))
+	}))
+	defer srv.Close()
+
+	c := NewClient(srv.URL, "test-token")
+	files, err := c.ListChangedFiles(context.Background(), "org/repo1", "abc", "def")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := []string{"renovate.json", "go.sum"}; !slices.Equal(files, want) {
+		t.Errorf("expected %v, got %v", want, files)
+	}
+}
+
+func TestOrgWebhooks(t *testing.T) {
+	var requests []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.EscapedPath())
+		switch r.Method {
+		case http.MethodGet:
+			_, _ = w.Write([]byte(`[{"id":42,"config":{"url":"https://example.com/webhook"},"active":true}]`))
+		case http.MethodDelete:
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			_, _ = w.Write([]byte(`{"id":42}`))
+		}
+	}))
+	defer srv.Close()
+
+	c := NewClient(srv.URL, "test-token")
+	ctx := context.Background()
+	opts := gitProviderClients.CreateWebhookOptions{URL: "https://example.com/webhook", AuthToken: "secret", Active: true}
+
+	hooks, err := c.ListOrgWebhooks(ctx, "org")
+	if err != nil || len(hooks) != 1 || hooks[0].URL != "https://example.com/webhook" {
+		t.Fatalf("unexpected hooks %+v: %v", hooks, err)
+	}
+	if _, err := c.CreateOrgWebhook(ctx, "org", opts); err != nil {
+		t.Fatalf("unexpected error creating: %v", err)
+	}
+	if _, err := c.UpdateOrgWebhook(ctx, "org", "42", opts); err != nil {
+		t.Fatalf("unexpected error updating: %v", err)
+	}
+	if err := c.DeleteOrgWebhook(ctx, "org", "42"); err != nil {
+		t.Fatalf("unexpected error deleting: %v", err)
+	}
+
+	want := []string{
+		"GET /api/v1/orgs/org/hooks",
+		"POST /api/v1/orgs/org/hooks",
+		"PATCH /api/v1/orgs/org/hooks/42",
+		"DELETE /api/v1/orgs/org/hooks/42",
+	}
+	if !slices.Equal(requests, want) {
+		t.Errorf("expected requests %v, got %v", want, requests)
+	}
+}
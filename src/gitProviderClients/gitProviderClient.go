@@ -1,31 +1,90 @@
 package gitProviderClients
 
-import "context"
+import (
+	"context"
+	"errors"
+	"time"
+)
 
 // GitProviderClient provides platform-specific repository operations.
 type GitProviderClient interface {
 	// GetRepositoryInfo returns skip-relevant metadata about a project. The
-	// metadata is fetched in a single platform API call so that fork and
-	// pending-deletion filtering do not each incur their own request.
+	// metadata is fetched in a single platform API call so that the discovery
+	// filters (forks, pending deletion, archived, ...) do not each incur their
+	// own request.
 	GetRepositoryInfo(ctx context.Context, project string) (RepositoryInfo, error)
 
+	// ListRepositories returns the repositories the token can access in the
+	// given namespace (an organisation, group, user or workspace), or all of
+	// them when namespace is empty. Archived repositories are left out, as in
+	// Renovate's autodiscovery. Every page of the listing is fetched.
+	ListRepositories(ctx context.Context, namespace string) ([]RepositoryListing, error)
+
+	// ListChangedFiles returns the paths of the files that differ between the
+	// base and head commits of a project. It backs the push trigger for push
+	// payloads that do not list the changed files themselves. Renamed files
+	// are reported under both their old and their new path.
+	ListChangedFiles(ctx context.Context, project, base, head string) ([]string, error)
+
 	ListRepoWebhooks(ctx context.Context, project string) ([]Webhook, error)
 	CreateRepoWebhook(ctx context.Context, project string, opts CreateWebhookOptions) (*Webhook, error)
 	UpdateRepoWebhook(ctx context.Context, project string, hookID string, opts CreateWebhookOptions) (*Webhook, error)
 	DeleteRepoWebhook(ctx context.Context, project string, hookID string) error
+
+	// The organization webhook methods manage hooks that receive the events
+	// of every repository of an organization (a GitHub or Gitea/Forgejo
+	// organization, a GitLab top-level group including its subgroups or a
+	// Bitbucket workspace). Providers without organization webhooks return
+	// ErrOrgWebhooksUnsupported.
+	ListOrgWebhooks(ctx context.Context, org string) ([]Webhook, error)
+	CreateOrgWebhook(ctx context.Context, org string, opts CreateWebhookOptions) (*Webhook, error)
+	UpdateOrgWebhook(ctx context.Context, org string, hookID string, opts CreateWebhookOptions) (*Webhook, error)
+	DeleteOrgWebhook(ctx context.Context, org string, hookID string) error
 }
 
+// ErrOrgWebhooksUnsupported is returned by the organization webhook methods
+// of providers that have no organization-level webhooks.
+var ErrOrgWebhooksUnsupported = errors.New("organization webhooks are not supported by this provider")
+
 // RepositoryInfo captures repo attributes used to decide whether to skip a
 // project during discovery. Not every provider exposes every attribute;
-// unsupported attributes are reported as false.
+// unsupported attributes are reported as their zero value.
 type RepositoryInfo struct {
 	// Fork is true if the repository is a fork.
 	Fork bool
 	// PendingDeletion is true if the repository is marked for delayed deletion.
 	// Only GitLab exposes this state.
 	PendingDeletion bool
+	// Archived is true if the repository is archived (read-only). Bitbucket
+	// Cloud has no archived state.
+	Archived bool
+	// Empty is true if the repository has no commits yet.
+	Empty bool
+	// Visibility is one of the Visibility* constants, or empty if unknown.
+	Visibility string
+	// DefaultBranch is the name of the repository's default branch.
+	DefaultBranch string
+	// LastPushAt is the time of the most recent push, or the closest activity
+	// timestamp the platform exposes. Zero if unknown.
+	LastPushAt time.Time
 }
 
+// RepositoryListing is a repository as returned by ListRepositories.
+type RepositoryListing struct {
+	// FullName is the project path as Renovate names it ("org/repo",
+	// "group/subgroup/repo").
+	FullName string
+	// Topics of the repository. Bitbucket Cloud has no topics.
+	Topics []string
+}
+
+// Repository visibilities as reported in RepositoryInfo.Visibility.
+const (
+	VisibilityPublic   = "public"
+	VisibilityInternal = "internal"
+	VisibilityPrivate  = "private"
+)
+
 type Repository struct {
 	ID       int64  `json:"id"`
 	FullName string `json:"full_name"`
@@ -4,12 +4,15 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 	"renovate-operator/gitProviderClients"
 	"strconv"
 	"strings"
+	"time"
 )
 
 // GiteaClient implements GitProviderClient for the Gitea and Forgejo APIs.
@@ -19,6 +22,10 @@ type GiteaClient struct {
 	HTTPClient *http.Client
 }
 
+// errNotFound is returned by listRepositoryPages when the listing endpoint
+// does not exist, e.g. /orgs/{name} for a user account.
+var errNotFound = errors.New("not found")
+
 func (c *GiteaClient) GetRepositoryInfo(ctx context.Context, project string) (gitProviderClients.RepositoryInfo, error) {
 	//trim /api/v1 if it is included in the endpoint, to avoid double /api/v1 in the URL
 	endpoint := strings.TrimSuffix(c.Endpoint, "/")
@@ -45,13 +52,146 @@ func (c *GiteaClient) GetRepositoryInfo(ctx context.Context, project string) (gi
 	}
 
 	var repo struct {
-		Fork bool `json:"fork"`
+		Fork          bool       `json:"fork"`
+		Archived      bool       `json:"archived"`
+		Empty         bool       `json:"empty"`
+		Private       bool       `json:"private"`
+		Internal      bool       `json:"internal"`
+		DefaultBranch string     `json:"default_branch"`
+		UpdatedAt     *time.Time `json:"updated_at"`
 	}
 	if err := json.NewDecoder(resp.Body).Decode(&repo); err != nil {
 		return gitProviderClients.RepositoryInfo{}, fmt.Errorf("failed to decode gitea API response for %s: %w", project, err)
 	}
+	info := gitProviderClients.RepositoryInfo{
+		Fork:          repo.Fork,
+		Archived:      repo.Archived,
+		Empty:         repo.Empty,
+		Visibility:    gitProviderClients.VisibilityPublic,
+		DefaultBranch: repo.DefaultBranch,
+	}
+	switch {
+	case repo.Private:
+		info.Visibility = gitProviderClients.VisibilityPrivate
+	case repo.Internal:
+		info.Visibility = gitProviderClients.VisibilityInternal
+	}
+	// The API exposes no push timestamp; updated_at also moves on pushes.
+	if repo.UpdatedAt != nil {
+		info.LastPushAt = *repo.UpdatedAt
+	}
 	// Gitea/Forgejo have no pending-deletion state.
-	return gitProviderClients.RepositoryInfo{Fork: repo.Fork}, nil
+	return info, nil
+}
+
+// wire format of the repository objects in the Gitea/Forgejo list endpoints
+type giteaListedRepo struct {
+	FullName string   `json:"full_name"`
+	Archived bool     `json:"archived"`
+	Topics   []string `json:"topics"`
+}
+
+func (c *GiteaClient) ListRepositories(ctx context.Context, namespace string) ([]gitProviderClients.RepositoryListing, error) {
+	if namespace == "" {
+		// like Renovate, list the repositories the token's user owns or
+		// contributes to rather than every public repository on the instance
+		resp, err := c.doRequest(ctx, http.MethodGet, "/api/v1/user", nil)
+		if err != nil {
+			return nil, fmt.Errorf("getting current user: %w", err)
+		}
+		var user struct {
+			ID int64 `json:"id"`
+		}
+		if err := decodeResponse(resp, &user); err != nil {
+			return nil, fmt.Errorf("getting current user: %w", err)
+		}
+		return c.listRepositoryPages(ctx, fmt.Sprintf("/api/v1/repos/search?uid=%d&archived=false", user.ID), true)
+	}
+
+	repos, err := c.listRepositoryPages(ctx, fmt.Sprintf("/api/v1/orgs/%s/repos", url.PathEscape(namespace)), false)
+	if errors.Is(err, errNotFound) {
+		// not an organisation, so list the user's repositories instead
+		return c.listRepositoryPages(ctx, fmt.Sprintf("/api/v1/users/%s/repos", url.PathEscape(namespace)), false)
+	}
+	return repos, err
+}
+
+// listRepositoryPages fetches every page of a repository listing. The search
+// endpoint wraps the repositories in an object.
+func (c *GiteaClient) listRepositoryPages(ctx context.Context, path string, wrapped bool) ([]gitProviderClients.RepositoryListing, error) {
+	var listings []gitProviderClients.RepositoryListing
+	page := 1
+	limit := 50
+	separator := "?"
+	if strings.Contains(path, "?") {
+		separator = "&"
+	}
+
+	for {
+		resp, err := c.doRequest(ctx, http.MethodGet, fmt.Sprintf("%s%slimit=%d&page=%d", path, separator, limit, page), nil)
+		if err != nil {
+			return nil, fmt.Errorf("listing repositories: %w", err)
+		}
+		if resp.StatusCode == http.StatusNotFound {
+			_ = resp.Body.Close()
+			return nil, errNotFound
+		}
+
+		var repos []giteaListedRepo
+		if wrapped {
+			var result struct {
+				Data []giteaListedRepo `json:"data"`
+			}
+			err = decodeResponse(resp, &result)
+			repos = result.Data
+		} else {
+			err = decodeResponse(resp, &repos)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("listing repositories: %w", err)
+		}
+
+		for _, repo := range repos {
+			if !repo.Archived {
+				listings = append(listings, gitProviderClients.RepositoryListing{FullName: repo.FullName, Topics: repo.Topics})
+			}
+		}
+		if len(repos) < limit {
+			break
+		}
+		page++
+	}
+
+	return listings, nil
+}
+
+// ListChangedFiles compares the two commits. The Gitea/Forgejo compare API reports
+// the files touched by each commit between base and head.
+func (c *GiteaClient) ListChangedFiles(ctx context.Context, project, base, head string) ([]string, error) {
+	path := fmt.Sprintf("/api/v1/repos/%s/compare/%s...%s", project, url.PathEscape(base), url.PathEscape(head))
+	resp, err := c.doRequest(ctx, http.MethodGet, path, nil)
+	if err != nil {
+		return nil, fmt.Errorf("comparing commits: %w", err)
+	}
+
+	var comparison struct {
+		Commits []struct {
+			Files []struct {
+				Filename string `json:"filename"`
+			} `json:"files"`
+		} `json:"commits"`
+	}
+	if err := decodeResponse(resp, &comparison); err != nil {
+		return nil, fmt.Errorf("comparing commits: %w", err)
+	}
+
+	var files []string
+	for _, commit := range comparison.Commits {
+		for _, file := range commit.Files {
+			files = append(files, file.Filename)
+		}
+	}
+	return files, nil
 }
 
 func (c *GiteaClient) doRequest(ctx context.Context, method, path string, body io.Reader) (*http.Response, error) {
@@ -87,10 +227,11 @@ type giteaHookConfig struct {
 }
 
 // giteaWebhookEvents is the fixed subscription for operator-managed hooks:
-// just the base issue and pull request events. The aggregate names "issues"/
-// "pull_request" would enable every sub-event (assign, label, milestone,
-// comment, review, sync), which the operator never needs.
-var giteaWebhookEvents = []string{"issues_only", "pull_request_only"}
+// just the base issue and pull request events, and pushes for the push
+// trigger. The aggregate names "issues"/"pull_request" would enable every
+// sub-event (assign, label, milestone, comment, review, sync), which the
+// operator never needs.
+var giteaWebhookEvents = []string{"issues_only", "pull_request_only", "push"}
 
 // giteaReportedEvents is how the hooks API reports that subscription back:
 // the enabled base flags are listed as "issues"/"pull_request".
@@ -123,12 +264,46 @@ func eventsEqual(actual, expected []string) bool {
 }
 
 func (c *GiteaClient) ListRepoWebhooks(ctx context.Context, project string) ([]gitProviderClients.Webhook, error) {
+	return c.listHooks(ctx, fmt.Sprintf("/api/v1/repos/%s/hooks", project))
+}
+
+func (c *GiteaClient) CreateRepoWebhook(ctx context.Context, project string, opts gitProviderClients.CreateWebhookOptions) (*gitProviderClients.Webhook, error) {
+	return c.createHook(ctx, fmt.Sprintf("/api/v1/repos/%s/hooks", project), opts)
+}
+
+func (c *GiteaClient) UpdateRepoWebhook(ctx context.Context, project string, hookID string, opts gitProviderClients.CreateWebhookOptions) (*gitProviderClients.Webhook, error) {
+	return c.updateHook(ctx, fmt.Sprintf("/api/v1/repos/%s/hooks/%s", project, hookID), opts)
+}
+
+func (c *GiteaClient) DeleteRepoWebhook(ctx context.Context, project string, hookID string) error {
+	return c.deleteHook(ctx, fmt.Sprintf("/api/v1/repos/%s/hooks/%s", project, hookID))
+}
+
+// The organization hooks API takes the same payload as the repository one.
+
+func (c *GiteaClient) ListOrgWebhooks(ctx context.Context, org string) ([]gitProviderClients.Webhook, error) {
+	return c.listHooks(ctx, fmt.Sprintf("/api/v1/orgs/%s/hooks", url.PathEscape(org)))
+}
+
+func (c *GiteaClient) CreateOrgWebhook(ctx context.Context, org string, opts gitProviderClients.CreateWebhookOptions) (*gitProviderClients.Webhook, error) {
+	return c.createHook(ctx, fmt.Sprintf("/api/v1/orgs/%s/hooks", url.PathEscape(org)), opts)
+}
+
+func (c *GiteaClient) UpdateOrgWebhook(ctx context.Context, org string, hookID string, opts gitProviderClients.CreateWebhookOptions) (*gitProviderClients.Webhook, error) {
+	return c.updateHook(ctx, fmt.Sprintf("/api/v1/orgs/%s/hooks/%s", url.PathEscape(org), hookID), opts)
+}
+
+func (c *GiteaClient) DeleteOrgWebhook(ctx context.Context, org string, hookID string) error {
+	return c.deleteHook(ctx, fmt.Sprintf("/api/v1/orgs/%s/hooks/%s", url.PathEscape(org), hookID))
+}
+
+func (c *GiteaClient) listHooks(ctx context.Context, hooksPath string) ([]gitProviderClients.Webhook, error) {
 	var allHooks []gitProviderClients.Webhook
 	page := 1
 	limit := 50
 
 	for {
-		path := fmt.Sprintf("/api/v1/repos/%s/hooks?limit=%d&page=%d", project, limit, page)
+		path := fmt.Sprintf("%s?limit=%d&page=%d", hooksPath, limit, page)
 		resp, err := c.doRequest(ctx, http.MethodGet, path, nil)
 		if err != nil {
 			return nil, fmt.Errorf("listing webhooks: %w", err)
@@ -154,7 +329,7 @@ func (c *GiteaClient) ListRepoWebhooks(ctx context.Context, project string) ([]g
 	return allHooks, nil
 }
 
-func (c *GiteaClient) CreateRepoWebhook(ctx context.Context, project string, opts gitProviderClients.CreateWebhookOptions) (*gitProviderClients.Webhook, error) {
+func (c *GiteaClient) createHook(ctx context.Context, hooksPath string, opts gitProviderClients.CreateWebhookOptions) (*gitProviderClients.Webhook, error) {
 	payload := giteaHook{
 		Type: "gitea",
 		Config: giteaHookConfig{
@@ -173,8 +348,7 @@ func (c *GiteaClient) CreateRepoWebhook(ctx context.Context, project string, opt
 		return nil, fmt.Errorf("marshalling webhook options: %w", err)
 	}
 
-	path := fmt.Sprintf("/api/v1/repos/%s/hooks", project)
-	resp, err := c.doRequest(ctx, http.MethodPost, path, bytes.NewReader(body))
+	resp, err := c.doRequest(ctx, http.MethodPost, hooksPath, bytes.NewReader(body))
 	if err != nil {
 		return nil, fmt.Errorf("creating webhook: %w", err)
 	}
@@ -187,7 +361,7 @@ func (c *GiteaClient) CreateRepoWebhook(ctx context.Context, project string, opt
 	return &result, nil
 }
 
-func (c *GiteaClient) UpdateRepoWebhook(ctx context.Context, project string, hookID string, opts gitProviderClients.CreateWebhookOptions) (*gitProviderClients.Webhook, error) {
+func (c *GiteaClient) updateHook(ctx context.Context, hookPath string, opts gitProviderClients.CreateWebhookOptions) (*gitProviderClients.Webhook, error) {
 	payload := giteaHook{
 		Config: giteaHookConfig{
 			URL:         opts.URL,
@@ -205,8 +379,7 @@ func (c *GiteaClient) UpdateRepoWebhook(ctx context.Context, project string, hoo
 		return nil, fmt.Errorf("marshalling webhook options: %w", err)
 	}
 
-	path := fmt.Sprintf("/api/v1/repos/%s/hooks/%s", project, hookID)
-	resp, err := c.doRequest(ctx, http.MethodPatch, path, bytes.NewReader(body))
+	resp, err := c.doRequest(ctx, http.MethodPatch, hookPath, bytes.NewReader(body))
 	if err != nil {
 		return nil, fmt.Errorf("updating webhook: %w", err)
 	}
@@ -219,9 +392,8 @@ func (c *GiteaClient) UpdateRepoWebhook(ctx context.Context, project string, hoo
 	return &result, nil
 }
 
-func (c *GiteaClient) DeleteRepoWebhook(ctx context.Context, project string, hookID string) error {
-	path := fmt.Sprintf("/api/v1/repos/%s/hooks/%s", project, hookID)
-	resp, err := c.doRequest(ctx, http.MethodDelete, path, nil)
+func (c *GiteaClient) deleteHook(ctx context.Context, hookPath string) error {
+	resp, err := c.doRequest(ctx, http.MethodDelete, hookPath, nil)
 	if err != nil {
 		return fmt.Errorf("deleting webhook: %w", err)
 	}
@@ -3,9 +3,12 @@ package giteaProvider
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"slices"
 	"testing"
+	"time"
 
 	"renovate-operator/gitProviderClients"
 )
@@ -14,6 +17,30 @@ func newTestClient(url string) *GiteaClient {
 	return &GiteaClient{Endpoint: url, Token: "test-token", HTTPClient: http.DefaultClient}
 }
 
+func TestGetRepositoryInfo(t *testing.T) {
+	handler := http.NewServeMux()
+	handler.HandleFunc("/api/v1/repos/org/repo1", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "token test-token" {
+			t.Errorf("expected token auth, got %q", r.Header.Get("Authorization"))
+		}
+		_, _ = w.Write([]byte(`{"archived": true, "empty": true, "private": true, "default_branch": "main", "updated_at": "2026-03-01T10:00:00Z"}`))
+	})
+
+	srv := httptest.NewServer(handler)
+	defer srv.Close()
+
+	info, err := newTestClient(srv.URL+"/api/v1").GetRepositoryInfo(context.Background(), "org/repo1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if info.Fork || !info.Archived || !info.Empty || info.Visibility != gitProviderClients.VisibilityPrivate || info.DefaultBranch != "main" {
+		t.Errorf("unexpected repository info: %+v", info)
+	}
+	if !info.LastPushAt.Equal(time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)) {
+		t.Errorf("unexpected last push time: %v", info.LastPushAt)
+	}
+}
+
 func TestCreateRepoWebhook(t *testing.T) {
 	handler := http.NewServeMux()
 	handler.HandleFunc("/api/v1/repos/org/repo1/hooks", func(w http.ResponseWriter, r *http.Request) {
@@ -27,8 +54,8 @@ func TestCreateRepoWebhook(t *testing.T) {
 			t.Errorf("expected hook type gitea, got %s", payload.Type)
 		}
 		// the provider applies its fixed subscription
-		if len(payload.Events) != 2 || payload.Events[0] != "issues_only" || payload.Events[1] != "pull_request_only" {
-			t.Errorf("expected events [issues_only pull_request_only], got %v", payload.Events)
+		if len(payload.Events) != 3 || payload.Events[0] != "issues_only" || payload.Events[1] != "pull_request_only" || payload.Events[2] != "push" {
+			t.Errorf("expected events [issues_only pull_request_only push], got %v", payload.Events)
 		}
 		if payload.Config.URL != "https://example.com/webhook" {
 			t.Errorf("expected webhook URL, got %s", payload.Config.URL)
@@ -93,7 +120,7 @@ func TestUpdateRepoWebhook(t *testing.T) {
 		}
 
 		events, _ := raw["events"].([]any)
-		if len(events) != 2 || events[0] != "issues_only" || events[1] != "pull_request_only" {
+		if len(events) != 3 || events[0] != "issues_only" || events[1] != "pull_request_only" || events[2] != "push" {
 			t.Errorf("expected the fixed subscription, got %v", events)
 		}
 
@@ -132,3 +159,127 @@ func TestDeleteRepoWebhook(t *testing.T) {
 		t.Fatalf("unexpected error: %v", err)
 	}
 }
+
+func TestListRepositories_UserSearch(t *testing.T) {
+	handler := http.NewServeMux()
+	handler.HandleFunc("/api/v1/user", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"id": 7}`))
+	})
+	handler.HandleFunc("/api/v1/repos/search", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("uid") != "7" || r.URL.Query().Get("archived") != "false" {
+			t.Errorf("expected the token user's unarchived repositories, got %s", r.URL.RawQuery)
+		}
+		var repos []giteaListedRepo
+		if r.URL.Query().Get("page") == "1" {
+			for i := range 50 {
+				repos = append(repos, giteaListedRepo{FullName: fmt.Sprintf("org/repo%d", i)})
+			}
+		} else {
+			repos = []giteaListedRepo{{FullName: "org/last", Topics: []string{"renovate"}}}
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"ok": true, "data": repos})
+	})
+
+	srv := httptest.NewServer(handler)
+	defer srv.Close()
+
+	repos, err := newTestClient(srv.URL).ListRepositories(context.Background(), "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(repos) != 51 {
+		t.Fatalf("expected 51 repositories, got %d", len(repos))
+	}
+	if last := repos[50]; last.FullName != "org/last" || len(last.Topics) != 1 {
+		t.Errorf("unexpected last repository: %+v", last)
+	}
+}
+
+func TestListRepositories_OrgAndUserFallback(t *testing.T) {
+	handler := http.NewServeMux()
+	handler.HandleFunc("/api/v1/orgs/org/repos", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`[{"full_name": "org/kept"}, {"full_name": "org/old", "archived": true}]`))
+	})
+	handler.HandleFunc("/api/v1/orgs/someone/repos", func(w http.ResponseWriter, r *http.Request) {
+		http.NotFound(w, r)
+	})
+	handler.HandleFunc("/api/v1/users/someone/repos", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`[{"full_name": "someone/dotfiles"}]`))
+	})
+
+	srv := httptest.NewServer(handler)
+	defer srv.Close()
+	c := newTestClient(srv.URL)
+
+	repos, err := c.ListRepositories(context.Background(), "org")
+	if err != nil || len(repos) != 1 || repos[0].FullName != "org/kept" {
+		t.Fatalf("unexpected org listing: %+v, %v", repos, err)
+	}
+	repos, err = c.ListRepositories(context.Background(), "someone")
+	if err != nil || len(repos) != 1 || repos[0].FullName != "someone/dotfiles" {
+		t.Fatalf("unexpected user listing: %+v, %v", repos, err)
+	}
+}
+
+func TestListChangedFiles(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/repos/org/repo1/compare/abc...def" {
+			t.Errorf("unexpected path %q", r.URL.Path)
+		}
+		_, _ = w.Write([]byte(`{"total_commits": 2, "commits": [{"files": [{"filename": "renovate.json", "status": "modified"}]}, {"files": [{"filename": "go.sum", "status": "added"}]}]}`))
+	}))
+	defer srv.Close()
+
+	c := newTestClient(srv.URL)
+	files, err := c.ListChangedFiles(context.Background(), "org/repo1", "abc", "def")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := []string{"renovate.json", "go.sum"}; !slices.Equal(files, want) {
+		t.Errorf("expected %v, got %v", want, files)
+	}
+}
+
+func TestOrgWebhooks(t *testing.T) {
+	var requests []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.EscapedPath())
+		switch r.Method {
+		case http.MethodGet:
+			_, _ = w.Write([]byte(`[{"id":42,"config":{"url":"https://example.com/webhook"},"active":true}]`))
+		case http.MethodDelete:
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			_, _ = w.Write([]byte(`{"id":42}`))
+		}
+	}))
+	defer srv.Close()
+
+	c := newTestClient(srv.URL)
+	ctx := context.Background()
+	opts := gitProviderClients.CreateWebhookOptions{URL: "https://example.com/webhook", AuthToken: "secret", Active: true}
+
+	hooks, err := c.ListOrgWebhooks(ctx, "org")
+	if err != nil || len(hooks) != 1 || hooks[0].URL != "https://example.com/webhook" {
+		t.Fatalf("unexpected hooks %+v: %v", hooks, err)
+	}
+	if _, err := c.CreateOrgWebhook(ctx, "org", opts); err != nil {
+		t.Fatalf("unexpected error creating: %v", err)
+	}
+	if _, err := c.UpdateOrgWebhook(ctx, "org", "42", opts); err != nil {
+		t.Fatalf("unexpected error updating: %v", err)
+	}
+	if err := c.DeleteOrgWebhook(ctx, "org", "42"); err != nil {
+		t.Fatalf("unexpected error deleting: %v", err)
+	}
+
+	want := []string{
+		"GET /api/v1/orgs/org/hooks",
+		"POST /api/v1/orgs/org/hooks",
+		"PATCH /api/v1/orgs/org/hooks/42",
+		"DELETE /api/v1/orgs/org/hooks/42",
+	}
+	if !slices.Equal(requests, want) {
+		t.Errorf("expected requests %v, got %v", want, requests)
+	}
+}
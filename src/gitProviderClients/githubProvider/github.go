@@ -4,12 +4,15 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 	"renovate-operator/gitProviderClients"
 	"strconv"
 	"strings"
+	"time"
 )
 
 // GitHubClient implements GitProviderClient for the GitHub API.
@@ -17,8 +20,15 @@ type GitHubClient struct {
 	Endpoint   string
 	Token      string
 	HTTPClient *http.Client
+	// Installation marks a GitHub App installation token, which lists its
+	// repositories through /installation/repositories instead of /user/repos.
+	Installation bool
 }
 
+// errNotFound is returned by listRepositoryPages when the listing endpoint
+// does not exist, e.g. /orgs/{name} for a user account.
+var errNotFound = errors.New("not found")
+
 func (c *GitHubClient) GetRepositoryInfo(ctx context.Context, project string) (gitProviderClients.RepositoryInfo, error) {
 	url := fmt.Sprintf("%s/repos/%s", c.Endpoint, project)
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
@@ -40,13 +50,156 @@ func (c *GitHubClient) GetRepositoryInfo(ctx context.Context, project string) (g
 	}
 
 	var repo struct {
-		Fork bool `json:"fork"`
+		Fork          bool       `json:"fork"`
+		Archived      bool       `json:"archived"`
+		Size          int64      `json:"size"`
+		Visibility    string     `json:"visibility"`
+		Private       bool       `json:"private"`
+		DefaultBranch string     `json:"default_branch"`
+		PushedAt      *time.Time `json:"pushed_at"`
 	}
 	if err := json.NewDecoder(resp.Body).Decode(&repo); err != nil {
 		return gitProviderClients.RepositoryInfo{}, fmt.Errorf("failed to decode GitHub API response for %s: %w", project, err)
 	}
+	// GitHub Enterprise Server before 3.x does not report visibility.
+	visibility := repo.Visibility
+	if visibility == "" {
+		visibility = gitProviderClients.VisibilityPublic
+		if repo.Private {
+			visibility = gitProviderClients.VisibilityPrivate
+		}
+	}
+	info := gitProviderClients.RepositoryInfo{
+		Fork:          repo.Fork,
+		Archived:      repo.Archived,
+		Visibility:    visibility,
+		DefaultBranch: repo.DefaultBranch,
+	}
+	// GitHub reports no explicit empty flag, and size is computed lazily: it
+	// reads 0 for small or freshly pushed repositories too. Only a size of 0
+	// is worth asking the commits listing, which answers 409 for an empty
+	// repository.
+	if repo.Size == 0 {
+		info.Empty = c.hasNoCommits(ctx, project)
+	}
+	if repo.PushedAt != nil {
+		info.LastPushAt = *repo.PushedAt
+	}
 	// GitHub deletes repositories immediately and has no pending-deletion state.
-	return gitProviderClients.RepositoryInfo{Fork: repo.Fork}, nil
+	return info, nil
+}
+
+// hasNoCommits reports whether the commits listing of a project answers 409
+// Conflict, GitHub's answer for an empty repository. Any other outcome leaves
+// the repository counted as not empty, so that it is kept.
+func (c *GitHubClient) hasNoCommits(ctx context.Context, project string) bool {
+	resp, err := c.doRequest(ctx, http.MethodGet, fmt.Sprintf("/repos/%s/commits?per_page=1", project), nil)
+	if err != nil {
+		return false
+	}
+	_ = resp.Body.Close()
+	return resp.StatusCode == http.StatusConflict
+}
+
+// wire format of the repository objects in the GitHub list endpoints
+type githubListedRepo struct {
+	FullName string   `json:"full_name"`
+	Archived bool     `json:"archived"`
+	Topics   []string `json:"topics"`
+}
+
+func (c *GitHubClient) ListRepositories(ctx context.Context, namespace string) ([]gitProviderClients.RepositoryListing, error) {
+	if namespace == "" {
+		if c.Installation {
+			return c.listRepositoryPages(ctx, "/installation/repositories", true)
+		}
+		return c.listRepositoryPages(ctx, "/user/repos?affiliation=owner,collaborator,organization_member", false)
+	}
+
+	repos, err := c.listRepositoryPages(ctx, fmt.Sprintf("/orgs/%s/repos", url.PathEscape(namespace)), false)
+	if errors.Is(err, errNotFound) {
+		// not an organisation, so list the user's repositories instead
+		return c.listRepositoryPages(ctx, fmt.Sprintf("/users/%s/repos", url.PathEscape(namespace)), false)
+	}
+	return repos, err
+}
+
+// listRepositoryPages fetches every page of a repository listing. The
+// installation endpoint wraps the repositories in an object.
+func (c *GitHubClient) listRepositoryPages(ctx context.Context, path string, wrapped bool) ([]gitProviderClients.RepositoryListing, error) {
+	var listings []gitProviderClients.RepositoryListing
+	page := 1
+	limit := 100
+	separator := "?"
+	if strings.Contains(path, "?") {
+		separator = "&"
+	}
+
+	for {
+		resp, err := c.doRequest(ctx, http.MethodGet, fmt.Sprintf("%s%sper_page=%d&page=%d", path, separator, limit, page), nil)
+		if err != nil {
+			return nil, fmt.Errorf("listing repositories: %w", err)
+		}
+		if resp.StatusCode == http.StatusNotFound {
+			_ = resp.Body.Close()
+			return nil, errNotFound
+		}
+
+		var repos []githubListedRepo
+		if wrapped {
+			var result struct {
+				Repositories []githubListedRepo `json:"repositories"`
+			}
+			err = decodeResponse(resp, &result)
+			repos = result.Repositories
+		} else {
+			err = decodeResponse(resp, &repos)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("listing repositories: %w", err)
+		}
+
+		for _, repo := range repos {
+			if !repo.Archived {
+				listings = append(listings, gitProviderClients.RepositoryListing{FullName: repo.FullName, Topics: repo.Topics})
+			}
+		}
+		if len(repos) < limit {
+			break
+		}
+		page++
+	}
+
+	return listings, nil
+}
+
+// ListChangedFiles compares the two commits. GitHub caps the file list of a
+// comparison at 300 files.
+func (c *GitHubClient) ListChangedFiles(ctx context.Context, project, base, head string) ([]string, error) {
+	path := fmt.Sprintf("/repos/%s/compare/%s...%s", project, url.PathEscape(base), url.PathEscape(head))
+	resp, err := c.doRequest(ctx, http.MethodGet, path, nil)
+	if err != nil {
+		return nil, fmt.Errorf("comparing commits: %w", err)
+	}
+
+	var comparison struct {
+		Files []struct {
+			Filename         string `json:"filename"`
+			PreviousFilename string `json:"previous_filename"`
+		} `json:"files"`
+	}
+	if err := decodeResponse(resp, &comparison); err != nil {
+		return nil, fmt.Errorf("comparing commits: %w", err)
+	}
+
+	var files []string
+	for _, file := range comparison.Files {
+		files = append(files, file.Filename)
+		if file.PreviousFilename != "" {
+			files = append(files, file.PreviousFilename)
+		}
+	}
+	return files, nil
 }
 
 func (c *GitHubClient) doRequest(ctx context.Context, method, path string, body io.Reader) (*http.Response, error) {
@@ -80,8 +233,9 @@ type githubHookConfig struct {
 }
 
 // githubWebhookEvents is the fixed subscription for operator-managed hooks:
-// the issue and pull request events used by the Renovate checkbox triggers.
-var githubWebhookEvents = []string{"issues", "pull_request"}
+// the issue and pull request events used by the Renovate checkbox triggers,
+// and pushes for the push trigger.
+var githubWebhookEvents = []string{"issues", "pull_request", "push"}
 
 func (h githubHook) toWebhook() gitProviderClients.Webhook {
 	return gitProviderClients.Webhook{
@@ -110,12 +264,46 @@ func eventsEqual(actual, expected []string) bool {
 }
 
 func (c *GitHubClient) ListRepoWebhooks(ctx context.Context, project string) ([]gitProviderClients.Webhook, error) {
+	return c.listHooks(ctx, fmt.Sprintf("/repos/%s/hooks", project))
+}
+
+func (c *GitHubClient) CreateRepoWebhook(ctx context.Context, project string, opts gitProviderClients.CreateWebhookOptions) (*gitProviderClients.Webhook, error) {
+	return c.createHook(ctx, fmt.Sprintf("/repos/%s/hooks", project), opts)
+}
+
+func (c *GitHubClient) UpdateRepoWebhook(ctx context.Context, project string, hookID string, opts gitProviderClients.CreateWebhookOptions) (*gitProviderClients.Webhook, error) {
+	return c.updateHook(ctx, fmt.Sprintf("/repos/%s/hooks/%s", project, hookID), opts)
+}
+
+func (c *GitHubClient) DeleteRepoWebhook(ctx context.Context, project string, hookID string) error {
+	return c.deleteHook(ctx, fmt.Sprintf("/repos/%s/hooks/%s", project, hookID))
+}
+
+// The organization webhooks API has the same shape as the repository one.
+
+func (c *GitHubClient) ListOrgWebhooks(ctx context.Context, org string) ([]gitProviderClients.Webhook, error) {
+	return c.listHooks(ctx, fmt.Sprintf("/orgs/%s/hooks", url.PathEscape(org)))
+}
+
+func (c *GitHubClient) CreateOrgWebhook(ctx context.Context, org string, opts gitProviderClients.CreateWebhookOptions) (*gitProviderClients.Webhook, error) {
+	return c.createHook(ctx, fmt.Sprintf("/orgs/%s/hooks", url.PathEscape(org)), opts)
+}
+
+func (c *GitHubClient) UpdateOrgWebhook(ctx context.Context, org string, hookID string, opts gitProviderClients.CreateWebhookOptions) (*gitProviderClients.Webhook, error) {
+	return c.updateHook(ctx, fmt.Sprintf("/orgs/%s/hooks/%s", url.PathEscape(org), hookID), opts)
+}
+
+func (c *GitHubClient) DeleteOrgWebhook(ctx context.Context, org string, hookID string) error {
+	return c.deleteHook(ctx, fmt.Sprintf("/orgs/%s/hooks/%s", url.PathEscape(org), hookID))
+}
+
+func (c *GitHubClient) listHooks(ctx context.Context, hooksPath string) ([]gitProviderClients.Webhook, error) {
 	var allHooks []gitProviderClients.Webhook
 	page := 1
 	limit := 50
 
 	for {
-		path := fmt.Sprintf("/repos/%s/hooks?per_page=%d&page=%d", project, limit, page)
+		path := fmt.Sprintf("%s?per_page=%d&page=%d", hooksPath, limit, page)
 		resp, err := c.doRequest(ctx, http.MethodGet, path, nil)
 		if err != nil {
 			return nil, fmt.Errorf("listing webhooks: %w", err)
@@ -141,7 +329,7 @@ func (c *GitHubClient) ListRepoWebhooks(ctx context.Context, project string) ([]
 	return allHooks, nil
 }
 
-func (c *GitHubClient) CreateRepoWebhook(ctx context.Context, project string, opts gitProviderClients.CreateWebhookOptions) (*gitProviderClients.Webhook, error) {
+func (c *GitHubClient) createHook(ctx context.Context, hooksPath string, opts gitProviderClients.CreateWebhookOptions) (*gitProviderClients.Webhook, error) {
 	payload := githubHook{
 		Name: "web",
 		Config: githubHookConfig{
@@ -158,7 +346,7 @@ func (c *GitHubClient) CreateRepoWebhook(ctx context.Context, project string, op
 		return nil, fmt.Errorf("marshalling webhook options: %w", err)
 	}
 
-	resp, err := c.doRequest(ctx, http.MethodPost, fmt.Sprintf("/repos/%s/hooks", project), bytes.NewReader(body))
+	resp, err := c.doRequest(ctx, http.MethodPost, hooksPath, bytes.NewReader(body))
 	if err != nil {
 		return nil, fmt.Errorf("creating webhook: %w", err)
 	}
@@ -171,7 +359,7 @@ func (c *GitHubClient) CreateRepoWebhook(ctx context.Context, project string, op
 	return &result, nil
 }
 
-func (c *GitHubClient) UpdateRepoWebhook(ctx context.Context, project string, hookID string, opts gitProviderClients.CreateWebhookOptions) (*gitProviderClients.Webhook, error) {
+func (c *GitHubClient) updateHook(ctx context.Context, hookPath string, opts gitProviderClients.CreateWebhookOptions) (*gitProviderClients.Webhook, error) {
 	payload := githubHook{
 		Config: githubHookConfig{
 			URL:         opts.URL,
@@ -187,7 +375,7 @@ func (c *GitHubClient) UpdateRepoWebhook(ctx context.Context, project string, ho
 		return nil, fmt.Errorf("marshalling webhook options: %w", err)
 	}
 
-	resp, err := c.doRequest(ctx, http.MethodPatch, fmt.Sprintf("/repos/%s/hooks/%s", project, hookID), bytes.NewReader(body))
+	resp, err := c.doRequest(ctx, http.MethodPatch, hookPath, bytes.NewReader(body))
 	if err != nil {
 		return nil, fmt.Errorf("updating webhook: %w", err)
 	}
@@ -200,8 +388,8 @@ func (c *GitHubClient) UpdateRepoWebhook(ctx context.Context, project string, ho
 	return &result, nil
 }
 
-func (c *GitHubClient) DeleteRepoWebhook(ctx context.Context, project string, hookID string) error {
-	resp, err := c.doRequest(ctx, http.MethodDelete, fmt.Sprintf("/repos/%s/hooks/%s", project, hookID), nil)
+func (c *GitHubClient) deleteHook(ctx context.Context, hookPath string) error {
+	resp, err := c.doRequest(ctx, http.MethodDelete, hookPath, nil)
 	if err != nil {
 		return fmt.Errorf("deleting webhook: %w", err)
 	}
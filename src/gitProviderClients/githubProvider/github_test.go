@@ -3,9 +3,12 @@ package githubProvider
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"slices"
 	"testing"
+	"time"
 
 	"renovate-operator/gitProviderClients"
 )
@@ -14,6 +17,65 @@ func newTestClient(url string) *GitHubClient {
 	return &GitHubClient{Endpoint: url, Token: "test-token", HTTPClient: http.DefaultClient}
 }
 
+func TestGetRepositoryInfo(t *testing.T) {
+	handler := http.NewServeMux()
+	handler.HandleFunc("/repos/org/repo1", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"fork": true, "archived": true, "size": 0, "visibility": "internal", "default_branch": "main", "pushed_at": "2026-03-01T10:00:00Z"}`))
+	})
+	handler.HandleFunc("/repos/org/repo1/commits", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusConflict)
+		_, _ = w.Write([]byte(`{"message": "Git Repository is empty."}`))
+	})
+	handler.HandleFunc("/repos/org/repo2", func(w http.ResponseWriter, r *http.Request) {
+		// older GitHub Enterprise Server versions only report "private"
+		_, _ = w.Write([]byte(`{"private": true, "size": 12, "default_branch": "master"}`))
+	})
+	handler.HandleFunc("/repos/org/repo2/commits", func(w http.ResponseWriter, r *http.Request) {
+		t.Error("the commits of a repository with a size should not be listed")
+	})
+	handler.HandleFunc("/repos/org/repo3", func(w http.ResponseWriter, r *http.Request) {
+		// size is computed lazily and reads 0 for small or new repositories
+		_, _ = w.Write([]byte(`{"size": 0, "default_branch": "main"}`))
+	})
+	handler.HandleFunc("/repos/org/repo3/commits", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("per_page") != "1" {
+			t.Errorf("expected a single commit to be listed, got %s", r.URL.RawQuery)
+		}
+		_, _ = w.Write([]byte(`[{"sha": "abc123"}]`))
+	})
+
+	srv := httptest.NewServer(handler)
+	defer srv.Close()
+	c := newTestClient(srv.URL)
+
+	info, err := c.GetRepositoryInfo(context.Background(), "org/repo1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !info.Fork || !info.Archived || !info.Empty || info.Visibility != gitProviderClients.VisibilityInternal || info.DefaultBranch != "main" {
+		t.Errorf("unexpected repository info: %+v", info)
+	}
+	if !info.LastPushAt.Equal(time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)) {
+		t.Errorf("unexpected last push time: %v", info.LastPushAt)
+	}
+
+	info, err = c.GetRepositoryInfo(context.Background(), "org/repo2")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if info.Empty || info.Visibility != gitProviderClients.VisibilityPrivate || !info.LastPushAt.IsZero() {
+		t.Errorf("unexpected repository info: %+v", info)
+	}
+
+	info, err = c.GetRepositoryInfo(context.Background(), "org/repo3")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if info.Empty {
+		t.Errorf("expected a repository of size 0 with commits not to be empty: %+v", info)
+	}
+}
+
 func TestListRepoWebhooks(t *testing.T) {
 	handler := http.NewServeMux()
 	handler.HandleFunc("/repos/org/repo1/hooks", func(w http.ResponseWriter, r *http.Request) {
@@ -96,7 +158,7 @@ func TestUpdateRepoWebhook(t *testing.T) {
 		if payload.Config.Secret != "secret" {
 			t.Errorf("expected HMAC secret, got %s", payload.Config.Secret)
 		}
-		if len(payload.Events) != 2 || payload.Events[0] != "issues" || payload.Events[1] != "pull_request" {
+		if len(payload.Events) != 3 || payload.Events[0] != "issues" || payload.Events[1] != "pull_request" || payload.Events[2] != "push" {
 			t.Errorf("expected the fixed subscription, got %v", payload.Events)
 		}
 
@@ -137,3 +199,139 @@ func TestDeleteRepoWebhook(t *testing.T) {
 		t.Fatalf("unexpected error: %v", err)
 	}
 }
+
+func TestListRepositories_PaginatesAndSkipsArchived(t *testing.T) {
+	handler := http.NewServeMux()
+	handler.HandleFunc("/orgs/org/repos", func(w http.ResponseWriter, r *http.Request) {
+		var repos []githubListedRepo
+		if r.URL.Query().Get("page") == "1" {
+			for i := range 100 {
+				repos = append(repos, githubListedRepo{FullName: fmt.Sprintf("org/repo%d", i)})
+			}
+		} else {
+			repos = []githubListedRepo{
+				{FullName: "org/last", Topics: []string{"renovate"}},
+				{FullName: "org/old", Archived: true},
+			}
+		}
+		_ = json.NewEncoder(w).Encode(repos)
+	})
+
+	srv := httptest.NewServer(handler)
+	defer srv.Close()
+
+	repos, err := newTestClient(srv.URL).ListRepositories(context.Background(), "org")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(repos) != 101 {
+		t.Fatalf("expected 101 repositories, got %d", len(repos))
+	}
+	if last := repos[100]; last.FullName != "org/last" || len(last.Topics) != 1 {
+		t.Errorf("unexpected last repository: %+v", last)
+	}
+}
+
+func TestListRepositories_FallsBackToUser(t *testing.T) {
+	handler := http.NewServeMux()
+	handler.HandleFunc("/orgs/someone/repos", func(w http.ResponseWriter, r *http.Request) {
+		http.NotFound(w, r)
+	})
+	handler.HandleFunc("/users/someone/repos", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`[{"full_name": "someone/dotfiles"}]`))
+	})
+
+	srv := httptest.NewServer(handler)
+	defer srv.Close()
+
+	repos, err := newTestClient(srv.URL).ListRepositories(context.Background(), "someone")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(repos) != 1 || repos[0].FullName != "someone/dotfiles" {
+		t.Errorf("unexpected repositories: %+v", repos)
+	}
+}
+
+func TestListRepositories_InstallationToken(t *testing.T) {
+	handler := http.NewServeMux()
+	handler.HandleFunc("/installation/repositories", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"total_count": 1, "repositories": [{"full_name": "org/app-repo"}]}`))
+	})
+
+	srv := httptest.NewServer(handler)
+	defer srv.Close()
+
+	c := newTestClient(srv.URL)
+	c.Installation = true
+	repos, err := c.ListRepositories(context.Background(), "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(repos) != 1 || repos[0].FullName != "org/app-repo" {
+		t.Errorf("unexpected repositories: %+v", repos)
+	}
+}
+
+func TestListChangedFiles(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/repos/org/repo1/compare/abc...def" {
+			t.Errorf("unexpected path %q", r.URL.Path)
+		}
+		_, _ = w.Write([]byte(`{"files": [{"filename": "renovate.json"}, {"filename": "go.mod", "previous_filename": "old/go.mod"}]}`))
+	}))
+	defer srv.Close()
+
+	files, err := newTestClient(srv.URL).ListChangedFiles(context.Background(), "org/repo1", "abc", "def")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// renamed files are reported under both paths
+	if want := []string{"renovate.json", "go.mod", "old/go.mod"}; !slices.Equal(files, want) {
+		t.Errorf("expected %v, got %v", want, files)
+	}
+}
+
+func TestOrgWebhooks(t *testing.T) {
+	var requests []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.EscapedPath())
+		switch r.Method {
+		case http.MethodGet:
+			_, _ = w.Write([]byte(`[{"id":42,"config":{"url":"https://example.com/webhook"},"active":true}]`))
+		case http.MethodDelete:
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			_, _ = w.Write([]byte(`{"id":42}`))
+		}
+	}))
+	defer srv.Close()
+
+	c := newTestClient(srv.URL)
+	ctx := context.Background()
+	opts := gitProviderClients.CreateWebhookOptions{URL: "https://example.com/webhook", AuthToken: "secret", Active: true}
+
+	hooks, err := c.ListOrgWebhooks(ctx, "org")
+	if err != nil || len(hooks) != 1 || hooks[0].URL != "https://example.com/webhook" {
+		t.Fatalf("unexpected hooks %+v: %v", hooks, err)
+	}
+	if _, err := c.CreateOrgWebhook(ctx, "org", opts); err != nil {
+		t.Fatalf("unexpected error creating: %v", err)
+	}
+	if _, err := c.UpdateOrgWebhook(ctx, "org", "42", opts); err != nil {
+		t.Fatalf("unexpected error updating: %v", err)
+	}
+	if err := c.DeleteOrgWebhook(ctx, "org", "42"); err != nil {
+		t.Fatalf("unexpected error deleting: %v", err)
+	}
+
+	want := []string{
+		"GET /orgs/org/hooks",
+		"POST /orgs/org/hooks",
+		"PATCH /orgs/org/hooks/42",
+		"DELETE /orgs/org/hooks/42",
+	}
+	if !slices.Equal(requests, want) {
+		t.Errorf("expected requests %v, got %v", want, requests)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -11,6 +12,7 @@ import (
 	"renovate-operator/gitProviderClients"
 	"strconv"
 	"strings"
+	"time"
 )
 
 // GitLabClient implements GitProviderClient for the GitLab API.
@@ -20,6 +22,10 @@ type GitLabClient struct {
 	HTTPClient *http.Client
 }
 
+// errNotFound is returned by listProjectPages when the listing endpoint does
+// not exist, e.g. /groups/{name} for a user namespace.
+var errNotFound = errors.New("not found")
+
 func (c *GitLabClient) GetRepositoryInfo(ctx context.Context, project string) (gitProviderClients.RepositoryInfo, error) {
 	// GitLab endpoint already includes /api/v4, project path must be URL-encoded
 	apiURL := fmt.Sprintf("%s/projects/%s", c.Endpoint, url.PathEscape(project))
@@ -44,6 +50,11 @@ func (c *GitLabClient) GetRepositoryInfo(ctx context.Context, project string) (g
 		ForkedFromProject   *json.RawMessage `json:"forked_from_project"`
 		MarkedForDeletionAt *string          `json:"marked_for_deletion_at"`
 		MarkedForDeletionOn *string          `json:"marked_for_deletion_on"`
+		Archived            bool             `json:"archived"`
+		EmptyRepo           bool             `json:"empty_repo"`
+		Visibility          string           `json:"visibility"`
+		DefaultBranch       string           `json:"default_branch"`
+		LastActivityAt      *time.Time       `json:"last_activity_at"`
 	}
 	if err := json.NewDecoder(resp.Body).Decode(&proj); err != nil {
 		return gitProviderClients.RepositoryInfo{}, fmt.Errorf("failed to decode GitLab API response for %s: %w", project, err)
@@ -51,10 +62,101 @@ func (c *GitLabClient) GetRepositoryInfo(ctx context.Context, project string) (g
 
 	pendingDeletion := (proj.MarkedForDeletionAt != nil && *proj.MarkedForDeletionAt != "") ||
 		(proj.MarkedForDeletionOn != nil && *proj.MarkedForDeletionOn != "")
-	return gitProviderClients.RepositoryInfo{
+	info := gitProviderClients.RepositoryInfo{
 		Fork:            proj.ForkedFromProject != nil,
 		PendingDeletion: pendingDeletion,
-	}, nil
+		Archived:        proj.Archived,
+		Empty:           proj.EmptyRepo,
+		Visibility:      proj.Visibility,
+		DefaultBranch:   proj.DefaultBranch,
+	}
+	// GitLab exposes no push timestamp; last_activity_at is the closest
+	// approximation and also moves on pushes.
+	if proj.LastActivityAt != nil {
+		info.LastPushAt = *proj.LastActivityAt
+	}
+	return info, nil
+}
+
+// wire format of the project objects in the GitLab list endpoints
+type gitlabListedProject struct {
+	PathWithNamespace string   `json:"path_with_namespace"`
+	Topics            []string `json:"topics"`
+}
+
+func (c *GitLabClient) ListRepositories(ctx context.Context, namespace string) ([]gitProviderClients.RepositoryListing, error) {
+	if namespace == "" {
+		return c.listProjectPages(ctx, "/projects?membership=true&archived=false")
+	}
+
+	projects, err := c.listProjectPages(ctx, fmt.Sprintf("/groups/%s/projects?include_subgroups=true&archived=false", url.PathEscape(namespace)))
+	if errors.Is(err, errNotFound) {
+		// not a group, so list the user's projects instead
+		return c.listProjectPages(ctx, fmt.Sprintf("/users/%s/projects?archived=false", url.PathEscape(namespace)))
+	}
+	return projects, err
+}
+
+// listProjectPages fetches every page of a project listing.
+func (c *GitLabClient) listProjectPages(ctx context.Context, path string) ([]gitProviderClients.RepositoryListing, error) {
+	var listings []gitProviderClients.RepositoryListing
+	page := 1
+	limit := 100
+
+	for {
+		resp, err := c.doRequest(ctx, http.MethodGet, fmt.Sprintf("%s&per_page=%d&page=%d", path, limit, page), nil)
+		if err != nil {
+			return nil, fmt.Errorf("listing projects: %w", err)
+		}
+		if resp.StatusCode == http.StatusNotFound {
+			_ = resp.Body.Close()
+			return nil, errNotFound
+		}
+
+		var projects []gitlabListedProject
+		if err := decodeResponse(resp, &projects); err != nil {
+			return nil, fmt.Errorf("listing projects: %w", err)
+		}
+
+		for _, project := range projects {
+			listings = append(listings, gitProviderClients.RepositoryListing{FullName: project.PathWithNamespace, Topics: project.Topics})
+		}
+		if len(projects) < limit {
+			break
+		}
+		page++
+	}
+
+	return listings, nil
+}
+
+// ListChangedFiles compares the two commits through the repository compare
+// API, which diffs head against its merge base with base.
+func (c *GitLabClient) ListChangedFiles(ctx context.Context, project, base, head string) ([]string, error) {
+	path := fmt.Sprintf("/projects/%s/repository/compare?from=%s&to=%s", url.PathEscape(project), url.QueryEscape(base), url.QueryEscape(head))
+	resp, err := c.doRequest(ctx, http.MethodGet, path, nil)
+	if err != nil {
+		return nil, fmt.Errorf("comparing commits: %w", err)
+	}
+
+	var comparison struct {
+		Diffs []struct {
+			OldPath string `json:"old_path"`
+			NewPath string `json:"new_path"`
+		} `json:"diffs"`
+	}
+	if err := decodeResponse(resp, &comparison); err != nil {
+		return nil, fmt.Errorf("comparing commits: %w", err)
+	}
+
+	var files []string
+	for _, diff := range comparison.Diffs {
+		files = append(files, diff.NewPath)
+		if diff.OldPath != diff.NewPath {
+			files = append(files, diff.OldPath)
+		}
+	}
+	return files, nil
 }
 
 func (c *GitLabClient) doRequest(ctx context.Context, method, path string, body io.Reader) (*http.Response, error) {
@@ -89,19 +191,55 @@ func (h gitlabHook) toWebhook() gitProviderClients.Webhook {
 		// GitLab hooks have no enabled/disabled state — existing means active.
 		Active: true,
 		// Operator-managed hooks subscribe to exactly issue + merge request
-		// events (the Renovate checkbox triggers).
-		EventsUpToDate: h.IssuesEvents && h.MergeRequestsEvents &&
-			!h.PushEvents && !h.NoteEvents && !h.TagPushEvents,
+		// events (the Renovate checkbox triggers) and push events (the push
+		// trigger).
+		EventsUpToDate: h.IssuesEvents && h.MergeRequestsEvents && h.PushEvents &&
+			!h.NoteEvents && !h.TagPushEvents,
 	}
 }
 
 func (c *GitLabClient) ListRepoWebhooks(ctx context.Context, project string) ([]gitProviderClients.Webhook, error) {
+	return c.listHooks(ctx, fmt.Sprintf("/projects/%s/hooks", url.PathEscape(project)))
+}
+
+func (c *GitLabClient) CreateRepoWebhook(ctx context.Context, project string, opts gitProviderClients.CreateWebhookOptions) (*gitProviderClients.Webhook, error) {
+	return c.createHook(ctx, fmt.Sprintf("/projects/%s/hooks", url.PathEscape(project)), opts)
+}
+
+func (c *GitLabClient) UpdateRepoWebhook(ctx context.Context, project string, hookID string, opts gitProviderClients.CreateWebhookOptions) (*gitProviderClients.Webhook, error) {
+	return c.updateHook(ctx, fmt.Sprintf("/projects/%s/hooks/%s", url.PathEscape(project), hookID), opts)
+}
+
+func (c *GitLabClient) DeleteRepoWebhook(ctx context.Context, project string, hookID string) error {
+	return c.deleteHook(ctx, fmt.Sprintf("/projects/%s/hooks/%s", url.PathEscape(project), hookID))
+}
+
+// Group hooks receive the events of every project of the group and of its
+// subgroups. They take the same payload as project hooks.
+
+func (c *GitLabClient) ListOrgWebhooks(ctx context.Context, org string) ([]gitProviderClients.Webhook, error) {
+	return c.listHooks(ctx, fmt.Sprintf("/groups/%s/hooks", url.PathEscape(org)))
+}
+
+func (c *GitLabClient) CreateOrgWebhook(ctx context.Context, org string, opts gitProviderClients.CreateWebhookOptions) (*gitProviderClients.Webhook, error) {
+	return c.createHook(ctx, fmt.Sprintf("/groups/%s/hooks", url.PathEscape(org)), opts)
+}
+
+func (c *GitLabClient) UpdateOrgWebhook(ctx context.Context, org string, hookID string, opts gitProviderClients.CreateWebhookOptions) (*gitProviderClients.Webhook, error) {
+	return c.updateHook(ctx, fmt.Sprintf("/groups/%s/hooks/%s", url.PathEscape(org), hookID), opts)
+}
+
+func (c *GitLabClient) DeleteOrgWebhook(ctx context.Context, org string, hookID string) error {
+	return c.deleteHook(ctx, fmt.Sprintf("/groups/%s/hooks/%s", url.PathEscape(org), hookID))
+}
+
+func (c *GitLabClient) listHooks(ctx context.Context, hooksPath string) ([]gitProviderClients.Webhook, error) {
 	var allHooks []gitProviderClients.Webhook
 	page := 1
 	limit := 50
 
 	for {
-		path := fmt.Sprintf("/projects/%s/hooks?per_page=%d&page=%d", url.PathEscape(project), limit, page)
+		path := fmt.Sprintf("%s?per_page=%d&page=%d", hooksPath, limit, page)
 		resp, err := c.doRequest(ctx, http.MethodGet, path, nil)
 		if err != nil {
 			return nil, fmt.Errorf("listing webhooks: %w", err)
@@ -127,14 +265,16 @@ func (c *GitLabClient) ListRepoWebhooks(ctx context.Context, project string) ([]
 	return allHooks, nil
 }
 
-func (c *GitLabClient) CreateRepoWebhook(ctx context.Context, project string, opts gitProviderClients.CreateWebhookOptions) (*gitProviderClients.Webhook, error) {
+func (c *GitLabClient) createHook(ctx context.Context, hooksPath string, opts gitProviderClients.CreateWebhookOptions) (*gitProviderClients.Webhook, error) {
 	payload := gitlabHook{
 		URL: opts.URL,
 		// GitLab sends the token back in the X-Gitlab-Token header.
 		Token: opts.AuthToken,
-		// fixed subscription: the events used by the Renovate checkbox triggers
+		// fixed subscription: the events used by the Renovate checkbox
+		// triggers and the push trigger
 		IssuesEvents:        true,
 		MergeRequestsEvents: true,
+		PushEvents:          true,
 	}
 
 	body, err := json.Marshal(payload)
@@ -142,7 +282,7 @@ func (c *GitLabClient) CreateRepoWebhook(ctx context.Context, project string, op
 		return nil, fmt.Errorf("marshalling webhook options: %w", err)
 	}
 
-	resp, err := c.doRequest(ctx, http.MethodPost, fmt.Sprintf("/projects/%s/hooks", url.PathEscape(project)), bytes.NewReader(body))
+	resp, err := c.doRequest(ctx, http.MethodPost, hooksPath, bytes.NewReader(body))
 	if err != nil {
 		return nil, fmt.Errorf("creating webhook: %w", err)
 	}
@@ -155,13 +295,15 @@ func (c *GitLabClient) CreateRepoWebhook(ctx context.Context, project string, op
 	return &result, nil
 }
 
-func (c *GitLabClient) UpdateRepoWebhook(ctx context.Context, project string, hookID string, opts gitProviderClients.CreateWebhookOptions) (*gitProviderClients.Webhook, error) {
+func (c *GitLabClient) updateHook(ctx context.Context, hookPath string, opts gitProviderClients.CreateWebhookOptions) (*gitProviderClients.Webhook, error) {
 	payload := gitlabHook{
 		URL:   opts.URL,
 		Token: opts.AuthToken,
-		// fixed subscription: the events used by the Renovate checkbox triggers
+		// fixed subscription: the events used by the Renovate checkbox
+		// triggers and the push trigger
 		IssuesEvents:        true,
 		MergeRequestsEvents: true,
+		PushEvents:          true,
 	}
 
 	body, err := json.Marshal(payload)
@@ -169,8 +311,7 @@ func (c *GitLabClient) UpdateRepoWebhook(ctx context.Context, project string, ho
 		return nil, fmt.Errorf("marshalling webhook options: %w", err)
 	}
 
-	path := fmt.Sprintf("/projects/%s/hooks/%s", url.PathEscape(project), hookID)
-	resp, err := c.doRequest(ctx, http.MethodPut, path, bytes.NewReader(body))
+	resp, err := c.doRequest(ctx, http.MethodPut, hookPath, bytes.NewReader(body))
 	if err != nil {
 		return nil, fmt.Errorf("updating webhook: %w", err)
 	}
@@ -183,9 +324,8 @@ func (c *GitLabClient) UpdateRepoWebhook(ctx context.Context, project string, ho
 	return &result, nil
 }
 
-func (c *GitLabClient) DeleteRepoWebhook(ctx context.Context, project string, hookID string) error {
-	path := fmt.Sprintf("/projects/%s/hooks/%s", url.PathEscape(project), hookID)
-	resp, err := c.doRequest(ctx, http.MethodDelete, path, nil)
+func (c *GitLabClient) deleteHook(ctx context.Context, hookPath string) error {
+	resp, err := c.doRequest(ctx, http.MethodDelete, hookPath, nil)
 	if err != nil {
 		return fmt.Errorf("deleting webhook: %w", err)
 	}
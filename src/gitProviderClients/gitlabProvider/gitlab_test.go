@@ -3,10 +3,13 @@ package gitlabProvider
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"renovate-operator/gitProviderClients"
+	"slices"
 	"testing"
+	"time"
 )
 
 func TestGetRepositoryInfo(t *testing.T) {
@@ -40,6 +43,17 @@ func TestGetRepositoryInfo(t *testing.T) {
 			body: `{"marked_for_deletion_at": ""}`,
 			want: gitProviderClients.RepositoryInfo{},
 		},
+		{
+			name: "metadata",
+			body: `{"archived": true, "empty_repo": true, "visibility": "internal", "default_branch": "main", "last_activity_at": "2026-03-01T10:00:00Z"}`,
+			want: gitProviderClients.RepositoryInfo{
+				Archived:      true,
+				Empty:         true,
+				Visibility:    gitProviderClients.VisibilityInternal,
+				DefaultBranch: "main",
+				LastPushAt:    time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC),
+			},
+		},
 		{
 			name: "fork and pending deletion",
 			body: `{"forked_from_project": {"id": 1}, "marked_for_deletion_at": "2026-01-01"}`,
@@ -62,6 +76,10 @@ func TestGetRepositoryInfo(t *testing.T) {
 			if err != nil {
 				t.Fatalf("unexpected error: %v", err)
 			}
+			if !got.LastPushAt.Equal(tt.want.LastPushAt) {
+				t.Errorf("expected last push %v, got %v", tt.want.LastPushAt, got.LastPushAt)
+			}
+			got.LastPushAt, tt.want.LastPushAt = time.Time{}, time.Time{}
 			if got != tt.want {
 				t.Errorf("expected %+v, got %+v", tt.want, got)
 			}
@@ -79,7 +97,7 @@ func TestListRepoWebhooks(t *testing.T) {
 			t.Errorf("expected private token header, got %q", r.Header.Get("PRIVATE-TOKEN"))
 		}
 		hooks := []gitlabHook{
-			{ID: 1, URL: "https://example.com/webhook", IssuesEvents: true, MergeRequestsEvents: true},
+			{ID: 1, URL: "https://example.com/webhook", IssuesEvents: true, MergeRequestsEvents: true, PushEvents: true},
 		}
 		_ = json.NewEncoder(w).Encode(hooks)
 	}))
@@ -95,9 +113,9 @@ func TestListRepoWebhooks(t *testing.T) {
 	if hooks[0].URL != "https://example.com/webhook" {
 		t.Errorf("expected webhook URL, got %s", hooks[0].URL)
 	}
-	// issue + MR flags without extras means the subscription is up to date
+	// issue + MR + push flags without extras means the subscription is up to date
 	if !hooks[0].EventsUpToDate {
-		t.Error("expected hook with issue+MR flags to report EventsUpToDate")
+		t.Error("expected hook with issue+MR+push flags to report EventsUpToDate")
 	}
 }
 
@@ -118,11 +136,11 @@ func TestCreateRepoWebhook(t *testing.T) {
 		if payload.Token != "secret" {
 			t.Errorf("expected hook token, got %s", payload.Token)
 		}
-		if !payload.IssuesEvents || !payload.MergeRequestsEvents {
-			t.Errorf("expected issues+merge request events enabled, got %+v", payload)
+		if !payload.IssuesEvents || !payload.MergeRequestsEvents || !payload.PushEvents {
+			t.Errorf("expected issues+merge request+push events enabled, got %+v", payload)
 		}
-		if payload.PushEvents {
-			t.Error("expected push events disabled")
+		if payload.TagPushEvents || payload.NoteEvents {
+			t.Error("expected tag push and note events disabled")
 		}
 
 		w.WriteHeader(http.StatusCreated)
@@ -200,3 +218,130 @@ func TestDeleteRepoWebhook(t *testing.T) {
 func newTestClient(url string) *GitLabClient {
 	return &GitLabClient{Endpoint: url, Token: "test-token", HTTPClient: http.DefaultClient}
 }
+
+func TestListRepositories_GroupPagination(t *testing.T) {
+	handler := http.NewServeMux()
+	handler.HandleFunc("/groups/group%2Fsub/projects", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("include_subgroups") != "true" || r.URL.Query().Get("archived") != "false" {
+			t.Errorf("expected subgroups and no archived projects, got %s", r.URL.RawQuery)
+		}
+		var projects []gitlabListedProject
+		if r.URL.Query().Get("page") == "1" {
+			for i := range 100 {
+				projects = append(projects, gitlabListedProject{PathWithNamespace: fmt.Sprintf("group/sub/p%d", i)})
+			}
+		} else {
+			projects = []gitlabListedProject{{PathWithNamespace: "group/sub/deep/last", Topics: []string{"renovate"}}}
+		}
+		_ = json.NewEncoder(w).Encode(projects)
+	})
+
+	srv := httptest.NewServer(handler)
+	defer srv.Close()
+
+	repos, err := newTestClient(srv.URL).ListRepositories(context.Background(), "group/sub")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(repos) != 101 {
+		t.Fatalf("expected 101 projects, got %d", len(repos))
+	}
+	if last := repos[100]; last.FullName != "group/sub/deep/last" || len(last.Topics) != 1 {
+		t.Errorf("unexpected last project: %+v", last)
+	}
+}
+
+func TestListRepositories_MembershipAndUserFallback(t *testing.T) {
+	handler := http.NewServeMux()
+	handler.HandleFunc("/projects", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("membership") != "true" {
+			t.Errorf("expected membership projects, got %s", r.URL.RawQuery)
+		}
+		_, _ = w.Write([]byte(`[{"path_with_namespace": "group/member"}]`))
+	})
+	handler.HandleFunc("/groups/someone/projects", func(w http.ResponseWriter, r *http.Request) {
+		http.NotFound(w, r)
+	})
+	handler.HandleFunc("/users/someone/projects", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`[{"path_with_namespace": "someone/dotfiles"}]`))
+	})
+
+	srv := httptest.NewServer(handler)
+	defer srv.Close()
+	c := newTestClient(srv.URL)
+
+	repos, err := c.ListRepositories(context.Background(), "")
+	if err != nil || len(repos) != 1 || repos[0].FullName != "group/member" {
+		t.Fatalf("unexpected membership listing: %+v, %v", repos, err)
+	}
+	repos, err = c.ListRepositories(context.Background(), "someone")
+	if err != nil || len(repos) != 1 || repos[0].FullName != "someone/dotfiles" {
+		t.Fatalf("unexpected user listing: %+v, %v", repos, err)
+	}
+}
+
+func TestListChangedFiles(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.EscapedPath() != "/projects/group%2Frepo1/repository/compare" {
+			t.Errorf("unexpected path %q", r.URL.EscapedPath())
+		}
+		if r.URL.Query().Get("from") != "abc" || r.URL.Query().Get("to") != "def" {
+			t.Errorf("unexpected query %q", r.URL.RawQuery)
+		}
+		_, _ = w.Write([]byte(`{"diffs": [{"old_path": "renovate.json", "new_path": "renovate.json"}, {"old_path": "a/package.json", "new_path": "b/package.json"}]}`))
+	}))
+	defer srv.Close()
+
+	files, err := newTestClient(srv.URL).ListChangedFiles(context.Background(), "group/repo1", "abc", "def")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := []string{"renovate.json", "b/package.json", "a/package.json"}; !slices.Equal(files, want) {
+		t.Errorf("expected %v, got %v", want, files)
+	}
+}
+
+func TestOrgWebhooks(t *testing.T) {
+	var requests []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.EscapedPath())
+		switch r.Method {
+		case http.MethodGet:
+			_, _ = w.Write([]byte(`[{"id":42,"url":"https://example.com/webhook"}]`))
+		case http.MethodDelete:
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			_, _ = w.Write([]byte(`{"id":42}`))
+		}
+	}))
+	defer srv.Close()
+
+	c := newTestClient(srv.URL)
+	ctx := context.Background()
+	opts := gitProviderClients.CreateWebhookOptions{URL: "https://example.com/webhook", AuthToken: "secret", Active: true}
+
+	hooks, err := c.ListOrgWebhooks(ctx, "group/sub")
+	if err != nil || len(hooks) != 1 || hooks[0].URL != "https://example.com/webhook" {
+		t.Fatalf("unexpected hooks %+v: %v", hooks, err)
+	}
+	if _, err := c.CreateOrgWebhook(ctx, "group/sub", opts); err != nil {
+		t.Fatalf("unexpected error creating: %v", err)
+	}
+	if _, err := c.UpdateOrgWebhook(ctx, "group/sub", "42", opts); err != nil {
+		t.Fatalf("unexpected error updating: %v", err)
+	}
+	if err := c.DeleteOrgWebhook(ctx, "group/sub", "42"); err != nil {
+		t.Fatalf("unexpected error deleting: %v", err)
+	}
+
+	// group hooks of a subgroup are addressed by the escaped full path
+	want := []string{
+		"GET /groups/group%2Fsub/hooks",
+		"POST /groups/group%2Fsub/hooks",
+		"PUT /groups/group%2Fsub/hooks/42",
+		"DELETE /groups/group%2Fsub/hooks/42",
+	}
+	if !slices.Equal(requests, want) {
+		t.Errorf("expected requests %v, got %v", want, requests)
+	}
+}
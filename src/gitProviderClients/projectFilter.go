@@ -2,31 +2,65 @@ package gitProviderClients
 
 import (
 	"context"
+	"slices"
 	"sync"
+	"time"
 
 	"github.com/go-logr/logr"
 )
 
+// FilterOptions selects the criteria FilterProjects applies.
+type FilterOptions struct {
+	// SkipForks excludes forked repositories.
+	SkipForks bool
+	// SkipPendingDeletion excludes repositories marked for delayed deletion
+	// (only GitLab reports this state).
+	SkipPendingDeletion bool
+	// SkipArchived excludes archived repositories.
+	SkipArchived bool
+	// SkipEmpty excludes repositories without any commits.
+	SkipEmpty bool
+	// InactiveFor excludes repositories whose last push is older than this
+	// duration. Zero disables the criterion.
+	InactiveFor time.Duration
+	// Visibility keeps only repositories with one of the listed visibilities.
+	// Empty disables the criterion.
+	Visibility []string
+}
+
+// Enabled reports whether any criterion is set.
+func (o FilterOptions) Enabled() bool {
+	return o.SkipForks || o.SkipPendingDeletion || o.SkipArchived || o.SkipEmpty || o.InactiveFor > 0 || len(o.Visibility) > 0
+}
+
 // FilterStats reports how many repositories were dropped by each criterion so
 // callers (which know the namespace/job labels) can record the
 // repositories_filtered metric.
 type FilterStats struct {
-	ForksRemoved   int
-	PendingRemoved int
+	ForksRemoved      int
+	PendingRemoved    int
+	ArchivedRemoved   int
+	EmptyRemoved      int
+	InactiveRemoved   int
+	VisibilityRemoved int
+}
+
+// Total returns the number of repositories dropped by any criterion.
+func (s FilterStats) Total() int {
+	return s.ForksRemoved + s.PendingRemoved + s.ArchivedRemoved + s.EmptyRemoved + s.InactiveRemoved + s.VisibilityRemoved
 }
 
 // FilterProjects filters out repositories that should be skipped during
-// discovery according to the enabled criteria:
-//   - skipForks excludes forked repositories
-//   - skipPendingDeletion excludes repositories marked for delayed deletion
-//     (only GitLab reports this state)
+// discovery according to the enabled criteria in opts. Each repository is
+// counted against the first criterion that drops it.
 //
 // Repository metadata is fetched once per project (a single platform API call),
-// so enabling both criteria does not double the number of requests. On API
+// so enabling several criteria does not multiply the number of requests. On API
 // errors for individual repos, the repo is kept (fail-open) to avoid
-// accidentally excluding valid projects.
-func FilterProjects(ctx context.Context, providerClient GitProviderClient, logger logr.Logger, projects []string, skipForks, skipPendingDeletion bool) ([]string, FilterStats, error) {
-	if len(projects) == 0 || (!skipForks && !skipPendingDeletion) {
+// accidentally excluding valid projects. Attributes a platform does not report
+// (an unknown visibility or last push time) likewise keep the repo.
+func FilterProjects(ctx context.Context, providerClient GitProviderClient, logger logr.Logger, projects []string, opts FilterOptions) ([]string, FilterStats, error) {
+	if len(projects) == 0 || !opts.Enabled() {
 		return projects, FilterStats{}, nil
 	}
 
@@ -55,8 +89,13 @@ func FilterProjects(ctx context.Context, providerClient GitProviderClient, logge
 	}
 	wg.Wait()
 
+	var inactiveBefore time.Time
+	if opts.InactiveFor > 0 {
+		inactiveBefore = time.Now().Add(-opts.InactiveFor)
+	}
+
 	filtered := make([]string, 0, len(projects))
-	var forksRemoved, pendingRemoved int
+	var stats FilterStats
 	for _, r := range results {
 		if r.err != nil {
 			// Fail-open: keep the repo when its metadata could not be fetched,
@@ -65,21 +104,39 @@ func FilterProjects(ctx context.Context, providerClient GitProviderClient, logge
 			filtered = append(filtered, r.project)
 			continue
 		}
-		if skipForks && r.info.Fork {
-			forksRemoved++
+		switch {
+		case opts.SkipForks && r.info.Fork:
+			stats.ForksRemoved++
 			logger.V(2).Info("Excluding forked repository", "project", r.project)
-			continue
-		}
-		if skipPendingDeletion && r.info.PendingDeletion {
-			pendingRemoved++
+		case opts.SkipPendingDeletion && r.info.PendingDeletion:
+			stats.PendingRemoved++
 			logger.V(2).Info("Excluding pending-deletion repository", "project", r.project)
-			continue
+		case opts.SkipArchived && r.info.Archived:
+			stats.ArchivedRemoved++
+			logger.V(2).Info("Excluding archived repository", "project", r.project)
+		case opts.SkipEmpty && r.info.Empty:
+			stats.EmptyRemoved++
+			logger.V(2).Info("Excluding empty repository", "project", r.project)
+		case !inactiveBefore.IsZero() && !r.info.LastPushAt.IsZero() && r.info.LastPushAt.Before(inactiveBefore):
+			stats.InactiveRemoved++
+			logger.V(2).Info("Excluding inactive repository", "project", r.project, "lastPush", r.info.LastPushAt)
+		case len(opts.Visibility) > 0 && r.info.Visibility != "" && !slices.Contains(opts.Visibility, r.info.Visibility):
+			stats.VisibilityRemoved++
+			logger.V(2).Info("Excluding repository by visibility", "project", r.project, "visibility", r.info.Visibility)
+		default:
+			filtered = append(filtered, r.project)
 		}
-		filtered = append(filtered, r.project)
 	}
 
-	if forksRemoved > 0 || pendingRemoved > 0 {
-		logger.Info("Filtered discovered repositories", "forks", forksRemoved, "pendingDeletion", pendingRemoved, "remaining", len(filtered))
+	if stats.Total() > 0 {
+		logger.Info("Filtered discovered repositories",
+			"forks", stats.ForksRemoved,
+			"pendingDeletion", stats.PendingRemoved,
+			"archived", stats.ArchivedRemoved,
+			"empty", stats.EmptyRemoved,
+			"inactive", stats.InactiveRemoved,
+			"visibility", stats.VisibilityRemoved,
+			"remaining", len(filtered))
 	}
-	return filtered, FilterStats{ForksRemoved: forksRemoved, PendingRemoved: pendingRemoved}, nil
+	return filtered, stats, nil
 }
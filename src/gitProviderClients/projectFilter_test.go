@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/go-logr/logr"
 )
@@ -80,7 +81,7 @@ func TestFilterProjects(t *testing.T) {
 			}
 
 			logger := logr.Logger{}
-			result, _, err := FilterProjects(context.Background(), client, logger, tt.projects, tt.skipForks, tt.skipPendingDeletion)
+			result, _, err := FilterProjects(context.Background(), client, logger, tt.projects, FilterOptions{SkipForks: tt.skipForks, SkipPendingDeletion: tt.skipPendingDeletion})
 			if err != nil {
 				t.Fatalf("unexpected error: %v", err)
 			}
@@ -91,8 +92,47 @@ func TestFilterProjects(t *testing.T) {
 	}
 }
 
+func TestFilterProjects_MetadataCriteria(t *testing.T) {
+	now := time.Now()
+	infos := map[string]RepositoryInfo{
+		"archived": {Archived: true, Visibility: VisibilityPrivate, LastPushAt: now},
+		"empty":    {Empty: true, Visibility: VisibilityPrivate},
+		"stale":    {Visibility: VisibilityPrivate, LastPushAt: now.Add(-200 * 24 * time.Hour)},
+		"public":   {Visibility: VisibilityPublic, LastPushAt: now},
+		"active":   {Visibility: VisibilityPrivate, LastPushAt: now.Add(-time.Hour)},
+		"unknown":  {},
+	}
+	projects := []string{"archived", "empty", "stale", "public", "active", "unknown"}
+
+	client := &mockGitProviderClient{
+		getRepositoryInfoFunc: func(ctx context.Context, project string) (RepositoryInfo, error) {
+			return infos[project], nil
+		},
+	}
+
+	result, stats, err := FilterProjects(context.Background(), client, logr.Logger{}, projects, FilterOptions{
+		SkipArchived: true,
+		SkipEmpty:    true,
+		InactiveFor:  180 * 24 * time.Hour,
+		Visibility:   []string{VisibilityPrivate},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// unknown visibility and last push time keep the repo (fail-open)
+	expected := []string{"active", "unknown"}
+	if !equalSlices(result, expected) {
+		t.Errorf("expected %v, got %v", expected, result)
+	}
+	want := FilterStats{ArchivedRemoved: 1, EmptyRemoved: 1, InactiveRemoved: 1, VisibilityRemoved: 1}
+	if stats != want {
+		t.Errorf("expected stats %+v, got %+v", want, stats)
+	}
+}
+
 type mockGitProviderClient struct {
 	getRepositoryInfoFunc func(ctx context.Context, project string) (RepositoryInfo, error)
+	listRepositoriesFunc  func(ctx context.Context, namespace string) ([]RepositoryListing, error)
 }
 
 func (m *mockGitProviderClient) GetRepositoryInfo(ctx context.Context, project string) (RepositoryInfo, error) {
@@ -102,6 +142,17 @@ func (m *mockGitProviderClient) GetRepositoryInfo(ctx context.Context, project s
 	return RepositoryInfo{}, nil
 }
 
+func (m *mockGitProviderClient) ListRepositories(ctx context.Context, namespace string) ([]RepositoryListing, error) {
+	if m.listRepositoriesFunc != nil {
+		return m.listRepositoriesFunc(ctx, namespace)
+	}
+	return nil, nil
+}
+
+func (m *mockGitProviderClient) ListChangedFiles(ctx context.Context, project, base, head string) ([]string, error) {
+	return nil, fmt.Errorf("comparing commits is not supported")
+}
+
 func (c *mockGitProviderClient) ListRepoWebhooks(ctx context.Context, project string) ([]Webhook, error) {
 	return nil, fmt.Errorf("listing webhooks is not supported")
 }
@@ -118,6 +169,22 @@ func (c *mockGitProviderClient) DeleteRepoWebhook(ctx context.Context, project s
 	return fmt.Errorf("deleting webhooks is not supported")
 }
 
+func (c *mockGitProviderClient) ListOrgWebhooks(ctx context.Context, org string) ([]Webhook, error) {
+	return nil, ErrOrgWebhooksUnsupported
+}
+
+func (c *mockGitProviderClient) CreateOrgWebhook(ctx context.Context, org string, opts CreateWebhookOptions) (*Webhook, error) {
+	return nil, ErrOrgWebhooksUnsupported
+}
+
+func (c *mockGitProviderClient) UpdateOrgWebhook(ctx context.Context, org string, hookID string, opts CreateWebhookOptions) (*Webhook, error) {
+	return nil, ErrOrgWebhooksUnsupported
+}
+
+func (c *mockGitProviderClient) DeleteOrgWebhook(ctx context.Context, org string, hookID string) error {
+	return ErrOrgWebhooksUnsupported
+}
+
 func equalSlices(a, b []string) bool {
 	if len(a) != len(b) {
 		return false
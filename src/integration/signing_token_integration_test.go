@@ -156,8 +156,8 @@ func startWebhookServer(t *testing.T) (string, crdmanager.RenovateJobManager) {
 		WithStatusSubresource(&api.RenovateJob{}).
 		Build()
 
-	mgr := crdmanager.NewRenovateJobManager(cl, nil, logr.Discard(), nil, nil, policy.Policy{})
-	webhook.NewWebookServer(mgr, logr.Discard()).Run()
+	mgr := crdmanager.NewRenovateJobManager(cl, nil, logr.Discard(), nil, nil, policy.Policy{}, nil, nil, nil, nil)
+	webhook.NewWebookServer(mgr, logr.Discard(), 0, nil).Run()
 
 	baseURL := "http://127.0.0.1:" + port
 	waitReady(t, baseURL)
@@ -0,0 +1,223 @@
+package apiTokens
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"renovate-operator/internal/kvstore"
+
+	"github.com/go-logr/logr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// ErrTokenNotFound is returned when a token does not (or no longer) exist.
+var ErrTokenNotFound = errors.New("API token not found")
+
+// ErrInvalidToken is returned by Verify for a credential that is malformed,
+// unknown, revoked or expired. The cases are deliberately not told apart.
+var ErrInvalidToken = errors.New("invalid API token")
+
+// Prefix starts every API token, so a Bearer credential can be recognized as
+// one without a lookup, and a leaked token can be found by secret scanners.
+const Prefix = "rop_"
+
+// AllRenovateJobs as the RenovateJob of a Scope matches every job of its
+// namespace, including jobs created after the token.
+const AllRenovateJobs = "*"
+
+// Owner is the identity a token acts as, captured when it is minted. Access
+// rules are evaluated against it on every request, so removing the owner from
+// a job's access revokes the token's access to that job; removing them from a
+// group at the identity provider does not, until the token expires.
+//
+// A service token has a Service owner instead of a user: it is minted by an
+// admin for a named service, such as a CI pipeline, and does not act as any
+// user, so it keeps working after whoever minted it leaves.
+type Owner struct {
+	// Subject is the stable, provider qualified identifier of the user, e.g.
+	// "oidc:<sub>".
+	Subject       string   `json:"subject,omitempty"`
+	Email         string   `json:"email,omitempty"`
+	Name          string   `json:"name,omitempty"`
+	Username      string   `json:"username,omitempty"`
+	EmailVerified bool     `json:"emailVerified,omitempty"`
+	Groups        []string `json:"groups,omitempty"`
+	// Service names the service a service token belongs to.
+	Service string `json:"service,omitempty"`
+}
+
+// ServiceOwnerKey is the Key of every service token, so they are listed
+// together whichever service they belong to.
+const ServiceOwnerKey = "services"
+
+// Key identifies the owner across tokens: the subject, or else the verified
+// email. Usernames and unverified emails are not unique across identities, so
+// an owner with neither has no key and cannot hold tokens.
+func (o Owner) Key() string {
+	switch {
+	case o.Service != "":
+		return ServiceOwnerKey
+	case o.Subject != "":
+		return o.Subject
+	case o.EmailVerified && o.Email != "":
+		return "email:" + strings.ToLower(o.Email)
+	default:
+		return ""
+	}
+}
+
+// Scope grants a token access to a RenovateJob, or to every job of a
+// namespace. Permissions are the permission strings of the UI API; a scope
+// without permissions still allows reading the job.
+type Scope struct {
+	Namespace   string   `json:"namespace"`
+	RenovateJob string   `json:"renovateJob"`
+	Permissions []string `json:"permissions"`
+}
+
+// Matches reports whether the scope covers the job.
+func (s Scope) Matches(namespace, renovateJob string) bool {
+	return s.Namespace == namespace && (s.RenovateJob == AllRenovateJobs || s.RenovateJob == renovateJob)
+}
+
+// Token is a stored API token. Only the hash of its secret is kept; the
+// plaintext is handed out once, when the token is minted.
+type Token struct {
+	ID     string  `json:"id"`
+	Name   string  `json:"name"`
+	Owner  Owner   `json:"owner"`
+	Scopes []Scope `json:"scopes"`
+	// CreatedBy names the admin who minted a service token.
+	CreatedBy string    `json:"createdBy,omitempty"`
+	CreatedAt time.Time `json:"createdAt"`
+	ExpiresAt time.Time `json:"expiresAt"`
+	// Hash is the hex encoded SHA-256 of the secret part of the token.
+	Hash string `json:"hash"`
+}
+
+// IsService reports whether the token belongs to a service rather than a user.
+func (t *Token) IsService() bool {
+	return t.Owner.Service != ""
+}
+
+// Expired reports whether the token is past its expiry at now.
+func (t *Token) Expired(now time.Time) bool {
+	return !now.Before(t.ExpiresAt)
+}
+
+// Store keeps the API tokens.
+type Store interface {
+	// Save adds or replaces a token.
+	Save(ctx context.Context, token Token) error
+	// Get returns a token, or ErrTokenNotFound. Expired tokens may still be
+	// returned; Verify checks the expiry.
+	Get(ctx context.Context, id string) (Token, error)
+	// List returns the unexpired tokens of an owner, by Owner.Key.
+	List(ctx context.Context, owner string) ([]Token, error)
+	// Delete removes a token, or returns ErrTokenNotFound.
+	Delete(ctx context.Context, id string) error
+}
+
+// NewStore creates a Store based on the provided mode.
+// Supported modes: "disabled" (returns nil), "memory" (in-memory store, per
+// replica and lost on restart), "valkey" (Valkey-backed, shared by all
+// replicas) and "secret" (a Secret named secretName in namespace).
+func NewStore(logger logr.Logger, mode string, valkeyCfg kvstore.ValkeyConfig, c client.Client, namespace, secretName string) (Store, error) {
+	switch mode {
+	case "memory":
+		return newMemoryStore(), nil
+	case "valkey":
+		kv, err := kvstore.NewKVStore(valkeyCfg, kvstore.UsageAPITokens)
+		if err != nil {
+			return nil, err
+		}
+		hashes, ok := kv.(hashKVStore)
+		if !ok {
+			return nil, errors.New("the API tokens need a Valkey store that keeps hashes")
+		}
+		return newKVStore(hashes, logger), nil
+	case "secret":
+		if namespace == "" || secretName == "" {
+			return nil, fmt.Errorf("the secret API token store needs a namespace and a secret name")
+		}
+		return newSecretStore(c, namespace, secretName), nil
+	case "disabled", "":
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("unknown API token store mode %q", mode)
+	}
+}
+
+// New mints a token expiring after ttl and returns it together with its
+// plaintext, which is not kept anywhere and must be shown to the owner.
+func New(name string, owner Owner, scopes []Scope, ttl time.Duration, now time.Time) (Token, string) {
+	id := rand.Text()
+	secret := rand.Text()
+	token := Token{
+		ID:        id,
+		Name:      name,
+		Owner:     owner,
+		Scopes:    scopes,
+		CreatedAt: now,
+		ExpiresAt: now.Add(ttl),
+		Hash:      hash(secret),
+	}
+	return token, Prefix + id + "_" + secret
+}
+
+// Verify returns the token a plaintext credential belongs to, or
+// ErrInvalidToken. Errors of the store other than a missing token are
+// returned as they are, so an outage is not reported as a bad credential.
+func Verify(ctx context.Context, store Store, plaintext string, now time.Time) (Token, error) {
+	id, secret, ok := strings.Cut(strings.TrimPrefix(plaintext, Prefix), "_")
+	if !strings.HasPrefix(plaintext, Prefix) || !ok || id == "" || secret == "" {
+		return Token{}, ErrInvalidToken
+	}
+	token, err := store.Get(ctx, id)
+	if errors.Is(err, ErrTokenNotFound) {
+		return Token{}, ErrInvalidToken
+	}
+	if err != nil {
+		return Token{}, err
+	}
+	if subtle.ConstantTimeCompare([]byte(hash(secret)), []byte(token.Hash)) != 1 || token.Expired(now) {
+		return Token{}, ErrInvalidToken
+	}
+	return token, nil
+}
+
+func hash(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+func indexKey(owner string) string {
+	return kvstore.JoinKey("API_TOKENS", owner)
+}
+
+func ownerKey(id string) string {
+	return kvstore.JoinKey("API_TOKEN_OWNER", id)
+}
+
+// sortTokens orders tokens newest first.
+func sortTokens(tokens []Token) {
+	slices.SortFunc(tokens, func(a, b Token) int {
+		if c := b.CreatedAt.Compare(a.CreatedAt); c != 0 {
+			return c
+		}
+		return strings.Compare(a.ID, b.ID)
+	})
+}
+
+// unexpired returns the tokens that have not expired at now, in place.
+func unexpired(tokens []Token, now time.Time) []Token {
+	return slices.DeleteFunc(tokens, func(t Token) bool { return t.Expired(now) })
+}
@@ -0,0 +1,219 @@
+package apiTokens
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"renovate-operator/internal/kvstore"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+// newTestKVStore returns a Valkey token store on miniredis.
+func newTestKVStore(t *testing.T) *kvStore {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	kv, err := kvstore.NewValkeyKVStore("redis://" + mr.Addr() + "/0")
+	if err != nil {
+		t.Fatalf("NewValkeyKVStore failed: %v", err)
+	}
+	return newKVStore(kv.(hashKVStore), logr.Discard())
+}
+
+func newFakeClient(t *testing.T) client.Client {
+	t.Helper()
+	scheme := runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(scheme); err != nil {
+		t.Fatalf("failed to add client-go scheme: %v", err)
+	}
+	return fake.NewClientBuilder().WithScheme(scheme).Build()
+}
+
+func stores(t *testing.T) map[string]Store {
+	return map[string]Store{
+		"memory": newMemoryStore(),
+		"valkey": newTestKVStore(t),
+		"secret": newSecretStore(newFakeClient(t), "renovate", "api-tokens"),
+	}
+}
+
+func TestStore(t *testing.T) {
+	alice := Owner{Email: "Alice@example.com", EmailVerified: true, Groups: []string{"team-a"}}
+	bob := Owner{Subject: "gitlab:7", Username: "bob"}
+	scopes := []Scope{{Namespace: "renovate", RenovateJob: "job1", Permissions: []string{"trigger"}}}
+
+	for name, store := range stores(t) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			now := time.Now()
+			older, _ := New("older", alice, scopes, time.Hour, now.Add(-time.Minute))
+			newer, plaintext := New("ci", alice, scopes, time.Hour, now)
+			expired, _ := New("expired", alice, scopes, time.Minute, now.Add(-time.Hour))
+			other, _ := New("other", bob, scopes, time.Hour, now)
+			for _, token := range []Token{older, newer, expired, other} {
+				if err := store.Save(ctx, token); err != nil {
+					t.Fatalf("failed to save %s: %v", token.Name, err)
+				}
+			}
+
+			listed, err := store.List(ctx, alice.Key())
+			if err != nil {
+				t.Fatalf("List returned error: %v", err)
+			}
+			if len(listed) != 2 || listed[0].ID != newer.ID || listed[1].ID != older.ID {
+				t.Errorf("expected alice's unexpired tokens newest first, got %+v", listed)
+			}
+
+			verified, err := Verify(ctx, store, plaintext, now)
+			if err != nil || verified.ID != newer.ID || verified.Owner.Groups[0] != "team-a" {
+				t.Fatalf("expected the plaintext to verify as the token, got %+v, %v", verified, err)
+			}
+			if verified.Hash == "" || strings.Contains(plaintext, verified.Hash) {
+				t.Error("expected only the hash of the secret to be kept")
+			}
+
+			if err := store.Delete(ctx, newer.ID); err != nil {
+				t.Fatalf("Delete returned error: %v", err)
+			}
+			if _, err := Verify(ctx, store, plaintext, now); !errors.Is(err, ErrInvalidToken) {
+				t.Errorf("expected a revoked token to be invalid, got %v", err)
+			}
+			if err := store.Delete(ctx, newer.ID); !errors.Is(err, ErrTokenNotFound) {
+				t.Errorf("expected deleting twice to report ErrTokenNotFound, got %v", err)
+			}
+			if listed, _ := store.List(ctx, bob.Key()); len(listed) != 1 || listed[0].ID != other.ID {
+				t.Errorf("expected bob's token to be untouched, got %+v", listed)
+			}
+		})
+	}
+}
+
+func TestKVStore_ConcurrentChanges(t *testing.T) {
+	store := newTestKVStore(t)
+	ctx := context.Background()
+	owner := Owner{Service: "ci"}
+	now := time.Now()
+
+	var revoked []Token
+	for range 10 {
+		token, _ := New("old", owner, nil, time.Hour, now)
+		if err := store.Save(ctx, token); err != nil {
+			t.Fatalf("failed to save: %v", err)
+		}
+		revoked = append(revoked, token)
+	}
+
+	// replicas mint and revoke the service tokens at the same moment
+	var wg sync.WaitGroup
+	for _, token := range revoked {
+		wg.Go(func() {
+			minted, _ := New("new", owner, nil, time.Hour, now)
+			_ = store.Save(ctx, minted)
+		})
+		wg.Go(func() { _ = store.Delete(ctx, token.ID) })
+	}
+	wg.Wait()
+
+	listed, err := store.List(ctx, owner.Key())
+	if err != nil || len(listed) != 10 || slices.ContainsFunc(listed, func(t Token) bool { return t.Name != "new" }) {
+		t.Errorf("expected exactly the 10 new tokens, got %d, %v", len(listed), err)
+	}
+}
+
+func TestVerify(t *testing.T) {
+	ctx := context.Background()
+	store := newMemoryStore()
+	now := time.Now()
+	token, plaintext := New("ci", Owner{Email: "alice@example.com"}, nil, time.Hour, now)
+	if err := store.Save(ctx, token); err != nil {
+		t.Fatalf("failed to save: %v", err)
+	}
+	if !strings.HasPrefix(plaintext, Prefix+token.ID+"_") {
+		t.Fatalf("expected the plaintext to carry the prefix and id, got %q", plaintext)
+	}
+
+	invalid := map[string]string{
+		"wrong secret":  Prefix + token.ID + "_WRONG",
+		"unknown id":    Prefix + "UNKNOWN_" + strings.TrimPrefix(plaintext, Prefix+token.ID+"_"),
+		"no prefix":     strings.TrimPrefix(plaintext, Prefix),
+		"no secret":     Prefix + token.ID,
+		"empty":         "",
+		"only a prefix": Prefix,
+	}
+	for name, credential := range invalid {
+		if _, err := Verify(ctx, store, credential, now); !errors.Is(err, ErrInvalidToken) {
+			t.Errorf("%s: expected ErrInvalidToken, got %v", name, err)
+		}
+	}
+	if _, err := Verify(ctx, store, plaintext, now.Add(time.Hour)); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("expected an expired token to be invalid, got %v", err)
+	}
+}
+
+func TestSecretStore_PrunesExpiredTokens(t *testing.T) {
+	ctx := context.Background()
+	c := newFakeClient(t)
+	store := newSecretStore(c, "renovate", "api-tokens")
+	expired, _ := New("expired", Owner{Email: "alice@example.com"}, nil, time.Minute, time.Now().Add(-time.Hour))
+	fresh, _ := New("fresh", Owner{Email: "alice@example.com"}, nil, time.Hour, time.Now())
+	for _, token := range []Token{expired, fresh} {
+		if err := store.Save(ctx, token); err != nil {
+			t.Fatalf("failed to save %s: %v", token.Name, err)
+		}
+	}
+
+	secret := &corev1.Secret{}
+	if err := c.Get(ctx, client.ObjectKey{Namespace: "renovate", Name: "api-tokens"}, secret); err != nil {
+		t.Fatalf("failed to get the secret: %v", err)
+	}
+	if _, ok := secret.Data[expired.ID]; ok || len(secret.Data) != 1 {
+		t.Errorf("expected only the fresh token to be kept, got keys %v", secret.Data)
+	}
+}
+
+func TestNewStore(t *testing.T) {
+	if store, err := NewStore(logr.Discard(), "disabled", kvstore.ValkeyConfig{}, nil, "", ""); store != nil || err != nil {
+		t.Errorf("expected no store when disabled, got %v, %v", store, err)
+	}
+	if _, err := NewStore(logr.Discard(), "secret", kvstore.ValkeyConfig{}, nil, "renovate", ""); err == nil {
+		t.Error("expected the secret mode to require a secret name")
+	}
+	if _, err := NewStore(logr.Discard(), "valkey", kvstore.ValkeyConfig{}, nil, "", ""); !errors.Is(err, kvstore.ErrValkeyNotConfigured) {
+		t.Errorf("expected the valkey mode to require valkey, got %v", err)
+	}
+	if _, err := NewStore(logr.Discard(), "file", kvstore.ValkeyConfig{}, nil, "", ""); err == nil {
+		t.Error("expected an unknown mode to be rejected")
+	}
+}
+
+func TestOwnerKey(t *testing.T) {
+	tests := []struct {
+		name  string
+		owner Owner
+		want  string
+	}{
+		{"subject", Owner{Subject: "oidc:123", Email: "me@example.com", EmailVerified: true}, "oidc:123"},
+		{"verified email", Owner{Email: "Me@Example.com", EmailVerified: true}, "email:me@example.com"},
+		{"unverified email", Owner{Email: "me@example.com", Username: "me"}, ""},
+		{"username only", Owner{Username: "me@example.com"}, ""},
+		{"service", Owner{Service: "ci"}, ServiceOwnerKey},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.owner.Key(); got != tt.want {
+				t.Errorf("Key() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,145 @@
+package apiTokens
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"renovate-operator/internal/kvstore"
+
+	"github.com/go-logr/logr"
+)
+
+// kvStore is the Valkey-backed implementation for API_TOKENS_MODE=valkey. The
+// tokens of an owner are kept together in a hash keyed by token ID, and an
+// entry per token points at its owner and expires with it. Saving and
+// deleting a token each write a single field of the hash, so replicas
+// changing the tokens of the same owner at the same moment lose none.
+type kvStore struct {
+	kv     hashKVStore
+	logger logr.Logger
+}
+
+// hashKVStore is a kvstore.KVStore that also keeps hashes.
+type hashKVStore interface {
+	kvstore.KVStore
+	kvstore.HashStore
+}
+
+func newKVStore(kv hashKVStore, logger logr.Logger) *kvStore {
+	return &kvStore{kv: kv, logger: logger}
+}
+
+func (s *kvStore) Save(ctx context.Context, token Token) error {
+	ttl := time.Until(token.ExpiresAt)
+	if ttl <= 0 {
+		// an expired token would be dropped right away
+		return nil
+	}
+	owner := token.Owner.Key()
+	data, err := json.Marshal(token)
+	if err != nil {
+		return fmt.Errorf("failed to encode API token: %w", err)
+	}
+	if err := s.kv.PutField(ctx, indexKey(owner), token.ID, data, ttl); err != nil {
+		return err
+	}
+	if err := s.kv.Put(ctx, ownerKey(token.ID), []byte(owner), ttl); err != nil {
+		return err
+	}
+	s.dropExpired(ctx, owner)
+	return nil
+}
+
+func (s *kvStore) Get(ctx context.Context, id string) (Token, error) {
+	owner, err := s.kv.Get(ctx, ownerKey(id))
+	if errors.Is(err, kvstore.ErrKeyNotFound) {
+		return Token{}, ErrTokenNotFound
+	}
+	if err != nil {
+		return Token{}, err
+	}
+	data, err := s.kv.GetField(ctx, indexKey(string(owner)), id)
+	if errors.Is(err, kvstore.ErrKeyNotFound) {
+		return Token{}, ErrTokenNotFound
+	}
+	if err != nil {
+		return Token{}, err
+	}
+	var token Token
+	if err := json.Unmarshal(data, &token); err != nil {
+		return Token{}, fmt.Errorf("failed to decode API token: %w", err)
+	}
+	return token, nil
+}
+
+func (s *kvStore) List(ctx context.Context, owner string) ([]Token, error) {
+	index, err := s.loadIndex(ctx, owner)
+	if err != nil {
+		return nil, err
+	}
+	index = unexpired(index, time.Now())
+	sortTokens(index)
+	return index, nil
+}
+
+func (s *kvStore) Delete(ctx context.Context, id string) error {
+	owner, err := s.kv.Get(ctx, ownerKey(id))
+	if errors.Is(err, kvstore.ErrKeyNotFound) {
+		return ErrTokenNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	// Removing the token from the hash goes first: once it is gone the token
+	// no longer verifies, whatever happens to the owner entry.
+	removed, err := s.kv.DelField(ctx, indexKey(string(owner)), id)
+	if err != nil {
+		return err
+	}
+	if err := s.kv.Del(ctx, ownerKey(id)); err != nil {
+		s.logger.Error(err, "failed to remove the owner entry of a revoked API token", "id", id)
+	}
+	if !removed {
+		return ErrTokenNotFound
+	}
+	return nil
+}
+
+func (s *kvStore) loadIndex(ctx context.Context, owner string) ([]Token, error) {
+	fields, err := s.kv.GetFields(ctx, indexKey(owner))
+	if err != nil {
+		return nil, err
+	}
+	index := make([]Token, 0, len(fields))
+	for _, data := range fields {
+		var token Token
+		if err := json.Unmarshal(data, &token); err != nil {
+			return nil, fmt.Errorf("failed to decode API token: %w", err)
+		}
+		index = append(index, token)
+	}
+	return index, nil
+}
+
+// dropExpired removes the expired tokens of owner from its hash, which only
+// expires with the last of them.
+func (s *kvStore) dropExpired(ctx context.Context, owner string) {
+	index, err := s.loadIndex(ctx, owner)
+	if err != nil {
+		s.logger.Error(err, "failed to load API tokens to drop the expired ones")
+		return
+	}
+	now := time.Now()
+	for _, token := range index {
+		if !token.Expired(now) {
+			continue
+		}
+		if _, err := s.kv.DelField(ctx, indexKey(owner), token.ID); err != nil {
+			s.logger.Error(err, "failed to drop an expired API token", "id", token.ID)
+		}
+	}
+}
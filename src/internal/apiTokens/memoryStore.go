@@ -0,0 +1,65 @@
+package apiTokens
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// memoryStore is the in-memory implementation for API_TOKENS_MODE=memory.
+// Tokens only work on the replica that minted them and are lost on restart.
+type memoryStore struct {
+	mu     sync.RWMutex
+	tokens map[string]Token
+}
+
+func newMemoryStore() *memoryStore {
+	return &memoryStore{tokens: make(map[string]Token)}
+}
+
+func (s *memoryStore) Save(_ context.Context, token Token) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	for id, existing := range s.tokens {
+		if existing.Expired(now) {
+			delete(s.tokens, id)
+		}
+	}
+	s.tokens[token.ID] = token
+	return nil
+}
+
+func (s *memoryStore) Get(_ context.Context, id string) (Token, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	token, ok := s.tokens[id]
+	if !ok {
+		return Token{}, ErrTokenNotFound
+	}
+	return token, nil
+}
+
+func (s *memoryStore) List(_ context.Context, owner string) ([]Token, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	now := time.Now()
+	result := make([]Token, 0)
+	for _, token := range s.tokens {
+		if token.Owner.Key() == owner && !token.Expired(now) {
+			result = append(result, token)
+		}
+	}
+	sortTokens(result)
+	return result, nil
+}
+
+func (s *memoryStore) Delete(_ context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.tokens[id]; !ok {
+		return ErrTokenNotFound
+	}
+	delete(s.tokens, id)
+	return nil
+}
@@ -0,0 +1,132 @@
+package apiTokens
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// secretStore is the implementation for API_TOKENS_MODE=secret. All tokens
+// are kept in one Secret, one data key per token ID, so they survive restarts
+// and are shared by all replicas without Valkey. Updates go through the
+// Secret's resourceVersion, so concurrent changes are retried, never lost.
+type secretStore struct {
+	client    client.Client
+	namespace string
+	name      string
+}
+
+func newSecretStore(c client.Client, namespace, name string) *secretStore {
+	return &secretStore{client: c, namespace: namespace, name: name}
+}
+
+func (s *secretStore) Save(ctx context.Context, token Token) error {
+	data, err := json.Marshal(token)
+	if err != nil {
+		return fmt.Errorf("failed to encode API token: %w", err)
+	}
+	// A replica creating the Secret at the same moment makes Create fail with
+	// AlreadyExists, which is retried like a conflict.
+	retriable := func(err error) bool { return errors.IsConflict(err) || errors.IsAlreadyExists(err) }
+	return retry.OnError(retry.DefaultRetry, retriable, func() error {
+		secret, err := s.load(ctx)
+		if errors.IsNotFound(err) {
+			return s.client.Create(ctx, &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: s.name, Namespace: s.namespace},
+				Type:       corev1.SecretTypeOpaque,
+				Data:       map[string][]byte{token.ID: data},
+			})
+		}
+		if err != nil {
+			return err
+		}
+		// Expired tokens are dropped whenever a token is added, which keeps
+		// the Secret from growing towards its size limit.
+		now := time.Now()
+		for id, raw := range secret.Data {
+			var existing Token
+			if json.Unmarshal(raw, &existing) == nil && existing.Expired(now) {
+				delete(secret.Data, id)
+			}
+		}
+		if secret.Data == nil {
+			secret.Data = map[string][]byte{}
+		}
+		secret.Data[token.ID] = data
+		return s.client.Update(ctx, secret)
+	})
+}
+
+func (s *secretStore) Get(ctx context.Context, id string) (Token, error) {
+	secret, err := s.load(ctx)
+	if errors.IsNotFound(err) {
+		return Token{}, ErrTokenNotFound
+	}
+	if err != nil {
+		return Token{}, err
+	}
+	raw, ok := secret.Data[id]
+	if !ok {
+		return Token{}, ErrTokenNotFound
+	}
+	var token Token
+	if err := json.Unmarshal(raw, &token); err != nil {
+		return Token{}, fmt.Errorf("failed to decode API token %s: %w", id, err)
+	}
+	return token, nil
+}
+
+func (s *secretStore) List(ctx context.Context, owner string) ([]Token, error) {
+	secret, err := s.load(ctx)
+	if errors.IsNotFound(err) {
+		return []Token{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	result := make([]Token, 0)
+	for id, raw := range secret.Data {
+		var token Token
+		if err := json.Unmarshal(raw, &token); err != nil {
+			return nil, fmt.Errorf("failed to decode API token %s: %w", id, err)
+		}
+		if token.Owner.Key() == owner && !token.Expired(now) {
+			result = append(result, token)
+		}
+	}
+	sortTokens(result)
+	return result, nil
+}
+
+func (s *secretStore) Delete(ctx context.Context, id string) error {
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		secret, err := s.load(ctx)
+		if errors.IsNotFound(err) {
+			return ErrTokenNotFound
+		}
+		if err != nil {
+			return err
+		}
+		if _, ok := secret.Data[id]; !ok {
+			return ErrTokenNotFound
+		}
+		delete(secret.Data, id)
+		return s.client.Update(ctx, secret)
+	})
+}
+
+func (s *secretStore) load(ctx context.Context) (*corev1.Secret, error) {
+	secret := &corev1.Secret{}
+	if err := s.client.Get(ctx, client.ObjectKey{Namespace: s.namespace, Name: s.name}, secret); err != nil {
+		return nil, err
+	}
+	return secret, nil
+}
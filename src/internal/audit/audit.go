@@ -0,0 +1,216 @@
+package audit
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"time"
+
+	"renovate-operator/internal/kvstore"
+	"renovate-operator/internal/objectstore"
+
+	"github.com/go-logr/logr"
+)
+
+// ErrNotQueryable is returned by List of a sink that only writes entries out,
+// such as the operator log.
+var ErrNotQueryable = errors.New("the audit sink cannot be queried")
+
+// Sources of an action.
+const (
+	// SourceUI is a signed-in user of the UI or its API.
+	SourceUI = "ui"
+	// SourceAPIToken is a request authenticated with an API token.
+	SourceAPIToken = "api-token"
+	// SourceServiceAccount is a request authenticated with a Kubernetes
+	// ServiceAccount token.
+	SourceServiceAccount = "service-account"
+	// SourceWebhook is a webhook delivery; the actor is its provider.
+	SourceWebhook = "webhook"
+	// SourceAnnotation is a trigger annotation on a RenovateJob.
+	SourceAnnotation = "annotation"
+)
+
+// Actions that are recorded.
+const (
+	ActionTrigger       = "trigger"
+	ActionTriggerAll    = "trigger-all"
+	ActionCancel        = "cancel"
+	ActionApprove       = "approve"
+	ActionDiscovery     = "discovery"
+	ActionWebhookReplay = "webhook-replay"
+	ActionJobCreate     = "job-create"
+	ActionJobUpdate     = "job-update"
+	ActionJobDelete     = "job-delete"
+	ActionTokenCreate   = "token-create"
+	ActionTokenRevoke   = "token-revoke"
+)
+
+// Results of an action.
+const (
+	ResultSuccess = "success"
+	// ResultDenied is an action refused for lack of access.
+	ResultDenied = "denied"
+	// ResultFailed is an action that was allowed but did not succeed.
+	ResultFailed = "failed"
+)
+
+// Entry is one recorded action.
+type Entry struct {
+	ID   string    `json:"id"`
+	Time time.Time `json:"time"`
+	// Actor is who acted: the email or username of a user, or the provider
+	// endpoint of a webhook (github, gitlab, ..., schedule). Empty for
+	// annotations, whose author only the Kubernetes audit log knows.
+	Actor  string `json:"actor,omitempty"`
+	Source string `json:"source"`
+	Action string `json:"action"`
+	// Namespace and RenovateJob identify the job acted on; both are empty for
+	// actions not tied to a job, such as minting an API token.
+	Namespace   string `json:"namespace,omitempty"`
+	RenovateJob string `json:"renovateJob,omitempty"`
+	Project     string `json:"project,omitempty"`
+	Result      string `json:"result"`
+	// Reason explains a denied or failed action.
+	Reason string `json:"reason,omitempty"`
+	// Details are action specific, e.g. debug for a trigger in debug mode.
+	Details map[string]string `json:"details,omitempty"`
+}
+
+// Filter selects entries. Empty fields match everything.
+type Filter struct {
+	Namespace   string
+	RenovateJob string
+	Project     string
+	Actor       string
+	Source      string
+	Action      string
+	Result      string
+	// Since and Until bound the time of an entry, both included.
+	Since time.Time
+	Until time.Time
+	// Limit is the number of entries returned at most; 0 is DefaultLimit.
+	Limit int
+	// Keep, if set, further narrows the entries, e.g. to those the caller
+	// may see. It is applied before Limit.
+	Keep func(Entry) bool
+}
+
+// DefaultLimit and MaxLimit bound the number of entries a query returns.
+const (
+	DefaultLimit = 100
+	MaxLimit     = 1000
+)
+
+// Matches reports whether entry passes the filter.
+func (f Filter) Matches(entry Entry) bool {
+	switch {
+	case f.Namespace != "" && entry.Namespace != f.Namespace,
+		f.RenovateJob != "" && entry.RenovateJob != f.RenovateJob,
+		f.Project != "" && entry.Project != f.Project,
+		f.Actor != "" && entry.Actor != f.Actor,
+		f.Source != "" && entry.Source != f.Source,
+		f.Action != "" && entry.Action != f.Action,
+		f.Result != "" && entry.Result != f.Result,
+		!f.Since.IsZero() && entry.Time.Before(f.Since),
+		!f.Until.IsZero() && entry.Time.After(f.Until):
+		return false
+	}
+	return f.Keep == nil || f.Keep(entry)
+}
+
+func (f Filter) limit() int {
+	if f.Limit <= 0 {
+		return DefaultLimit
+	}
+	return min(f.Limit, MaxLimit)
+}
+
+// Sink records the actions taken on RenovateJobs.
+type Sink interface {
+	// Record writes an entry. It never fails the action: an entry that
+	// cannot be written is reported in the operator log.
+	Record(ctx context.Context, entry Entry)
+	// List returns the entries matching filter, newest first, or
+	// ErrNotQueryable.
+	List(ctx context.Context, filter Filter) ([]Entry, error)
+}
+
+// NewSink creates a Sink based on the provided mode.
+// Supported modes: "disabled" (returns nil), "log" (the operator log, not
+// queryable), "memory" (in-memory, per replica), "valkey" (Valkey-backed,
+// shared by all replicas) and "s3" (one object per entry under s3Prefix).
+// Entries older than retention are not listed, and dropped where the sink
+// expires them itself.
+func NewSink(logger logr.Logger, mode string, valkeyCfg kvstore.ValkeyConfig, s3Cfg objectstore.S3Config, s3Prefix string, retention time.Duration) (Sink, error) {
+	switch mode {
+	case "log":
+		return &logSink{logger: logger}, nil
+	case "memory":
+		return newMemorySink(retention), nil
+	case "valkey":
+		kv, err := kvstore.NewKVStore(valkeyCfg, kvstore.UsageAuditLog)
+		if err != nil {
+			return nil, err
+		}
+		lists, ok := kv.(kvstore.ListStore)
+		if !ok {
+			return nil, errors.New("the audit log needs a Valkey store that keeps lists")
+		}
+		return newKVSink(lists, retention, logger), nil
+	case "s3":
+		sink, err := newS3Sink(context.Background(), s3Cfg, s3Prefix, retention, logger)
+		if err != nil {
+			return nil, fmt.Errorf("initializing S3 audit sink: %w", err)
+		}
+		return sink, nil
+	case "disabled", "":
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("unknown audit mode %q", mode)
+	}
+}
+
+// prepare fills in the ID and time of an entry that has none.
+func prepare(entry *Entry) {
+	if entry.ID == "" {
+		entry.ID = rand.Text()
+	}
+	if entry.Time.IsZero() {
+		entry.Time = time.Now()
+	}
+	entry.Time = entry.Time.UTC()
+}
+
+// Record writes entry to sink, if there is one.
+func Record(ctx context.Context, sink Sink, entry Entry) {
+	if sink != nil {
+		sink.Record(ctx, entry)
+	}
+}
+
+// dayFormat names the UTC day an entry is kept under.
+const dayFormat = "2006-01-02"
+
+// days returns the UTC days from until back to since, newest first.
+func days(since, until time.Time) []string {
+	var result []string
+	oldest := since.UTC().Truncate(24 * time.Hour)
+	for day := until.UTC().Truncate(24 * time.Hour); !day.Before(oldest); day = day.Add(-24 * time.Hour) {
+		result = append(result, day.Format(dayFormat))
+	}
+	return result
+}
+
+// window returns the period a query of filter covers, bounded by retention.
+func window(filter Filter, retention time.Duration, now time.Time) (since, until time.Time) {
+	since, until = filter.Since, filter.Until
+	if until.IsZero() || until.After(now) {
+		until = now
+	}
+	if oldest := now.Add(-retention); since.Before(oldest) {
+		since = oldest
+	}
+	return since, until
+}
@@ -0,0 +1,273 @@
+package audit
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"renovate-operator/internal/kvstore"
+	"renovate-operator/internal/objectstore"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/go-logr/logr"
+)
+
+// fakeLists is an in-memory kvstore.ListStore.
+type fakeLists struct {
+	mu    sync.Mutex
+	lists map[string][][]byte
+}
+
+func (f *fakeLists) Append(_ context.Context, key string, value []byte, size int, _ time.Duration) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	list := append(f.lists[key], value)
+	f.lists[key] = list[max(0, len(list)-size):]
+	return nil
+}
+
+func (f *fakeLists) Range(_ context.Context, key string) ([][]byte, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return slices.Clone(f.lists[key]), nil
+}
+
+// fakeS3 is an in-memory bucket.
+type fakeS3 struct {
+	mu      sync.Mutex
+	objects map[string][]byte
+	gets    int
+}
+
+func (f *fakeS3) PutObject(_ context.Context, in *s3.PutObjectInput, _ ...func(*s3.Options)) (*s3.PutObjectOutput, error) {
+	data, err := io.ReadAll(in.Body)
+	if err != nil {
+		return nil, err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.objects[aws.ToString(in.Key)] = data
+	return &s3.PutObjectOutput{}, nil
+}
+
+func (f *fakeS3) GetObject(_ context.Context, in *s3.GetObjectInput, _ ...func(*s3.Options)) (*s3.GetObjectOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.gets++
+	data, ok := f.objects[aws.ToString(in.Key)]
+	if !ok {
+		return nil, &types.NoSuchKey{}
+	}
+	return &s3.GetObjectOutput{Body: io.NopCloser(bytes.NewReader(data))}, nil
+}
+
+func (f *fakeS3) ListObjectsV2(_ context.Context, in *s3.ListObjectsV2Input, _ ...func(*s3.Options)) (*s3.ListObjectsV2Output, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	out := &s3.ListObjectsV2Output{}
+	for key := range f.objects {
+		if strings.HasPrefix(key, aws.ToString(in.Prefix)) {
+			out.Contents = append(out.Contents, types.Object{Key: aws.String(key)})
+		}
+	}
+	return out, nil
+}
+
+func testSinks() map[string]Sink {
+	return map[string]Sink{
+		"memory": newMemorySink(24 * time.Hour),
+		"valkey": newKVSink(&fakeLists{lists: map[string][][]byte{}}, 24*time.Hour, logr.Discard()),
+		"s3": &s3Sink{
+			client:    &fakeS3{objects: map[string][]byte{}},
+			bucket:    "audit",
+			prefix:    "renovate-audit",
+			retention: 24 * time.Hour,
+			logger:    logr.Discard(),
+		},
+	}
+}
+
+func TestSink(t *testing.T) {
+	for name, sink := range testSinks() {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			now := time.Now()
+			sink.Record(ctx, Entry{Time: now.Add(-48 * time.Hour), Actor: "expired@example.com", Source: SourceUI, Action: ActionTrigger, Result: ResultSuccess})
+			for i := range 4 {
+				sink.Record(ctx, Entry{
+					Time:        now.Add(time.Duration(i-4) * time.Minute),
+					Actor:       fmt.Sprintf("user%d@example.com", i%2),
+					Source:      SourceUI,
+					Action:      ActionTrigger,
+					Namespace:   "renovate",
+					RenovateJob: "job1",
+					Project:     fmt.Sprintf("org/repo%d", i),
+					Result:      ResultSuccess,
+				})
+			}
+			sink.Record(ctx, Entry{Actor: "github", Source: SourceWebhook, Action: ActionTrigger, Namespace: "renovate", RenovateJob: "job2", Project: "org/other", Result: ResultSuccess})
+			sink.Record(ctx, Entry{Actor: "user0@example.com", Source: SourceUI, Action: ActionCancel, Namespace: "renovate", RenovateJob: "job1", Result: ResultDenied, Reason: "Forbidden"})
+
+			entries, err := sink.List(ctx, Filter{})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(entries) != 6 || entries[0].Action != ActionCancel || entries[5].Project != "org/repo0" {
+				t.Fatalf("expected the entries within the retention, newest first, got %+v", entries)
+			}
+			if entries[0].ID == "" || entries[0].Time.Location() != time.UTC {
+				t.Errorf("expected entries to get an ID and a UTC time, got %+v", entries[0])
+			}
+
+			entries, _ = sink.List(ctx, Filter{RenovateJob: "job1", Action: ActionTrigger, Actor: "user1@example.com"})
+			if len(entries) != 2 || entries[0].Project != "org/repo3" || entries[1].Project != "org/repo1" {
+				t.Errorf("expected the triggers of user1 on job1, got %+v", entries)
+			}
+
+			entries, _ = sink.List(ctx, Filter{Source: SourceUI, Limit: 2, Keep: func(e Entry) bool { return e.Result == ResultSuccess }})
+			if len(entries) != 2 || entries[0].Project != "org/repo3" || entries[1].Project != "org/repo2" {
+				t.Errorf("expected Keep to apply before the limit, got %+v", entries)
+			}
+
+			entries, _ = sink.List(ctx, Filter{Since: now.Add(-3*time.Minute - time.Second), Until: now.Add(-time.Minute)})
+			if len(entries) != 3 || entries[0].Project != "org/repo3" || entries[2].Project != "org/repo1" {
+				t.Errorf("expected the entries between since and until, got %+v", entries)
+			}
+		})
+	}
+}
+
+func TestKVSink_KeepsConcurrentEntries(t *testing.T) {
+	mr := miniredis.RunT(t)
+	kv, err := kvstore.NewValkeyKVStore("redis://" + mr.Addr() + "/0")
+	if err != nil {
+		t.Fatalf("NewValkeyKVStore failed: %v", err)
+	}
+	// two sinks stand for two replicas sharing the store
+	replicas := []*kvSink{
+		newKVSink(kv.(kvstore.ListStore), 24*time.Hour, logr.Discard()),
+		newKVSink(kv.(kvstore.ListStore), 24*time.Hour, logr.Discard()),
+	}
+
+	var wg sync.WaitGroup
+	for i := range 50 {
+		wg.Go(func() {
+			replicas[i%2].Record(context.Background(), Entry{Source: SourceUI, Action: ActionTrigger, Project: fmt.Sprintf("org/repo%d", i)})
+		})
+	}
+	wg.Wait()
+
+	entries, err := replicas[0].List(context.Background(), Filter{})
+	if err != nil || len(entries) != 50 {
+		t.Errorf("expected all 50 entries, got %d, %v", len(entries), err)
+	}
+	if ttl := mr.TTL(dayKey(time.Now().UTC().Format(dayFormat), SourceUI)); ttl != 48*time.Hour {
+		t.Errorf("expected the list to expire after the retention and a day, got %v", ttl)
+	}
+}
+
+func TestKVSink_CapsEachSource(t *testing.T) {
+	lists := &fakeLists{lists: map[string][][]byte{}}
+	sink := newKVSink(lists, 24*time.Hour, logr.Discard())
+	ctx := context.Background()
+	now := time.Now()
+
+	sink.Record(ctx, Entry{Time: now.Add(-time.Minute), Actor: "user0@example.com", Source: SourceUI, Action: ActionCancel})
+	for i := range maxEntriesPerDay + 5 {
+		sink.Record(ctx, Entry{Time: now.Add(time.Duration(i) - time.Second), Actor: "github", Source: SourceWebhook, Action: ActionTrigger})
+	}
+	sink.Record(ctx, Entry{Actor: "someone", Action: ActionTrigger})
+
+	day := now.UTC().Format(dayFormat)
+	if got := len(lists.lists[dayKey(day, SourceWebhook)]); got != maxEntriesPerDay {
+		t.Errorf("expected the webhook entries to be capped at %d, got %d", maxEntriesPerDay, got)
+	}
+	entries, err := sink.List(ctx, Filter{Source: SourceUI})
+	if err != nil || len(entries) != 1 || entries[0].Actor != "user0@example.com" {
+		t.Errorf("expected the UI entry to outlast the webhook entries, got %+v, %v", entries, err)
+	}
+	if len(lists.lists[dayKey(day, sourceOther)]) != 1 {
+		t.Errorf("expected an entry without a known source under %q, got %v", sourceOther, slices.Collect(maps.Keys(lists.lists)))
+	}
+	entries, _ = sink.List(ctx, Filter{Limit: 2})
+	if len(entries) != 2 || entries[0].Actor != "someone" || entries[1].Source != SourceWebhook {
+		t.Errorf("expected the sources merged newest first, got %+v", entries)
+	}
+}
+
+func TestS3Sink_ObjectPerEntry(t *testing.T) {
+	client := &fakeS3{objects: map[string][]byte{}}
+	sink := &s3Sink{client: client, bucket: "audit", prefix: "audit", retention: 24 * time.Hour, logger: logr.Discard()}
+	at := time.Date(2026, 3, 4, 5, 6, 7, 0, time.UTC)
+	sink.Record(context.Background(), Entry{ID: "ABC", Time: at, Action: ActionTrigger})
+
+	if _, ok := client.objects["audit/2026-03-04/050607.000000000-ABC.json"]; !ok {
+		t.Errorf("expected one object per entry under the day, got %v", slices.Collect(maps.Keys(client.objects)))
+	}
+}
+
+func TestS3Sink_StopsScanning(t *testing.T) {
+	client := &fakeS3{objects: map[string][]byte{}}
+	sink := &s3Sink{client: client, bucket: "audit", prefix: "audit", retention: 24 * time.Hour, logger: logr.Discard()}
+	for range maxScannedObjects + 10 {
+		sink.Record(context.Background(), Entry{Action: ActionTrigger})
+	}
+
+	entries, err := sink.List(context.Background(), Filter{Action: ActionCancel})
+	if err != nil || len(entries) != 0 {
+		t.Fatalf("expected no entries, got %v, %v", entries, err)
+	}
+	if client.gets != maxScannedObjects {
+		t.Errorf("expected %d objects to be read, got %d", maxScannedObjects, client.gets)
+	}
+}
+
+func TestLogSink_NotQueryable(t *testing.T) {
+	sink := &logSink{logger: logr.Discard()}
+	sink.Record(context.Background(), Entry{Action: ActionTrigger})
+	if _, err := sink.List(context.Background(), Filter{}); !errors.Is(err, ErrNotQueryable) {
+		t.Errorf("expected ErrNotQueryable, got %v", err)
+	}
+}
+
+func TestFilterLimit(t *testing.T) {
+	for limit, want := range map[int]int{0: DefaultLimit, -1: DefaultLimit, 5: 5, MaxLimit + 1: MaxLimit} {
+		if got := (Filter{Limit: limit}).limit(); got != want {
+			t.Errorf("limit %d = %d, want %d", limit, got, want)
+		}
+	}
+}
+
+func TestDays(t *testing.T) {
+	since := time.Date(2026, 3, 1, 23, 0, 0, 0, time.UTC)
+	until := time.Date(2026, 3, 3, 1, 0, 0, 0, time.UTC)
+	if got, want := days(since, until), []string{"2026-03-03", "2026-03-02", "2026-03-01"}; !slices.Equal(got, want) {
+		t.Errorf("days = %v, want %v", got, want)
+	}
+}
+
+func TestNewSink(t *testing.T) {
+	if sink, err := NewSink(logr.Discard(), "disabled", kvstore.ValkeyConfig{}, objectstore.S3Config{}, "", time.Hour); sink != nil || err != nil {
+		t.Errorf("expected no sink when disabled, got %v, %v", sink, err)
+	}
+	if _, err := NewSink(logr.Discard(), "valkey", kvstore.ValkeyConfig{}, objectstore.S3Config{}, "", time.Hour); !errors.Is(err, kvstore.ErrValkeyNotConfigured) {
+		t.Errorf("expected an error without Valkey, got %v", err)
+	}
+	if _, err := NewSink(logr.Discard(), "s3", kvstore.ValkeyConfig{}, objectstore.S3Config{}, "", time.Hour); err == nil {
+		t.Error("expected an error without a bucket")
+	}
+	if _, err := NewSink(logr.Discard(), "bogus", kvstore.ValkeyConfig{}, objectstore.S3Config{}, "", time.Hour); err == nil {
+		t.Error("expected an error for an unknown mode")
+	}
+}
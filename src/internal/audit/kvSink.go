@@ -0,0 +1,116 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"time"
+
+	"renovate-operator/internal/kvstore"
+
+	"github.com/go-logr/logr"
+)
+
+// maxEntriesPerDay bounds the entries of a source on a day in Valkey, oldest
+// dropped first, so a flood of webhook deliveries or refused requests cannot
+// grow a list without limit or push out the actions of other sources.
+const maxEntriesPerDay = 10000
+
+// sourceOther keeps the entries of a source not listed in sources.
+const sourceOther = "other"
+
+// sources are the sources with a list of their own in Valkey.
+var sources = []string{SourceUI, SourceAPIToken, SourceServiceAccount, SourceWebhook, SourceAnnotation, sourceOther}
+
+// kvSink is the Valkey-backed implementation for AUDIT_MODE=valkey, with a
+// list per UTC day and source holding its actions, oldest first. Entries are
+// appended, so replicas recording at the same moment keep all of them. A list
+// expires once its day is older than the retention.
+type kvSink struct {
+	kv        kvstore.ListStore
+	retention time.Duration
+	logger    logr.Logger
+}
+
+func newKVSink(kv kvstore.ListStore, retention time.Duration, logger logr.Logger) *kvSink {
+	return &kvSink{kv: kv, retention: retention, logger: logger}
+}
+
+func (s *kvSink) Record(ctx context.Context, entry Entry) {
+	prepare(&entry)
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 10*time.Second)
+	defer cancel()
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		s.logger.Error(err, "failed to encode audit entry")
+		return
+	}
+	key := dayKey(entry.Time.Format(dayFormat), sourceOf(entry.Source))
+	if err := s.kv.Append(ctx, key, data, maxEntriesPerDay, s.retention+24*time.Hour); err != nil {
+		s.logger.Error(err, "failed to save audit entry to valkey, entry lost", "action", entry.Action, "actor", entry.Actor)
+	}
+}
+
+func (s *kvSink) List(ctx context.Context, filter Filter) ([]Entry, error) {
+	since, until := window(filter, s.retention, time.Now())
+	filter.Since, filter.Until = since, until
+
+	listed := sources
+	if filter.Source != "" {
+		listed = []string{sourceOf(filter.Source)}
+	}
+
+	result := []Entry{}
+	for _, day := range days(since, until) {
+		var entries []Entry
+		for _, source := range listed {
+			loaded, err := s.load(ctx, dayKey(day, source))
+			if err != nil {
+				return nil, err
+			}
+			entries = append(entries, loaded...)
+		}
+		slices.Reverse(entries)
+		slices.SortStableFunc(entries, func(a, b Entry) int { return b.Time.Compare(a.Time) })
+		for _, entry := range entries {
+			if !filter.Matches(entry) {
+				continue
+			}
+			result = append(result, entry)
+			if len(result) == filter.limit() {
+				return result, nil
+			}
+		}
+	}
+	return result, nil
+}
+
+func (s *kvSink) load(ctx context.Context, key string) ([]Entry, error) {
+	values, err := s.kv.Range(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	entries := make([]Entry, 0, len(values))
+	for _, value := range values {
+		var entry Entry
+		if err := json.Unmarshal(value, &entry); err != nil {
+			return nil, fmt.Errorf("failed to decode audit entry: %w", err)
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// sourceOf returns the source whose list keeps the entries of source.
+func sourceOf(source string) string {
+	if slices.Contains(sources, source) {
+		return source
+	}
+	return sourceOther
+}
+
+func dayKey(day, source string) string {
+	return kvstore.JoinKey("AUDIT_LOG", day, source)
+}
@@ -0,0 +1,39 @@
+package audit
+
+import (
+	"context"
+
+	"github.com/go-logr/logr"
+)
+
+// logSink writes every entry to the operator log, for AUDIT_MODE=log. Log
+// collection keeps and searches the entries, so the UI API cannot list them.
+type logSink struct {
+	logger logr.Logger
+}
+
+func (s *logSink) Record(_ context.Context, entry Entry) {
+	prepare(&entry)
+	keysAndValues := []any{
+		"id", entry.ID,
+		"time", entry.Time,
+		"actor", entry.Actor,
+		"source", entry.Source,
+		"action", entry.Action,
+		"namespace", entry.Namespace,
+		"renovateJob", entry.RenovateJob,
+		"project", entry.Project,
+		"result", entry.Result,
+	}
+	if entry.Reason != "" {
+		keysAndValues = append(keysAndValues, "reason", entry.Reason)
+	}
+	if len(entry.Details) > 0 {
+		keysAndValues = append(keysAndValues, "details", entry.Details)
+	}
+	s.logger.Info("audit", keysAndValues...)
+}
+
+func (s *logSink) List(context.Context, Filter) ([]Entry, error) {
+	return nil, ErrNotQueryable
+}
@@ -0,0 +1,55 @@
+package audit
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+)
+
+// maxMemoryEntries bounds the entries the memory sink keeps, oldest dropped
+// first.
+const maxMemoryEntries = 10000
+
+// memorySink is the in-memory implementation for AUDIT_MODE=memory. Each
+// replica only sees the actions it handled itself, and a restart forgets them.
+type memorySink struct {
+	retention time.Duration
+
+	mu sync.RWMutex
+	// entries, oldest first
+	entries []Entry
+}
+
+func newMemorySink(retention time.Duration) *memorySink {
+	return &memorySink{retention: retention}
+}
+
+func (s *memorySink) Record(_ context.Context, entry Entry) {
+	prepare(&entry)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.entries = append(s.entries, entry)
+	oldest := entry.Time.Add(-s.retention)
+	expired := slices.IndexFunc(s.entries, func(e Entry) bool { return !e.Time.Before(oldest) })
+	if expired < 0 {
+		expired = len(s.entries)
+	}
+	s.entries = slices.Delete(s.entries, 0, max(expired, len(s.entries)-maxMemoryEntries))
+}
+
+func (s *memorySink) List(_ context.Context, filter Filter) ([]Entry, error) {
+	since, until := window(filter, s.retention, time.Now())
+	filter.Since, filter.Until = since, until
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	result := []Entry{}
+	for i := len(s.entries) - 1; i >= 0 && len(result) < filter.limit(); i-- {
+		if filter.Matches(s.entries[i]) {
+			result = append(result, s.entries[i])
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,160 @@
+package audit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"renovate-operator/internal/objectstore"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/go-logr/logr"
+)
+
+// maxScannedObjects bounds the objects one query reads, since every entry is
+// an object of its own. A query reaching it returns what it found so far;
+// since and until narrow it down.
+const maxScannedObjects = 2000
+
+// s3API is the part of the S3 client the sink uses.
+type s3API interface {
+	s3.ListObjectsV2APIClient
+	PutObject(ctx context.Context, params *s3.PutObjectInput, optFns ...func(*s3.Options)) (*s3.PutObjectOutput, error)
+	GetObject(ctx context.Context, params *s3.GetObjectInput, optFns ...func(*s3.Options)) (*s3.GetObjectOutput, error)
+}
+
+// s3Sink is the S3-backed implementation for AUDIT_MODE=s3. Every entry is an
+// object of its own, {prefix}/{day}/{time}-{id}.json, so replicas never
+// overwrite each other and the bucket's own retention (lifecycle rules, object
+// lock) applies. The operator deletes nothing; it only lists the last
+// retention period.
+type s3Sink struct {
+	client    s3API
+	bucket    string
+	prefix    string
+	retention time.Duration
+	logger    logr.Logger
+}
+
+func newS3Sink(ctx context.Context, cfg objectstore.S3Config, prefix string, retention time.Duration, logger logr.Logger) (*s3Sink, error) {
+	if cfg.Bucket == "" {
+		return nil, errors.New("S3_BUCKET is required")
+	}
+	client, err := objectstore.NewS3Client(ctx, cfg)
+	if err != nil {
+		return nil, fmt.Errorf("creating S3 client: %w", err)
+	}
+	if prefix == "" {
+		prefix = "renovate-audit"
+	}
+	return &s3Sink{
+		client:    client,
+		bucket:    cfg.Bucket,
+		prefix:    strings.TrimSuffix(prefix, "/"),
+		retention: retention,
+		logger:    logger,
+	}, nil
+}
+
+func (s *s3Sink) Record(ctx context.Context, entry Entry) {
+	prepare(&entry)
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 30*time.Second)
+	defer cancel()
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		s.logger.Error(err, "failed to encode audit entry")
+		return
+	}
+	_, err = s.client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket:      aws.String(s.bucket),
+		Key:         aws.String(s.objectKey(entry)),
+		Body:        bytes.NewReader(data),
+		ContentType: aws.String("application/json"),
+	})
+	if err != nil {
+		s.logger.Error(err, "failed to save audit entry to S3, entry lost", "bucket", s.bucket, "action", entry.Action, "actor", entry.Actor)
+	}
+}
+
+func (s *s3Sink) List(ctx context.Context, filter Filter) ([]Entry, error) {
+	since, until := window(filter, s.retention, time.Now())
+	filter.Since, filter.Until = since, until
+
+	result := []Entry{}
+	scanned := 0
+	for _, day := range days(since, until) {
+		keys, err := s.listDay(ctx, day)
+		if err != nil {
+			return nil, err
+		}
+		// keys sort by time within a day
+		for _, key := range slices.Backward(keys) {
+			if scanned == maxScannedObjects {
+				s.logger.V(1).Info("audit query stopped after reading the maximum number of objects", "scanned", scanned, "found", len(result))
+				return result, nil
+			}
+			scanned++
+			entry, err := s.get(ctx, key)
+			if err != nil {
+				return nil, err
+			}
+			if !filter.Matches(entry) {
+				continue
+			}
+			result = append(result, entry)
+			if len(result) == filter.limit() {
+				return result, nil
+			}
+		}
+	}
+	return result, nil
+}
+
+func (s *s3Sink) listDay(ctx context.Context, day string) ([]string, error) {
+	var keys []string
+	paginator := s3.NewListObjectsV2Paginator(s.client, &s3.ListObjectsV2Input{
+		Bucket: aws.String(s.bucket),
+		Prefix: aws.String(s.prefix + "/" + day + "/"),
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("listing audit entries in S3: %w", err)
+		}
+		for _, object := range page.Contents {
+			keys = append(keys, aws.ToString(object.Key))
+		}
+	}
+	slices.Sort(keys)
+	return keys, nil
+}
+
+func (s *s3Sink) get(ctx context.Context, key string) (Entry, error) {
+	out, err := s.client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return Entry{}, fmt.Errorf("reading audit entry %s from S3: %w", key, err)
+	}
+	defer func() { _ = out.Body.Close() }()
+
+	var entry Entry
+	if err := json.NewDecoder(out.Body).Decode(&entry); err != nil {
+		return Entry{}, fmt.Errorf("decoding audit entry %s: %w", key, err)
+	}
+	return entry, nil
+}
+
+// objectKey is {prefix}/{day}/{time}-{id}.json, with a fixed-width time so the
+// keys of a day sort by it.
+func (s *s3Sink) objectKey(entry Entry) string {
+	return fmt.Sprintf("%s/%s/%s-%s.json", s.prefix, entry.Time.Format(dayFormat), entry.Time.Format("150405.000000000"), entry.ID)
+}
@@ -0,0 +1,68 @@
+package crdmanager
+
+import (
+	"context"
+	"testing"
+
+	api "renovate-operator/api/v1alpha1"
+	"renovate-operator/internal/kvstore"
+	"renovate-operator/internal/logStore"
+	"renovate-operator/internal/notifications"
+	"renovate-operator/internal/objectstore"
+	"renovate-operator/internal/types"
+
+	"github.com/go-logr/logr"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+type recordingNotifier struct {
+	received []notifications.Notification
+}
+
+func (n *recordingNotifier) Notify(_ context.Context, _ *api.RenovateJob, notification notifications.Notification) {
+	n.received = append(n.received, notification)
+}
+
+func TestRenovateJobManager_Notifies(t *testing.T) {
+	notifier := &recordingNotifier{}
+
+	scheme := runtime.NewScheme()
+	if err := api.AddToScheme(scheme); err != nil {
+		t.Fatalf("failed to add scheme: %v", err)
+	}
+	j := makeJob("job1", "default", []api.ProjectStatus{
+		{Name: "org/a", Status: api.JobStatusScheduled, LastRunStatus: api.JobStatusCompleted},
+	})
+	j.Spec.Notifications = &api.RenovateJobNotifications{Routes: []api.NotificationRoute{{Type: api.NotificationTargetWebhook}}}
+	cl := fake.NewClientBuilder().WithScheme(scheme).WithObjects(j).WithStatusSubresource(&api.RenovateJob{}).Build()
+	log, err := logStore.NewLogStore(logr.Logger{}, "memory", kvstore.ValkeyConfig{}, objectstore.S3Config{}, "")
+	if err != nil {
+		t.Fatalf("failed to initialise logStore")
+	}
+	mgr := NewRenovateJobManager(cl, nil, logr.Logger{}, log, nil, testPolicy(), nil, nil, notifier, nil)
+	ctx := context.Background()
+	jobId := RenovateJobIdentifier{Name: "job1", Namespace: "default"}
+
+	for _, status := range []api.RenovateProjectStatus{api.JobStatusRunning, api.JobStatusFailed, api.JobStatusRunning, api.JobStatusFailed} {
+		if err := mgr.UpdateProjectStatus(ctx, "org/a", jobId, &types.RenovateStatusUpdate{Status: status}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	// refusing twice for the same reason notifies once
+	for range 2 {
+		if err := mgr.SetAcceptedCondition(ctx, jobId, false, "ImageNotAllowed", "spec.image is not allowed"); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	want := []api.NotificationEvent{api.NotificationEventFailed, api.NotificationEventPolicyDenied}
+	if len(notifier.received) != len(want) {
+		t.Fatalf("expected notifications %v, got %+v", want, notifier.received)
+	}
+	for i, event := range want {
+		if notifier.received[i].Event != event {
+			t.Errorf("expected notification %d to be %s, got %s", i, event, notifier.received[i].Event)
+		}
+	}
+}
@@ -4,7 +4,6 @@ import (
 	"context"
 	"crypto/hmac"
 	"crypto/sha256"
-	"encoding/base64"
 	"errors"
 	"fmt"
 	"io"
@@ -19,9 +18,12 @@ import (
 	"renovate-operator/config"
 	"renovate-operator/gitProviderClients"
 	gitProviderClientFactory "renovate-operator/gitProviderClients/factory"
+	"renovate-operator/internal/eventStream"
 	"renovate-operator/internal/logStore"
+	"renovate-operator/internal/notifications"
 	"renovate-operator/internal/podLogs"
 	"renovate-operator/internal/policy"
+	"renovate-operator/internal/reports"
 	"renovate-operator/internal/types"
 	"renovate-operator/internal/utils"
 	"renovate-operator/internal/webhookSync"
@@ -29,9 +31,11 @@ import (
 
 	"github.com/go-logr/logr"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/meta"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/events"
 	"k8s.io/client-go/util/retry"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
@@ -57,9 +61,28 @@ type RenovateJobManager interface {
 	// GetProjectsByStatus retrieves all projects with a specific status within a RenovateJob CRD.
 	GetProjectsByStatus(ctx context.Context, job RenovateJobIdentifier, status api.RenovateProjectStatus) ([]RenovateProjectStatus, error)
 	// ReconcileProjects reconciles the list of projects in a RenovateJob CRD
-	// with the provided list. It returns the names of the projects that were
-	// removed (present before, absent now).
+	// with the provided list and records the refresh as the last discovery time.
+	// Discovered projects matching spec.excludeRepositories are dropped and
+	// spec.repositories are always added.
+	// New projects start as pending-approval when spec.onboarding requires
+	// approval. Added and removed projects are recorded as Events and metrics.
+	// It returns the names of the projects that were removed (present before,
+	// absent now).
 	ReconcileProjects(ctx context.Context, job *api.RenovateJob, projects []string) ([]string, error)
+	// ApproveProjects moves the given pending-approval projects to Scheduled.
+	// Projects that are not pending approval are left untouched.
+	ApproveProjects(ctx context.Context, job RenovateJobIdentifier, projects []string) error
+	// DiscoverProjects lists the job's projects through the platform API
+	// (native discovery), applying spec.discoveryNamespaces, discoverTopics and
+	// discoveryFilters. The result is meant to be passed to ReconcileProjects.
+	DiscoverProjects(ctx context.Context, job *api.RenovateJob) ([]string, error)
+	// GetDefaultBranch looks up a project's default branch through the
+	// platform API, for push payloads that do not carry it.
+	GetDefaultBranch(ctx context.Context, job *api.RenovateJob, project string) (string, error)
+	// ListChangedFiles lists the files that differ between two commits of a
+	// project through the platform API, for push payloads that do not list
+	// them.
+	ListChangedFiles(ctx context.Context, job *api.RenovateJob, project, base, head string) ([]string, error)
 	// SyncWebhooks ensures the operator's webhook exists on every project of
 	// the RenovateJob and removes it from the given removed projects (the diff
 	// reported by ReconcileProjects). Stateless: hooks are identified by their
@@ -99,6 +122,10 @@ type renovateJobManager struct {
 	logStore                 logStore.LogStore
 	logReader                podLogs.PodLogReader
 	policy                   policy.Policy
+	recorder                 events.EventRecorder
+	publisher                eventStream.Publisher
+	notifier                 notifications.Notifier
+	history                  reports.History
 }
 
 type RenovateJobIdentifier struct {
@@ -127,9 +154,14 @@ type RenovateProjectStatus struct {
 	PRActivity           *api.PRActivity               `json:"prActivity,omitempty"`
 	LogIssues            *api.LogIssues                `json:"logIssues,omitempty"`
 	ExecutionOptions     *api.RenovateExecutionOptions `json:"executionOptions,omitempty"`
+	RerunAfterCurrent    bool                          `json:"rerunAfterCurrent,omitempty"`
 }
 
-func NewRenovateJobManager(client client.Client, gitProviderClientFactory gitProviderClientFactory.GitProviderClientFactory, logger logr.Logger, ls logStore.LogStore, lr podLogs.PodLogReader, p policy.Policy) RenovateJobManager {
+// NewRenovateJobManager creates a RenovateJobManager. recorder may be nil, in
+// which case no Events are recorded. publisher, notifier and history may be
+// nil as well, in which case no run events are published, no notifications
+// sent and no runs counted.
+func NewRenovateJobManager(client client.Client, gitProviderClientFactory gitProviderClientFactory.GitProviderClientFactory, logger logr.Logger, ls logStore.LogStore, lr podLogs.PodLogReader, p policy.Policy, recorder events.EventRecorder, publisher eventStream.Publisher, notifier notifications.Notifier, history reports.History) RenovateJobManager {
 	return &renovateJobManager{
 		client:                   client,
 		gitProviderClientFactory: gitProviderClientFactory,
@@ -138,6 +170,10 @@ func NewRenovateJobManager(client client.Client, gitProviderClientFactory gitPro
 		logStore:                 ls,
 		logReader:                lr,
 		policy:                   p,
+		recorder:                 recorder,
+		publisher:                publisher,
+		notifier:                 notifier,
+		history:                  history,
 	}
 }
 
@@ -182,6 +218,7 @@ func (r *renovateJobManager) GetProjectsByStatus(ctx context.Context, job Renova
 				PRActivity:           project.PRActivity,
 				LogIssues:            project.LogIssues,
 				ExecutionOptions:     project.ExecutionOptions,
+				RerunAfterCurrent:    project.RerunAfterCurrent,
 			})
 		}
 	}
@@ -207,6 +244,7 @@ func (r *renovateJobManager) GetProjectsForRenovateJob(ctx context.Context, job
 			PRActivity:           project.PRActivity,
 			LogIssues:            project.LogIssues,
 			ExecutionOptions:     project.ExecutionOptions,
+			RerunAfterCurrent:    project.RerunAfterCurrent,
 		})
 	}
 	return result, nil
@@ -245,9 +283,18 @@ func (r *renovateJobManager) ListRenovateJobsFull(ctx context.Context) ([]api.Re
 }
 
 func (r *renovateJobManager) UpdateProjectStatus(ctx context.Context, project string, job RenovateJobIdentifier, status *types.RenovateStatusUpdate) error {
+	update, err := r.updateProjectStatus(ctx, project, job, status)
+	if update != nil {
+		r.announceProjectUpdate(ctx, *update)
+	}
+	return err
+}
+
+func (r *renovateJobManager) updateProjectStatus(ctx context.Context, project string, job RenovateJobIdentifier, status *types.RenovateStatusUpdate) (*projectUpdate, error) {
 	defer r.globalManagerLock(false)()
 
-	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+	var update *projectUpdate
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
 		renovateJob, err := loadRenovateJob(ctx, job.Name, job.Namespace, r.client)
 		if err != nil {
 			return err
@@ -265,41 +312,133 @@ func (r *renovateJobManager) UpdateProjectStatus(ctx context.Context, project st
 		}
 
 		projectStatus := renovateJob.Status.Projects[index]
+		previous := projectStatus
 		renovateJob.Status.Projects[index] = *utils.GetUpdateStatusForProject(&projectStatus, status)
 
-		return r.client.Status().Update(ctx, renovateJob)
+		if err := r.client.Status().Update(ctx, renovateJob); err != nil {
+			return err
+		}
+		update = &projectUpdate{renovateJob: renovateJob, previous: previous, project: renovateJob.Status.Projects[index], desired: status.Status}
+		return nil
 	})
+	return update, err
 }
 
 func (r *renovateJobManager) UpdateProjectStatusBatched(ctx context.Context, fn func(p api.ProjectStatus) bool, job RenovateJobIdentifier, status *types.RenovateStatusUpdate) error {
+	updates, err := r.updateProjectStatusBatched(ctx, fn, job, status)
+	for _, update := range updates {
+		r.announceProjectUpdate(ctx, update)
+	}
+	return err
+}
+
+func (r *renovateJobManager) updateProjectStatusBatched(ctx context.Context, fn func(p api.ProjectStatus) bool, job RenovateJobIdentifier, status *types.RenovateStatusUpdate) ([]projectUpdate, error) {
 	defer r.globalManagerLock(false)()
 
-	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+	var updates []projectUpdate
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
 		renovateJob, err := loadRenovateJob(ctx, job.Name, job.Namespace, r.client)
 		if err != nil {
 			return err
 		}
 
+		var updated []int
+		previous := make(map[int]api.ProjectStatus)
 		for i := range renovateJob.Status.Projects {
 			p := renovateJob.Status.Projects[i]
 
 			if fn(p) {
+				updated = append(updated, i)
+				previous[i] = p
 				renovateJob.Status.Projects[i] = *utils.GetUpdateStatusForProject(&p, status)
 			}
 		}
 
-		return r.client.Status().Update(ctx, renovateJob)
+		if err := r.client.Status().Update(ctx, renovateJob); err != nil {
+			return err
+		}
+		updates = nil
+		for _, i := range updated {
+			updates = append(updates, projectUpdate{renovateJob: renovateJob, previous: previous[i], project: renovateJob.Status.Projects[i], desired: status.Status})
+		}
+		return nil
 	})
+	return updates, err
+}
+
+// projectUpdate is a project of renovateJob that was updated from previous to
+// project with desired. It is announced once the manager lock is released, so
+// slow sinks and stores never hold up the status updates of other callers.
+type projectUpdate struct {
+	renovateJob       *api.RenovateJob
+	previous, project api.ProjectStatus
+	desired           api.RenovateProjectStatus
+}
+
+// announceProjectUpdate publishes the events, sends the notifications and
+// records the run of an update.
+func (r *renovateJobManager) announceProjectUpdate(ctx context.Context, update projectUpdate) {
+	renovateJob, previous, project, desired := update.renovateJob, update.previous, update.project, update.desired
+	r.publishRunEvent(ctx, renovateJob, previous.Status, project, desired)
+	if r.history != nil && previous.Status == api.JobStatusRunning && (desired == api.JobStatusCompleted || desired == api.JobStatusFailed) {
+		r.history.Record(ctx, reports.Run{
+			Namespace:   renovateJob.Namespace,
+			RenovateJob: renovateJob.Name,
+			Project:     project.Name,
+			Status:      desired,
+			Time:        time.Now(),
+			PRActivity:  project.PRActivity,
+		})
+	}
+	if r.notifier != nil {
+		for _, notification := range notifications.ForRun(renovateJob, previous, project, desired) {
+			r.notifier.Notify(ctx, renovateJob, notification)
+		}
+	}
+}
+
+// publishRunEvent publishes the event of a run that started or finished with
+// an update to desired. A run that finishes while a rerun is pending moves
+// straight back to scheduled, but it still finished.
+func (r *renovateJobManager) publishRunEvent(ctx context.Context, renovateJob *api.RenovateJob, previous api.RenovateProjectStatus, project api.ProjectStatus, desired api.RenovateProjectStatus) {
+	switch {
+	case previous == api.JobStatusScheduled && desired == api.JobStatusRunning:
+	case previous == api.JobStatusRunning && (desired == api.JobStatusCompleted || desired == api.JobStatusFailed || desired == api.JobStatusCancelled):
+	default:
+		return
+	}
+	data := eventStream.RunData{
+		Namespace:   renovateJob.Namespace,
+		RenovateJob: renovateJob.Name,
+		Project:     project.Name,
+		Status:      desired,
+	}
+	// a starting run still carries the results of the previous one
+	if desired != api.JobStatusRunning {
+		data.RenovateResultStatus = project.RenovateResultStatus
+		data.PRActivity = project.PRActivity
+		data.LogIssues = project.LogIssues
+		if project.Duration != nil {
+			data.Duration = *project.Duration
+		}
+	}
+	if r.publisher == nil {
+		return
+	}
+	if event, ok := eventStream.NewRunEvent(data); ok {
+		r.publisher.Publish(ctx, event)
+	}
 }
 
 func (r *renovateJobManager) ReconcileProjects(ctx context.Context, renovateJob *api.RenovateJob, projects []string) ([]string, error) {
 
-	if (renovateJob.Spec.SkipForks || renovateJob.Spec.SkipPendingDeletion) && r.gitProviderClientFactory != nil {
+	filterOptions := r.filterOptions(renovateJob)
+	if filterOptions.Enabled() && r.gitProviderClientFactory != nil {
 		providerClient, err := r.gitProviderClientFactory.NewClient(ctx, renovateJob)
 		if err != nil {
 			r.logger.Error(err, "Failed to create git provider client for project filtering")
 		} else {
-			newProjects, stats, err := gitProviderClients.FilterProjects(ctx, providerClient, r.logger, projects, renovateJob.Spec.SkipForks, renovateJob.Spec.SkipPendingDeletion)
+			newProjects, stats, err := gitProviderClients.FilterProjects(ctx, providerClient, r.logger, projects, filterOptions)
 			if err != nil {
 				r.logger.Error(err, "Failed to filter discovered repositories")
 			} else {
@@ -307,13 +446,21 @@ func (r *renovateJobManager) ReconcileProjects(ctx context.Context, renovateJob
 				projects = newProjects
 				metricStore.AddRepositoriesFiltered(ctx, renovateJob.Namespace, renovateJob.Name, "fork", stats.ForksRemoved)
 				metricStore.AddRepositoriesFiltered(ctx, renovateJob.Namespace, renovateJob.Name, "pending_deletion", stats.PendingRemoved)
+				metricStore.AddRepositoriesFiltered(ctx, renovateJob.Namespace, renovateJob.Name, "archived", stats.ArchivedRemoved)
+				metricStore.AddRepositoriesFiltered(ctx, renovateJob.Namespace, renovateJob.Name, "empty", stats.EmptyRemoved)
+				metricStore.AddRepositoriesFiltered(ctx, renovateJob.Namespace, renovateJob.Name, "inactive", stats.InactiveRemoved)
+				metricStore.AddRepositoriesFiltered(ctx, renovateJob.Namespace, renovateJob.Name, "visibility", stats.VisibilityRemoved)
 			}
 		}
 	}
 
+	projects = r.applyRepositoryRules(ctx, renovateJob, projects)
+	approves := r.onboardingGate(renovateJob)
+
 	defer r.globalManagerLock(false)()
 
-	var removed []string
+	var removed, added, pending []string
+	var updated *api.RenovateJob
 	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
 		renovateJob, err := loadRenovateJob(ctx, renovateJob.Name, renovateJob.Namespace, r.client)
 		if err != nil {
@@ -340,28 +487,262 @@ func (r *renovateJobManager) ReconcileProjects(ctx context.Context, renovateJob
 			}
 		}
 
+		added, pending = added[:0], pending[:0]
 		newProjects := make([]api.ProjectStatus, 0, len(projects))
 		for _, project := range projects {
 			if crdProject, exists := crdProjectSet[project]; exists {
+				// a project still waiting for approval is released once the
+				// gate no longer holds it (approval turned off, or a new
+				// auto-approve rule matches)
+				if crdProject.Status == api.JobStatusPendingApproval && approves(project) {
+					crdProject.Status = api.JobStatusScheduled
+					crdProject.LastTransition = v1.Now()
+				}
 				// add project that exist in the new project list
 				newProjects = append(newProjects, crdProject)
 			} else {
 				// add new project to the list
+				status := api.JobStatusScheduled
+				if !approves(project) {
+					status = api.JobStatusPendingApproval
+					pending = append(pending, project)
+				}
+				added = append(added, project)
 				now := v1.Now()
 				newProjects = append(newProjects, api.ProjectStatus{
 					Name:           project,
-					Status:         api.JobStatusScheduled,
+					Status:         status,
 					LastTransition: now,
 				})
 			}
 		}
 		renovateJob.Status.Projects = newProjects
+		discoveredAt := v1.Now()
+		renovateJob.Status.LastDiscoveryTime = &discoveredAt
 
+		updated = renovateJob
 		return r.client.Status().Update(ctx, renovateJob)
 	})
+	if err == nil {
+		r.recordDiscoveryDiff(ctx, updated, added, removed, pending)
+	}
 	return removed, err
 }
 
+func (r *renovateJobManager) ApproveProjects(ctx context.Context, job RenovateJobIdentifier, projects []string) error {
+	approve := make(map[string]struct{}, len(projects))
+	for _, project := range projects {
+		approve[project] = struct{}{}
+	}
+
+	defer r.globalManagerLock(false)()
+
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		renovateJob, err := loadRenovateJob(ctx, job.Name, job.Namespace, r.client)
+		if err != nil {
+			return err
+		}
+
+		for i := range renovateJob.Status.Projects {
+			p := &renovateJob.Status.Projects[i]
+			if _, ok := approve[p.Name]; ok && p.Status == api.JobStatusPendingApproval {
+				p.Status = api.JobStatusScheduled
+				p.LastTransition = v1.Now()
+			}
+		}
+
+		return r.client.Status().Update(ctx, renovateJob)
+	})
+}
+
+// onboardingGate returns whether a newly discovered project may run without
+// an admin's approval under the job's spec.onboarding.
+func (r *renovateJobManager) onboardingGate(renovateJob *api.RenovateJob) func(project string) bool {
+	onboarding := renovateJob.Spec.Onboarding
+	if onboarding == nil || !onboarding.RequireApproval {
+		return func(string) bool { return true }
+	}
+	matcher, err := utils.NewRepositoryMatcher(onboarding.AutoApprove)
+	if err != nil {
+		r.logger.Error(err, "Ignoring invalid onboarding.autoApprove entries", "job", renovateJob.Fullname())
+	}
+	return matcher.Matches
+}
+
+// maxEventProjects caps how many project names a discovery Event lists, so
+// the first discovery of a large organisation does not produce a huge message.
+const maxEventProjects = 10
+
+// recordDiscoveryDiff records the projects a discovery added and removed as
+// Events on the RenovateJob and as metrics.
+func (r *renovateJobManager) recordDiscoveryDiff(ctx context.Context, renovateJob *api.RenovateJob, added, removed, pending []string) {
+	metricStore.AddDiscoveryProjectChanges(ctx, renovateJob.Namespace, renovateJob.Name, "added", len(added))
+	metricStore.AddDiscoveryProjectChanges(ctx, renovateJob.Namespace, renovateJob.Name, "removed", len(removed))
+	metricStore.AddDiscoveryProjectChanges(ctx, renovateJob.Namespace, renovateJob.Name, "pending_approval", len(pending))
+
+	if r.recorder == nil {
+		return
+	}
+	if len(added) > 0 {
+		r.recorder.Eventf(renovateJob, nil, corev1.EventTypeNormal, "ProjectsAdded", "Discovery", "Discovery added %d project(s): %s", len(added), summarizeProjects(added))
+	}
+	if len(removed) > 0 {
+		r.recorder.Eventf(renovateJob, nil, corev1.EventTypeNormal, "ProjectsRemoved", "Discovery", "Discovery removed %d project(s): %s", len(removed), summarizeProjects(removed))
+	}
+	if len(pending) > 0 {
+		r.recorder.Eventf(renovateJob, nil, corev1.EventTypeNormal, "ProjectsPendingApproval", "Discovery", "%d new project(s) wait for approval: %s", len(pending), summarizeProjects(pending))
+	}
+}
+
+func summarizeProjects(projects []string) string {
+	sorted := slices.Sorted(slices.Values(projects))
+	if len(sorted) <= maxEventProjects {
+		return strings.Join(sorted, ", ")
+	}
+	return fmt.Sprintf("%s and %d more", strings.Join(sorted[:maxEventProjects], ", "), len(sorted)-maxEventProjects)
+}
+
+// filterOptions maps the repository metadata filters of the RenovateJob spec.
+// An invalid skipInactiveFor is logged and disables only that criterion.
+func (r *renovateJobManager) filterOptions(renovateJob *api.RenovateJob) gitProviderClients.FilterOptions {
+	inactiveFor, err := utils.ParseDurationWithDays(renovateJob.Spec.SkipInactiveFor)
+	if err != nil {
+		r.logger.Error(err, "Ignoring invalid skipInactiveFor", "job", renovateJob.Fullname())
+		inactiveFor = 0
+	}
+	return gitProviderClients.FilterOptions{
+		SkipForks:           renovateJob.Spec.SkipForks,
+		SkipPendingDeletion: renovateJob.Spec.SkipPendingDeletion,
+		SkipArchived:        renovateJob.Spec.SkipArchived,
+		SkipEmpty:           renovateJob.Spec.SkipEmpty,
+		InactiveFor:         inactiveFor,
+		Visibility:          renovateJob.Spec.Visibility,
+	}
+}
+
+// applyRepositoryRules drops discovered projects matching spec.excludeRepositories
+// and adds the pinned spec.repositories, returning a sorted list without duplicates.
+func (r *renovateJobManager) applyRepositoryRules(ctx context.Context, renovateJob *api.RenovateJob, projects []string) []string {
+	if len(renovateJob.Spec.ExcludeRepositories) == 0 && len(renovateJob.Spec.Repositories) == 0 {
+		return projects
+	}
+
+	if len(renovateJob.Spec.ExcludeRepositories) > 0 {
+		matcher, err := utils.NewRepositoryMatcher(renovateJob.Spec.ExcludeRepositories)
+		if err != nil {
+			r.logger.Error(err, "Ignoring invalid excludeRepositories entries", "job", renovateJob.Fullname())
+		}
+		kept := make([]string, 0, len(projects))
+		excluded := 0
+		for _, project := range projects {
+			if matcher.Matches(project) {
+				excluded++
+				r.logger.V(2).Info("Excluding repository", "project", project)
+				continue
+			}
+			kept = append(kept, project)
+		}
+		projects = kept
+		metricStore.AddRepositoriesFiltered(ctx, renovateJob.Namespace, renovateJob.Name, "excluded", excluded)
+	}
+
+	seen := make(map[string]struct{}, len(projects)+len(renovateJob.Spec.Repositories))
+	merged := make([]string, 0, len(projects)+len(renovateJob.Spec.Repositories))
+	for _, project := range slices.Concat(projects, renovateJob.Spec.Repositories) {
+		if _, ok := seen[project]; ok || project == "" {
+			continue
+		}
+		seen[project] = struct{}{}
+		merged = append(merged, project)
+	}
+	slices.Sort(merged)
+	return merged
+}
+
+// platformsWithoutTopics are the platforms whose repositories carry no topics.
+var platformsWithoutTopics = []string{"bitbucket", "bitbucket-server", "azure"}
+
+func (r *renovateJobManager) DiscoverProjects(ctx context.Context, renovateJob *api.RenovateJob) ([]string, error) {
+	if r.gitProviderClientFactory == nil {
+		return nil, fmt.Errorf("native discovery is not available")
+	}
+	platform, _ := utils.GetPlatformAndEndpoint(renovateJob.Spec.Provider)
+	if slices.Contains(platformsWithoutTopics, platform) && len(renovateJob.Spec.DiscoverTopics) > 0 {
+		// listing nothing would remove every project
+		return nil, fmt.Errorf("discoverTopics is not supported by native discovery on %s", platform)
+	}
+
+	providerClient, err := r.gitProviderClientFactory.NewClient(ctx, renovateJob)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create git provider client: %w", err)
+	}
+	projects, err := gitProviderClients.DiscoverRepositories(ctx, providerClient, renovateJob.Spec.DiscoveryNamespaces, renovateJob.Spec.DiscoverTopics)
+	if err != nil {
+		return nil, err
+	}
+	return r.applyDiscoveryFilters(renovateJob, projects), nil
+}
+
+func (r *renovateJobManager) GetDefaultBranch(ctx context.Context, renovateJob *api.RenovateJob, project string) (string, error) {
+	if r.gitProviderClientFactory == nil {
+		return "", fmt.Errorf("platform API access is not available")
+	}
+	providerClient, err := r.gitProviderClientFactory.NewClient(ctx, renovateJob)
+	if err != nil {
+		return "", fmt.Errorf("failed to create git provider client: %w", err)
+	}
+	info, err := providerClient.GetRepositoryInfo(ctx, project)
+	if err != nil {
+		return "", err
+	}
+	return info.DefaultBranch, nil
+}
+
+func (r *renovateJobManager) ListChangedFiles(ctx context.Context, renovateJob *api.RenovateJob, project, base, head string) ([]string, error) {
+	if r.gitProviderClientFactory == nil {
+		return nil, fmt.Errorf("platform API access is not available")
+	}
+	providerClient, err := r.gitProviderClientFactory.NewClient(ctx, renovateJob)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create git provider client: %w", err)
+	}
+	return providerClient.ListChangedFiles(ctx, project, base, head)
+}
+
+// applyDiscoveryFilters keeps the projects matching spec.discoveryFilters the
+// way Renovate's autodiscoverFilter does: a project must match one of the
+// filters, unless it matches a filter negated with "!".
+func (r *renovateJobManager) applyDiscoveryFilters(renovateJob *api.RenovateJob, projects []string) []string {
+	if len(renovateJob.Spec.DiscoveryFilters) == 0 {
+		return projects
+	}
+
+	var include, exclude []string
+	for _, filter := range renovateJob.Spec.DiscoveryFilters {
+		if negated, ok := strings.CutPrefix(filter, "!"); ok {
+			exclude = append(exclude, negated)
+		} else {
+			include = append(include, filter)
+		}
+	}
+	included, err := utils.NewRepositoryMatcher(include)
+	if err != nil {
+		r.logger.Error(err, "Ignoring invalid discoveryFilters entries", "job", renovateJob.Fullname())
+	}
+	excluded, err := utils.NewRepositoryMatcher(exclude)
+	if err != nil {
+		r.logger.Error(err, "Ignoring invalid discoveryFilters entries", "job", renovateJob.Fullname())
+	}
+
+	kept := make([]string, 0, len(projects))
+	for _, project := range projects {
+		if (len(include) == 0 || included.Matches(project)) && !excluded.Matches(project) {
+			kept = append(kept, project)
+		}
+	}
+	return kept
+}
+
 func (r *renovateJobManager) SyncWebhooks(ctx context.Context, job RenovateJobIdentifier, removedProjects []string) error {
 	unlock := r.globalManagerLock(true)
 	renovateJob, err := loadRenovateJob(ctx, job.Name, job.Namespace, r.client)
@@ -377,24 +758,49 @@ func (r *renovateJobManager) SyncWebhooks(ctx context.Context, job RenovateJobId
 	syncEnabled := webhook.Enabled && webhook.Sync.Enabled
 
 	// Reconcile toward the desired state: with sync enabled, hooks exist on all
-	// current projects and are removed from projects that dropped out; with
-	// sync disabled, hooks are removed from every project.
+	// current projects (or their organizations) and are removed from projects
+	// that dropped out; with sync disabled, hooks are removed from every
+	// project and organization.
 	current := make([]string, 0, len(renovateJob.Status.Projects))
 	for _, project := range renovateJob.Status.Projects {
 		current = append(current, project.Name)
 	}
-	var desired, removed []string
-	if syncEnabled {
-		desired = current
-		removed = removedProjects
-	} else {
-		removed = append(current, removedProjects...)
+	plan := newWebhookSyncPlan(renovateJob.Status.WebhookSync)
+	switch {
+	case !syncEnabled:
+		if plan.previous.Scope != api.WebhookSyncScopeOrganization {
+			plan.removed = append(current, removedProjects...)
+		}
+		plan.removedOrgs = plan.previous.Organizations
+	case webhook.Sync.Scope == api.WebhookSyncScopeOrganization:
+		plan.scope = api.WebhookSyncScopeOrganization
+		plan.desiredOrgs = webhookOrganizations(webhook.Sync, current)
+		plan.removedOrgs = withoutAll(plan.previous.Organizations, plan.desiredOrgs)
+		if plan.previous.Scope != api.WebhookSyncScopeOrganization {
+			// the per-project hooks are replaced once the organization
+			// hooks are in place
+			plan.replaced = current
+			plan.removed = removedProjects
+		}
+	default:
+		plan.scope = api.WebhookSyncScopeRepository
+		plan.desired = current
+		plan.removed = removedProjects
+		plan.removedOrgs = plan.previous.Organizations
 	}
-	if len(desired) == 0 && len(removed) == 0 {
-		return nil
+	plan.addPendingRemovals()
+	if plan.empty() {
+		if renovateJob.Status.WebhookSync == nil {
+			return nil
+		}
+		return r.updateWebhookSyncStatus(ctx, job, plan.nextState(r.logger, webhookSync.Result{}, webhookSync.Result{}))
 	}
 
-	return r.runWebhookSync(ctx, renovateJob, job, desired, removed)
+	state, err := r.runWebhookSync(ctx, renovateJob, job, plan)
+	if err != nil {
+		return err
+	}
+	return r.updateWebhookSyncStatus(ctx, job, state)
 }
 
 func (r *renovateJobManager) CleanupWebhooks(ctx context.Context, job RenovateJobIdentifier) error {
@@ -405,20 +811,28 @@ func (r *renovateJobManager) CleanupWebhooks(ctx context.Context, job RenovateJo
 		return fmt.Errorf("failed to load renovate job: %w", err)
 	}
 
-	removed := make([]string, 0, len(renovateJob.Status.Projects))
-	for _, project := range renovateJob.Status.Projects {
-		removed = append(removed, project.Name)
+	plan := newWebhookSyncPlan(renovateJob.Status.WebhookSync)
+	if plan.previous.Scope != api.WebhookSyncScopeOrganization {
+		for _, project := range renovateJob.Status.Projects {
+			plan.removed = append(plan.removed, project.Name)
+		}
 	}
-	if len(removed) == 0 {
+	plan.removedOrgs = plan.previous.Organizations
+	plan.addPendingRemovals()
+	if plan.empty() {
 		return nil
 	}
-	return r.runWebhookSync(ctx, renovateJob, job, nil, removed)
+	// the job is going away, so removals that fail again are only logged
+	_, err = r.runWebhookSync(ctx, renovateJob, job, plan)
+	return err
 }
 
-// runWebhookSync builds the provider client and delivery URL, then runs one
-// webhook sync cycle over the given project sets.
-func (r *renovateJobManager) runWebhookSync(ctx context.Context, renovateJob *api.RenovateJob, job RenovateJobIdentifier, desired, removed []string) error {
+// runWebhookSync builds the provider client and delivery URLs, then runs one
+// webhook sync cycle over the plan and returns the state to record for the
+// next one.
+func (r *renovateJobManager) runWebhookSync(ctx context.Context, renovateJob *api.RenovateJob, job RenovateJobIdentifier, plan webhookSyncPlan) (*api.WebhookSyncStatus, error) {
 	webhook := renovateJob.Spec.Webhook
+	writes := len(plan.desired) > 0 || len(plan.desiredOrgs) > 0
 
 	var gitProvider gitProviderClients.GitProviderClient
 	var err error
@@ -428,44 +842,80 @@ func (r *renovateJobManager) runWebhookSync(ctx context.Context, renovateJob *ap
 		gitProvider, err = r.gitProviderClientFactory.NewClient(ctx, renovateJob)
 	}
 	if err != nil {
-		return fmt.Errorf("failed to create git provider client: %w", err)
+		return nil, fmt.Errorf("failed to create git provider client: %w", err)
 	}
 
 	rawURL, err := webhookURLForJob(renovateJob)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	// Only writes are gated. For a removal the delivery URL is matching input, not
 	// a destination, and refusing it would strand exactly the hook that a hostile
 	// baseUrl created.
-	if len(desired) > 0 {
+	if writes {
 		if err := r.policy.ValidateDestination(rawURL, "spec.webhook.baseUrl"); err != nil {
 			metricStore.IncPolicyDenial(ctx, "destination")
-			return fmt.Errorf("refusing to write webhooks: %w", err)
+			return nil, fmt.Errorf("refusing to write webhooks: %w", err)
 		}
 	}
 	webhookURL, err := buildWebhookURL(rawURL, job)
 	if err != nil {
-		return fmt.Errorf("failed to parse webhookURL: %w", err)
+		return nil, fmt.Errorf("failed to parse webhookURL: %w", err)
+	}
+	orgWebhookURL, err := buildOrgWebhookURL(webhookURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse webhookURL: %w", err)
 	}
 
 	var authToken string
-	if len(desired) > 0 && webhook != nil && webhook.Authentication != nil && webhook.Authentication.Enabled && webhook.Authentication.SecretRef != nil {
+	if writes && webhook != nil && webhook.Authentication != nil && webhook.Authentication.Enabled && webhook.Authentication.SecretRef != nil {
 		tokens, err := r.getRenovateJobTokens(ctx, renovateJob)
 		if err != nil {
-			return fmt.Errorf("failed to read webhook auth token: %w", err)
+			return nil, fmt.Errorf("failed to read webhook auth token: %w", err)
 		}
 		if len(tokens) > 0 {
 			authToken = tokens[0]
 		}
 	}
 
-	opts := webhookSync.Options{
-		WebhookURL: webhookURL,
-		AuthToken:  authToken,
+	logger := r.logger.WithName("webhook-sync")
+	var orgResult webhookSync.Result
+	if len(plan.desiredOrgs) > 0 || len(plan.removedOrgs) > 0 {
+		opts := webhookSync.Options{WebhookURL: orgWebhookURL, AuthToken: authToken}
+		orgResult = webhookSync.SyncOrganizations(ctx, logger, gitProvider, opts, plan.desiredOrgs, plan.removedOrgs)
+	}
+
+	removed := plan.removed
+	for _, project := range plan.replaced {
+		if coveredByOrganization(project, orgResult.Ensured) {
+			removed = append(removed, project)
+		}
+	}
+	var result webhookSync.Result
+	if len(plan.desired) > 0 || len(removed) > 0 {
+		opts := webhookSync.Options{WebhookURL: webhookURL, AuthToken: authToken}
+		result = webhookSync.Sync(ctx, logger, gitProvider, opts, plan.desired, removed)
 	}
-	webhookSync.Sync(ctx, r.logger.WithName("webhook-sync"), gitProvider, opts, desired, removed)
-	return nil
+
+	return plan.nextState(logger, orgResult, result), nil
+}
+
+// updateWebhookSyncStatus records the state of the webhook sync on the job,
+// unless it is unchanged.
+func (r *renovateJobManager) updateWebhookSyncStatus(ctx context.Context, job RenovateJobIdentifier, state *api.WebhookSyncStatus) error {
+	defer r.globalManagerLock(false)()
+
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		renovateJob, err := loadRenovateJob(ctx, job.Name, job.Namespace, r.client)
+		if err != nil {
+			return err
+		}
+		if equality.Semantic.DeepEqual(renovateJob.Status.WebhookSync, state) {
+			return nil
+		}
+		renovateJob.Status.WebhookSync = state
+		return r.client.Status().Update(ctx, renovateJob)
+	})
 }
 
 func webhookURLForJob(renovateJob *api.RenovateJob) (string, error) {
@@ -501,6 +951,19 @@ func buildWebhookURL(rawURL string, job RenovateJobIdentifier) (string, error) {
 	return parsed.String(), nil
 }
 
+// buildOrgWebhookURL marks the delivery URL of an organization hook, whose
+// deliveries also cover repositories that are not projects of the job.
+func buildOrgWebhookURL(webhookURL string) (string, error) {
+	parsed, err := url.Parse(webhookURL)
+	if err != nil {
+		return "", err
+	}
+	q := parsed.Query()
+	q.Set(utils.WebhookScopeParameter, string(api.WebhookSyncScopeOrganization))
+	parsed.RawQuery = q.Encode()
+	return parsed.String(), nil
+}
+
 func (r *renovateJobManager) StreamLogsForProject(ctx context.Context, job RenovateJobIdentifier, project string) (io.ReadCloser, error) {
 	// Phase 1: hold the read lock only for CRD + k8s Job metadata lookup.
 	unlock := r.globalManagerLock(true)
@@ -673,11 +1136,11 @@ func (r *renovateJobManager) IsWebhookStandardSignatureValid(ctx context.Context
 
 	signedContent := msgID + "." + timestamp + "." + string(body)
 	for _, token := range tokens {
-		key, ok := decodeStandardWebhookSigningKey(token)
+		key, ok := utils.DecodeStandardWebhookSigningKey(token)
 		if !ok {
 			continue
 		}
-		expected := computeStandardWebhookSignature(key, signedContent)
+		expected := utils.ComputeStandardWebhookSignature(key, signedContent)
 		if matchesAnyStandardWebhookSignature(signature, expected) {
 			return true, nil
 		}
@@ -726,13 +1189,23 @@ func (r *renovateJobManager) SetAcceptedCondition(ctx context.Context, job Renov
 			ObservedGeneration: renovateJob.Generation,
 		}
 
+		// only a new refusal is worth a notification, not a reworded one
+		previous := meta.FindStatusCondition(renovateJob.Status.Conditions, api.ConditionAccepted)
+		newlyRefused := !accepted && (previous == nil || previous.Status != v1.ConditionFalse || previous.Reason != reason)
+
 		// The reconciler runs on a one-minute requeue, so writing unconditionally
 		// would rewrite the status and bump resourceVersion on every tick forever.
 		// SetStatusCondition reports whether anything actually changed.
 		if !meta.SetStatusCondition(&renovateJob.Status.Conditions, condition) {
 			return nil
 		}
-		return r.client.Status().Update(ctx, renovateJob)
+		if err := r.client.Status().Update(ctx, renovateJob); err != nil {
+			return err
+		}
+		if newlyRefused && r.notifier != nil {
+			r.notifier.Notify(ctx, renovateJob, notifications.ForPolicyDenial(renovateJob, reason, message))
+		}
+		return nil
 	})
 }
 
@@ -747,34 +1220,6 @@ func computeHMAC256(message []byte, secret string) string {
 // time before the request is rejected as a potential replay. Matches the Standard Webhooks default.
 const standardWebhookTimestampTolerance = 5 * time.Minute
 
-// decodeStandardWebhookSigningKey returns the raw HMAC key for a Standard Webhooks signing secret. The
-// canonical form is "whsec_" + base64(key), as issued by Standard Webhooks senders (GitLab among them).
-// A bare value is base64-decoded when possible, otherwise used verbatim as the key.
-func decodeStandardWebhookSigningKey(secret string) ([]byte, bool) {
-	if secret == "" {
-		return nil, false
-	}
-	if rest, found := strings.CutPrefix(secret, "whsec_"); found {
-		decoded, err := base64.StdEncoding.DecodeString(rest)
-		if err != nil {
-			return nil, false
-		}
-		return decoded, true
-	}
-	if decoded, err := base64.StdEncoding.DecodeString(secret); err == nil {
-		return decoded, true
-	}
-	return []byte(secret), true
-}
-
-// computeStandardWebhookSignature returns the base64 HMAC-SHA256 of signedContent, without the
-// "v1," version prefix.
-func computeStandardWebhookSignature(key []byte, signedContent string) string {
-	mac := hmac.New(sha256.New, key)
-	mac.Write([]byte(signedContent))
-	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
-}
-
 // matchesAnyStandardWebhookSignature reports whether expected (raw base64) matches any "v1" entry
 // in a space-separated webhook-signature header value. Comparison is constant-time.
 func matchesAnyStandardWebhookSignature(header, expected string) bool {
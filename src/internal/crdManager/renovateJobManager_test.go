@@ -2,11 +2,13 @@ package crdmanager
 
 import (
 	"context"
+	"slices"
 	"strings"
 	"testing"
 
 	api "renovate-operator/api/v1alpha1"
 	"renovate-operator/config"
+	"renovate-operator/gitProviderClients"
 	"renovate-operator/internal/kvstore"
 	"renovate-operator/internal/logStore"
 	"renovate-operator/internal/objectstore"
@@ -16,6 +18,7 @@ import (
 	"github.com/go-logr/logr"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/events"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 )
 
@@ -52,7 +55,7 @@ func TestListRenovateJobs(t *testing.T) {
 	if err != nil {
 		t.Fatalf("failed to initialise logStore")
 	}
-	mgr := NewRenovateJobManager(cl, nil, logr.Logger{}, log, nil, testPolicy())
+	mgr := NewRenovateJobManager(cl, nil, logr.Logger{}, log, nil, testPolicy(), nil, nil, nil, nil)
 	ctx := context.Background()
 	list, err := mgr.ListRenovateJobs(ctx)
 	if err != nil {
@@ -78,7 +81,7 @@ func TestListRenovateJobsFull(t *testing.T) {
 	if err != nil {
 		t.Fatalf("failed to initialise logStore")
 	}
-	mgr := NewRenovateJobManager(cl, nil, logr.Logger{}, log, nil, testPolicy())
+	mgr := NewRenovateJobManager(cl, nil, logr.Logger{}, log, nil, testPolicy(), nil, nil, nil, nil)
 	ctx := context.Background()
 	list, err := mgr.ListRenovateJobsFull(ctx)
 	if err != nil {
@@ -114,7 +117,7 @@ func TestUpdateProjectStatus_AddAndUpdate(t *testing.T) {
 	if err != nil {
 		t.Fatalf("failed to initialise logStore")
 	}
-	mgr := NewRenovateJobManager(cl, nil, logr.Logger{}, log, nil, testPolicy())
+	mgr := NewRenovateJobManager(cl, nil, logr.Logger{}, log, nil, testPolicy(), nil, nil, nil, nil)
 	ctx := context.Background()
 
 	err = mgr.UpdateProjectStatus(ctx, "existingProject", RenovateJobIdentifier{Name: "job1", Namespace: "default"}, &types.RenovateStatusUpdate{Status: api.JobStatusRunning})
@@ -154,7 +157,7 @@ func TestUpdateProjectStatusBatched(t *testing.T) {
 	if err != nil {
 		t.Fatalf("failed to initialise logStore")
 	}
-	mgr := NewRenovateJobManager(cl, nil, logr.Logger{}, log, nil, testPolicy())
+	mgr := NewRenovateJobManager(cl, nil, logr.Logger{}, log, nil, testPolicy(), nil, nil, nil, nil)
 	ctx := context.Background()
 
 	// predicate: mark non-running projects as scheduled
@@ -200,7 +203,7 @@ func TestReconcileProjects_AddsAndKeepsExisting(t *testing.T) {
 	if err != nil {
 		t.Fatalf("failed to initialise logStore")
 	}
-	mgr := NewRenovateJobManager(cl, nil, logr.Logger{}, log, nil, testPolicy())
+	mgr := NewRenovateJobManager(cl, nil, logr.Logger{}, log, nil, testPolicy(), nil, nil, nil, nil)
 	ctx := context.Background()
 
 	rJob, err := mgr.GetRenovateJob(ctx, "job1", "default")
@@ -252,7 +255,7 @@ func TestGetProjectsFilters(t *testing.T) {
 	if err != nil {
 		t.Fatalf("failed to initialise logStore")
 	}
-	mgr := NewRenovateJobManager(cl, nil, logr.Logger{}, log, nil, testPolicy())
+	mgr := NewRenovateJobManager(cl, nil, logr.Logger{}, log, nil, testPolicy(), nil, nil, nil, nil)
 	ctx := context.Background()
 
 	list, err := mgr.GetProjectsByStatus(ctx, RenovateJobIdentifier{Name: "job1", Namespace: "default"}, api.JobStatusCompleted)
@@ -365,8 +368,199 @@ func TestWebhookURLForJobErrorsWithoutBaseURL(t *testing.T) {
 func TestWebhookURLForJobErrorsForUnsupportedPlatform(t *testing.T) {
 	setBaseURL(t, "https://hooks.example.com")
 
-	_, err := webhookURLForJob(syncJob("bitbucket"))
+	_, err := webhookURLForJob(syncJob("local"))
 	if err == nil {
 		t.Fatal("expected error for platform without webhook endpoint")
 	}
 }
+
+func TestReconcileProjects_ExcludesAndPinsRepositories(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := api.AddToScheme(scheme); err != nil {
+		t.Fatalf("failed to add scheme: %v", err)
+	}
+
+	j := makeJob("job1", "default", nil)
+	j.Spec.Repositories = []string{"other/pinned", "org/legacy-keep"}
+	j.Spec.ExcludeRepositories = []string{"org/legacy-*", "/sandbox/"}
+	cl := fake.NewClientBuilder().WithScheme(scheme).WithObjects(j).WithStatusSubresource(&api.RenovateJob{}).Build()
+
+	log, err := logStore.NewLogStore(logr.Logger{}, "memory", kvstore.ValkeyConfig{}, objectstore.S3Config{}, "")
+	if err != nil {
+		t.Fatalf("failed to initialise logStore")
+	}
+	mgr := NewRenovateJobManager(cl, nil, logr.Logger{}, log, nil, testPolicy(), nil, nil, nil, nil)
+	ctx := context.Background()
+
+	rJob, err := mgr.GetRenovateJob(ctx, "job1", "default")
+	if err != nil {
+		t.Fatalf("unexpected error getting job for reconcile: %v", err)
+	}
+
+	discovered := []string{"org/api", "org/legacy-web", "org/sandbox-1", "other/pinned"}
+	if _, err := mgr.ReconcileProjects(ctx, rJob, discovered); err != nil {
+		t.Fatalf("unexpected error in reconcile: %v", err)
+	}
+	job, err := mgr.GetRenovateJob(ctx, "job1", "default")
+	if err != nil {
+		t.Fatalf("unexpected error getting job: %v", err)
+	}
+
+	var names []string
+	for _, p := range job.Status.Projects {
+		names = append(names, p.Name)
+	}
+	expected := []string{"org/api", "org/legacy-keep", "other/pinned"}
+	if !slices.Equal(names, expected) {
+		t.Fatalf("expected projects %v, got %v", expected, names)
+	}
+}
+
+func TestReconcileProjects_OnboardingHoldsNewProjects(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := api.AddToScheme(scheme); err != nil {
+		t.Fatalf("failed to add scheme: %v", err)
+	}
+
+	j := makeJob("job1", "default", []api.ProjectStatus{{Name: "org/existing", Status: api.JobStatusCompleted}})
+	j.Spec.Onboarding = &api.RenovateJobOnboarding{RequireApproval: true, AutoApprove: []string{"org/trusted-*"}}
+	cl := fake.NewClientBuilder().WithScheme(scheme).WithObjects(j).WithStatusSubresource(&api.RenovateJob{}).Build()
+
+	log, err := logStore.NewLogStore(logr.Logger{}, "memory", kvstore.ValkeyConfig{}, objectstore.S3Config{}, "")
+	if err != nil {
+		t.Fatalf("failed to initialise logStore")
+	}
+	recorder := events.NewFakeRecorder(10)
+	mgr := NewRenovateJobManager(cl, nil, logr.Logger{}, log, nil, testPolicy(), recorder, nil, nil, nil)
+	ctx := context.Background()
+
+	rJob, err := mgr.GetRenovateJob(ctx, "job1", "default")
+	if err != nil {
+		t.Fatalf("unexpected error getting job for reconcile: %v", err)
+	}
+	if _, err := mgr.ReconcileProjects(ctx, rJob, []string{"org/existing", "org/new", "org/trusted-api"}); err != nil {
+		t.Fatalf("unexpected error in reconcile: %v", err)
+	}
+
+	job, err := mgr.GetRenovateJob(ctx, "job1", "default")
+	if err != nil {
+		t.Fatalf("unexpected error getting job: %v", err)
+	}
+	statuses := map[string]api.RenovateProjectStatus{}
+	for _, p := range job.Status.Projects {
+		statuses[p.Name] = p.Status
+	}
+	want := map[string]api.RenovateProjectStatus{
+		"org/existing":    api.JobStatusCompleted,
+		"org/new":         api.JobStatusPendingApproval,
+		"org/trusted-api": api.JobStatusScheduled,
+	}
+	for name, status := range want {
+		if statuses[name] != status {
+			t.Errorf("project %s: expected status %q, got %q", name, status, statuses[name])
+		}
+	}
+
+	var reasons []string
+	for len(recorder.Events) > 0 {
+		reasons = append(reasons, strings.Fields(<-recorder.Events)[1])
+	}
+	if !slices.Equal(reasons, []string{"ProjectsAdded", "ProjectsPendingApproval"}) {
+		t.Errorf("unexpected events: %v", reasons)
+	}
+
+	// a trigger must not bypass the gate
+	if err := mgr.UpdateProjectStatus(ctx, "org/new", RenovateJobIdentifier{Name: "job1", Namespace: "default"}, &types.RenovateStatusUpdate{Status: api.JobStatusScheduled}); err != nil {
+		t.Fatalf("unexpected error updating status: %v", err)
+	}
+	job, err = mgr.GetRenovateJob(ctx, "job1", "default")
+	if err != nil {
+		t.Fatalf("unexpected error getting job: %v", err)
+	}
+	for _, p := range job.Status.Projects {
+		if p.Name == "org/new" && p.Status != api.JobStatusPendingApproval {
+			t.Errorf("expected trigger to leave the project pending, got %q", p.Status)
+		}
+	}
+
+	if err := mgr.ApproveProjects(ctx, RenovateJobIdentifier{Name: "job1", Namespace: "default"}, []string{"org/new", "org/existing"}); err != nil {
+		t.Fatalf("unexpected error approving: %v", err)
+	}
+	job, err = mgr.GetRenovateJob(ctx, "job1", "default")
+	if err != nil {
+		t.Fatalf("unexpected error getting job: %v", err)
+	}
+	for _, p := range job.Status.Projects {
+		if p.Name == "org/new" && p.Status != api.JobStatusScheduled {
+			t.Errorf("expected approved project to be scheduled, got %q", p.Status)
+		}
+		if p.Name == "org/existing" && p.Status != api.JobStatusCompleted {
+			t.Errorf("approval must not touch projects that are not pending, got %q", p.Status)
+		}
+	}
+}
+
+func TestDiscoverProjects_AppliesDiscoveryFilters(t *testing.T) {
+	provider := &recordingProvider{repos: map[string][]gitProviderClients.RepositoryListing{
+		"org": {
+			{FullName: "org/api", Topics: []string{"renovate"}},
+			{FullName: "org/api-legacy", Topics: []string{"renovate"}},
+			{FullName: "org/web", Topics: []string{"renovate"}},
+			{FullName: "org/docs"},
+			{FullName: "org/sub/tool", Topics: []string{"renovate"}},
+		},
+	}}
+	mgr := syncManager(t, provider)
+
+	job := makeJob("job1", "default", nil)
+	job.Spec.Provider = &api.RenovateProvider{Name: "github"}
+	job.Spec.DiscoveryNamespaces = []string{"org"}
+	job.Spec.DiscoverTopics = []string{"renovate"}
+	job.Spec.DiscoveryFilters = []string{"org/*", "!org/*-legacy"}
+
+	projects, err := mgr.DiscoverProjects(context.Background(), job)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := []string{"org/api", "org/web"}; !slices.Equal(projects, want) {
+		t.Errorf("expected %v, got %v", want, projects)
+	}
+}
+
+func TestDiscoverProjects_RejectsTopicsOnPlatformsWithoutTopics(t *testing.T) {
+	mgr := syncManager(t, &recordingProvider{})
+
+	for _, platform := range []string{"bitbucket", "bitbucket-server", "azure"} {
+		job := makeJob("job1", "default", nil)
+		job.Spec.Provider = &api.RenovateProvider{Name: platform}
+		job.Spec.DiscoverTopics = []string{"renovate"}
+
+		if _, err := mgr.DiscoverProjects(context.Background(), job); err == nil {
+			t.Fatalf("expected an error for topics on %s", platform)
+		}
+	}
+}
+
+func TestPushLookupsUseThePlatformAPI(t *testing.T) {
+	provider := &recordingProvider{
+		info:    map[string]gitProviderClients.RepositoryInfo{"PRJ/repo": {DefaultBranch: "develop"}},
+		changed: map[string][]string{"PRJ/repo@abc..def": {"renovate.json"}},
+	}
+	mgr := syncManager(t, provider)
+	job := makeJob("job1", "default", nil)
+	job.Spec.Provider = &api.RenovateProvider{Name: "bitbucket-server"}
+
+	branch, err := mgr.GetDefaultBranch(context.Background(), job, "PRJ/repo")
+	if err != nil || branch != "develop" {
+		t.Errorf("expected default branch develop, got %q, %v", branch, err)
+	}
+	files, err := mgr.ListChangedFiles(context.Background(), job, "PRJ/repo", "abc", "def")
+	if err != nil || !slices.Equal(files, []string{"renovate.json"}) {
+		t.Errorf("expected the changed files of the comparison, got %v, %v", files, err)
+	}
+
+	noPlatform := &renovateJobManager{logger: logr.Discard()}
+	if _, err := noPlatform.ListChangedFiles(context.Background(), job, "PRJ/repo", "abc", "def"); err == nil {
+		t.Error("expected an error without platform API access")
+	}
+}
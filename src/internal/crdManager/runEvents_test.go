@@ -0,0 +1,123 @@
+package crdmanager
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	api "renovate-operator/api/v1alpha1"
+	"renovate-operator/internal/eventStream"
+	"renovate-operator/internal/kvstore"
+	"renovate-operator/internal/logStore"
+	"renovate-operator/internal/objectstore"
+	"renovate-operator/internal/reports"
+	"renovate-operator/internal/types"
+
+	"github.com/go-logr/logr"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+type recordingPublisher struct {
+	events []eventStream.Event
+	// manager, if set, must not be locked while an event is published
+	manager *renovateJobManager
+	// underLock counts the events published with the manager locked
+	underLock int
+}
+
+func (p *recordingPublisher) Publish(_ context.Context, event eventStream.Event) {
+	p.events = append(p.events, event)
+	if p.manager != nil {
+		if !p.manager.lock.TryLock() {
+			p.underLock++
+			return
+		}
+		p.manager.lock.Unlock()
+	}
+}
+
+func TestUpdateProjectStatus_PublishesRunEvents(t *testing.T) {
+	publisher := &recordingPublisher{}
+
+	scheme := runtime.NewScheme()
+	if err := api.AddToScheme(scheme); err != nil {
+		t.Fatalf("failed to add scheme: %v", err)
+	}
+	result := "done"
+	j := makeJob("job1", "default", []api.ProjectStatus{
+		{Name: "org/a", Status: api.JobStatusScheduled, RenovateResultStatus: &result},
+		{Name: "org/b", Status: api.JobStatusRunning},
+		{Name: "org/c", Status: api.JobStatusCompleted},
+	})
+	cl := fake.NewClientBuilder().WithScheme(scheme).WithObjects(j).WithStatusSubresource(&api.RenovateJob{}).Build()
+	log, err := logStore.NewLogStore(logr.Logger{}, "memory", kvstore.ValkeyConfig{}, objectstore.S3Config{}, "")
+	if err != nil {
+		t.Fatalf("failed to initialise logStore")
+	}
+	history, _ := reports.NewHistory(logr.Discard(), "memory", kvstore.ValkeyConfig{})
+	mgr := NewRenovateJobManager(cl, nil, logr.Logger{}, log, nil, testPolicy(), nil, publisher, nil, history)
+	publisher.manager = mgr.(*renovateJobManager)
+	ctx := context.Background()
+	jobId := RenovateJobIdentifier{Name: "job1", Namespace: "default"}
+
+	if err := mgr.UpdateProjectStatus(ctx, "org/a", jobId, &types.RenovateStatusUpdate{Status: api.JobStatusRunning}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	duration := "1m0s"
+	if err := mgr.UpdateProjectStatus(ctx, "org/a", jobId, &types.RenovateStatusUpdate{
+		Status:     api.JobStatusFailed,
+		Duration:   &duration,
+		PRActivity: &api.PRActivity{Created: 1},
+		LogIssues:  &api.LogIssues{ErrorCount: 2},
+	}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// no transition, no event
+	if err := mgr.UpdateProjectStatus(ctx, "org/c", jobId, &types.RenovateStatusUpdate{Status: api.JobStatusRunning}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// batched updates publish an event per project
+	isRunning := func(p api.ProjectStatus) bool { return p.Status == api.JobStatusRunning }
+	if err := mgr.UpdateProjectStatusBatched(ctx, isRunning, jobId, &types.RenovateStatusUpdate{Status: api.JobStatusCancelled}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var got []string
+	for _, event := range publisher.events {
+		got = append(got, event.Type+" "+event.Subject)
+	}
+	want := []string{
+		eventStream.TypeRunStarted + " org/a",
+		eventStream.TypeRunFailed + " org/a",
+		eventStream.TypeRunCancelled + " org/b",
+	}
+	if len(got) != len(want) {
+		t.Fatalf("expected events %v, got %v", want, got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("expected event %d to be %q, got %q", i, want[i], got[i])
+		}
+	}
+	if publisher.underLock != 0 {
+		t.Errorf("expected the events to be published once the manager lock is released, %d were not", publisher.underLock)
+	}
+
+	var started, failed eventStream.RunData
+	_ = json.Unmarshal(publisher.events[0].Data, &started)
+	_ = json.Unmarshal(publisher.events[1].Data, &failed)
+	if started.RenovateResultStatus != nil {
+		t.Errorf("expected the started event not to carry the previous result, got %q", *started.RenovateResultStatus)
+	}
+	if failed.Duration != "1m0s" || failed.PRActivity == nil || failed.PRActivity.Created != 1 || failed.LogIssues == nil || failed.LogIssues.ErrorCount != 2 {
+		t.Errorf("expected the failed event to carry the run results, got %+v", failed)
+	}
+
+	// only the finished run is counted, a cancelled one is not
+	activity, err := history.Activity(ctx, "default", "job1", time.Now(), time.Now())
+	if err != nil || activity.Runs != 1 || activity.Failed != 1 || activity.Created != 1 || activity.Failures["org/a"] != 1 {
+		t.Errorf("expected the failed run of org/a to be recorded, got %+v, %v", activity, err)
+	}
+}
@@ -3,6 +3,8 @@ package crdmanager
 import (
 	"testing"
 	"time"
+
+	"renovate-operator/internal/utils"
 )
 
 // canonicalSigningSecret is the public Standard Webhooks / svix example signing secret (published in
@@ -21,12 +23,12 @@ func TestStandardWebhookSignatureKnownAnswer(t *testing.T) {
 	)
 	body := []byte(`{"test": 2432232314}`)
 
-	key, ok := decodeStandardWebhookSigningKey(secret)
+	key, ok := utils.DecodeStandardWebhookSigningKey(secret)
 	if !ok {
-		t.Fatal("decodeStandardWebhookSigningKey returned ok=false for canonical secret")
+		t.Fatal("DecodeStandardWebhookSigningKey returned ok=false for canonical secret")
 	}
 
-	got := computeStandardWebhookSignature(key, msgID+"."+timestamp+"."+string(body))
+	got := utils.ComputeStandardWebhookSignature(key, msgID+"."+timestamp+"."+string(body))
 	if got != want {
 		t.Fatalf("signature mismatch:\n got %q\nwant %q", got, want)
 	}
@@ -46,16 +48,16 @@ func TestStandardWebhookSignatureKnownAnswer(t *testing.T) {
 }
 
 func TestDecodeStandardWebhookSigningKey(t *testing.T) {
-	if _, ok := decodeStandardWebhookSigningKey(""); ok {
+	if _, ok := utils.DecodeStandardWebhookSigningKey(""); ok {
 		t.Error("empty secret should return ok=false")
 	}
-	if _, ok := decodeStandardWebhookSigningKey("whsec_not!!base64"); ok {
+	if _, ok := utils.DecodeStandardWebhookSigningKey("whsec_not!!base64"); ok {
 		t.Error("whsec_ secret with invalid base64 should return ok=false")
 	}
-	if key, ok := decodeStandardWebhookSigningKey(canonicalSigningSecret); !ok || len(key) == 0 {
+	if key, ok := utils.DecodeStandardWebhookSigningKey(canonicalSigningSecret); !ok || len(key) == 0 {
 		t.Errorf("canonical whsec_ secret should decode to a non-empty key, got len=%d ok=%v", len(key), ok)
 	}
-	if raw, ok := decodeStandardWebhookSigningKey("plain-secret-!!"); !ok || string(raw) != "plain-secret-!!" {
+	if raw, ok := utils.DecodeStandardWebhookSigningKey("plain-secret-!!"); !ok || string(raw) != "plain-secret-!!" {
 		t.Errorf("bare non-base64 secret should be used verbatim, got %q ok=%v", raw, ok)
 	}
 }
@@ -0,0 +1,148 @@
+package crdmanager
+
+import (
+	"slices"
+	"strings"
+
+	api "renovate-operator/api/v1alpha1"
+	"renovate-operator/internal/webhookSync"
+
+	"github.com/go-logr/logr"
+)
+
+// maxWebhookRemovalAttempts bounds the retries of a hook removal. A project
+// that was deleted together with its hooks fails them all.
+const maxWebhookRemovalAttempts = 5
+
+// webhookSyncPlan is one webhook sync cycle of a job: the projects and
+// organizations to carry the operator's hook and those to remove it from.
+type webhookSyncPlan struct {
+	// previous is the state recorded by the last cycle
+	previous api.WebhookSyncStatus
+	// scope is recorded once the cycle reached it, empty when sync is off
+	scope api.WebhookSyncScope
+
+	desired     []string
+	removed     []string
+	desiredOrgs []string
+	removedOrgs []string
+	// replaced are projects whose hook is removed once the hook of their
+	// organization is in place
+	replaced []string
+}
+
+func newWebhookSyncPlan(previous *api.WebhookSyncStatus) webhookSyncPlan {
+	plan := webhookSyncPlan{}
+	if previous != nil {
+		plan.previous = *previous
+	}
+	return plan
+}
+
+// addPendingRemovals adds the removals that failed in earlier cycles, unless
+// the target is desired again.
+func (p *webhookSyncPlan) addPendingRemovals() {
+	for _, pending := range p.previous.PendingRemovals {
+		if pending.Organization {
+			if !slices.Contains(p.desiredOrgs, pending.Name) && !slices.Contains(p.removedOrgs, pending.Name) {
+				p.removedOrgs = append(p.removedOrgs, pending.Name)
+			}
+			continue
+		}
+		if !slices.Contains(p.desired, pending.Name) && !slices.Contains(p.removed, pending.Name) {
+			p.removed = append(p.removed, pending.Name)
+		}
+	}
+}
+
+func (p *webhookSyncPlan) empty() bool {
+	return len(p.desired) == 0 && len(p.removed) == 0 && len(p.desiredOrgs) == 0 && len(p.removedOrgs) == 0 && len(p.replaced) == 0
+}
+
+// nextState is the state to record after the cycle: the organizations that
+// carry hooks and the removals to retry. The organization scope is only
+// recorded once every organization has its hook, so the per-project hooks are
+// replaced on a later cycle otherwise.
+func (p *webhookSyncPlan) nextState(logger logr.Logger, orgResult, result webhookSync.Result) *api.WebhookSyncStatus {
+	state := &api.WebhookSyncStatus{Scope: p.scope, Organizations: p.desiredOrgs}
+	if p.scope == api.WebhookSyncScopeOrganization && len(orgResult.Ensured) < len(p.desiredOrgs) {
+		state.Scope = p.previous.Scope
+	}
+
+	attempts := func(name string, organization bool) int {
+		for _, pending := range p.previous.PendingRemovals {
+			if pending.Name == name && pending.Organization == organization {
+				return pending.Attempts
+			}
+		}
+		return 0
+	}
+	pend := func(name string, organization bool) {
+		removal := api.PendingWebhookRemoval{Name: name, Organization: organization, Attempts: attempts(name, organization) + 1}
+		if removal.Attempts >= maxWebhookRemovalAttempts {
+			logger.Info("giving up on removing the operator's webhook, it must be removed manually", "target", name, "organization", organization, "attempts", removal.Attempts)
+			return
+		}
+		state.PendingRemovals = append(state.PendingRemovals, removal)
+	}
+	for _, org := range orgResult.FailedRemovals {
+		pend(org, true)
+	}
+	for _, project := range result.FailedRemovals {
+		pend(project, false)
+	}
+
+	if state.Scope == "" && len(state.Organizations) == 0 && len(state.PendingRemovals) == 0 {
+		return nil
+	}
+	return state
+}
+
+// webhookOrganizations returns the organizations that carry the hooks of a
+// job in the organization scope: the configured ones, or the owners of its
+// projects. For GitLab that is the top-level group, whose hooks also cover
+// its subgroups.
+func webhookOrganizations(sync *api.RenovateWebhookSync, projects []string) []string {
+	if len(sync.Organizations) > 0 {
+		return sync.Organizations
+	}
+	var orgs []string
+	for _, project := range projects {
+		if org := projectOrganization(project); org != "" && !slices.Contains(orgs, org) {
+			orgs = append(orgs, org)
+		}
+	}
+	slices.Sort(orgs)
+	return orgs
+}
+
+// projectOrganization returns the first path segment of a project.
+func projectOrganization(project string) string {
+	org, _, found := strings.Cut(project, "/")
+	if !found {
+		return ""
+	}
+	return org
+}
+
+// coveredByOrganization reports whether the project belongs to one of the
+// organizations, which may be GitLab subgroups.
+func coveredByOrganization(project string, orgs []string) bool {
+	for _, org := range orgs {
+		if strings.HasPrefix(project, org+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// withoutAll returns the items of list that are not in remove.
+func withoutAll(list, remove []string) []string {
+	var result []string
+	for _, item := range list {
+		if !slices.Contains(remove, item) {
+			result = append(result, item)
+		}
+	}
+	return result
+}
@@ -0,0 +1,157 @@
+package crdmanager
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+
+	api "renovate-operator/api/v1alpha1"
+	"renovate-operator/gitProviderClients"
+	"renovate-operator/internal/webhookSync"
+
+	"github.com/go-logr/logr"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+const orgHookURL = "https://operator.example.com/webhook/v1/github?job=job1&namespace=default"
+
+// webhookSyncManager serves job1 in default from a fake cluster, with its
+// webhooks on the given provider.
+func webhookSyncManager(t *testing.T, provider gitProviderClients.GitProviderClient, job *api.RenovateJob) *renovateJobManager {
+	t.Helper()
+	setBaseURL(t, "https://operator.example.com")
+	scheme := runtime.NewScheme()
+	if err := api.AddToScheme(scheme); err != nil {
+		t.Fatalf("failed to add scheme: %v", err)
+	}
+	return &renovateJobManager{
+		client:                   fake.NewClientBuilder().WithScheme(scheme).WithObjects(job).WithStatusSubresource(&api.RenovateJob{}).Build(),
+		gitProviderClientFactory: stubFactory{provider: provider},
+		logger:                   logr.Discard(),
+		lock:                     &sync.RWMutex{},
+		policy:                   testPolicy(),
+	}
+}
+
+func webhookSyncStatus(t *testing.T, mgr *renovateJobManager) *api.WebhookSyncStatus {
+	t.Helper()
+	job, err := mgr.GetRenovateJob(context.Background(), "job1", "default")
+	if err != nil {
+		t.Fatalf("unexpected error getting job: %v", err)
+	}
+	return job.Status.WebhookSync
+}
+
+func TestSyncWebhooks_OrganizationScopeReplacesProjectHooks(t *testing.T) {
+	job := makeJob("job1", "default", []api.ProjectStatus{{Name: "org/a"}, {Name: "org/b"}, {Name: "other/c"}})
+	job.Spec.Provider = &api.RenovateProvider{Name: "github"}
+	job.Spec.Webhook = &api.RenovateWebhook{Enabled: true, Sync: &api.RenovateWebhookSync{Enabled: true, Scope: api.WebhookSyncScopeOrganization}}
+	provider := &recordingProvider{hooks: map[string][]gitProviderClients.Webhook{
+		"org/a":   {{ID: "1", URL: orgHookURL, Active: true, EventsUpToDate: true}},
+		"other/c": {{ID: "2", URL: orgHookURL, Active: true, EventsUpToDate: true}},
+	}}
+	mgr := webhookSyncManager(t, provider, job)
+	id := RenovateJobIdentifier{Name: "job1", Namespace: "default"}
+
+	if err := mgr.SyncWebhooks(context.Background(), id, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	slices.Sort(provider.created)
+	if want := []string{"org:org", "org:other"}; !slices.Equal(provider.created, want) {
+		t.Errorf("expected organization hooks %v, got %v", want, provider.created)
+	}
+	slices.Sort(provider.deleted)
+	if want := []string{"org/a", "other/c"}; !slices.Equal(provider.deleted, want) {
+		t.Errorf("expected the project hooks to be replaced, deleted %v", provider.deleted)
+	}
+	state := webhookSyncStatus(t, mgr)
+	if state == nil || state.Scope != api.WebhookSyncScopeOrganization || !slices.Equal(state.Organizations, []string{"org", "other"}) {
+		t.Fatalf("unexpected webhook sync status %+v", state)
+	}
+
+	// the project hooks are only replaced once
+	provider.listed, provider.deleted = nil, nil
+	if err := mgr.SyncWebhooks(context.Background(), id, []string{"org/b"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, target := range provider.listed {
+		if target == "org/a" || target == "org/b" {
+			t.Errorf("expected no project hooks to be looked at, listed %v", provider.listed)
+		}
+	}
+}
+
+func TestSyncWebhooks_RetriesFailedRemovals(t *testing.T) {
+	job := makeJob("job1", "default", []api.ProjectStatus{{Name: "org/a"}})
+	job.Spec.Provider = &api.RenovateProvider{Name: "github"}
+	job.Spec.Webhook = &api.RenovateWebhook{Enabled: true, Sync: &api.RenovateWebhookSync{Enabled: true}}
+	provider := &recordingProvider{
+		hooks: map[string][]gitProviderClients.Webhook{
+			"org/a":   {{ID: "1", URL: orgHookURL, Active: true, EventsUpToDate: true}},
+			"org/old": {{ID: "2", URL: orgHookURL, Active: true, EventsUpToDate: true}},
+		},
+		deleteErr: map[string]error{"org/old": errors.New("boom")},
+	}
+	mgr := webhookSyncManager(t, provider, job)
+	id := RenovateJobIdentifier{Name: "job1", Namespace: "default"}
+
+	if err := mgr.SyncWebhooks(context.Background(), id, []string{"org/old"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	state := webhookSyncStatus(t, mgr)
+	if state == nil || len(state.PendingRemovals) != 1 || state.PendingRemovals[0] != (api.PendingWebhookRemoval{Name: "org/old", Attempts: 1}) {
+		t.Fatalf("expected the failed removal to be pending, got %+v", state)
+	}
+
+	// retried without being reported as removed again
+	if err := mgr.SyncWebhooks(context.Background(), id, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if state := webhookSyncStatus(t, mgr); len(state.PendingRemovals) != 1 || state.PendingRemovals[0].Attempts != 2 {
+		t.Fatalf("expected a second attempt, got %+v", state)
+	}
+
+	provider.deleteErr = nil
+	if err := mgr.SyncWebhooks(context.Background(), id, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !slices.Contains(provider.deleted, "org/old") {
+		t.Errorf("expected the hook to be removed by the retry, deleted %v", provider.deleted)
+	}
+	if state := webhookSyncStatus(t, mgr); len(state.PendingRemovals) != 0 {
+		t.Errorf("expected no pending removals, got %+v", state.PendingRemovals)
+	}
+}
+
+func TestWebhookSyncPlan_GivesUpAfterMaxAttempts(t *testing.T) {
+	plan := newWebhookSyncPlan(&api.WebhookSyncStatus{PendingRemovals: []api.PendingWebhookRemoval{
+		{Name: "org/gone", Attempts: maxWebhookRemovalAttempts - 1},
+		{Name: "org", Organization: true, Attempts: 1},
+	}})
+	plan.addPendingRemovals()
+	if !slices.Equal(plan.removed, []string{"org/gone"}) || !slices.Equal(plan.removedOrgs, []string{"org"}) {
+		t.Fatalf("expected the pending removals to be planned, got %v and %v", plan.removed, plan.removedOrgs)
+	}
+
+	state := plan.nextState(logr.Discard(), webhookSync.Result{FailedRemovals: []string{"org"}}, webhookSync.Result{FailedRemovals: []string{"org/gone"}})
+	if state == nil || len(state.PendingRemovals) != 1 || state.PendingRemovals[0] != (api.PendingWebhookRemoval{Name: "org", Organization: true, Attempts: 2}) {
+		t.Errorf("expected only the organization removal to remain, got %+v", state)
+	}
+}
+
+func TestWebhookOrganizations(t *testing.T) {
+	projects := []string{"group/sub/a", "group/b", "other/c", "no-owner"}
+	if got := webhookOrganizations(&api.RenovateWebhookSync{}, projects); !slices.Equal(got, []string{"group", "other"}) {
+		t.Errorf("expected the owners of the projects, got %v", got)
+	}
+	configured := &api.RenovateWebhookSync{Organizations: []string{"group/sub"}}
+	if got := webhookOrganizations(configured, projects); !slices.Equal(got, []string{"group/sub"}) {
+		t.Errorf("expected the configured organizations, got %v", got)
+	}
+	if !coveredByOrganization("group/sub/a", []string{"group/sub"}) || coveredByOrganization("group/b", []string{"group/sub"}) {
+		t.Error("expected a subgroup to cover only its own projects")
+	}
+}
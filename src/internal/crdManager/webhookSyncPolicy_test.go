@@ -26,10 +26,23 @@ type recordingProvider struct {
 	created []string
 	deleted []string
 	hooks   map[string][]gitProviderClients.Webhook
+	repos   map[string][]gitProviderClients.RepositoryListing
+	info    map[string]gitProviderClients.RepositoryInfo
+	changed map[string][]string
+	// deleteErr fails the removal of the hook of a project
+	deleteErr map[string]error
 }
 
-func (p *recordingProvider) GetRepositoryInfo(context.Context, string) (gitProviderClients.RepositoryInfo, error) {
-	return gitProviderClients.RepositoryInfo{}, nil
+func (p *recordingProvider) GetRepositoryInfo(_ context.Context, project string) (gitProviderClients.RepositoryInfo, error) {
+	return p.info[project], nil
+}
+
+func (p *recordingProvider) ListRepositories(_ context.Context, namespace string) ([]gitProviderClients.RepositoryListing, error) {
+	return p.repos[namespace], nil
+}
+
+func (p *recordingProvider) ListChangedFiles(_ context.Context, project, base, head string) ([]string, error) {
+	return p.changed[project+"@"+base+".."+head], nil
 }
 
 func (p *recordingProvider) ListRepoWebhooks(_ context.Context, project string) ([]gitProviderClients.Webhook, error) {
@@ -53,10 +66,31 @@ func (p *recordingProvider) UpdateRepoWebhook(_ context.Context, _ string, hookI
 func (p *recordingProvider) DeleteRepoWebhook(_ context.Context, project string, _ string) error {
 	p.mu.Lock()
 	defer p.mu.Unlock()
+	if err := p.deleteErr[project]; err != nil {
+		return err
+	}
 	p.deleted = append(p.deleted, project)
 	return nil
 }
 
+// Organization hooks are recorded under "org:" and the organization name.
+
+func (p *recordingProvider) ListOrgWebhooks(ctx context.Context, org string) ([]gitProviderClients.Webhook, error) {
+	return p.ListRepoWebhooks(ctx, "org:"+org)
+}
+
+func (p *recordingProvider) CreateOrgWebhook(ctx context.Context, org string, opts gitProviderClients.CreateWebhookOptions) (*gitProviderClients.Webhook, error) {
+	return p.CreateRepoWebhook(ctx, "org:"+org, opts)
+}
+
+func (p *recordingProvider) UpdateOrgWebhook(ctx context.Context, org string, hookID string, opts gitProviderClients.CreateWebhookOptions) (*gitProviderClients.Webhook, error) {
+	return p.UpdateRepoWebhook(ctx, "org:"+org, hookID, opts)
+}
+
+func (p *recordingProvider) DeleteOrgWebhook(ctx context.Context, org string, hookID string) error {
+	return p.DeleteRepoWebhook(ctx, "org:"+org, hookID)
+}
+
 type stubFactory struct {
 	provider gitProviderClients.GitProviderClient
 }
@@ -87,7 +121,7 @@ func TestRunWebhookSyncRefusesWritesToForeignBaseURL(t *testing.T) {
 	job := syncJob("forgejo")
 	job.Spec.Webhook.BaseURL = "https://attacker.example.net"
 
-	err := mgr.runWebhookSync(context.Background(), job, RenovateJobIdentifier{Name: "j", Namespace: "n"}, []string{"org/a"}, nil)
+	_, err := mgr.runWebhookSync(context.Background(), job, RenovateJobIdentifier{Name: "j", Namespace: "n"}, webhookSyncPlan{desired: []string{"org/a"}})
 	if err == nil {
 		t.Fatal("expected the sync to be refused for a non-allowlisted delivery host")
 	}
@@ -107,7 +141,7 @@ func TestRunWebhookSyncAllowsWritesToAllowlistedBaseURL(t *testing.T) {
 	job := syncJob("forgejo")
 	job.Spec.Webhook.BaseURL = "https://renovate.example.com"
 
-	if err := mgr.runWebhookSync(context.Background(), job, RenovateJobIdentifier{Name: "j", Namespace: "n"}, []string{"org/a"}, nil); err != nil {
+	if _, err := mgr.runWebhookSync(context.Background(), job, RenovateJobIdentifier{Name: "j", Namespace: "n"}, webhookSyncPlan{desired: []string{"org/a"}}); err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
 	if len(provider.created) != 1 {
@@ -129,7 +163,7 @@ func TestRunWebhookSyncStillRemovesHooksUnderForeignBaseURL(t *testing.T) {
 	job := syncJob("forgejo")
 	job.Spec.Webhook.BaseURL = "https://attacker.example.net"
 
-	if err := mgr.runWebhookSync(context.Background(), job, RenovateJobIdentifier{Name: "j", Namespace: "n"}, nil, []string{"org/a"}); err != nil {
+	if _, err := mgr.runWebhookSync(context.Background(), job, RenovateJobIdentifier{Name: "j", Namespace: "n"}, webhookSyncPlan{removed: []string{"org/a"}}); err != nil {
 		t.Fatalf("removal must not be blocked by the destination policy, got %v", err)
 	}
 	if len(provider.deleted) != 1 {
@@ -213,7 +247,7 @@ func TestRunWebhookSyncRefusesWritesToForeignEnvBaseURL(t *testing.T) {
 	job := syncJob("forgejo")
 	job.Spec.Webhook.BaseURL = ""
 
-	if err := mgr.runWebhookSync(context.Background(), job, RenovateJobIdentifier{Name: "j", Namespace: "n"}, []string{"org/a"}, nil); err == nil {
+	if _, err := mgr.runWebhookSync(context.Background(), job, RenovateJobIdentifier{Name: "j", Namespace: "n"}, webhookSyncPlan{desired: []string{"org/a"}}); err == nil {
 		t.Fatal("expected a misconfigured WEBHOOK_BASE_URL to be refused too")
 	}
 	if len(provider.created) != 0 {
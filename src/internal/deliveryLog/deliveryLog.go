@@ -0,0 +1,118 @@
+package deliveryLog
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"renovate-operator/internal/kvstore"
+
+	"github.com/go-logr/logr"
+)
+
+// ErrDeliveryNotFound is returned when a delivery is not (or no longer) in the
+// log.
+var ErrDeliveryNotFound = errors.New("delivery not found")
+
+// ErrDeliveryNotReplayable is returned when replaying a delivery that was not
+// authenticated or whose body was not kept.
+var ErrDeliveryNotReplayable = errors.New("delivery was not authenticated or its body was not kept, it cannot be replayed")
+
+// MaxBodySize is the largest request body kept for replay. Larger deliveries
+// are logged without their body and cannot be replayed.
+const MaxBodySize = 256 * 1024
+
+// Outcomes of a delivery, matching the result label of
+// renovate_operator_webhook_requests_total.
+const (
+	OutcomeAccepted = "accepted"
+	OutcomeIgnored  = "ignored"
+	OutcomeRejected = "rejected"
+)
+
+// Delivery is one webhook request as received by the webhook server.
+type Delivery struct {
+	ID   string    `json:"id"`
+	Time time.Time `json:"time"`
+	// Provider is the webhook endpoint (github, gitlab, ..., schedule).
+	Provider string `json:"provider"`
+	// Event is the platform's event type, when it sends one in a header.
+	Event string `json:"event,omitempty"`
+	// Query is the raw query string of the delivery URL.
+	Query string `json:"query,omitempty"`
+	// Headers are the request headers, with credentials redacted.
+	Headers map[string]string `json:"headers,omitempty"`
+	// Namespace and RenovateJob identify the job the delivery was resolved to,
+	// or the job it was addressed to when it was refused before resolution.
+	Namespace   string `json:"namespace"`
+	RenovateJob string `json:"renovateJob"`
+	// Authenticated reports whether the delivery passed the job's webhook
+	// authentication.
+	Authenticated bool   `json:"authenticated"`
+	Project       string `json:"project,omitempty"`
+	Outcome       string `json:"outcome"`
+	// Reason explains an ignored or rejected delivery.
+	Reason     string `json:"reason,omitempty"`
+	StatusCode int    `json:"statusCode"`
+	BodySize   int    `json:"bodySize"`
+	// Replayable is false when the delivery was not authenticated or its
+	// body was too large to keep.
+	Replayable bool `json:"replayable"`
+	// ReplayOf is the ID of the delivery this one replayed.
+	ReplayOf string `json:"replayOf,omitempty"`
+}
+
+// DeliveryLog keeps the most recent webhook deliveries per RenovateJob.
+type DeliveryLog interface {
+	// Record adds a delivery to its job's log, dropping the oldest entry
+	// once the log is full. body is kept for replay when the delivery was
+	// authenticated and the body is not larger than MaxBodySize.
+	Record(ctx context.Context, delivery Delivery, body []byte)
+	// List returns the job's deliveries, newest first.
+	List(ctx context.Context, namespace, renovateJob string) ([]Delivery, error)
+	// Get returns a delivery of the job together with its body, or
+	// ErrDeliveryNotFound.
+	Get(ctx context.Context, namespace, renovateJob, id string) (Delivery, []byte, error)
+}
+
+// NewDeliveryLog creates a DeliveryLog based on the provided mode.
+// Supported modes: "disabled" (returns nil), "memory" (in-memory store, per
+// replica) and "valkey" (Valkey-backed, shared by all replicas). size is the
+// number of deliveries kept per RenovateJob.
+func NewDeliveryLog(logger logr.Logger, mode string, valkeyCfg kvstore.ValkeyConfig, size int) (DeliveryLog, error) {
+	switch mode {
+	case "memory":
+		return newMemoryDeliveryLog(size), nil
+	case "valkey":
+		kv, err := kvstore.NewKVStore(valkeyCfg, kvstore.UsageWebhookDeliveries)
+		if err != nil {
+			return nil, err
+		}
+		return newKVDeliveryLog(kv, size, logger), nil
+	case "disabled", "":
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("unknown webhook delivery log mode %q", mode)
+	}
+}
+
+// prepare fills in the fields Record derives from the body and returns the
+// body to keep, or nil. A replay skips webhook authentication, so only the
+// body of an authenticated delivery is kept.
+func prepare(delivery *Delivery, body []byte) []byte {
+	delivery.BodySize = len(body)
+	delivery.Replayable = delivery.Authenticated && len(body) <= MaxBodySize
+	if !delivery.Replayable {
+		return nil
+	}
+	return body
+}
+
+func indexKey(namespace, renovateJob string) string {
+	return kvstore.JoinKey("WEBHOOK_DELIVERIES", namespace, renovateJob)
+}
+
+func bodyKey(namespace, renovateJob, id string) string {
+	return kvstore.JoinKey("WEBHOOK_DELIVERY_BODY", namespace, renovateJob, id)
+}
@@ -0,0 +1,88 @@
+package deliveryLog
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"renovate-operator/internal/kvstore"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-logr/logr"
+)
+
+func TestDeliveryLog(t *testing.T) {
+	mr := miniredis.RunT(t)
+	kv, err := kvstore.NewValkeyKVStore("redis://" + mr.Addr() + "/0")
+	if err != nil {
+		t.Fatalf("NewValkeyKVStore failed: %v", err)
+	}
+	logs := map[string]DeliveryLog{
+		"memory": newMemoryDeliveryLog(3),
+		"valkey": newKVDeliveryLog(kv, 3, logr.Discard()),
+	}
+
+	for name, log := range logs {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			for i := range 5 {
+				log.Record(ctx, Delivery{ID: fmt.Sprint(i), Namespace: "renovate", RenovateJob: "job1", Authenticated: true, Outcome: OutcomeAccepted}, []byte(fmt.Sprintf(`{"n":%d}`, i)))
+			}
+			log.Record(ctx, Delivery{ID: "other", Namespace: "renovate", RenovateJob: "job2"}, nil)
+			log.Record(ctx, Delivery{ID: "large", Namespace: "renovate", RenovateJob: "job2", Authenticated: true}, []byte(strings.Repeat("x", MaxBodySize+1)))
+			log.Record(ctx, Delivery{ID: "rejected", Namespace: "renovate", RenovateJob: "job2", Outcome: OutcomeRejected}, []byte(`{"n":0}`))
+
+			deliveries, err := log.List(ctx, "renovate", "job1")
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(deliveries) != 3 || deliveries[0].ID != "4" || deliveries[2].ID != "2" {
+				t.Fatalf("expected the three newest deliveries, newest first, got %+v", deliveries)
+			}
+
+			delivery, body, err := log.Get(ctx, "renovate", "job1", "3")
+			if err != nil || string(body) != `{"n":3}` || !delivery.Replayable || delivery.BodySize != 7 {
+				t.Errorf("unexpected delivery %+v with body %q: %v", delivery, body, err)
+			}
+			if _, _, err := log.Get(ctx, "renovate", "job1", "0"); !errors.Is(err, ErrDeliveryNotFound) {
+				t.Errorf("expected a dropped delivery to be gone, got %v", err)
+			}
+			if _, _, err := log.Get(ctx, "renovate", "job2", "3"); !errors.Is(err, ErrDeliveryNotFound) {
+				t.Errorf("expected deliveries to be kept per job, got %v", err)
+			}
+
+			delivery, body, err = log.Get(ctx, "renovate", "job2", "large")
+			if err != nil || body != nil || delivery.Replayable || delivery.BodySize != MaxBodySize+1 {
+				t.Errorf("expected an oversized body not to be kept, got %+v: %v", delivery, err)
+			}
+			// a replay skips authentication, so a rejected payload must not
+			// be kept for one
+			delivery, body, err = log.Get(ctx, "renovate", "job2", "rejected")
+			if err != nil || body != nil || delivery.Replayable || delivery.BodySize != 7 {
+				t.Errorf("expected the body of an unauthenticated delivery not to be kept, got %+v: %v", delivery, err)
+			}
+		})
+	}
+
+	// bodies of dropped deliveries are removed along with their index entry
+	if _, err := kv.Get(context.Background(), bodyKey("renovate", "job1", "1")); !errors.Is(err, kvstore.ErrKeyNotFound) {
+		t.Errorf("expected the body of a dropped delivery to be deleted, got %v", err)
+	}
+	if ttl := mr.TTL(indexKey("renovate", "job1")); ttl != deliveryTTL {
+		t.Errorf("expected the log to expire after %v, got %v", deliveryTTL, ttl)
+	}
+}
+
+func TestNewDeliveryLog(t *testing.T) {
+	if log, err := NewDeliveryLog(logr.Discard(), "disabled", kvstore.ValkeyConfig{}, 10); log != nil || err != nil {
+		t.Errorf("expected no log when disabled, got %v, %v", log, err)
+	}
+	if _, err := NewDeliveryLog(logr.Discard(), "valkey", kvstore.ValkeyConfig{}, 10); !errors.Is(err, kvstore.ErrValkeyNotConfigured) {
+		t.Errorf("expected an error without Valkey, got %v", err)
+	}
+	if _, err := NewDeliveryLog(logr.Discard(), "bogus", kvstore.ValkeyConfig{}, 10); err == nil {
+		t.Error("expected an error for an unknown mode")
+	}
+}
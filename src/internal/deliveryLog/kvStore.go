@@ -0,0 +1,128 @@
+package deliveryLog
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"renovate-operator/internal/kvstore"
+
+	"github.com/go-logr/logr"
+)
+
+// deliveryTTL is the retention period of a job's log in Valkey. Every delivery
+// refreshes it, so only the logs of jobs that stopped receiving webhooks expire.
+const deliveryTTL = 7 * 24 * time.Hour
+
+// kvDeliveryLog is the Valkey-backed implementation for
+// WEBHOOK_DELIVERY_LOG_MODE=valkey. A job's log is an index entry listing the
+// deliveries (oldest first) plus one entry per kept body.
+//
+// The index is updated read-modify-write. Two replicas recording a delivery
+// for the same job at the same moment can lose one of the two entries, which
+// is acceptable for a debugging aid.
+type kvDeliveryLog struct {
+	kv     kvstore.KVStore
+	size   int
+	logger logr.Logger
+
+	// mu serializes the index updates of this replica
+	mu sync.Mutex
+}
+
+func newKVDeliveryLog(kv kvstore.KVStore, size int, logger logr.Logger) *kvDeliveryLog {
+	return &kvDeliveryLog{kv: kv, size: size, logger: logger}
+}
+
+func (l *kvDeliveryLog) Record(ctx context.Context, delivery Delivery, body []byte) {
+	body = prepare(&delivery, body)
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 10*time.Second)
+	defer cancel()
+
+	if body != nil {
+		if err := l.kv.Put(ctx, bodyKey(delivery.Namespace, delivery.RenovateJob, delivery.ID), body, deliveryTTL); err != nil {
+			l.logger.Error(err, "failed to save webhook delivery body to valkey")
+			delivery.Replayable = false
+		}
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	index, err := l.loadIndex(ctx, delivery.Namespace, delivery.RenovateJob)
+	if err != nil {
+		l.logger.Error(err, "failed to load webhook delivery log from valkey")
+		return
+	}
+	index = append(index, delivery)
+	if len(index) > l.size {
+		for _, dropped := range index[:len(index)-l.size] {
+			if err := l.kv.Del(ctx, bodyKey(dropped.Namespace, dropped.RenovateJob, dropped.ID)); err != nil {
+				l.logger.Error(err, "failed to delete webhook delivery body from valkey")
+			}
+		}
+		index = index[len(index)-l.size:]
+	}
+	data, err := json.Marshal(index)
+	if err != nil {
+		l.logger.Error(err, "failed to encode webhook delivery log")
+		return
+	}
+	if err := l.kv.Put(ctx, indexKey(delivery.Namespace, delivery.RenovateJob), data, deliveryTTL); err != nil {
+		l.logger.Error(err, "failed to save webhook delivery log to valkey")
+	}
+}
+
+func (l *kvDeliveryLog) List(ctx context.Context, namespace, renovateJob string) ([]Delivery, error) {
+	index, err := l.loadIndex(ctx, namespace, renovateJob)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]Delivery, 0, len(index))
+	for i := len(index) - 1; i >= 0; i-- {
+		result = append(result, index[i])
+	}
+	return result, nil
+}
+
+func (l *kvDeliveryLog) Get(ctx context.Context, namespace, renovateJob, id string) (Delivery, []byte, error) {
+	index, err := l.loadIndex(ctx, namespace, renovateJob)
+	if err != nil {
+		return Delivery{}, nil, err
+	}
+	for _, delivery := range index {
+		if delivery.ID != id {
+			continue
+		}
+		if !delivery.Replayable {
+			return delivery, nil, nil
+		}
+		body, err := l.kv.Get(ctx, bodyKey(namespace, renovateJob, id))
+		if errors.Is(err, kvstore.ErrKeyNotFound) {
+			delivery.Replayable = false
+			return delivery, nil, nil
+		}
+		if err != nil {
+			return Delivery{}, nil, err
+		}
+		return delivery, body, nil
+	}
+	return Delivery{}, nil, ErrDeliveryNotFound
+}
+
+func (l *kvDeliveryLog) loadIndex(ctx context.Context, namespace, renovateJob string) ([]Delivery, error) {
+	data, err := l.kv.Get(ctx, indexKey(namespace, renovateJob))
+	if errors.Is(err, kvstore.ErrKeyNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var index []Delivery
+	if err := json.Unmarshal(data, &index); err != nil {
+		return nil, fmt.Errorf("failed to decode webhook delivery log: %w", err)
+	}
+	return index, nil
+}
@@ -0,0 +1,62 @@
+package deliveryLog
+
+import (
+	"context"
+	"slices"
+	"sync"
+)
+
+// memoryDeliveryLog is the in-memory implementation for
+// WEBHOOK_DELIVERY_LOG_MODE=memory. Each replica only sees the deliveries it
+// received itself.
+type memoryDeliveryLog struct {
+	size int
+
+	mu sync.RWMutex
+	// entries per job key, oldest first
+	entries map[string][]memoryEntry
+}
+
+type memoryEntry struct {
+	delivery Delivery
+	body     []byte
+}
+
+func newMemoryDeliveryLog(size int) *memoryDeliveryLog {
+	return &memoryDeliveryLog{size: size, entries: make(map[string][]memoryEntry)}
+}
+
+func (l *memoryDeliveryLog) Record(_ context.Context, delivery Delivery, body []byte) {
+	body = prepare(&delivery, body)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	key := indexKey(delivery.Namespace, delivery.RenovateJob)
+	entries := append(l.entries[key], memoryEntry{delivery: delivery, body: body})
+	if len(entries) > l.size {
+		entries = slices.Delete(entries, 0, len(entries)-l.size)
+	}
+	l.entries[key] = entries
+}
+
+func (l *memoryDeliveryLog) List(_ context.Context, namespace, renovateJob string) ([]Delivery, error) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	entries := l.entries[indexKey(namespace, renovateJob)]
+	result := make([]Delivery, 0, len(entries))
+	for i := len(entries) - 1; i >= 0; i-- {
+		result = append(result, entries[i].delivery)
+	}
+	return result, nil
+}
+
+func (l *memoryDeliveryLog) Get(_ context.Context, namespace, renovateJob, id string) (Delivery, []byte, error) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	for _, entry := range l.entries[indexKey(namespace, renovateJob)] {
+		if entry.delivery.ID == id {
+			return entry.delivery, entry.body, nil
+		}
+	}
+	return Delivery{}, nil, ErrDeliveryNotFound
+}
@@ -0,0 +1,163 @@
+package eventStream
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"renovate-operator/metricStore"
+
+	"github.com/go-logr/logr"
+)
+
+const (
+	// maxDeliveryAttempts bounds the attempts to deliver an event to a sink,
+	// spread over about an hour and a half by the backoff.
+	maxDeliveryAttempts = 10
+	minRetryDelay       = 10 * time.Second
+	maxRetryDelay       = time.Hour
+	// dispatchInterval is how often the outbox is checked for due retries.
+	dispatchInterval = 5 * time.Second
+	deliveryTimeout  = 10 * time.Second
+)
+
+// Dispatcher queues published events in the outbox and delivers them to the
+// sinks. It is a controller-runtime Runnable; with an outbox shared between
+// replicas it only runs on the leader.
+type Dispatcher struct {
+	sinks  []Sink
+	outbox Outbox
+	shared bool
+	client *http.Client
+	logger logr.Logger
+	wake   chan struct{}
+}
+
+// NewDispatcher creates a Dispatcher delivering to sinks. shared tells whether
+// the outbox is shared by all replicas.
+func NewDispatcher(logger logr.Logger, sinks []Sink, outbox Outbox, shared bool) *Dispatcher {
+	return &Dispatcher{
+		sinks:  sinks,
+		outbox: outbox,
+		shared: shared,
+		client: &http.Client{Timeout: deliveryTimeout},
+		logger: logger,
+		wake:   make(chan struct{}, 1),
+	}
+}
+
+// Publish implements Publisher.
+func (d *Dispatcher) Publish(ctx context.Context, event Event) {
+	ctx = context.WithoutCancel(ctx)
+	for i := range d.sinks {
+		sink := &d.sinks[i]
+		if !sink.accepts(event.Type) {
+			continue
+		}
+		entry := Entry{ID: rand.Text(), Sink: sink.Name, Event: event, NextAttempt: event.Time}
+		if err := d.outbox.Add(ctx, entry); err != nil {
+			d.logger.Error(err, "failed to queue event", "sink", sink.Name, "type", event.Type)
+		}
+	}
+	select {
+	case d.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Start delivers the queued events until ctx is done.
+func (d *Dispatcher) Start(ctx context.Context) error {
+	ticker := time.NewTicker(dispatchInterval)
+	defer ticker.Stop()
+	for {
+		d.dispatch(ctx, time.Now())
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		case <-d.wake:
+		}
+	}
+}
+
+// NeedLeaderElection implements manager.LeaderElectionRunnable.
+func (d *Dispatcher) NeedLeaderElection() bool {
+	return d.shared
+}
+
+// dispatch makes the attempts that are due at now.
+func (d *Dispatcher) dispatch(ctx context.Context, now time.Time) {
+	due, err := d.outbox.Due(ctx, now)
+	if err != nil {
+		d.logger.Error(err, "failed to read the event outbox")
+		return
+	}
+
+	for _, entry := range due {
+		sink := d.sink(entry.Sink)
+		if sink == nil {
+			// the sink was removed from the configuration
+			_ = d.outbox.Remove(ctx, entry.ID)
+			continue
+		}
+
+		err := d.deliver(ctx, sink, entry.Event, now)
+		entry.Attempts++
+		switch {
+		case err == nil:
+			metricStore.IncEventDelivery(ctx, sink.Name, "delivered")
+			err = d.outbox.Remove(ctx, entry.ID)
+		case entry.Attempts >= maxDeliveryAttempts:
+			metricStore.IncEventDelivery(ctx, sink.Name, "dropped")
+			d.logger.Error(err, "giving up on delivering event", "sink", sink.Name, "type", entry.Event.Type, "event", entry.Event.ID, "attempts", entry.Attempts)
+			err = d.outbox.Remove(ctx, entry.ID)
+		default:
+			metricStore.IncEventDelivery(ctx, sink.Name, "retried")
+			d.logger.V(1).Info("failed to deliver event, retrying", "sink", sink.Name, "type", entry.Event.Type, "event", entry.Event.ID, "attempts", entry.Attempts, "error", err.Error())
+			entry.NextAttempt = now.Add(retryDelay(entry.Attempts))
+			err = d.outbox.Update(ctx, entry)
+		}
+		if err != nil {
+			d.logger.Error(err, "failed to update the event outbox", "sink", sink.Name, "event", entry.Event.ID)
+		}
+	}
+}
+
+func (d *Dispatcher) sink(name string) *Sink {
+	for i := range d.sinks {
+		if d.sinks[i].Name == name {
+			return &d.sinks[i]
+		}
+	}
+	return nil
+}
+
+// retryDelay doubles the delay with every failed attempt, up to maxRetryDelay.
+func retryDelay(attempts int) time.Duration {
+	delay := minRetryDelay
+	for i := 1; i < attempts && delay < maxRetryDelay; i++ {
+		delay *= 2
+	}
+	return min(delay, maxRetryDelay)
+}
+
+func (d *Dispatcher) deliver(ctx context.Context, sink *Sink, event Event, now time.Time) error {
+	req, err := sink.newRequest(ctx, event, now)
+	if err != nil {
+		return err
+	}
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("sink answered with status %d", resp.StatusCode)
+	}
+	return nil
+}
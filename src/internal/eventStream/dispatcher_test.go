@@ -0,0 +1,109 @@
+package eventStream
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+)
+
+// recordingSink is an HTTP sink answering with status and recording the
+// event IDs it received.
+type recordingSink struct {
+	mu       sync.Mutex
+	status   int
+	received []string
+}
+
+func (s *recordingSink) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.received = append(s.received, r.Header.Get("Webhook-Id"))
+	w.WriteHeader(s.status)
+}
+
+func newTestDispatcher(t *testing.T, handlers map[string]*recordingSink, types map[string][]string) (*Dispatcher, Outbox) {
+	t.Helper()
+	var sinks []Sink
+	for name, handler := range handlers {
+		server := httptest.NewServer(handler)
+		t.Cleanup(server.Close)
+		// a secret, so the requests carry the event ID in Webhook-Id
+		sinks = append(sinks, Sink{Name: name, URL: server.URL, Format: FormatCloudEvents, Types: types[name], secret: "whsec_c2VjcmV0"})
+	}
+	outbox := &memoryOutbox{}
+	return NewDispatcher(logr.Discard(), sinks, outbox, false), outbox
+}
+
+func TestDispatcher_DeliversToSubscribedSinks(t *testing.T) {
+	all := &recordingSink{status: http.StatusNoContent}
+	failures := &recordingSink{status: http.StatusOK}
+	dispatcher, outbox := newTestDispatcher(t,
+		map[string]*recordingSink{"all": all, "failures": failures},
+		map[string][]string{"failures": {TypeRunFailed}},
+	)
+	ctx := context.Background()
+
+	event := testRunEvent(t)
+	dispatcher.Publish(ctx, event)
+	dispatcher.dispatch(ctx, time.Now())
+
+	if len(all.received) != 1 || all.received[0] != event.ID {
+		t.Errorf("expected the event to be delivered to the sink without types, got %v", all.received)
+	}
+	if len(failures.received) != 0 {
+		t.Errorf("expected a completed run not to reach the failures sink, got %v", failures.received)
+	}
+	if due, _ := outbox.Due(ctx, time.Now().Add(24*time.Hour)); len(due) != 0 {
+		t.Errorf("expected the outbox to be empty after delivery, got %+v", due)
+	}
+}
+
+func TestDispatcher_RetriesWithBackoffAndDrops(t *testing.T) {
+	sink := &recordingSink{status: http.StatusServiceUnavailable}
+	dispatcher, outbox := newTestDispatcher(t, map[string]*recordingSink{"ci": sink}, nil)
+	ctx := context.Background()
+
+	event := testRunEvent(t)
+	dispatcher.Publish(ctx, event)
+	now := event.Time
+	dispatcher.dispatch(ctx, now)
+
+	due, _ := outbox.Due(ctx, now.Add(minRetryDelay-time.Second))
+	if len(due) != 0 {
+		t.Fatalf("expected the retry to wait for the backoff, got %+v", due)
+	}
+	due, _ = outbox.Due(ctx, now.Add(minRetryDelay))
+	if len(due) != 1 || due[0].Attempts != 1 {
+		t.Fatalf("expected one entry with one attempt, got %+v", due)
+	}
+
+	for range maxDeliveryAttempts - 1 {
+		now = now.Add(maxRetryDelay)
+		dispatcher.dispatch(ctx, now)
+	}
+	if len(sink.received) != maxDeliveryAttempts {
+		t.Errorf("expected %d attempts, got %d", maxDeliveryAttempts, len(sink.received))
+	}
+	for _, id := range sink.received {
+		if id != event.ID {
+			t.Errorf("expected every attempt to carry the event ID %s, got %s", event.ID, id)
+		}
+	}
+	if due, _ := outbox.Due(ctx, now.Add(24*time.Hour)); len(due) != 0 {
+		t.Errorf("expected the event to be dropped after %d attempts, got %+v", maxDeliveryAttempts, due)
+	}
+}
+
+func TestRetryDelay(t *testing.T) {
+	tests := map[int]time.Duration{1: minRetryDelay, 2: 2 * minRetryDelay, 4: 8 * minRetryDelay, 20: maxRetryDelay}
+	for attempts, want := range tests {
+		if got := retryDelay(attempts); got != want {
+			t.Errorf("retryDelay(%d) = %v, want %v", attempts, got, want)
+		}
+	}
+}
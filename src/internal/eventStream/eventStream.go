@@ -0,0 +1,110 @@
+package eventStream
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	api "renovate-operator/api/v1alpha1"
+)
+
+// Event stream.
+//
+// The operator publishes an event when a project run starts, completes, fails
+// or is cancelled, and when a discovery finishes. Events are queued in an
+// outbox per configured sink and delivered over HTTP by the Dispatcher, which
+// retries failed deliveries with a backoff. Publishing never blocks or fails
+// the status change that caused the event.
+
+// Event types.
+const (
+	TypeRunStarted        = "com.renovate.run.started"
+	TypeRunCompleted      = "com.renovate.run.completed"
+	TypeRunFailed         = "com.renovate.run.failed"
+	TypeRunCancelled      = "com.renovate.run.cancelled"
+	TypeDiscoveryFinished = "com.renovate.discovery.finished"
+)
+
+// Types lists every event type the operator publishes.
+var Types = []string{TypeRunStarted, TypeRunCompleted, TypeRunFailed, TypeRunCancelled, TypeDiscoveryFinished}
+
+// Event is an event in the shape of a CloudEvent: Source identifies the
+// RenovateJob, Subject the project of a run.
+type Event struct {
+	ID      string          `json:"id"`
+	Type    string          `json:"type"`
+	Source  string          `json:"source"`
+	Subject string          `json:"subject,omitempty"`
+	Time    time.Time       `json:"time"`
+	Data    json.RawMessage `json:"data"`
+}
+
+// RunData is the data of the run events. The result fields are set when the
+// run finished and its logs could be parsed.
+type RunData struct {
+	Namespace            string                    `json:"namespace"`
+	RenovateJob          string                    `json:"renovateJob"`
+	Project              string                    `json:"project"`
+	Status               api.RenovateProjectStatus `json:"status"`
+	Duration             string                    `json:"duration,omitempty"`
+	RenovateResultStatus *string                   `json:"renovateResultStatus,omitempty"`
+	PRActivity           *api.PRActivity           `json:"prActivity,omitempty"`
+	LogIssues            *api.LogIssues            `json:"logIssues,omitempty"`
+}
+
+// DiscoveryData is the data of the discovery events. Projects is the number of
+// projects discovered, zero when the discovery failed.
+type DiscoveryData struct {
+	Namespace   string                    `json:"namespace"`
+	RenovateJob string                    `json:"renovateJob"`
+	Status      api.RenovateProjectStatus `json:"status"`
+	Projects    int                       `json:"projects"`
+}
+
+// runEventTypes maps the status a run moved to onto its event type.
+var runEventTypes = map[api.RenovateProjectStatus]string{
+	api.JobStatusRunning:   TypeRunStarted,
+	api.JobStatusCompleted: TypeRunCompleted,
+	api.JobStatusFailed:    TypeRunFailed,
+	api.JobStatusCancelled: TypeRunCancelled,
+}
+
+// NewRunEvent returns the event of a run that moved to data.Status, or false
+// when the status is not one a run event is published for.
+func NewRunEvent(data RunData) (Event, bool) {
+	eventType, ok := runEventTypes[data.Status]
+	if !ok {
+		return Event{}, false
+	}
+	return newEvent(eventType, source(data.Namespace, data.RenovateJob), data.Project, data), true
+}
+
+// NewDiscoveryEvent returns the event of a finished discovery.
+func NewDiscoveryEvent(data DiscoveryData) Event {
+	return newEvent(TypeDiscoveryFinished, source(data.Namespace, data.RenovateJob), "", data)
+}
+
+func newEvent(eventType, source, subject string, data any) Event {
+	// the data types always encode
+	encoded, _ := json.Marshal(data)
+	return Event{
+		ID:      rand.Text(),
+		Type:    eventType,
+		Source:  source,
+		Subject: subject,
+		Time:    time.Now().UTC(),
+		Data:    encoded,
+	}
+}
+
+func source(namespace, renovateJob string) string {
+	return fmt.Sprintf("/namespaces/%s/renovatejobs/%s", namespace, renovateJob)
+}
+
+// Publisher takes events for delivery.
+type Publisher interface {
+	// Publish queues the event for every sink that subscribed to its type.
+	Publish(ctx context.Context, event Event)
+}
@@ -0,0 +1,202 @@
+package eventStream
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"renovate-operator/internal/kvstore"
+)
+
+// maxOutboxSize bounds the outbox. A sink that is down for long enough to
+// fill it loses its oldest events first.
+const maxOutboxSize = 1000
+
+// outboxTTL is the retention of the outbox in Valkey, refreshed by every
+// change. It outlasts the retries of every entry.
+const outboxTTL = 48 * time.Hour
+
+// Entry is the delivery of an event to one sink.
+type Entry struct {
+	ID       string `json:"id"`
+	Sink     string `json:"sink"`
+	Event    Event  `json:"event"`
+	Attempts int    `json:"attempts"`
+	// NextAttempt is the earliest time of the next delivery attempt.
+	NextAttempt time.Time `json:"nextAttempt"`
+}
+
+// Outbox holds the deliveries that have not succeeded yet.
+type Outbox interface {
+	// Add queues an entry, dropping the oldest once the outbox is full.
+	Add(ctx context.Context, entry Entry) error
+	// Due returns the entries whose next attempt is at or before now, oldest
+	// first.
+	Due(ctx context.Context, now time.Time) ([]Entry, error)
+	// Update replaces the stored entry with the same ID, if it still exists.
+	Update(ctx context.Context, entry Entry) error
+	// Remove deletes an entry.
+	Remove(ctx context.Context, id string) error
+}
+
+// NewOutbox creates an Outbox based on the provided mode.
+// Supported modes: "memory" (in-memory, per replica, lost on restart) and
+// "valkey" (Valkey-backed, survives restarts and is shared by all replicas).
+func NewOutbox(mode string, valkeyCfg kvstore.ValkeyConfig) (Outbox, error) {
+	switch mode {
+	case "memory", "":
+		return &memoryOutbox{}, nil
+	case "valkey":
+		kv, err := kvstore.NewKVStore(valkeyCfg, kvstore.UsageEventOutbox)
+		if err != nil {
+			return nil, err
+		}
+		hashes, ok := kv.(kvstore.HashStore)
+		if !ok {
+			return nil, errors.New("the event outbox needs a Valkey store that keeps hashes")
+		}
+		return &kvOutbox{kv: hashes}, nil
+	default:
+		return nil, fmt.Errorf("unknown event outbox mode %q", mode)
+	}
+}
+
+// entries is the content of an outbox, oldest first.
+type entries []Entry
+
+func (e entries) add(entry Entry) entries {
+	e = append(e, entry)
+	if len(e) > maxOutboxSize {
+		e = e[len(e)-maxOutboxSize:]
+	}
+	return e
+}
+
+func (e entries) due(now time.Time) []Entry {
+	var result []Entry
+	for _, entry := range e {
+		if !entry.NextAttempt.After(now) {
+			result = append(result, entry)
+		}
+	}
+	return result
+}
+
+func (e entries) update(entry Entry) {
+	if i := slices.IndexFunc(e, func(stored Entry) bool { return stored.ID == entry.ID }); i >= 0 {
+		e[i] = entry
+	}
+}
+
+func (e entries) remove(id string) entries {
+	return slices.DeleteFunc(e, func(stored Entry) bool { return stored.ID == id })
+}
+
+type memoryOutbox struct {
+	mu      sync.Mutex
+	entries entries
+}
+
+func (o *memoryOutbox) Add(_ context.Context, entry Entry) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.entries = o.entries.add(entry)
+	return nil
+}
+
+func (o *memoryOutbox) Due(_ context.Context, now time.Time) ([]Entry, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.entries.due(now), nil
+}
+
+func (o *memoryOutbox) Update(_ context.Context, entry Entry) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.entries.update(entry)
+	return nil
+}
+
+func (o *memoryOutbox) Remove(_ context.Context, id string) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.entries = o.entries.remove(id)
+	return nil
+}
+
+// kvOutbox is the Valkey-backed implementation for EVENT_OUTBOX_MODE=valkey,
+// with the entries in a hash keyed by their ID. Adding, updating and removing
+// an entry each write a single field, so replicas queueing events while the
+// leader delivers them lose none. The outbox is bounded when the dispatcher
+// reads the due entries rather than on every Add.
+type kvOutbox struct {
+	kv kvstore.HashStore
+}
+
+func outboxKey() string {
+	return kvstore.JoinKey("EVENT_OUTBOX")
+}
+
+func (o *kvOutbox) Add(ctx context.Context, entry Entry) error {
+	return o.put(ctx, entry)
+}
+
+func (o *kvOutbox) Due(ctx context.Context, now time.Time) ([]Entry, error) {
+	e, err := o.load(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if excess := len(e) - maxOutboxSize; excess > 0 {
+		for _, dropped := range e[:excess] {
+			if err := o.Remove(ctx, dropped.ID); err != nil {
+				return nil, err
+			}
+		}
+		e = e[excess:]
+	}
+	return e.due(now), nil
+}
+
+// Update rewrites the entry. Only the dispatcher updates and removes entries,
+// so an entry it read is still there.
+func (o *kvOutbox) Update(ctx context.Context, entry Entry) error {
+	return o.put(ctx, entry)
+}
+
+func (o *kvOutbox) Remove(ctx context.Context, id string) error {
+	_, err := o.kv.DelField(ctx, outboxKey(), id)
+	return err
+}
+
+func (o *kvOutbox) put(ctx context.Context, entry Entry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to encode event outbox entry: %w", err)
+	}
+	return o.kv.PutField(ctx, outboxKey(), entry.ID, data, outboxTTL)
+}
+
+// load returns the entries, oldest event first.
+func (o *kvOutbox) load(ctx context.Context) (entries, error) {
+	fields, err := o.kv.GetFields(ctx, outboxKey())
+	if err != nil {
+		return nil, err
+	}
+	e := make(entries, 0, len(fields))
+	for _, data := range fields {
+		var entry Entry
+		if err := json.Unmarshal(data, &entry); err != nil {
+			return nil, fmt.Errorf("failed to decode event outbox entry: %w", err)
+		}
+		e = append(e, entry)
+	}
+	slices.SortFunc(e, func(a, b Entry) int {
+		return cmp.Or(a.Event.Time.Compare(b.Event.Time), cmp.Compare(a.ID, b.ID))
+	})
+	return e, nil
+}
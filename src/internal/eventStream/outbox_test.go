@@ -0,0 +1,120 @@
+package eventStream
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"renovate-operator/internal/kvstore"
+
+	"github.com/alicebob/miniredis/v2"
+)
+
+// newTestKVOutbox returns a Valkey outbox on miniredis.
+func newTestKVOutbox(t *testing.T) *kvOutbox {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	kv, err := kvstore.NewValkeyKVStore("redis://" + mr.Addr() + "/0")
+	if err != nil {
+		t.Fatalf("NewValkeyKVStore failed: %v", err)
+	}
+	return &kvOutbox{kv: kv.(kvstore.HashStore)}
+}
+
+func TestOutbox(t *testing.T) {
+	outboxes := map[string]Outbox{
+		"memory": &memoryOutbox{},
+		"valkey": newTestKVOutbox(t),
+	}
+
+	for name, outbox := range outboxes {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			now := time.Now()
+			for i := range 3 {
+				entry := Entry{ID: fmt.Sprint(i), Sink: "ci", Event: Event{Time: now.Add(time.Duration(i) * time.Millisecond)}, NextAttempt: now.Add(time.Duration(i-1) * time.Minute)}
+				if err := outbox.Add(ctx, entry); err != nil {
+					t.Fatalf("Add returned error: %v", err)
+				}
+			}
+
+			due, err := outbox.Due(ctx, now)
+			if err != nil {
+				t.Fatalf("Due returned error: %v", err)
+			}
+			if len(due) != 2 || due[0].ID != "0" || due[1].ID != "1" {
+				t.Fatalf("expected entries 0 and 1 to be due, got %+v", due)
+			}
+
+			due[0].Attempts = 1
+			due[0].NextAttempt = now.Add(time.Hour)
+			if err := outbox.Update(ctx, due[0]); err != nil {
+				t.Fatalf("Update returned error: %v", err)
+			}
+			if err := outbox.Remove(ctx, "1"); err != nil {
+				t.Fatalf("Remove returned error: %v", err)
+			}
+
+			due, _ = outbox.Due(ctx, now.Add(time.Minute))
+			if len(due) != 1 || due[0].ID != "2" {
+				t.Fatalf("expected only entry 2 to be due, got %+v", due)
+			}
+			due, _ = outbox.Due(ctx, now.Add(2*time.Hour))
+			if len(due) != 2 || due[0].ID != "0" || due[0].Attempts != 1 {
+				t.Fatalf("expected the updated entry 0 and entry 2 to be due, got %+v", due)
+			}
+		})
+	}
+}
+
+func TestOutbox_DropsOldestWhenFull(t *testing.T) {
+	outboxes := map[string]Outbox{
+		"memory": &memoryOutbox{},
+		"valkey": newTestKVOutbox(t),
+	}
+
+	for name, outbox := range outboxes {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			start := time.Now().Add(-time.Hour)
+			for i := range maxOutboxSize + 5 {
+				_ = outbox.Add(ctx, Entry{ID: fmt.Sprint(i), Event: Event{Time: start.Add(time.Duration(i) * time.Millisecond)}})
+			}
+
+			due, _ := outbox.Due(ctx, time.Now())
+			if len(due) != maxOutboxSize || due[0].ID != "5" {
+				t.Fatalf("expected the %d newest entries starting at 5, got %d starting at %s", maxOutboxSize, len(due), due[0].ID)
+			}
+		})
+	}
+}
+
+func TestKVOutbox_ConcurrentChanges(t *testing.T) {
+	outbox := newTestKVOutbox(t)
+	ctx := context.Background()
+	for i := range 20 {
+		_ = outbox.Add(ctx, Entry{ID: fmt.Sprintf("old%d", i)})
+	}
+
+	// replicas queue events while the leader removes the delivered ones
+	var wg sync.WaitGroup
+	for i := range 20 {
+		wg.Go(func() { _ = outbox.Add(ctx, Entry{ID: fmt.Sprintf("new%d", i)}) })
+		wg.Go(func() { _ = outbox.Remove(ctx, fmt.Sprintf("old%d", i)) })
+	}
+	wg.Wait()
+
+	due, err := outbox.Due(ctx, time.Now())
+	if err != nil || len(due) != 20 || !strings.HasPrefix(due[0].ID, "new") {
+		t.Fatalf("expected exactly the 20 new entries, got %d, %v", len(due), err)
+	}
+}
+
+func TestNewOutbox_UnknownMode(t *testing.T) {
+	if _, err := NewOutbox("disk", kvstore.ValkeyConfig{}); err == nil {
+		t.Error("expected an unknown mode to be rejected")
+	}
+}
@@ -0,0 +1,131 @@
+package eventStream
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"time"
+
+	"renovate-operator/internal/utils"
+)
+
+// Formats of the requests a sink receives.
+const (
+	// FormatCloudEvents sends the event as a structured mode CloudEvent.
+	FormatCloudEvents = "cloudevents"
+	// FormatStandardWebhooks sends the event as a Standard Webhooks payload.
+	FormatStandardWebhooks = "standardwebhooks"
+)
+
+// Sink is an HTTP endpoint receiving events.
+type Sink struct {
+	Name string `json:"name"`
+	URL  string `json:"url"`
+	// Format is FormatCloudEvents (default) or FormatStandardWebhooks.
+	Format string `json:"format,omitempty"`
+	// Types limits the sink to these event types; empty subscribes to all.
+	Types []string `json:"types,omitempty"`
+
+	// secret signs the requests with a Standard Webhooks signature
+	secret string
+}
+
+// SinkSecretEnv returns the environment variable holding the signing secret of
+// the sink at index in EVENT_SINKS.
+func SinkSecretEnv(index int) string {
+	return fmt.Sprintf("EVENT_SINK_%d_SECRET", index)
+}
+
+// ParseSinks reads the JSON list of sinks, with the signing secret of each
+// sink looked up through getenv (typically os.Getenv).
+func ParseSinks(raw string, getenv func(key string) string) ([]Sink, error) {
+	var sinks []Sink
+	if raw == "" {
+		return nil, nil
+	}
+	if err := json.Unmarshal([]byte(raw), &sinks); err != nil {
+		return nil, fmt.Errorf("event sinks must be a JSON list: %w", err)
+	}
+
+	seen := make(map[string]bool, len(sinks))
+	for i := range sinks {
+		sink := &sinks[i]
+		if sink.Name == "" {
+			return nil, fmt.Errorf("event sink %d has no name", i)
+		}
+		if seen[sink.Name] {
+			return nil, fmt.Errorf("event sink name %q is used twice", sink.Name)
+		}
+		seen[sink.Name] = true
+
+		u, err := url.Parse(sink.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return nil, fmt.Errorf("event sink %q needs an http or https url", sink.Name)
+		}
+		switch sink.Format {
+		case "":
+			sink.Format = FormatCloudEvents
+		case FormatCloudEvents, FormatStandardWebhooks:
+		default:
+			return nil, fmt.Errorf("event sink %q has unknown format %q, expected %s or %s", sink.Name, sink.Format, FormatCloudEvents, FormatStandardWebhooks)
+		}
+		for _, eventType := range sink.Types {
+			if !slices.Contains(Types, eventType) {
+				return nil, fmt.Errorf("event sink %q subscribes to unknown event type %q", sink.Name, eventType)
+			}
+		}
+		sink.secret = getenv(SinkSecretEnv(i))
+	}
+	return sinks, nil
+}
+
+// accepts reports whether the sink subscribed to the event type.
+func (s *Sink) accepts(eventType string) bool {
+	return len(s.Types) == 0 || slices.Contains(s.Types, eventType)
+}
+
+// newRequest builds the request delivering event to the sink. The
+// webhook-id of a signed request is the event ID, so receivers can
+// deduplicate retries.
+func (s *Sink) newRequest(ctx context.Context, event Event, now time.Time) (*http.Request, error) {
+	var body []byte
+	var err error
+	contentType := "application/json"
+	switch s.Format {
+	case FormatStandardWebhooks:
+		body, err = json.Marshal(struct {
+			Type      string          `json:"type"`
+			Timestamp time.Time       `json:"timestamp"`
+			Data      json.RawMessage `json:"data"`
+		}{event.Type, event.Time, event.Data})
+	default:
+		contentType = "application/cloudevents+json"
+		body, err = json.Marshal(struct {
+			SpecVersion     string `json:"specversion"`
+			DataContentType string `json:"datacontenttype"`
+			Event
+		}{"1.0", "application/json", event})
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode event: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.URL, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", contentType)
+	if key, ok := utils.DecodeStandardWebhookSigningKey(s.secret); ok {
+		timestamp := strconv.FormatInt(now.Unix(), 10)
+		signature := utils.ComputeStandardWebhookSignature(key, event.ID+"."+timestamp+"."+string(body))
+		req.Header.Set("Webhook-Id", event.ID)
+		req.Header.Set("Webhook-Timestamp", timestamp)
+		req.Header.Set("Webhook-Signature", "v1,"+signature)
+	}
+	return req, nil
+}
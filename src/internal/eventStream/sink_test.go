@@ -0,0 +1,143 @@
+package eventStream
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	api "renovate-operator/api/v1alpha1"
+	"renovate-operator/internal/utils"
+)
+
+func TestParseSinks(t *testing.T) {
+	env := map[string]string{SinkSecretEnv(1): "whsec_c2VjcmV0"}
+	sinks, err := ParseSinks(`[
+		{"name":"ci","url":"https://ci.example.com/hook","types":["com.renovate.run.failed"]},
+		{"name":"chat","url":"http://chat:8080/events","format":"standardwebhooks"}
+	]`, func(key string) string { return env[key] })
+	if err != nil {
+		t.Fatalf("ParseSinks returned error: %v", err)
+	}
+	if len(sinks) != 2 {
+		t.Fatalf("expected 2 sinks, got %d", len(sinks))
+	}
+	if sinks[0].Format != FormatCloudEvents || sinks[0].secret != "" {
+		t.Errorf("expected the first sink to default to cloudevents without a secret, got %+v", sinks[0])
+	}
+	if sinks[1].Format != FormatStandardWebhooks || sinks[1].secret != "whsec_c2VjcmV0" {
+		t.Errorf("expected the second sink to use standardwebhooks with its secret, got %+v", sinks[1])
+	}
+	if !sinks[0].accepts(TypeRunFailed) || sinks[0].accepts(TypeRunStarted) || !sinks[1].accepts(TypeDiscoveryFinished) {
+		t.Error("expected sinks to accept exactly their subscribed types")
+	}
+
+	if sinks, err := ParseSinks("", nil); err != nil || sinks != nil {
+		t.Errorf("expected no sinks for an empty value, got %v, %v", sinks, err)
+	}
+
+	invalid := map[string]string{
+		"not a list":     `{"name":"ci"}`,
+		"missing name":   `[{"url":"https://ci.example.com"}]`,
+		"duplicate name": `[{"name":"ci","url":"https://a.example.com"},{"name":"ci","url":"https://b.example.com"}]`,
+		"no url":         `[{"name":"ci"}]`,
+		"bad scheme":     `[{"name":"ci","url":"ftp://ci.example.com"}]`,
+		"unknown format": `[{"name":"ci","url":"https://ci.example.com","format":"xml"}]`,
+		"unknown type":   `[{"name":"ci","url":"https://ci.example.com","types":["com.renovate.other"]}]`,
+	}
+	for name, raw := range invalid {
+		t.Run(name, func(t *testing.T) {
+			if _, err := ParseSinks(raw, func(string) string { return "" }); err == nil {
+				t.Errorf("expected %s to be rejected", raw)
+			}
+		})
+	}
+}
+
+func testRunEvent(t *testing.T) Event {
+	t.Helper()
+	result := "done"
+	event, ok := NewRunEvent(RunData{
+		Namespace:            "renovate",
+		RenovateJob:          "job1",
+		Project:              "org/repo",
+		Status:               api.JobStatusCompleted,
+		RenovateResultStatus: &result,
+		PRActivity:           &api.PRActivity{Created: 2},
+	})
+	if !ok {
+		t.Fatal("expected a completed run to have an event")
+	}
+	return event
+}
+
+func TestNewRunEvent(t *testing.T) {
+	event := testRunEvent(t)
+	if event.Type != TypeRunCompleted || event.Source != "/namespaces/renovate/renovatejobs/job1" || event.Subject != "org/repo" || event.ID == "" {
+		t.Errorf("unexpected event attributes: %+v", event)
+	}
+	var data RunData
+	if err := json.Unmarshal(event.Data, &data); err != nil {
+		t.Fatalf("failed to decode data: %v", err)
+	}
+	if data.PRActivity == nil || data.PRActivity.Created != 2 || data.RenovateResultStatus == nil || *data.RenovateResultStatus != "done" {
+		t.Errorf("expected the run results in the data, got %+v", data)
+	}
+
+	if _, ok := NewRunEvent(RunData{Status: api.JobStatusScheduled}); ok {
+		t.Error("expected no event for a scheduled project")
+	}
+}
+
+func TestSinkRequest_CloudEvents(t *testing.T) {
+	event := testRunEvent(t)
+	sink := Sink{Name: "ci", URL: "https://ci.example.com/hook", Format: FormatCloudEvents}
+
+	req, err := sink.newRequest(context.Background(), event, time.Now())
+	if err != nil {
+		t.Fatalf("newRequest returned error: %v", err)
+	}
+	if ct := req.Header.Get("Content-Type"); ct != "application/cloudevents+json" {
+		t.Errorf("expected a structured CloudEvent, got content type %q", ct)
+	}
+	if req.Header.Get("Webhook-Signature") != "" {
+		t.Error("expected no signature without a secret")
+	}
+
+	body, _ := io.ReadAll(req.Body)
+	var decoded map[string]any
+	if err := json.Unmarshal(body, &decoded); err != nil {
+		t.Fatalf("failed to decode body: %v", err)
+	}
+	if decoded["specversion"] != "1.0" || decoded["type"] != TypeRunCompleted || decoded["id"] != event.ID || decoded["subject"] != "org/repo" {
+		t.Errorf("unexpected CloudEvent: %s", body)
+	}
+}
+
+func TestSinkRequest_StandardWebhooksSigned(t *testing.T) {
+	event := testRunEvent(t)
+	secret := "whsec_" + base64.StdEncoding.EncodeToString([]byte("signing-key"))
+	sink := Sink{Name: "chat", URL: "https://chat.example.com", Format: FormatStandardWebhooks, secret: secret}
+	now := time.Unix(1700000000, 0)
+
+	req, err := sink.newRequest(context.Background(), event, now)
+	if err != nil {
+		t.Fatalf("newRequest returned error: %v", err)
+	}
+	body, _ := io.ReadAll(req.Body)
+	if !strings.HasPrefix(string(body), `{"type":"com.renovate.run.completed",`) {
+		t.Errorf("unexpected Standard Webhooks payload: %s", body)
+	}
+
+	if req.Header.Get("Webhook-Id") != event.ID || req.Header.Get("Webhook-Timestamp") != "1700000000" {
+		t.Errorf("unexpected signature headers: %v", req.Header)
+	}
+	key, _ := utils.DecodeStandardWebhookSigningKey(secret)
+	want := "v1," + utils.ComputeStandardWebhookSignature(key, event.ID+".1700000000."+string(body))
+	if got := req.Header.Get("Webhook-Signature"); got != want {
+		t.Errorf("expected signature %q, got %q", want, got)
+	}
+}
@@ -20,6 +20,40 @@ type KVStore interface {
 	Close() error
 }
 
+// ListStore is implemented by stores that also keep append-only lists, for
+// data that many replicas add to at the same time.
+type ListStore interface {
+	// Append adds value to the end of the list at key, keeps only the newest
+	// size values and sets the ttl of the list.
+	Append(ctx context.Context, key string, value []byte, size int, ttl time.Duration) error
+	// Range returns the values of the list at key, oldest first, or none if
+	// there is no list.
+	Range(ctx context.Context, key string) ([][]byte, error)
+}
+
+// HashStore is implemented by stores that also keep hashes, whose fields are
+// written one at a time, for data that many replicas change at once.
+type HashStore interface {
+	// PutField sets field of the hash at key to value and keeps the hash for
+	// at least ttl.
+	PutField(ctx context.Context, key, field string, value []byte, ttl time.Duration) error
+	// GetField returns field of the hash at key, or ErrKeyNotFound.
+	GetField(ctx context.Context, key, field string) ([]byte, error)
+	// GetFields returns the fields of the hash at key, or none if there is no
+	// hash.
+	GetFields(ctx context.Context, key string) (map[string][]byte, error)
+	// DelField removes field from the hash at key and reports whether it was
+	// there.
+	DelField(ctx context.Context, key, field string) (bool, error)
+	// IncrFields adds each increment to its counter field of the hash at key
+	// and keeps the hash for at least ttl. Counter fields are read with
+	// GetCounters, not GetFields.
+	IncrFields(ctx context.Context, key string, increments map[string]int64, ttl time.Duration) error
+	// GetCounters returns the counter fields of the hash at key, or none if
+	// there is no hash.
+	GetCounters(ctx context.Context, key string) (map[string]int64, error)
+}
+
 // JoinKey builds a composite key by joining parts with ":".
 func JoinKey(parts ...string) string {
 	return strings.Join(parts, ":")
@@ -43,22 +43,54 @@ func ConfigFromEnv(get func(key string) string) ValkeyConfig {
 type Usage int
 
 const (
-	UsageSessionStore  Usage = 0 // Session encryption store
-	UsageRenovateCache Usage = 1 // Renovate job cache forwarded to executor jobs
-	UsageRenovateLogs  Usage = 2 // Log storage for completed Renovate runs
+	UsageSessionStore      Usage = 0 // Session encryption store
+	UsageRenovateCache     Usage = 1 // Renovate job cache forwarded to executor jobs
+	UsageRenovateLogs      Usage = 2 // Log storage for completed Renovate runs
+	UsageWebhookDeliveries Usage = 3 // Webhook delivery log
+	UsageEventOutbox       Usage = 4 // Outbox of the outbound event stream
+	UsageRunHistory        Usage = 5 // Daily run activity aggregated into digest reports
+	UsageAPITokens         Usage = 6 // Hashed API tokens of the UI API
+	UsageAuditLog          Usage = 7 // Audit log of UI, webhook and annotation actions
+
+	// lastUsage is the highest database offset in use; keep it in sync when
+	// adding a usage.
+	lastUsage = UsageAuditLog
 )
 
+// valkeyDatabases is the number of databases Valkey provides by default
+// (the databases setting).
+const valkeyDatabases = 16
+
+// Validate reports a configuration whose databases do not all exist. With a
+// predefined URL the usages are offsets from its database, so the highest
+// usage must still land below valkeyDatabases.
+func (cfg ValkeyConfig) Validate() error {
+	if cfg.URL == "" {
+		return nil
+	}
+	base := urlDB(cfg.URL)
+	if highest := base + int(lastUsage); highest >= valkeyDatabases {
+		return fmt.Errorf("VALKEY_URL selects database %d, but the operator uses databases %d to %d and Valkey provides %d (0 to %d) by default: select database %d or lower",
+			base, base, highest, valkeyDatabases, valkeyDatabases-1, valkeyDatabases-1-int(lastUsage))
+	}
+	return nil
+}
+
 // URLForUsage returns the Valkey connection URL for the given usage.
 //
 // Database selection depends on how the config is provided:
 //
 //   - URL-based (ValkeyConfig.URL set): the URL's database index is the base, and the
 //     usage value is added as an offset. A predefined URL of redis://host/5 yields:
-//     UsageSessionStore→5, UsageRenovateCache→6, UsageRenovateLogs→7.
+//     UsageSessionStore→5, UsageRenovateCache→6, UsageRenovateLogs→7,
+//     UsageWebhookDeliveries→8, UsageEventOutbox→9, UsageRunHistory→10,
+//     UsageAPITokens→11, UsageAuditLog→12.
 //     If the URL carries no explicit database (e.g. redis://host), base is 0.
 //
 //   - Host-based (ValkeyConfig.Host set): usage value is the absolute database index.
-//     UsageSessionStore→0, UsageRenovateCache→1, UsageRenovateLogs→2.
+//     UsageSessionStore→0, UsageRenovateCache→1, UsageRenovateLogs→2,
+//     UsageWebhookDeliveries→3, UsageEventOutbox→4, UsageRunHistory→5,
+//     UsageAPITokens→6, UsageAuditLog→7.
 //
 // Returns "" if neither URL nor Host is configured.
 func (cfg ValkeyConfig) URLForUsage(usage Usage) string {
@@ -105,12 +137,20 @@ func offsetURLDB(rawURL string, offset int) string {
 	if err != nil {
 		return rawURL
 	}
-	base := 0
+	u.Path = fmt.Sprintf("/%d", urlDB(rawURL)+offset)
+	return u.String()
+}
+
+// urlDB returns the database index in the path of rawURL, 0 when it has none.
+func urlDB(rawURL string) int {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return 0
+	}
 	if p := strings.TrimPrefix(u.Path, "/"); p != "" {
 		if n, parseErr := strconv.Atoi(p); parseErr == nil {
-			base = n
+			return n
 		}
 	}
-	u.Path = fmt.Sprintf("/%d", base+offset)
-	return u.String()
+	return 0
 }
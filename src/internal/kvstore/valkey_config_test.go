@@ -0,0 +1,24 @@
+package kvstore
+
+import "testing"
+
+func TestValkeyConfig_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     ValkeyConfig
+		wantErr bool
+	}{
+		{"not configured", ValkeyConfig{}, false},
+		{"host-based", ValkeyConfig{Host: "valkey"}, false},
+		{"url without database", ValkeyConfig{URL: "redis://valkey:6379"}, false},
+		{"highest usage is the last database", ValkeyConfig{URL: "redis://valkey:6379/8"}, false},
+		{"highest usage past the last database", ValkeyConfig{URL: "redis://valkey:6379/9"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.cfg.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() = %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -70,6 +70,116 @@ func (v *valkeyKVStore) Del(ctx context.Context, key string) error {
 	return v.client.Do(ctx, v.client.B().Del().Key(key).Build()).Error()
 }
 
+func (v *valkeyKVStore) Append(ctx context.Context, key string, value []byte, size int, ttl time.Duration) error {
+	encoded := base64.StdEncoding.EncodeToString(value)
+	for _, resp := range v.client.DoMulti(ctx,
+		v.client.B().Rpush().Key(key).Element(encoded).Build(),
+		v.client.B().Ltrim().Key(key).Start(int64(-size)).Stop(-1).Build(),
+		v.client.B().Expire().Key(key).Seconds(int64(ttl/time.Second)).Build(),
+	) {
+		if err := resp.Error(); err != nil {
+			return fmt.Errorf("failed to append to list in Valkey: %w", err)
+		}
+	}
+	return nil
+}
+
+func (v *valkeyKVStore) Range(ctx context.Context, key string) ([][]byte, error) {
+	vals, err := v.client.Do(ctx, v.client.B().Lrange().Key(key).Start(0).Stop(-1).Build()).AsStrSlice()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read list from Valkey: %w", err)
+	}
+
+	values := make([][]byte, 0, len(vals))
+	for _, val := range vals {
+		decoded, err := base64.StdEncoding.DecodeString(val)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode value: %w", err)
+		}
+		values = append(values, decoded)
+	}
+	return values, nil
+}
+
+func (v *valkeyKVStore) PutField(ctx context.Context, key, field string, value []byte, ttl time.Duration) error {
+	encoded := base64.StdEncoding.EncodeToString(value)
+	return v.doAndKeep(ctx, key, ttl, v.client.B().Hset().Key(key).FieldValue().FieldValue(field, encoded).Build())
+}
+
+func (v *valkeyKVStore) GetField(ctx context.Context, key, field string) ([]byte, error) {
+	val, err := v.client.Do(ctx, v.client.B().Hget().Key(key).Field(field).Build()).ToString()
+	if valkey.IsValkeyNil(err) {
+		return nil, ErrKeyNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get field from Valkey: %w", err)
+	}
+
+	decoded, err := base64.StdEncoding.DecodeString(val)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode value: %w", err)
+	}
+
+	return decoded, nil
+}
+
+func (v *valkeyKVStore) GetFields(ctx context.Context, key string) (map[string][]byte, error) {
+	vals, err := v.client.Do(ctx, v.client.B().Hgetall().Key(key).Build()).AsStrMap()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get fields from Valkey: %w", err)
+	}
+
+	fields := make(map[string][]byte, len(vals))
+	for field, val := range vals {
+		decoded, err := base64.StdEncoding.DecodeString(val)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode value: %w", err)
+		}
+		fields[field] = decoded
+	}
+	return fields, nil
+}
+
+func (v *valkeyKVStore) DelField(ctx context.Context, key, field string) (bool, error) {
+	removed, err := v.client.Do(ctx, v.client.B().Hdel().Key(key).Field(field).Build()).AsInt64()
+	if err != nil {
+		return false, fmt.Errorf("failed to delete field from Valkey: %w", err)
+	}
+	return removed > 0, nil
+}
+
+func (v *valkeyKVStore) IncrFields(ctx context.Context, key string, increments map[string]int64, ttl time.Duration) error {
+	cmds := make(valkey.Commands, 0, len(increments))
+	for field, n := range increments {
+		cmds = append(cmds, v.client.B().Hincrby().Key(key).Field(field).Increment(n).Build())
+	}
+	return v.doAndKeep(ctx, key, ttl, cmds...)
+}
+
+func (v *valkeyKVStore) GetCounters(ctx context.Context, key string) (map[string]int64, error) {
+	counters, err := v.client.Do(ctx, v.client.B().Hgetall().Key(key).Build()).AsIntMap()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get counters from Valkey: %w", err)
+	}
+	return counters, nil
+}
+
+// doAndKeep runs cmds, then keeps key for at least ttl: it sets the ttl of a
+// key without one, and extends a shorter one.
+func (v *valkeyKVStore) doAndKeep(ctx context.Context, key string, ttl time.Duration, cmds ...valkey.Completed) error {
+	seconds := int64(ttl / time.Second)
+	cmds = append(cmds,
+		v.client.B().Expire().Key(key).Seconds(seconds).Nx().Build(),
+		v.client.B().Expire().Key(key).Seconds(seconds).Gt().Build(),
+	)
+	for _, resp := range v.client.DoMulti(ctx, cmds...) {
+		if err := resp.Error(); err != nil {
+			return fmt.Errorf("failed to update hash in Valkey: %w", err)
+		}
+	}
+	return nil
+}
+
 func (v *valkeyKVStore) Close() error {
 	v.client.Close()
 	return nil
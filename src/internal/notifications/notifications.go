@@ -0,0 +1,136 @@
+package notifications
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	api "renovate-operator/api/v1alpha1"
+)
+
+// Notifications.
+//
+// A RenovateJob lists routes in spec.notifications; every route sends the
+// events it subscribed to, for the projects it covers, to a Slack-compatible,
+// Microsoft Teams or generic webhook target. Notifications are sent in the
+// background, so raising one never blocks the status change behind it.
+
+// maxListedPRs bounds the PRs awaiting approval listed in a notification.
+const maxListedPRs = 10
+
+// Notification is something worth telling the people behind a RenovateJob.
+type Notification struct {
+	Event       api.NotificationEvent `json:"event"`
+	Namespace   string                `json:"namespace"`
+	RenovateJob string                `json:"renovateJob"`
+	// Project is empty for events about the whole job.
+	Project string `json:"project,omitempty"`
+	// Title is a one-line summary, Details adds context line by line.
+	Title   string   `json:"title"`
+	Details []string `json:"details,omitempty"`
+	// LogURL links to the logs of the run in the UI, when the UI URL is known.
+	LogURL string    `json:"logUrl,omitempty"`
+	Time   time.Time `json:"time"`
+}
+
+// ForRun returns the notifications for a project whose run just finished.
+// previous is the project before the update, project after it and status the
+// status the run finished with.
+func ForRun(renovateJob *api.RenovateJob, previous, project api.ProjectStatus, status api.RenovateProjectStatus) []Notification {
+	if previous.Status != api.JobStatusRunning {
+		return nil
+	}
+
+	var result []Notification
+	newRun := func(event api.NotificationEvent, title string, details ...string) {
+		result = append(result, Notification{
+			Event:       event,
+			Namespace:   renovateJob.Namespace,
+			RenovateJob: renovateJob.Name,
+			Project:     project.Name,
+			Title:       title,
+			Details:     details,
+			Time:        time.Now().UTC(),
+		})
+	}
+
+	switch {
+	case status == api.JobStatusFailed && previous.LastRunStatus == api.JobStatusCompleted:
+		newRun(api.NotificationEventFailed, fmt.Sprintf("Renovate failed for %s", project.Name), logIssueDetails(project.LogIssues)...)
+	case status == api.JobStatusCompleted && previous.LastRunStatus == api.JobStatusFailed:
+		newRun(api.NotificationEventRecovered, fmt.Sprintf("Renovate recovered for %s", project.Name))
+	}
+
+	if status == api.JobStatusCompleted && project.PRActivity != nil && project.PRActivity.NeedsApproval > 0 &&
+		(previous.PRActivity == nil || project.PRActivity.NeedsApproval > previous.PRActivity.NeedsApproval) {
+		newRun(api.NotificationEventNeedsApproval,
+			fmt.Sprintf("%d PRs await approval for %s", project.PRActivity.NeedsApproval, project.Name),
+			approvalDetails(project.PRActivity)...)
+	}
+	return result
+}
+
+// ForPolicyDenial returns the notification for a RenovateJob the operator's
+// policy refused.
+func ForPolicyDenial(renovateJob *api.RenovateJob, reason, message string) Notification {
+	return Notification{
+		Event:       api.NotificationEventPolicyDenied,
+		Namespace:   renovateJob.Namespace,
+		RenovateJob: renovateJob.Name,
+		Title:       fmt.Sprintf("RenovateJob %s/%s was refused by the operator's policy (%s)", renovateJob.Namespace, renovateJob.Name, reason),
+		Details:     []string{message, "Nothing runs for this job until the policy is satisfied."},
+		Time:        time.Now().UTC(),
+	}
+}
+
+func logIssueDetails(issues *api.LogIssues) []string {
+	if issues == nil {
+		return nil
+	}
+	details := []string{fmt.Sprintf("%d errors, %d warnings", issues.ErrorCount, issues.WarnCount)}
+	for _, issue := range issues.Issues {
+		// Renovate logs errors at level 50; the first is usually the cause
+		if issue.Level >= 50 {
+			details = append(details, "First error: "+issue.Message)
+			break
+		}
+	}
+	return details
+}
+
+func approvalDetails(activity *api.PRActivity) []string {
+	var details []string
+	for _, pr := range activity.PRs {
+		if pr.Action != api.PRActionNeedsApproval {
+			continue
+		}
+		if len(details) == maxListedPRs {
+			details = append(details, "…")
+			break
+		}
+		title := pr.Title
+		if title == "" {
+			title = pr.Branch
+		}
+		details = append(details, "• "+title)
+	}
+	return details
+}
+
+// text renders the notification as plain text lines.
+func (n Notification) text() string {
+	var b strings.Builder
+	b.WriteString(n.Title)
+	for _, line := range n.Details {
+		b.WriteString("\n")
+		b.WriteString(line)
+	}
+	return b.String()
+}
+
+// Notifier takes notifications for delivery.
+type Notifier interface {
+	// Notify sends the notification through the routes of renovateJob.
+	Notify(ctx context.Context, renovateJob *api.RenovateJob, notification Notification)
+}
@@ -0,0 +1,134 @@
+package notifications
+
+import (
+	"strings"
+	"testing"
+
+	api "renovate-operator/api/v1alpha1"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func testJob() *api.RenovateJob {
+	return &api.RenovateJob{ObjectMeta: metav1.ObjectMeta{Name: "job1", Namespace: "renovate"}}
+}
+
+func TestForRun(t *testing.T) {
+	running := func(lastRun api.RenovateProjectStatus) api.ProjectStatus {
+		return api.ProjectStatus{Name: "org/repo", Status: api.JobStatusRunning, LastRunStatus: lastRun}
+	}
+
+	tests := map[string]struct {
+		previous api.ProjectStatus
+		project  api.ProjectStatus
+		status   api.RenovateProjectStatus
+		want     []api.NotificationEvent
+	}{
+		"completed to failed": {
+			previous: running(api.JobStatusCompleted),
+			project:  api.ProjectStatus{Name: "org/repo"},
+			status:   api.JobStatusFailed,
+			want:     []api.NotificationEvent{api.NotificationEventFailed},
+		},
+		"failed again": {
+			previous: running(api.JobStatusFailed),
+			project:  api.ProjectStatus{Name: "org/repo"},
+			status:   api.JobStatusFailed,
+		},
+		"first run failed": {
+			previous: running(""),
+			project:  api.ProjectStatus{Name: "org/repo"},
+			status:   api.JobStatusFailed,
+		},
+		"recovered": {
+			previous: running(api.JobStatusFailed),
+			project:  api.ProjectStatus{Name: "org/repo"},
+			status:   api.JobStatusCompleted,
+			want:     []api.NotificationEvent{api.NotificationEventRecovered},
+		},
+		"recovered with new PRs awaiting approval": {
+			previous: running(api.JobStatusFailed),
+			project:  api.ProjectStatus{Name: "org/repo", PRActivity: &api.PRActivity{NeedsApproval: 1}},
+			status:   api.JobStatusCompleted,
+			want:     []api.NotificationEvent{api.NotificationEventRecovered, api.NotificationEventNeedsApproval},
+		},
+		"same PRs still awaiting approval": {
+			previous: func() api.ProjectStatus {
+				p := running(api.JobStatusCompleted)
+				p.PRActivity = &api.PRActivity{NeedsApproval: 2}
+				return p
+			}(),
+			project: api.ProjectStatus{Name: "org/repo", PRActivity: &api.PRActivity{NeedsApproval: 2}},
+			status:  api.JobStatusCompleted,
+		},
+		"not a finished run": {
+			previous: api.ProjectStatus{Name: "org/repo", Status: api.JobStatusScheduled, LastRunStatus: api.JobStatusCompleted},
+			project:  api.ProjectStatus{Name: "org/repo"},
+			status:   api.JobStatusFailed,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			got := ForRun(testJob(), tt.previous, tt.project, tt.status)
+			if len(got) != len(tt.want) {
+				t.Fatalf("expected %v, got %+v", tt.want, got)
+			}
+			for i, n := range got {
+				if n.Event != tt.want[i] {
+					t.Errorf("expected event %d to be %s, got %s", i, tt.want[i], n.Event)
+				}
+				if n.Namespace != "renovate" || n.RenovateJob != "job1" || n.Project != "org/repo" {
+					t.Errorf("expected the notification to name the project, got %+v", n)
+				}
+			}
+		})
+	}
+}
+
+func TestForRun_Details(t *testing.T) {
+	previous := api.ProjectStatus{Name: "org/repo", Status: api.JobStatusRunning, LastRunStatus: api.JobStatusCompleted}
+	project := api.ProjectStatus{Name: "org/repo", LogIssues: &api.LogIssues{
+		WarnCount:  3,
+		ErrorCount: 1,
+		Issues: []api.LogIssue{
+			{Level: 40, Message: "deprecated option"},
+			{Level: 50, Message: "authentication failed"},
+		},
+	}}
+
+	failed := ForRun(testJob(), previous, project, api.JobStatusFailed)
+	if len(failed) != 1 {
+		t.Fatalf("expected one notification, got %+v", failed)
+	}
+	text := failed[0].text()
+	if !strings.Contains(text, "1 errors, 3 warnings") || !strings.Contains(text, "First error: authentication failed") {
+		t.Errorf("expected the log issues in the notification, got %q", text)
+	}
+
+	var prs []api.PRDetail
+	for range maxListedPRs + 2 {
+		prs = append(prs, api.PRDetail{Action: api.PRActionNeedsApproval, Title: "Update dependency"})
+	}
+	prs = append(prs, api.PRDetail{Action: api.PRActionCreated, Title: "Created"})
+	previous.LastRunStatus = api.JobStatusCompleted
+	project = api.ProjectStatus{Name: "org/repo", PRActivity: &api.PRActivity{NeedsApproval: len(prs) - 1, PRs: prs}}
+
+	approval := ForRun(testJob(), previous, project, api.JobStatusCompleted)
+	if len(approval) != 1 || approval[0].Event != api.NotificationEventNeedsApproval {
+		t.Fatalf("expected a needsApproval notification, got %+v", approval)
+	}
+	if details := approval[0].Details; len(details) != maxListedPRs+1 || details[maxListedPRs] != "…" {
+		t.Errorf("expected %d listed PRs and an ellipsis, got %v", maxListedPRs, details)
+	}
+}
+
+func TestForPolicyDenial(t *testing.T) {
+	n := ForPolicyDenial(testJob(), "ImageNotAllowed", "spec.image is not allowed")
+	if n.Event != api.NotificationEventPolicyDenied || n.Project != "" {
+		t.Errorf("expected a job-wide policyDenied notification, got %+v", n)
+	}
+	if !strings.Contains(n.Title, "ImageNotAllowed") || n.Details[0] != "spec.image is not allowed" {
+		t.Errorf("expected the reason and message in the notification, got %+v", n)
+	}
+}
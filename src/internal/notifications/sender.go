@@ -0,0 +1,290 @@
+package notifications
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	api "renovate-operator/api/v1alpha1"
+	"renovate-operator/internal/policy"
+	"renovate-operator/internal/utils"
+	"renovate-operator/metricStore"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// queueSize bounds the notifications waiting to be sent; more are dropped.
+	queueSize    = 100
+	sendTimeout  = 10 * time.Second
+	rateInterval = time.Hour
+)
+
+type queued struct {
+	renovateJob  *api.RenovateJob
+	notification Notification
+}
+
+// Sender sends notifications through the routes of their RenovateJob. It is a
+// controller-runtime Runnable that runs on every replica.
+type Sender struct {
+	client  client.Reader
+	policy  policy.Policy
+	http    *http.Client
+	logger  logr.Logger
+	uiURL   string
+	limiter *rateLimiter
+	queue   chan queued
+}
+
+// NewSender creates a Sender. uiURL is the external URL of the UI used for
+// log links, empty for none. Every route sends at most ratePerHour
+// notifications an hour; 0 disables the limit.
+func NewSender(logger logr.Logger, c client.Reader, p policy.Policy, uiURL string, ratePerHour int) *Sender {
+	return &Sender{
+		client:  c,
+		policy:  p,
+		http:    &http.Client{Timeout: sendTimeout},
+		logger:  logger,
+		uiURL:   strings.TrimSuffix(uiURL, "/"),
+		limiter: newRateLimiter(ratePerHour, rateInterval),
+		queue:   make(chan queued, queueSize),
+	}
+}
+
+// Notify implements Notifier. It never blocks; when the queue is full the
+// notification is dropped. Jobs without routes are skipped before anything is
+// queued.
+func (s *Sender) Notify(_ context.Context, renovateJob *api.RenovateJob, notification Notification) {
+	if renovateJob.Spec.Notifications == nil || len(renovateJob.Spec.Notifications.Routes) == 0 {
+		return
+	}
+	select {
+	case s.queue <- queued{renovateJob: renovateJob.DeepCopyObject().(*api.RenovateJob), notification: notification}:
+	default:
+		s.logger.Info("notification queue is full, dropping notification", "renovateJob", renovateJob.Name, "namespace", renovateJob.Namespace, "event", notification.Event)
+	}
+}
+
+// Start sends the queued notifications until ctx is done.
+func (s *Sender) Start(ctx context.Context) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case q := <-s.queue:
+			s.send(ctx, q.renovateJob, q.notification, time.Now())
+		}
+	}
+}
+
+// NeedLeaderElection implements manager.LeaderElectionRunnable.
+func (s *Sender) NeedLeaderElection() bool {
+	return false
+}
+
+// send delivers the notification through every matching route of renovateJob.
+func (s *Sender) send(ctx context.Context, renovateJob *api.RenovateJob, notification Notification, now time.Time) {
+	spec := renovateJob.Spec.Notifications
+	if spec == nil {
+		return
+	}
+	if notification.Project != "" && matchesAny(spec.MutedProjects, notification.Project) {
+		s.logger.V(1).Info("project is muted, not notifying", "renovateJob", renovateJob.Name, "namespace", renovateJob.Namespace, "project", notification.Project, "event", notification.Event)
+		return
+	}
+	if notification.Project != "" && s.uiURL != "" {
+		notification.LogURL = s.logURL(notification)
+	}
+
+	for i, route := range spec.Routes {
+		if !routeMatches(route, notification) {
+			continue
+		}
+		target, event := string(route.Type), string(notification.Event)
+		if !s.limiter.allow(fmt.Sprintf("%s/%s/%d", renovateJob.Namespace, renovateJob.Name, i), now) {
+			metricStore.IncNotification(ctx, target, event, "rate_limited")
+			s.logger.V(1).Info("notification route is rate limited", "renovateJob", renovateJob.Name, "namespace", renovateJob.Namespace, "route", i, "event", notification.Event)
+			continue
+		}
+		if err := s.sendToRoute(ctx, renovateJob, i, route, notification); err != nil {
+			metricStore.IncNotification(ctx, target, event, "failed")
+			s.logger.Error(err, "failed to send notification", "renovateJob", renovateJob.Name, "namespace", renovateJob.Namespace, "route", i, "event", notification.Event)
+			continue
+		}
+		metricStore.IncNotification(ctx, target, event, "sent")
+	}
+}
+
+func (s *Sender) logURL(notification Notification) string {
+	q := url.Values{}
+	q.Set("namespace", notification.Namespace)
+	q.Set("renovate", notification.RenovateJob)
+	q.Set("project", notification.Project)
+	return s.uiURL + "/logs?" + q.Encode()
+}
+
+// routeMatches reports whether the route covers the event and project of the
+// notification.
+func routeMatches(route api.NotificationRoute, notification Notification) bool {
+	if len(route.Events) > 0 && !slices.Contains(route.Events, notification.Event) {
+		return false
+	}
+	if notification.Project == "" || len(route.Projects) == 0 {
+		return true
+	}
+	return matchesAny(route.Projects, notification.Project)
+}
+
+func matchesAny(patterns []string, project string) bool {
+	if len(patterns) == 0 {
+		return false
+	}
+	// invalid patterns are reported by the matcher and skipped
+	matcher, _ := utils.NewRepositoryMatcher(patterns)
+	return matcher.Matches(project)
+}
+
+func (s *Sender) sendToRoute(ctx context.Context, renovateJob *api.RenovateJob, index int, route api.NotificationRoute, notification Notification) error {
+	target, err := s.targetURL(ctx, renovateJob.Namespace, route.SecretRef)
+	if err != nil {
+		return err
+	}
+	if err := s.policy.ValidateDestination(target, fmt.Sprintf("spec.notifications.routes[%d].secretRef", index)); err != nil {
+		metricStore.IncPolicyDenial(ctx, "destination")
+		return err
+	}
+
+	body, err := payload(route.Type, notification)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(ctx, sendTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := s.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("target answered with status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// targetURL reads the URL of a route from its secret, which must be opted in
+// like every secret a RenovateJob references.
+func (s *Sender) targetURL(ctx context.Context, namespace string, ref api.RenovateSecretKeyReference) (string, error) {
+	secret := &corev1.Secret{}
+	if err := s.client.Get(ctx, client.ObjectKey{Name: ref.Name, Namespace: namespace}, secret); err != nil {
+		if apierrors.IsNotFound(err) {
+			metricStore.IncSecretResolutionError(ctx, "not_found")
+		} else {
+			metricStore.IncSecretResolutionError(ctx, "api_error")
+		}
+		return "", err
+	}
+	if err := s.policy.ValidateReferencedSecret(secret); err != nil {
+		metricStore.IncPolicyDenial(ctx, "secret_ref")
+		return "", err
+	}
+	value, ok := secret.Data[ref.Key]
+	if !ok {
+		metricStore.IncSecretResolutionError(ctx, "key_missing")
+		return "", fmt.Errorf("secret key %s not found in secret %s", ref.Key, ref.Name)
+	}
+	return strings.TrimSpace(string(value)), nil
+}
+
+// payload renders the notification for the target type.
+func payload(targetType api.NotificationTargetType, notification Notification) ([]byte, error) {
+	switch targetType {
+	case api.NotificationTargetSlack:
+		text := notification.text()
+		if notification.LogURL != "" {
+			text += fmt.Sprintf("\n<%s|View logs>", notification.LogURL)
+		}
+		return json.Marshal(map[string]string{"text": text})
+	case api.NotificationTargetTeams:
+		return json.Marshal(teamsMessage(notification))
+	case api.NotificationTargetWebhook:
+		return json.Marshal(notification)
+	default:
+		return nil, fmt.Errorf("unknown notification target type %q", targetType)
+	}
+}
+
+// teamsMessage wraps the notification in an Adaptive Card, which both Teams
+// incoming webhooks and Workflows accept.
+func teamsMessage(notification Notification) map[string]any {
+	body := []map[string]any{{
+		"type":   "TextBlock",
+		"text":   notification.Title,
+		"weight": "Bolder",
+		"wrap":   true,
+	}}
+	for _, line := range notification.Details {
+		body = append(body, map[string]any{"type": "TextBlock", "text": line, "wrap": true, "spacing": "Small"})
+	}
+	card := map[string]any{
+		"$schema": "http://adaptivecards.io/schemas/adaptive-card.json",
+		"type":    "AdaptiveCard",
+		"version": "1.4",
+		"body":    body,
+	}
+	if notification.LogURL != "" {
+		card["actions"] = []map[string]any{{"type": "Action.OpenUrl", "title": "View logs", "url": notification.LogURL}}
+	}
+	return map[string]any{
+		"type": "message",
+		"attachments": []map[string]any{{
+			"contentType": "application/vnd.microsoft.card.adaptive",
+			"content":     card,
+		}},
+	}
+}
+
+// rateLimiter allows a number of events per key within a sliding interval.
+type rateLimiter struct {
+	mu       sync.Mutex
+	limit    int
+	interval time.Duration
+	sent     map[string][]time.Time
+}
+
+func newRateLimiter(limit int, interval time.Duration) *rateLimiter {
+	return &rateLimiter{limit: limit, interval: interval, sent: map[string][]time.Time{}}
+}
+
+// allow records an event for key at now, unless the key used up its limit.
+func (l *rateLimiter) allow(key string, now time.Time) bool {
+	if l.limit <= 0 {
+		return true
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	recent := slices.DeleteFunc(l.sent[key], func(t time.Time) bool { return !t.After(now.Add(-l.interval)) })
+	if len(recent) >= l.limit {
+		l.sent[key] = recent
+		return false
+	}
+	l.sent[key] = append(recent, now)
+	return true
+}
@@ -0,0 +1,237 @@
+package notifications
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	api "renovate-operator/api/v1alpha1"
+	"renovate-operator/internal/policy"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+// recordingTarget is a notification target recording the bodies it received.
+type recordingTarget struct {
+	mu     sync.Mutex
+	bodies []map[string]any
+}
+
+func (r *recordingTarget) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	body, _ := io.ReadAll(req.Body)
+	decoded := map[string]any{}
+	_ = json.Unmarshal(body, &decoded)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.bodies = append(r.bodies, decoded)
+	w.WriteHeader(http.StatusOK)
+}
+
+// newTestSender returns a Sender whose routes resolve to target through the
+// secret "hooks" in namespace renovate, one key per target type.
+func newTestSender(t *testing.T, p policy.Policy, labelled bool, ratePerHour int) (*Sender, *recordingTarget) {
+	t.Helper()
+	target := &recordingTarget{}
+	server := httptest.NewServer(target)
+	t.Cleanup(server.Close)
+
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "hooks", Namespace: "renovate"},
+		Data: map[string][]byte{
+			"slack":   []byte(server.URL + "/slack\n"),
+			"teams":   []byte(server.URL + "/teams"),
+			"webhook": []byte(server.URL + "/webhook"),
+		},
+	}
+	if labelled {
+		secret.Labels = map[string]string{api.LabelAllowRef: "true"}
+	}
+	scheme := runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(scheme); err != nil {
+		t.Fatalf("failed to add core scheme: %v", err)
+	}
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(secret).Build()
+	return NewSender(logr.Discard(), c, p, "https://renovate.example.com/", ratePerHour), target
+}
+
+func testPolicy() policy.Policy {
+	return policy.Policy{AllowedHosts: []string{"127.0.0.1"}}
+}
+
+func route(targetType api.NotificationTargetType, events ...api.NotificationEvent) api.NotificationRoute {
+	return api.NotificationRoute{
+		Type:      targetType,
+		Events:    events,
+		SecretRef: api.RenovateSecretKeyReference{Name: "hooks", Key: string(targetType)},
+	}
+}
+
+func jobWithRoutes(routes ...api.NotificationRoute) *api.RenovateJob {
+	job := testJob()
+	job.Spec.Notifications = &api.RenovateJobNotifications{Routes: routes}
+	return job
+}
+
+func failedRun() Notification {
+	return Notification{
+		Event:       api.NotificationEventFailed,
+		Namespace:   "renovate",
+		RenovateJob: "job1",
+		Project:     "org/repo",
+		Title:       "Renovate failed for org/repo",
+		Details:     []string{"1 errors, 0 warnings"},
+	}
+}
+
+func TestSender_Payloads(t *testing.T) {
+	sender, target := newTestSender(t, testPolicy(), true, 0)
+	job := jobWithRoutes(
+		route(api.NotificationTargetSlack),
+		route(api.NotificationTargetTeams),
+		route(api.NotificationTargetWebhook),
+	)
+
+	sender.send(context.Background(), job, failedRun(), time.Now())
+	if len(target.bodies) != 3 {
+		t.Fatalf("expected one request per route, got %d", len(target.bodies))
+	}
+
+	logURL := "https://renovate.example.com/logs?" + url.Values{
+		"namespace": {"renovate"}, "renovate": {"job1"}, "project": {"org/repo"},
+	}.Encode()
+
+	slack, _ := target.bodies[0]["text"].(string)
+	if !strings.HasPrefix(slack, "Renovate failed for org/repo\n1 errors, 0 warnings") || !strings.Contains(slack, "<"+logURL+"|View logs>") {
+		t.Errorf("unexpected Slack text: %q", slack)
+	}
+
+	teams, _ := json.Marshal(target.bodies[1])
+	if target.bodies[1]["type"] != "message" || !strings.Contains(string(teams), `"application/vnd.microsoft.card.adaptive"`) ||
+		!strings.Contains(string(teams), `"Action.OpenUrl"`) {
+		t.Errorf("unexpected Teams message: %s", teams)
+	}
+
+	if target.bodies[2]["event"] != "failed" || target.bodies[2]["project"] != "org/repo" || target.bodies[2]["logUrl"] != logURL {
+		t.Errorf("unexpected webhook payload: %v", target.bodies[2])
+	}
+}
+
+func TestSender_Routing(t *testing.T) {
+	sender, target := newTestSender(t, testPolicy(), true, 0)
+	frontend := route(api.NotificationTargetWebhook)
+	frontend.Projects = []string{"org/frontend-*"}
+	job := jobWithRoutes(
+		route(api.NotificationTargetWebhook, api.NotificationEventRecovered),
+		frontend,
+	)
+	ctx := context.Background()
+
+	sender.send(ctx, job, failedRun(), time.Now())
+	if len(target.bodies) != 0 {
+		t.Fatalf("expected no route to cover the failed run, got %v", target.bodies)
+	}
+
+	n := failedRun()
+	n.Project = "org/frontend-app"
+	sender.send(ctx, job, n, time.Now())
+	if len(target.bodies) != 1 {
+		t.Fatalf("expected the project route to cover org/frontend-app, got %v", target.bodies)
+	}
+
+	denial := ForPolicyDenial(job, "ImageNotAllowed", "not allowed")
+	sender.send(ctx, job, denial, time.Now())
+	if len(target.bodies) != 2 {
+		t.Errorf("expected the project filter to let job-wide events through, got %v", target.bodies)
+	}
+}
+
+func TestSender_MutedProject(t *testing.T) {
+	sender, target := newTestSender(t, testPolicy(), true, 0)
+	job := jobWithRoutes(route(api.NotificationTargetWebhook))
+	job.Spec.Notifications.MutedProjects = []string{"/^org\\/re/"}
+
+	sender.send(context.Background(), job, failedRun(), time.Now())
+	if len(target.bodies) != 0 {
+		t.Errorf("expected nothing for a muted project, got %v", target.bodies)
+	}
+}
+
+func TestSender_RateLimit(t *testing.T) {
+	sender, target := newTestSender(t, testPolicy(), true, 2)
+	job := jobWithRoutes(route(api.NotificationTargetWebhook))
+	ctx := context.Background()
+	now := time.Now()
+
+	for range 3 {
+		sender.send(ctx, job, failedRun(), now)
+	}
+	if len(target.bodies) != 2 {
+		t.Fatalf("expected 2 notifications within the hour, got %d", len(target.bodies))
+	}
+
+	sender.send(ctx, job, failedRun(), now.Add(rateInterval+time.Second))
+	if len(target.bodies) != 3 {
+		t.Errorf("expected the route to send again an hour later, got %d", len(target.bodies))
+	}
+}
+
+func TestSender_Policy(t *testing.T) {
+	tests := map[string]struct {
+		policy   policy.Policy
+		labelled bool
+	}{
+		"destination not allowed": {policy: policy.Policy{AllowedHosts: []string{"hooks.slack.com"}}, labelled: true},
+		"secret not opted in":     {policy: testPolicy(), labelled: false},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			sender, target := newTestSender(t, tt.policy, tt.labelled, 0)
+			sender.send(context.Background(), jobWithRoutes(route(api.NotificationTargetWebhook)), failedRun(), time.Now())
+			if len(target.bodies) != 0 {
+				t.Errorf("expected the policy to stop the notification, got %v", target.bodies)
+			}
+		})
+	}
+}
+
+func TestSender_NotifyCopiesTheJob(t *testing.T) {
+	sender, _ := newTestSender(t, testPolicy(), true, 0)
+	job := jobWithRoutes(route(api.NotificationTargetWebhook))
+
+	sender.Notify(context.Background(), job, failedRun())
+	job.Spec.Notifications.Routes = nil
+
+	q := <-sender.queue
+	if len(q.renovateJob.Spec.Notifications.Routes) != 1 {
+		t.Error("expected the queued job to be unaffected by later changes")
+	}
+}
+
+func TestSender_NotifySkipsJobsWithoutRoutes(t *testing.T) {
+	sender, _ := newTestSender(t, testPolicy(), true, 0)
+	job := testJob()
+
+	sender.Notify(context.Background(), job, ForPolicyDenial(job, "r", "m"))
+	if len(sender.queue) != 0 {
+		t.Fatalf("expected nothing to be queued for a job without routes, got %d", len(sender.queue))
+	}
+
+	job.Spec.Notifications = &api.RenovateJobNotifications{Routes: []api.NotificationRoute{{Type: api.NotificationTargetSlack}}}
+	sender.Notify(context.Background(), job, ForPolicyDenial(job, "r", "m"))
+	if len(sender.queue) != 1 {
+		t.Fatalf("expected the notification to be queued, got %d", len(sender.queue))
+	}
+}
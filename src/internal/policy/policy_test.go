@@ -177,6 +177,7 @@ var chartDefaultAllowedHosts = []string{
 	"bitbucket.org",
 	"gitea.com",
 	"codeberg.org",
+	"dev.azure.com",
 }
 
 func TestChartDefaultsAreAccepted(t *testing.T) {
@@ -6,11 +6,13 @@ import (
 	api "renovate-operator/api/v1alpha1"
 	"renovate-operator/config"
 	crdManager "renovate-operator/internal/crdManager"
+	"renovate-operator/internal/eventStream"
 	"renovate-operator/internal/podLogs"
 	"renovate-operator/internal/policy"
 	"renovate-operator/internal/types"
 	"renovate-operator/metricStore"
 	"sync"
+	"time"
 
 	"github.com/go-logr/logr"
 	"go.opentelemetry.io/otel"
@@ -31,6 +33,8 @@ type DiscoveryAgent interface {
 	// scheduleAfterCompletion controls whether ProcessDiscoveryJobResult will schedule all
 	// non-running projects once the job completes (true for cron, false for UI-triggered).
 	// Completion is handled reactively by the job controller via ProcessDiscoveryJobResult.
+	// A job listing only static repositories is reconciled in place without a
+	// pod; native discovery lists the projects in the background instead.
 	CreateDiscoveryJob(ctx context.Context, renovateJob api.RenovateJob, options DiscoveryJobOptions) (string, error)
 	// GetDiscoveryJobStatus retrieves the current status of the discovery job for the given RenovateJob CRD.
 	GetDiscoveryJobStatus(ctx context.Context, job *api.RenovateJob) (api.RenovateProjectStatus, error)
@@ -53,9 +57,31 @@ type discoveryAgent struct {
 	syncer    map[string]*sync.RWMutex
 	logReader podLogs.PodLogReader
 	policy    policy.Policy
+	publisher eventStream.Publisher
+
+	// native tracks the native discoveries by job. They list the projects in
+	// the background, as a discovery pod does, so that a slow platform blocks
+	// neither the caller nor the job's lock.
+	nativeLock sync.Mutex
+	native     map[string]*nativeDiscovery
+	nativeRuns sync.WaitGroup
+}
+
+// nativeDiscovery is the state of the latest native discovery of a job.
+type nativeDiscovery struct {
+	status api.RenovateProjectStatus
+	// scheduleAll is set when a caller asks for every project to be scheduled
+	// once the discovery finishes, like the annotation on a discovery pod.
+	scheduleAll bool
 }
 
-func NewDiscoveryAgent(scheme *runtime.Scheme, client client.Client, logger logr.Logger, manager crdManager.RenovateJobManager, lr podLogs.PodLogReader, p policy.Policy) DiscoveryAgent {
+// nativeDiscoveryTimeout bounds a native discovery, so that a platform that
+// never answers does not leave it running for good.
+const nativeDiscoveryTimeout = 15 * time.Minute
+
+// NewDiscoveryAgent creates a DiscoveryAgent. publisher may be nil, in which
+// case no discovery events are published.
+func NewDiscoveryAgent(scheme *runtime.Scheme, client client.Client, logger logr.Logger, manager crdManager.RenovateJobManager, lr podLogs.PodLogReader, p policy.Policy, publisher eventStream.Publisher) DiscoveryAgent {
 	return &discoveryAgent{
 		client:    client,
 		logger:    logger,
@@ -64,12 +90,23 @@ func NewDiscoveryAgent(scheme *runtime.Scheme, client client.Client, logger logr
 		syncer:    make(map[string]*sync.RWMutex),
 		logReader: lr,
 		policy:    p,
+		publisher: publisher,
+		native:    make(map[string]*nativeDiscovery),
 	}
 }
 
 // GetDiscoveryJobStatus implements DiscoveryAgent.
 func (e *discoveryAgent) GetDiscoveryJobStatus(ctx context.Context, job *api.RenovateJob) (api.RenovateProjectStatus, error) {
 	name := job.Fullname()
+	if job.UsesNativeDiscovery() {
+		e.nativeLock.Lock()
+		defer e.nativeLock.Unlock()
+		if run := e.native[name]; run != nil {
+			return run.status, nil
+		}
+		return api.JobStatusScheduled, nil
+	}
+
 	lock := e.syncer[name]
 	if lock == nil {
 		lock = &sync.RWMutex{}
@@ -118,6 +155,14 @@ func (e *discoveryAgent) ProcessDiscoveryJobResult(ctx context.Context, k8sJob *
 	if status == api.JobStatusFailed {
 		log.FromContext(ctx).Info("discovery job failed", "renovateJob", jobId.Name)
 		metricStore.IncDiscoveryJob(ctx, jobId.Namespace, jobId.Name, "failed")
+		e.publishDiscoveryFinished(ctx, jobId.Namespace, jobId.Name, api.JobStatusFailed, 0)
+		// A failed discovery leaves the project list untouched, so a scheduled run
+		// still goes ahead on the last known-good list.
+		if k8sJob.Annotations[api.ScheduleAfterDiscoveryAnnotationKey] == "true" {
+			if err := e.scheduleKnownProjects(ctx, jobId); err != nil {
+				return err
+			}
+		}
 		_ = crdManager.MarkJobProcessed(ctx, e.client, k8sJob)
 		return nil
 	}
@@ -142,6 +187,7 @@ func (e *discoveryAgent) ProcessDiscoveryJobResult(ctx context.Context, k8sJob *
 	log.FromContext(ctx).V(2).Info("Discovered projects", "count", len(projects), "job", renovateJob.Fullname())
 
 	metricStore.IncDiscoveryJob(ctx, jobId.Namespace, jobId.Name, "completed")
+	e.publishDiscoveryFinished(ctx, jobId.Namespace, jobId.Name, api.JobStatusCompleted, len(projects))
 	metricStore.SetDiscoveredRepositories(jobId.Namespace, jobId.Name, len(projects))
 
 	removedProjects, err := e.manager.ReconcileProjects(ctx, renovateJob, projects)
@@ -156,13 +202,8 @@ func (e *discoveryAgent) ProcessDiscoveryJobResult(ctx context.Context, k8sJob *
 	}
 
 	if k8sJob.Annotations[api.ScheduleAfterDiscoveryAnnotationKey] == "true" {
-		isNotRunning := func(p api.ProjectStatus) bool {
-			return p.Status != api.JobStatusRunning
-		}
-		if err := e.manager.UpdateProjectStatusBatched(ctx, isNotRunning, jobId, &types.RenovateStatusUpdate{
-			Status: api.JobStatusScheduled,
-		}); err != nil {
-			return fmt.Errorf("failed to schedule projects: %w", err)
+		if err := e.scheduleKnownProjects(ctx, jobId); err != nil {
+			return err
 		}
 	}
 
@@ -180,6 +221,19 @@ func (e *discoveryAgent) ProcessDiscoveryJobResult(ctx context.Context, k8sJob *
 	return nil
 }
 
+// scheduleKnownProjects sets every non-running project of the RenovateJob to Scheduled.
+func (e *discoveryAgent) scheduleKnownProjects(ctx context.Context, jobId crdManager.RenovateJobIdentifier) error {
+	isNotRunning := func(p api.ProjectStatus) bool {
+		return p.Status != api.JobStatusRunning
+	}
+	if err := e.manager.UpdateProjectStatusBatched(ctx, isNotRunning, jobId, &types.RenovateStatusUpdate{
+		Status: api.JobStatusScheduled,
+	}); err != nil {
+		return fmt.Errorf("failed to schedule projects: %w", err)
+	}
+	return nil
+}
+
 func (e *discoveryAgent) CreateDiscoveryJob(ctx context.Context, renovateJob api.RenovateJob, options DiscoveryJobOptions) (string, error) {
 	// Defence in depth: the reconciler refuses such a job up front, but discovery is
 	// also reachable from the UI and from an annotation trigger.
@@ -197,6 +251,14 @@ func (e *discoveryAgent) CreateDiscoveryJob(ctx context.Context, renovateJob api
 	lock.Lock()
 	defer lock.Unlock()
 
+	if !renovateJob.UsesAutodiscovery() {
+		return "", e.reconcileStaticProjects(ctx, &renovateJob, options)
+	}
+	if renovateJob.UsesNativeDiscovery() {
+		e.startNativeDiscovery(ctx, &renovateJob, options)
+		return "", nil
+	}
+
 	existingJob, err := crdManager.GetJobByLabel(ctx, e.client, crdManager.JobSelector{
 		JobType:         crdManager.DiscoveryJobType,
 		Namespace:       renovateJob.Namespace,
@@ -245,3 +307,123 @@ func (e *discoveryAgent) CreateDiscoveryJob(ctx context.Context, renovateJob api
 
 	return generation, nil
 }
+
+// reconcileStaticProjects applies spec.repositories directly when the job does
+// not use autodiscovery, so no discovery pod is needed.
+func (e *discoveryAgent) reconcileStaticProjects(ctx context.Context, renovateJob *api.RenovateJob, options DiscoveryJobOptions) error {
+	metricStore.SetDiscoveredRepositories(renovateJob.Namespace, renovateJob.Name, len(renovateJob.Spec.Repositories))
+	if err := e.reconcileInPlace(ctx, renovateJob, nil, options); err != nil {
+		return fmt.Errorf("failed to reconcile static projects: %w", err)
+	}
+	return nil
+}
+
+// startNativeDiscovery starts listing the job's projects through the platform
+// API in the background, unless a native discovery of the job is running
+// already.
+func (e *discoveryAgent) startNativeDiscovery(ctx context.Context, renovateJob *api.RenovateJob, options DiscoveryJobOptions) {
+	name := renovateJob.Fullname()
+	e.nativeLock.Lock()
+	defer e.nativeLock.Unlock()
+
+	if run := e.native[name]; run != nil && run.status == api.JobStatusRunning {
+		log.FromContext(ctx).V(1).Info("native discovery already running, skipping", "renovateJob", name)
+		run.scheduleAll = run.scheduleAll || options.TriggerAllProjects
+		return
+	}
+	run := &nativeDiscovery{status: api.JobStatusRunning, scheduleAll: options.TriggerAllProjects}
+	e.native[name] = run
+
+	// The discovery outlives the request or reconcile that started it.
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), nativeDiscoveryTimeout)
+	e.nativeRuns.Add(1)
+	go func() {
+		defer e.nativeRuns.Done()
+		defer cancel()
+		if err := e.runNativeDiscovery(ctx, renovateJob, run); err != nil {
+			log.FromContext(ctx).Error(err, "native discovery failed", "renovateJob", name)
+		}
+	}()
+}
+
+// finishNativeDiscovery records the outcome of a native discovery and reports
+// whether every project is to be scheduled.
+func (e *discoveryAgent) finishNativeDiscovery(run *nativeDiscovery, status api.RenovateProjectStatus) bool {
+	e.nativeLock.Lock()
+	defer e.nativeLock.Unlock()
+	run.status = status
+	return run.scheduleAll
+}
+
+// runNativeDiscovery lists the job's projects through the platform API instead
+// of starting a Renovate discovery pod and parsing its logs.
+func (e *discoveryAgent) runNativeDiscovery(ctx context.Context, renovateJob *api.RenovateJob, run *nativeDiscovery) error {
+	jobId := crdManager.RenovateJobIdentifier{Name: renovateJob.Name, Namespace: renovateJob.Namespace}
+	projects, err := e.manager.DiscoverProjects(ctx, renovateJob)
+	if err != nil {
+		metricStore.IncDiscoveryJob(ctx, renovateJob.Namespace, renovateJob.Name, "failed")
+		e.publishDiscoveryFinished(ctx, renovateJob.Namespace, renovateJob.Name, api.JobStatusFailed, 0)
+		// As with a failed discovery pod, the project list is left untouched and
+		// a scheduled run still goes ahead on the last known-good list.
+		if e.finishNativeDiscovery(run, api.JobStatusFailed) {
+			if scheduleErr := e.scheduleKnownProjects(ctx, jobId); scheduleErr != nil {
+				log.FromContext(ctx).Error(scheduleErr, "failed to schedule projects after failed discovery", "renovateJob", renovateJob.Name)
+			}
+		}
+		return fmt.Errorf("listing projects: %w", err)
+	}
+	log.FromContext(ctx).V(2).Info("Discovered projects", "count", len(projects), "job", renovateJob.Fullname())
+
+	metricStore.IncDiscoveryJob(ctx, renovateJob.Namespace, renovateJob.Name, "completed")
+	e.publishDiscoveryFinished(ctx, renovateJob.Namespace, renovateJob.Name, api.JobStatusCompleted, len(projects))
+	metricStore.SetDiscoveredRepositories(renovateJob.Namespace, renovateJob.Name, len(projects))
+	err = e.reconcileInPlace(ctx, renovateJob, projects, DiscoveryJobOptions{})
+	status := api.JobStatusCompleted
+	if err != nil {
+		status = api.JobStatusFailed
+	}
+	if e.finishNativeDiscovery(run, status) {
+		if scheduleErr := e.scheduleKnownProjects(ctx, jobId); scheduleErr != nil {
+			return scheduleErr
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("failed to reconcile discovered projects: %w", err)
+	}
+	return nil
+}
+
+// reconcileInPlace does what ProcessDiscoveryJobResult does for a finished
+// discovery pod: reconcile the projects, sync webhooks and optionally schedule
+// every project.
+func (e *discoveryAgent) reconcileInPlace(ctx context.Context, renovateJob *api.RenovateJob, projects []string, options DiscoveryJobOptions) error {
+	jobId := crdManager.RenovateJobIdentifier{Name: renovateJob.Name, Namespace: renovateJob.Namespace}
+
+	removedProjects, err := e.manager.ReconcileProjects(ctx, renovateJob, projects)
+	if err != nil {
+		return err
+	}
+
+	if err := e.manager.SyncWebhooks(ctx, jobId, removedProjects); err != nil {
+		log.FromContext(ctx).Error(err, "failed to sync webhooks", "renovateJob", renovateJob.Name)
+	}
+
+	if options.TriggerAllProjects {
+		return e.scheduleKnownProjects(ctx, jobId)
+	}
+	return nil
+}
+
+// publishDiscoveryFinished publishes the event of a discovery that finished
+// with status.
+func (e *discoveryAgent) publishDiscoveryFinished(ctx context.Context, namespace, renovateJob string, status api.RenovateProjectStatus, projects int) {
+	if e.publisher == nil {
+		return
+	}
+	e.publisher.Publish(ctx, eventStream.NewDiscoveryEvent(eventStream.DiscoveryData{
+		Namespace:   namespace,
+		RenovateJob: renovateJob,
+		Status:      status,
+		Projects:    projects,
+	}))
+}
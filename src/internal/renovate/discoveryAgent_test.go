@@ -26,6 +26,7 @@ import (
 type fakeJobManager struct {
 	getJobFn                     func(ctx context.Context, name, namespace string) (*api.RenovateJob, error)
 	reconcileProjectsFn          func(ctx context.Context, job *api.RenovateJob, projects []string) error
+	discoverProjectsFn           func(ctx context.Context, job *api.RenovateJob) ([]string, error)
 	updateProjectStatusBatchedFn func(ctx context.Context, fn func(p api.ProjectStatus) bool, job crdManager.RenovateJobIdentifier, status *types.RenovateStatusUpdate) error
 }
 
@@ -87,6 +88,24 @@ func (f *fakeJobManager) SetAcceptedCondition(ctx context.Context, job crdManage
 func (f *fakeJobManager) CancelProjectJob(ctx context.Context, project string, job crdManager.RenovateJobIdentifier) error {
 	return nil
 }
+func (f *fakeJobManager) ApproveProjects(ctx context.Context, job crdManager.RenovateJobIdentifier, projects []string) error {
+	return nil
+}
+
+func (f *fakeJobManager) DiscoverProjects(ctx context.Context, job *api.RenovateJob) ([]string, error) {
+	if f.discoverProjectsFn != nil {
+		return f.discoverProjectsFn(ctx, job)
+	}
+	return nil, nil
+}
+
+func (f *fakeJobManager) GetDefaultBranch(ctx context.Context, job *api.RenovateJob, project string) (string, error) {
+	return "", nil
+}
+
+func (f *fakeJobManager) ListChangedFiles(ctx context.Context, job *api.RenovateJob, project, base, head string) ([]string, error) {
+	return nil, nil
+}
 
 type fakePodLogReader struct {
 	getSucceededJobLogFn func(ctx context.Context, job *batchv1.Job) (string, error)
@@ -150,7 +169,7 @@ func TestGetDiscoveryJobStatus(t *testing.T) {
 
 	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(running, failed, succeeded).Build()
 
-	daIface := NewDiscoveryAgent(scheme, c, testLogger, nil, nil, policy.Policy{})
+	daIface := NewDiscoveryAgent(scheme, c, testLogger, nil, nil, policy.Policy{}, nil)
 	da := daIface.(*discoveryAgent)
 
 	tests := []struct {
@@ -196,7 +215,7 @@ func TestCreateDiscoveryJob(t *testing.T) {
 	})
 
 	c := fake.NewClientBuilder().WithScheme(scheme).WithStatusSubresource(&batchv1.Job{}).Build()
-	da := NewDiscoveryAgent(scheme, c, testLogger, nil, nil, policy.Policy{}).(*discoveryAgent)
+	da := NewDiscoveryAgent(scheme, c, testLogger, nil, nil, policy.Policy{}, nil).(*discoveryAgent)
 
 	rj := &api.RenovateJob{}
 	rj.Name = "job1"
@@ -248,7 +267,7 @@ func TestCreateDiscoveryJob_AlreadyRunning(t *testing.T) {
 	}
 
 	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(runningJob).Build()
-	da := NewDiscoveryAgent(scheme, c, testLogger, nil, nil, policy.Policy{}).(*discoveryAgent)
+	da := NewDiscoveryAgent(scheme, c, testLogger, nil, nil, policy.Policy{}, nil).(*discoveryAgent)
 
 	rj := &api.RenovateJob{}
 	rj.Name = "job1"
@@ -299,7 +318,7 @@ func TestCreateDiscoveryJob_AlreadyRunning_SetsAnnotation(t *testing.T) {
 	}
 
 	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(runningJob).Build()
-	da := NewDiscoveryAgent(scheme, c, testLogger, nil, nil, policy.Policy{}).(*discoveryAgent)
+	da := NewDiscoveryAgent(scheme, c, testLogger, nil, nil, policy.Policy{}, nil).(*discoveryAgent)
 
 	rj := &api.RenovateJob{}
 	rj.Name = "job1"
@@ -348,7 +367,7 @@ func TestProcessDiscoveryJobResult(t *testing.T) {
 			return `["a","b"]`, nil
 		},
 	}
-	da := NewDiscoveryAgent(scheme, c, testLogger, mgr, lr, policy.Policy{}).(*discoveryAgent)
+	da := NewDiscoveryAgent(scheme, c, testLogger, mgr, lr, policy.Policy{}, nil).(*discoveryAgent)
 
 	// succeeded k8s Job (getJobStatus checks Conditions, not Succeeded counter)
 	k8sJob := &batchv1.Job{
@@ -371,10 +390,58 @@ func TestProcessDiscoveryJobResult(t *testing.T) {
 	}
 }
 
+func TestProcessDiscoveryJobResult_FailedKeepsLastKnownProjects(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := batchv1.AddToScheme(scheme); err != nil {
+		t.Fatalf("failed to add batch scheme: %v", err)
+	}
+
+	failedJob := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "job1-discovery-abc",
+			Namespace:   "ns",
+			Annotations: map[string]string{api.ScheduleAfterDiscoveryAnnotationKey: "true"},
+		},
+		Status: batchv1.JobStatus{
+			Conditions: []batchv1.JobCondition{
+				{Type: batchv1.JobFailed, Status: corev1.ConditionTrue},
+			},
+		},
+	}
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(failedJob).Build()
+
+	reconciled := false
+	var scheduled *types.RenovateStatusUpdate
+	mgr := &fakeJobManager{
+		reconcileProjectsFn: func(ctx context.Context, job *api.RenovateJob, projects []string) error {
+			reconciled = true
+			return nil
+		},
+		updateProjectStatusBatchedFn: func(ctx context.Context, fn func(p api.ProjectStatus) bool, job crdManager.RenovateJobIdentifier, status *types.RenovateStatusUpdate) error {
+			scheduled = status
+			return nil
+		},
+	}
+	da := NewDiscoveryAgent(scheme, c, testLogger, mgr, nil, policy.Policy{}, nil).(*discoveryAgent)
+
+	if err := da.ProcessDiscoveryJobResult(context.Background(), failedJob, crdManager.RenovateJobIdentifier{
+		Namespace: "ns",
+		Name:      "job1",
+	}); err != nil {
+		t.Fatalf("ProcessDiscoveryJobResult returned error: %v", err)
+	}
+	if reconciled {
+		t.Fatalf("a failed discovery must not touch the project list")
+	}
+	if scheduled == nil || scheduled.Status != api.JobStatusScheduled {
+		t.Fatalf("expected the last known projects to be scheduled, got %+v", scheduled)
+	}
+}
+
 func TestProcessDiscoveryJobResult_NilJob(t *testing.T) {
 	scheme := runtime.NewScheme()
 	c := fake.NewClientBuilder().WithScheme(scheme).Build()
-	da := NewDiscoveryAgent(scheme, c, testLogger, nil, nil, policy.Policy{}).(*discoveryAgent)
+	da := NewDiscoveryAgent(scheme, c, testLogger, nil, nil, policy.Policy{}, nil).(*discoveryAgent)
 
 	if err := da.ProcessDiscoveryJobResult(context.Background(), nil, crdManager.RenovateJobIdentifier{
 		Namespace: "ns",
@@ -390,7 +457,7 @@ func TestProcessDiscoveryJobResult_RunningJob(t *testing.T) {
 		t.Fatalf("failed to add batch scheme: %v", err)
 	}
 	c := fake.NewClientBuilder().WithScheme(scheme).Build()
-	da := NewDiscoveryAgent(scheme, c, testLogger, nil, nil, policy.Policy{}).(*discoveryAgent)
+	da := NewDiscoveryAgent(scheme, c, testLogger, nil, nil, policy.Policy{}, nil).(*discoveryAgent)
 
 	runningJob := &batchv1.Job{
 		ObjectMeta: metav1.ObjectMeta{Name: "job1-discovery-abc", Namespace: "ns"},
@@ -432,3 +499,204 @@ func TestEnsureRedisURLSecret(t *testing.T) {
 		t.Fatalf("expected redis-url secret data, got %q", got)
 	}
 }
+
+func TestCreateDiscoveryJob_StaticRepositoriesSkipPod(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := api.AddToScheme(scheme); err != nil {
+		t.Fatalf("failed to add api scheme: %v", err)
+	}
+	if err := batchv1.AddToScheme(scheme); err != nil {
+		t.Fatalf("failed to add batch scheme: %v", err)
+	}
+
+	var reconciled bool
+	var scheduled bool
+	mgr := &fakeJobManager{
+		reconcileProjectsFn: func(ctx context.Context, job *api.RenovateJob, projects []string) error {
+			reconciled = true
+			if len(projects) != 0 {
+				t.Errorf("expected no discovered projects, got %v", projects)
+			}
+			return nil
+		},
+		updateProjectStatusBatchedFn: func(ctx context.Context, fn func(p api.ProjectStatus) bool, job crdManager.RenovateJobIdentifier, status *types.RenovateStatusUpdate) error {
+			scheduled = true
+			return nil
+		},
+	}
+
+	c := fake.NewClientBuilder().WithScheme(scheme).Build()
+	da := NewDiscoveryAgent(scheme, c, testLogger, mgr, nil, policy.Policy{}, nil).(*discoveryAgent)
+
+	rj := &api.RenovateJob{}
+	rj.Name = "job1"
+	rj.Namespace = "ns"
+	rj.Spec.Repositories = []string{"org/a", "org/b"}
+
+	generation, err := da.CreateDiscoveryJob(context.Background(), *rj, DiscoveryJobOptions{TriggerAllProjects: true})
+	if err != nil {
+		t.Fatalf("CreateDiscoveryJob returned error: %v", err)
+	}
+	if generation != "" {
+		t.Errorf("expected empty generation, got %q", generation)
+	}
+	if !reconciled || !scheduled {
+		t.Errorf("expected static projects to be reconciled and scheduled (reconciled=%v scheduled=%v)", reconciled, scheduled)
+	}
+
+	jobList := &batchv1.JobList{}
+	if err := c.List(context.Background(), jobList); err != nil {
+		t.Fatalf("listing jobs: %v", err)
+	}
+	if len(jobList.Items) != 0 {
+		t.Fatalf("expected no discovery job, got %d", len(jobList.Items))
+	}
+}
+
+func TestCreateDiscoveryJob_NativeDiscoverySkipsPod(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := api.AddToScheme(scheme); err != nil {
+		t.Fatalf("failed to add api scheme: %v", err)
+	}
+	if err := batchv1.AddToScheme(scheme); err != nil {
+		t.Fatalf("failed to add batch scheme: %v", err)
+	}
+
+	var reconciledProjects []string
+	mgr := &fakeJobManager{
+		discoverProjectsFn: func(ctx context.Context, job *api.RenovateJob) ([]string, error) {
+			return []string{"org/a", "org/b"}, nil
+		},
+		reconcileProjectsFn: func(ctx context.Context, job *api.RenovateJob, projects []string) error {
+			reconciledProjects = projects
+			return nil
+		},
+	}
+
+	c := fake.NewClientBuilder().WithScheme(scheme).Build()
+	da := NewDiscoveryAgent(scheme, c, testLogger, mgr, nil, policy.Policy{}, nil).(*discoveryAgent)
+
+	rj := &api.RenovateJob{}
+	rj.Name = "job1"
+	rj.Namespace = "ns"
+	rj.Spec.DiscoveryMode = api.DiscoveryModeNative
+
+	if _, err := da.CreateDiscoveryJob(context.Background(), *rj, DiscoveryJobOptions{}); err != nil {
+		t.Fatalf("CreateDiscoveryJob returned error: %v", err)
+	}
+	da.nativeRuns.Wait()
+	if len(reconciledProjects) != 2 {
+		t.Errorf("expected the listed projects to be reconciled, got %v", reconciledProjects)
+	}
+
+	jobList := &batchv1.JobList{}
+	if err := c.List(context.Background(), jobList); err != nil {
+		t.Fatalf("listing jobs: %v", err)
+	}
+	if len(jobList.Items) != 0 {
+		t.Fatalf("expected no discovery job, got %d", len(jobList.Items))
+	}
+}
+
+func TestCreateDiscoveryJob_NativeDiscoveryFailureKeepsProjects(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := api.AddToScheme(scheme); err != nil {
+		t.Fatalf("failed to add api scheme: %v", err)
+	}
+
+	var reconciled, scheduled bool
+	mgr := &fakeJobManager{
+		discoverProjectsFn: func(ctx context.Context, job *api.RenovateJob) ([]string, error) {
+			return nil, fmt.Errorf("platform unavailable")
+		},
+		reconcileProjectsFn: func(ctx context.Context, job *api.RenovateJob, projects []string) error {
+			reconciled = true
+			return nil
+		},
+		updateProjectStatusBatchedFn: func(ctx context.Context, fn func(p api.ProjectStatus) bool, job crdManager.RenovateJobIdentifier, status *types.RenovateStatusUpdate) error {
+			scheduled = true
+			return nil
+		},
+	}
+
+	c := fake.NewClientBuilder().WithScheme(scheme).Build()
+	da := NewDiscoveryAgent(scheme, c, testLogger, mgr, nil, policy.Policy{}, nil).(*discoveryAgent)
+
+	rj := &api.RenovateJob{}
+	rj.Name = "job1"
+	rj.Namespace = "ns"
+	rj.Spec.DiscoveryMode = api.DiscoveryModeNative
+
+	if _, err := da.CreateDiscoveryJob(context.Background(), *rj, DiscoveryJobOptions{TriggerAllProjects: true}); err != nil {
+		t.Fatalf("CreateDiscoveryJob returned error: %v", err)
+	}
+	da.nativeRuns.Wait()
+	if status, _ := da.GetDiscoveryJobStatus(context.Background(), rj); status != api.JobStatusFailed {
+		t.Errorf("expected the discovery to be reported as failed, got %s", status)
+	}
+	if reconciled {
+		t.Error("a failed discovery must not reconcile the project list")
+	}
+	if !scheduled {
+		t.Error("expected the known projects to be scheduled anyway")
+	}
+}
+
+func TestCreateDiscoveryJob_NativeDiscoveryRunsInBackground(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := api.AddToScheme(scheme); err != nil {
+		t.Fatalf("failed to add api scheme: %v", err)
+	}
+
+	release := make(chan struct{})
+	var listings, schedules int
+	mgr := &fakeJobManager{
+		discoverProjectsFn: func(ctx context.Context, job *api.RenovateJob) ([]string, error) {
+			listings++
+			<-release
+			return []string{"org/a"}, nil
+		},
+		reconcileProjectsFn: func(ctx context.Context, job *api.RenovateJob, projects []string) error {
+			return nil
+		},
+		updateProjectStatusBatchedFn: func(ctx context.Context, fn func(p api.ProjectStatus) bool, job crdManager.RenovateJobIdentifier, status *types.RenovateStatusUpdate) error {
+			schedules++
+			return nil
+		},
+	}
+
+	c := fake.NewClientBuilder().WithScheme(scheme).Build()
+	da := NewDiscoveryAgent(scheme, c, testLogger, mgr, nil, policy.Policy{}, nil).(*discoveryAgent)
+
+	rj := &api.RenovateJob{}
+	rj.Name = "job1"
+	rj.Namespace = "ns"
+	rj.Spec.DiscoveryMode = api.DiscoveryModeNative
+
+	if status, _ := da.GetDiscoveryJobStatus(context.Background(), rj); status != api.JobStatusScheduled {
+		t.Errorf("expected no discovery before the first one, got %s", status)
+	}
+	// returns while the platform is still listing
+	if _, err := da.CreateDiscoveryJob(context.Background(), *rj, DiscoveryJobOptions{}); err != nil {
+		t.Fatalf("CreateDiscoveryJob returned error: %v", err)
+	}
+	if status, _ := da.GetDiscoveryJobStatus(context.Background(), rj); status != api.JobStatusRunning {
+		t.Errorf("expected the discovery to be running, got %s", status)
+	}
+	// a second request joins the running discovery and asks for a schedule
+	if _, err := da.CreateDiscoveryJob(context.Background(), *rj, DiscoveryJobOptions{TriggerAllProjects: true}); err != nil {
+		t.Fatalf("CreateDiscoveryJob returned error: %v", err)
+	}
+
+	close(release)
+	da.nativeRuns.Wait()
+	if listings != 1 {
+		t.Errorf("expected a single listing, got %d", listings)
+	}
+	if schedules != 1 {
+		t.Errorf("expected the projects to be scheduled once the discovery finished, got %d", schedules)
+	}
+	if status, _ := da.GetDiscoveryJobStatus(context.Background(), rj); status != api.JobStatusCompleted {
+		t.Errorf("expected the discovery to be completed, got %s", status)
+	}
+}
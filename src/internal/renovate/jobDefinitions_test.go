@@ -845,3 +845,13 @@ func expectPriorityClassName(t *testing.T, job *batchv1.Job, expectedPriorityCla
 		t.Fatalf("expected priority class name %q, got %q", expectedPriorityClassName, job.Spec.Template.Spec.PriorityClassName)
 	}
 }
+
+func TestDefaultEnvVars_Azure(t *testing.T) {
+	job := &api.RenovateJob{}
+	job.Spec.Provider = &api.RenovateProvider{Name: "azure", Endpoint: "https://dev.azure.com/org"}
+	container := &v1.Container{Env: getDefaultEnvVars(job)}
+
+	expectEnvVar(t, container, "RENOVATE_PLATFORM", "azure")
+	// Renovate resolves the API relative to the organisation URL
+	expectEnvVar(t, container, "RENOVATE_ENDPOINT", "https://dev.azure.com/org/")
+}
@@ -0,0 +1,275 @@
+package reports
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/mail"
+	"net/smtp"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	api "renovate-operator/api/v1alpha1"
+	"renovate-operator/internal/utils"
+	"renovate-operator/metricStore"
+
+	"github.com/go-logr/logr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// Types of the sinks a scheduled report is delivered to.
+const (
+	// SinkWebhook posts the report to an HTTP endpoint.
+	SinkWebhook = "webhook"
+	// SinkSMTP mails the report.
+	SinkSMTP = "smtp"
+)
+
+const deliveryTimeout = 30 * time.Second
+
+// Sink is where a scheduled report is delivered to.
+type Sink struct {
+	Name string `json:"name"`
+	// Type is SinkWebhook or SinkSMTP.
+	Type string `json:"type"`
+	// Format defaults to FormatJSON for a webhook and FormatHTML for a mail.
+	Format string `json:"format,omitempty"`
+	// URL is the endpoint of a webhook.
+	URL string `json:"url,omitempty"`
+	// Address is the host:port of the SMTP server.
+	Address string `json:"address,omitempty"`
+	// From and To are the addresses a mail is sent from and to.
+	From string   `json:"from,omitempty"`
+	To   []string `json:"to,omitempty"`
+	// Username authenticates to the SMTP server, with the sink's secret as
+	// password.
+	Username string `json:"username,omitempty"`
+
+	// secret signs the requests of a webhook with a Standard Webhooks
+	// signature, or is the password of the SMTP user
+	secret string
+}
+
+// SinkSecretEnv returns the environment variable holding the secret of the
+// sink at index in REPORT_SINKS.
+func SinkSecretEnv(index int) string {
+	return fmt.Sprintf("REPORT_SINK_%d_SECRET", index)
+}
+
+// ParseSinks reads the JSON list of sinks, with the secret of each sink looked
+// up through getenv (typically os.Getenv).
+func ParseSinks(raw string, getenv func(key string) string) ([]Sink, error) {
+	var sinks []Sink
+	if raw == "" {
+		return nil, nil
+	}
+	if err := json.Unmarshal([]byte(raw), &sinks); err != nil {
+		return nil, fmt.Errorf("report sinks must be a JSON list: %w", err)
+	}
+
+	seen := make(map[string]bool, len(sinks))
+	for i := range sinks {
+		sink := &sinks[i]
+		if sink.Name == "" {
+			return nil, fmt.Errorf("report sink %d has no name", i)
+		}
+		if seen[sink.Name] {
+			return nil, fmt.Errorf("report sink name %q is used twice", sink.Name)
+		}
+		seen[sink.Name] = true
+
+		switch sink.Type {
+		case SinkWebhook:
+			u, err := url.Parse(sink.URL)
+			if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+				return nil, fmt.Errorf("report sink %q needs an http or https url", sink.Name)
+			}
+			if sink.Format == "" {
+				sink.Format = FormatJSON
+			}
+		case SinkSMTP:
+			if _, _, err := net.SplitHostPort(sink.Address); err != nil {
+				return nil, fmt.Errorf("report sink %q needs an SMTP address as host:port", sink.Name)
+			}
+			if _, err := mail.ParseAddress(sink.From); err != nil {
+				return nil, fmt.Errorf("report sink %q has an invalid from address: %w", sink.Name, err)
+			}
+			if len(sink.To) == 0 {
+				return nil, fmt.Errorf("report sink %q has no recipients", sink.Name)
+			}
+			for _, to := range sink.To {
+				if _, err := mail.ParseAddress(to); err != nil {
+					return nil, fmt.Errorf("report sink %q has an invalid recipient %q: %w", sink.Name, to, err)
+				}
+			}
+			switch sink.Format {
+			case "":
+				sink.Format = FormatHTML
+			case FormatJSON:
+				return nil, fmt.Errorf("report sink %q mails %s or %s, not %s", sink.Name, FormatHTML, FormatMarkdown, FormatJSON)
+			}
+		default:
+			return nil, fmt.Errorf("report sink %q has unknown type %q, expected %s or %s", sink.Name, sink.Type, SinkWebhook, SinkSMTP)
+		}
+		if err := ValidateFormat(sink.Format); err != nil {
+			return nil, fmt.Errorf("report sink %q: %w", sink.Name, err)
+		}
+		sink.secret = getenv(SinkSecretEnv(i))
+	}
+	return sinks, nil
+}
+
+// Deliverer delivers the report of all RenovateJobs to the sinks, on the
+// schedule it is registered with.
+type Deliverer struct {
+	client  client.Reader
+	history History
+	sinks   []Sink
+	days    int
+	http    *http.Client
+	logger  logr.Logger
+}
+
+// NewDeliverer creates a Deliverer for reports covering the last days days.
+func NewDeliverer(logger logr.Logger, c client.Reader, history History, sinks []Sink, days int) *Deliverer {
+	return &Deliverer{
+		client:  c,
+		history: history,
+		sinks:   sinks,
+		days:    days,
+		http:    &http.Client{Timeout: deliveryTimeout},
+		logger:  logger,
+	}
+}
+
+// Deliver generates the report until now and sends it to every sink.
+func (d *Deliverer) Deliver(ctx context.Context, now time.Time) {
+	jobs := &api.RenovateJobList{}
+	if err := d.client.List(ctx, jobs); err != nil {
+		d.logger.Error(err, "failed to list renovatejobs for the report")
+		return
+	}
+	report, err := Generate(ctx, d.history, jobs.Items, d.days, now)
+	if err != nil {
+		d.logger.Error(err, "failed to generate the report")
+		return
+	}
+
+	for i := range d.sinks {
+		sink := &d.sinks[i]
+		if err := d.send(ctx, sink, report, now); err != nil {
+			metricStore.IncReportDelivery(ctx, sink.Name, "failed")
+			d.logger.Error(err, "failed to deliver the report", "sink", sink.Name)
+			continue
+		}
+		metricStore.IncReportDelivery(ctx, sink.Name, "sent")
+		d.logger.Info("delivered the report", "sink", sink.Name, "from", report.From, "to", report.To)
+	}
+}
+
+func (d *Deliverer) send(ctx context.Context, sink *Sink, report Report, now time.Time) error {
+	body, err := Render(report, sink.Format)
+	if err != nil {
+		return err
+	}
+	if sink.Type == SinkSMTP {
+		return sendMail(sink, report.Title(), body, now)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, deliveryTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, sink.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", ContentType(sink.Format))
+	if key, ok := utils.DecodeStandardWebhookSigningKey(sink.secret); ok {
+		id := rand.Text()
+		timestamp := strconv.FormatInt(now.Unix(), 10)
+		req.Header.Set("Webhook-Id", id)
+		req.Header.Set("Webhook-Timestamp", timestamp)
+		req.Header.Set("Webhook-Signature", "v1,"+utils.ComputeStandardWebhookSignature(key, id+"."+timestamp+"."+string(body)))
+	}
+	resp, err := d.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("sink answered with status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// sendMail mails the rendered report. Like smtp.SendMail, it upgrades to TLS
+// when the server offers STARTTLS, and net/smtp refuses to send a password in
+// plain text to anything but localhost.
+func sendMail(sink *Sink, subject string, body []byte, now time.Time) error {
+	var msg bytes.Buffer
+	fmt.Fprintf(&msg, "From: %s\r\n", sink.From)
+	fmt.Fprintf(&msg, "To: %s\r\n", strings.Join(sink.To, ", "))
+	fmt.Fprintf(&msg, "Subject: %s\r\n", subject)
+	fmt.Fprintf(&msg, "Date: %s\r\n", now.Format(time.RFC1123Z))
+	msg.WriteString("MIME-Version: 1.0\r\n")
+	contentType := "text/html; charset=utf-8"
+	if sink.Format == FormatMarkdown {
+		contentType = "text/plain; charset=utf-8"
+	}
+	fmt.Fprintf(&msg, "Content-Type: %s\r\n\r\n", contentType)
+	msg.Write(bytes.ReplaceAll(bytes.ReplaceAll(body, []byte("\r\n"), []byte("\n")), []byte("\n"), []byte("\r\n")))
+
+	host, _, _ := net.SplitHostPort(sink.Address)
+	conn, err := net.DialTimeout("tcp", sink.Address, deliveryTimeout)
+	if err != nil {
+		return err
+	}
+	// smtp.SendMail has no timeout, a stalled server would block the schedule
+	_ = conn.SetDeadline(time.Now().Add(deliveryTimeout))
+	c, err := smtp.NewClient(conn, host)
+	if err != nil {
+		_ = conn.Close()
+		return err
+	}
+	defer func() { _ = c.Close() }()
+
+	if ok, _ := c.Extension("STARTTLS"); ok {
+		if err := c.StartTLS(&tls.Config{ServerName: host}); err != nil {
+			return err
+		}
+	}
+	if sink.Username != "" {
+		if err := c.Auth(smtp.PlainAuth("", sink.Username, sink.secret, host)); err != nil {
+			return err
+		}
+	}
+	from, _ := mail.ParseAddress(sink.From)
+	if err := c.Mail(from.Address); err != nil {
+		return err
+	}
+	for _, recipient := range sink.To {
+		to, _ := mail.ParseAddress(recipient)
+		if err := c.Rcpt(to.Address); err != nil {
+			return err
+		}
+	}
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(msg.Bytes()); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return c.Quit()
+}
@@ -0,0 +1,218 @@
+package reports
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	api "renovate-operator/api/v1alpha1"
+	"renovate-operator/internal/utils"
+
+	"github.com/go-logr/logr"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestParseSinks(t *testing.T) {
+	env := map[string]string{SinkSecretEnv(1): "password"}
+	sinks, err := ParseSinks(`[
+		{"name":"managers","type":"webhook","url":"https://chat.example.com/hook","format":"markdown"},
+		{"name":"mail","type":"smtp","address":"smtp.example.com:587","from":"Renovate <renovate@example.com>","to":["team@example.com"],"username":"renovate"}
+	]`, func(key string) string { return env[key] })
+	if err != nil {
+		t.Fatalf("ParseSinks returned error: %v", err)
+	}
+	if len(sinks) != 2 || sinks[0].Format != FormatMarkdown || sinks[0].secret != "" {
+		t.Errorf("unexpected webhook sink: %+v", sinks)
+	}
+	if sinks[1].Format != FormatHTML || sinks[1].secret != "password" {
+		t.Errorf("expected the mail to default to html with its password, got %+v", sinks[1])
+	}
+
+	invalid := map[string]string{
+		"not a list":     `{"name":"a"}`,
+		"missing name":   `[{"type":"webhook","url":"https://a.example.com"}]`,
+		"duplicate name": `[{"name":"a","type":"webhook","url":"https://a.example.com"},{"name":"a","type":"webhook","url":"https://b.example.com"}]`,
+		"unknown type":   `[{"name":"a","type":"slack","url":"https://a.example.com"}]`,
+		"bad url":        `[{"name":"a","type":"webhook","url":"ftp://a.example.com"}]`,
+		"unknown format": `[{"name":"a","type":"webhook","url":"https://a.example.com","format":"pdf"}]`,
+		"no port":        `[{"name":"a","type":"smtp","address":"smtp.example.com","from":"a@example.com","to":["b@example.com"]}]`,
+		"bad from":       `[{"name":"a","type":"smtp","address":"smtp.example.com:25","from":"renovate","to":["b@example.com"]}]`,
+		"no recipients":  `[{"name":"a","type":"smtp","address":"smtp.example.com:25","from":"a@example.com"}]`,
+		"json mail":      `[{"name":"a","type":"smtp","address":"smtp.example.com:25","from":"a@example.com","to":["b@example.com"],"format":"json"}]`,
+	}
+	for name, raw := range invalid {
+		t.Run(name, func(t *testing.T) {
+			if _, err := ParseSinks(raw, func(string) string { return "" }); err == nil {
+				t.Errorf("expected %s to be rejected", raw)
+			}
+		})
+	}
+}
+
+// smtpStandIn is a minimal SMTP server accepting every mail, with PLAIN
+// authentication.
+type smtpStandIn struct {
+	listener net.Listener
+
+	mu   sync.Mutex
+	auth string
+	from string
+	to   []string
+	data string
+}
+
+func newSMTPStandIn(t *testing.T) *smtpStandIn {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	s := &smtpStandIn{listener: listener}
+	t.Cleanup(func() { _ = listener.Close() })
+	go s.serve()
+	return s
+}
+
+func (s *smtpStandIn) serve() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		go s.handle(conn)
+	}
+}
+
+func (s *smtpStandIn) handle(conn net.Conn) {
+	defer func() { _ = conn.Close() }()
+	r := bufio.NewReader(conn)
+	reply := func(line string) { _, _ = io.WriteString(conn, line+"\r\n") }
+
+	reply("220 localhost ESMTP")
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		line = strings.TrimRight(line, "\r\n")
+		command := strings.ToUpper(strings.SplitN(line, " ", 2)[0])
+		s.mu.Lock()
+		switch command {
+		case "EHLO":
+			reply("250-localhost")
+			reply("250 AUTH PLAIN")
+		case "AUTH":
+			decoded, _ := base64.StdEncoding.DecodeString(strings.TrimPrefix(line, "AUTH PLAIN "))
+			s.auth = string(decoded)
+			reply("235 authenticated")
+		case "MAIL":
+			s.from = line
+			reply("250 ok")
+		case "RCPT":
+			s.to = append(s.to, line)
+			reply("250 ok")
+		case "DATA":
+			reply("354 go ahead")
+			var data strings.Builder
+			for {
+				dataLine, err := r.ReadString('\n')
+				if err != nil || dataLine == ".\r\n" {
+					break
+				}
+				data.WriteString(dataLine)
+			}
+			s.data = data.String()
+			reply("250 queued")
+		case "QUIT":
+			reply("221 bye")
+			s.mu.Unlock()
+			return
+		default:
+			reply("250 ok")
+		}
+		s.mu.Unlock()
+	}
+}
+
+// recordingWebhook is a webhook sink recording the last request.
+type recordingWebhook struct {
+	mu      sync.Mutex
+	headers http.Header
+	body    string
+}
+
+func (r *recordingWebhook) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	body, _ := io.ReadAll(req.Body)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.headers = req.Header
+	r.body = string(body)
+	w.WriteHeader(http.StatusAccepted)
+}
+
+func TestDeliverer_Deliver(t *testing.T) {
+	mail := newSMTPStandIn(t)
+	webhook := &recordingWebhook{}
+	server := httptest.NewServer(webhook)
+	t.Cleanup(server.Close)
+
+	secret := "whsec_" + base64.StdEncoding.EncodeToString([]byte("signing-key"))
+	env := map[string]string{SinkSecretEnv(0): secret, SinkSecretEnv(1): "password"}
+	sinks, err := ParseSinks(`[
+		{"name":"ci","type":"webhook","url":"`+server.URL+`"},
+		{"name":"mail","type":"smtp","address":"`+mail.listener.Addr().String()+`","from":"Renovate <renovate@example.com>","to":["Team <team@example.com>","lead@example.com"],"username":"renovate"}
+	]`, func(key string) string { return env[key] })
+	if err != nil {
+		t.Fatalf("ParseSinks returned error: %v", err)
+	}
+
+	scheme := runtime.NewScheme()
+	if err := api.AddToScheme(scheme); err != nil {
+		t.Fatalf("failed to add scheme: %v", err)
+	}
+	job := reportJob("renovate", "job1", 2)
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(&job).Build()
+
+	now := time.Date(2026, 10, 19, 8, 0, 0, 0, time.UTC)
+	history := newMemoryHistory()
+	history.Record(context.Background(), testRun("org/a", api.JobStatusFailed, now.Add(-time.Hour), 0))
+
+	NewDeliverer(logr.Discard(), c, history, sinks, 7).Deliver(context.Background(), now)
+
+	if !strings.Contains(webhook.body, `"failed": 1`) || webhook.headers.Get("Content-Type") != "application/json" {
+		t.Errorf("expected the JSON report at the webhook, got %q (%v)", webhook.body, webhook.headers)
+	}
+	key, _ := utils.DecodeStandardWebhookSigningKey(secret)
+	id, timestamp := webhook.headers.Get("Webhook-Id"), webhook.headers.Get("Webhook-Timestamp")
+	if want := "v1," + utils.ComputeStandardWebhookSignature(key, id+"."+timestamp+"."+webhook.body); webhook.headers.Get("Webhook-Signature") != want {
+		t.Errorf("expected a valid signature, got headers %v", webhook.headers)
+	}
+
+	mail.mu.Lock()
+	defer mail.mu.Unlock()
+	if mail.auth != "\x00renovate\x00password" {
+		t.Errorf("expected PLAIN authentication as renovate, got %q", mail.auth)
+	}
+	if mail.from != "MAIL FROM:<renovate@example.com>" || len(mail.to) != 2 || mail.to[0] != "RCPT TO:<team@example.com>" {
+		t.Errorf("unexpected envelope: %q to %q", mail.from, mail.to)
+	}
+	for _, want := range []string{
+		"Subject: Renovate report 2026-10-13 to 2026-10-19\r\n",
+		"To: Team <team@example.com>, lead@example.com\r\n",
+		"Content-Type: text/html; charset=utf-8\r\n",
+		"<td>job1</td>",
+	} {
+		if !strings.Contains(mail.data, want) {
+			t.Errorf("expected the mail to contain %q, got:\n%s", want, mail.data)
+		}
+	}
+}
@@ -0,0 +1,239 @@
+package reports
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"strings"
+	"sync"
+	"time"
+
+	api "renovate-operator/api/v1alpha1"
+	"renovate-operator/internal/kvstore"
+
+	"github.com/go-logr/logr"
+)
+
+// MaxDays is the longest period a report covers, and how long the run history
+// is kept.
+const MaxDays = 31
+
+// dayFormat names the UTC day a run is counted on.
+const dayFormat = "2006-01-02"
+
+// Run is a finished run of a project.
+type Run struct {
+	Namespace   string
+	RenovateJob string
+	Project     string
+	// Status is api.JobStatusCompleted or api.JobStatusFailed.
+	Status     api.RenovateProjectStatus
+	Time       time.Time
+	PRActivity *api.PRActivity
+}
+
+// Activity sums up the finished runs of a RenovateJob.
+type Activity struct {
+	Runs       int `json:"runs"`
+	Completed  int `json:"completed"`
+	Failed     int `json:"failed"`
+	Automerged int `json:"automerged"`
+	Created    int `json:"created"`
+	Updated    int `json:"updated"`
+	// Failures counts the failed runs per project.
+	Failures map[string]int `json:"failures,omitempty"`
+}
+
+func (a *Activity) add(run Run) {
+	a.Runs++
+	switch run.Status {
+	case api.JobStatusCompleted:
+		a.Completed++
+	case api.JobStatusFailed:
+		a.Failed++
+		if a.Failures == nil {
+			a.Failures = map[string]int{}
+		}
+		a.Failures[run.Project]++
+	}
+	if run.PRActivity != nil {
+		a.Automerged += run.PRActivity.Automerged
+		a.Created += run.PRActivity.Created
+		a.Updated += run.PRActivity.Updated
+	}
+}
+
+func (a *Activity) merge(other Activity) {
+	a.Runs += other.Runs
+	a.Completed += other.Completed
+	a.Failed += other.Failed
+	a.Automerged += other.Automerged
+	a.Created += other.Created
+	a.Updated += other.Updated
+	for project, failures := range other.Failures {
+		if a.Failures == nil {
+			a.Failures = map[string]int{}
+		}
+		a.Failures[project] += failures
+	}
+}
+
+// History keeps the activity of every RenovateJob per day, for the last
+// MaxDays days.
+type History interface {
+	// Record counts a finished run on the day it finished.
+	Record(ctx context.Context, run Run)
+	// Activity sums up the days from from to to, both included.
+	Activity(ctx context.Context, namespace, renovateJob string, from, to time.Time) (Activity, error)
+}
+
+// NewHistory creates a History based on the provided mode.
+// Supported modes: "disabled" (returns nil), "memory" (in-memory store, per
+// replica) and "valkey" (Valkey-backed, shared by all replicas).
+func NewHistory(logger logr.Logger, mode string, valkeyCfg kvstore.ValkeyConfig) (History, error) {
+	switch mode {
+	case "memory":
+		return newMemoryHistory(), nil
+	case "valkey":
+		kv, err := kvstore.NewKVStore(valkeyCfg, kvstore.UsageRunHistory)
+		if err != nil {
+			return nil, err
+		}
+		hashes, ok := kv.(kvstore.HashStore)
+		if !ok {
+			return nil, errors.New("the run history needs a Valkey store that keeps hashes")
+		}
+		return &kvHistory{kv: hashes, logger: logger}, nil
+	case "disabled", "":
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("unknown run history mode %q", mode)
+	}
+}
+
+// days returns the UTC days from from to to, both included.
+func days(from, to time.Time) []string {
+	var result []string
+	for day := from.UTC().Truncate(24 * time.Hour); !day.After(to.UTC()); day = day.Add(24 * time.Hour) {
+		result = append(result, day.Format(dayFormat))
+	}
+	return result
+}
+
+func jobKey(namespace, renovateJob string) string {
+	return kvstore.JoinKey("RUN_HISTORY", namespace, renovateJob)
+}
+
+// memoryHistory is the in-memory implementation for REPORT_HISTORY_MODE=memory.
+// Each replica only counts the runs whose status it updated itself.
+type memoryHistory struct {
+	mu sync.RWMutex
+	// activity per job key and day
+	activity map[string]map[string]Activity
+}
+
+func newMemoryHistory() *memoryHistory {
+	return &memoryHistory{activity: map[string]map[string]Activity{}}
+}
+
+func (h *memoryHistory) Record(_ context.Context, run Run) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	key := jobKey(run.Namespace, run.RenovateJob)
+	byDay, ok := h.activity[key]
+	if !ok {
+		byDay = map[string]Activity{}
+		h.activity[key] = byDay
+	}
+	// days sort by their name, so anything before the oldest kept day expired
+	oldest := run.Time.UTC().AddDate(0, 0, -MaxDays).Format(dayFormat)
+	maps.DeleteFunc(byDay, func(day string, _ Activity) bool { return day < oldest })
+
+	day := run.Time.UTC().Format(dayFormat)
+	activity := byDay[day]
+	activity.add(run)
+	byDay[day] = activity
+}
+
+func (h *memoryHistory) Activity(_ context.Context, namespace, renovateJob string, from, to time.Time) (Activity, error) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	var result Activity
+	byDay := h.activity[jobKey(namespace, renovateJob)]
+	for _, day := range days(from, to) {
+		result.merge(byDay[day])
+	}
+	return result, nil
+}
+
+// historyTTL keeps a day in Valkey for as long as a report can cover it.
+const historyTTL = (MaxDays + 1) * 24 * time.Hour
+
+// kvHistory is the Valkey-backed implementation for REPORT_HISTORY_MODE=valkey,
+// with a hash of counters per job and day. A run increments the counters, so
+// replicas recording runs of the same job at the same moment count all of
+// them.
+type kvHistory struct {
+	kv     kvstore.HashStore
+	logger logr.Logger
+}
+
+// failuresField prefixes the counter of the failed runs of a project.
+const failuresField = "failures:"
+
+func (h *kvHistory) Record(ctx context.Context, run Run) {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 10*time.Second)
+	defer cancel()
+	key := kvstore.JoinKey(jobKey(run.Namespace, run.RenovateJob), run.Time.UTC().Format(dayFormat))
+
+	var activity Activity
+	activity.add(run)
+	increments := map[string]int64{
+		"runs":       int64(activity.Runs),
+		"completed":  int64(activity.Completed),
+		"failed":     int64(activity.Failed),
+		"automerged": int64(activity.Automerged),
+		"created":    int64(activity.Created),
+		"updated":    int64(activity.Updated),
+	}
+	for project, failures := range activity.Failures {
+		increments[failuresField+project] = int64(failures)
+	}
+	if err := h.kv.IncrFields(ctx, key, increments, historyTTL); err != nil {
+		h.logger.Error(err, "failed to save run history to valkey")
+	}
+}
+
+func (h *kvHistory) Activity(ctx context.Context, namespace, renovateJob string, from, to time.Time) (Activity, error) {
+	var result Activity
+	for _, day := range days(from, to) {
+		counters, err := h.kv.GetCounters(ctx, kvstore.JoinKey(jobKey(namespace, renovateJob), day))
+		if err != nil {
+			return Activity{}, err
+		}
+		result.merge(activityOf(counters))
+	}
+	return result, nil
+}
+
+// activityOf is the activity a day's counters in Valkey hold.
+func activityOf(counters map[string]int64) Activity {
+	activity := Activity{
+		Runs:       int(counters["runs"]),
+		Completed:  int(counters["completed"]),
+		Failed:     int(counters["failed"]),
+		Automerged: int(counters["automerged"]),
+		Created:    int(counters["created"]),
+		Updated:    int(counters["updated"]),
+	}
+	for field, failures := range counters {
+		if project, ok := strings.CutPrefix(field, failuresField); ok {
+			if activity.Failures == nil {
+				activity.Failures = map[string]int{}
+			}
+			activity.Failures[project] = int(failures)
+		}
+	}
+	return activity
+}
@@ -0,0 +1,131 @@
+package reports
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	api "renovate-operator/api/v1alpha1"
+	"renovate-operator/internal/kvstore"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-logr/logr"
+)
+
+// newTestKVHistory returns a Valkey run history on miniredis.
+func newTestKVHistory(t *testing.T) (*kvHistory, *miniredis.Miniredis) {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	kv, err := kvstore.NewValkeyKVStore("redis://" + mr.Addr() + "/0")
+	if err != nil {
+		t.Fatalf("NewValkeyKVStore failed: %v", err)
+	}
+	return &kvHistory{kv: kv.(kvstore.HashStore), logger: logr.Discard()}, mr
+}
+
+func testRun(project string, status api.RenovateProjectStatus, at time.Time, created int) Run {
+	return Run{
+		Namespace:   "renovate",
+		RenovateJob: "job1",
+		Project:     project,
+		Status:      status,
+		Time:        at,
+		PRActivity:  &api.PRActivity{Created: created, Automerged: 1},
+	}
+}
+
+func TestHistory(t *testing.T) {
+	valkey, _ := newTestKVHistory(t)
+	histories := map[string]History{
+		"memory": newMemoryHistory(),
+		"valkey": valkey,
+	}
+
+	for name, history := range histories {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			monday := time.Date(2026, 10, 12, 9, 0, 0, 0, time.UTC)
+			history.Record(ctx, testRun("org/a", api.JobStatusCompleted, monday, 2))
+			history.Record(ctx, testRun("org/b", api.JobStatusFailed, monday.Add(time.Hour), 0))
+			history.Record(ctx, testRun("org/b", api.JobStatusFailed, monday.AddDate(0, 0, 2), 0))
+			history.Record(ctx, testRun("org/a", api.JobStatusCompleted, monday.AddDate(0, 0, 3), 1))
+
+			activity, err := history.Activity(ctx, "renovate", "job1", monday.Truncate(24*time.Hour), monday.AddDate(0, 0, 2))
+			if err != nil {
+				t.Fatalf("Activity returned error: %v", err)
+			}
+			if activity.Runs != 3 || activity.Completed != 1 || activity.Failed != 2 || activity.Created != 2 || activity.Automerged != 3 {
+				t.Errorf("unexpected activity of the first three days: %+v", activity)
+			}
+			if activity.Failures["org/b"] != 2 || len(activity.Failures) != 1 {
+				t.Errorf("expected two failures of org/b, got %v", activity.Failures)
+			}
+
+			activity, _ = history.Activity(ctx, "renovate", "job1", monday.AddDate(0, 0, 3), monday.AddDate(0, 0, 4))
+			if activity.Runs != 1 || activity.Created != 1 {
+				t.Errorf("unexpected activity of the last day: %+v", activity)
+			}
+			if other, _ := history.Activity(ctx, "renovate", "job2", monday, monday.AddDate(0, 0, 4)); other.Runs != 0 {
+				t.Errorf("expected no activity for another job, got %+v", other)
+			}
+		})
+	}
+}
+
+func TestKVHistory_CountsConcurrentRuns(t *testing.T) {
+	history, mr := newTestKVHistory(t)
+	ctx := context.Background()
+	at := time.Now()
+
+	// replicas record the runs of the same job at the same moment
+	var wg sync.WaitGroup
+	for range 20 {
+		wg.Go(func() { history.Record(ctx, testRun("org/a", api.JobStatusFailed, at, 1)) })
+	}
+	wg.Wait()
+
+	activity, err := history.Activity(ctx, "renovate", "job1", at, at)
+	if err != nil || activity.Runs != 20 || activity.Created != 20 || activity.Failures["org/a"] != 20 {
+		t.Errorf("expected all 20 runs to be counted, got %+v, %v", activity, err)
+	}
+	if ttl := mr.TTL(kvstore.JoinKey(jobKey("renovate", "job1"), at.UTC().Format(dayFormat))); ttl != historyTTL {
+		t.Errorf("expected the day to expire after %v, got %v", historyTTL, ttl)
+	}
+}
+
+func TestMemoryHistory_Expires(t *testing.T) {
+	ctx := context.Background()
+	history := newMemoryHistory()
+	start := time.Date(2026, 9, 1, 12, 0, 0, 0, time.UTC)
+	history.Record(ctx, testRun("org/a", api.JobStatusCompleted, start, 1))
+	history.Record(ctx, testRun("org/a", api.JobStatusCompleted, start.AddDate(0, 0, MaxDays+1), 1))
+
+	if kept := len(history.activity[jobKey("renovate", "job1")]); kept != 1 {
+		t.Errorf("expected the day older than %d days to be dropped, %d days kept", MaxDays, kept)
+	}
+}
+
+func TestDays(t *testing.T) {
+	from := time.Date(2026, 10, 12, 23, 0, 0, 0, time.UTC)
+	to := time.Date(2026, 10, 14, 1, 0, 0, 0, time.UTC)
+	got := days(from, to)
+	want := []string{"2026-10-12", "2026-10-13", "2026-10-14"}
+	if len(got) != len(want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("expected day %d to be %s, got %s", i, want[i], got[i])
+		}
+	}
+}
+
+func TestNewHistory(t *testing.T) {
+	if history, err := NewHistory(logr.Discard(), "disabled", kvstore.ValkeyConfig{}); err != nil || history != nil {
+		t.Errorf("expected no history when disabled, got %v, %v", history, err)
+	}
+	if _, err := NewHistory(logr.Discard(), "disk", kvstore.ValkeyConfig{}); err == nil {
+		t.Error("expected an unknown mode to be rejected")
+	}
+}
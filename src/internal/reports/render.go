@@ -0,0 +1,135 @@
+package reports
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"strings"
+)
+
+// Formats a report is rendered in.
+const (
+	FormatJSON     = "json"
+	FormatMarkdown = "markdown"
+	FormatHTML     = "html"
+)
+
+// ContentType returns the media type of a rendered format.
+func ContentType(format string) string {
+	switch format {
+	case FormatMarkdown:
+		return "text/markdown; charset=utf-8"
+	case FormatHTML:
+		return "text/html; charset=utf-8"
+	default:
+		return "application/json"
+	}
+}
+
+// ValidateFormat reports whether format is one a report can be rendered in.
+func ValidateFormat(format string) error {
+	switch format {
+	case FormatJSON, FormatMarkdown, FormatHTML:
+		return nil
+	default:
+		return fmt.Errorf("unknown report format %q, expected %s, %s or %s", format, FormatJSON, FormatMarkdown, FormatHTML)
+	}
+}
+
+// Render renders the report in format.
+func Render(report Report, format string) ([]byte, error) {
+	switch format {
+	case FormatJSON:
+		return json.MarshalIndent(report, "", "  ")
+	case FormatMarkdown:
+		return renderMarkdown(report), nil
+	case FormatHTML:
+		var b bytes.Buffer
+		if err := htmlTemplate.Execute(&b, report); err != nil {
+			return nil, err
+		}
+		return b.Bytes(), nil
+	default:
+		return nil, ValidateFormat(format)
+	}
+}
+
+// Title names the report and its period.
+func (r Report) Title() string {
+	return fmt.Sprintf("Renovate report %s to %s", r.From.Format(dayFormat), r.To.Format(dayFormat))
+}
+
+const summaryHeader = "| Projects | Runs | Failed runs | PRs created | PRs updated | PRs automerged | PRs awaiting approval |"
+
+func summaryCells(s Summary) string {
+	return fmt.Sprintf("| %d | %d | %d | %d | %d | %d | %d |", s.Projects, s.Runs, s.Failed, s.Created, s.Updated, s.Automerged, s.NeedsApproval)
+}
+
+// escapeMarkdown keeps names from breaking a table cell.
+var escapeMarkdown = strings.NewReplacer("|", `\|`, "*", `\*`, "_", `\_`)
+
+func renderMarkdown(report Report) []byte {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s\n\n", report.Title())
+	fmt.Fprintf(&b, "%s\n|---|---|---|---|---|---|---|\n%s\n", summaryHeader, summaryCells(report.Totals))
+
+	for _, namespace := range report.Namespaces {
+		fmt.Fprintf(&b, "\n## %s\n\n", escapeMarkdown.Replace(namespace.Namespace))
+		fmt.Fprintf(&b, "| RenovateJob %s\n|---|---|---|---|---|---|---|---|\n", summaryHeader)
+		for _, job := range namespace.RenovateJobs {
+			fmt.Fprintf(&b, "| %s %s\n", escapeMarkdown.Replace(job.Name), summaryCells(job.Summary))
+		}
+		for _, job := range namespace.RenovateJobs {
+			if len(job.FailingProjects) == 0 {
+				continue
+			}
+			fmt.Fprintf(&b, "\nFailed runs of %s by project:\n\n", escapeMarkdown.Replace(job.Name))
+			for _, project := range job.FailingProjects {
+				fmt.Fprintf(&b, "- %s: %d\n", escapeMarkdown.Replace(project.Project), project.Failures)
+			}
+		}
+	}
+	return []byte(b.String())
+}
+
+var htmlTemplate = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{ .Title }}</title>
+<style>
+body { font-family: sans-serif; color: #1f2328; }
+table { border-collapse: collapse; margin-bottom: 1em; }
+th, td { border: 1px solid #d0d7de; padding: 4px 8px; text-align: right; }
+th:first-child, td:first-child { text-align: left; }
+</style>
+</head>
+<body>
+<h1>{{ .Title }}</h1>
+<table>
+<tr><th></th>{{ template "header" }}</tr>
+<tr><td>All RenovateJobs</td>{{ template "cells" .Totals }}</tr>
+</table>
+{{- range .Namespaces }}
+<h2>{{ .Namespace }}</h2>
+<table>
+<tr><th>RenovateJob</th>{{ template "header" }}</tr>
+{{- range .RenovateJobs }}
+<tr><td>{{ .Name }}</td>{{ template "cells" .Summary }}</tr>
+{{- end }}
+</table>
+{{- range .RenovateJobs }}{{ if .FailingProjects }}
+<p>Failed runs of {{ .Name }} by project:</p>
+<ul>
+{{- range .FailingProjects }}
+<li>{{ .Project }}: {{ .Failures }}</li>
+{{- end }}
+</ul>
+{{- end }}{{ end }}
+{{- end }}
+</body>
+</html>
+{{ define "header" }}<th>Projects</th><th>Runs</th><th>Failed runs</th><th>PRs created</th><th>PRs updated</th><th>PRs automerged</th><th>PRs awaiting approval</th>{{ end }}
+{{- define "cells" }}<td>{{ .Projects }}</td><td>{{ .Runs }}</td><td>{{ .Failed }}</td><td>{{ .Created }}</td><td>{{ .Updated }}</td><td>{{ .Automerged }}</td><td>{{ .NeedsApproval }}</td>{{ end }}
+`))
@@ -0,0 +1,146 @@
+package reports
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"time"
+
+	api "renovate-operator/api/v1alpha1"
+)
+
+// Digest reports.
+//
+// A report sums up what Renovate did across the RenovateJobs over the last
+// days: the runs and their outcome, the PRs created, updated and automerged,
+// and the PRs still waiting for approval. Runs are counted in a History as
+// they finish, so a report only covers what happened while it was kept.
+
+// DefaultDays is the period of a report when none is asked for.
+const DefaultDays = 7
+
+// maxFailingProjects bounds the projects listed as failing per RenovateJob.
+const maxFailingProjects = 5
+
+// Summary sums up the activity of a RenovateJob or a group of them.
+type Summary struct {
+	Projects   int `json:"projects"`
+	Runs       int `json:"runs"`
+	Completed  int `json:"completed"`
+	Failed     int `json:"failed"`
+	Automerged int `json:"automerged"`
+	Created    int `json:"created"`
+	Updated    int `json:"updated"`
+	// NeedsApproval is the number of PRs waiting for approval when the report
+	// was generated, not during the period.
+	NeedsApproval int `json:"needsApproval"`
+}
+
+func (s *Summary) add(other Summary) {
+	s.Projects += other.Projects
+	s.Runs += other.Runs
+	s.Completed += other.Completed
+	s.Failed += other.Failed
+	s.Automerged += other.Automerged
+	s.Created += other.Created
+	s.Updated += other.Updated
+	s.NeedsApproval += other.NeedsApproval
+}
+
+// ProjectFailures is a project and its failed runs during the period.
+type ProjectFailures struct {
+	Project  string `json:"project"`
+	Failures int    `json:"failures"`
+}
+
+// JobReport is the activity of a RenovateJob.
+type JobReport struct {
+	Name string `json:"name"`
+	Summary
+	// FailingProjects are the projects with the most failed runs, most first.
+	FailingProjects []ProjectFailures `json:"failingProjects,omitempty"`
+}
+
+// NamespaceReport is the activity of the RenovateJobs of a namespace.
+type NamespaceReport struct {
+	Namespace string `json:"namespace"`
+	Summary
+	RenovateJobs []JobReport `json:"renovateJobs"`
+}
+
+// Report is the activity of the RenovateJobs from From to To.
+type Report struct {
+	From       time.Time         `json:"from"`
+	To         time.Time         `json:"to"`
+	Totals     Summary           `json:"totals"`
+	Namespaces []NamespaceReport `json:"namespaces"`
+}
+
+// Period returns the start of a report covering the last days days until now:
+// the start of the UTC day days-1 days ago, so a report always covers whole
+// days besides the current one.
+func Period(days int, now time.Time) time.Time {
+	return now.UTC().Truncate(24*time.Hour).AddDate(0, 0, -(days - 1))
+}
+
+// Generate reports the activity of jobs over the last days days until now.
+func Generate(ctx context.Context, history History, jobs []api.RenovateJob, days int, now time.Time) (Report, error) {
+	report := Report{From: Period(days, now), To: now.UTC(), Namespaces: []NamespaceReport{}}
+
+	byNamespace := map[string]*NamespaceReport{}
+	for i := range jobs {
+		job := &jobs[i]
+		activity, err := history.Activity(ctx, job.Namespace, job.Name, report.From, report.To)
+		if err != nil {
+			return Report{}, err
+		}
+		jobReport := JobReport{
+			Name: job.Name,
+			Summary: Summary{
+				Projects:   len(job.Status.Projects),
+				Runs:       activity.Runs,
+				Completed:  activity.Completed,
+				Failed:     activity.Failed,
+				Automerged: activity.Automerged,
+				Created:    activity.Created,
+				Updated:    activity.Updated,
+			},
+			FailingProjects: failingProjects(activity.Failures),
+		}
+		for _, project := range job.Status.Projects {
+			if project.PRActivity != nil {
+				jobReport.NeedsApproval += project.PRActivity.NeedsApproval
+			}
+		}
+
+		namespace, ok := byNamespace[job.Namespace]
+		if !ok {
+			namespace = &NamespaceReport{Namespace: job.Namespace}
+			byNamespace[job.Namespace] = namespace
+		}
+		namespace.add(jobReport.Summary)
+		namespace.RenovateJobs = append(namespace.RenovateJobs, jobReport)
+		report.Totals.add(jobReport.Summary)
+	}
+
+	for _, namespace := range byNamespace {
+		slices.SortFunc(namespace.RenovateJobs, func(a, b JobReport) int { return cmp.Compare(a.Name, b.Name) })
+		report.Namespaces = append(report.Namespaces, *namespace)
+	}
+	slices.SortFunc(report.Namespaces, func(a, b NamespaceReport) int { return cmp.Compare(a.Namespace, b.Namespace) })
+	return report, nil
+}
+
+func failingProjects(failures map[string]int) []ProjectFailures {
+	result := make([]ProjectFailures, 0, len(failures))
+	for project, count := range failures {
+		result = append(result, ProjectFailures{Project: project, Failures: count})
+	}
+	slices.SortFunc(result, func(a, b ProjectFailures) int {
+		return cmp.Or(cmp.Compare(b.Failures, a.Failures), cmp.Compare(a.Project, b.Project))
+	})
+	if len(result) > maxFailingProjects {
+		result = result[:maxFailingProjects]
+	}
+	return result
+}
@@ -0,0 +1,129 @@
+package reports
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	api "renovate-operator/api/v1alpha1"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func reportJob(namespace, name string, needsApproval ...int) api.RenovateJob {
+	job := api.RenovateJob{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace}}
+	for i, count := range needsApproval {
+		job.Status.Projects = append(job.Status.Projects, api.ProjectStatus{
+			Name:       fmt.Sprintf("org/%d", i),
+			PRActivity: &api.PRActivity{NeedsApproval: count},
+		})
+	}
+	return job
+}
+
+func testReport(t *testing.T) Report {
+	t.Helper()
+	ctx := context.Background()
+	now := time.Date(2026, 10, 19, 8, 0, 0, 0, time.UTC)
+	history := newMemoryHistory()
+	for i := range maxFailingProjects + 2 {
+		run := testRun(fmt.Sprintf("org/failing-%d", i), api.JobStatusFailed, now.Add(-time.Hour), 0)
+		history.Record(ctx, run)
+		if i == 3 {
+			history.Record(ctx, run)
+		}
+	}
+	history.Record(ctx, testRun("org/a", api.JobStatusCompleted, now.AddDate(0, 0, -2), 4))
+	// before the period
+	history.Record(ctx, testRun("org/a", api.JobStatusCompleted, now.AddDate(0, 0, -8), 100))
+	other := testRun("org/x", api.JobStatusCompleted, now, 1)
+	other.Namespace = "apps"
+	other.RenovateJob = "gitlab"
+	history.Record(ctx, other)
+
+	jobs := []api.RenovateJob{
+		reportJob("renovate", "job1", 2, 1),
+		reportJob("apps", "gitlab", 0),
+		reportJob("renovate", "idle"),
+	}
+	report, err := Generate(ctx, history, jobs, 7, now)
+	if err != nil {
+		t.Fatalf("Generate returned error: %v", err)
+	}
+	return report
+}
+
+func TestGenerate(t *testing.T) {
+	report := testReport(t)
+
+	if !report.From.Equal(time.Date(2026, 10, 13, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("expected the report to start at the beginning of the 7th day back, got %v", report.From)
+	}
+	want := Summary{Projects: 3, Runs: 10, Completed: 2, Failed: 8, Automerged: 10, Created: 5, NeedsApproval: 3}
+	if report.Totals != want {
+		t.Errorf("expected totals %+v, got %+v", want, report.Totals)
+	}
+
+	if len(report.Namespaces) != 2 || report.Namespaces[0].Namespace != "apps" || report.Namespaces[1].Namespace != "renovate" {
+		t.Fatalf("expected the namespaces apps and renovate in order, got %+v", report.Namespaces)
+	}
+	renovate := report.Namespaces[1]
+	if len(renovate.RenovateJobs) != 2 || renovate.RenovateJobs[0].Name != "idle" || renovate.RenovateJobs[1].Name != "job1" {
+		t.Fatalf("expected the jobs idle and job1 in order, got %+v", renovate.RenovateJobs)
+	}
+	if renovate.Runs != 9 || renovate.NeedsApproval != 3 {
+		t.Errorf("unexpected namespace summary: %+v", renovate.Summary)
+	}
+
+	failing := renovate.RenovateJobs[1].FailingProjects
+	if len(failing) != maxFailingProjects {
+		t.Fatalf("expected %d failing projects, got %+v", maxFailingProjects, failing)
+	}
+	if failing[0] != (ProjectFailures{Project: "org/failing-3", Failures: 2}) || failing[1].Project != "org/failing-0" {
+		t.Errorf("expected the most failing project first, then by name, got %+v", failing)
+	}
+}
+
+func TestRender(t *testing.T) {
+	report := testReport(t)
+
+	body, err := Render(report, FormatJSON)
+	if err != nil {
+		t.Fatalf("Render returned error: %v", err)
+	}
+	var decoded Report
+	if err := json.Unmarshal(body, &decoded); err != nil || decoded.Totals != report.Totals {
+		t.Errorf("expected the JSON report to round-trip, got %s (%v)", body, err)
+	}
+
+	body, _ = Render(report, FormatMarkdown)
+	markdown := string(body)
+	for _, want := range []string{
+		"# Renovate report 2026-10-13 to 2026-10-19",
+		"| 3 | 10 | 8 | 5 | 0 | 10 | 3 |",
+		"## renovate",
+		"| job1 | 2 | 9 | 8 | 4 | 0 | 9 | 3 |",
+		"- org/failing-3: 2\n",
+	} {
+		if !strings.Contains(markdown, want) {
+			t.Errorf("expected the Markdown report to contain %q, got:\n%s", want, markdown)
+		}
+	}
+
+	report.Namespaces[0].RenovateJobs[0].Name = "<script>"
+	body, _ = Render(report, FormatHTML)
+	html := string(body)
+	if !strings.Contains(html, "<h2>renovate</h2>") || !strings.Contains(html, "<td>job1</td><td>2</td><td>9</td>") {
+		t.Errorf("unexpected HTML report:\n%s", html)
+	}
+	if strings.Contains(html, "<script>") || !strings.Contains(html, "&lt;script&gt;") {
+		t.Error("expected names to be escaped in the HTML report")
+	}
+
+	if _, err := Render(report, "pdf"); err == nil {
+		t.Error("expected an unknown format to be rejected")
+	}
+}
@@ -12,4 +12,7 @@ type RenovateStatusUpdate struct {
 	LogIssues            *api.LogIssues
 	Duration             *string
 	ExecutionOptions     *api.RenovateExecutionOptions
+	// RerunIfRunning asks for another run after the current one when the
+	// update schedules a project that is running.
+	RerunIfRunning bool
 }
@@ -0,0 +1,34 @@
+package utils
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ParseDurationWithDays parses a duration like "180d", "12h" or "1d12h". In
+// addition to everything time.ParseDuration accepts, a leading day component
+// ("<n>d") is supported. An empty string yields zero.
+func ParseDurationWithDays(s string) (time.Duration, error) {
+	if s == "" {
+		return 0, nil
+	}
+	var days time.Duration
+	if idx := strings.Index(s, "d"); idx >= 0 {
+		n, err := strconv.Atoi(s[:idx])
+		if err != nil || n < 0 {
+			return 0, fmt.Errorf("invalid duration %q", s)
+		}
+		days = time.Duration(n) * 24 * time.Hour
+		s = s[idx+1:]
+		if s == "" {
+			return days, nil
+		}
+	}
+	rest, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid duration %q: %w", s, err)
+	}
+	return days + rest, nil
+}
@@ -0,0 +1,35 @@
+package utils
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseDurationWithDays(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    time.Duration
+		wantErr bool
+	}{
+		{input: "", want: 0},
+		{input: "180d", want: 180 * 24 * time.Hour},
+		{input: "72h", want: 72 * time.Hour},
+		{input: "1d12h", want: 36 * time.Hour},
+		{input: "30m", want: 30 * time.Minute},
+		{input: "xd", wantErr: true},
+		{input: "-1d", wantErr: true},
+		{input: "180", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseDurationWithDays(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseDurationWithDays(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseDurationWithDays(%q) = %v, want %v", tt.input, got, tt.want)
+			}
+		})
+	}
+}
@@ -2,6 +2,7 @@ package utils
 
 import (
 	"fmt"
+	"strings"
 
 	api "renovate-operator/api/v1alpha1"
 )
@@ -11,13 +12,22 @@ func GetPlatformAndEndpoint(provider *api.RenovateProvider) (string, string) {
 		return "", ""
 	}
 	endpoint := provider.Endpoint
-	if endpoint == "" {
-		switch provider.Name {
-		case "github":
+	switch provider.Name {
+	case "github":
+		if endpoint == "" {
 			endpoint = "https://api.github.com"
-		case "gitlab":
+		}
+	case "gitlab":
+		if endpoint == "" {
 			endpoint = "https://gitlab.com/api/v4"
 		}
+	case "azure":
+		// There is no default: the endpoint is the organisation (collection)
+		// URL. Renovate resolves API paths relative to it, so without the
+		// trailing slash the organisation segment would be dropped.
+		if endpoint != "" && !strings.HasSuffix(endpoint, "/") {
+			endpoint += "/"
+		}
 	}
 	return provider.Name, endpoint
 }
@@ -33,6 +43,10 @@ func GetPublicEndpoint(provider *api.RenovateProvider) string {
 	return endpoint
 }
 
+// WebhookScopeParameter is the query parameter that marks the delivery URL of
+// an organization webhook.
+const WebhookScopeParameter = "scope"
+
 // WebhookEndpointPath returns the operator's webhook server path for the given
 // platform.
 func WebhookEndpointPath(platform string) (string, error) {
@@ -45,6 +59,12 @@ func WebhookEndpointPath(platform string) (string, error) {
 		return "/webhook/v1/forgejo", nil
 	case "gitea":
 		return "/webhook/v1/gitea", nil
+	case "bitbucket":
+		return "/webhook/v1/bitbucket", nil
+	case "bitbucket-server":
+		return "/webhook/v1/bitbucket-server", nil
+	case "azure":
+		return "/webhook/v1/azure", nil
 	default:
 		return "", fmt.Errorf("no webhook endpoint for platform %q", platform)
 	}
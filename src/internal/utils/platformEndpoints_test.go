@@ -46,6 +46,23 @@ func TestGetPlatformAndEndpoint(t *testing.T) {
 			expectedPlatform: "gitlab",
 			expectedEndpoint: "https://gitlab.com/api/v4",
 		},
+		{
+			name: "azure provider gets a trailing slash",
+			provider: &api.RenovateProvider{
+				Name:     "azure",
+				Endpoint: "https://dev.azure.com/org",
+			},
+			expectedPlatform: "azure",
+			expectedEndpoint: "https://dev.azure.com/org/",
+		},
+		{
+			name: "azure provider with no endpoint",
+			provider: &api.RenovateProvider{
+				Name: "azure",
+			},
+			expectedPlatform: "azure",
+			expectedEndpoint: "",
+		},
 		{
 			name:             "nil provider",
 			provider:         nil,
@@ -141,7 +158,9 @@ func TestWebhookEndpointPath(t *testing.T) {
 		{platform: "gitlab", path: "/webhook/v1/gitlab"},
 		{platform: "forgejo", path: "/webhook/v1/forgejo"},
 		{platform: "gitea", path: "/webhook/v1/gitea"},
-		{platform: "bitbucket", wantErr: true},
+		{platform: "bitbucket-server", path: "/webhook/v1/bitbucket-server"},
+		{platform: "azure", path: "/webhook/v1/azure"},
+		{platform: "bitbucket", path: "/webhook/v1/bitbucket"},
 		{platform: "", wantErr: true},
 	}
 
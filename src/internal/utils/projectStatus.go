@@ -26,14 +26,20 @@ func GetUpdateStatusForProject(projectStatus *api.ProjectStatus, desiredStatus *
 }
 
 func validateProjectStatusScheduled(projectStatus *api.ProjectStatus, desiredStatus *types.RenovateStatusUpdate) *api.ProjectStatus {
-	// cannot schedule a project that is currently running
-	if projectStatus.Status != api.JobStatusRunning {
+	// cannot schedule a project that is currently running, or one still waiting
+	// for approval (see ApproveProjects)
+	if projectStatus.Status != api.JobStatusRunning && projectStatus.Status != api.JobStatusPendingApproval {
 		projectStatus.Status = api.JobStatusScheduled
 		projectStatus.LastTransition = v1.Now()
 		projectStatus.ExecutionOptions = desiredStatus.ExecutionOptions
 		if desiredStatus.Priority > projectStatus.Priority {
 			projectStatus.Priority = desiredStatus.Priority
 		}
+	} else if projectStatus.Status == api.JobStatusRunning && desiredStatus.RerunIfRunning {
+		projectStatus.RerunAfterCurrent = true
+		if desiredStatus.Priority > projectStatus.Priority {
+			projectStatus.Priority = desiredStatus.Priority
+		}
 	}
 	updateRenovateResultStatus(projectStatus, desiredStatus.RenovateResultStatus)
 	updatePRActivity(projectStatus, desiredStatus.PRActivity)
@@ -48,6 +54,7 @@ func validateProjectStatusRunning(projectStatus *api.ProjectStatus, desiredStatu
 		projectStatus.LastTransition = v1.Now()
 		projectStatus.Priority = 0
 		projectStatus.ExecutionOptions = nil
+		projectStatus.RerunAfterCurrent = false
 	}
 	projectStatus.Duration = nil
 	updateRenovateResultStatus(projectStatus, desiredStatus.RenovateResultStatus)
@@ -59,9 +66,7 @@ func validateProjectStatusRunning(projectStatus *api.ProjectStatus, desiredStatu
 func validateProjectStatusCompleted(projectStatus *api.ProjectStatus, desiredStatus *types.RenovateStatusUpdate) *api.ProjectStatus {
 	// can only set a running project to completed
 	if projectStatus.Status == api.JobStatusRunning {
-		projectStatus.Status = api.JobStatusCompleted
-		projectStatus.Priority = 0
-		projectStatus.LastTransition = v1.Now()
+		finishRun(projectStatus, api.JobStatusCompleted)
 	}
 	projectStatus.Duration = desiredStatus.Duration
 	updateRenovateResultStatus(projectStatus, desiredStatus.RenovateResultStatus)
@@ -73,9 +78,7 @@ func validateProjectStatusCompleted(projectStatus *api.ProjectStatus, desiredSta
 func validateProjectStatusFailed(projectStatus *api.ProjectStatus, desiredStatus *types.RenovateStatusUpdate) *api.ProjectStatus {
 	// can only set a running project to failed
 	if projectStatus.Status == api.JobStatusRunning {
-		projectStatus.Status = api.JobStatusFailed
-		projectStatus.Priority = 0
-		projectStatus.LastTransition = v1.Now()
+		finishRun(projectStatus, api.JobStatusFailed)
 	}
 	projectStatus.Duration = desiredStatus.Duration
 	updateRenovateResultStatus(projectStatus, desiredStatus.RenovateResultStatus)
@@ -90,6 +93,8 @@ func validateProjectStatusCancelled(projectStatus *api.ProjectStatus, desiredSta
 		projectStatus.Status = api.JobStatusCancelled
 		projectStatus.Priority = 0
 		projectStatus.LastTransition = v1.Now()
+		// a cancelled run is not repeated
+		projectStatus.RerunAfterCurrent = false
 	}
 	projectStatus.Duration = desiredStatus.Duration
 	updateRenovateResultStatus(projectStatus, desiredStatus.RenovateResultStatus)
@@ -98,6 +103,20 @@ func validateProjectStatusCancelled(projectStatus *api.ProjectStatus, desiredSta
 	return projectStatus
 }
 
+// finishRun moves a running project to its final status, or straight back to
+// scheduled when a rerun was requested during the run.
+func finishRun(projectStatus *api.ProjectStatus, status api.RenovateProjectStatus) {
+	projectStatus.LastTransition = v1.Now()
+	projectStatus.LastRunStatus = status
+	if projectStatus.RerunAfterCurrent {
+		projectStatus.Status = api.JobStatusScheduled
+		projectStatus.RerunAfterCurrent = false
+		return
+	}
+	projectStatus.Status = status
+	projectStatus.Priority = 0
+}
+
 func updateRenovateResultStatus(projectStatus *api.ProjectStatus, status *string) {
 	if status != nil {
 		projectStatus.RenovateResultStatus = status
@@ -38,6 +38,18 @@ func TestGetUpdateStatusForProject(t *testing.T) {
 			desiredStatus:  api.JobStatusCompleted,
 			expectedStatus: api.JobStatusScheduled,
 		},
+		{
+			name:           "Schedule from PendingApproval",
+			currentStatus:  api.JobStatusPendingApproval,
+			desiredStatus:  api.JobStatusScheduled,
+			expectedStatus: api.JobStatusPendingApproval,
+		},
+		{
+			name:           "Run from PendingApproval",
+			currentStatus:  api.JobStatusPendingApproval,
+			desiredStatus:  api.JobStatusRunning,
+			expectedStatus: api.JobStatusPendingApproval,
+		},
 		{
 			name:           "Fail from Running",
 			currentStatus:  api.JobStatusRunning,
@@ -116,3 +128,66 @@ func TestGetUpdateStatusForProject_Priority(t *testing.T) {
 		}
 	})
 }
+
+func TestGetUpdateStatusForProject_RerunAfterCurrent(t *testing.T) {
+	rerun := &types.RenovateStatusUpdate{Status: api.JobStatusScheduled, Priority: 1, RerunIfRunning: true}
+
+	t.Run("Scheduling a running project with RerunIfRunning flags it", func(t *testing.T) {
+		proj := &api.ProjectStatus{Name: "p", Status: api.JobStatusRunning}
+		result := GetUpdateStatusForProject(proj, rerun)
+		if result.Status != api.JobStatusRunning || !result.RerunAfterCurrent || result.Priority != 1 {
+			t.Errorf("expected a running project flagged for a rerun, got %+v", result)
+		}
+	})
+
+	t.Run("Scheduling an idle project does not flag it", func(t *testing.T) {
+		proj := &api.ProjectStatus{Name: "p", Status: api.JobStatusCompleted}
+		result := GetUpdateStatusForProject(proj, rerun)
+		if result.Status != api.JobStatusScheduled || result.RerunAfterCurrent {
+			t.Errorf("expected a plainly scheduled project, got %+v", result)
+		}
+	})
+
+	for _, finished := range []api.RenovateProjectStatus{api.JobStatusCompleted, api.JobStatusFailed} {
+		t.Run("Flagged project is scheduled again when "+string(finished), func(t *testing.T) {
+			proj := &api.ProjectStatus{Name: "p", Status: api.JobStatusRunning, Priority: 1, RerunAfterCurrent: true}
+			result := GetUpdateStatusForProject(proj, &types.RenovateStatusUpdate{Status: finished, RenovateResultStatus: new("done")})
+			if result.Status != api.JobStatusScheduled || result.RerunAfterCurrent || result.Priority != 1 {
+				t.Errorf("expected the project to be rescheduled once, got %+v", result)
+			}
+			if result.RenovateResultStatus == nil || *result.RenovateResultStatus != "done" {
+				t.Errorf("expected the result of the finished run to be kept, got %v", result.RenovateResultStatus)
+			}
+		})
+	}
+
+	t.Run("Cancelling drops the rerun", func(t *testing.T) {
+		proj := &api.ProjectStatus{Name: "p", Status: api.JobStatusRunning, Priority: 1, RerunAfterCurrent: true}
+		result := GetUpdateStatusForProject(proj, &types.RenovateStatusUpdate{Status: api.JobStatusCancelled})
+		if result.Status != api.JobStatusCancelled || result.RerunAfterCurrent || result.Priority != 0 {
+			t.Errorf("expected a cancelled project without rerun, got %+v", result)
+		}
+	})
+}
+
+func TestGetUpdateStatusForProject_LastRunStatus(t *testing.T) {
+	for _, finished := range []api.RenovateProjectStatus{api.JobStatusCompleted, api.JobStatusFailed} {
+		t.Run("Finished run records "+string(finished), func(t *testing.T) {
+			for _, rerun := range []bool{false, true} {
+				proj := &api.ProjectStatus{Name: "p", Status: api.JobStatusRunning, RerunAfterCurrent: rerun}
+				result := GetUpdateStatusForProject(proj, &types.RenovateStatusUpdate{Status: finished})
+				if result.LastRunStatus != finished {
+					t.Errorf("expected last run status %s (rerun %v), got %q", finished, rerun, result.LastRunStatus)
+				}
+			}
+		})
+	}
+
+	t.Run("Cancelled run keeps the previous result", func(t *testing.T) {
+		proj := &api.ProjectStatus{Name: "p", Status: api.JobStatusRunning, LastRunStatus: api.JobStatusFailed}
+		result := GetUpdateStatusForProject(proj, &types.RenovateStatusUpdate{Status: api.JobStatusCancelled})
+		if result.LastRunStatus != api.JobStatusFailed {
+			t.Errorf("expected the failed result to be kept, got %q", result.LastRunStatus)
+		}
+	})
+}
@@ -0,0 +1,74 @@
+package utils
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// RepositoryMatcher matches repository names against a list of exclusion
+// patterns. A pattern wrapped in slashes ("/^org/tmp-.*$/") is a regular
+// expression; anything else is a case-insensitive glob where "*" and "?" stay
+// within one path segment and "**" spans segments.
+type RepositoryMatcher struct {
+	patterns []*regexp.Regexp
+}
+
+// NewRepositoryMatcher compiles the given patterns. Invalid patterns are
+// skipped and reported in the returned error so callers can log them without
+// dropping the valid ones.
+func NewRepositoryMatcher(patterns []string) (*RepositoryMatcher, error) {
+	m := &RepositoryMatcher{patterns: make([]*regexp.Regexp, 0, len(patterns))}
+	var invalid []string
+	for _, pattern := range patterns {
+		re, err := compileRepositoryPattern(pattern)
+		if err != nil {
+			invalid = append(invalid, fmt.Sprintf("%q: %v", pattern, err))
+			continue
+		}
+		m.patterns = append(m.patterns, re)
+	}
+	if len(invalid) > 0 {
+		return m, fmt.Errorf("invalid repository patterns: %s", strings.Join(invalid, "; "))
+	}
+	return m, nil
+}
+
+// Matches reports whether the repository matches any of the patterns.
+func (m *RepositoryMatcher) Matches(repository string) bool {
+	for _, re := range m.patterns {
+		if re.MatchString(repository) {
+			return true
+		}
+	}
+	return false
+}
+
+func compileRepositoryPattern(pattern string) (*regexp.Regexp, error) {
+	if pattern == "" {
+		return nil, fmt.Errorf("empty pattern")
+	}
+	if len(pattern) > 2 && strings.HasPrefix(pattern, "/") && strings.HasSuffix(pattern, "/") {
+		return regexp.Compile(pattern[1 : len(pattern)-1])
+	}
+
+	var b strings.Builder
+	b.WriteString("(?i)^")
+	for i := 0; i < len(pattern); i++ {
+		switch c := pattern[i]; c {
+		case '*':
+			if i+1 < len(pattern) && pattern[i+1] == '*' {
+				b.WriteString(".*")
+				i++
+			} else {
+				b.WriteString("[^/]*")
+			}
+		case '?':
+			b.WriteString("[^/]")
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	b.WriteString("$")
+	return regexp.Compile(b.String())
+}
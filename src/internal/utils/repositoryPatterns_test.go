@@ -0,0 +1,47 @@
+package utils
+
+import "testing"
+
+func TestRepositoryMatcher(t *testing.T) {
+	tests := []struct {
+		name       string
+		patterns   []string
+		repository string
+		want       bool
+	}{
+		{name: "exact glob", patterns: []string{"org/repo"}, repository: "org/repo", want: true},
+		{name: "glob is case-insensitive", patterns: []string{"Org/Repo"}, repository: "org/repo", want: true},
+		{name: "star stays in segment", patterns: []string{"org/*"}, repository: "org/sub/repo", want: false},
+		{name: "star matches segment", patterns: []string{"org/legacy-*"}, repository: "org/legacy-api", want: true},
+		{name: "double star spans segments", patterns: []string{"group/**"}, repository: "group/sub/repo", want: true},
+		{name: "question mark", patterns: []string{"org/repo?"}, repository: "org/repo1", want: true},
+		{name: "glob is anchored", patterns: []string{"repo"}, repository: "org/repo", want: false},
+		{name: "dot is literal", patterns: []string{"org/a.b"}, repository: "org/axb", want: false},
+		{name: "regex", patterns: []string{"/^org/tmp-[0-9]+$/"}, repository: "org/tmp-42", want: true},
+		{name: "regex not anchored by default", patterns: []string{"/sandbox/"}, repository: "org/sandbox-x", want: true},
+		{name: "no patterns", patterns: nil, repository: "org/repo", want: false},
+		{name: "any pattern matches", patterns: []string{"other/*", "org/*"}, repository: "org/repo", want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := NewRepositoryMatcher(tt.patterns)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := m.Matches(tt.repository); got != tt.want {
+				t.Errorf("Matches(%q) = %v, want %v", tt.repository, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRepositoryMatcher_InvalidPatternKeepsValidOnes(t *testing.T) {
+	m, err := NewRepositoryMatcher([]string{"/[unclosed/", "org/*"})
+	if err == nil {
+		t.Fatal("expected an error for the invalid pattern")
+	}
+	if !m.Matches("org/repo") {
+		t.Error("expected the valid pattern to still match")
+	}
+}
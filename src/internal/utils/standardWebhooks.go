@@ -0,0 +1,39 @@
+package utils
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"strings"
+)
+
+// Standard Webhooks (https://www.standardwebhooks.com/) signing, shared by the
+// verification of inbound deliveries and the signing of outbound events.
+
+// DecodeStandardWebhookSigningKey returns the raw HMAC key for a Standard Webhooks signing secret. The
+// canonical form is "whsec_" + base64(key), as issued by Standard Webhooks senders (GitLab among them).
+// A bare value is base64-decoded when possible, otherwise used verbatim as the key.
+func DecodeStandardWebhookSigningKey(secret string) ([]byte, bool) {
+	if secret == "" {
+		return nil, false
+	}
+	if rest, found := strings.CutPrefix(secret, "whsec_"); found {
+		decoded, err := base64.StdEncoding.DecodeString(rest)
+		if err != nil {
+			return nil, false
+		}
+		return decoded, true
+	}
+	if decoded, err := base64.StdEncoding.DecodeString(secret); err == nil {
+		return decoded, true
+	}
+	return []byte(secret), true
+}
+
+// ComputeStandardWebhookSignature returns the base64 HMAC-SHA256 of signedContent, without the
+// "v1," version prefix.
+func ComputeStandardWebhookSignature(key []byte, signedContent string) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(signedContent))
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
@@ -1,6 +1,6 @@
 /*
-Package webhookSync keeps repository webhooks on the Git platform in sync with
-the projects of a RenovateJob. It is provider-agnostic: all platform access
+Package webhookSync keeps repository or organization webhooks on the Git
+platform in sync with the projects of a RenovateJob. It is provider-agnostic: all platform access
 goes through the gitProviderClients.GitProviderClient interface.
 
 Sync is stateless: the operator's hooks are identified by the platform
@@ -11,6 +11,10 @@ The host is deliberately excluded from that identity: it is configuration, not
 identity. A hook whose identity matches but whose delivery URL differs is one
 of ours that was written under a different base URL, and is reconciled in place
 rather than duplicated.
+
+The one piece of state the caller keeps is the list of removals that failed,
+which Sync and SyncOrganizations return so they can be retried on the next
+cycle instead of leaving the hook orphaned.
 */
 package webhookSync
 
@@ -18,6 +22,7 @@ import (
 	"context"
 	"fmt"
 	"net/url"
+	"slices"
 	"strings"
 	"sync"
 
@@ -42,10 +47,62 @@ type Options struct {
 // Sync ensures the operator's webhook exists on every project in desired and
 // removes the operator's webhook from the removed projects. Failures are
 // logged and skipped (fail open): a failed ensure is corrected on the next
-// cycle, a removal that fails is not retried: the orphaned hook is logged and
-// must be cleaned up manually (its deliveries are rejected by the operator).
-func Sync(ctx context.Context, logger logr.Logger, client gitProviderClients.GitProviderClient, opts Options, desired []string, removed []string) {
+// cycle. The removals that failed are returned for the caller to retry them,
+// as nothing else will bring those projects up again.
+func Sync(ctx context.Context, logger logr.Logger, client gitProviderClients.GitProviderClient, opts Options, desired []string, removed []string) Result {
+	return syncHooks(ctx, logger, repoHooks(client), opts, desired, removed)
+}
+
+// SyncOrganizations is Sync for organization webhooks: desired and removed
+// name organizations (GitLab groups, Bitbucket workspaces) instead of
+// projects.
+func SyncOrganizations(ctx context.Context, logger logr.Logger, client gitProviderClients.GitProviderClient, opts Options, desired []string, removed []string) Result {
+	return syncHooks(ctx, logger, orgHooks(client), opts, desired, removed)
+}
+
+// Result reports the outcome of a sync cycle per target.
+type Result struct {
+	// Ensured are the desired targets that carry the operator's hook.
+	Ensured []string
+	// FailedRemovals are the removed targets whose hook may remain.
+	FailedRemovals []string
+}
+
+// hookAPI is the part of the provider client that manages the hooks of one
+// kind of target, a repository or an organization.
+type hookAPI struct {
+	// kind is the log key of the target
+	kind   string
+	list   func(ctx context.Context, target string) ([]gitProviderClients.Webhook, error)
+	create func(ctx context.Context, target string, opts gitProviderClients.CreateWebhookOptions) (*gitProviderClients.Webhook, error)
+	update func(ctx context.Context, target, hookID string, opts gitProviderClients.CreateWebhookOptions) (*gitProviderClients.Webhook, error)
+	delete func(ctx context.Context, target, hookID string) error
+}
+
+func repoHooks(client gitProviderClients.GitProviderClient) hookAPI {
+	return hookAPI{
+		kind:   "repo",
+		list:   client.ListRepoWebhooks,
+		create: client.CreateRepoWebhook,
+		update: client.UpdateRepoWebhook,
+		delete: client.DeleteRepoWebhook,
+	}
+}
+
+func orgHooks(client gitProviderClients.GitProviderClient) hookAPI {
+	return hookAPI{
+		kind:   "organization",
+		list:   client.ListOrgWebhooks,
+		create: client.CreateOrgWebhook,
+		update: client.UpdateOrgWebhook,
+		delete: client.DeleteOrgWebhook,
+	}
+}
+
+func syncHooks(ctx context.Context, logger logr.Logger, hooks hookAPI, opts Options, desired []string, removed []string) Result {
 	var wg sync.WaitGroup
+	var mu sync.Mutex
+	var result Result
 	semaphore := make(chan struct{}, maxConcurrentRequests)
 
 	run := func(_ string, fn func()) {
@@ -58,21 +115,33 @@ func Sync(ctx context.Context, logger logr.Logger, client gitProviderClients.Git
 		}()
 	}
 
-	for _, project := range desired {
-		run(project, func() {
-			if err := ensureWebhook(ctx, logger, client, opts, project); err != nil {
-				logger.Error(err, "failed to ensure webhook", "repo", project)
+	for _, target := range desired {
+		run(target, func() {
+			if err := ensureWebhook(ctx, logger, hooks, opts, target); err != nil {
+				logger.Error(err, "failed to ensure webhook", hooks.kind, target)
+				return
 			}
+			mu.Lock()
+			result.Ensured = append(result.Ensured, target)
+			mu.Unlock()
 		})
 	}
 
-	for _, project := range removed {
-		run(project, func() {
-			removeWebhook(ctx, logger, client, opts.WebhookURL, project)
+	for _, target := range removed {
+		run(target, func() {
+			if removeWebhook(ctx, logger, hooks, opts.WebhookURL, target) {
+				return
+			}
+			mu.Lock()
+			result.FailedRemovals = append(result.FailedRemovals, target)
+			mu.Unlock()
 		})
 	}
 
 	wg.Wait()
+	slices.Sort(result.Ensured)
+	slices.Sort(result.FailedRemovals)
+	return result
 }
 
 // hookIdentity is the host-independent part of a delivery URL that marks a hook
@@ -100,11 +169,11 @@ func identityOf(rawURL string) (hookIdentity, error) {
 	}, nil
 }
 
-// ensureWebhook makes sure the operator's hook exists on the project with the
+// ensureWebhook makes sure the operator's hook exists on the target with the
 // desired configuration. An existing hook of ours whose delivery URL, event
 // subscription or active state drifted is updated in place. The auth token is
 // write-only on every platform and cannot be drift-checked.
-func ensureWebhook(ctx context.Context, logger logr.Logger, client gitProviderClients.GitProviderClient, opts Options, project string) error {
+func ensureWebhook(ctx context.Context, logger logr.Logger, hooks hookAPI, opts Options, target string) error {
 	desired := gitProviderClients.CreateWebhookOptions{
 		URL:       opts.WebhookURL,
 		AuthToken: opts.AuthToken,
@@ -116,63 +185,66 @@ func ensureWebhook(ctx context.Context, logger logr.Logger, client gitProviderCl
 		return fmt.Errorf("failed to parse webhook URL: %w", err)
 	}
 
-	hooks, err := client.ListRepoWebhooks(ctx, project)
+	existing, err := hooks.list(ctx, target)
 	if err != nil {
 		return err
 	}
-	for _, hook := range hooks {
+	for _, hook := range existing {
 		if found, err := identityOf(hook.URL); err != nil || found != wanted {
 			continue
 		}
 		if hook.URL == opts.WebhookURL && hook.Active && hook.EventsUpToDate {
 			return nil
 		}
-		if _, err := client.UpdateRepoWebhook(ctx, project, hook.ID, desired); err != nil {
+		if _, err := hooks.update(ctx, target, hook.ID, desired); err != nil {
 			return err
 		}
 		if hook.URL != opts.WebhookURL {
-			logger.Info("updated webhook whose delivery URL changed", "repo", project, "hookID", hook.ID, "from", hook.URL, "to", opts.WebhookURL)
+			logger.Info("updated webhook whose delivery URL changed", hooks.kind, target, "hookID", hook.ID, "from", hook.URL, "to", opts.WebhookURL)
 		} else {
-			logger.Info("updated webhook whose configuration drifted", "repo", project, "hookID", hook.ID)
+			logger.Info("updated webhook whose configuration drifted", hooks.kind, target, "hookID", hook.ID)
 		}
 		return nil
 	}
 
-	hook, err := client.CreateRepoWebhook(ctx, project, desired)
+	hook, err := hooks.create(ctx, target, desired)
 	if err != nil {
 		return err
 	}
-	logger.Info("created webhook on repo", "repo", project, "hookID", hook.ID)
+	logger.Info("created webhook on "+hooks.kind, hooks.kind, target, "hookID", hook.ID)
 	return nil
 }
 
-// removeWebhook deletes the operator's hook from the project, if present. It
-// matches the same identity as ensureWebhook, so a hook left behind under an
-// earlier base URL is cleaned up rather than orphaned.
-func removeWebhook(ctx context.Context, logger logr.Logger, client gitProviderClients.GitProviderClient, webhookURL, project string) {
+// removeWebhook deletes the operator's hook from the target, if present, and
+// reports whether the target is free of it. It matches the same identity as
+// ensureWebhook, so a hook left behind under an earlier base URL is cleaned up
+// rather than orphaned.
+func removeWebhook(ctx context.Context, logger logr.Logger, hooks hookAPI, webhookURL, target string) bool {
 	wanted, err := identityOf(webhookURL)
 	if err != nil {
-		logger.Error(err, "failed to parse webhook URL, skipping removal", "repo", project)
-		return
+		// retrying cannot fix the URL
+		logger.Error(err, "failed to parse webhook URL, skipping removal", hooks.kind, target)
+		return true
 	}
 
-	hooks, err := client.ListRepoWebhooks(ctx, project)
+	existing, err := hooks.list(ctx, target)
 	if err != nil {
-		// The repo may already be gone together with its hooks; anything else
-		// leaves the hook orphaned (harmless: its deliveries are rejected).
-		logger.Error(err, "failed to list webhooks for removal, the operator's hook may remain orphaned", "repo", project)
-		return
+		// The repo may already be gone together with its hooks, which the
+		// retries run into until the caller gives up.
+		logger.Error(err, "failed to list webhooks for removal, retrying on the next sync", hooks.kind, target)
+		return false
 	}
 
-	for _, hook := range hooks {
+	for _, hook := range existing {
 		if found, err := identityOf(hook.URL); err != nil || found != wanted {
 			continue
 		}
-		if err := client.DeleteRepoWebhook(ctx, project, hook.ID); err != nil {
-			logger.Error(err, "failed to remove webhook, it remains orphaned", "repo", project, "hookID", hook.ID)
-			return
+		if err := hooks.delete(ctx, target, hook.ID); err != nil {
+			logger.Error(err, "failed to remove webhook, retrying on the next sync", hooks.kind, target, "hookID", hook.ID)
+			return false
 		}
-		logger.Info("removed webhook from repo", "repo", project, "hookID", hook.ID)
-		return
+		logger.Info("removed webhook from "+hooks.kind, hooks.kind, target, "hookID", hook.ID)
+		return true
 	}
+	return true
 }
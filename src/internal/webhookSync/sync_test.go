@@ -3,6 +3,7 @@ package webhookSync
 import (
 	"context"
 	"fmt"
+	"slices"
 	"sync"
 	"testing"
 
@@ -38,6 +39,14 @@ func (f *fakeClient) GetRepositoryInfo(ctx context.Context, project string) (git
 	return gitProviderClients.RepositoryInfo{}, nil
 }
 
+func (f *fakeClient) ListRepositories(ctx context.Context, namespace string) ([]gitProviderClients.RepositoryListing, error) {
+	return nil, nil
+}
+
+func (f *fakeClient) ListChangedFiles(ctx context.Context, project, base, head string) ([]string, error) {
+	return nil, nil
+}
+
 func (f *fakeClient) ListRepoWebhooks(ctx context.Context, project string) ([]gitProviderClients.Webhook, error) {
 	f.mu.Lock()
 	defer f.mu.Unlock()
@@ -91,6 +100,25 @@ func (f *fakeClient) DeleteRepoWebhook(ctx context.Context, project string, hook
 	return fmt.Errorf("hook %s not found", hookID)
 }
 
+// Organization hooks are kept alongside the repository hooks, under "org:"
+// and the organization name.
+
+func (f *fakeClient) ListOrgWebhooks(ctx context.Context, org string) ([]gitProviderClients.Webhook, error) {
+	return f.ListRepoWebhooks(ctx, "org:"+org)
+}
+
+func (f *fakeClient) CreateOrgWebhook(ctx context.Context, org string, opts gitProviderClients.CreateWebhookOptions) (*gitProviderClients.Webhook, error) {
+	return f.CreateRepoWebhook(ctx, "org:"+org, opts)
+}
+
+func (f *fakeClient) UpdateOrgWebhook(ctx context.Context, org string, hookID string, opts gitProviderClients.CreateWebhookOptions) (*gitProviderClients.Webhook, error) {
+	return f.UpdateRepoWebhook(ctx, "org:"+org, hookID, opts)
+}
+
+func (f *fakeClient) DeleteOrgWebhook(ctx context.Context, org string, hookID string) error {
+	return f.DeleteRepoWebhook(ctx, "org:"+org, hookID)
+}
+
 var testOpts = Options{WebhookURL: "https://operator.example.com/webhook/v1/forgejo?job=a&namespace=b"}
 
 func TestSyncCreatesMissingWebhooks(t *testing.T) {
@@ -294,15 +322,66 @@ func TestSyncContinuesAfterEnsureFailure(t *testing.T) {
 	}
 }
 
-func TestSyncRemovalFailureDoesNotPanic(t *testing.T) {
+func TestSyncReportsFailedRemovals(t *testing.T) {
 	client := newFakeClient()
 	client.hooks["org/old"] = []gitProviderClients.Webhook{{ID: "3", URL: testOpts.WebhookURL}}
+	client.hooks["org/gone"] = []gitProviderClients.Webhook{{ID: "4", URL: testOpts.WebhookURL}}
+	client.hooks["org/fine"] = []gitProviderClients.Webhook{{ID: "5", URL: testOpts.WebhookURL}}
 	client.deleteErr = map[string]error{"org/old": fmt.Errorf("boom")}
+	client.listErr = map[string]error{"org/gone": fmt.Errorf("boom")}
 
-	// the failure is logged; the hook stays orphaned (documented behavior)
-	Sync(context.Background(), logr.Discard(), client, testOpts, nil, []string{"org/old"})
+	result := Sync(context.Background(), logr.Discard(), client, testOpts, nil, []string{"org/old", "org/gone", "org/fine", "org/none"})
 
 	if len(client.hooks["org/old"]) != 1 {
 		t.Error("expected hook to remain when deletion fails")
 	}
+	// a project without the hook is done, the failed ones are retried by the caller
+	if want := []string{"org/gone", "org/old"}; !slices.Equal(result.FailedRemovals, want) {
+		t.Errorf("expected failed removals %v, got %v", want, result.FailedRemovals)
+	}
+}
+
+func TestSyncReportsEnsuredTargets(t *testing.T) {
+	client := newFakeClient()
+	client.createErr = map[string]error{"org/b": fmt.Errorf("boom")}
+
+	result := Sync(context.Background(), logr.Discard(), client, testOpts, []string{"org/a", "org/b", "org/c"}, nil)
+
+	if want := []string{"org/a", "org/c"}; !slices.Equal(result.Ensured, want) {
+		t.Errorf("expected ensured %v, got %v", want, result.Ensured)
+	}
+}
+
+func TestSyncOrganizations(t *testing.T) {
+	client := newFakeClient()
+	orgOpts := Options{WebhookURL: testOpts.WebhookURL + "&scope=organization"}
+	client.hooks["org:old"] = []gitProviderClients.Webhook{{ID: "3", URL: orgOpts.WebhookURL}}
+	// a repository of the organization keeps its own hook
+	client.hooks["org/a"] = []gitProviderClients.Webhook{{ID: "4", URL: testOpts.WebhookURL}}
+
+	result := SyncOrganizations(context.Background(), logr.Discard(), client, orgOpts, []string{"org"}, []string{"old"})
+
+	if len(client.hooks["org:org"]) != 1 || client.hooks["org:org"][0].URL != orgOpts.WebhookURL {
+		t.Errorf("expected the organization hook to be created, got %+v", client.hooks["org:org"])
+	}
+	if len(client.hooks["org:old"]) != 0 {
+		t.Errorf("expected the hook of the removed organization to be deleted, got %+v", client.hooks["org:old"])
+	}
+	if len(client.hooks["org/a"]) != 1 {
+		t.Error("expected repository hooks to be left alone")
+	}
+	if !slices.Equal(result.Ensured, []string{"org"}) || len(result.FailedRemovals) != 0 {
+		t.Errorf("unexpected result %+v", result)
+	}
+}
+
+func TestSyncOrganizationsUnsupported(t *testing.T) {
+	client := newFakeClient()
+	client.listErr = map[string]error{"org:org": gitProviderClients.ErrOrgWebhooksUnsupported}
+
+	result := SyncOrganizations(context.Background(), logr.Discard(), client, testOpts, []string{"org"}, nil)
+
+	if len(result.Ensured) != 0 {
+		t.Errorf("expected nothing to be ensured, got %v", result.Ensured)
+	}
 }
@@ -26,10 +26,14 @@ const (
 	labelStatus    = "status"
 	labelKind      = "kind"
 	labelReason    = "reason"
+	labelChange    = "change"
 	labelResult    = "result"
 	labelLevel     = "level"
 	labelProvider  = "provider"
 	labelErrorType = "error_type"
+	labelSink      = "sink"
+	labelTarget    = "target"
+	labelEvent     = "event"
 	// labelPolicyCheck names which policy check refused an action
 	labelPolicyCheck = "check"
 )
@@ -157,6 +161,13 @@ var (
 			Help: "Total repositories dropped by discovery filters by reason",
 		},
 		[]string{labelNamespace, labelJob, labelReason})
+
+	discoveryProjectChanges = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "renovate_operator_discovery_project_changes_total",
+			Help: "Total projects added to or removed from a RenovateJob by discovery",
+		},
+		[]string{labelNamespace, labelJob, labelChange})
 )
 
 // Prometheus metrics — SRE: scheduler (Group D).
@@ -260,6 +271,43 @@ var (
 			Help: "Total webhook payloads that failed to decode by provider",
 		},
 		[]string{labelProvider})
+
+	webhookEventsCoalesced = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "renovate_operator_webhook_events_coalesced_total",
+			Help: "Total accepted webhook events merged into an already pending scheduling decision by provider",
+		},
+		[]string{labelProvider})
+)
+
+// Prometheus metrics — event stream (Group J).
+var (
+	eventDeliveries = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "renovate_operator_event_deliveries_total",
+			Help: "Total outbound event delivery attempts by sink and result",
+		},
+		[]string{labelSink, labelResult})
+)
+
+// Prometheus metrics — notifications (Group K).
+var (
+	notifications = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "renovate_operator_notifications_total",
+			Help: "Total chat and webhook notifications by target type, event and result",
+		},
+		[]string{labelTarget, labelEvent, labelResult})
+)
+
+// Prometheus metrics — digest reports (Group M).
+var (
+	reportDeliveries = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "renovate_operator_report_deliveries_total",
+			Help: "Total scheduled report deliveries by sink and result",
+		},
+		[]string{labelSink, labelResult})
 )
 
 // Prometheus metrics — SecOps: credential resolution (Group I).
@@ -306,6 +354,7 @@ var (
 	otelJobFailures, _       = otelMeter.Int64Counter("renovate_operator.job.failures", metric.WithDescription("Renovate Job failures by mode"))
 	otelDiscoveryJobs, _     = otelMeter.Int64Counter("renovate_operator.discovery.jobs", metric.WithDescription("Discovery Jobs by status"))
 	otelReposFiltered, _     = otelMeter.Int64Counter("renovate_operator.repositories.filtered", metric.WithDescription("Repositories dropped by filters"))
+	otelProjectChanges, _    = otelMeter.Int64Counter("renovate_operator.discovery.project_changes", metric.WithDescription("Projects added or removed by discovery"))
 	otelScheduleRuns, _      = otelMeter.Int64Counter("renovate_operator.schedule.runs", metric.WithDescription("Cron schedule firings by result"))
 	otelPRsCreated, _        = otelMeter.Int64Counter("renovate_operator.pull_requests.created", metric.WithDescription("Pull requests created"))
 	otelPRsMerged, _         = otelMeter.Int64Counter("renovate_operator.pull_requests.merged", metric.WithDescription("Pull requests automerged"))
@@ -314,6 +363,10 @@ var (
 	otelWebhookSigFail, _    = otelMeter.Int64Counter("renovate_operator.webhook.signature_verification.failures", metric.WithDescription("Webhook signature failures"))
 	otelWebhookAuthFail, _   = otelMeter.Int64Counter("renovate_operator.webhook.auth.failures", metric.WithDescription("Webhook auth failures"))
 	otelWebhookDecodeFail, _ = otelMeter.Int64Counter("renovate_operator.webhook.payload_decode.failures", metric.WithDescription("Webhook payload decode failures"))
+	otelWebhookCoalesced, _  = otelMeter.Int64Counter("renovate_operator.webhook.events.coalesced", metric.WithDescription("Webhook events merged into a pending scheduling decision"))
+	otelEventDeliveries, _   = otelMeter.Int64Counter("renovate_operator.event.deliveries", metric.WithDescription("Outbound event delivery attempts by result"))
+	otelNotifications, _     = otelMeter.Int64Counter("renovate_operator.notifications", metric.WithDescription("Chat and webhook notifications by result"))
+	otelReportDeliveries, _  = otelMeter.Int64Counter("renovate_operator.report.deliveries", metric.WithDescription("Scheduled report deliveries by result"))
 	otelSecretResolErrors, _ = otelMeter.Int64Counter("renovate_operator.secret.resolution.errors", metric.WithDescription("Secret resolution errors"))
 	otelPolicyDenials, _     = otelMeter.Int64Counter("renovate_operator.policy.denials", metric.WithDescription("RenovateJob actions refused by policy"))
 )
@@ -340,6 +393,7 @@ func Register(registry ctrlmetrics.RegistererGatherer) {
 		discoveryJobs,
 		discoveredRepositories,
 		repositoriesFiltered,
+		discoveryProjectChanges,
 		// Group D
 		scheduleRuns,
 		scheduleNextRun,
@@ -357,6 +411,13 @@ func Register(registry ctrlmetrics.RegistererGatherer) {
 		webhookSignatureFailures,
 		webhookAuthFailures,
 		webhookPayloadDecodeFailures,
+		webhookEventsCoalesced,
+		// Group J
+		eventDeliveries,
+		// Group K
+		notifications,
+		// Group M
+		reportDeliveries,
 		// Group I
 		secretResolutionErrors,
 		policyEnabled,
@@ -504,7 +565,7 @@ func SetDiscoveredRepositories(namespace, job string, count int) {
 	discoveredRepositories.WithLabelValues(namespace, job).Set(float64(count))
 }
 
-// AddRepositoriesFiltered counts repositories dropped by a filter. reason is "fork" or "pending_deletion".
+// AddRepositoriesFiltered counts repositories dropped by a filter, e.g. reason "fork" or "excluded".
 func AddRepositoriesFiltered(ctx context.Context, namespace, job, reason string, count int) {
 	if count <= 0 {
 		return
@@ -514,6 +575,18 @@ func AddRepositoriesFiltered(ctx context.Context, namespace, job, reason string,
 		attribute.String(labelNamespace, namespace), attribute.String(labelJob, job), attribute.String(labelReason, reason))
 }
 
+// AddDiscoveryProjectChanges counts projects a discovery added to or removed
+// from a RenovateJob. change is "added", "removed" or "pending_approval" (added,
+// but held back until approved).
+func AddDiscoveryProjectChanges(ctx context.Context, namespace, job, change string, count int) {
+	if count <= 0 {
+		return
+	}
+	discoveryProjectChanges.WithLabelValues(namespace, job, change).Add(float64(count))
+	addOtel(ctx, otelProjectChanges, int64(count),
+		attribute.String(labelNamespace, namespace), attribute.String(labelJob, job), attribute.String(labelChange, change))
+}
+
 // ---------------------------------------------------------------------------
 // Group D — scheduler
 // ---------------------------------------------------------------------------
@@ -617,7 +690,8 @@ func SetLastExecutionDuration(namespace, job, project string, seconds float64) {
 // Group H — webhook integrity
 // ---------------------------------------------------------------------------
 
-// IncWebhookRequest counts a webhook request. provider is github/gitlab/forgejo/schedule;
+// IncWebhookRequest counts a webhook request. provider is the platform handler
+// (github/gitlab/forgejo/gitea/bitbucket/bitbucket-server/azure) or schedule;
 // result is accepted/rejected/ignored.
 func IncWebhookRequest(ctx context.Context, provider, result string) {
 	webhookRequests.WithLabelValues(provider, result).Inc()
@@ -640,6 +714,46 @@ func IncWebhookPayloadDecodeFailure(ctx context.Context, provider string) {
 	addOtel(ctx, otelWebhookDecodeFail, 1, attribute.String(labelProvider, provider))
 }
 
+// IncWebhookEventCoalesced counts an accepted webhook event that joined a
+// scheduling decision already pending in the debounce window.
+func IncWebhookEventCoalesced(ctx context.Context, provider string) {
+	webhookEventsCoalesced.WithLabelValues(provider).Inc()
+	addOtel(ctx, otelWebhookCoalesced, 1, attribute.String(labelProvider, provider))
+}
+
+// ---------------------------------------------------------------------------
+// Group J — event stream
+// ---------------------------------------------------------------------------
+
+// IncEventDelivery counts an attempt to deliver an event to a sink. result is
+// delivered/retried/dropped.
+func IncEventDelivery(ctx context.Context, sink, result string) {
+	eventDeliveries.WithLabelValues(sink, result).Inc()
+	addOtel(ctx, otelEventDeliveries, 1, attribute.String(labelSink, sink), attribute.String(labelResult, result))
+}
+
+// ---------------------------------------------------------------------------
+// Group K — notifications
+// ---------------------------------------------------------------------------
+
+// IncNotification counts a notification for a route. result is
+// sent/failed/rate_limited.
+func IncNotification(ctx context.Context, target, event, result string) {
+	notifications.WithLabelValues(target, event, result).Inc()
+	addOtel(ctx, otelNotifications, 1, attribute.String(labelTarget, target), attribute.String(labelEvent, event), attribute.String(labelResult, result))
+}
+
+// ---------------------------------------------------------------------------
+// Group M — digest reports
+// ---------------------------------------------------------------------------
+
+// IncReportDelivery counts a scheduled report delivery to a sink. result is
+// sent/failed.
+func IncReportDelivery(ctx context.Context, sink, result string) {
+	reportDeliveries.WithLabelValues(sink, result).Inc()
+	addOtel(ctx, otelReportDeliveries, 1, attribute.String(labelSink, sink), attribute.String(labelResult, result))
+}
+
 // ---------------------------------------------------------------------------
 // Group I — credential resolution
 // ---------------------------------------------------------------------------
@@ -27,6 +27,14 @@ type Scheduler interface {
 	AddScheduleReplaceExisting(expr string, namespace, job string, fn func()) error
 	// Removes a schedule for the given RenovateJob.
 	RemoveSchedule(namespace, job string)
+	// Adds the separate discovery schedule of a RenovateJob, replacing any existing
+	// one. It is tracked apart from the run schedule, so both can coexist.
+	AddDiscoveryScheduleReplaceExisting(expr string, namespace, job string, fn func()) error
+	// Removes the discovery schedule for the given RenovateJob.
+	RemoveDiscoverySchedule(namespace, job string)
+	// Adds a schedule of the operator itself, not tied to a RenovateJob, replacing
+	// any existing one with the same name.
+	AddOperatorScheduleReplaceExisting(expr string, name string, fn func()) error
 	// Gets the next run time for a cron schedule expression.
 	// key is used as a seed for Jenkins-style H expressions; pass an empty string for plain cron.
 	GetNextRunOnSchedule(schedule, key string) time.Time
@@ -82,16 +90,41 @@ func scheduleName(namespace, job string) string {
 	return job + "-" + namespace
 }
 
+// discoveryScheduleName builds the key of a RenovateJob's discovery schedule. The
+// "/" cannot appear in a Kubernetes name, so it never collides with a run schedule.
+func discoveryScheduleName(namespace, job string) string {
+	return scheduleName(namespace, job) + "/discovery"
+}
+
+// ValidateSchedule reports whether expr is a cron expression the scheduler
+// accepts, including Jenkins-style H expressions.
+func ValidateSchedule(expr string) error {
+	// the key only seeds H expressions, any key validates them
+	_, err := cron.FullParser().ParseWithHashKey(expr, "validate")
+	return err
+}
+
+// operatorScheduleName builds the key of an operator-wide schedule. The "/"
+// cannot appear in a Kubernetes name, so it never collides with a job's schedule.
+func operatorScheduleName(name string) string {
+	return "operator/" + name
+}
+
 // Adds a new schedule, does NOT cleanly remove existing ones with the same name
 func (s *scheduler) AddSchedule(expr string, namespace, job string, fn func()) error {
+	return s.addSchedule(scheduleName(namespace, job), expr, namespace, job, true, fn)
+}
+
+// addSchedule registers fn under name. runMetrics controls whether the firing is
+// reported as the job's run schedule in the schedule metrics.
+func (s *scheduler) addSchedule(name, expr string, namespace, job string, runMetrics bool, fn func()) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
-	name := scheduleName(namespace, job)
 	sched, err := s.hashParser.ParseWithHashKey(expr, name)
 	if err != nil {
 		return err
 	}
-	id, err := s.cronManager.ScheduleJob(sched, cron.FuncJob(s.execute(name, expr, namespace, job, fn)))
+	id, err := s.cronManager.ScheduleJob(sched, cron.FuncJob(s.execute(name, expr, namespace, job, runMetrics, fn)))
 	if err != nil {
 		return err
 	}
@@ -111,7 +144,7 @@ func (s *scheduler) AddSchedule(expr string, namespace, job string, fn func()) e
 		return e
 	})
 	// emit next planned run metric (Group D); AddScheduleReplaceExisting delegates here.
-	if !nextRun.IsZero() {
+	if runMetrics && !nextRun.IsZero() {
 		metricStore.SetScheduleNextRun(namespace, job, float64(nextRun.Unix()))
 	}
 	return nil
@@ -119,7 +152,20 @@ func (s *scheduler) AddSchedule(expr string, namespace, job string, fn func()) e
 
 // Adds a new schedule, if one with the same name already exists, it will be replaced
 func (s *scheduler) AddScheduleReplaceExisting(expr string, namespace, job string, fn func()) error {
-	name := scheduleName(namespace, job)
+	return s.addScheduleReplaceExisting(scheduleName(namespace, job), expr, namespace, job, true, fn)
+}
+
+// Adds the discovery schedule of a RenovateJob, if one already exists, it will be replaced
+func (s *scheduler) AddDiscoveryScheduleReplaceExisting(expr string, namespace, job string, fn func()) error {
+	return s.addScheduleReplaceExisting(discoveryScheduleName(namespace, job), expr, namespace, job, false, fn)
+}
+
+// Adds an operator-wide schedule, if one with the same name already exists, it will be replaced
+func (s *scheduler) AddOperatorScheduleReplaceExisting(expr string, name string, fn func()) error {
+	return s.addScheduleReplaceExisting(operatorScheduleName(name), expr, "", "", false, fn)
+}
+
+func (s *scheduler) addScheduleReplaceExisting(name, expr string, namespace, job string, runMetrics bool, fn func()) error {
 	s.mu.Lock()
 	entry, exists := s.entries[name]
 	s.mu.Unlock()
@@ -129,14 +175,22 @@ func (s *scheduler) AddScheduleReplaceExisting(expr string, namespace, job strin
 			return nil // Schedule already exists with the same expression
 		}
 		// If the schedule exists but with a different expression, remove it first
-		s.RemoveSchedule(namespace, job)
+		s.removeSchedule(name)
 	}
-	return s.AddSchedule(expr, namespace, job, fn)
+	return s.addSchedule(name, expr, namespace, job, runMetrics, fn)
 }
+
 func (s *scheduler) RemoveSchedule(namespace, job string) {
+	s.removeSchedule(scheduleName(namespace, job))
+}
+
+func (s *scheduler) RemoveDiscoverySchedule(namespace, job string) {
+	s.removeSchedule(discoveryScheduleName(namespace, job))
+}
+
+func (s *scheduler) removeSchedule(name string) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
-	name := scheduleName(namespace, job)
 	if entry, ok := s.entries[name]; ok {
 		s.cronManager.Remove(entry.entryId)
 		delete(s.entries, name)
@@ -158,8 +212,9 @@ func (s *scheduler) GetNextRunOnSchedule(schedule, key string) time.Time {
 
 // execute the cron expression while also adapting the health status in this time.
 // key is the internal schedule/health key; ns and job are the RenovateJob's
-// namespace and name, used as metric labels.
-func (s *scheduler) execute(key string, schedule string, ns, job string, fn func()) func() {
+// namespace and name, used as metric labels. Metrics are only emitted when
+// runMetrics is set, so a discovery schedule does not count as a run.
+func (s *scheduler) execute(key string, schedule string, ns, job string, runMetrics bool, fn func()) func() {
 	return func() {
 		// The scheduled function is a bare func() with no error return, so the only
 		// failure signal available without changing the public Scheduler signature is
@@ -187,17 +242,21 @@ func (s *scheduler) execute(key string, schedule string, ns, job string, fn func
 			return e
 		})
 		// refresh next planned run metric (Group D) as the schedule fires.
-		if !nextRun.IsZero() {
+		if runMetrics && !nextRun.IsZero() {
 			metricStore.SetScheduleNextRun(ns, job, float64(nextRun.Unix()))
 		}
 
 		s.logger.Info("executing schedule", "schedule", key)
 		defer func() {
 			if r := recover(); r != nil {
-				metricStore.IncScheduleRun(ctx, ns, job, "error")
+				if runMetrics {
+					metricStore.IncScheduleRun(ctx, ns, job, "error")
+				}
 				panic(r) // preserve existing panic-propagation behavior
 			}
-			metricStore.IncScheduleRun(ctx, ns, job, "success")
+			if runMetrics {
+				metricStore.IncScheduleRun(ctx, ns, job, "success")
+			}
 		}()
 		fn()
 		s.logger.Info("schedule executed", "schedule", key)
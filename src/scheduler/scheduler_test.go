@@ -318,3 +318,75 @@ func TestAddScheduleReplaceExistingHashedCronSameExprNotReAdded(t *testing.T) {
 		t.Errorf("expected exactly 1 schedule entry, got %d", len(hc.Scheduler.Scheduler))
 	}
 }
+
+func TestDiscoveryScheduleIsTrackedSeparately(t *testing.T) {
+	h := health.NewHealthCheck()
+	s := NewScheduler(testLogger, h)
+	s.Start()
+	defer s.Stop()
+
+	if err := s.AddScheduleReplaceExisting("* * * * *", "ns", "test", func() {}); err != nil {
+		t.Fatalf("AddScheduleReplaceExisting returned error: %v", err)
+	}
+	if err := s.AddDiscoveryScheduleReplaceExisting("0 0 * * 1", "ns", "test", func() {}); err != nil {
+		t.Fatalf("AddDiscoveryScheduleReplaceExisting returned error: %v", err)
+	}
+
+	hc := h.GetHealth()
+	if len(hc.Scheduler.Scheduler) != 2 {
+		t.Fatalf("expected run and discovery schedule, got %d entries", len(hc.Scheduler.Scheduler))
+	}
+	if got := hc.Scheduler.Scheduler["test-ns/discovery"].Schedule; got != "0 0 * * 1" {
+		t.Errorf("discovery schedule = %q, want %q", got, "0 0 * * 1")
+	}
+
+	s.RemoveDiscoverySchedule("ns", "test")
+
+	hc = h.GetHealth()
+	if _, exists := hc.Scheduler.Scheduler["test-ns/discovery"]; exists {
+		t.Error("discovery schedule should be removed")
+	}
+	if _, exists := hc.Scheduler.Scheduler["test-ns"]; !exists {
+		t.Error("removing the discovery schedule must keep the run schedule")
+	}
+}
+
+func TestOperatorScheduleIsTrackedSeparately(t *testing.T) {
+	h := health.NewHealthCheck()
+	s := NewScheduler(testLogger, h)
+	s.Start()
+	defer s.Stop()
+
+	if err := s.AddScheduleReplaceExisting("* * * * *", "reports", "operator", func() {}); err != nil {
+		t.Fatalf("AddScheduleReplaceExisting returned error: %v", err)
+	}
+	if err := s.AddOperatorScheduleReplaceExisting("0 8 * * 1", "reports", func() {}); err != nil {
+		t.Fatalf("AddOperatorScheduleReplaceExisting returned error: %v", err)
+	}
+
+	hc := h.GetHealth()
+	if len(hc.Scheduler.Scheduler) != 2 {
+		t.Fatalf("expected the job and the operator schedule, got %d entries", len(hc.Scheduler.Scheduler))
+	}
+	if got := hc.Scheduler.Scheduler["operator/reports"].Schedule; got != "0 8 * * 1" {
+		t.Errorf("operator schedule = %q, want %q", got, "0 8 * * 1")
+	}
+
+	if err := s.AddOperatorScheduleReplaceExisting("0 9 * * 1", "reports", func() {}); err != nil {
+		t.Fatalf("AddOperatorScheduleReplaceExisting returned error: %v", err)
+	}
+	if got := h.GetHealth().Scheduler.Scheduler["operator/reports"].Schedule; got != "0 9 * * 1" {
+		t.Errorf("expected the operator schedule to be replaced, got %q", got)
+	}
+}
+
+func TestValidateSchedule(t *testing.T) {
+	for _, expr := range []string{"0 8 * * 1", "H H * * 1", "@weekly"} {
+		if err := ValidateSchedule(expr); err != nil {
+			t.Errorf("expected %q to be valid, got %v", expr, err)
+		}
+	}
+	if err := ValidateSchedule("every monday"); err == nil {
+		t.Error("expected an invalid expression to be rejected")
+	}
+}
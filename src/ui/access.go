@@ -66,6 +66,9 @@ const (
 	roleNone accessRole = iota
 	// roleReader may read the job but not act on it.
 	roleReader
+	// roleCustom may read the job and take the actions a custom role or RBAC
+	// granted on it, but not all of them.
+	roleCustom
 	// roleAdmin may read the job and trigger, cancel and reconfigure its runs.
 	roleAdmin
 )
@@ -74,6 +77,8 @@ func (r accessRole) String() string {
 	switch r {
 	case roleReader:
 		return "reader"
+	case roleCustom:
+		return "custom"
 	case roleAdmin:
 		return "admin"
 	default:
@@ -88,8 +93,23 @@ const (
 	permLogs       = "logs"
 	permTrigger    = "trigger"
 	permTriggerAll = "triggerAll"
-	permCancel     = "cancel"
-	permDiscovery  = "discovery"
+	// permTriggerDebug is needed on top of permTrigger or permTriggerAll to
+	// run with debug logging, which writes far more into the logs.
+	permTriggerDebug = "triggerDebug"
+	permCancel       = "cancel"
+	permDiscovery    = "discovery"
+	// permApprove covers releasing projects held for onboarding approval,
+	// which admits new repositories to the job rather than rerunning known ones.
+	permApprove = "approve"
+	// permWebhookDeliveries covers browsing and replaying a job's webhook
+	// deliveries, whose payloads are not limited to what readers may see.
+	permWebhookDeliveries = "webhookDeliveries"
+	// permEdit covers changing and deleting the job itself, when job editing
+	// is enabled.
+	permEdit = "edit"
+	// permAudit covers reading the audit log of the job, which names who
+	// acted on it.
+	permAudit = "audit"
 )
 
 // AccessDefaults are the operator-wide fallbacks for jobs that leave parts of
@@ -101,6 +121,9 @@ type AccessDefaults struct {
 	AdminUsers        []string
 	AnonymousRead     bool
 	AnonymousReadLogs bool
+	// Roles are the custom roles jobs can bind, by name, each as the
+	// permissions it grants. See ParseRoles.
+	Roles map[string][]string
 	// AuthorizationDisabled turns every authenticated request into an admin and
 	// stops group and user rules from being evaluated at all. It is spelled
 	// negatively, like policy.Disabled, so the zero value enforces and a test
@@ -165,12 +188,23 @@ func jobConfiguresGroups(job *api.RenovateJob) bool {
 	if job.Spec.Access == nil {
 		return false
 	}
-	return len(job.Spec.Access.ReaderGroups) > 0 || len(job.Spec.Access.AdminGroups) > 0
+	if len(job.Spec.Access.ReaderGroups) > 0 || len(job.Spec.Access.AdminGroups) > 0 {
+		return true
+	}
+	return slices.ContainsFunc(job.Spec.Access.RoleBindings, func(b api.RenovateJobRoleBinding) bool {
+		return len(b.Groups) > 0
+	})
 }
 
 type accessDecision struct {
 	Role        accessRole
 	CanViewLogs bool
+	// scope narrows the permissions to those an API token was granted on the
+	// job, or RBAC allows on it. nil when the role alone decides.
+	scope []string
+	// reviews, when set, asks RBAC for each permission the first time it is
+	// needed instead of the role granting it.
+	reviews *rbacReviews
 }
 
 func (d accessDecision) canRead() bool  { return d.Role != roleNone }
@@ -178,24 +212,72 @@ func (d accessDecision) canWrite() bool { return d.Role == roleAdmin }
 
 // permissions lists the actions this decision allows, for the UI to gate on.
 func (d accessDecision) permissions() []string {
-	perms := make([]string, 0, 5)
-	if d.CanViewLogs {
+	perms := make([]string, 0, 10)
+	if d.CanViewLogs || d.reviews != nil {
 		perms = append(perms, permLogs)
 	}
-	if d.canWrite() {
-		perms = append(perms, permTrigger, permTriggerAll, permCancel, permDiscovery)
+	if d.Role >= roleCustom || d.reviews != nil {
+		perms = append(perms, permTrigger, permTriggerAll, permTriggerDebug, permCancel, permDiscovery, permApprove, permWebhookDeliveries, permEdit, permAudit)
+	}
+	if d.scope != nil {
+		perms = slices.DeleteFunc(perms, func(p string) bool { return !slices.Contains(d.scope, p) })
+	}
+	if d.reviews != nil {
+		perms = d.reviews.granted(perms)
 	}
 	return perms
 }
 
+// has reports whether the decision allows permission. Unlike permissions, it
+// only has RBAC review the one permission asked for.
 func (d accessDecision) has(permission string) bool {
+	if d.reviews != nil {
+		if d.scope != nil && !slices.Contains(d.scope, permission) {
+			return false
+		}
+		return len(d.reviews.granted([]string{permission})) == 1
+	}
 	return slices.Contains(d.permissions(), permission)
 }
 
+// complete returns the decision with the role its permissions amount to. A
+// decision RBAC reviews lazily only knows it may read until every permission
+// has been reviewed.
+func (d accessDecision) complete() accessDecision {
+	if d.reviews == nil {
+		return d
+	}
+	return grantedDecision(d.permissions())
+}
+
 func adminDecision() accessDecision {
 	return accessDecision{Role: roleAdmin, CanViewLogs: true}
 }
 
+// isFullAdmin reports whether decision allows everything an admin may do,
+// rather than the part of it a custom role, RBAC or an API token grants.
+func isFullAdmin(decision accessDecision) bool {
+	return slices.Equal(decision.permissions(), adminDecision().permissions())
+}
+
+// grantedDecision is the access a set of granted permissions amounts to: read
+// access, custom as soon as anything beyond logs is granted, with the scope
+// keeping it to what was granted, and admin once every permission is.
+func grantedDecision(granted []string) accessDecision {
+	scope := slices.Compact(slices.Sorted(slices.Values(granted)))
+	if scope == nil {
+		scope = []string{}
+	}
+	decision := accessDecision{Role: roleReader, CanViewLogs: slices.Contains(scope, permLogs), scope: scope}
+	switch {
+	case !slices.ContainsFunc(adminDecision().permissions(), func(p string) bool { return !slices.Contains(scope, p) }):
+		return adminDecision()
+	case slices.ContainsFunc(scope, func(p string) bool { return p != permLogs }):
+		decision.Role = roleCustom
+	}
+	return decision
+}
+
 type effectiveAccess struct {
 	readerGroups      []string
 	adminGroups       []string
@@ -203,6 +285,7 @@ type effectiveAccess struct {
 	adminUsers        []string
 	anonymousRead     bool
 	anonymousReadLogs bool
+	roleBindings      []api.RenovateJobRoleBinding
 }
 
 func resolveEffectiveAccess(job *api.RenovateJob, defaults AccessDefaults) effectiveAccess {
@@ -246,6 +329,7 @@ func resolveEffectiveAccess(job *api.RenovateJob, defaults AccessDefaults) effec
 	if access.AnonymousReadLogs != nil {
 		eff.anonymousReadLogs = *access.AnonymousReadLogs
 	}
+	eff.roleBindings = access.RoleBindings
 
 	return eff
 }
@@ -258,8 +342,9 @@ var conflictingAccessLogged sync.Map
 
 // resolveAccess evaluates a session against a job's effective access
 // configuration. A nil session represents a request without authentication,
-// which only anonymous read can satisfy.
-func resolveAccess(job *api.RenovateJob, session *sessionData, defaults AccessDefaults, logger logr.Logger) accessDecision {
+// which only anonymous read can satisfy. serviceAccount reports that the
+// session stands in for a ServiceAccount token.
+func resolveAccess(job *api.RenovateJob, session *sessionData, serviceAccount bool, defaults AccessDefaults, logger logr.Logger) accessDecision {
 	if job == nil {
 		return accessDecision{}
 	}
@@ -294,14 +379,23 @@ func resolveAccess(job *api.RenovateJob, session *sessionData, defaults AccessDe
 	if session != nil {
 		userGroups = normalizeGroups(session.Groups)
 	}
-	identities := session.identities()
+	identities := session.identities(serviceAccount)
 
-	switch {
-	case hasIntersection(identities, eff.adminUsers),
-		hasIntersection(userGroups, eff.adminGroups):
+	if hasIntersection(identities, eff.adminUsers) || hasIntersection(userGroups, eff.adminGroups) {
 		return accessDecision{Role: roleAdmin, CanViewLogs: true}
-	case hasIntersection(identities, eff.readerUsers),
-		hasIntersection(userGroups, eff.readerGroups):
+	}
+	reader := hasIntersection(identities, eff.readerUsers) || hasIntersection(userGroups, eff.readerGroups)
+
+	// Bound roles add up with each other and with the reader lists.
+	if granted, bound := boundPermissions(job, eff.roleBindings, identities, userGroups, defaults.Roles, logger); bound {
+		if reader || (eff.anonymousRead && eff.anonymousReadLogs) {
+			granted = append(granted, permLogs)
+		}
+		return grantedDecision(granted)
+	}
+
+	switch {
+	case reader:
 		return accessDecision{Role: roleReader, CanViewLogs: true}
 	case eff.anonymousRead:
 		// Renovate logs are unredacted, so anonymous readers need a second opt-in.
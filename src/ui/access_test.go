@@ -7,6 +7,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"slices"
+	"strings"
 	"testing"
 	"time"
 
@@ -38,7 +39,7 @@ func TestResolveAccess(t *testing.T) {
 			job:             &api.RenovateJob{Spec: api.RenovateJobSpec{Access: &api.RenovateJobAccess{AdminGroups: []string{"team-admin"}}}},
 			session:         &sessionData{Groups: []string{"team-admin"}},
 			wantRole:        roleAdmin,
-			wantPermissions: []string{permLogs, permTrigger, permTriggerAll, permCancel, permDiscovery},
+			wantPermissions: []string{permLogs, permTrigger, permTriggerAll, permTriggerDebug, permCancel, permDiscovery, permApprove, permWebhookDeliveries, permEdit, permAudit},
 		},
 		{
 			name:            "reader group grants logs only",
@@ -71,7 +72,7 @@ func TestResolveAccess(t *testing.T) {
 			session:         &sessionData{Email: "nobody@example.com", Groups: []string{"team-unrelated"}},
 			defaults:        AccessDefaults{AuthorizationDisabled: true},
 			wantRole:        roleAdmin,
-			wantPermissions: []string{permLogs, permTrigger, permTriggerAll, permCancel, permDiscovery},
+			wantPermissions: []string{permLogs, permTrigger, permTriggerAll, permTriggerDebug, permCancel, permDiscovery, permApprove, permWebhookDeliveries, permEdit, permAudit},
 		},
 		{
 			name:            "authorization disabled grants a session admin on an unconfigured job",
@@ -79,7 +80,7 @@ func TestResolveAccess(t *testing.T) {
 			session:         &sessionData{Email: "nobody@example.com"},
 			defaults:        AccessDefaults{AuthorizationDisabled: true},
 			wantRole:        roleAdmin,
-			wantPermissions: []string{permLogs, permTrigger, permTriggerAll, permCancel, permDiscovery},
+			wantPermissions: []string{permLogs, permTrigger, permTriggerAll, permTriggerDebug, permCancel, permDiscovery, permApprove, permWebhookDeliveries, permEdit, permAudit},
 		},
 		{
 			name:            "authorization disabled still denies requests without a session",
@@ -104,14 +105,14 @@ func TestResolveAccess(t *testing.T) {
 			session:         &sessionData{Email: "nobody@example.com"},
 			defaults:        AccessDefaults{AuthorizationDisabled: true},
 			wantRole:        roleAdmin,
-			wantPermissions: []string{permLogs, permTrigger, permTriggerAll, permCancel, permDiscovery},
+			wantPermissions: []string{permLogs, permTrigger, permTriggerAll, permTriggerDebug, permCancel, permDiscovery, permApprove, permWebhookDeliveries, permEdit, permAudit},
 		},
 		{
 			name:            "admin user matched by email",
 			job:             &api.RenovateJob{Spec: api.RenovateJobSpec{Access: &api.RenovateJobAccess{AdminUsers: []string{"me@example.com"}}}},
 			session:         &sessionData{Email: "me@example.com", EmailVerified: true},
 			wantRole:        roleAdmin,
-			wantPermissions: []string{permLogs, permTrigger, permTriggerAll, permCancel, permDiscovery},
+			wantPermissions: []string{permLogs, permTrigger, permTriggerAll, permTriggerDebug, permCancel, permDiscovery, permApprove, permWebhookDeliveries, permEdit, permAudit},
 		},
 		{
 			// The homelab case: a personal GitHub account is in no org, so it has
@@ -120,14 +121,14 @@ func TestResolveAccess(t *testing.T) {
 			job:             &api.RenovateJob{Spec: api.RenovateJobSpec{Access: &api.RenovateJobAccess{AdminUsers: []string{"octocat"}}}},
 			session:         &sessionData{Email: "octocat@github", Username: "octocat", EmailVerified: true},
 			wantRole:        roleAdmin,
-			wantPermissions: []string{permLogs, permTrigger, permTriggerAll, permCancel, permDiscovery},
+			wantPermissions: []string{permLogs, permTrigger, permTriggerAll, permTriggerDebug, permCancel, permDiscovery, permApprove, permWebhookDeliveries, permEdit, permAudit},
 		},
 		{
 			name:            "user match is case-insensitive",
 			job:             &api.RenovateJob{Spec: api.RenovateJobSpec{Access: &api.RenovateJobAccess{AdminUsers: []string{"Me@Example.COM"}}}},
 			session:         &sessionData{Email: "me@example.com", EmailVerified: true},
 			wantRole:        roleAdmin,
-			wantPermissions: []string{permLogs, permTrigger, permTriggerAll, permCancel, permDiscovery},
+			wantPermissions: []string{permLogs, permTrigger, permTriggerAll, permTriggerDebug, permCancel, permDiscovery, permApprove, permWebhookDeliveries, permEdit, permAudit},
 		},
 		{
 			name:            "reader user grants logs only",
@@ -157,7 +158,7 @@ func TestResolveAccess(t *testing.T) {
 			job:             &api.RenovateJob{Spec: api.RenovateJobSpec{Access: &api.RenovateJobAccess{AdminUsers: []string{"octocat"}}}},
 			session:         &sessionData{Email: "spoofed@example.com", Username: "octocat", EmailVerified: false},
 			wantRole:        roleAdmin,
-			wantPermissions: []string{permLogs, permTrigger, permTriggerAll, permCancel, permDiscovery},
+			wantPermissions: []string{permLogs, permTrigger, permTriggerAll, permTriggerDebug, permCancel, permDiscovery, permApprove, permWebhookDeliveries, permEdit, permAudit},
 		},
 		{
 			// An empty identity must never match an empty configured entry.
@@ -173,7 +174,7 @@ func TestResolveAccess(t *testing.T) {
 			session:         &sessionData{Email: "me@example.com", EmailVerified: true, Groups: nil},
 			defaults:        AccessDefaults{AdminUsers: []string{"other@example.com"}},
 			wantRole:        roleAdmin,
-			wantPermissions: []string{permLogs, permTrigger, permTriggerAll, permCancel, permDiscovery},
+			wantPermissions: []string{permLogs, permTrigger, permTriggerAll, permTriggerDebug, permCancel, permDiscovery, permApprove, permWebhookDeliveries, permEdit, permAudit},
 		},
 		{
 			name:            "default admin users apply when the job sets none",
@@ -181,14 +182,14 @@ func TestResolveAccess(t *testing.T) {
 			session:         &sessionData{Email: "me@example.com", EmailVerified: true},
 			defaults:        AccessDefaults{AdminUsers: []string{"me@example.com"}},
 			wantRole:        roleAdmin,
-			wantPermissions: []string{permLogs, permTrigger, permTriggerAll, permCancel, permDiscovery},
+			wantPermissions: []string{permLogs, permTrigger, permTriggerAll, permTriggerDebug, permCancel, permDiscovery, permApprove, permWebhookDeliveries, permEdit, permAudit},
 		},
 		{
 			name:            "admin user outranks a reader group match",
 			job:             &api.RenovateJob{Spec: api.RenovateJobSpec{Access: &api.RenovateJobAccess{AdminUsers: []string{"me@example.com"}, ReaderGroups: []string{"team-reader"}}}},
 			session:         &sessionData{Email: "me@example.com", EmailVerified: true, Groups: []string{"team-reader"}},
 			wantRole:        roleAdmin,
-			wantPermissions: []string{permLogs, permTrigger, permTriggerAll, permCancel, permDiscovery},
+			wantPermissions: []string{permLogs, permTrigger, permTriggerAll, permTriggerDebug, permCancel, permDiscovery, permApprove, permWebhookDeliveries, permEdit, permAudit},
 		},
 		{
 			name:            "operator defaults fill in unset job fields",
@@ -196,7 +197,7 @@ func TestResolveAccess(t *testing.T) {
 			session:         &sessionData{Groups: []string{"team-default-admin"}},
 			defaults:        AccessDefaults{AdminGroups: []string{"team-default-admin"}},
 			wantRole:        roleAdmin,
-			wantPermissions: []string{permLogs, permTrigger, permTriggerAll, permCancel, permDiscovery},
+			wantPermissions: []string{permLogs, permTrigger, permTriggerAll, permTriggerDebug, permCancel, permDiscovery, permApprove, permWebhookDeliveries, permEdit, permAudit},
 		},
 		{
 			// Inheritance is per field and REPLACES, it does not merge: a job that
@@ -238,7 +239,7 @@ func TestResolveAccess(t *testing.T) {
 			job:             &api.RenovateJob{Spec: api.RenovateJobSpec{AllowedGroups: []string{"team-legacy"}}}, //nolint:staticcheck // deprecated field is intentionally still honoured
 			session:         &sessionData{Groups: []string{"team-legacy"}},
 			wantRole:        roleAdmin,
-			wantPermissions: []string{permLogs, permTrigger, permTriggerAll, permCancel, permDiscovery},
+			wantPermissions: []string{permLogs, permTrigger, permTriggerAll, permTriggerDebug, permCancel, permDiscovery, permApprove, permWebhookDeliveries, permEdit, permAudit},
 		},
 		{
 			name: "deprecated allowedGroups next to access fails closed",
@@ -262,7 +263,7 @@ func TestResolveAccess(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			decision := resolveAccess(tt.job, tt.session, tt.defaults, logr.Discard())
+			decision := resolveAccess(tt.job, tt.session, false, tt.defaults, logr.Discard())
 
 			if decision.Role != tt.wantRole {
 				t.Errorf("role = %q, want %q", decision.Role.String(), tt.wantRole.String())
@@ -289,58 +290,97 @@ func TestWriteRoutesRequireAdmin(t *testing.T) {
 				return job, nil
 			},
 		},
-		logger:    logr.Discard(),
-		discovery: &mockDiscoveryAgent{},
-		scheduler: &mockScheduler{},
-		auth:      &OIDCAuth{},
-		Router:    mux.NewRouter(),
+		logger:     logr.Discard(),
+		discovery:  &mockDiscoveryAgent{},
+		scheduler:  &mockScheduler{},
+		auth:       &OIDCAuth{},
+		deliveries: &mockWebhookDeliveries{},
+		editing:    &JobEditing{},
+		Router:     mux.NewRouter(),
 	}
+	server.SetAPITokens(newTokenStore(t), time.Hour)
 	server.registerApiV1Routes(server.Router)
 
-	var postPaths []string
+	var writeRoutes []string
 	err := server.Router.Walk(func(route *mux.Route, _ *mux.Router, _ []*mux.Route) error {
 		methods, methodsErr := route.GetMethods()
-		if methodsErr != nil || !slices.Contains(methods, http.MethodPost) {
+		if methodsErr != nil {
 			return nil
 		}
 		path, pathErr := route.GetPathTemplate()
 		if pathErr != nil {
 			return pathErr
 		}
-		postPaths = append(postPaths, path)
+		for _, method := range methods {
+			if method == http.MethodPost || method == http.MethodPut || method == http.MethodDelete {
+				writeRoutes = append(writeRoutes, method+" "+path)
+			}
+		}
 		return nil
 	})
 	if err != nil {
 		t.Fatalf("failed to walk routes: %v", err)
 	}
 
-	wantPaths := []string{
-		"/api/v1/renovate",
-		"/api/v1/renovate/all",
-		"/api/v1/renovate/cancel",
-		"/api/v1/discovery/start",
+	wantRoutes := []string{
+		"POST /api/v1/renovate",
+		"POST /api/v1/renovate/all",
+		"POST /api/v1/renovate/cancel",
+		"POST /api/v1/renovate/approve",
+		"POST /api/v1/discovery/start",
+		"POST /api/v1/webhook/deliveries/replay",
+		"POST /api/v1/renovatejobs",
+		"PUT /api/v1/renovatejobs",
+		"DELETE /api/v1/renovatejobs",
+		"POST /api/v1/tokens",
+		"DELETE /api/v1/tokens",
+		"POST /api/v1/servicetokens",
+		"DELETE /api/v1/servicetokens",
 	}
-	slices.Sort(postPaths)
-	slices.Sort(wantPaths)
-	if !slices.Equal(postPaths, wantPaths) {
-		t.Fatalf("mutating routes = %v, want %v -- a new write route needs a permission and a case here", postPaths, wantPaths)
+	slices.Sort(writeRoutes)
+	slices.Sort(wantRoutes)
+	if !slices.Equal(writeRoutes, wantRoutes) {
+		t.Fatalf("mutating routes = %v, want %v -- a new write route needs a permission and a case here", writeRoutes, wantRoutes)
 	}
 
-	body := `{"renovateJob":"job1","namespace":"default","project":"proj"}`
-	for _, path := range postPaths {
-		t.Run(path, func(t *testing.T) {
-			req := httptest.NewRequest(http.MethodPost, path, bytes.NewBufferString(body))
+	body := `{"renovateJob":"job1","namespace":"default","project":"proj","id":"delivery"}`
+	requests := map[string]*http.Request{
+		"POST /api/v1/renovatejobs":    httptest.NewRequest(http.MethodPost, "/api/v1/renovatejobs", bytes.NewBufferString(`{"renovateJob":"job1","namespace":"default"}`)),
+		"PUT /api/v1/renovatejobs":     httptest.NewRequest(http.MethodPut, "/api/v1/renovatejobs", bytes.NewBufferString(`{"renovateJob":"job1","namespace":"default","resourceVersion":"1"}`)),
+		"DELETE /api/v1/renovatejobs":  httptest.NewRequest(http.MethodDelete, "/api/v1/renovatejobs?namespace=default&renovate=job1&resourceVersion=1", nil),
+		"POST /api/v1/tokens":          httptest.NewRequest(http.MethodPost, "/api/v1/tokens", bytes.NewBufferString(`{"name":"ci","scopes":[{"namespace":"default","renovateJob":"job1","permissions":["trigger"]}]}`)),
+		"DELETE /api/v1/tokens":        httptest.NewRequest(http.MethodDelete, "/api/v1/tokens?id=unknown", nil),
+		"POST /api/v1/servicetokens":   httptest.NewRequest(http.MethodPost, "/api/v1/servicetokens", bytes.NewBufferString(`{"service":"ci","name":"ci","scopes":[{"namespace":"default","renovateJob":"job1"}]}`)),
+		"DELETE /api/v1/servicetokens": httptest.NewRequest(http.MethodDelete, "/api/v1/servicetokens?id=unknown", nil),
+	}
+	// API tokens belong to a user rather than a job: revoking a token that is
+	// not the caller's answers as if it did not exist.
+	wantStatus := map[string]int{
+		"DELETE /api/v1/tokens":        http.StatusNotFound,
+		"DELETE /api/v1/servicetokens": http.StatusNotFound,
+	}
+	for _, route := range writeRoutes {
+		t.Run(route, func(t *testing.T) {
+			req, ok := requests[route]
+			if !ok {
+				req = httptest.NewRequest(http.MethodPost, strings.TrimPrefix(route, "POST "), bytes.NewBufferString(body))
+			}
 			req.Header.Set("Content-Type", "application/json")
 			req = req.WithContext(context.WithValue(req.Context(), sessionContextKey, &sessionData{
-				Email:  "reader@example.com",
-				Groups: []string{"team-reader"},
+				Subject: "oidc:reader",
+				Email:   "reader@example.com",
+				Groups:  []string{"team-reader"},
 			}))
 
 			w := httptest.NewRecorder()
 			server.Router.ServeHTTP(w, req)
 
-			if w.Code != http.StatusForbidden {
-				t.Errorf("reader got status %d for %s, want %d", w.Code, path, http.StatusForbidden)
+			want, ok := wantStatus[route]
+			if !ok {
+				want = http.StatusForbidden
+			}
+			if w.Code != want {
+				t.Errorf("reader got status %d for %s, want %d", w.Code, route, want)
 			}
 		})
 	}
@@ -0,0 +1,474 @@
+package ui
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	api "renovate-operator/api/v1alpha1"
+	"renovate-operator/internal/apiTokens"
+)
+
+const (
+	// maxAPITokensPerOwner bounds the tokens a user can hold at once.
+	maxAPITokensPerOwner = 50
+	// maxAPITokenNameLength bounds the name a token is listed under.
+	maxAPITokenNameLength = 100
+	// defaultAPITokenLifetime applies when a token is minted without an
+	// expiry, unless the configured maximum is shorter.
+	defaultAPITokenLifetime = 30 * 24 * time.Hour
+	// maxServiceAPITokens bounds the service tokens of all services together.
+	maxServiceAPITokens = 200
+	// maxServiceNameLength bounds the name of the service a token belongs to.
+	maxServiceNameLength = 63
+)
+
+// serviceNamePattern is what a service token's service may be called.
+var serviceNamePattern = regexp.MustCompile(`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`)
+
+// APIToken is an API token as listed to those managing it. The secret is only
+// ever returned once, when the token is minted.
+type APIToken struct {
+	ID        string            `json:"id"`
+	Name      string            `json:"name"`
+	Scopes    []apiTokens.Scope `json:"scopes"`
+	CreatedAt time.Time         `json:"createdAt"`
+	ExpiresAt time.Time         `json:"expiresAt"`
+	// Service and CreatedBy are set for service tokens.
+	Service   string `json:"service,omitempty"`
+	CreatedBy string `json:"createdBy,omitempty"`
+}
+
+func apiTokenInfo(token apiTokens.Token) APIToken {
+	return APIToken{
+		ID:        token.ID,
+		Name:      token.Name,
+		Scopes:    token.Scopes,
+		CreatedAt: token.CreatedAt,
+		ExpiresAt: token.ExpiresAt,
+		Service:   token.Owner.Service,
+		CreatedBy: token.CreatedBy,
+	}
+}
+
+// getTokenFromContext returns the API token the request authenticated with,
+// or nil for requests with a session cookie or without authentication.
+func getTokenFromContext(r *http.Request) *apiTokens.Token {
+	token, ok := r.Context().Value(tokenContextKey).(*apiTokens.Token)
+	if !ok {
+		return nil
+	}
+	return token
+}
+
+// SetAPITokens enables minting API tokens, valid for at most maxLifetime, and
+// makes the auth provider accept them as Bearer credentials.
+func (s *Server) SetAPITokens(store apiTokens.Store, maxLifetime time.Duration) {
+	s.tokens = store
+	s.tokenMaxLifetime = maxLifetime
+	if provider, ok := s.auth.(interface{ setAPITokens(apiTokens.Store) }); ok {
+		provider.setAPITokens(store)
+	}
+}
+
+// restrictToToken narrows a decision to the scopes of the token covering the
+// job. A job no scope covers behaves as if it does not exist, and a decision
+// that is already narrowed keeps only what both allow.
+func restrictToToken(decision accessDecision, token *apiTokens.Token, job *api.RenovateJob) accessDecision {
+	var scope []string
+	for _, s := range token.Scopes {
+		if !s.Matches(job.Namespace, job.Name) {
+			continue
+		}
+		if scope == nil {
+			// a matching scope without permissions still grants read
+			scope = []string{}
+		}
+		scope = append(scope, s.Permissions...)
+	}
+	if scope == nil {
+		return accessDecision{}
+	}
+	if decision.scope != nil {
+		scope = slices.DeleteFunc(scope, func(p string) bool { return !slices.Contains(decision.scope, p) })
+	}
+	decision.scope = scope
+	return decision
+}
+
+// tokenManager returns the session of a request managing API tokens, or
+// answers it. Tokens are managed from the UI only: a token that could mint
+// tokens would outlive its own expiry.
+func (s *Server) tokenManager(w http.ResponseWriter, r *http.Request) (*sessionData, bool) {
+	if s.tokens == nil {
+		http.Error(w, "not found", http.StatusNotFound)
+		return nil, false
+	}
+	if getTokenFromContext(r) != nil || isServiceAccountRequest(r) {
+		writeError(w, HttpResultError{Message: "API tokens can only be managed from a UI session", StatusCode: http.StatusForbidden})
+		return nil, false
+	}
+	session := getSessionFromContext(r)
+	if session == nil {
+		writeError(w, HttpResultError{Message: "unauthorized", StatusCode: http.StatusUnauthorized})
+		return nil, false
+	}
+	return session, true
+}
+
+// tokenOwner is tokenManager for the caller's personal tokens, which need an
+// identity that cannot be claimed by someone else.
+func (s *Server) tokenOwner(w http.ResponseWriter, r *http.Request) (*sessionData, bool) {
+	session, ok := s.tokenManager(w, r)
+	if !ok {
+		return nil, false
+	}
+	if ownerOf(session).Key() == "" {
+		writeError(w, HttpResultError{Message: "the identity provider supplied neither a stable subject nor a verified email to own API tokens", StatusCode: http.StatusForbidden})
+		return nil, false
+	}
+	return session, true
+}
+
+func ownerOf(session *sessionData) apiTokens.Owner {
+	return apiTokens.Owner{
+		Subject:       session.Subject,
+		Email:         session.Email,
+		Name:          session.Name,
+		Username:      session.Username,
+		EmailVerified: session.EmailVerified,
+		Groups:        session.Groups,
+	}
+}
+
+// getAPITokens lists the caller's unexpired API tokens, newest first.
+func (s *Server) getAPITokens(w http.ResponseWriter, r *http.Request) {
+	session, ok := s.tokenOwner(w, r)
+	if !ok {
+		return
+	}
+	tokens, err := s.tokens.List(r.Context(), ownerOf(session).Key())
+	if err != nil {
+		internalServerError(w, err, "failed to load API tokens")
+		return
+	}
+	writeAPITokens(w, tokens)
+}
+
+func writeAPITokens(w http.ResponseWriter, tokens []apiTokens.Token) {
+	result := make([]APIToken, 0, len(tokens))
+	for _, token := range tokens {
+		result = append(result, apiTokenInfo(token))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(result)
+}
+
+// apiTokenRequest is the body minting a token.
+type apiTokenRequest struct {
+	Name          string            `json:"name"`
+	Scopes        []apiTokens.Scope `json:"scopes"`
+	ExpiresInDays int               `json:"expiresInDays"`
+}
+
+// validateTokenRequest checks a token about to be minted and returns its
+// lifetime, or answers the request.
+//
+// A scope naming a job may only grant what the caller holds on that job now.
+// A personal token's scope covering a whole namespace is checked when the
+// token is used, like every other scope. A service token is not checked
+// against anyone when it is used, so it must name its jobs, and only admins of
+// each of them may mint it.
+func (s *Server) validateTokenRequest(w http.ResponseWriter, r *http.Request, body *apiTokenRequest, service bool) (time.Duration, bool) {
+	body.Name = strings.TrimSpace(body.Name)
+	if body.Name == "" || len(body.Name) > maxAPITokenNameLength {
+		badRequestError(w, nil, fmt.Sprintf("name is required and must not be longer than %d characters", maxAPITokenNameLength))
+		return 0, false
+	}
+	if len(body.Scopes) == 0 {
+		badRequestError(w, nil, "at least one scope is required")
+		return 0, false
+	}
+	lifetime := min(defaultAPITokenLifetime, s.tokenMaxLifetime)
+	if body.ExpiresInDays != 0 {
+		lifetime = time.Duration(body.ExpiresInDays) * 24 * time.Hour
+		if body.ExpiresInDays < 0 || lifetime > s.tokenMaxLifetime {
+			badRequestError(w, nil, fmt.Sprintf("expiresInDays must be between 1 and %d", int(s.tokenMaxLifetime.Hours()/24)))
+			return 0, false
+		}
+	}
+
+	known := adminDecision().permissions()
+	for i := range body.Scopes {
+		scope := &body.Scopes[i]
+		if scope.Namespace == "" || scope.RenovateJob == "" {
+			badRequestError(w, nil, "every scope needs a namespace and a renovateJob")
+			return 0, false
+		}
+		for _, permission := range scope.Permissions {
+			if !slices.Contains(known, permission) {
+				badRequestError(w, nil, fmt.Sprintf("unknown permission %q, expected one of %s", permission, strings.Join(known, ", ")))
+				return 0, false
+			}
+		}
+		slices.Sort(scope.Permissions)
+		scope.Permissions = slices.Compact(scope.Permissions)
+		if scope.Permissions == nil {
+			scope.Permissions = []string{}
+		}
+		if scope.RenovateJob == apiTokens.AllRenovateJobs {
+			if service {
+				badRequestError(w, nil, "every scope of a service token needs to name its renovateJob")
+				return 0, false
+			}
+			continue
+		}
+		_, decision := s.resolveJobAccess(r, scope.Namespace, scope.RenovateJob)
+		if !decision.canRead() {
+			auditDenied(r)
+			http.Error(w, "not found", http.StatusNotFound)
+			return 0, false
+		}
+		if service && !isFullAdmin(decision) {
+			auditDenied(r)
+			writeError(w, HttpResultError{
+				Message:    fmt.Sprintf("only admins of %s/%s can mint service tokens for it", scope.Namespace, scope.RenovateJob),
+				StatusCode: http.StatusForbidden,
+			})
+			return 0, false
+		}
+		for _, permission := range scope.Permissions {
+			if !decision.has(permission) {
+				writeError(w, HttpResultError{
+					Message:    fmt.Sprintf("you do not hold %s on %s/%s", permission, scope.Namespace, scope.RenovateJob),
+					StatusCode: http.StatusForbidden,
+				})
+				return 0, false
+			}
+		}
+	}
+	return lifetime, true
+}
+
+// createAPIToken mints an API token for the caller and returns it, the only
+// time its secret is disclosed.
+func (s *Server) createAPIToken(w http.ResponseWriter, r *http.Request) {
+	session, ok := s.tokenOwner(w, r)
+	if !ok {
+		return
+	}
+
+	var body apiTokenRequest
+	if err := decodeStrict(r, &body); err != nil {
+		badRequestError(w, err, "failed to parse request body")
+		return
+	}
+	lifetime, ok := s.validateTokenRequest(w, r, &body, false)
+	if !ok {
+		return
+	}
+
+	owner := ownerOf(session)
+	existing, err := s.tokens.List(r.Context(), owner.Key())
+	if err != nil {
+		internalServerError(w, err, "failed to load API tokens")
+		return
+	}
+	if len(existing) >= maxAPITokensPerOwner {
+		badRequestError(w, nil, fmt.Sprintf("at most %d API tokens can be held at once, revoke one first", maxAPITokensPerOwner))
+		return
+	}
+
+	token, plaintext := apiTokens.New(body.Name, owner, body.Scopes, lifetime, time.Now())
+	s.saveAPIToken(w, r, token, plaintext)
+}
+
+// saveAPIToken stores a minted token and answers with its plaintext.
+func (s *Server) saveAPIToken(w http.ResponseWriter, r *http.Request, token apiTokens.Token, plaintext string) {
+	if err := s.tokens.Save(r.Context(), token); err != nil {
+		internalServerError(w, err, "failed to save API token")
+		return
+	}
+	auditDetail(r, "tokenId", token.ID)
+	auditDetail(r, "tokenName", token.Name)
+	if token.IsService() {
+		auditDetail(r, "service", token.Owner.Service)
+	}
+	s.logger.Info("API token minted",
+		"user", sessionEmail(r),
+		"service", token.Owner.Service,
+		"id", token.ID,
+		"name", token.Name,
+		"expiresAt", token.ExpiresAt)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	_ = json.NewEncoder(w).Encode(struct {
+		Token    string   `json:"token"`
+		APIToken APIToken `json:"apiToken"`
+	}{Token: plaintext, APIToken: apiTokenInfo(token)})
+}
+
+// deleteAPIToken revokes one of the caller's API tokens.
+func (s *Server) deleteAPIToken(w http.ResponseWriter, r *http.Request) {
+	session, ok := s.tokenOwner(w, r)
+	if !ok {
+		return
+	}
+	key := ownerOf(session).Key()
+	s.revokeAPIToken(w, r, func(token apiTokens.Token) error {
+		if token.Owner.Key() != key {
+			return apiTokens.ErrTokenNotFound
+		}
+		return nil
+	})
+}
+
+// revokeAPIToken revokes the token named by the request if manages returns
+// nil for it. A token manages reports as apiTokens.ErrTokenNotFound is
+// reported like a missing one, so token IDs cannot be probed.
+func (s *Server) revokeAPIToken(w http.ResponseWriter, r *http.Request, manages func(apiTokens.Token) error) {
+	id := r.URL.Query().Get("id")
+	if id == "" {
+		badRequestError(w, nil, "Missing parameters")
+		return
+	}
+	auditDetail(r, "tokenId", id)
+
+	token, err := s.tokens.Get(r.Context(), id)
+	if err == nil {
+		err = manages(token)
+	}
+	if err == nil {
+		err = s.tokens.Delete(r.Context(), id)
+	}
+	if errors.Is(err, apiTokens.ErrTokenNotFound) {
+		writeError(w, HttpResultError{Message: "API token not found", StatusCode: http.StatusNotFound, Error: err})
+		return
+	}
+	if errors.Is(err, errNotServiceAdmin) {
+		auditDenied(r)
+		writeError(w, HttpResultError{Message: err.Error(), StatusCode: http.StatusForbidden})
+		return
+	}
+	if err != nil {
+		internalServerError(w, err, "failed to revoke API token")
+		return
+	}
+	if token.IsService() {
+		auditDetail(r, "service", token.Owner.Service)
+	}
+	s.logger.Info("API token revoked", "user", sessionEmail(r), "service", token.Owner.Service, "id", id, "name", token.Name)
+	writeSuccess(w, SuccessResult{Message: "API token revoked"})
+}
+
+// errNotServiceAdmin refuses a service token to someone who may act on its
+// jobs, but not with everything an admin may do. A service token outlives the
+// access of whoever minted it, so only full admins manage them.
+var errNotServiceAdmin = errors.New("only admins of every RenovateJob of a service token can manage it")
+
+// managesServiceToken reports whether the request holds full admin on every
+// job the service token names that still exists, and on at least one.
+func (s *Server) managesServiceToken(r *http.Request, token apiTokens.Token) bool {
+	return s.serviceTokenAccess(r, token) == nil
+}
+
+// serviceTokenAccess is managesServiceToken as an error: errNotServiceAdmin
+// for a request that may act on a job of the token, but not as a full admin,
+// and apiTokens.ErrTokenNotFound for anyone else.
+func (s *Server) serviceTokenAccess(r *http.Request, token apiTokens.Token) error {
+	if !token.IsService() {
+		return apiTokens.ErrTokenNotFound
+	}
+	var err error
+	managed := false
+	for _, scope := range token.Scopes {
+		job, decision := s.resolveJobAccess(r, scope.Namespace, scope.RenovateJob)
+		if job == nil {
+			continue
+		}
+		decision = decision.complete()
+		switch {
+		case isFullAdmin(decision):
+			managed = true
+		case decision.Role >= roleCustom:
+			err = errNotServiceAdmin
+		default:
+			return apiTokens.ErrTokenNotFound
+		}
+	}
+	if err == nil && !managed {
+		err = apiTokens.ErrTokenNotFound
+	}
+	return err
+}
+
+// getServiceTokens lists the unexpired service tokens the caller manages,
+// newest first.
+func (s *Server) getServiceTokens(w http.ResponseWriter, r *http.Request) {
+	if _, ok := s.tokenManager(w, r); !ok {
+		return
+	}
+	tokens, err := s.tokens.List(r.Context(), apiTokens.ServiceOwnerKey)
+	if err != nil {
+		internalServerError(w, err, "failed to load API tokens")
+		return
+	}
+	tokens = slices.DeleteFunc(tokens, func(token apiTokens.Token) bool { return !s.managesServiceToken(r, token) })
+	writeAPITokens(w, tokens)
+}
+
+// createServiceToken mints a service token and returns it, the only time its
+// secret is disclosed.
+func (s *Server) createServiceToken(w http.ResponseWriter, r *http.Request) {
+	if _, ok := s.tokenManager(w, r); !ok {
+		return
+	}
+
+	var body struct {
+		Service string `json:"service"`
+		apiTokenRequest
+	}
+	if err := decodeStrict(r, &body); err != nil {
+		badRequestError(w, err, "failed to parse request body")
+		return
+	}
+	if len(body.Service) > maxServiceNameLength || !serviceNamePattern.MatchString(body.Service) {
+		badRequestError(w, nil, fmt.Sprintf("service is required and must be a lowercase name of letters, digits and dashes, at most %d characters", maxServiceNameLength))
+		return
+	}
+	lifetime, ok := s.validateTokenRequest(w, r, &body.apiTokenRequest, true)
+	if !ok {
+		return
+	}
+
+	existing, err := s.tokens.List(r.Context(), apiTokens.ServiceOwnerKey)
+	if err != nil {
+		internalServerError(w, err, "failed to load API tokens")
+		return
+	}
+	if len(existing) >= maxServiceAPITokens {
+		badRequestError(w, nil, fmt.Sprintf("at most %d service tokens can exist at once, revoke one first", maxServiceAPITokens))
+		return
+	}
+
+	token, plaintext := apiTokens.New(body.Name, apiTokens.Owner{Service: body.Service}, body.Scopes, lifetime, time.Now())
+	token.CreatedBy = sessionEmail(r)
+	s.saveAPIToken(w, r, token, plaintext)
+}
+
+// deleteServiceToken revokes a service token the caller manages.
+func (s *Server) deleteServiceToken(w http.ResponseWriter, r *http.Request) {
+	if _, ok := s.tokenManager(w, r); !ok {
+		return
+	}
+	s.revokeAPIToken(w, r, func(token apiTokens.Token) error {
+		return s.serviceTokenAccess(r, token)
+	})
+}
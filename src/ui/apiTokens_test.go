@@ -0,0 +1,444 @@
+package ui
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	api "renovate-operator/api/v1alpha1"
+	"renovate-operator/config"
+	"renovate-operator/internal/apiTokens"
+	"renovate-operator/internal/kvstore"
+
+	"github.com/go-logr/logr"
+	"github.com/gorilla/mux"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func newTokenStore(t *testing.T) apiTokens.Store {
+	t.Helper()
+	store, err := apiTokens.NewStore(logr.Discard(), "memory", kvstore.ValkeyConfig{}, nil, "", "")
+	if err != nil {
+		t.Fatalf("failed to create token store: %v", err)
+	}
+	return store
+}
+
+func tokenJob(namespace, name string) *api.RenovateJob {
+	return &api.RenovateJob{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+		Spec: api.RenovateJobSpec{Access: &api.RenovateJobAccess{
+			ReaderGroups: []string{"team-reader"},
+			AdminGroups:  []string{"team-admin"},
+			RoleBindings: []api.RenovateJobRoleBinding{{Role: "developer", Groups: []string{"team-dev"}}},
+		}},
+	}
+}
+
+var (
+	tokenAdmin  = &sessionData{Subject: "oidc:admin", Email: "admin@example.com", Groups: []string{"team-admin"}}
+	tokenReader = &sessionData{Subject: "oidc:reader", Email: "reader@example.com", Groups: []string{"team-reader"}}
+	// tokenDeveloper holds the custom role developer, which may trigger
+	tokenDeveloper = &sessionData{Subject: "oidc:developer", Email: "developer@example.com", Groups: []string{"team-dev"}}
+)
+
+func TestAuthMiddleware_APITokens(t *testing.T) {
+	defs := []config.ConfigItemDescription{
+		{Key: "WEBHOOK_SERVER_UNIFIED_HOST", Optional: true, Default: "false"},
+	}
+	if err := config.InitializeConfigModule(defs); err != nil {
+		t.Fatalf("failed to initialize config module: %v", err)
+	}
+
+	base, err := newBaseAuth(testEncryptionKey(t), logr.Discard(), NewMemorySessionStore())
+	if err != nil {
+		t.Fatalf("Failed to create baseAuth: %v", err)
+	}
+	store := newTokenStore(t)
+	base.setAPITokens(store)
+	token, plaintext := apiTokens.New("ci", apiTokens.Owner{Email: "admin@example.com", Groups: []string{"team-admin"}}, nil, time.Hour, time.Now())
+	if err := store.Save(context.Background(), token); err != nil {
+		t.Fatalf("failed to save token: %v", err)
+	}
+
+	tests := []struct {
+		name          string
+		path          string
+		authorization string
+		wantHandler   bool
+		wantStatus    int
+	}{
+		{"token authenticates the API", "/api/v1/renovate", "Bearer " + plaintext, true, http.StatusOK},
+		{"scheme is case-insensitive", "/api/v1/renovate", "bearer " + plaintext, true, http.StatusOK},
+		{"wrong secret is rejected", "/api/v1/renovate", "Bearer " + apiTokens.Prefix + token.ID + "_WRONG", false, http.StatusUnauthorized},
+		{"token does not open the UI", "/some-page", "Bearer " + plaintext, false, http.StatusFound},
+		{"other bearer credentials are not tokens", "/api/v1/renovate", "Bearer eyJhbGciOiJSUzI1NiJ9", false, http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reached := false
+			middleware := base.authMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				reached = true
+				session, got := getSessionFromContext(r), getTokenFromContext(r)
+				if session == nil || session.Email != "admin@example.com" || session.Groups[0] != "team-admin" {
+					t.Errorf("expected the token's owner as the session, got %+v", session)
+				}
+				if got == nil || got.ID != token.ID {
+					t.Errorf("expected the token in the context, got %+v", got)
+				}
+				w.WriteHeader(http.StatusOK)
+			}))
+
+			req := httptest.NewRequest(http.MethodPost, tt.path, nil)
+			req.Header.Set("Authorization", tt.authorization)
+			w := httptest.NewRecorder()
+			middleware.ServeHTTP(w, req)
+
+			if reached != tt.wantHandler {
+				t.Errorf("handler reached = %v, want %v", reached, tt.wantHandler)
+			}
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestDecideJobAccess_APITokenScopes(t *testing.T) {
+	server := &Server{logger: logr.Discard(), auth: &OIDCAuth{}}
+	job1, job2, elsewhere := tokenJob("default", "job1"), tokenJob("default", "job2"), tokenJob("other", "job1")
+
+	tests := []struct {
+		name    string
+		session *sessionData
+		scopes  []apiTokens.Scope
+		job     *api.RenovateJob
+		read    bool
+		want    []string
+	}{
+		{
+			name:    "job scope narrows an admin",
+			session: tokenAdmin,
+			scopes:  []apiTokens.Scope{{Namespace: "default", RenovateJob: "job1", Permissions: []string{permLogs, permTrigger}}},
+			job:     job1, read: true, want: []string{permLogs, permTrigger},
+		},
+		{
+			name:    "job outside every scope is hidden",
+			session: tokenAdmin,
+			scopes:  []apiTokens.Scope{{Namespace: "default", RenovateJob: "job1", Permissions: []string{permTrigger}}},
+			job:     job2, read: false, want: []string{},
+		},
+		{
+			name:    "namespace scope covers every job of the namespace only",
+			session: tokenAdmin,
+			scopes:  []apiTokens.Scope{{Namespace: "default", RenovateJob: apiTokens.AllRenovateJobs, Permissions: []string{permCancel}}},
+			job:     elsewhere, read: false, want: []string{},
+		},
+		{
+			name:    "scope without permissions reads",
+			session: tokenAdmin,
+			scopes:  []apiTokens.Scope{{Namespace: "default", RenovateJob: apiTokens.AllRenovateJobs, Permissions: []string{}}},
+			job:     job2, read: true, want: []string{},
+		},
+		{
+			name:    "matching scopes add up",
+			session: tokenAdmin,
+			scopes: []apiTokens.Scope{
+				{Namespace: "default", RenovateJob: apiTokens.AllRenovateJobs, Permissions: []string{permLogs}},
+				{Namespace: "default", RenovateJob: "job1", Permissions: []string{permDiscovery}},
+			},
+			job: job1, read: true, want: []string{permLogs, permDiscovery},
+		},
+		{
+			name:    "token cannot exceed its owner",
+			session: tokenReader,
+			scopes:  []apiTokens.Scope{{Namespace: "default", RenovateJob: apiTokens.AllRenovateJobs, Permissions: []string{permLogs, permTrigger}}},
+			job:     job1, read: true, want: []string{permLogs},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token := &apiTokens.Token{ID: "id", Scopes: tt.scopes}
+			ctx := context.WithValue(context.Background(), sessionContextKey, tt.session)
+			ctx = context.WithValue(ctx, tokenContextKey, token)
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/renovatejobs", nil).WithContext(ctx)
+
+			decision := server.decideJobAccess(req, tt.job)
+			if decision.canRead() != tt.read {
+				t.Errorf("canRead = %v, want %v", decision.canRead(), tt.read)
+			}
+			if got := decision.permissions(); !slices.Equal(got, tt.want) {
+				t.Errorf("permissions = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// tokenServer serves the API with API tokens enabled, for the jobs job1 and
+// job2 in default.
+func tokenServer(t *testing.T) *Server {
+	t.Helper()
+	server := &Server{
+		manager: &mockRenovateJobManager{
+			getRenovateJobFunc: func(_ context.Context, name, namespace string) (*api.RenovateJob, error) {
+				if namespace != "default" || (name != "job1" && name != "job2") {
+					return nil, fmt.Errorf("renovatejob %s/%s not found", namespace, name)
+				}
+				return tokenJob(namespace, name), nil
+			},
+		},
+		logger:         logr.Discard(),
+		auth:           &OIDCAuth{},
+		accessDefaults: AccessDefaults{Roles: map[string][]string{"developer": {permLogs, permTrigger}}},
+		Router:         mux.NewRouter(),
+	}
+	server.SetAPITokens(newTokenStore(t), 90*24*time.Hour)
+	server.registerApiV1Routes(server.Router)
+	return server
+}
+
+func tokenRequest(t *testing.T, server *Server, method, path, body string, session *sessionData) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(method, path, bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	if session != nil {
+		req = req.WithContext(context.WithValue(req.Context(), sessionContextKey, session))
+	}
+	w := httptest.NewRecorder()
+	server.Router.ServeHTTP(w, req)
+	return w
+}
+
+func TestCreateAPIToken(t *testing.T) {
+	server := tokenServer(t)
+
+	w := tokenRequest(t, server, http.MethodPost, "/api/v1/tokens",
+		`{"name":" ci ","expiresInDays":7,"scopes":[{"namespace":"default","renovateJob":"job1","permissions":["trigger","logs","trigger"]}]}`, tokenAdmin)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+	var created struct {
+		Token    string   `json:"token"`
+		APIToken APIToken `json:"apiToken"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&created); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if !strings.HasPrefix(created.Token, apiTokens.Prefix+created.APIToken.ID+"_") || created.APIToken.Name != "ci" {
+		t.Errorf("expected the plaintext token of ci, got %+v", created)
+	}
+	if got := created.APIToken.Scopes[0].Permissions; !slices.Equal(got, []string{permLogs, permTrigger}) {
+		t.Errorf("expected the permissions sorted and deduplicated, got %v", got)
+	}
+	if lifetime := created.APIToken.ExpiresAt.Sub(created.APIToken.CreatedAt); lifetime != 7*24*time.Hour {
+		t.Errorf("expected a lifetime of 7 days, got %s", lifetime)
+	}
+
+	tests := []struct {
+		name    string
+		session *sessionData
+		body    string
+		want    int
+	}{
+		{"reader cannot grant what they do not hold", tokenReader,
+			`{"name":"ci","scopes":[{"namespace":"default","renovateJob":"job1","permissions":["trigger"]}]}`, http.StatusForbidden},
+		{"reader can mint a read token", tokenReader,
+			`{"name":"ci","scopes":[{"namespace":"default","renovateJob":"job1","permissions":["logs"]}]}`, http.StatusCreated},
+		{"namespace scope is checked on use", tokenReader,
+			`{"name":"ci","scopes":[{"namespace":"default","renovateJob":"*","permissions":["trigger"]}]}`, http.StatusCreated},
+		{"unknown job", tokenAdmin,
+			`{"name":"ci","scopes":[{"namespace":"default","renovateJob":"job3"}]}`, http.StatusNotFound},
+		{"unknown permission", tokenAdmin,
+			`{"name":"ci","scopes":[{"namespace":"default","renovateJob":"job1","permissions":["delete"]}]}`, http.StatusBadRequest},
+		{"lifetime above the maximum", tokenAdmin,
+			`{"name":"ci","expiresInDays":91,"scopes":[{"namespace":"default","renovateJob":"job1"}]}`, http.StatusBadRequest},
+		{"no scopes", tokenAdmin, `{"name":"ci"}`, http.StatusBadRequest},
+		{"no name", tokenAdmin, `{"scopes":[{"namespace":"default","renovateJob":"job1"}]}`, http.StatusBadRequest},
+		{"unknown field", tokenAdmin, `{"name":"ci","admin":true,"scopes":[{"namespace":"default","renovateJob":"job1"}]}`, http.StatusBadRequest},
+		{"no session", nil, `{"name":"ci","scopes":[{"namespace":"default","renovateJob":"job1"}]}`, http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if w := tokenRequest(t, server, http.MethodPost, "/api/v1/tokens", tt.body, tt.session); w.Code != tt.want {
+				t.Errorf("expected %d, got %d: %s", tt.want, w.Code, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestListAndRevokeAPITokens(t *testing.T) {
+	server := tokenServer(t)
+	w := tokenRequest(t, server, http.MethodPost, "/api/v1/tokens", `{"name":"ci","scopes":[{"namespace":"default","renovateJob":"job1"}]}`, tokenAdmin)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+
+	list := func(session *sessionData) []APIToken {
+		t.Helper()
+		w := tokenRequest(t, server, http.MethodGet, "/api/v1/tokens", "", session)
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+		}
+		if strings.Contains(w.Body.String(), "hash") {
+			t.Errorf("expected the hash not to be listed, got %s", w.Body.String())
+		}
+		var tokens []APIToken
+		_ = json.NewDecoder(w.Body).Decode(&tokens)
+		return tokens
+	}
+	tokens := list(tokenAdmin)
+	if len(tokens) != 1 || tokens[0].Name != "ci" {
+		t.Fatalf("expected the admin's token, got %+v", tokens)
+	}
+	if others := list(tokenReader); len(others) != 0 {
+		t.Errorf("expected the reader to see no tokens, got %+v", others)
+	}
+
+	path := "/api/v1/tokens?id=" + tokens[0].ID
+	if w := tokenRequest(t, server, http.MethodDelete, path, "", tokenReader); w.Code != http.StatusNotFound {
+		t.Errorf("expected another user's token to be not found, got %d", w.Code)
+	}
+	if w := tokenRequest(t, server, http.MethodDelete, path, "", tokenAdmin); w.Code != http.StatusOK {
+		t.Errorf("expected the owner to revoke the token, got %d: %s", w.Code, w.Body.String())
+	}
+	if tokens := list(tokenAdmin); len(tokens) != 0 {
+		t.Errorf("expected no tokens after revoking, got %+v", tokens)
+	}
+}
+
+func TestCreateAPIToken_NeedsAnUnclaimableOwner(t *testing.T) {
+	server := tokenServer(t)
+	body := `{"name":"ci","scopes":[{"namespace":"default","renovateJob":"job1"}]}`
+	for _, session := range []*sessionData{
+		{Email: "admin@example.com", Groups: []string{"team-admin"}},
+		{Username: "admin@example.com", Groups: []string{"team-admin"}},
+	} {
+		if w := tokenRequest(t, server, http.MethodPost, "/api/v1/tokens", body, session); w.Code != http.StatusForbidden {
+			t.Errorf("%+v: expected 403, got %d: %s", session, w.Code, w.Body.String())
+		}
+	}
+	verified := &sessionData{Email: "admin@example.com", EmailVerified: true, Groups: []string{"team-admin"}}
+	if w := tokenRequest(t, server, http.MethodPost, "/api/v1/tokens", body, verified); w.Code != http.StatusCreated {
+		t.Errorf("expected a verified email to own tokens, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestServiceTokens(t *testing.T) {
+	server := tokenServer(t)
+
+	tests := []struct {
+		name    string
+		session *sessionData
+		body    string
+		want    int
+	}{
+		{"reader cannot mint service tokens", tokenReader,
+			`{"service":"ci","name":"ci","scopes":[{"namespace":"default","renovateJob":"job1","permissions":["logs"]}]}`, http.StatusForbidden},
+		{"custom role cannot mint service tokens", tokenDeveloper,
+			`{"service":"ci","name":"ci","scopes":[{"namespace":"default","renovateJob":"job1","permissions":["trigger"]}]}`, http.StatusForbidden},
+		{"service tokens name their jobs", tokenAdmin,
+			`{"service":"ci","name":"ci","scopes":[{"namespace":"default","renovateJob":"*","permissions":["trigger"]}]}`, http.StatusBadRequest},
+		{"invalid service name", tokenAdmin,
+			`{"service":"CI Bot","name":"ci","scopes":[{"namespace":"default","renovateJob":"job1"}]}`, http.StatusBadRequest},
+		{"no service", tokenAdmin,
+			`{"name":"ci","scopes":[{"namespace":"default","renovateJob":"job1"}]}`, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if w := tokenRequest(t, server, http.MethodPost, "/api/v1/servicetokens", tt.body, tt.session); w.Code != tt.want {
+				t.Errorf("expected %d, got %d: %s", tt.want, w.Code, w.Body.String())
+			}
+		})
+	}
+
+	w := tokenRequest(t, server, http.MethodPost, "/api/v1/servicetokens",
+		`{"service":"release-pipeline","name":"ci","scopes":[{"namespace":"default","renovateJob":"job1","permissions":["trigger"]}]}`, tokenAdmin)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+
+	list := func(session *sessionData) []APIToken {
+		t.Helper()
+		w := tokenRequest(t, server, http.MethodGet, "/api/v1/servicetokens", "", session)
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+		}
+		var tokens []APIToken
+		_ = json.NewDecoder(w.Body).Decode(&tokens)
+		return tokens
+	}
+	tokens := list(&sessionData{Subject: "oidc:other-admin", Groups: []string{"team-admin"}})
+	if len(tokens) != 1 || tokens[0].Service != "release-pipeline" || tokens[0].CreatedBy != "admin@example.com" {
+		t.Fatalf("expected every admin of job1 to see the service token, got %+v", tokens)
+	}
+	if personal := tokenRequest(t, server, http.MethodGet, "/api/v1/tokens", "", tokenAdmin); strings.Contains(personal.Body.String(), tokens[0].ID) {
+		t.Errorf("expected the service token not to be listed as a personal token, got %s", personal.Body.String())
+	}
+	if others := list(tokenReader); len(others) != 0 {
+		t.Errorf("expected the reader to see no service tokens, got %+v", others)
+	}
+	if others := list(tokenDeveloper); len(others) != 0 {
+		t.Errorf("expected a custom role to see no service tokens, got %+v", others)
+	}
+
+	t.Run("acts as no user", func(t *testing.T) {
+		token := &apiTokens.Token{ID: "id", Owner: apiTokens.Owner{Service: "release-pipeline"}, Scopes: []apiTokens.Scope{
+			{Namespace: "default", RenovateJob: "job1", Permissions: []string{permTrigger}},
+		}}
+		ctx := context.WithValue(context.Background(), sessionContextKey, &sessionData{Username: "service:release-pipeline"})
+		ctx = context.WithValue(ctx, tokenContextKey, token)
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/renovatejobs", nil).WithContext(ctx)
+		if got := server.decideJobAccess(req, tokenJob("default", "job1")).permissions(); !slices.Equal(got, []string{permTrigger}) {
+			t.Errorf("expected the scope's permissions on job1, got %v", got)
+		}
+		if server.decideJobAccess(req, tokenJob("default", "job2")).canRead() {
+			t.Error("expected job2 to be hidden")
+		}
+	})
+
+	path := "/api/v1/servicetokens?id=" + tokens[0].ID
+	if w := tokenRequest(t, server, http.MethodDelete, path, "", tokenReader); w.Code != http.StatusNotFound {
+		t.Errorf("expected a reader not to find the service token, got %d", w.Code)
+	}
+	if w := tokenRequest(t, server, http.MethodDelete, path, "", tokenDeveloper); w.Code != http.StatusForbidden {
+		t.Errorf("expected a custom role not to revoke the service token, got %d", w.Code)
+	}
+	if w := tokenRequest(t, server, http.MethodDelete, "/api/v1/tokens?id="+tokens[0].ID, "", tokenAdmin); w.Code != http.StatusNotFound {
+		t.Errorf("expected a service token not to be revoked as a personal token, got %d", w.Code)
+	}
+	if w := tokenRequest(t, server, http.MethodDelete, path, "", &sessionData{Subject: "oidc:other-admin", Groups: []string{"team-admin"}}); w.Code != http.StatusOK {
+		t.Errorf("expected another admin to revoke the service token, got %d: %s", w.Code, w.Body.String())
+	}
+	if tokens := list(tokenAdmin); len(tokens) != 0 {
+		t.Errorf("expected no service tokens after revoking, got %+v", tokens)
+	}
+}
+
+func TestAPITokenEndpoints_Guards(t *testing.T) {
+	t.Run("tokens cannot manage tokens", func(t *testing.T) {
+		server := tokenServer(t)
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/tokens", nil)
+		ctx := context.WithValue(req.Context(), sessionContextKey, tokenAdmin)
+		ctx = context.WithValue(ctx, tokenContextKey, &apiTokens.Token{ID: "id"})
+		w := httptest.NewRecorder()
+		server.Router.ServeHTTP(w, req.WithContext(ctx))
+		if w.Code != http.StatusForbidden {
+			t.Errorf("expected 403, got %d", w.Code)
+		}
+	})
+
+	t.Run("not found when disabled", func(t *testing.T) {
+		server := &Server{logger: logr.Discard(), auth: &OIDCAuth{}, Router: mux.NewRouter()}
+		server.registerApiV1Routes(server.Router)
+		if w := tokenRequest(t, server, http.MethodGet, "/api/v1/tokens", "", tokenAdmin); w.Code != http.StatusNotFound {
+			t.Errorf("expected 404, got %d", w.Code)
+		}
+	})
+}
@@ -0,0 +1,232 @@
+package ui
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"renovate-operator/internal/audit"
+)
+
+// SetAudit makes the server record the actions taken through the API in sink
+// and serve them on /api/v1/audit.
+func (s *Server) SetAudit(sink audit.Sink) {
+	s.audit = sink
+}
+
+type auditRecordKey struct{}
+
+// auditRecord collects what a handler acted on, for the audit entry written
+// once it has answered.
+type auditRecord struct {
+	namespace   string
+	renovateJob string
+	project     string
+	details     map[string]string
+	// denied is set when the access checks refused the request, which they
+	// answer with 404 as often as with 403.
+	denied bool
+}
+
+func auditRecordFromContext(r *http.Request) *auditRecord {
+	record, _ := r.Context().Value(auditRecordKey{}).(*auditRecord)
+	return record
+}
+
+// auditTarget stores the job and project a request acts on on its audit
+// record, if there is one.
+func auditTarget(r *http.Request, namespace, renovateJob, project string) {
+	if record := auditRecordFromContext(r); record != nil {
+		record.namespace, record.renovateJob, record.project = namespace, renovateJob, project
+	}
+}
+
+// auditDetail adds an action specific detail to the audit record of r.
+func auditDetail(r *http.Request, key, value string) {
+	record := auditRecordFromContext(r)
+	if record == nil {
+		return
+	}
+	if record.details == nil {
+		record.details = map[string]string{}
+	}
+	record.details[key] = value
+}
+
+// auditDenied marks the request as refused for lack of access.
+func auditDenied(r *http.Request) {
+	if record := auditRecordFromContext(r); record != nil {
+		record.denied = true
+	}
+}
+
+// auditResponseWriter captures the status of a response.
+type auditResponseWriter struct {
+	http.ResponseWriter
+	status int
+}
+
+func (w *auditResponseWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *auditResponseWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// audited records every request to handler as action in the audit log, with
+// its outcome taken from the response status.
+func (s *Server) audited(action string, handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if s.audit == nil {
+			handler(w, r)
+			return
+		}
+
+		record := &auditRecord{}
+		writer := &auditResponseWriter{ResponseWriter: w}
+		handler(writer, r.WithContext(context.WithValue(r.Context(), auditRecordKey{}, record)))
+
+		status := responseStatus(writer.status)
+		entry := audit.Entry{
+			Actor:       sessionEmail(r),
+			Source:      auditSource(r),
+			Action:      action,
+			Namespace:   record.namespace,
+			RenovateJob: record.renovateJob,
+			Project:     record.project,
+			Details:     record.details,
+		}
+		switch {
+		case record.denied || status == http.StatusUnauthorized || status == http.StatusForbidden:
+			entry.Result = audit.ResultDenied
+			entry.Reason = http.StatusText(status)
+		case status >= http.StatusBadRequest:
+			entry.Result = audit.ResultFailed
+			entry.Reason = http.StatusText(status)
+		default:
+			entry.Result = audit.ResultSuccess
+		}
+		s.audit.Record(r.Context(), entry)
+	}
+}
+
+// responseStatus is the status a response was sent with, 200 when the
+// handler never wrote to it.
+func responseStatus(status int) int {
+	if status == 0 {
+		return http.StatusOK
+	}
+	return status
+}
+
+// auditSource names how a request authenticated.
+func auditSource(r *http.Request) string {
+	switch {
+	case getTokenFromContext(r) != nil:
+		return audit.SourceAPIToken
+	case isServiceAccountRequest(r):
+		return audit.SourceServiceAccount
+	default:
+		return audit.SourceUI
+	}
+}
+
+// getAuditLog lists the audit entries the request may see, newest first: those
+// of the jobs it holds the audit permission on, and the entries of its own
+// actions that are not tied to a job, such as minting API tokens. Without
+// authentication every entry is visible.
+func (s *Server) getAuditLog(w http.ResponseWriter, r *http.Request) {
+	if s.audit == nil {
+		http.Error(w, "not found", http.StatusNotFound)
+		return
+	}
+
+	filter, err := parseAuditFilter(r)
+	if err != nil {
+		badRequestError(w, err, err.Error())
+		return
+	}
+
+	if s.auth != nil {
+		renovateJobs, err := s.manager.ListRenovateJobsFull(r.Context())
+		if err != nil {
+			internalServerError(w, err, "failed to load renovatejobs")
+			return
+		}
+		renovateJobs, decisions := s.filterReadableJobs(r, renovateJobs)
+		auditable := make(map[string]bool, len(renovateJobs))
+		for i := range renovateJobs {
+			if decisions[i].has(permAudit) {
+				auditable[renovateJobs[i].Namespace+"/"+renovateJobs[i].Name] = true
+			}
+		}
+		session := getSessionFromContext(r)
+		actor := sessionEmail(r)
+		filter.Keep = func(entry audit.Entry) bool {
+			if entry.RenovateJob != "" {
+				return auditable[entry.Namespace+"/"+entry.RenovateJob]
+			}
+			return session != nil && entry.Actor == actor
+		}
+	}
+
+	entries, err := s.audit.List(r.Context(), filter)
+	if errors.Is(err, audit.ErrNotQueryable) {
+		writeError(w, HttpResultError{
+			Message:    "the audit log is written to the operator log, set audit.mode to memory, valkey or s3 to query it here",
+			StatusCode: http.StatusNotImplemented,
+			Error:      err,
+		})
+		return
+	}
+	if err != nil {
+		internalServerError(w, err, "failed to load audit log")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(entries)
+}
+
+func parseAuditFilter(r *http.Request) (audit.Filter, error) {
+	query := r.URL.Query()
+	filter := audit.Filter{
+		Namespace:   query.Get("namespace"),
+		RenovateJob: query.Get("renovate"),
+		Project:     query.Get("project"),
+		Actor:       query.Get("actor"),
+		Source:      query.Get("source"),
+		Action:      query.Get("action"),
+		Result:      query.Get("result"),
+	}
+	for name, bound := range map[string]*time.Time{"since": &filter.Since, "until": &filter.Until} {
+		raw := query.Get(name)
+		if raw == "" {
+			continue
+		}
+		parsed, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			return filter, fmt.Errorf("%s must be an RFC 3339 time, e.g. 2026-01-02T15:04:05Z", name)
+		}
+		*bound = parsed
+	}
+	if raw := query.Get("limit"); raw != "" {
+		limit, err := strconv.Atoi(raw)
+		if err != nil || limit < 1 || limit > audit.MaxLimit {
+			return filter, fmt.Errorf("limit must be between 1 and %d", audit.MaxLimit)
+		}
+		filter.Limit = limit
+	}
+	return filter, nil
+}
@@ -0,0 +1,204 @@
+package ui
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	api "renovate-operator/api/v1alpha1"
+	"renovate-operator/internal/audit"
+	crdmanager "renovate-operator/internal/crdManager"
+	"renovate-operator/internal/kvstore"
+	"renovate-operator/internal/objectstore"
+	"renovate-operator/internal/types"
+
+	"github.com/go-logr/logr"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func newMemoryAuditSink(t *testing.T) audit.Sink {
+	t.Helper()
+	sink, err := audit.NewSink(logr.Discard(), "memory", kvstore.ValkeyConfig{}, objectstore.S3Config{}, "", 24*time.Hour)
+	if err != nil {
+		t.Fatalf("NewSink returned error: %v", err)
+	}
+	return sink
+}
+
+func auditTestJobs() []api.RenovateJob {
+	return []api.RenovateJob{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "job1", Namespace: "team-a"},
+			Spec:       api.RenovateJobSpec{Access: &api.RenovateJobAccess{AdminGroups: []string{"team-a"}, ReaderGroups: []string{"team-a-readers"}}},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "job2", Namespace: "team-b"},
+			Spec:       api.RenovateJobSpec{Access: &api.RenovateJobAccess{AdminGroups: []string{"team-b"}}},
+		},
+	}
+}
+
+func TestAudited(t *testing.T) {
+	jobs := auditTestJobs()
+	sink := newMemoryAuditSink(t)
+	server := &Server{
+		manager: &mockRenovateJobManager{
+			getRenovateJobFunc: func(_ context.Context, name, namespace string) (*api.RenovateJob, error) {
+				for i := range jobs {
+					if jobs[i].Name == name && jobs[i].Namespace == namespace {
+						return &jobs[i], nil
+					}
+				}
+				return nil, errors.New("not found")
+			},
+			updateProjectStatusFunc: func(_ context.Context, project string, _ crdmanager.RenovateJobIdentifier, _ *types.RenovateStatusUpdate) error {
+				if project == "org/broken" {
+					return errors.New("conflict")
+				}
+				return nil
+			},
+		},
+		logger: logr.Discard(),
+		auth:   &OIDCAuth{},
+		audit:  sink,
+	}
+	trigger := server.audited(audit.ActionTrigger, server.runRenovateForProject)
+	post := func(group, body string) {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/renovate", strings.NewReader(body))
+		session := &sessionData{Email: "me@example.com", Groups: []string{group}}
+		req = req.WithContext(context.WithValue(req.Context(), sessionContextKey, session))
+		trigger(httptest.NewRecorder(), req)
+	}
+
+	post("team-a", `{"namespace":"team-a","renovateJob":"job1","project":"org/repo","executionOptions":{"debug":true}}`)
+	post("team-b", `{"namespace":"team-a","renovateJob":"job1","project":"org/repo"}`)
+	post("team-a-readers", `{"namespace":"team-a","renovateJob":"job1","project":"org/repo"}`)
+	post("team-a", `{"namespace":"team-a","renovateJob":"job1","project":"org/broken"}`)
+
+	entries, err := sink.List(context.Background(), audit.Filter{})
+	if err != nil {
+		t.Fatalf("List returned error: %v", err)
+	}
+	if len(entries) != 4 {
+		t.Fatalf("expected an entry per request, got %+v", entries)
+	}
+	want := []struct{ result, reason string }{
+		{audit.ResultFailed, "Internal Server Error"},
+		{audit.ResultDenied, "Forbidden"},
+		{audit.ResultDenied, "Not Found"},
+		{audit.ResultSuccess, ""},
+	}
+	for i, entry := range entries {
+		if entry.Result != want[i].result || entry.Reason != want[i].reason {
+			t.Errorf("entry %d: result %q (%q), want %q (%q)", i, entry.Result, entry.Reason, want[i].result, want[i].reason)
+		}
+		if entry.Actor != "me@example.com" || entry.Source != audit.SourceUI || entry.Action != audit.ActionTrigger ||
+			entry.Namespace != "team-a" || entry.RenovateJob != "job1" {
+			t.Errorf("entry %d: unexpected actor or target: %+v", i, entry)
+		}
+	}
+	if entries[3].Project != "org/repo" || entries[3].Details["debug"] != "true" {
+		t.Errorf("expected the project and the debug detail, got %+v", entries[3])
+	}
+
+	server.audit = nil
+	post("team-a", `{"namespace":"team-a","renovateJob":"job1","project":"org/repo"}`)
+	if entries, _ := sink.List(context.Background(), audit.Filter{}); len(entries) != 4 {
+		t.Errorf("expected nothing to be recorded without a sink, got %d entries", len(entries))
+	}
+}
+
+func TestGetAuditLog(t *testing.T) {
+	jobs := auditTestJobs()
+	sink := newMemoryAuditSink(t)
+	for _, entry := range []audit.Entry{
+		{Actor: "me@example.com", Source: audit.SourceUI, Action: audit.ActionTrigger, Namespace: "team-a", RenovateJob: "job1", Project: "org/repo", Result: audit.ResultSuccess},
+		{Actor: "github", Source: audit.SourceWebhook, Action: audit.ActionTrigger, Namespace: "team-b", RenovateJob: "job2", Project: "org/repo", Result: audit.ResultSuccess},
+		{Actor: "me@example.com", Source: audit.SourceUI, Action: audit.ActionTokenCreate, Result: audit.ResultSuccess},
+		{Actor: "other@example.com", Source: audit.SourceUI, Action: audit.ActionTokenCreate, Result: audit.ResultSuccess},
+		{Source: audit.SourceAnnotation, Action: audit.ActionDiscovery, Namespace: "team-a", RenovateJob: "job1", Result: audit.ResultSuccess},
+	} {
+		sink.Record(context.Background(), entry)
+	}
+
+	server := &Server{
+		manager: &mockRenovateJobManager{
+			listRenovateJobsFullFunc: func(_ context.Context) ([]api.RenovateJob, error) { return jobs, nil },
+		},
+		logger: logr.Discard(),
+		auth:   &OIDCAuth{},
+		audit:  sink,
+	}
+	get := func(target string, groups ...string) (*httptest.ResponseRecorder, []audit.Entry) {
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		session := &sessionData{Email: "me@example.com", Groups: groups}
+		req = req.WithContext(context.WithValue(req.Context(), sessionContextKey, session))
+		w := httptest.NewRecorder()
+		server.getAuditLog(w, req)
+		var entries []audit.Entry
+		if w.Code == http.StatusOK {
+			if err := json.NewDecoder(w.Body).Decode(&entries); err != nil {
+				t.Fatalf("failed to decode entries: %v", err)
+			}
+		}
+		return w, entries
+	}
+
+	t.Run("admins see their jobs and their own actions", func(t *testing.T) {
+		_, entries := get("/api/v1/audit", "team-a")
+		if len(entries) != 3 || entries[0].Source != audit.SourceAnnotation || entries[1].Action != audit.ActionTokenCreate || entries[2].RenovateJob != "job1" {
+			t.Errorf("expected the entries of job1 and the own token, got %+v", entries)
+		}
+	})
+
+	t.Run("readers see no entries of the job", func(t *testing.T) {
+		w, entries := get("/api/v1/audit?renovate=job1", "team-a-readers")
+		if w.Code != http.StatusOK || entries == nil || len(entries) != 0 {
+			t.Errorf("expected an empty list, got %d %+v", w.Code, entries)
+		}
+	})
+
+	t.Run("filters", func(t *testing.T) {
+		_, entries := get("/api/v1/audit?source=webhook&actor=github", "team-a", "team-b")
+		if len(entries) != 1 || entries[0].RenovateJob != "job2" {
+			t.Errorf("expected the webhook entry, got %+v", entries)
+		}
+		_, entries = get("/api/v1/audit?limit=1", "team-a", "team-b")
+		if len(entries) != 1 || entries[0].Source != audit.SourceAnnotation {
+			t.Errorf("expected the newest entry only, got %+v", entries)
+		}
+	})
+
+	t.Run("invalid parameters", func(t *testing.T) {
+		for _, target := range []string{"/api/v1/audit?since=yesterday", "/api/v1/audit?limit=0", "/api/v1/audit?limit=1001"} {
+			if w, _ := get(target, "team-a"); w.Code != http.StatusBadRequest {
+				t.Errorf("%s: expected 400, got %d", target, w.Code)
+			}
+		}
+	})
+
+	t.Run("log sink cannot be queried", func(t *testing.T) {
+		logSink, _ := audit.NewSink(logr.Discard(), "log", kvstore.ValkeyConfig{}, objectstore.S3Config{}, "", time.Hour)
+		server := &Server{logger: logr.Discard(), audit: logSink}
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/audit", nil)
+		w := httptest.NewRecorder()
+		server.getAuditLog(w, req)
+		if w.Code != http.StatusNotImplemented {
+			t.Errorf("expected 501, got %d", w.Code)
+		}
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		server := &Server{logger: logr.Discard()}
+		w := httptest.NewRecorder()
+		server.getAuditLog(w, httptest.NewRequest(http.MethodGet, "/api/v1/audit", nil))
+		if w.Code != http.StatusNotFound {
+			t.Errorf("expected 404, got %d", w.Code)
+		}
+	})
+}
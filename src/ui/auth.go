@@ -8,11 +8,13 @@ import (
 	"crypto/sha256"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
 	"renovate-operator/config"
+	"renovate-operator/internal/apiTokens"
 	"strings"
 	"time"
 
@@ -28,26 +30,40 @@ const (
 
 // currentSessionVersion is bumped whenever a field access control reads is added
 // to sessionData. Sessions minted by an older operator are then discarded.
-const currentSessionVersion = 1
+const currentSessionVersion = 2
 
 type contextKey string
 
-const sessionContextKey contextKey = "session"
+const (
+	sessionContextKey contextKey = "session"
+	// tokenContextKey holds the API token a request authenticated with, next
+	// to the session standing in for its owner.
+	tokenContextKey contextKey = "apiToken"
+	// serviceAccountContextKey marks a request authenticated with a
+	// Kubernetes ServiceAccount token.
+	serviceAccountContextKey contextKey = "serviceAccount"
+)
 
 type sessionData struct {
-	Version       int      `json:"v,omitempty"`
-	Email         string   `json:"email"`
-	Name          string   `json:"name"`
-	EmailVerified bool     `json:"ev,omitempty"`
-	Username      string   `json:"username,omitempty"`
-	Expiry        int64    `json:"exp"`
-	AccessToken   string   `json:"at,omitempty"`
-	Groups        []string `json:"groups"`
+	Version       int    `json:"v,omitempty"`
+	Email         string `json:"email"`
+	Name          string `json:"name"`
+	EmailVerified bool   `json:"ev,omitempty"`
+	Username      string `json:"username,omitempty"`
+	// Subject is the stable identifier the provider reports for the user,
+	// qualified with the provider, e.g. "oidc:<sub>" or "github:<id>". It
+	// keys the user's API tokens, as emails and usernames need not be unique.
+	Subject     string   `json:"sub,omitempty"`
+	Expiry      int64    `json:"exp"`
+	AccessToken string   `json:"at,omitempty"`
+	Groups      []string `json:"groups"`
 }
 
 // identities returns the values user-based access rules may match, normalized
-// the same way the configured rules are.
-func (s *sessionData) identities() []string {
+// the same way the configured rules are. A ServiceAccount name is only an
+// identity of a request that authenticated with a ServiceAccount token, so a
+// login whose username merely looks like one cannot match rules naming it.
+func (s *sessionData) identities(serviceAccount bool) []string {
 	if s == nil {
 		return nil
 	}
@@ -57,9 +73,11 @@ func (s *sessionData) identities() []string {
 		candidates = append(candidates, s.Email)
 	}
 	for _, id := range candidates {
-		if id = strings.ToLower(strings.TrimSpace(id)); id != "" {
-			ids = append(ids, id)
+		id = strings.ToLower(strings.TrimSpace(id))
+		if id == "" || (!serviceAccount && strings.HasPrefix(id, serviceAccountUsernamePrefix)) {
+			continue
 		}
+		ids = append(ids, id)
 	}
 	return ids
 }
@@ -89,6 +107,22 @@ type baseAuth struct {
 	gcm          cipher.AEAD
 	logger       logr.Logger
 	sessionStore SessionStore
+	// tokens verifies API tokens sent as Bearer credentials; nil when API
+	// tokens are disabled
+	tokens apiTokens.Store
+	// serviceAccounts authenticates Kubernetes ServiceAccount tokens sent as
+	// Bearer credentials; nil when they are not accepted
+	serviceAccounts *ServiceAccountAuth
+}
+
+// setAPITokens makes the middleware accept the API tokens of store.
+func (b *baseAuth) setAPITokens(store apiTokens.Store) {
+	b.tokens = store
+}
+
+// setServiceAccountAuth makes the middleware accept ServiceAccount tokens.
+func (b *baseAuth) setServiceAccountAuth(auth *ServiceAccountAuth) {
+	b.serviceAccounts = auth
 }
 
 // ComputeEncryptionKey derives a 32-byte AES key from a session secret.
@@ -196,6 +230,8 @@ func isAnonymousReadPath(path string) bool {
 	case "/", "/index.html", "/logs",
 		"/api/v1/version",
 		"/api/v1/renovatejobs",
+		"/api/v1/renovatejobs/projects",
+		"/api/v1/events",
 		"/api/v1/logs",
 		"/api/v1/discovery/status":
 		return true
@@ -205,24 +241,14 @@ func isAnonymousReadPath(path string) bool {
 
 func (b *baseAuth) authMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		// Strip the configured base path so route matching below operates on
-		// application-relative paths regardless of the sub-path the UI is
-		// served under.
-		base := BasePath()
-		// When BASE_PATH is set, cookies are scoped to that path, so the root "/"
-		// must remain accessible to allow the redirect to the base path.
-		if base != "" && r.URL.Path == "/" {
+		path, ok := appPath(r)
+		// Allow public paths without authentication
+		if !ok || isPublicPath(path) {
 			next.ServeHTTP(w, r)
 			return
 		}
-		path := strings.TrimPrefix(r.URL.Path, base)
-		if path == "" {
-			path = "/"
-		}
 
-		// Allow public paths without authentication
-		if isPublicPath(path) {
-			next.ServeHTTP(w, r)
+		if b.serveBearer(w, r, next, path) {
 			return
 		}
 
@@ -287,11 +313,125 @@ func (b *baseAuth) authMiddleware(next http.Handler) http.Handler {
 	})
 }
 
+// appPath returns the request path relative to the base path, so route
+// matching operates on application-relative paths regardless of the sub-path
+// the UI is served under. It reports false for the root "/" outside the base
+// path: cookies are scoped to the base path, so the root must stay accessible
+// to redirect there.
+func appPath(r *http.Request) (string, bool) {
+	base := BasePath()
+	if base != "" && r.URL.Path == "/" {
+		return "", false
+	}
+	path := strings.TrimPrefix(r.URL.Path, base)
+	if path == "" {
+		path = "/"
+	}
+	return path, true
+}
+
+// serveBearer serves an API request carrying an API token or a ServiceAccount
+// token, and reports whether it did. Only the API accepts them: a token is not
+// meant to drive the UI.
+func (b *baseAuth) serveBearer(w http.ResponseWriter, r *http.Request, next http.Handler, path string) bool {
+	credential, ok := bearerCredential(r)
+	if !ok || !strings.HasPrefix(path, "/api/") {
+		return false
+	}
+	if b.tokens != nil && strings.HasPrefix(credential, apiTokens.Prefix) {
+		b.serveWithAPIToken(w, r, next, credential)
+		return true
+	}
+	if b.serviceAccounts != nil {
+		b.serveWithServiceAccount(w, r, next, credential)
+		return true
+	}
+	return false
+}
+
+// bearerCredential returns the Bearer credential of the Authorization header.
+func bearerCredential(r *http.Request) (string, bool) {
+	scheme, credential, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	credential = strings.TrimSpace(credential)
+	if !ok || !strings.EqualFold(scheme, "Bearer") || credential == "" {
+		return "", false
+	}
+	return credential, true
+}
+
+// serveWithAPIToken serves a request authenticated with an API token. The
+// token's owner stands in as the session, so access rules are evaluated as
+// for them, and the token itself narrows what they allow.
+func (b *baseAuth) serveWithAPIToken(w http.ResponseWriter, r *http.Request, next http.Handler, credential string) {
+	token, err := apiTokens.Verify(r.Context(), b.tokens, credential, time.Now())
+	if err != nil {
+		if errors.Is(err, apiTokens.ErrInvalidToken) {
+			b.logger.V(1).Info("API token rejected",
+				"path", r.URL.Path,
+				"method", r.Method,
+				"remote_addr", r.RemoteAddr)
+		} else {
+			b.logger.Error(err, "failed to verify API token")
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusUnauthorized)
+		_ = json.NewEncoder(w).Encode(map[string]string{"error": "unauthorized"})
+		return
+	}
+
+	session := &sessionData{
+		Version:       currentSessionVersion,
+		Email:         token.Owner.Email,
+		Name:          token.Owner.Name,
+		EmailVerified: token.Owner.EmailVerified,
+		Username:      token.Owner.Username,
+		Subject:       token.Owner.Subject,
+		Expiry:        token.ExpiresAt.Unix(),
+		Groups:        token.Owner.Groups,
+	}
+	if token.IsService() {
+		// Named for the audit and access logs only: access rules are never
+		// evaluated for a service token (see decideJobAccess).
+		session.Username = "service:" + token.Owner.Service
+		session.Name = token.Owner.Service
+	}
+	ctx := context.WithValue(r.Context(), sessionContextKey, session)
+	ctx = context.WithValue(ctx, tokenContextKey, &token)
+	next.ServeHTTP(w, r.WithContext(ctx))
+}
+
+// serveWithServiceAccount serves a request authenticated with a Kubernetes
+// ServiceAccount token. The ServiceAccount stands in as the session, so
+// access rules naming it apply.
+func (b *baseAuth) serveWithServiceAccount(w http.ResponseWriter, r *http.Request, next http.Handler, credential string) {
+	session, err := b.serviceAccounts.authenticate(r.Context(), credential)
+	if err != nil {
+		if errors.Is(err, errServiceAccountRejected) {
+			b.logger.V(1).Info("ServiceAccount token rejected",
+				"path", r.URL.Path,
+				"method", r.Method,
+				"remote_addr", r.RemoteAddr,
+				"reason", err.Error())
+		} else {
+			b.logger.Error(err, "failed to review ServiceAccount token")
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusUnauthorized)
+		_ = json.NewEncoder(w).Encode(map[string]string{"error": "unauthorized"})
+		return
+	}
+
+	ctx := context.WithValue(r.Context(), sessionContextKey, session)
+	ctx = context.WithValue(ctx, serviceAccountContextKey, true)
+	next.ServeHTTP(w, r.WithContext(ctx))
+}
+
 func (b *baseAuth) handleAuthStatus(w http.ResponseWriter, r *http.Request) {
 	session, _ := b.getSession(r)
 
 	result := map[string]any{
-		"enabled": true,
+		"enabled":   true,
+		"apiTokens": b.tokens != nil,
 	}
 
 	if session != nil {
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"net/http"
 	"renovate-operator/internal/telemetry"
+	"strconv"
 	"strings"
 
 	"github.com/go-logr/logr"
@@ -92,7 +93,7 @@ func (g *GitHubOAuth) HandleCallback(w http.ResponseWriter, r *http.Request) {
 	g.logger.Info("token exchange successful")
 
 	// Fetch user info from GitHub API
-	email, name, login, err := g.fetchGitHubUser(oauth2Token.AccessToken)
+	email, name, login, id, err := g.fetchGitHubUser(oauth2Token.AccessToken)
 	if err != nil {
 		g.logger.Error(err, "failed to fetch GitHub user info")
 		http.Error(w, "failed to fetch user info", http.StatusInternalServerError)
@@ -127,6 +128,7 @@ func (g *GitHubOAuth) HandleCallback(w http.ResponseWriter, r *http.Request) {
 		s.Groups = groups
 		s.Username = login
 		s.EmailVerified = true
+		s.Subject = "github:" + strconv.FormatInt(id, 10)
 	})
 	if err != nil {
 		g.logger.Error(err, "failed to build complete URL")
@@ -221,17 +223,24 @@ func (g *GitHubOAuth) fetchGitHubGroups(accessToken string) ([]string, error) {
 	return groups, nil
 }
 
-// maxGitHubPages bounds pagination so one account cannot make a single login
+// maxAPIPages bounds pagination so one account cannot make a single login
 // issue an unbounded number of API calls. At 100 per page this reaches well past
 // the group cap sanitizeGroups applies anyway.
-const maxGitHubPages = 20
+const maxAPIPages = 20
 
-// fetchAllPages follows GitHub's Link-header pagination and returns every item.
-func fetchAllPages[T any](g *GitHubOAuth, accessToken, url string) ([]T, error) {
+// jsonPager fetches one page of a paginated API and returns the next page's
+// URL, or "" on the last page.
+type jsonPager interface {
+	getJSON(accessToken, url string, target any) (nextURL string, err error)
+}
+
+// fetchAllPages follows Link-header pagination, as GitHub and GitLab send it,
+// and returns every item.
+func fetchAllPages[T any](g jsonPager, accessToken, url string) ([]T, error) {
 	var all []T
 	for page := 0; url != ""; page++ {
-		if page >= maxGitHubPages {
-			return nil, fmt.Errorf("more than %d pages of results, refusing to continue", maxGitHubPages)
+		if page >= maxAPIPages {
+			return nil, fmt.Errorf("more than %d pages of results, refusing to continue", maxAPIPages)
 		}
 
 		var batch []T
@@ -275,7 +284,7 @@ func (g *GitHubOAuth) getJSON(accessToken, url string, target any) (nextURL stri
 }
 
 // nextPageURL extracts the rel="next" target from an RFC 8288 Link header as
-// GitHub sends it, or "" when there is no next page.
+// GitHub and GitLab send it, or "" when there is no next page.
 func nextPageURL(link string) string {
 	for _, entry := range strings.Split(link, ",") {
 		parts := strings.Split(entry, ";")
@@ -297,17 +306,17 @@ func nextPageURL(link string) string {
 	return ""
 }
 
-func (g *GitHubOAuth) fetchGitHubUser(accessToken string) (email, name, login string, err error) {
+func (g *GitHubOAuth) fetchGitHubUser(accessToken string) (email, name, login string, id int64, err error) {
 	req, err := http.NewRequest("GET", "https://api.github.com/user", nil)
 	if err != nil {
-		return "", "", "", err
+		return "", "", "", 0, err
 	}
 	req.Header.Set("Authorization", "Bearer "+accessToken)
 	req.Header.Set("Accept", "application/vnd.github+json")
 
 	resp, err := g.httpClient.Do(req)
 	if err != nil {
-		return "", "", "", err
+		return "", "", "", 0, err
 	}
 	defer func() {
 		if err := resp.Body.Close(); err != nil {
@@ -316,16 +325,17 @@ func (g *GitHubOAuth) fetchGitHubUser(accessToken string) (email, name, login st
 	}()
 
 	if resp.StatusCode != http.StatusOK {
-		return "", "", "", fmt.Errorf("GitHub API returned status %d", resp.StatusCode)
+		return "", "", "", 0, fmt.Errorf("GitHub API returned status %d", resp.StatusCode)
 	}
 
 	var user struct {
+		ID    int64  `json:"id"`
 		Login string `json:"login"`
 		Name  string `json:"name"`
 		Email string `json:"email"`
 	}
 	if err := json.NewDecoder(resp.Body).Decode(&user); err != nil {
-		return "", "", "", err
+		return "", "", "", 0, err
 	}
 
 	name = user.Name
@@ -341,7 +351,7 @@ func (g *GitHubOAuth) fetchGitHubUser(accessToken string) (email, name, login st
 		email = user.Login + "@github"
 	}
 
-	return email, name, user.Login, nil
+	return email, name, user.Login, user.ID, nil
 }
 
 func (g *GitHubOAuth) fetchPrimaryEmail(accessToken string) (string, error) {
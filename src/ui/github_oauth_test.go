@@ -146,8 +146,8 @@ func TestFetchAllPagesStopsAtPageLimit(t *testing.T) {
 	if _, err := fetchAllPages[item](provider, "token", srv.URL+"/orgs"); err == nil {
 		t.Fatal("expected an error when the page limit is exceeded, got nil")
 	}
-	if requests != maxGitHubPages {
-		t.Errorf("made %d requests, want the %d page cap", requests, maxGitHubPages)
+	if requests != maxAPIPages {
+		t.Errorf("made %d requests, want the %d page cap", requests, maxAPIPages)
 	}
 }
 
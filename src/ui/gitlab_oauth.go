@@ -0,0 +1,340 @@
+package ui
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"renovate-operator/internal/telemetry"
+	"strconv"
+	"strings"
+
+	"github.com/go-logr/logr"
+	"golang.org/x/oauth2"
+)
+
+// gitLabComURL is the GitLab instance used when no URL is configured.
+const gitLabComURL = "https://gitlab.com"
+
+// gitLabAccessLevel is a GitLab role and the access level the API names it by.
+type gitLabAccessLevel struct {
+	level int
+	name  string
+}
+
+// gitLabAccessLevels are the roles a group membership is mapped to, lowest
+// first. Planner (15) is left out, as older GitLab versions reject it as a
+// filter; planners are mapped as guests.
+var gitLabAccessLevels = []gitLabAccessLevel{
+	{level: 10, name: "guest"},
+	{level: 20, name: "reporter"},
+	{level: 30, name: "developer"},
+	{level: 40, name: "maintainer"},
+	{level: 50, name: "owner"},
+}
+
+// ParseGitLabAccessLevel returns the access level of a GitLab role name.
+func ParseGitLabAccessLevel(name string) (int, error) {
+	for _, accessLevel := range gitLabAccessLevels {
+		if strings.EqualFold(name, accessLevel.name) {
+			return accessLevel.level, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown GitLab role %q: must be one of guest, reporter, developer, maintainer, owner", name)
+}
+
+type GitLabOAuthConfig struct {
+	// URL is the GitLab instance, e.g. https://gitlab.example.com. Defaults
+	// to gitlab.com.
+	URL          string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	// MinAccessLevel is the lowest access level a group membership needs to
+	// be mapped to groups. Defaults to guest.
+	MinAccessLevel      int
+	AllowedGroupPrefix  string
+	AllowedGroupPattern string
+}
+
+// GitLabOAuth logs users in with a GitLab OAuth application and maps every
+// group and subgroup they belong to into two session groups: its full path,
+// e.g. "platform/backend", and the path with their role, e.g.
+// "platform/backend:maintainer".
+type GitLabOAuth struct {
+	baseAuth
+	oauth2Config      oauth2.Config
+	httpClient        *http.Client
+	baseURL           string
+	minAccessLevel    int
+	groupFilterConfig GroupFilterConfig
+}
+
+func NewGitLabOAuth(cfg GitLabOAuthConfig, encryptionKey [32]byte, logger logr.Logger, sessionStore SessionStore) (*GitLabOAuth, error) {
+	baseURL := strings.TrimSuffix(cfg.URL, "/")
+	if baseURL == "" {
+		baseURL = gitLabComURL
+	}
+	if parsed, err := url.Parse(baseURL); err != nil || (parsed.Scheme != "https" && parsed.Scheme != "http") || parsed.Host == "" {
+		return nil, fmt.Errorf("invalid GitLab URL %q: expected an http or https URL", cfg.URL)
+	}
+	minAccessLevel := cfg.MinAccessLevel
+	if minAccessLevel == 0 {
+		minAccessLevel = gitLabAccessLevels[0].level
+	}
+
+	groupFilterConfig, err := NewGroupFilterConfig(cfg.AllowedGroupPrefix, cfg.AllowedGroupPattern)
+	if err != nil {
+		return nil, err
+	}
+
+	oauth2Cfg := oauth2.Config{
+		ClientID:     cfg.ClientID,
+		ClientSecret: cfg.ClientSecret,
+		RedirectURL:  cfg.RedirectURL,
+		Endpoint: oauth2.Endpoint{
+			AuthURL:  baseURL + "/oauth/authorize",
+			TokenURL: baseURL + "/oauth/token",
+		},
+		// read_api is what lists the user's groups with their access level;
+		// read_user alone only covers the profile.
+		Scopes: []string{"read_user", "read_api"},
+	}
+
+	base, err := newBaseAuth(encryptionKey, logger, sessionStore)
+	if err != nil {
+		return nil, err
+	}
+
+	return &GitLabOAuth{
+		baseAuth:          base,
+		oauth2Config:      oauth2Cfg,
+		httpClient:        &http.Client{Transport: telemetry.WrapTransport(http.DefaultTransport)},
+		baseURL:           baseURL,
+		minAccessLevel:    minAccessLevel,
+		groupFilterConfig: groupFilterConfig,
+	}, nil
+}
+
+func (g *GitLabOAuth) AuthMiddleware(next http.Handler) http.Handler {
+	return g.authMiddleware(next)
+}
+
+func (g *GitLabOAuth) HandleLogin(w http.ResponseWriter, r *http.Request) {
+	g.logger.Info("login initiated", "remoteAddr", r.RemoteAddr)
+	state, err := g.setStateCookie(w, r)
+	if err != nil {
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	authURL := g.oauth2Config.AuthCodeURL(state)
+	g.logger.Info("redirecting to GitLab", "url", authURL)
+	http.Redirect(w, r, authURL, http.StatusFound)
+}
+
+func (g *GitLabOAuth) HandleCallback(w http.ResponseWriter, r *http.Request) {
+	// Prevent proxies from caching this response (it contains Set-Cookie headers)
+	w.Header().Set("Cache-Control", "no-store, no-cache, must-revalidate")
+	w.Header().Set("Pragma", "no-cache")
+
+	g.logger.Info("callback received", "hasCode", r.URL.Query().Get("code") != "", "hasState", r.URL.Query().Get("state") != "")
+
+	if err := g.validateStateCookie(r); err != nil {
+		g.logger.Error(err, "state cookie validation failed")
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	g.clearStateCookie(w)
+
+	exchangeCtx := context.WithValue(r.Context(), oauth2.HTTPClient, g.httpClient)
+	oauth2Token, err := g.oauth2Config.Exchange(exchangeCtx, r.URL.Query().Get("code"))
+	if err != nil {
+		g.logger.Error(err, "failed to exchange code for token")
+		http.Error(w, "failed to exchange token", http.StatusInternalServerError)
+		return
+	}
+	g.logger.Info("token exchange successful")
+
+	user, err := g.fetchGitLabUser(oauth2Token.AccessToken)
+	if err != nil {
+		g.logger.Error(err, "failed to fetch GitLab user info")
+		http.Error(w, "failed to fetch user info", http.StatusInternalServerError)
+		return
+	}
+	g.logger.Info("user info fetched", "email", user.Email, "name", user.Name, "username", user.Username)
+
+	fetched, err := g.fetchGitLabGroups(oauth2Token.AccessToken)
+	if err != nil {
+		// As for GitHub: a session missing part of its groups would quietly
+		// lack whatever access they grant, so none is created.
+		g.logger.Error(err, "failed to fetch GitLab group membership, refusing to create a session with incomplete authorization",
+			"email", user.Email, "username", user.Username)
+		http.Error(w, "could not determine your group membership, so no session was created.", http.StatusForbidden)
+		return
+	}
+
+	groups := ValidateAndNormalizeGroups(fetched, g.groupFilterConfig, g.logger)
+	if isGroupFilterDenied(g.groupFilterConfig, groups) {
+		g.logger.Info("Access denied: no groups matching configured filter",
+			"user", user.Email,
+			"groups_before_validation", fetched)
+		http.Redirect(w, r, withBase("/auth/unauthorized"), http.StatusFound)
+		return
+	}
+	g.logger.V(1).Info("GitLab groups fetched", "email", user.Email, "groups", groups)
+
+	completeURL, err := g.buildCompleteURL(r.Context(), user.Email, user.Name, func(s *sessionData) {
+		s.AccessToken = oauth2Token.AccessToken
+		s.Groups = groups
+		s.Username = user.Username
+		s.Subject = "gitlab:" + strconv.FormatInt(user.ID, 10)
+		// GitLab only makes a confirmed address the primary email.
+		s.EmailVerified = true
+	})
+	if err != nil {
+		g.logger.Error(err, "failed to build complete URL")
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+
+	http.Redirect(w, r, completeURL, http.StatusFound)
+}
+
+func (g *GitLabOAuth) HandleComplete(w http.ResponseWriter, r *http.Request) {
+	g.handleComplete(w, r)
+}
+
+func (g *GitLabOAuth) HandleLogout(w http.ResponseWriter, r *http.Request) {
+	if session, err := g.getSession(r); err == nil && session.AccessToken != "" {
+		g.revokeGitLabToken(session.AccessToken)
+	}
+	g.deleteSession(r)
+	g.clearSessionCookie(w)
+	http.Redirect(w, r, withBase("/auth/logged-out"), http.StatusFound)
+}
+
+func (g *GitLabOAuth) revokeGitLabToken(accessToken string) {
+	form := url.Values{
+		"token":         {accessToken},
+		"client_id":     {g.oauth2Config.ClientID},
+		"client_secret": {g.oauth2Config.ClientSecret},
+	}
+	resp, err := g.httpClient.PostForm(g.baseURL+"/oauth/revoke", form)
+	if err != nil {
+		g.logger.Error(err, "failed to revoke GitLab token")
+		return
+	}
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			g.logger.Error(err, "failed to close response body")
+		}
+	}()
+
+	if resp.StatusCode == http.StatusOK {
+		g.logger.Info("GitLab OAuth token revoked successfully")
+	} else {
+		g.logger.Info("GitLab token revocation returned unexpected status", "status", resp.StatusCode)
+	}
+}
+
+func (g *GitLabOAuth) HandleAuthStatus(w http.ResponseWriter, r *http.Request) {
+	g.handleAuthStatus(w, r)
+}
+
+func (g *GitLabOAuth) SupportsGroups() bool {
+	return true
+}
+
+type gitLabUser struct {
+	ID       int64  `json:"id"`
+	Username string `json:"username"`
+	Name     string `json:"name"`
+	Email    string `json:"email"`
+}
+
+func (g *GitLabOAuth) fetchGitLabUser(accessToken string) (gitLabUser, error) {
+	var user gitLabUser
+	if _, err := g.getJSON(accessToken, g.baseURL+"/api/v4/user", &user); err != nil {
+		return gitLabUser{}, err
+	}
+	if user.Username == "" {
+		return gitLabUser{}, errors.New("GitLab returned a user without a username")
+	}
+	if user.Name == "" {
+		user.Name = user.Username
+	}
+	if user.Email == "" {
+		user.Email = user.Username + "@gitlab"
+	}
+	return user, nil
+}
+
+// fetchGitLabGroups returns the full path of every group and subgroup the
+// user holds at least the minimum access level in, each followed by the path
+// with the user's role. The groups API does not report the access level, so
+// it is listed once per role and a group's role is the highest it is listed
+// for; that also covers membership inherited from a parent group.
+func (g *GitLabOAuth) fetchGitLabGroups(accessToken string) ([]string, error) {
+	type gitLabGroup struct {
+		FullPath string `json:"full_path"`
+	}
+
+	roles := make(map[string]string)
+	var paths []string
+	for _, accessLevel := range gitLabAccessLevels {
+		if accessLevel.level < g.minAccessLevel {
+			continue
+		}
+		listURL := fmt.Sprintf("%s/api/v4/groups?min_access_level=%d&all_available=false&per_page=100", g.baseURL, accessLevel.level)
+		groups, err := fetchAllPages[gitLabGroup](g, accessToken, listURL)
+		if err != nil {
+			return nil, fmt.Errorf("fetching %s groups: %w", accessLevel.name, err)
+		}
+		for _, group := range groups {
+			if group.FullPath == "" {
+				continue
+			}
+			if _, seen := roles[group.FullPath]; !seen {
+				paths = append(paths, group.FullPath)
+			}
+			roles[group.FullPath] = accessLevel.name
+		}
+	}
+
+	result := make([]string, 0, 2*len(paths))
+	for _, path := range paths {
+		result = append(result, path, path+":"+roles[path])
+	}
+	return result, nil
+}
+
+func (g *GitLabOAuth) getJSON(accessToken, url string, target any) (nextURL string, err error) {
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := g.httpClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			g.logger.Error(err, "failed to close response body")
+		}
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("GitLab API %s returned status %d", url, resp.StatusCode)
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(target); err != nil {
+		return "", err
+	}
+
+	return nextPageURL(resp.Header.Get("Link")), nil
+}
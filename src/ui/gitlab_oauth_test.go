@@ -0,0 +1,204 @@
+package ui
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"strings"
+	"testing"
+
+	"renovate-operator/config"
+
+	"github.com/go-logr/logr"
+)
+
+// fakeGitLab serves the OAuth and API endpoints a login uses. memberships maps
+// group paths to the user's access level; the groups API lists a group for
+// every min_access_level at or below it, one group per page.
+func fakeGitLab(t *testing.T, memberships map[string]int) *httptest.Server {
+	t.Helper()
+	var srv *httptest.Server
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /oauth/token", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"access_token":"gitlab-token","token_type":"Bearer"}`))
+	})
+	mux.HandleFunc("GET /api/v4/user", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer gitlab-token" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		_, _ = w.Write([]byte(`{"username":"alice","name":"Alice","email":"alice@example.com"}`))
+	})
+	mux.HandleFunc("GET /api/v4/groups", func(w http.ResponseWriter, r *http.Request) {
+		var minLevel, page int
+		_, _ = fmt.Sscan(r.URL.Query().Get("min_access_level"), &minLevel)
+		_, _ = fmt.Sscan(r.URL.Query().Get("page"), &page)
+		var paths []string
+		for path, level := range memberships {
+			if level >= minLevel {
+				paths = append(paths, path)
+			}
+		}
+		slices.Sort(paths)
+		if page < len(paths)-1 {
+			next := *r.URL
+			query := next.Query()
+			query.Set("page", fmt.Sprint(page+1))
+			next.RawQuery = query.Encode()
+			w.Header().Set("Link", fmt.Sprintf(`<%s%s>; rel="next"`, srv.URL, next.RequestURI()))
+		}
+		groups := []map[string]string{}
+		if page < len(paths) {
+			groups = append(groups, map[string]string{"full_path": paths[page]})
+		}
+		_ = json.NewEncoder(w).Encode(groups)
+	})
+	srv = httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func newTestGitLabOAuth(t *testing.T, cfg GitLabOAuthConfig) *GitLabOAuth {
+	t.Helper()
+	defs := []config.ConfigItemDescription{
+		{Key: "WEBHOOK_SERVER_UNIFIED_HOST", Optional: true, Default: "false"},
+	}
+	if err := config.InitializeConfigModule(defs); err != nil {
+		t.Fatalf("failed to initialize config module: %v", err)
+	}
+	auth, err := NewGitLabOAuth(cfg, testEncryptionKey(t), logr.Discard(), nil)
+	if err != nil {
+		t.Fatalf("NewGitLabOAuth returned error: %v", err)
+	}
+	return auth
+}
+
+// gitLabLogin runs the OAuth flow and returns the callback response.
+func gitLabLogin(t *testing.T, auth *GitLabOAuth) *httptest.ResponseRecorder {
+	t.Helper()
+	w := httptest.NewRecorder()
+	auth.HandleLogin(w, httptest.NewRequest(http.MethodGet, "/auth/login", nil))
+	authURL, err := url.Parse(w.Header().Get("Location"))
+	if err != nil {
+		t.Fatalf("invalid authorization URL: %v", err)
+	}
+	if !strings.HasSuffix(authURL.Path, "/oauth/authorize") || !strings.Contains(authURL.Query().Get("scope"), "read_api") {
+		t.Fatalf("expected to be sent to GitLab with read_api, got %s", authURL)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/auth/callback?code=code&state="+url.QueryEscape(authURL.Query().Get("state")), nil)
+	for _, cookie := range w.Result().Cookies() {
+		req.AddCookie(cookie)
+	}
+	w = httptest.NewRecorder()
+	auth.HandleCallback(w, req)
+	return w
+}
+
+// completedSession decrypts the session the callback hands to /auth/complete.
+func completedSession(t *testing.T, auth *GitLabOAuth, w *httptest.ResponseRecorder) *sessionData {
+	t.Helper()
+	location, err := url.Parse(w.Header().Get("Location"))
+	if err != nil || !strings.HasSuffix(location.Path, "/auth/complete") {
+		t.Fatalf("expected a redirect to /auth/complete, got %d %q", w.Code, w.Header().Get("Location"))
+	}
+	session, err := auth.decryptSessionData(location.Query().Get("s"))
+	if err != nil {
+		t.Fatalf("failed to decrypt session: %v", err)
+	}
+	return session
+}
+
+func TestParseGitLabAccessLevel(t *testing.T) {
+	if level, err := ParseGitLabAccessLevel("Maintainer"); err != nil || level != 40 {
+		t.Errorf("ParseGitLabAccessLevel(Maintainer) = %d, %v", level, err)
+	}
+	if _, err := ParseGitLabAccessLevel("admin"); err == nil {
+		t.Error("expected an unknown role to be rejected")
+	}
+}
+
+func TestNewGitLabOAuth_Validation(t *testing.T) {
+	if _, err := NewGitLabOAuth(GitLabOAuthConfig{URL: "gitlab.example.com"}, testEncryptionKey(t), logr.Discard(), nil); err == nil {
+		t.Error("expected a URL without scheme to be rejected")
+	}
+	if _, err := NewGitLabOAuth(GitLabOAuthConfig{AllowedGroupPattern: "("}, testEncryptionKey(t), logr.Discard(), nil); err == nil {
+		t.Error("expected an invalid group pattern to be rejected")
+	}
+	auth, err := NewGitLabOAuth(GitLabOAuthConfig{URL: "https://gitlab.example.com/"}, testEncryptionKey(t), logr.Discard(), nil)
+	if err != nil {
+		t.Fatalf("NewGitLabOAuth returned error: %v", err)
+	}
+	if auth.oauth2Config.Endpoint.TokenURL != "https://gitlab.example.com/oauth/token" || !auth.SupportsGroups() {
+		t.Errorf("unexpected provider: %+v", auth.oauth2Config.Endpoint)
+	}
+}
+
+func TestGitLabOAuth_LoginMapsGroupsWithRoles(t *testing.T) {
+	srv := fakeGitLab(t, map[string]int{
+		"platform":          50,
+		"platform/backend":  50,
+		"platform/frontend": 20,
+		"Docs":              10,
+	})
+	auth := newTestGitLabOAuth(t, GitLabOAuthConfig{URL: srv.URL, ClientID: "id", ClientSecret: "secret"})
+
+	session := completedSession(t, auth, gitLabLogin(t, auth))
+	if session.Email != "alice@example.com" || session.Username != "alice" || !session.EmailVerified {
+		t.Errorf("unexpected identity: %+v", session)
+	}
+	want := []string{
+		"docs", "docs:guest",
+		"platform", "platform:owner",
+		"platform/backend", "platform/backend:owner",
+		"platform/frontend", "platform/frontend:reporter",
+	}
+	if !slices.Equal(session.Groups, want) {
+		t.Errorf("groups = %v, want %v", session.Groups, want)
+	}
+	if session.AccessToken != "gitlab-token" {
+		t.Error("expected the access token to be kept for revocation")
+	}
+}
+
+func TestGitLabOAuth_MinAccessLevelAndFilter(t *testing.T) {
+	srv := fakeGitLab(t, map[string]int{
+		"renovate-admins": 30,
+		"renovate-docs":   10,
+		"platform":        40,
+	})
+
+	auth := newTestGitLabOAuth(t, GitLabOAuthConfig{URL: srv.URL, MinAccessLevel: 30, AllowedGroupPrefix: "renovate-"})
+	session := completedSession(t, auth, gitLabLogin(t, auth))
+	if want := []string{"renovate-admins", "renovate-admins:developer"}; !slices.Equal(session.Groups, want) {
+		t.Errorf("groups = %v, want %v", session.Groups, want)
+	}
+
+	auth = newTestGitLabOAuth(t, GitLabOAuthConfig{URL: srv.URL, AllowedGroupPrefix: "security-"})
+	if w := gitLabLogin(t, auth); w.Code != http.StatusFound || !strings.HasSuffix(w.Header().Get("Location"), "/auth/unauthorized") {
+		t.Errorf("expected a user without matching groups to be refused, got %d %q", w.Code, w.Header().Get("Location"))
+	}
+}
+
+// TestGitLabOAuth_FailedGroupLookupFails mirrors the GitHub rule: a refused
+// membership lookup, as for a token without read_api, yields no partial group
+// list to create a session with.
+func TestGitLabOAuth_FailedGroupLookupFails(t *testing.T) {
+	provider := &GitLabOAuth{
+		baseAuth:       baseAuth{logger: logr.Discard()},
+		httpClient:     &http.Client{Transport: forbiddenTransport{}},
+		baseURL:        gitLabComURL,
+		minAccessLevel: 10,
+	}
+	groups, err := provider.fetchGitLabGroups("token")
+	if err == nil {
+		t.Fatal("expected an error when membership cannot be determined, got nil")
+	}
+	if groups != nil {
+		t.Errorf("groups = %v, want nil so no partial set can be used", groups)
+	}
+}
@@ -0,0 +1,276 @@
+package ui
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/netip"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+)
+
+// HeaderAuthConfig configures authentication by an identity-aware proxy, such
+// as oauth2-proxy or Pomerium, which logs users in and forwards who they are
+// in request headers.
+type HeaderAuthConfig struct {
+	EmailHeader     string
+	UsernameHeader  string
+	NameHeader      string
+	GroupsHeader    string
+	GroupsSeparator string
+	// TrustedProxies are the addresses the proxy connects from. Identity
+	// headers from anywhere else are ignored.
+	TrustedProxies []netip.Prefix
+	// SecretHeader carries Secret, which the proxy adds to every request.
+	SecretHeader string
+	Secret       string
+	// LogoutURL is the proxy's sign-out endpoint, e.g. /oauth2/sign_out.
+	LogoutURL           string
+	AllowedGroupPrefix  string
+	AllowedGroupPattern string
+}
+
+// HeaderAuth trusts the identity headers of requests that come from a
+// configured proxy address or carry the shared secret; when both are
+// configured, a request has to satisfy both. There is no session of its own:
+// every request is authenticated by its headers, and login and logout are
+// the proxy's.
+type HeaderAuth struct {
+	baseAuth
+	cfg               HeaderAuthConfig
+	groupFilterConfig GroupFilterConfig
+}
+
+// ParseTrustedProxies parses CIDRs and single addresses.
+func ParseTrustedProxies(entries []string) ([]netip.Prefix, error) {
+	prefixes := make([]netip.Prefix, 0, len(entries))
+	for _, entry := range entries {
+		if prefix, err := netip.ParsePrefix(entry); err == nil {
+			prefixes = append(prefixes, prefix.Masked())
+			continue
+		}
+		addr, err := netip.ParseAddr(entry)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy %q: expected a CIDR or an address", entry)
+		}
+		prefixes = append(prefixes, netip.PrefixFrom(addr.Unmap(), addr.Unmap().BitLen()))
+	}
+	return prefixes, nil
+}
+
+func NewHeaderAuth(cfg HeaderAuthConfig, encryptionKey [32]byte, logger logr.Logger) (*HeaderAuth, error) {
+	if len(cfg.TrustedProxies) == 0 && cfg.Secret == "" {
+		return nil, fmt.Errorf("header authentication needs trusted proxies or a shared secret, otherwise anybody can claim any identity")
+	}
+	if cfg.Secret != "" && cfg.SecretHeader == "" {
+		return nil, fmt.Errorf("header authentication with a shared secret needs the header carrying it")
+	}
+	if cfg.EmailHeader == "" && cfg.UsernameHeader == "" {
+		return nil, fmt.Errorf("header authentication needs an email or a username header")
+	}
+	if cfg.GroupsSeparator == "" {
+		cfg.GroupsSeparator = ","
+	}
+	groupFilterConfig, err := NewGroupFilterConfig(cfg.AllowedGroupPrefix, cfg.AllowedGroupPattern)
+	if err != nil {
+		return nil, err
+	}
+	// Cookies are never issued, so the key only backs the shared helpers.
+	base, err := newBaseAuth(encryptionKey, logger, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &HeaderAuth{baseAuth: base, cfg: cfg, groupFilterConfig: groupFilterConfig}, nil
+}
+
+// trusted reports whether the request comes from the proxy.
+func (h *HeaderAuth) trusted(r *http.Request) bool {
+	if len(h.cfg.TrustedProxies) > 0 {
+		addrPort, err := netip.ParseAddrPort(r.RemoteAddr)
+		if err != nil {
+			return false
+		}
+		addr := addrPort.Addr().Unmap()
+		if !containsAddr(h.cfg.TrustedProxies, addr) {
+			return false
+		}
+	}
+	if h.cfg.Secret != "" {
+		secret := r.Header.Get(h.cfg.SecretHeader)
+		if subtle.ConstantTimeCompare([]byte(secret), []byte(h.cfg.Secret)) != 1 {
+			return false
+		}
+	}
+	return true
+}
+
+func containsAddr(prefixes []netip.Prefix, addr netip.Addr) bool {
+	for _, prefix := range prefixes {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// errHeaderGroupsDenied is returned for a user none of whose groups pass the
+// configured group filter.
+var errHeaderGroupsDenied = errors.New("no groups matching the configured filter")
+
+// session returns the identity the proxy forwarded, or nil when the request
+// did not come from the proxy or names nobody.
+func (h *HeaderAuth) session(r *http.Request) (*sessionData, error) {
+	email := strings.TrimSpace(headerValue(r, h.cfg.EmailHeader))
+	username := strings.TrimSpace(headerValue(r, h.cfg.UsernameHeader))
+	if email == "" && username == "" {
+		return nil, nil
+	}
+	if !h.trusted(r) {
+		h.logger.Info("identity headers ignored, the request did not come from the trusted proxy",
+			"remote_addr", r.RemoteAddr,
+			"path", r.URL.Path)
+		return nil, nil
+	}
+
+	var groups []string
+	if h.cfg.GroupsHeader != "" {
+		// A proxy may repeat the header instead of joining the values.
+		for _, value := range r.Header.Values(h.cfg.GroupsHeader) {
+			groups = append(groups, strings.Split(value, h.cfg.GroupsSeparator)...)
+		}
+	}
+	validatedGroups := ValidateAndNormalizeGroups(groups, h.groupFilterConfig, h.logger)
+	if isGroupFilterDenied(h.groupFilterConfig, validatedGroups) {
+		return nil, errHeaderGroupsDenied
+	}
+
+	name := strings.TrimSpace(headerValue(r, h.cfg.NameHeader))
+	if name == "" {
+		name = username
+	}
+	var subject string
+	if username != "" {
+		subject = "header:" + username
+	}
+	return &sessionData{
+		Version: currentSessionVersion,
+		Email:   email,
+		// The proxy vouches for the address, as it authenticated the user.
+		EmailVerified: email != "",
+		Name:          name,
+		Username:      username,
+		Subject:       subject,
+		Groups:        validatedGroups,
+		Expiry:        time.Now().Add(sessionDuration).Unix(),
+	}, nil
+}
+
+func headerValue(r *http.Request, header string) string {
+	if header == "" {
+		return ""
+	}
+	return r.Header.Get(header)
+}
+
+func (h *HeaderAuth) AuthMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path, ok := appPath(r)
+		if !ok || isPublicPath(path) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if h.serveBearer(w, r, next, path) {
+			return
+		}
+
+		session, err := h.session(r)
+		if err != nil {
+			h.logger.Info("Access denied: no groups matching configured filter",
+				"user", headerValue(r, h.cfg.EmailHeader),
+				"path", path)
+			if strings.HasPrefix(path, "/api/") {
+				writeError(w, HttpResultError{Message: "forbidden", StatusCode: http.StatusForbidden})
+				return
+			}
+			http.Redirect(w, r, withBase("/auth/unauthorized"), http.StatusFound)
+			return
+		}
+		if session == nil {
+			if isAnonymousReadPath(path) {
+				next.ServeHTTP(w, r)
+				return
+			}
+			h.logger.V(1).Info("Unauthenticated request rejected",
+				"path", path,
+				"method", r.Method,
+				"remote_addr", r.RemoteAddr,
+				"user_agent", r.UserAgent())
+			// There is no login to redirect to: the proxy signs users in.
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusUnauthorized)
+			_ = json.NewEncoder(w).Encode(map[string]string{"error": "unauthorized"})
+			return
+		}
+
+		ctx := context.WithValue(r.Context(), sessionContextKey, session)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// HandleLogin sends a user the proxy signed in back to the UI. Anybody else
+// reached the operator around the proxy, which no redirect can fix; sending
+// them to the UI would only bring them back here.
+func (h *HeaderAuth) HandleLogin(w http.ResponseWriter, r *http.Request) {
+	if session, err := h.session(r); err == nil && session != nil {
+		http.Redirect(w, r, withBase("/"), http.StatusFound)
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain")
+	w.WriteHeader(http.StatusUnauthorized)
+	_, _ = fmt.Fprint(w, "Sign-in is handled by the authenticating proxy in front of the renovate-operator, "+
+		"and this request did not come through it. Open the UI through the proxy.")
+}
+
+func (h *HeaderAuth) HandleCallback(w http.ResponseWriter, r *http.Request) {
+	http.NotFound(w, r)
+}
+
+func (h *HeaderAuth) HandleComplete(w http.ResponseWriter, r *http.Request) {
+	http.Redirect(w, r, withBase("/"), http.StatusFound)
+}
+
+func (h *HeaderAuth) HandleLogout(w http.ResponseWriter, r *http.Request) {
+	if h.cfg.LogoutURL != "" {
+		http.Redirect(w, r, h.cfg.LogoutURL, http.StatusFound)
+		return
+	}
+	http.Redirect(w, r, withBase("/auth/logged-out"), http.StatusFound)
+}
+
+func (h *HeaderAuth) HandleAuthStatus(w http.ResponseWriter, r *http.Request) {
+	session, _ := h.session(r)
+
+	result := map[string]any{
+		"enabled":   true,
+		"apiTokens": h.tokens != nil,
+	}
+	if session != nil {
+		result["authenticated"] = true
+		result["email"] = session.Email
+		result["name"] = session.Name
+	} else {
+		result["authenticated"] = false
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(result)
+}
+
+func (h *HeaderAuth) SupportsGroups() bool {
+	return true
+}
@@ -0,0 +1,232 @@
+package ui
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"slices"
+	"testing"
+
+	"renovate-operator/config"
+
+	"github.com/go-logr/logr"
+)
+
+func newTestHeaderAuth(t *testing.T, cfg HeaderAuthConfig) *HeaderAuth {
+	t.Helper()
+	defs := []config.ConfigItemDescription{
+		{Key: "WEBHOOK_SERVER_UNIFIED_HOST", Optional: true, Default: "false"},
+	}
+	if err := config.InitializeConfigModule(defs); err != nil {
+		t.Fatalf("failed to initialize config module: %v", err)
+	}
+	if cfg.EmailHeader == "" {
+		cfg.EmailHeader = "X-Auth-Request-Email"
+	}
+	cfg.UsernameHeader = "X-Auth-Request-Preferred-Username"
+	cfg.GroupsHeader = "X-Auth-Request-Groups"
+	auth, err := NewHeaderAuth(cfg, testEncryptionKey(t), logr.Discard())
+	if err != nil {
+		t.Fatalf("NewHeaderAuth returned error: %v", err)
+	}
+	return auth
+}
+
+func TestParseTrustedProxies(t *testing.T) {
+	prefixes, err := ParseTrustedProxies([]string{"10.0.0.0/8", "192.168.1.7", "fd00::1/64", "::ffff:172.16.0.1"})
+	if err != nil {
+		t.Fatalf("ParseTrustedProxies returned error: %v", err)
+	}
+	want := []netip.Prefix{
+		netip.MustParsePrefix("10.0.0.0/8"),
+		netip.MustParsePrefix("192.168.1.7/32"),
+		netip.MustParsePrefix("fd00::/64"),
+		netip.MustParsePrefix("172.16.0.1/32"),
+	}
+	if !slices.Equal(prefixes, want) {
+		t.Errorf("prefixes = %v, want %v", prefixes, want)
+	}
+	if _, err := ParseTrustedProxies([]string{"proxy.example.com"}); err == nil {
+		t.Error("expected a hostname to be rejected")
+	}
+}
+
+func TestNewHeaderAuth_RequiresTrust(t *testing.T) {
+	tests := map[string]HeaderAuthConfig{
+		"neither proxies nor secret": {EmailHeader: "X-Email"},
+		"secret without header":      {EmailHeader: "X-Email", Secret: "s3cret"},
+		"no identity header":         {Secret: "s3cret", SecretHeader: "X-Proxy-Secret"},
+		"invalid group pattern":      {EmailHeader: "X-Email", Secret: "s3cret", SecretHeader: "X-Proxy-Secret", AllowedGroupPattern: "("},
+	}
+	for name, cfg := range tests {
+		if _, err := NewHeaderAuth(cfg, testEncryptionKey(t), logr.Discard()); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestHeaderAuth_Middleware(t *testing.T) {
+	proxies := []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")}
+	tests := []struct {
+		name        string
+		cfg         HeaderAuthConfig
+		remoteAddr  string
+		path        string
+		headers     map[string]string
+		wantHandler bool
+		wantStatus  int
+		wantSession bool
+	}{
+		{
+			name:       "trusted proxy address",
+			cfg:        HeaderAuthConfig{TrustedProxies: proxies},
+			remoteAddr: "10.1.2.3:41000", path: "/api/v1/renovate",
+			headers:     map[string]string{"X-Auth-Request-Email": "alice@example.com", "X-Auth-Request-Groups": "Team-A, team-b"},
+			wantHandler: true, wantStatus: http.StatusOK, wantSession: true,
+		},
+		{
+			name:       "headers from elsewhere are ignored",
+			cfg:        HeaderAuthConfig{TrustedProxies: proxies},
+			remoteAddr: "192.168.1.1:41000", path: "/api/v1/renovate",
+			headers:     map[string]string{"X-Auth-Request-Email": "alice@example.com"},
+			wantHandler: false, wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "shared secret",
+			cfg:        HeaderAuthConfig{SecretHeader: "X-Proxy-Secret", Secret: "s3cret"},
+			remoteAddr: "192.168.1.1:41000", path: "/api/v1/renovate",
+			headers:     map[string]string{"X-Auth-Request-Email": "alice@example.com", "X-Auth-Request-Groups": "team-a,team-b", "X-Proxy-Secret": "s3cret"},
+			wantHandler: true, wantStatus: http.StatusOK, wantSession: true,
+		},
+		{
+			name:       "wrong shared secret",
+			cfg:        HeaderAuthConfig{SecretHeader: "X-Proxy-Secret", Secret: "s3cret"},
+			remoteAddr: "10.1.2.3:41000", path: "/api/v1/renovate",
+			headers:     map[string]string{"X-Auth-Request-Email": "alice@example.com", "X-Proxy-Secret": "guess"},
+			wantHandler: false, wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "both configured need both",
+			cfg:        HeaderAuthConfig{TrustedProxies: proxies, SecretHeader: "X-Proxy-Secret", Secret: "s3cret"},
+			remoteAddr: "192.168.1.1:41000", path: "/api/v1/renovate",
+			headers:     map[string]string{"X-Auth-Request-Email": "alice@example.com", "X-Proxy-Secret": "s3cret"},
+			wantHandler: false, wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "group filter leaves nothing on the API",
+			cfg:        HeaderAuthConfig{TrustedProxies: proxies, AllowedGroupPrefix: "renovate-"},
+			remoteAddr: "10.1.2.3:41000", path: "/api/v1/renovate",
+			headers:     map[string]string{"X-Auth-Request-Email": "alice@example.com", "X-Auth-Request-Groups": "team-a"},
+			wantHandler: false, wantStatus: http.StatusForbidden,
+		},
+		{
+			name:       "group filter leaves nothing in the UI",
+			cfg:        HeaderAuthConfig{TrustedProxies: proxies, AllowedGroupPrefix: "renovate-"},
+			remoteAddr: "10.1.2.3:41000", path: "/some-page",
+			headers:     map[string]string{"X-Auth-Request-Email": "alice@example.com", "X-Auth-Request-Groups": "team-a"},
+			wantHandler: false, wantStatus: http.StatusFound,
+		},
+		{
+			name:       "anonymous read paths pass without identity",
+			cfg:        HeaderAuthConfig{TrustedProxies: proxies},
+			remoteAddr: "192.168.1.1:41000", path: "/api/v1/renovatejobs",
+			wantHandler: true, wantStatus: http.StatusOK,
+		},
+		{
+			name:       "public paths pass",
+			cfg:        HeaderAuthConfig{TrustedProxies: proxies},
+			remoteAddr: "192.168.1.1:41000", path: "/health",
+			wantHandler: true, wantStatus: http.StatusOK,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			auth := newTestHeaderAuth(t, tt.cfg)
+			reached := false
+			middleware := auth.AuthMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				reached = true
+				session := getSessionFromContext(r)
+				if tt.wantSession {
+					if session == nil || session.Email != "alice@example.com" || !session.EmailVerified ||
+						!slices.Equal(session.Groups, []string{"team-a", "team-b"}) {
+						t.Errorf("expected the forwarded identity as the session, got %+v", session)
+					}
+				} else if session != nil {
+					t.Errorf("expected no session, got %+v", session)
+				}
+				w.WriteHeader(http.StatusOK)
+			}))
+
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			req.RemoteAddr = tt.remoteAddr
+			for header, value := range tt.headers {
+				req.Header.Set(header, value)
+			}
+			w := httptest.NewRecorder()
+			middleware.ServeHTTP(w, req)
+
+			if reached != tt.wantHandler {
+				t.Errorf("handler reached = %v, want %v", reached, tt.wantHandler)
+			}
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestHeaderAuth_RepeatedGroupHeaders(t *testing.T) {
+	auth := newTestHeaderAuth(t, HeaderAuthConfig{SecretHeader: "X-Proxy-Secret", Secret: "s3cret", GroupsSeparator: "|"})
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/renovate", nil)
+	req.Header.Set("X-Proxy-Secret", "s3cret")
+	req.Header.Set("X-Auth-Request-Preferred-Username", "alice")
+	req.Header.Add("X-Auth-Request-Groups", "team-a|team-b")
+	req.Header.Add("X-Auth-Request-Groups", "team-c")
+
+	session, err := auth.session(req)
+	if err != nil || session == nil {
+		t.Fatalf("expected a session, got %+v, %v", session, err)
+	}
+	if session.Username != "alice" || session.Name != "alice" || session.EmailVerified || session.Subject != "header:alice" {
+		t.Errorf("expected a username-only session, got %+v", session)
+	}
+	if !slices.Equal(session.Groups, []string{"team-a", "team-b", "team-c"}) {
+		t.Errorf("groups = %v", session.Groups)
+	}
+}
+
+func TestHeaderAuth_LoginAndStatus(t *testing.T) {
+	auth := newTestHeaderAuth(t, HeaderAuthConfig{SecretHeader: "X-Proxy-Secret", Secret: "s3cret"})
+	if !auth.SupportsGroups() {
+		t.Error("expected header authentication to support groups")
+	}
+
+	signedIn := func(path string) *http.Request {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set("X-Proxy-Secret", "s3cret")
+		req.Header.Set("X-Auth-Request-Email", "alice@example.com")
+		return req
+	}
+
+	w := httptest.NewRecorder()
+	auth.HandleLogin(w, signedIn("/auth/login"))
+	if w.Code != http.StatusFound {
+		t.Errorf("expected a signed-in user to be sent to the UI, got %d", w.Code)
+	}
+	w = httptest.NewRecorder()
+	auth.HandleLogin(w, httptest.NewRequest(http.MethodGet, "/auth/login", nil))
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("expected a request around the proxy not to be redirected, got %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	auth.HandleAuthStatus(w, signedIn("/api/v1/auth/status"))
+	var status map[string]any
+	if err := json.NewDecoder(w.Body).Decode(&status); err != nil {
+		t.Fatalf("failed to decode status: %v", err)
+	}
+	if status["authenticated"] != true || status["email"] != "alice@example.com" {
+		t.Errorf("expected the forwarded identity in the status, got %v", status)
+	}
+}
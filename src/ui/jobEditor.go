@@ -0,0 +1,483 @@
+package ui
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	api "renovate-operator/api/v1alpha1"
+	"renovate-operator/internal/policy"
+	"renovate-operator/scheduler"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/events"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// anyNamespace in the namespace allow-list stands for every group as a key and
+// for every namespace as a value.
+const anyNamespace = "*"
+
+// Event reasons recorded on the RenovateJobs changed through the UI API.
+const (
+	reasonCreatedFromUI = "CreatedFromUI"
+	reasonEditedFromUI  = "EditedFromUI"
+	reasonDeletedFromUI = "DeletedFromUI"
+)
+
+// JobEditing configures the endpoints that create, edit and delete
+// RenovateJobs.
+type JobEditing struct {
+	// Reader reads the job to change from the API server rather than the
+	// cache, so an edit never writes back fields the cache has not caught up
+	// with.
+	Reader   client.Reader
+	Writer   client.Writer
+	Policy   policy.Policy
+	Recorder events.EventRecorder
+	// Namespaces maps groups to the namespaces their members may create, edit
+	// and delete jobs in. The group "*" applies to every request and the
+	// namespace "*" to every namespace. Holding admin access on a job is
+	// required on top.
+	Namespaces map[string][]string
+}
+
+// ParseEditableNamespaces reads the namespace allow-list of job editing, a
+// JSON object of groups to namespaces. Groups are normalized like session
+// groups.
+func ParseEditableNamespaces(raw string) (map[string][]string, error) {
+	if raw == "" {
+		return nil, nil
+	}
+	var parsed map[string][]string
+	if err := json.Unmarshal([]byte(raw), &parsed); err != nil {
+		return nil, fmt.Errorf("expected a JSON object of groups to namespaces: %w", err)
+	}
+	namespaces := make(map[string][]string, len(parsed))
+	for group, list := range parsed {
+		group = strings.ToLower(strings.TrimSpace(group))
+		if group == "" {
+			return nil, fmt.Errorf("empty group")
+		}
+		for _, namespace := range list {
+			if namespace == "" {
+				return nil, fmt.Errorf("group %q lists an empty namespace", group)
+			}
+		}
+		namespaces[group] = append(namespaces[group], list...)
+	}
+	return namespaces, nil
+}
+
+// SetJobEditing enables the endpoints that create, edit and delete
+// RenovateJobs.
+func (s *Server) SetJobEditing(editing JobEditing) {
+	s.editing = &editing
+}
+
+// namespaceEditable reports whether the request's groups may change jobs in
+// namespace.
+func (e *JobEditing) namespaceEditable(session *sessionData, namespace string) bool {
+	groups := []string{anyNamespace}
+	if session != nil {
+		groups = append(groups, normalizeGroups(session.Groups)...)
+	}
+	for _, group := range groups {
+		if allowed := e.Namespaces[group]; slices.Contains(allowed, anyNamespace) || slices.Contains(allowed, namespace) {
+			return true
+		}
+	}
+	return false
+}
+
+// jobSpecFields is the part of a RenovateJobSpec the UI API may set. Unset
+// fields are left as they are. Everything that decides what the pods run as
+// or can reach beyond the platform - service account, security context,
+// volumes, env, scheduling - is only changed through the resource itself.
+type jobSpecFields struct {
+	Schedule            *string                `json:"schedule,omitempty"`
+	DiscoverySchedule   *string                `json:"discoverySchedule,omitempty"`
+	Image               *string                `json:"image,omitempty"`
+	Provider            *api.RenovateProvider  `json:"provider,omitempty"`
+	SecretRef           *string                `json:"secretRef,omitempty"`
+	Parallelism         *int32                 `json:"parallelism,omitempty"`
+	DiscoveryFilters    *[]string              `json:"discoveryFilters,omitempty"`
+	DiscoverTopics      *[]string              `json:"discoverTopics,omitempty"`
+	Repositories        *[]string              `json:"repositories,omitempty"`
+	ExcludeRepositories *[]string              `json:"excludeRepositories,omitempty"`
+	SkipForks           *bool                  `json:"skipForks,omitempty"`
+	SkipArchived        *bool                  `json:"skipArchived,omitempty"`
+	SkipEmpty           *bool                  `json:"skipEmpty,omitempty"`
+	SkipInactiveFor     *string                `json:"skipInactiveFor,omitempty"`
+	Visibility          *[]string              `json:"visibility,omitempty"`
+	Access              *api.RenovateJobAccess `json:"access,omitempty"`
+}
+
+func specFields(spec *api.RenovateJobSpec) jobSpecFields {
+	return jobSpecFields{
+		Schedule:            &spec.Schedule,
+		DiscoverySchedule:   &spec.DiscoverySchedule,
+		Image:               &spec.Image,
+		Provider:            spec.Provider,
+		SecretRef:           &spec.SecretRef,
+		Parallelism:         &spec.Parallelism,
+		DiscoveryFilters:    &spec.DiscoveryFilters,
+		DiscoverTopics:      &spec.DiscoverTopics,
+		Repositories:        &spec.Repositories,
+		ExcludeRepositories: &spec.ExcludeRepositories,
+		SkipForks:           &spec.SkipForks,
+		SkipArchived:        &spec.SkipArchived,
+		SkipEmpty:           &spec.SkipEmpty,
+		SkipInactiveFor:     &spec.SkipInactiveFor,
+		Visibility:          &spec.Visibility,
+		Access:              spec.Access,
+	}
+}
+
+// apply sets the fields onto spec and returns the names of those that changed.
+func (f *jobSpecFields) apply(spec *api.RenovateJobSpec) []string {
+	var changed []string
+	setField(&changed, "schedule", &spec.Schedule, f.Schedule)
+	setField(&changed, "discoverySchedule", &spec.DiscoverySchedule, f.DiscoverySchedule)
+	setField(&changed, "image", &spec.Image, f.Image)
+	if f.Provider != nil {
+		setField(&changed, "provider", &spec.Provider, &f.Provider)
+	}
+	setField(&changed, "secretRef", &spec.SecretRef, f.SecretRef)
+	setField(&changed, "parallelism", &spec.Parallelism, f.Parallelism)
+	setField(&changed, "discoveryFilters", &spec.DiscoveryFilters, f.DiscoveryFilters)
+	setField(&changed, "discoverTopics", &spec.DiscoverTopics, f.DiscoverTopics)
+	setField(&changed, "repositories", &spec.Repositories, f.Repositories)
+	setField(&changed, "excludeRepositories", &spec.ExcludeRepositories, f.ExcludeRepositories)
+	setField(&changed, "skipForks", &spec.SkipForks, f.SkipForks)
+	setField(&changed, "skipArchived", &spec.SkipArchived, f.SkipArchived)
+	setField(&changed, "skipEmpty", &spec.SkipEmpty, f.SkipEmpty)
+	setField(&changed, "skipInactiveFor", &spec.SkipInactiveFor, f.SkipInactiveFor)
+	setField(&changed, "visibility", &spec.Visibility, f.Visibility)
+	if f.Access != nil {
+		setField(&changed, "access", &spec.Access, &f.Access)
+		// the CRD rejects both at once, so setting access migrates the
+		// deprecated allowedGroups
+		if len(spec.AllowedGroups) > 0 { //nolint:staticcheck // deprecated field is intentionally still honoured
+			spec.AllowedGroups = nil //nolint:staticcheck // deprecated field is intentionally still honoured
+			changed = append(changed, "allowedGroups")
+		}
+	}
+	return changed
+}
+
+func setField[T any](changed *[]string, name string, dst *T, src *T) {
+	if src == nil || equality.Semantic.DeepEqual(*dst, *src) {
+		return
+	}
+	*dst = *src
+	*changed = append(*changed, name)
+}
+
+// EditableRenovateJob is a job as the UI API edits it.
+type EditableRenovateJob struct {
+	Namespace       string        `json:"namespace"`
+	RenovateJob     string        `json:"renovateJob"`
+	ResourceVersion string        `json:"resourceVersion"`
+	Spec            jobSpecFields `json:"spec"`
+	// Changed lists the fields a create or edit changed.
+	Changed []string `json:"changed,omitempty"`
+}
+
+func editableJob(job *api.RenovateJob, changed []string) EditableRenovateJob {
+	return EditableRenovateJob{
+		Namespace:       job.Namespace,
+		RenovateJob:     job.Name,
+		ResourceVersion: job.ResourceVersion,
+		Spec:            specFields(&job.Spec),
+		Changed:         changed,
+	}
+}
+
+// decodeStrict decodes a request body, rejecting fields outside the editable
+// subset instead of silently dropping them.
+func decodeStrict(r *http.Request, v any) error {
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	return decoder.Decode(v)
+}
+
+// requireEditableNamespace answers 403 when the request's groups may not
+// change jobs in namespace.
+func (s *Server) requireEditableNamespace(w http.ResponseWriter, r *http.Request, namespace string) bool {
+	if s.editing.namespaceEditable(getSessionFromContext(r), namespace) {
+		return true
+	}
+	s.logger.Info("Access denied: namespace not editable",
+		"user", sessionEmail(r),
+		"namespace", namespace,
+		"path", r.URL.Path,
+		"method", r.Method,
+		"remote_addr", r.RemoteAddr)
+	http.Error(w, "forbidden", http.StatusForbidden)
+	return false
+}
+
+// validateEditedJob runs the checks the API server cannot: the operator's
+// policy and the schedules.
+func (s *Server) validateEditedJob(w http.ResponseWriter, job *api.RenovateJob) bool {
+	if err := s.editing.Policy.ValidateJob(job); err != nil {
+		writeError(w, HttpResultError{
+			Message:    fmt.Sprintf("refused by policy (%s): %s", policy.ReasonFor(err), err.Error()),
+			StatusCode: http.StatusUnprocessableEntity,
+			Error:      err,
+		})
+		return false
+	}
+	if err := scheduler.ValidateSchedule(job.Spec.Schedule); err != nil {
+		badRequestError(w, err, fmt.Sprintf("invalid schedule: %s", err.Error()))
+		return false
+	}
+	if job.Spec.DiscoverySchedule != "" {
+		if err := scheduler.ValidateSchedule(job.Spec.DiscoverySchedule); err != nil {
+			badRequestError(w, err, fmt.Sprintf("invalid discoverySchedule: %s", err.Error()))
+			return false
+		}
+	}
+	return true
+}
+
+// writeEditError answers a failed write, passing on what the API server
+// reports about conflicts and invalid specs.
+func (s *Server) writeEditError(w http.ResponseWriter, err error, action string, job *api.RenovateJob) {
+	switch {
+	case errors.IsConflict(err):
+		writeError(w, HttpResultError{Message: "the RenovateJob was changed in the meantime, reload it and try again", StatusCode: http.StatusConflict, Error: err})
+	case errors.IsAlreadyExists(err):
+		writeError(w, HttpResultError{Message: "a RenovateJob of this name already exists", StatusCode: http.StatusConflict, Error: err})
+	case errors.IsNotFound(err):
+		http.Error(w, "not found", http.StatusNotFound)
+	case errors.IsInvalid(err):
+		writeError(w, HttpResultError{Message: err.Error(), StatusCode: http.StatusUnprocessableEntity, Error: err})
+	default:
+		s.logger.Error(err, "Failed to "+action+" RenovateJob", "renovateJob", job.Name, "namespace", job.Namespace)
+		internalServerError(w, err, "failed to "+action+" renovatejob")
+	}
+}
+
+// stampModified records who changed the job last, for `kubectl describe`.
+func stampModified(r *http.Request, job *api.RenovateJob) {
+	if job.Annotations == nil {
+		job.Annotations = map[string]string{}
+	}
+	job.Annotations[api.ModifiedByAnnotationKey] = sessionEmail(r)
+	job.Annotations[api.ModifiedAtAnnotationKey] = time.Now().UTC().Format(time.RFC3339)
+}
+
+// recordEdit logs a change and records it as an Event on the job.
+func (s *Server) recordEdit(r *http.Request, job *api.RenovateJob, reason, message string, changed []string) {
+	user := sessionEmail(r)
+	s.logger.Info(message, "user", user, "renovateJob", job.Name, "namespace", job.Namespace, "changed", changed)
+	if len(changed) > 0 {
+		auditDetail(r, "changed", strings.Join(changed, ","))
+	}
+	if s.editing.Recorder == nil {
+		return
+	}
+	note := fmt.Sprintf("%s by %s", message, user)
+	if len(changed) > 0 {
+		note += ": " + strings.Join(changed, ", ")
+	}
+	s.editing.Recorder.Eventf(job, nil, corev1.EventTypeNormal, reason, "Edit", "%s", note)
+}
+
+// getEditableRenovateJob returns the editable fields of a job, with the
+// resourceVersion an edit must name.
+func (s *Server) getEditableRenovateJob(w http.ResponseWriter, r *http.Request) {
+	if s.editing == nil {
+		http.Error(w, "not found", http.StatusNotFound)
+		return
+	}
+	namespace, name := r.URL.Query().Get("namespace"), r.URL.Query().Get("renovate")
+	if _, ok := s.requirePermission(w, r, namespace, name, permEdit); !ok {
+		return
+	}
+
+	job := &api.RenovateJob{}
+	if err := s.editing.Reader.Get(r.Context(), client.ObjectKey{Namespace: namespace, Name: name}, job); err != nil {
+		s.writeEditError(w, err, "load", &api.RenovateJob{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace}})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(editableJob(job, nil))
+}
+
+// createRenovateJob creates a job in a namespace the request's groups may
+// edit. The job's access rules must leave the request an admin of it, so no
+// one creates a job they cannot see.
+func (s *Server) createRenovateJob(w http.ResponseWriter, r *http.Request) {
+	if s.editing == nil {
+		http.Error(w, "not found", http.StatusNotFound)
+		return
+	}
+
+	var body struct {
+		Namespace   string `json:"namespace"`
+		RenovateJob string `json:"renovateJob"`
+		jobSpecFields
+	}
+	if err := decodeStrict(r, &body); err != nil {
+		badRequestError(w, err, fmt.Sprintf("failed to parse request body: %s", err.Error()))
+		return
+	}
+	if body.Namespace == "" || body.RenovateJob == "" {
+		badRequestError(w, nil, "Missing parameters")
+		return
+	}
+	auditTarget(r, body.Namespace, body.RenovateJob, "")
+
+	if s.accessEnforceable(r.Context()) != nil {
+		http.Error(w, "forbidden", http.StatusForbidden)
+		return
+	}
+	if !s.requireEditableNamespace(w, r, body.Namespace) {
+		return
+	}
+
+	if body.Schedule == nil || body.Image == nil || body.Provider == nil || body.Parallelism == nil {
+		badRequestError(w, nil, "schedule, image, provider and parallelism are required")
+		return
+	}
+
+	job := &api.RenovateJob{ObjectMeta: metav1.ObjectMeta{Name: body.RenovateJob, Namespace: body.Namespace}}
+	changed := body.apply(&job.Spec)
+	if !s.decideJobAccess(r, job).has(permEdit) {
+		badRequestError(w, nil, "the access rules of the RenovateJob must grant you admin access")
+		return
+	}
+	if !s.validateEditedJob(w, job) {
+		return
+	}
+
+	stampModified(r, job)
+	if err := s.editing.Writer.Create(r.Context(), job); err != nil {
+		s.writeEditError(w, err, "create", job)
+		return
+	}
+	s.recordEdit(r, job, reasonCreatedFromUI, "RenovateJob created", changed)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	_ = json.NewEncoder(w).Encode(editableJob(job, changed))
+}
+
+// updateRenovateJob edits a job. The request names the resourceVersion it
+// was made against and is refused with 409 when the job changed since.
+func (s *Server) updateRenovateJob(w http.ResponseWriter, r *http.Request) {
+	if s.editing == nil {
+		http.Error(w, "not found", http.StatusNotFound)
+		return
+	}
+
+	var body struct {
+		Namespace       string `json:"namespace"`
+		RenovateJob     string `json:"renovateJob"`
+		ResourceVersion string `json:"resourceVersion"`
+		jobSpecFields
+	}
+	if err := decodeStrict(r, &body); err != nil {
+		badRequestError(w, err, fmt.Sprintf("failed to parse request body: %s", err.Error()))
+		return
+	}
+	if body.Namespace == "" || body.RenovateJob == "" || body.ResourceVersion == "" {
+		badRequestError(w, nil, "Missing parameters")
+		return
+	}
+	auditTarget(r, body.Namespace, body.RenovateJob, "")
+
+	if _, ok := s.requirePermission(w, r, body.Namespace, body.RenovateJob, permEdit); !ok {
+		return
+	}
+	if !s.requireEditableNamespace(w, r, body.Namespace) {
+		return
+	}
+
+	job := &api.RenovateJob{}
+	if err := s.editing.Reader.Get(r.Context(), client.ObjectKey{Namespace: body.Namespace, Name: body.RenovateJob}, job); err != nil {
+		s.writeEditError(w, err, "load", &api.RenovateJob{ObjectMeta: metav1.ObjectMeta{Name: body.RenovateJob, Namespace: body.Namespace}})
+		return
+	}
+	if job.ResourceVersion != body.ResourceVersion {
+		writeError(w, HttpResultError{Message: "the RenovateJob was changed in the meantime, reload it and try again", StatusCode: http.StatusConflict})
+		return
+	}
+
+	before := s.decideJobAccess(r, job)
+	changed := body.apply(&job.Spec)
+	if len(changed) == 0 {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(editableJob(job, nil))
+		return
+	}
+	// A role that may edit the job could otherwise grant itself the rest.
+	if slices.Contains(changed, "access") && !isFullAdmin(before) {
+		s.logger.Info("Access denied: changing access rules needs admin access",
+			"user", sessionEmail(r),
+			"resource", body.RenovateJob,
+			"namespace", body.Namespace)
+		http.Error(w, "forbidden", http.StatusForbidden)
+		return
+	}
+	if !s.decideJobAccess(r, job).has(permEdit) {
+		badRequestError(w, nil, "the access rules of the RenovateJob must keep granting you admin access")
+		return
+	}
+	if !s.validateEditedJob(w, job) {
+		return
+	}
+
+	stampModified(r, job)
+	if err := s.editing.Writer.Update(r.Context(), job); err != nil {
+		s.writeEditError(w, err, "update", job)
+		return
+	}
+	s.recordEdit(r, job, reasonEditedFromUI, "RenovateJob edited", changed)
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(editableJob(job, changed))
+}
+
+// deleteRenovateJob deletes a job, provided it is still at the
+// resourceVersion the request names.
+func (s *Server) deleteRenovateJob(w http.ResponseWriter, r *http.Request) {
+	if s.editing == nil {
+		http.Error(w, "not found", http.StatusNotFound)
+		return
+	}
+
+	query := r.URL.Query()
+	namespace, name, resourceVersion := query.Get("namespace"), query.Get("renovate"), query.Get("resourceVersion")
+	if namespace == "" || name == "" || resourceVersion == "" {
+		badRequestError(w, nil, "Missing parameters")
+		return
+	}
+	auditTarget(r, namespace, name, "")
+
+	job, ok := s.requirePermission(w, r, namespace, name, permEdit)
+	if !ok {
+		return
+	}
+	if !s.requireEditableNamespace(w, r, namespace) {
+		return
+	}
+
+	if err := s.editing.Writer.Delete(r.Context(), job, client.Preconditions{ResourceVersion: &resourceVersion}); err != nil {
+		s.writeEditError(w, err, "delete", job)
+		return
+	}
+	s.recordEdit(r, job, reasonDeletedFromUI, "RenovateJob deleted", nil)
+
+	writeSuccess(w, SuccessResult{Message: "RenovateJob deleted"})
+}
@@ -0,0 +1,278 @@
+package ui
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	api "renovate-operator/api/v1alpha1"
+	"renovate-operator/internal/policy"
+
+	"github.com/go-logr/logr"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/events"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func editingServer(t *testing.T) (*Server, client.Client, *events.FakeRecorder) {
+	t.Helper()
+	scheme := runtime.NewScheme()
+	if err := api.AddToScheme(scheme); err != nil {
+		t.Fatalf("failed to add api scheme: %v", err)
+	}
+	existing := &api.RenovateJob{
+		ObjectMeta: metav1.ObjectMeta{Name: "job1", Namespace: "team-a"},
+		Spec: api.RenovateJobSpec{
+			Schedule:    "0 * * * *",
+			Image:       "renovate/renovate:41",
+			Provider:    &api.RenovateProvider{Name: "github"},
+			Parallelism: 1,
+			Access:      &api.RenovateJobAccess{AdminGroups: []string{"team-a"}, ReaderGroups: []string{"readers"}},
+		},
+	}
+	cl := fake.NewClientBuilder().WithScheme(scheme).WithObjects(existing).Build()
+	recorder := events.NewFakeRecorder(10)
+
+	server := &Server{
+		manager: &mockRenovateJobManager{
+			getRenovateJobFunc: func(ctx context.Context, name, namespace string) (*api.RenovateJob, error) {
+				job := &api.RenovateJob{}
+				if err := cl.Get(ctx, client.ObjectKey{Name: name, Namespace: namespace}, job); err != nil {
+					return nil, err
+				}
+				return job, nil
+			},
+		},
+		logger: logr.Discard(),
+		auth:   &OIDCAuth{},
+		editing: &JobEditing{
+			Reader:     cl,
+			Writer:     cl,
+			Policy:     policy.Policy{AllowedImages: []string{"renovate/renovate"}, AllowedHosts: []string{"api.github.com"}},
+			Recorder:   recorder,
+			Namespaces: map[string][]string{"team-a": {"team-a"}, "readers": {"*"}},
+		},
+	}
+	return server, cl, recorder
+}
+
+func editRequest(method, target, body string, groups ...string) *http.Request {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	session := &sessionData{Email: "someone@example.com", Groups: groups}
+	return req.WithContext(context.WithValue(req.Context(), sessionContextKey, session))
+}
+
+func currentResourceVersion(t *testing.T, cl client.Client) string {
+	t.Helper()
+	job := &api.RenovateJob{}
+	if err := cl.Get(context.Background(), client.ObjectKey{Name: "job1", Namespace: "team-a"}, job); err != nil {
+		t.Fatalf("failed to get job1: %v", err)
+	}
+	return job.ResourceVersion
+}
+
+func TestCreateRenovateJob(t *testing.T) {
+	server, cl, recorder := editingServer(t)
+	create := func(body string, groups ...string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		server.createRenovateJob(w, editRequest(http.MethodPost, "/api/v1/renovatejobs", body, groups...))
+		return w
+	}
+
+	w := create(`{"namespace":"team-a","renovateJob":"job2","schedule":"0 2 * * *","image":"renovate/renovate:41",
+		"provider":{"name":"github"},"parallelism":2,"repositories":["org/api"],"access":{"adminGroups":["team-a"]}}`, "team-a")
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+	created := &api.RenovateJob{}
+	if err := cl.Get(context.Background(), client.ObjectKey{Name: "job2", Namespace: "team-a"}, created); err != nil {
+		t.Fatalf("expected job2 to be created: %v", err)
+	}
+	if created.Spec.Parallelism != 2 || !slices.Equal(created.Spec.Repositories, []string{"org/api"}) {
+		t.Errorf("unexpected spec %+v", created.Spec)
+	}
+	if created.Annotations[api.ModifiedByAnnotationKey] != "someone@example.com" || created.Annotations[api.ModifiedAtAnnotationKey] == "" {
+		t.Errorf("expected the creator to be recorded, got %v", created.Annotations)
+	}
+	if event := <-recorder.Events; !strings.Contains(event, reasonCreatedFromUI) || !strings.Contains(event, "someone@example.com") {
+		t.Errorf("unexpected event %q", event)
+	}
+
+	tests := []struct {
+		name   string
+		body   string
+		groups []string
+		want   int
+	}{
+		{"namespace not allowed", `{"namespace":"team-b","renovateJob":"job3"}`, []string{"team-a"}, http.StatusForbidden},
+		{"unknown field", `{"namespace":"team-a","renovateJob":"job3","extraEnv":[]}`, []string{"team-a"}, http.StatusBadRequest},
+		{"missing required fields", `{"namespace":"team-a","renovateJob":"job3","schedule":"0 2 * * *"}`, []string{"team-a"}, http.StatusBadRequest},
+		{"access not granting the creator admin", `{"namespace":"team-a","renovateJob":"job3","schedule":"0 2 * * *","image":"renovate/renovate:41",
+			"provider":{"name":"github"},"parallelism":1,"access":{"readerGroups":["team-a"]}}`, []string{"team-a"}, http.StatusBadRequest},
+		{"refused by policy", `{"namespace":"team-a","renovateJob":"job3","schedule":"0 2 * * *","image":"evil/renovate:41",
+			"provider":{"name":"github"},"parallelism":1,"access":{"adminGroups":["team-a"]}}`, []string{"team-a"}, http.StatusUnprocessableEntity},
+		{"invalid schedule", `{"namespace":"team-a","renovateJob":"job3","schedule":"often","image":"renovate/renovate:41",
+			"provider":{"name":"github"},"parallelism":1,"access":{"adminGroups":["team-a"]}}`, []string{"team-a"}, http.StatusBadRequest},
+		{"already exists", `{"namespace":"team-a","renovateJob":"job1","schedule":"0 2 * * *","image":"renovate/renovate:41",
+			"provider":{"name":"github"},"parallelism":1,"access":{"adminGroups":["team-a"]}}`, []string{"team-a"}, http.StatusConflict},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if w := create(tt.body, tt.groups...); w.Code != tt.want {
+				t.Errorf("expected %d, got %d: %s", tt.want, w.Code, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestUpdateRenovateJob(t *testing.T) {
+	server, cl, recorder := editingServer(t)
+	update := func(body string, groups ...string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		server.updateRenovateJob(w, editRequest(http.MethodPut, "/api/v1/renovatejobs", body, groups...))
+		return w
+	}
+
+	resourceVersion := currentResourceVersion(t, cl)
+	w := update(`{"namespace":"team-a","renovateJob":"job1","resourceVersion":"`+resourceVersion+`","schedule":"0 3 * * *","skipForks":true,"parallelism":1}`, "team-a")
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var edited EditableRenovateJob
+	if err := json.NewDecoder(w.Body).Decode(&edited); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if !slices.Equal(edited.Changed, []string{"schedule", "skipForks"}) || edited.ResourceVersion == resourceVersion {
+		t.Errorf("expected schedule and skipForks to change with a new resourceVersion, got %+v", edited)
+	}
+	if event := <-recorder.Events; !strings.Contains(event, reasonEditedFromUI) || !strings.Contains(event, "schedule, skipForks") {
+		t.Errorf("unexpected event %q", event)
+	}
+
+	tests := []struct {
+		name   string
+		body   string
+		groups []string
+		want   int
+	}{
+		{"stale resourceVersion", `{"namespace":"team-a","renovateJob":"job1","resourceVersion":"` + resourceVersion + `","schedule":"0 4 * * *"}`, []string{"team-a"}, http.StatusConflict},
+		{"reader", `{"namespace":"team-a","renovateJob":"job1","resourceVersion":"` + edited.ResourceVersion + `","schedule":"0 4 * * *"}`, []string{"readers"}, http.StatusForbidden},
+		{"no access", `{"namespace":"team-a","renovateJob":"job1","resourceVersion":"` + edited.ResourceVersion + `","schedule":"0 4 * * *"}`, []string{"team-b"}, http.StatusNotFound},
+		{"locking the editor out", `{"namespace":"team-a","renovateJob":"job1","resourceVersion":"` + edited.ResourceVersion + `","access":{"adminGroups":["team-b"]}}`, []string{"team-a"}, http.StatusBadRequest},
+		{"missing resourceVersion", `{"namespace":"team-a","renovateJob":"job1","schedule":"0 4 * * *"}`, []string{"team-a"}, http.StatusBadRequest},
+		{"field outside the subset", `{"namespace":"team-a","renovateJob":"job1","resourceVersion":"` + edited.ResourceVersion + `","serviceAccount":{"name":"root"}}`, []string{"team-a"}, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if w := update(tt.body, tt.groups...); w.Code != tt.want {
+				t.Errorf("expected %d, got %d: %s", tt.want, w.Code, w.Body.String())
+			}
+		})
+	}
+
+	t.Run("role that manages the job cannot change its access", func(t *testing.T) {
+		server.accessDefaults.Roles = map[string][]string{"maintainer": {permEdit}}
+		server.editing.Namespaces = map[string][]string{"team-a": {"team-a"}, "maintainers": {"team-a"}}
+		t.Cleanup(func() {
+			server.accessDefaults.Roles = nil
+			server.editing.Namespaces = map[string][]string{"team-a": {"team-a"}}
+		})
+		job := &api.RenovateJob{}
+		if err := cl.Get(context.Background(), client.ObjectKey{Name: "job1", Namespace: "team-a"}, job); err != nil {
+			t.Fatalf("failed to get job1: %v", err)
+		}
+		job.Spec.Access.RoleBindings = []api.RenovateJobRoleBinding{{Role: "maintainer", Groups: []string{"maintainers"}}}
+		if err := cl.Update(context.Background(), job); err != nil {
+			t.Fatalf("failed to bind the role: %v", err)
+		}
+
+		body := `{"namespace":"team-a","renovateJob":"job1","resourceVersion":"` + job.ResourceVersion + `","schedule":"0 5 * * *"}`
+		w := update(body, "maintainers")
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected the role to edit the schedule, got %d: %s", w.Code, w.Body.String())
+		}
+		if err := json.NewDecoder(w.Body).Decode(&edited); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		<-recorder.Events
+
+		body = `{"namespace":"team-a","renovateJob":"job1","resourceVersion":"` + edited.ResourceVersion + `","access":{"adminGroups":["maintainers"]}}`
+		if w := update(body, "maintainers"); w.Code != http.StatusForbidden {
+			t.Errorf("expected 403, got %d: %s", w.Code, w.Body.String())
+		}
+	})
+
+	t.Run("namespace not allowed", func(t *testing.T) {
+		server.editing.Namespaces = map[string][]string{"team-a": {"team-b"}}
+		t.Cleanup(func() { server.editing.Namespaces = map[string][]string{"team-a": {"team-a"}} })
+		body := `{"namespace":"team-a","renovateJob":"job1","resourceVersion":"` + edited.ResourceVersion + `","schedule":"0 4 * * *"}`
+		if w := update(body, "team-a"); w.Code != http.StatusForbidden {
+			t.Errorf("expected 403, got %d: %s", w.Code, w.Body.String())
+		}
+	})
+}
+
+func TestDeleteRenovateJob(t *testing.T) {
+	server, cl, recorder := editingServer(t)
+	remove := func(resourceVersion string, groups ...string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		target := "/api/v1/renovatejobs?namespace=team-a&renovate=job1&resourceVersion=" + resourceVersion
+		server.deleteRenovateJob(w, editRequest(http.MethodDelete, target, "", groups...))
+		return w
+	}
+
+	resourceVersion := currentResourceVersion(t, cl)
+	if w := remove(resourceVersion, "readers"); w.Code != http.StatusForbidden {
+		t.Errorf("expected 403 for a reader, got %d", w.Code)
+	}
+	if w := remove("1"+resourceVersion, "team-a"); w.Code != http.StatusConflict {
+		t.Errorf("expected 409 for a stale resourceVersion, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := remove(resourceVersion, "team-a"); w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if err := cl.Get(context.Background(), client.ObjectKey{Name: "job1", Namespace: "team-a"}, &api.RenovateJob{}); err == nil {
+		t.Error("expected job1 to be deleted")
+	}
+	if event := <-recorder.Events; !strings.Contains(event, reasonDeletedFromUI) {
+		t.Errorf("unexpected event %q", event)
+	}
+}
+
+func TestJobEditingDisabled(t *testing.T) {
+	server := &Server{logger: logr.Discard()}
+	for _, handler := range []http.HandlerFunc{server.createRenovateJob, server.updateRenovateJob, server.deleteRenovateJob, server.getEditableRenovateJob} {
+		w := httptest.NewRecorder()
+		handler(w, httptest.NewRequest(http.MethodPost, "/api/v1/renovatejobs", bytes.NewBufferString("{}")))
+		if w.Code != http.StatusNotFound {
+			t.Errorf("expected 404 with job editing disabled, got %d", w.Code)
+		}
+	}
+}
+
+func TestParseEditableNamespaces(t *testing.T) {
+	namespaces, err := ParseEditableNamespaces(`{"Team-A":["team-a"],"*":["sandbox"]}`)
+	if err != nil {
+		t.Fatalf("ParseEditableNamespaces returned error: %v", err)
+	}
+	editing := &JobEditing{Namespaces: namespaces}
+	if !editing.namespaceEditable(&sessionData{Groups: []string{"team-a"}}, "team-a") {
+		t.Error("expected team-a to edit its namespace")
+	}
+	if !editing.namespaceEditable(nil, "sandbox") || editing.namespaceEditable(nil, "team-a") {
+		t.Error("expected everyone to edit sandbox only")
+	}
+
+	for _, invalid := range []string{`[]`, `{"":["a"]}`, `{"team":[""]}`} {
+		if _, err := ParseEditableNamespaces(invalid); err == nil {
+			t.Errorf("expected %s to be rejected", invalid)
+		}
+	}
+}
@@ -0,0 +1,231 @@
+package ui
+
+import (
+	"cmp"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	api "renovate-operator/api/v1alpha1"
+)
+
+// maxPageLimit bounds the page size of the jobs and projects endpoints.
+const maxPageLimit = 1000
+
+// statusNeedsApproval filters the projects with PRs awaiting approval, which
+// is not a project status of its own.
+const statusNeedsApproval = "needs-approval"
+
+// Sort keys of the projects.
+const (
+	sortByName           = "name"
+	sortByLastTransition = "lastTransition"
+	sortByDuration       = "duration"
+	sortByPriority       = "priority"
+)
+
+// ProjectSummary counts the projects of a job, whatever the filters of the
+// request.
+type ProjectSummary struct {
+	Total           int `json:"total"`
+	Scheduled       int `json:"scheduled"`
+	Running         int `json:"running"`
+	Completed       int `json:"completed"`
+	Failed          int `json:"failed"`
+	Cancelled       int `json:"cancelled"`
+	PendingApproval int `json:"pendingApproval"`
+	// NeedsApproval counts the PRs awaiting approval, not the projects.
+	NeedsApproval int `json:"needsApproval"`
+	WithIssues    int `json:"withIssues"`
+	OpenPRs       int `json:"openPRs"`
+}
+
+func summarizeProjects(projects []api.ProjectStatus) ProjectSummary {
+	summary := ProjectSummary{Total: len(projects)}
+	for i := range projects {
+		p := &projects[i]
+		switch p.Status {
+		case api.JobStatusScheduled:
+			summary.Scheduled++
+		case api.JobStatusRunning:
+			summary.Running++
+		case api.JobStatusCompleted:
+			summary.Completed++
+		case api.JobStatusFailed:
+			summary.Failed++
+		case api.JobStatusCancelled:
+			summary.Cancelled++
+		case api.JobStatusPendingApproval:
+			summary.PendingApproval++
+		}
+		if p.PRActivity != nil {
+			summary.NeedsApproval += p.PRActivity.NeedsApproval
+			summary.OpenPRs += p.PRActivity.Created + p.PRActivity.Updated + p.PRActivity.Unchanged
+		}
+		if p.LogIssues != nil && (p.LogIssues.WarnCount > 0 || p.LogIssues.ErrorCount > 0) {
+			summary.WithIssues++
+		}
+	}
+	return summary
+}
+
+// projectQuery selects and orders the projects of a job.
+type projectQuery struct {
+	statuses   []string
+	search     string
+	sortBy     string
+	descending bool
+}
+
+func parseProjectQuery(query url.Values) (projectQuery, error) {
+	q := projectQuery{search: strings.ToLower(strings.TrimSpace(query.Get("search")))}
+
+	if raw := query.Get("status"); raw != "" {
+		for status := range strings.SplitSeq(raw, ",") {
+			switch status = strings.TrimSpace(status); api.RenovateProjectStatus(status) {
+			case api.JobStatusScheduled, api.JobStatusRunning, api.JobStatusCompleted, api.JobStatusFailed,
+				api.JobStatusCancelled, api.JobStatusPendingApproval, statusNeedsApproval:
+				q.statuses = append(q.statuses, status)
+			default:
+				return q, fmt.Errorf("unknown status %q", status)
+			}
+		}
+	}
+
+	switch q.sortBy = query.Get("sort"); q.sortBy {
+	case "":
+	case sortByName:
+	case sortByLastTransition, sortByDuration, sortByPriority:
+		// the most recent, longest and most urgent first
+		q.descending = true
+	default:
+		return q, fmt.Errorf("unknown sort %q, expected %s, %s, %s or %s", q.sortBy, sortByName, sortByLastTransition, sortByDuration, sortByPriority)
+	}
+	switch order := query.Get("order"); order {
+	case "":
+	case "asc":
+		q.descending = false
+	case "desc":
+		q.descending = true
+	default:
+		return q, fmt.Errorf("unknown order %q, expected asc or desc", order)
+	}
+	return q, nil
+}
+
+// filtered reports whether the query leaves projects out.
+func (q projectQuery) filtered() bool {
+	return len(q.statuses) > 0 || q.search != ""
+}
+
+func (q projectQuery) matches(p *api.ProjectStatus) bool {
+	if q.search != "" && !strings.Contains(strings.ToLower(p.Name), q.search) {
+		return false
+	}
+	if len(q.statuses) == 0 {
+		return true
+	}
+	for _, status := range q.statuses {
+		if status == statusNeedsApproval {
+			if p.PRActivity != nil && p.PRActivity.NeedsApproval > 0 {
+				return true
+			}
+		} else if string(p.Status) == status {
+			return true
+		}
+	}
+	return false
+}
+
+// apply returns the matching projects in order, leaving projects untouched.
+func (q projectQuery) apply(projects []api.ProjectStatus) []api.ProjectStatus {
+	result := make([]api.ProjectStatus, 0, len(projects))
+	for i := range projects {
+		if q.matches(&projects[i]) {
+			result = append(result, projects[i])
+		}
+	}
+	if q.sortBy == "" {
+		return result
+	}
+	slices.SortStableFunc(result, func(a, b api.ProjectStatus) int {
+		var c int
+		switch q.sortBy {
+		case sortByLastTransition:
+			c = a.LastTransition.Compare(b.LastTransition.Time)
+		case sortByDuration:
+			c = cmp.Compare(parseProjectDuration(a.Duration), parseProjectDuration(b.Duration))
+		case sortByPriority:
+			c = cmp.Compare(a.Priority, b.Priority)
+		}
+		if q.descending {
+			c = -c
+		}
+		// ties, and sort=name, go by name
+		if c == 0 {
+			c = strings.Compare(a.Name, b.Name)
+			if q.sortBy == sortByName && q.descending {
+				c = -c
+			}
+		}
+		return c
+	})
+	return result
+}
+
+// parseProjectDuration reads the duration of a run as the executor records it,
+// e.g. "1h 2m 3s". Projects without one sort as the shortest.
+func parseProjectDuration(duration *string) time.Duration {
+	if duration == nil {
+		return 0
+	}
+	d, err := time.ParseDuration(strings.ReplaceAll(*duration, " ", ""))
+	if err != nil {
+		return 0
+	}
+	return d
+}
+
+// pageQuery is a page of a list. A zero limit is the whole list.
+type pageQuery struct {
+	page  int
+	limit int
+}
+
+func parsePageQuery(query url.Values) (pageQuery, error) {
+	p := pageQuery{page: 1}
+	if raw := query.Get("limit"); raw != "" {
+		limit, err := strconv.Atoi(raw)
+		if err != nil || limit < 1 || limit > maxPageLimit {
+			return p, fmt.Errorf("limit must be between 1 and %d", maxPageLimit)
+		}
+		p.limit = limit
+	}
+	if raw := query.Get("page"); raw != "" {
+		page, err := strconv.Atoi(raw)
+		if err != nil || page < 1 {
+			return p, fmt.Errorf("page must be a positive number")
+		}
+		p.page = page
+	}
+	return p, nil
+}
+
+// paginate returns the page of items and sets X-Total-Count to the length of
+// the whole list.
+func paginate[T any](w http.ResponseWriter, items []T, p pageQuery) []T {
+	w.Header().Set("X-Total-Count", strconv.Itoa(len(items)))
+	if p.limit == 0 {
+		return items
+	}
+	if p.page-1 > len(items)/p.limit {
+		return items[:0]
+	}
+	start := min((p.page-1)*p.limit, len(items))
+	end := min(start+p.limit, len(items))
+	return items[start:end]
+}
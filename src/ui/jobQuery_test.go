@@ -0,0 +1,120 @@
+package ui
+
+import (
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"testing"
+	"time"
+
+	api "renovate-operator/api/v1alpha1"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func queryProjects() []api.ProjectStatus {
+	duration := func(d string) *string { return &d }
+	at := func(minutes int) metav1.Time {
+		return metav1.NewTime(time.Date(2026, 10, 19, 8, minutes, 0, 0, time.UTC))
+	}
+	return []api.ProjectStatus{
+		{Name: "org/api", Status: api.JobStatusFailed, LastTransition: at(5), Duration: duration("2m 10s"), Priority: 1,
+			LogIssues: &api.LogIssues{ErrorCount: 2}},
+		{Name: "org/web", Status: api.JobStatusCompleted, LastTransition: at(20), Duration: duration("1h 0m 5s"),
+			PRActivity: &api.PRActivity{Created: 1, Updated: 2, NeedsApproval: 3}},
+		{Name: "org/worker", Status: api.JobStatusRunning, LastTransition: at(10), Priority: 2},
+		{Name: "team/Website", Status: api.JobStatusPendingApproval},
+	}
+}
+
+func projectNames(projects []api.ProjectStatus) []string {
+	names := make([]string, 0, len(projects))
+	for _, p := range projects {
+		names = append(names, p.Name)
+	}
+	return names
+}
+
+func TestProjectQuery(t *testing.T) {
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		{"", []string{"org/api", "org/web", "org/worker", "team/Website"}},
+		{"status=failed,running", []string{"org/api", "org/worker"}},
+		{"status=needs-approval", []string{"org/web"}},
+		{"status=pending-approval", []string{"team/Website"}},
+		{"search=WEB", []string{"org/web", "team/Website"}},
+		{"search=web&status=completed", []string{"org/web"}},
+		{"sort=lastTransition", []string{"org/web", "org/worker", "org/api", "team/Website"}},
+		{"sort=lastTransition&order=asc", []string{"team/Website", "org/api", "org/worker", "org/web"}},
+		{"sort=duration", []string{"org/web", "org/api", "org/worker", "team/Website"}},
+		{"sort=priority", []string{"org/worker", "org/api", "org/web", "team/Website"}},
+		{"sort=name&order=desc", []string{"team/Website", "org/worker", "org/web", "org/api"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			values, _ := url.ParseQuery(tt.query)
+			q, err := parseProjectQuery(values)
+			if err != nil {
+				t.Fatalf("parseProjectQuery returned error: %v", err)
+			}
+			projects := queryProjects()
+			if got := projectNames(q.apply(projects)); !slices.Equal(got, tt.want) {
+				t.Errorf("expected %v, got %v", tt.want, got)
+			}
+			if projects[0].Name != "org/api" {
+				t.Error("expected apply to leave the projects of the job untouched")
+			}
+		})
+	}
+
+	for _, invalid := range []string{"status=broken", "sort=size", "order=up"} {
+		values, _ := url.ParseQuery(invalid)
+		if _, err := parseProjectQuery(values); err == nil {
+			t.Errorf("expected %q to be rejected", invalid)
+		}
+	}
+}
+
+func TestPaginate(t *testing.T) {
+	items := []int{1, 2, 3, 4, 5}
+	tests := []struct {
+		query string
+		want  []int
+	}{
+		{"", items},
+		{"limit=2", []int{1, 2}},
+		{"limit=2&page=3", []int{5}},
+		{"limit=2&page=4", []int{}},
+		{"limit=1000&page=9223372036854775807", []int{}},
+	}
+	for _, tt := range tests {
+		values, _ := url.ParseQuery(tt.query)
+		page, err := parsePageQuery(values)
+		if err != nil {
+			t.Fatalf("parsePageQuery(%q) returned error: %v", tt.query, err)
+		}
+		w := httptest.NewRecorder()
+		if got := paginate(w, items, page); !slices.Equal(got, tt.want) {
+			t.Errorf("%q: expected %v, got %v", tt.query, tt.want, got)
+		}
+		if total := w.Header().Get("X-Total-Count"); total != "5" {
+			t.Errorf("%q: expected X-Total-Count 5, got %q", tt.query, total)
+		}
+	}
+
+	for _, invalid := range []string{"limit=0", "limit=1001", "limit=x", "page=0", "page=-1"} {
+		values, _ := url.ParseQuery(invalid)
+		if _, err := parsePageQuery(values); err == nil {
+			t.Errorf("expected %q to be rejected", invalid)
+		}
+	}
+}
+
+func TestSummarizeProjects(t *testing.T) {
+	want := ProjectSummary{Total: 4, Running: 1, Completed: 1, Failed: 1, PendingApproval: 1, NeedsApproval: 3, WithIssues: 1, OpenPRs: 3}
+	if got := summarizeProjects(queryProjects()); got != want {
+		t.Errorf("expected %+v, got %+v", want, got)
+	}
+}
@@ -316,6 +316,7 @@ func (o *OIDCAuth) HandleCallback(w http.ResponseWriter, r *http.Request) {
 		s.Groups = validatedGroups
 		s.Username = claims.PreferredUsername
 		s.EmailVerified = emailVerified
+		s.Subject = "oidc:" + idToken.Subject
 	})
 	if err != nil {
 		o.logger.Error(err, "failed to build complete URL")
@@ -0,0 +1,269 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	api "renovate-operator/api/v1alpha1"
+
+	"github.com/go-logr/logr"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	authorizationv1client "k8s.io/client-go/kubernetes/typed/authorization/v1"
+)
+
+// rbacDecisionTTL is how long the access a user holds on a job is reused
+// before the API server is asked again. RoleBinding changes take effect after
+// at most this long, and a dashboard listing every job does not review each of
+// them on every poll.
+const rbacDecisionTTL = 10 * time.Second
+
+// rbacReviewTimeout bounds the reviews of the permissions of a decision, which
+// run outside the request that made it.
+const rbacReviewTimeout = 10 * time.Second
+
+// The identities of requests without a session and of every session, as the
+// API server names them, so RBAC can grant access to them like it does for
+// its own requests.
+const (
+	rbacAnonymousUser        = "system:anonymous"
+	rbacUnauthenticatedGroup = "system:unauthenticated"
+	rbacAuthenticatedGroup   = "system:authenticated"
+)
+
+// rbacResource is what a SubjectAccessReview asks for on a RenovateJob.
+type rbacResource struct {
+	verb        string
+	subresource string
+}
+
+// rbacRead is the access that makes a job visible at all.
+var rbacRead = rbacResource{verb: "get"}
+
+// rbacPermissions maps each permission to the access it takes on the job.
+// Actions without a Kubernetes counterpart are custom verbs, which only RBAC
+// understands, so granting them changes nothing about what kubectl allows.
+var rbacPermissions = map[string]rbacResource{
+	permLogs:              {verb: "get", subresource: "logs"},
+	permTrigger:           {verb: "trigger"},
+	permTriggerAll:        {verb: "triggerall"},
+	permTriggerDebug:      {verb: "triggerdebug"},
+	permCancel:            {verb: "cancel"},
+	permDiscovery:         {verb: "discover"},
+	permApprove:           {verb: "approve"},
+	permWebhookDeliveries: {verb: "get", subresource: "webhookdeliveries"},
+	permEdit:              {verb: "update"},
+	permAudit:             {verb: "get", subresource: "audit"},
+}
+
+// RBACIdentity configures how a session is named in a SubjectAccessReview. It
+// mirrors the API server's --oidc-username-claim, --oidc-username-prefix and
+// --oidc-groups-prefix, so the users and groups RoleBindings name for kubectl
+// grant the same access in the UI.
+type RBACIdentity struct {
+	// UserClaim is "email" or "username". An email is only used when the
+	// identity provider verified it.
+	UserClaim   string
+	UserPrefix  string
+	GroupPrefix string
+}
+
+// RBACAuthorizer decides access to RenovateJobs with SubjectAccessReviews
+// instead of the access rules of the jobs and the operator-wide defaults.
+type RBACAuthorizer struct {
+	reviews  authorizationv1client.SubjectAccessReviewInterface
+	identity RBACIdentity
+	logger   logr.Logger
+
+	mu        sync.Mutex
+	decisions map[string]cachedRBACDecision
+	pruned    time.Time
+}
+
+type cachedRBACDecision struct {
+	decision accessDecision
+	until    time.Time
+}
+
+func NewRBACAuthorizer(reviews authorizationv1client.SubjectAccessReviewInterface, identity RBACIdentity, logger logr.Logger) *RBACAuthorizer {
+	return &RBACAuthorizer{
+		reviews:   reviews,
+		identity:  identity,
+		logger:    logger,
+		decisions: make(map[string]cachedRBACDecision),
+	}
+}
+
+// SetRBACAuthorizer makes the server decide access with authorizer instead of
+// the access rules.
+func (s *Server) SetRBACAuthorizer(authorizer *RBACAuthorizer) {
+	s.rbac = authorizer
+}
+
+// subject returns the user and groups a session is reviewed as. A
+// ServiceAccount is named as the API server names it, without prefixes and
+// with the groups of its namespace.
+func (a *RBACAuthorizer) subject(session *sessionData, serviceAccount bool) (string, []string) {
+	if session == nil {
+		return rbacAnonymousUser, []string{rbacUnauthenticatedGroup}
+	}
+	if serviceAccount {
+		account := strings.TrimPrefix(session.Username, serviceAccountUsernamePrefix)
+		namespace, _, _ := strings.Cut(account, ":")
+		return session.Username, []string{"system:serviceaccounts", "system:serviceaccounts:" + namespace, rbacAuthenticatedGroup}
+	}
+
+	user := ""
+	switch a.identity.UserClaim {
+	case "username":
+		user = session.Username
+	default:
+		if session.EmailVerified {
+			user = session.Email
+		}
+	}
+	if user != "" {
+		user = a.identity.UserPrefix + user
+	}
+	groups := make([]string, 0, len(session.Groups)+1)
+	for _, group := range session.Groups {
+		groups = append(groups, a.identity.GroupPrefix+group)
+	}
+	return user, append(groups, rbacAuthenticatedGroup)
+}
+
+// decide returns the access the session holds on job. Only the read access
+// is reviewed up front; every other permission is reviewed the first time the
+// decision is asked for it, so a request checking one permission costs one
+// review more. A review that fails is treated as denied and the result is not
+// cached, so the next request asks again.
+func (a *RBACAuthorizer) decide(ctx context.Context, session *sessionData, serviceAccount bool, job *api.RenovateJob) accessDecision {
+	user, groups := a.subject(session, serviceAccount)
+	key := strings.Join([]string{job.Namespace, job.Name, user, strings.Join(groups, "\n")}, "\x00")
+	now := time.Now()
+
+	a.mu.Lock()
+	cached, ok := a.decisions[key]
+	a.mu.Unlock()
+	if ok && now.Before(cached.until) {
+		return cached.decision
+	}
+
+	allowed, err := a.allowed(ctx, user, groups, job, rbacRead)
+	if err != nil || !allowed {
+		if err == nil {
+			a.store(key, accessDecision{}, now)
+		}
+		return accessDecision{}
+	}
+
+	decision := accessDecision{Role: roleReader, reviews: &rbacReviews{
+		authorizer: a,
+		user:       user,
+		groups:     groups,
+		job:        &api.RenovateJob{ObjectMeta: metav1.ObjectMeta{Name: job.Name, Namespace: job.Namespace}},
+		allowed:    make(map[string]bool),
+	}}
+	a.store(key, decision, now)
+	return decision
+}
+
+// rbacReviews holds what RBAC allows a subject on a job, reviewing each
+// permission once. It lives as long as the cached decision it belongs to.
+type rbacReviews struct {
+	authorizer *RBACAuthorizer
+	user       string
+	groups     []string
+	job        *api.RenovateJob
+
+	mu      sync.Mutex
+	allowed map[string]bool
+}
+
+// granted returns the permissions RBAC allows, reviewing those not reviewed
+// yet in parallel. A failed review denies the permission and is asked again
+// next time.
+func (r *rbacReviews) granted(permissions []string) []string {
+	r.mu.Lock()
+	var pending []string
+	for _, permission := range permissions {
+		if _, reviewed := r.allowed[permission]; !reviewed {
+			pending = append(pending, permission)
+		}
+	}
+	r.mu.Unlock()
+
+	if len(pending) > 0 {
+		// the decision outlives the request that made it
+		ctx, cancel := context.WithTimeout(context.Background(), rbacReviewTimeout)
+		defer cancel()
+		var wg sync.WaitGroup
+		for _, permission := range pending {
+			resource, known := rbacPermissions[permission]
+			if !known {
+				continue
+			}
+			wg.Go(func() {
+				allowed, err := r.authorizer.allowed(ctx, r.user, r.groups, r.job, resource)
+				if err != nil {
+					return
+				}
+				r.mu.Lock()
+				defer r.mu.Unlock()
+				r.allowed[permission] = allowed
+			})
+		}
+		wg.Wait()
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return slices.DeleteFunc(slices.Clone(permissions), func(p string) bool { return !r.allowed[p] })
+}
+
+func (a *RBACAuthorizer) allowed(ctx context.Context, user string, groups []string, job *api.RenovateJob, resource rbacResource) (bool, error) {
+	review, err := a.reviews.Create(ctx, &authorizationv1.SubjectAccessReview{
+		Spec: authorizationv1.SubjectAccessReviewSpec{
+			User:   user,
+			Groups: groups,
+			ResourceAttributes: &authorizationv1.ResourceAttributes{
+				Namespace:   job.Namespace,
+				Verb:        resource.verb,
+				Group:       api.GroupName,
+				Resource:    "renovatejobs",
+				Subresource: resource.subresource,
+				Name:        job.Name,
+			},
+		},
+	}, metav1.CreateOptions{})
+	if err != nil {
+		a.logger.Error(err, "subject access review failed, denying",
+			"user", user,
+			"verb", resource.verb,
+			"subresource", resource.subresource,
+			"resource", job.Name,
+			"namespace", job.Namespace)
+		return false, fmt.Errorf("subject access review failed: %w", err)
+	}
+	return review.Status.Allowed && !review.Status.Denied, nil
+}
+
+// store caches a decision. Expired decisions are dropped at most once per
+// rbacDecisionTTL, so listing many jobs does not scan the cache per job.
+func (a *RBACAuthorizer) store(key string, decision accessDecision, now time.Time) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if now.Sub(a.pruned) > rbacDecisionTTL {
+		for k, entry := range a.decisions {
+			if !now.Before(entry.until) {
+				delete(a.decisions, k)
+			}
+		}
+		a.pruned = now
+	}
+	a.decisions[key] = cachedRBACDecision{decision: decision, until: now.Add(rbacDecisionTTL)}
+}
@@ -0,0 +1,219 @@
+package ui
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sync/atomic"
+	"testing"
+
+	api "renovate-operator/api/v1alpha1"
+	"renovate-operator/internal/apiTokens"
+
+	"github.com/go-logr/logr"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+// fakeRBAC is an API server answering SubjectAccessReviews with allow, and
+// counting them. A nil allow fails every review.
+func fakeRBAC(t *testing.T, allow func(spec authorizationv1.SubjectAccessReviewSpec) bool) (*RBACAuthorizer, *atomic.Int32) {
+	t.Helper()
+	var reviews atomic.Int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/apis/authorization.k8s.io/v1/subjectaccessreviews" {
+			http.NotFound(w, r)
+			return
+		}
+		reviews.Add(1)
+		if allow == nil {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		var review authorizationv1.SubjectAccessReview
+		if err := json.NewDecoder(r.Body).Decode(&review); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		review.Status.Allowed = allow(review.Spec)
+		review.APIVersion, review.Kind = "authorization.k8s.io/v1", "SubjectAccessReview"
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(review)
+	}))
+	t.Cleanup(ts.Close)
+
+	clientset, err := kubernetes.NewForConfig(&rest.Config{Host: ts.URL, ContentConfig: rest.ContentConfig{ContentType: "application/json"}})
+	if err != nil {
+		t.Fatalf("failed to create clientset: %v", err)
+	}
+	authorizer := NewRBACAuthorizer(clientset.AuthorizationV1().SubjectAccessReviews(), RBACIdentity{UserClaim: "email", UserPrefix: "oidc:", GroupPrefix: "oidc:"}, logr.Discard())
+	return authorizer, &reviews
+}
+
+// rbacRules grants verbs on renovatejobs, and subresources as
+// "<verb> <subresource>", to users and groups.
+func rbacRules(grants map[string][]string) func(spec authorizationv1.SubjectAccessReviewSpec) bool {
+	return func(spec authorizationv1.SubjectAccessReviewSpec) bool {
+		attrs := spec.ResourceAttributes
+		if attrs == nil || attrs.Group != api.GroupName || attrs.Resource != "renovatejobs" || attrs.Namespace != "default" {
+			return false
+		}
+		access := attrs.Verb
+		if attrs.Subresource != "" {
+			access += " " + attrs.Subresource
+		}
+		for _, subject := range append([]string{spec.User}, spec.Groups...) {
+			if slices.Contains(grants[subject], access) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+func rbacJob(name string) *api.RenovateJob {
+	return &api.RenovateJob{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"}}
+}
+
+func TestRBACAuthorizer_Subject(t *testing.T) {
+	authorizer := NewRBACAuthorizer(nil, RBACIdentity{UserClaim: "email", UserPrefix: "oidc:", GroupPrefix: "oidc:"}, logr.Discard())
+	byUsername := NewRBACAuthorizer(nil, RBACIdentity{UserClaim: "username"}, logr.Discard())
+
+	tests := []struct {
+		name           string
+		authorizer     *RBACAuthorizer
+		session        *sessionData
+		serviceAccount bool
+		wantUser       string
+		wantGroups     []string
+	}{
+		{
+			name:       "verified email with prefixes",
+			authorizer: authorizer,
+			session:    &sessionData{Email: "alice@example.com", EmailVerified: true, Username: "alice", Groups: []string{"team-a"}},
+			wantUser:   "oidc:alice@example.com", wantGroups: []string{"oidc:team-a", "system:authenticated"},
+		},
+		{
+			name:       "unverified email names no user",
+			authorizer: authorizer,
+			session:    &sessionData{Email: "alice@example.com", Groups: []string{"team-a"}},
+			wantUser:   "", wantGroups: []string{"oidc:team-a", "system:authenticated"},
+		},
+		{
+			name:       "username claim",
+			authorizer: byUsername,
+			session:    &sessionData{Email: "alice@example.com", EmailVerified: true, Username: "alice"},
+			wantUser:   "alice", wantGroups: []string{"system:authenticated"},
+		},
+		{
+			name:           "ServiceAccount keeps its name",
+			authorizer:     authorizer,
+			session:        &sessionData{Username: "system:serviceaccount:ci:deployer"},
+			serviceAccount: true,
+			wantUser:       "system:serviceaccount:ci:deployer",
+			wantGroups:     []string{"system:serviceaccounts", "system:serviceaccounts:ci", "system:authenticated"},
+		},
+		{
+			name:       "no session is anonymous",
+			authorizer: authorizer,
+			wantUser:   "system:anonymous", wantGroups: []string{"system:unauthenticated"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			user, groups := tt.authorizer.subject(tt.session, tt.serviceAccount)
+			if user != tt.wantUser || !slices.Equal(groups, tt.wantGroups) {
+				t.Errorf("subject = %q %v, want %q %v", user, groups, tt.wantUser, tt.wantGroups)
+			}
+		})
+	}
+}
+
+func TestRBACAuthorizer_Decide(t *testing.T) {
+	authorizer, reviews := fakeRBAC(t, rbacRules(map[string][]string{
+		"oidc:team-reader": {"get"},
+		"oidc:team-ops":    {"get", "get logs", "trigger", "cancel"},
+	}))
+	ctx := context.Background()
+	job := rbacJob("job1")
+
+	reader := authorizer.decide(ctx, &sessionData{Groups: []string{"team-reader"}}, false, job)
+	if !reader.canRead() || reviews.Load() != 1 {
+		t.Errorf("expected get alone to read after one review, got %+v after %d reviews", reader, reviews.Load())
+	}
+	if completed := reader.complete(); completed.Role != roleReader || len(completed.permissions()) != 0 {
+		t.Errorf("expected get alone to read, got %+v with %v", completed, completed.permissions())
+	}
+
+	// permissions are reviewed when they are asked for, and only once
+	before := reviews.Load()
+	ops := authorizer.decide(ctx, &sessionData{Groups: []string{"team-ops"}}, false, job)
+	if !ops.has(permTrigger) || !ops.has(permTrigger) || ops.has(permEdit) || reviews.Load() != before+3 {
+		t.Errorf("expected the read and the two permissions asked for to be reviewed, got %d reviews", reviews.Load()-before)
+	}
+	if completed := ops.complete(); completed.Role != roleCustom || !slices.Equal(completed.permissions(), []string{permLogs, permTrigger, permCancel}) {
+		t.Errorf("expected the granted verbs as permissions, got %+v with %v", completed, completed.permissions())
+	}
+	if reviews.Load() != before+1+int32(len(rbacPermissions)) {
+		t.Errorf("expected every permission to be reviewed once, got %d reviews", reviews.Load()-before)
+	}
+
+	before = reviews.Load()
+	outsider := authorizer.decide(ctx, &sessionData{Groups: []string{"team-other"}}, false, job)
+	if outsider.canRead() || reviews.Load() != before+1 {
+		t.Errorf("expected a job without get to be hidden after one review, got %+v after %d reviews", outsider, reviews.Load()-before)
+	}
+
+	before = reviews.Load()
+	if cached := authorizer.decide(ctx, &sessionData{Groups: []string{"team-ops"}}, false, job); !slices.Equal(cached.permissions(), ops.permissions()) || reviews.Load() != before {
+		t.Errorf("expected the decision to be cached, got %v after %d reviews", cached.permissions(), reviews.Load()-before)
+	}
+	if other := authorizer.decide(ctx, &sessionData{Groups: []string{"team-ops"}}, false, rbacJob("job2")); !other.canRead() || reviews.Load() == before {
+		t.Error("expected another job to be reviewed on its own")
+	}
+}
+
+func TestRBACAuthorizer_FailedReviewsDenyAndAreNotCached(t *testing.T) {
+	authorizer, reviews := fakeRBAC(t, nil)
+	ctx := context.Background()
+	session := &sessionData{Groups: []string{"team-ops"}}
+
+	if decision := authorizer.decide(ctx, session, false, rbacJob("job1")); decision.canRead() {
+		t.Errorf("expected a failed review to deny, got %+v", decision)
+	}
+	authorizer.decide(ctx, session, false, rbacJob("job1"))
+	if reviews.Load() != 2 {
+		t.Errorf("expected a failed review to be retried, got %d reviews", reviews.Load())
+	}
+}
+
+func TestDecideJobAccess_RBAC(t *testing.T) {
+	authorizer, _ := fakeRBAC(t, rbacRules(map[string][]string{
+		"oidc:team-ops": {"get", "get logs", "trigger", "cancel"},
+	}))
+	server := &Server{logger: logr.Discard(), auth: &OIDCAuth{}}
+	server.SetRBACAuthorizer(authorizer)
+
+	// The job's own access rules name nobody: RBAC alone decides.
+	job := tokenJob("default", "job1")
+	session := &sessionData{Groups: []string{"team-ops"}}
+	ctx := context.WithValue(context.Background(), sessionContextKey, session)
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/renovatejobs", nil).WithContext(ctx)
+	if got := server.decideJobAccess(req, job).permissions(); !slices.Equal(got, []string{permLogs, permTrigger, permCancel}) {
+		t.Errorf("permissions = %v, want RBAC's", got)
+	}
+
+	token := &apiTokens.Token{ID: "id", Scopes: []apiTokens.Scope{{Namespace: "default", RenovateJob: "job1", Permissions: []string{permTrigger, permDiscovery}}}}
+	req = req.WithContext(context.WithValue(ctx, tokenContextKey, token))
+	if got := server.decideJobAccess(req, job).permissions(); !slices.Equal(got, []string{permTrigger}) {
+		t.Errorf("expected a token to keep what both it and RBAC allow, got %v", got)
+	}
+
+	if server.checkAccessEnforceable([]api.RenovateJob{*job}) != nil {
+		t.Error("expected group rules not to be checked in RBAC mode")
+	}
+}
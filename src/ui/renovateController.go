@@ -2,16 +2,19 @@ package ui
 
 import (
 	"bufio"
+	"cmp"
 	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
 	api "renovate-operator/api/v1alpha1"
+	"renovate-operator/internal/audit"
 	crdmanager "renovate-operator/internal/crdManager"
 	"renovate-operator/internal/renovate"
 	"renovate-operator/internal/telemetry"
 	"renovate-operator/internal/types"
 	"renovate-operator/internal/utils"
+	"slices"
 	"strings"
 	"time"
 
@@ -22,14 +25,17 @@ import (
 )
 
 type RenovateJobInfo struct {
-	Name             string                             `json:"name"`
-	Namespace        string                             `json:"namespace"`
-	CronExpression   string                             `json:"cronExpression"`
-	NextSchedule     time.Time                          `json:"nextSchedule"`
-	DiscoveryStatus  api.RenovateProjectStatus          `json:"discoveryStatus"`
-	Projects         []crdmanager.RenovateProjectStatus `json:"projects"`
-	Platform         string                             `json:"platform,omitempty"`
-	PlatformEndpoint string                             `json:"platformEndpoint,omitempty"`
+	Name            string                             `json:"name"`
+	Namespace       string                             `json:"namespace"`
+	CronExpression  string                             `json:"cronExpression"`
+	NextSchedule    time.Time                          `json:"nextSchedule"`
+	DiscoveryStatus api.RenovateProjectStatus          `json:"discoveryStatus"`
+	Projects        []crdmanager.RenovateProjectStatus `json:"projects"`
+	// Summary counts all projects of the job, including those the query
+	// leaves out of Projects.
+	Summary          *ProjectSummary `json:"summary,omitempty"`
+	Platform         string          `json:"platform,omitempty"`
+	PlatformEndpoint string          `json:"platformEndpoint,omitempty"`
 	// Accepted is false when the operator's policy refuses this job, in which case
 	// nothing runs for it and AcceptedMessage says what to fix. Jobs reconciled by an
 	// older operator have no condition yet and are reported as accepted.
@@ -37,18 +43,43 @@ type RenovateJobInfo struct {
 	AcceptedMessage string   `json:"acceptedMessage,omitempty"`
 	Role            string   `json:"role,omitempty"`
 	Permissions     []string `json:"permissions"`
+	// DiscoveryCronExpression is the separate discovery schedule, empty when
+	// discovery runs with every scheduled run.
+	DiscoveryCronExpression string     `json:"discoveryCronExpression,omitempty"`
+	LastDiscovery           *time.Time `json:"lastDiscovery,omitempty"`
+	// WebhookDeliveries reports that the job receives webhooks and the
+	// delivery log is enabled, so its deliveries can be browsed.
+	WebhookDeliveries bool `json:"webhookDeliveries,omitempty"`
 }
 
 func (s *Server) decideJobAccess(r *http.Request, job *api.RenovateJob) accessDecision {
 	if s.auth == nil {
 		return adminDecision()
 	}
-	return resolveAccess(job, getSessionFromContext(r), s.accessDefaults, s.logger)
+	// A service token acts as no user; its scopes alone say what it may do.
+	if token := getTokenFromContext(r); token != nil && token.IsService() {
+		return restrictToToken(adminDecision(), token, job)
+	}
+	var decision accessDecision
+	if s.rbac != nil {
+		decision = s.rbac.decide(r.Context(), getSessionFromContext(r), isServiceAccountRequest(r), job)
+	} else {
+		decision = resolveAccess(job, getSessionFromContext(r), isServiceAccountRequest(r), s.accessDefaults, s.logger)
+	}
+	if token := getTokenFromContext(r); token != nil {
+		return restrictToToken(decision, token, job)
+	}
+	return decision
 }
 
 // checkAccessEnforceable reports whether the configured access rules can be
 // enforced, given the full set of jobs, and refreshes the cached verdict.
 func (s *Server) checkAccessEnforceable(jobs []api.RenovateJob) *AccessMisconfiguration {
+	// RBAC does not evaluate the group rules a provider without groups
+	// could never satisfy.
+	if s.rbac != nil {
+		return nil
+	}
 	misconfiguration, jobsWithGroups := detectAccessMisconfiguration(s.auth, s.accessDefaults, jobs)
 
 	// Logged only on a transition, because the dashboard polls: repeating this
@@ -76,7 +107,7 @@ func (s *Server) checkAccessEnforceable(jobs []api.RenovateJob) *AccessMisconfig
 // endpoints the dashboard polls -- and which anonymous read exposes without a
 // session -- would take the manager's global lock to list every RenovateJob.
 func (s *Server) accessEnforceable(ctx context.Context) *AccessMisconfiguration {
-	if s.auth == nil || s.auth.SupportsGroups() {
+	if s.auth == nil || s.auth.SupportsGroups() || s.rbac != nil {
 		return nil
 	}
 
@@ -173,20 +204,27 @@ func (s *Server) resolveJobAccess(r *http.Request, namespace, jobName string) (*
 func (s *Server) requireRead(w http.ResponseWriter, r *http.Request, namespace, jobName string) (*api.RenovateJob, bool) {
 	job, decision := s.resolveJobAccess(r, namespace, jobName)
 	if !decision.canRead() {
+		auditDenied(r)
 		http.Error(w, "not found", http.StatusNotFound)
 		return nil, false
 	}
 	return job, true
 }
 
-func (s *Server) requirePermission(w http.ResponseWriter, r *http.Request, namespace, jobName, permission string) (*api.RenovateJob, bool) {
+// requirePermission resolves the job and checks the request holds every one of
+// permissions on it.
+func (s *Server) requirePermission(w http.ResponseWriter, r *http.Request, namespace, jobName string, permissions ...string) (*api.RenovateJob, bool) {
 	job, decision := s.resolveJobAccess(r, namespace, jobName)
 	if !decision.canRead() {
+		auditDenied(r)
 		http.Error(w, "not found", http.StatusNotFound)
 		return nil, false
 	}
 
-	if !decision.has(permission) {
+	for _, permission := range permissions {
+		if decision.has(permission) {
+			continue
+		}
 		s.logger.Info("Access denied: missing permission",
 			"user", sessionEmail(r),
 			"role", decision.Role.String(),
@@ -196,6 +234,7 @@ func (s *Server) requirePermission(w http.ResponseWriter, r *http.Request, names
 			"path", r.URL.Path,
 			"method", r.Method,
 			"remote_addr", r.RemoteAddr)
+		auditDenied(r)
 		http.Error(w, "forbidden", http.StatusForbidden)
 		return nil, false
 	}
@@ -203,10 +242,23 @@ func (s *Server) requirePermission(w http.ResponseWriter, r *http.Request, names
 	return job, true
 }
 
-// sessionEmail returns the session's email for audit logs, or "anonymous" when
-// the request carries no session.
+// triggerPermissions returns the permissions a trigger with options needs on
+// top of permission.
+func triggerPermissions(permission string, options *api.RenovateExecutionOptions) []string {
+	if options != nil && options.Debug {
+		return []string{permission, permTriggerDebug}
+	}
+	return []string{permission}
+}
+
+// sessionEmail returns the session's email for audit logs, its username when
+// it has no email, as for ServiceAccounts, or "anonymous" when the request
+// carries no session.
 func sessionEmail(r *http.Request) string {
 	if session := getSessionFromContext(r); session != nil {
+		if session.Email == "" && session.Username != "" {
+			return session.Username
+		}
 		return session.Email
 	}
 	return "anonymous"
@@ -218,12 +270,29 @@ func (s *Server) registerApiV1Routes(router *mux.Router) {
 	apiV1.HandleFunc("/version", s.getVersion).Methods("GET")
 	apiV1.HandleFunc("/access/status", s.getAccessStatus).Methods("GET")
 	apiV1.HandleFunc("/renovatejobs", s.getRenovateJobs).Methods("GET")
-	apiV1.HandleFunc("/renovate", s.runRenovateForProject).Methods("POST")
-	apiV1.HandleFunc("/renovate/all", s.runRenovateForAllProjects).Methods("POST")
-	apiV1.HandleFunc("/renovate/cancel", s.cancelRenovateForProject).Methods("POST")
+	apiV1.HandleFunc("/renovatejobs", s.audited(audit.ActionJobCreate, s.createRenovateJob)).Methods("POST")
+	apiV1.HandleFunc("/renovatejobs", s.audited(audit.ActionJobUpdate, s.updateRenovateJob)).Methods("PUT")
+	apiV1.HandleFunc("/renovatejobs", s.audited(audit.ActionJobDelete, s.deleteRenovateJob)).Methods("DELETE")
+	apiV1.HandleFunc("/renovatejobs/projects", s.getRenovateJobProjects).Methods("GET")
+	apiV1.HandleFunc("/renovatejobs/spec", s.getEditableRenovateJob).Methods("GET")
+	apiV1.HandleFunc("/events", s.streamEvents).Methods("GET")
+	apiV1.HandleFunc("/renovate", s.audited(audit.ActionTrigger, s.runRenovateForProject)).Methods("POST")
+	apiV1.HandleFunc("/renovate/all", s.audited(audit.ActionTriggerAll, s.runRenovateForAllProjects)).Methods("POST")
+	apiV1.HandleFunc("/renovate/cancel", s.audited(audit.ActionCancel, s.cancelRenovateForProject)).Methods("POST")
+	apiV1.HandleFunc("/renovate/approve", s.audited(audit.ActionApprove, s.approveRenovateForProject)).Methods("POST")
 	apiV1.HandleFunc("/logs", s.getRenovateJobLogs).Methods("GET")
-	apiV1.HandleFunc("/discovery/start", s.runDiscoveryForProject).Methods("POST")
+	apiV1.HandleFunc("/discovery/start", s.audited(audit.ActionDiscovery, s.runDiscoveryForProject)).Methods("POST")
 	apiV1.HandleFunc("/discovery/status", s.discoveryStatusForProject).Methods("GET")
+	apiV1.HandleFunc("/webhook/deliveries", s.getWebhookDeliveries).Methods("GET")
+	apiV1.HandleFunc("/webhook/deliveries/replay", s.audited(audit.ActionWebhookReplay, s.replayWebhookDelivery)).Methods("POST")
+	apiV1.HandleFunc("/reports", s.getReport).Methods("GET")
+	apiV1.HandleFunc("/audit", s.getAuditLog).Methods("GET")
+	apiV1.HandleFunc("/tokens", s.getAPITokens).Methods("GET")
+	apiV1.HandleFunc("/tokens", s.audited(audit.ActionTokenCreate, s.createAPIToken)).Methods("POST")
+	apiV1.HandleFunc("/tokens", s.audited(audit.ActionTokenRevoke, s.deleteAPIToken)).Methods("DELETE")
+	apiV1.HandleFunc("/servicetokens", s.getServiceTokens).Methods("GET")
+	apiV1.HandleFunc("/servicetokens", s.audited(audit.ActionTokenCreate, s.createServiceToken)).Methods("POST")
+	apiV1.HandleFunc("/servicetokens", s.audited(audit.ActionTokenRevoke, s.deleteServiceToken)).Methods("DELETE")
 }
 
 func (s *Server) getVersion(w http.ResponseWriter, r *http.Request) {
@@ -253,7 +322,33 @@ func (s *Server) getAccessStatus(w http.ResponseWriter, r *http.Request) {
 	_ = json.NewEncoder(w).Encode(result)
 }
 
+// readableJob is a job the request can read, with the projects its query
+// selects.
+type readableJob struct {
+	job      *api.RenovateJob
+	decision accessDecision
+	projects []api.ProjectStatus
+}
+
+// getRenovateJobs lists the jobs the request can read. The query narrows the
+// projects by status and name (see parseProjectQuery), pages through the jobs,
+// and with summary=true leaves out the projects in favour of their counts.
+// Jobs without matching projects are left out when filtering, unless the
+// search matches the job's name.
 func (s *Server) getRenovateJobs(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	projectQuery, err := parseProjectQuery(query)
+	if err != nil {
+		badRequestError(w, err, err.Error())
+		return
+	}
+	page, err := parsePageQuery(query)
+	if err != nil {
+		badRequestError(w, err, err.Error())
+		return
+	}
+	summaryOnly := query.Get("summary") == "true"
+
 	renovateJobs, err := s.manager.ListRenovateJobsFull(r.Context())
 	if err != nil {
 		internalServerError(w, err, "failed to load renovatejobs")
@@ -262,9 +357,26 @@ func (s *Server) getRenovateJobs(w http.ResponseWriter, r *http.Request) {
 
 	renovateJobs, decisions := s.filterReadableJobs(r, renovateJobs)
 
-	result := make([]RenovateJobInfo, 0)
+	selected := make([]readableJob, 0, len(renovateJobs))
 	for i := range renovateJobs {
-		renovateJob := &renovateJobs[i]
+		jobQuery := projectQuery
+		if jobQuery.search != "" && strings.Contains(strings.ToLower(renovateJobs[i].Name), jobQuery.search) {
+			jobQuery.search = ""
+		}
+		projects := jobQuery.apply(renovateJobs[i].Status.Projects)
+		if jobQuery.filtered() && len(projects) == 0 {
+			continue
+		}
+		selected = append(selected, readableJob{job: &renovateJobs[i], decision: decisions[i], projects: projects})
+	}
+	slices.SortFunc(selected, func(a, b readableJob) int {
+		return cmp.Or(strings.Compare(a.job.Namespace, b.job.Namespace), strings.Compare(a.job.Name, b.job.Name))
+	})
+
+	result := make([]RenovateJobInfo, 0)
+	for _, entry := range paginate(w, selected, page) {
+		renovateJob := entry.job
+		decision := entry.decision.complete()
 
 		discoveryStatus, err := s.discovery.GetDiscoveryJobStatus(r.Context(), renovateJob)
 		if err != nil {
@@ -279,36 +391,38 @@ func (s *Server) getRenovateJobs(w http.ResponseWriter, r *http.Request) {
 		platform, _ := utils.GetPlatformAndEndpoint(renovateJob.Spec.Provider)
 		platformEndpoint := utils.GetPublicEndpoint(renovateJob.Spec.Provider)
 
-		projects := make([]crdmanager.RenovateProjectStatus, 0, len(renovateJob.Status.Projects))
-		for _, p := range renovateJob.Status.Projects {
-			projects = append(projects, crdmanager.RenovateProjectStatus{
-				Name:                 p.Name,
-				Status:               p.Status,
-				LastTransition:       crdmanager.NonZeroTime(p.LastTransition.Time),
-				Priority:             p.Priority,
-				RenovateResultStatus: p.RenovateResultStatus,
-				Duration:             p.Duration,
-				PRActivity:           p.PRActivity,
-				LogIssues:            p.LogIssues,
-				ExecutionOptions:     p.ExecutionOptions,
-			})
+		projects := make([]crdmanager.RenovateProjectStatus, 0, len(entry.projects))
+		if !summaryOnly {
+			for j := range entry.projects {
+				projects = append(projects, projectInfo(&entry.projects[j]))
+			}
 		}
+		summary := summarizeProjects(renovateJob.Status.Projects)
 
 		accepted, acceptedMessage := acceptedState(renovateJob)
 
+		var lastDiscovery *time.Time
+		if renovateJob.Status.LastDiscoveryTime != nil {
+			lastDiscovery = crdmanager.NonZeroTime(renovateJob.Status.LastDiscoveryTime.Time)
+		}
+
 		result = append(result, RenovateJobInfo{
-			Name:             renovateJob.Name,
-			Namespace:        renovateJob.Namespace,
-			Accepted:         accepted,
-			AcceptedMessage:  acceptedMessage,
-			NextSchedule:     s.scheduler.GetNextRunOnSchedule(renovateJob.Spec.Schedule, renovateJob.Fullname()),
-			Projects:         projects,
-			CronExpression:   renovateJob.Spec.Schedule,
-			DiscoveryStatus:  discoveryStatus,
-			Platform:         platform,
-			PlatformEndpoint: platformEndpoint,
-			Role:             decisions[i].Role.String(),
-			Permissions:      decisions[i].permissions(),
+			Name:                    renovateJob.Name,
+			Namespace:               renovateJob.Namespace,
+			Accepted:                accepted,
+			AcceptedMessage:         acceptedMessage,
+			NextSchedule:            s.scheduler.GetNextRunOnSchedule(renovateJob.Spec.Schedule, renovateJob.Fullname()),
+			Projects:                projects,
+			Summary:                 &summary,
+			CronExpression:          renovateJob.Spec.Schedule,
+			DiscoveryCronExpression: renovateJob.Spec.DiscoverySchedule,
+			LastDiscovery:           lastDiscovery,
+			DiscoveryStatus:         discoveryStatus,
+			Platform:                platform,
+			PlatformEndpoint:        platformEndpoint,
+			Role:                    decision.Role.String(),
+			Permissions:             decision.permissions(),
+			WebhookDeliveries:       s.deliveries != nil && renovateJob.Spec.Webhook != nil && renovateJob.Spec.Webhook.Enabled,
 		})
 	}
 
@@ -316,6 +430,53 @@ func (s *Server) getRenovateJobs(w http.ResponseWriter, r *http.Request) {
 	_ = json.NewEncoder(w).Encode(result)
 }
 
+// getRenovateJobProjects pages through the projects of a job, narrowed and
+// ordered like those of getRenovateJobs, for clients that list the jobs with
+// summary=true and load the projects on demand.
+func (s *Server) getRenovateJobProjects(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	projectQuery, err := parseProjectQuery(query)
+	if err != nil {
+		badRequestError(w, err, err.Error())
+		return
+	}
+	page, err := parsePageQuery(query)
+	if err != nil {
+		badRequestError(w, err, err.Error())
+		return
+	}
+
+	job, ok := s.requireRead(w, r, query.Get("namespace"), query.Get("renovate"))
+	if !ok {
+		return
+	}
+
+	selected := paginate(w, projectQuery.apply(job.Status.Projects), page)
+	result := make([]crdmanager.RenovateProjectStatus, 0, len(selected))
+	for i := range selected {
+		result = append(result, projectInfo(&selected[i]))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(result)
+}
+
+// projectInfo is the project as the UI shows it.
+func projectInfo(p *api.ProjectStatus) crdmanager.RenovateProjectStatus {
+	return crdmanager.RenovateProjectStatus{
+		Name:                 p.Name,
+		Status:               p.Status,
+		LastTransition:       crdmanager.NonZeroTime(p.LastTransition.Time),
+		Priority:             p.Priority,
+		RenovateResultStatus: p.RenovateResultStatus,
+		Duration:             p.Duration,
+		PRActivity:           p.PRActivity,
+		LogIssues:            p.LogIssues,
+		ExecutionOptions:     p.ExecutionOptions,
+		RerunAfterCurrent:    p.RerunAfterCurrent,
+	}
+}
+
 // acceptedState reads the Accepted condition.
 func acceptedState(job *api.RenovateJob) (bool, string) {
 	condition := meta.FindStatusCondition(job.Status.Conditions, api.ConditionAccepted)
@@ -438,7 +599,11 @@ func (s *Server) runRenovateForProject(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if _, ok := s.requirePermission(w, r, body.Namespace, body.RenovateJob, permTrigger); !ok {
+	auditTarget(r, body.Namespace, body.RenovateJob, body.Project)
+	if body.ExecutionOptions != nil && body.ExecutionOptions.Debug {
+		auditDetail(r, "debug", "true")
+	}
+	if _, ok := s.requirePermission(w, r, body.Namespace, body.RenovateJob, triggerPermissions(permTrigger, body.ExecutionOptions)...); !ok {
 		return
 	}
 
@@ -477,6 +642,7 @@ func (s *Server) cancelRenovateForProject(w http.ResponseWriter, r *http.Request
 		return
 	}
 
+	auditTarget(r, params.namespace, params.name, params.project)
 	if _, ok := s.requirePermission(w, r, params.namespace, params.name, permCancel); !ok {
 		return
 	}
@@ -499,6 +665,43 @@ func (s *Server) cancelRenovateForProject(w http.ResponseWriter, r *http.Request
 	s.logger.V(2).Info("Successfully cancelled Renovate for project", "project", params.project, "renovateJob", params.name, "namespace", params.namespace)
 }
 
+// approveRenovateForProject releases a project held in pending-approval by the
+// job's onboarding gate so it is scheduled like any other project.
+func (s *Server) approveRenovateForProject(w http.ResponseWriter, r *http.Request) {
+	params, err := getRenovateJsonBody(r)
+	if err != nil {
+		badRequestError(w, err, "failed to parse request body")
+		return
+	}
+
+	if params.name == "" || params.namespace == "" || params.project == "" {
+		badRequestError(w, err, "Missing parameters")
+		return
+	}
+
+	auditTarget(r, params.namespace, params.name, params.project)
+	if _, ok := s.requirePermission(w, r, params.namespace, params.name, permApprove); !ok {
+		return
+	}
+
+	err = s.manager.ApproveProjects(
+		r.Context(),
+		crdmanager.RenovateJobIdentifier{
+			Name:      params.name,
+			Namespace: params.namespace,
+		},
+		[]string{params.project},
+	)
+	if err != nil {
+		s.logger.Error(err, "Failed to approve project", "project", params.project, "renovateJob", params.name, "namespace", params.namespace)
+		internalServerError(w, err, "failed to approve project")
+		return
+	}
+
+	writeSuccess(w, SuccessResult{Message: "Project approved"})
+	s.logger.V(2).Info("Successfully approved project", "project", params.project, "renovateJob", params.name, "namespace", params.namespace)
+}
+
 func (s *Server) runRenovateForAllProjects(w http.ResponseWriter, r *http.Request) {
 	var body struct {
 		RenovateJob      string                        `json:"renovateJob"`
@@ -515,7 +718,11 @@ func (s *Server) runRenovateForAllProjects(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
-	if _, ok := s.requirePermission(w, r, body.Namespace, body.RenovateJob, permTriggerAll); !ok {
+	auditTarget(r, body.Namespace, body.RenovateJob, "")
+	if body.ExecutionOptions != nil && body.ExecutionOptions.Debug {
+		auditDetail(r, "debug", "true")
+	}
+	if _, ok := s.requirePermission(w, r, body.Namespace, body.RenovateJob, triggerPermissions(permTriggerAll, body.ExecutionOptions)...); !ok {
 		return
 	}
 
@@ -558,6 +765,7 @@ func (s *Server) runDiscoveryForProject(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	auditTarget(r, params.namespace, params.name, "")
 	// requirePermission returns the job to avoid a duplicate K8s API call
 	job, ok := s.requirePermission(w, r, params.namespace, params.name, permDiscovery)
 	if !ok {
@@ -569,6 +777,7 @@ func (s *Server) runDiscoveryForProject(w http.ResponseWriter, r *http.Request)
 	status, err := s.discovery.GetDiscoveryJobStatus(ctx, job)
 	if err == nil && status == api.JobStatusRunning {
 		// discovery job is already running
+		auditDetail(r, "alreadyRunning", "true")
 		writeSuccess(w, SuccessResult{Message: "discovery job is already running"})
 		return
 	}
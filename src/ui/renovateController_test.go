@@ -36,6 +36,7 @@ type mockRenovateJobManager struct {
 	getRenovateJobFunc            func(ctx context.Context, name, namespace string) (*api.RenovateJob, error)
 	reconcileProjectsFunc         func(ctx context.Context, jobId *api.RenovateJob, projects []string) error
 	cancelProjectJobFunc          func(ctx context.Context, project string, jobId crdmanager.RenovateJobIdentifier) error
+	approveProjectsFunc           func(ctx context.Context, jobId crdmanager.RenovateJobIdentifier, projects []string) error
 }
 
 func (m *mockRenovateJobManager) ListRenovateJobs(ctx context.Context) ([]crdmanager.RenovateJobIdentifier, error) {
@@ -136,6 +137,25 @@ func (m *mockRenovateJobManager) CancelProjectJob(ctx context.Context, project s
 	return nil
 }
 
+func (m *mockRenovateJobManager) ApproveProjects(ctx context.Context, jobId crdmanager.RenovateJobIdentifier, projects []string) error {
+	if m.approveProjectsFunc != nil {
+		return m.approveProjectsFunc(ctx, jobId, projects)
+	}
+	return nil
+}
+
+func (m *mockRenovateJobManager) DiscoverProjects(ctx context.Context, job *api.RenovateJob) ([]string, error) {
+	return nil, nil
+}
+
+func (m *mockRenovateJobManager) GetDefaultBranch(ctx context.Context, job *api.RenovateJob, project string) (string, error) {
+	return "", nil
+}
+
+func (m *mockRenovateJobManager) ListChangedFiles(ctx context.Context, job *api.RenovateJob, project, base, head string) ([]string, error) {
+	return nil, nil
+}
+
 // Mock DiscoveryAgent
 type mockDiscoveryAgent struct {
 	getDiscoveryJobStatusFunc func(ctx context.Context, job *api.RenovateJob) (api.RenovateProjectStatus, error)
@@ -337,6 +357,47 @@ func TestRunRenovateForProject_MissingParams(t *testing.T) {
 	}
 }
 
+func TestApproveRenovateForProject_Success(t *testing.T) {
+	var approved []string
+	mockManager := &mockRenovateJobManager{
+		approveProjectsFunc: func(ctx context.Context, jobId crdmanager.RenovateJobIdentifier, projects []string) error {
+			if jobId.Name != "job1" || jobId.Namespace != "default" {
+				t.Errorf("unexpected job %v", jobId)
+			}
+			approved = projects
+			return nil
+		},
+		getRenovateJobFunc: func(ctx context.Context, name, namespace string) (*api.RenovateJob, error) {
+			return &api.RenovateJob{}, nil
+		},
+	}
+
+	server := &Server{
+		manager: mockManager,
+		logger:  logr.Discard(),
+	}
+
+	body := map[string]string{
+		"renovateJob": "job1",
+		"namespace":   "default",
+		"project":     "project1",
+	}
+	jsonBody, _ := json.Marshal(body)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/renovate/approve", bytes.NewReader(jsonBody))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	server.approveRenovateForProject(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+	if len(approved) != 1 || approved[0] != "project1" {
+		t.Errorf("Expected project1 to be approved, got %v", approved)
+	}
+}
+
 func TestDiscoveryStatusForProject_Success(t *testing.T) {
 	mockManager := &mockRenovateJobManager{
 		getRenovateJobFunc: func(ctx context.Context, name, namespace string) (*api.RenovateJob, error) {
@@ -481,6 +542,13 @@ func (m *mockScheduler) AddScheduleReplaceExisting(expr string, namespace, job s
 	return nil
 }
 func (m *mockScheduler) RemoveSchedule(namespace, job string) {}
+func (m *mockScheduler) AddDiscoveryScheduleReplaceExisting(expr string, namespace, job string, fn func()) error {
+	return nil
+}
+func (m *mockScheduler) RemoveDiscoverySchedule(namespace, job string) {}
+func (m *mockScheduler) AddOperatorScheduleReplaceExisting(expr string, name string, fn func()) error {
+	return nil
+}
 func (m *mockScheduler) GetNextRunOnSchedule(schedule, key string) time.Time {
 	return time.Now().Add(24 * time.Hour)
 }
@@ -800,7 +868,7 @@ func TestGetRenovateJobs_ManyMissingDiscoveryJobsReturnsQuickly(t *testing.T) {
 	server := &Server{
 		manager:   mockManager,
 		logger:    logr.Discard(),
-		discovery: renovate.NewDiscoveryAgent(scheme, fake.NewClientBuilder().WithScheme(scheme).Build(), logr.Discard(), nil, nil, policy.Policy{}),
+		discovery: renovate.NewDiscoveryAgent(scheme, fake.NewClientBuilder().WithScheme(scheme).Build(), logr.Discard(), nil, nil, policy.Policy{}, nil),
 		scheduler: &mockScheduler{},
 	}
 
@@ -1101,3 +1169,126 @@ func TestRunRenovateForAllProjects_Authorization(t *testing.T) {
 		})
 	}
 }
+
+func TestGetRenovateJobs_Query(t *testing.T) {
+	jobs := []api.RenovateJob{
+		{ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "team-b"}, Status: api.RenovateJobStatus{Projects: queryProjects()}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "idle", Namespace: "team-a"}, Status: api.RenovateJobStatus{Projects: []api.ProjectStatus{
+			{Name: "org/docs", Status: api.JobStatusCompleted},
+		}}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "broken", Namespace: "team-a"}, Status: api.RenovateJobStatus{Projects: []api.ProjectStatus{
+			{Name: "org/legacy", Status: api.JobStatusFailed},
+		}}},
+	}
+	server := &Server{
+		manager: &mockRenovateJobManager{
+			listRenovateJobsFullFunc: func(ctx context.Context) ([]api.RenovateJob, error) { return jobs, nil },
+		},
+		logger:    logr.Discard(),
+		discovery: &mockDiscoveryAgent{},
+		scheduler: &mockScheduler{},
+	}
+	list := func(t *testing.T, query string) ([]RenovateJobInfo, string) {
+		t.Helper()
+		w := httptest.NewRecorder()
+		server.getRenovateJobs(w, httptest.NewRequest(http.MethodGet, "/api/v1/renovatejobs?"+query, nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+		}
+		var result []RenovateJobInfo
+		if err := json.NewDecoder(w.Body).Decode(&result); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		return result, w.Header().Get("X-Total-Count")
+	}
+
+	t.Run("pages through the jobs in namespace and name order", func(t *testing.T) {
+		result, total := list(t, "limit=2&page=1")
+		if total != "3" || len(result) != 2 || result[0].Name != "broken" || result[1].Name != "idle" {
+			t.Errorf("expected broken and idle of 3 jobs, got %s of %+v", total, result)
+		}
+		if result, _ = list(t, "limit=2&page=2"); len(result) != 1 || result[0].Name != "web" || len(result[0].Projects) != 4 {
+			t.Errorf("expected web with all projects on the second page, got %+v", result)
+		}
+	})
+
+	t.Run("leaves out jobs without matching projects", func(t *testing.T) {
+		result, total := list(t, "status=failed&sort=name")
+		if total != "2" || len(result) != 2 || result[0].Name != "broken" || result[1].Name != "web" {
+			t.Fatalf("expected broken and web, got %+v", result)
+		}
+		if len(result[1].Projects) != 1 || result[1].Projects[0].Name != "org/api" || result[1].Summary.Total != 4 {
+			t.Errorf("expected the failing project of web with the counts of all projects, got %+v", result[1])
+		}
+	})
+
+	t.Run("a search matching the job keeps its projects", func(t *testing.T) {
+		result, _ := list(t, "search=idl")
+		if len(result) != 1 || result[0].Name != "idle" || len(result[0].Projects) != 1 {
+			t.Errorf("expected idle with its project, got %+v", result)
+		}
+	})
+
+	t.Run("summary leaves out the projects", func(t *testing.T) {
+		result, _ := list(t, "summary=true")
+		if len(result) != 3 || len(result[2].Projects) != 0 || result[2].Summary == nil || result[2].Summary.Failed != 1 || result[2].Summary.OpenPRs != 3 {
+			t.Errorf("expected the counts without projects, got %+v", result)
+		}
+	})
+
+	t.Run("rejects invalid parameters", func(t *testing.T) {
+		for _, query := range []string{"status=unknown", "sort=size", "limit=0"} {
+			w := httptest.NewRecorder()
+			server.getRenovateJobs(w, httptest.NewRequest(http.MethodGet, "/api/v1/renovatejobs?"+query, nil))
+			if w.Code != http.StatusBadRequest {
+				t.Errorf("expected 400 for %s, got %d", query, w.Code)
+			}
+		}
+	})
+}
+
+func TestGetRenovateJobProjects(t *testing.T) {
+	job := &api.RenovateJob{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "team-b"},
+		Spec:       api.RenovateJobSpec{Access: &api.RenovateJobAccess{ReaderGroups: []string{"team-b"}}},
+		Status:     api.RenovateJobStatus{Projects: queryProjects()},
+	}
+	server := &Server{
+		manager: &mockRenovateJobManager{
+			getRenovateJobFunc: func(ctx context.Context, name, namespace string) (*api.RenovateJob, error) {
+				if name == job.Name && namespace == job.Namespace {
+					return job, nil
+				}
+				return nil, k8serrors.NewNotFound(schema.GroupResource{}, name)
+			},
+		},
+		logger: logr.Discard(),
+		auth:   &OIDCAuth{},
+	}
+	get := func(query string, groups ...string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/renovatejobs/projects?"+query, nil)
+		req = req.WithContext(context.WithValue(req.Context(), sessionContextKey, &sessionData{Email: "someone@example.com", Groups: groups}))
+		w := httptest.NewRecorder()
+		server.getRenovateJobProjects(w, req)
+		return w
+	}
+
+	w := get("namespace=team-b&renovate=web&sort=lastTransition&limit=2&page=2", "team-b")
+	if w.Code != http.StatusOK || w.Header().Get("X-Total-Count") != "4" {
+		t.Fatalf("expected 200 with 4 projects in total, got %d %q", w.Code, w.Header().Get("X-Total-Count"))
+	}
+	var projects []crdmanager.RenovateProjectStatus
+	if err := json.NewDecoder(w.Body).Decode(&projects); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if len(projects) != 2 || projects[0].Name != "org/api" || projects[1].Name != "team/Website" {
+		t.Errorf("expected the two least recent projects, got %+v", projects)
+	}
+
+	if w := get("namespace=team-b&renovate=web", "team-a"); w.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for a job the caller cannot read, got %d", w.Code)
+	}
+	if w := get("namespace=team-b&renovate=web&status=done", "team-b"); w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for an unknown status, got %d", w.Code)
+	}
+}
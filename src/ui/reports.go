@@ -0,0 +1,68 @@
+package ui
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	api "renovate-operator/api/v1alpha1"
+	"renovate-operator/internal/reports"
+)
+
+// getReport renders the digest report of the RenovateJobs the request can
+// read, optionally narrowed to a namespace and a job.
+func (s *Server) getReport(w http.ResponseWriter, r *http.Request) {
+	if s.reports == nil {
+		http.Error(w, "not found", http.StatusNotFound)
+		return
+	}
+
+	query := r.URL.Query()
+	days := reports.DefaultDays
+	if raw := query.Get("days"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed < 1 || parsed > reports.MaxDays {
+			badRequestError(w, err, fmt.Sprintf("days must be between 1 and %d", reports.MaxDays))
+			return
+		}
+		days = parsed
+	}
+	format := query.Get("format")
+	if format == "" {
+		format = reports.FormatJSON
+	}
+	if err := reports.ValidateFormat(format); err != nil {
+		badRequestError(w, err, err.Error())
+		return
+	}
+
+	renovateJobs, err := s.manager.ListRenovateJobsFull(r.Context())
+	if err != nil {
+		internalServerError(w, err, "failed to load renovatejobs")
+		return
+	}
+	renovateJobs, _ = s.filterReadableJobs(r, renovateJobs)
+
+	namespace, renovate := query.Get("namespace"), query.Get("renovate")
+	selected := make([]api.RenovateJob, 0, len(renovateJobs))
+	for _, job := range renovateJobs {
+		if (namespace == "" || job.Namespace == namespace) && (renovate == "" || job.Name == renovate) {
+			selected = append(selected, job)
+		}
+	}
+
+	report, err := reports.Generate(r.Context(), s.reports, selected, days, time.Now())
+	if err != nil {
+		internalServerError(w, err, "failed to generate report")
+		return
+	}
+	body, err := reports.Render(report, format)
+	if err != nil {
+		internalServerError(w, err, "failed to render report")
+		return
+	}
+
+	w.Header().Set("Content-Type", reports.ContentType(format))
+	_, _ = w.Write(body)
+}
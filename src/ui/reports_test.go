@@ -0,0 +1,103 @@
+package ui
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	api "renovate-operator/api/v1alpha1"
+	"renovate-operator/internal/kvstore"
+	"renovate-operator/internal/reports"
+
+	"github.com/go-logr/logr"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestGetReport(t *testing.T) {
+	jobs := []api.RenovateJob{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "job1", Namespace: "team-a"},
+			Spec:       api.RenovateJobSpec{Access: &api.RenovateJobAccess{ReaderGroups: []string{"team-a"}}},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "job2", Namespace: "team-b"},
+			Spec:       api.RenovateJobSpec{Access: &api.RenovateJobAccess{ReaderGroups: []string{"team-b"}}},
+		},
+	}
+	history, err := reports.NewHistory(logr.Discard(), "memory", kvstore.ValkeyConfig{})
+	if err != nil {
+		t.Fatalf("NewHistory returned error: %v", err)
+	}
+	for _, job := range jobs {
+		history.Record(context.Background(), reports.Run{
+			Namespace:   job.Namespace,
+			RenovateJob: job.Name,
+			Project:     "org/repo",
+			Status:      api.JobStatusCompleted,
+			Time:        time.Now(),
+			PRActivity:  &api.PRActivity{Created: 3},
+		})
+	}
+
+	server := &Server{
+		manager: &mockRenovateJobManager{
+			listRenovateJobsFullFunc: func(_ context.Context) ([]api.RenovateJob, error) { return jobs, nil },
+		},
+		logger:  logr.Discard(),
+		auth:    &OIDCAuth{},
+		reports: history,
+	}
+	get := func(target string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		session := &sessionData{Email: "someone@example.com", Groups: []string{"team-a"}}
+		req = req.WithContext(context.WithValue(req.Context(), sessionContextKey, session))
+		w := httptest.NewRecorder()
+		server.getReport(w, req)
+		return w
+	}
+
+	t.Run("covers the readable jobs only", func(t *testing.T) {
+		w := get("/api/v1/reports")
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+		}
+		var report reports.Report
+		if err := json.NewDecoder(w.Body).Decode(&report); err != nil {
+			t.Fatalf("failed to decode report: %v", err)
+		}
+		if len(report.Namespaces) != 1 || report.Namespaces[0].Namespace != "team-a" || report.Totals.Created != 3 {
+			t.Errorf("expected the report of team-a only, got %+v", report)
+		}
+	})
+
+	t.Run("renders markdown", func(t *testing.T) {
+		w := get("/api/v1/reports?format=markdown&days=1&namespace=team-a&renovate=job1")
+		if w.Code != http.StatusOK || !strings.HasPrefix(w.Header().Get("Content-Type"), "text/markdown") {
+			t.Fatalf("expected a Markdown report, got %d %q", w.Code, w.Header().Get("Content-Type"))
+		}
+		if !strings.Contains(w.Body.String(), "| job1 | 0 | 1 | 0 | 3 |") {
+			t.Errorf("unexpected Markdown report:\n%s", w.Body.String())
+		}
+	})
+
+	t.Run("rejects invalid parameters", func(t *testing.T) {
+		for _, target := range []string{"/api/v1/reports?days=0", "/api/v1/reports?days=32", "/api/v1/reports?format=pdf"} {
+			if w := get(target); w.Code != http.StatusBadRequest {
+				t.Errorf("expected 400 for %s, got %d", target, w.Code)
+			}
+		}
+	})
+
+	t.Run("not found without history", func(t *testing.T) {
+		disabled := &Server{logger: logr.Discard()}
+		w := httptest.NewRecorder()
+		disabled.getReport(w, httptest.NewRequest(http.MethodGet, "/api/v1/reports", nil))
+		if w.Code != http.StatusNotFound {
+			t.Errorf("expected 404 with the run history disabled, got %d", w.Code)
+		}
+	})
+}
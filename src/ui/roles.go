@@ -0,0 +1,139 @@
+package ui
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+
+	api "renovate-operator/api/v1alpha1"
+
+	"github.com/go-logr/logr"
+)
+
+// Role permissions are what a custom role is written in. They name what
+// people do in the UI; each grants one or more of the API permissions above.
+const (
+	rolePermView         = "view"
+	rolePermLogs         = "logs"
+	rolePermTrigger      = "trigger"
+	rolePermTriggerDebug = "trigger-debug"
+	rolePermCancel       = "cancel"
+	rolePermDiscovery    = "discovery"
+	rolePermManage       = "manage"
+)
+
+// rolePermissionGrants maps each role permission to the API permissions it
+// grants. view grants none: binding any role makes the job readable.
+var rolePermissionGrants = map[string][]string{
+	rolePermView:         {},
+	rolePermLogs:         {permLogs},
+	rolePermTrigger:      {permTrigger, permTriggerAll},
+	rolePermTriggerDebug: {permTriggerDebug},
+	rolePermCancel:       {permCancel},
+	rolePermDiscovery:    {permDiscovery},
+	rolePermManage:       {permApprove, permWebhookDeliveries, permEdit, permAudit},
+}
+
+// The built-in roles, which role bindings name like custom ones and custom
+// roles cannot redefine.
+const (
+	builtinRoleReader = "reader"
+	builtinRoleAdmin  = "admin"
+)
+
+var roleNamePattern = regexp.MustCompile(`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`)
+
+// ParseRoles reads the operator-wide custom roles, a JSON object of role
+// names to role permissions, e.g. {"developer": ["view", "logs", "trigger"]},
+// and returns each role as the API permissions it grants.
+func ParseRoles(raw string) (map[string][]string, error) {
+	if raw == "" {
+		return nil, nil
+	}
+	var parsed map[string][]string
+	if err := json.Unmarshal([]byte(raw), &parsed); err != nil {
+		return nil, fmt.Errorf("expected a JSON object of role names to permissions: %w", err)
+	}
+
+	known := make([]string, 0, len(rolePermissionGrants))
+	for permission := range rolePermissionGrants {
+		known = append(known, permission)
+	}
+	slices.Sort(known)
+
+	roles := make(map[string][]string, len(parsed))
+	for name, permissions := range parsed {
+		if !roleNamePattern.MatchString(name) || len(name) > 63 {
+			return nil, fmt.Errorf("invalid role name %q: expected lowercase letters, digits and dashes", name)
+		}
+		if name == builtinRoleReader || name == builtinRoleAdmin {
+			return nil, fmt.Errorf("role %q is built in and cannot be redefined", name)
+		}
+		if len(permissions) == 0 {
+			return nil, fmt.Errorf("role %q grants no permission, give it at least %q", name, rolePermView)
+		}
+		granted := []string{}
+		for _, permission := range permissions {
+			grants, ok := rolePermissionGrants[permission]
+			if !ok {
+				return nil, fmt.Errorf("role %q: unknown permission %q, expected one of %s", name, permission, strings.Join(known, ", "))
+			}
+			granted = append(granted, grants...)
+		}
+		// Debug mode changes how a run is triggered, not whether it may be.
+		if slices.Contains(permissions, rolePermTriggerDebug) && !slices.Contains(permissions, rolePermTrigger) {
+			return nil, fmt.Errorf("role %q: %q needs %q", name, rolePermTriggerDebug, rolePermTrigger)
+		}
+		roles[name] = slices.Compact(slices.Sorted(slices.Values(granted)))
+	}
+	return roles, nil
+}
+
+// rolePermissions returns the API permissions a role grants, and whether the
+// role exists.
+func rolePermissions(roles map[string][]string, name string) ([]string, bool) {
+	switch name {
+	case builtinRoleReader:
+		return []string{permLogs}, true
+	case builtinRoleAdmin:
+		return adminDecision().permissions(), true
+	}
+	permissions, ok := roles[name]
+	return permissions, ok
+}
+
+// unknownRoleLogged remembers the bindings to unknown roles already reported,
+// keyed by namespace/name/role, for the same reason as conflictingAccessLogged.
+var unknownRoleLogged sync.Map
+
+// boundPermissions returns the API permissions the role bindings of a job
+// grant the identities and groups, and whether any binding matched. A binding
+// to a role that does not exist grants nothing.
+func boundPermissions(job *api.RenovateJob, bindings []api.RenovateJobRoleBinding, identities, groups []string, roles map[string][]string, logger logr.Logger) ([]string, bool) {
+	var (
+		granted []string
+		bound   bool
+	)
+	for _, binding := range bindings {
+		if !hasIntersection(identities, normalizeGroups(binding.Users)) && !hasIntersection(groups, normalizeGroups(binding.Groups)) {
+			continue
+		}
+		role := strings.ToLower(strings.TrimSpace(binding.Role))
+		permissions, ok := rolePermissions(roles, role)
+		if !ok {
+			if _, seen := unknownRoleLogged.LoadOrStore(job.Namespace+"/"+job.Name+"/"+role, struct{}{}); !seen {
+				logger.Error(nil, "role binding ignored: the role does not exist",
+					"role", role,
+					"resource", job.Name,
+					"namespace", job.Namespace)
+			}
+			continue
+		}
+		bound = true
+		granted = append(granted, permissions...)
+	}
+	return granted, bound
+}
@@ -0,0 +1,239 @@
+package ui
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/go-logr/logr"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	api "renovate-operator/api/v1alpha1"
+	crdmanager "renovate-operator/internal/crdManager"
+)
+
+func TestParseRoles(t *testing.T) {
+	roles, err := ParseRoles(`{"developer": ["view", "logs", "trigger"], "operator": ["view", "trigger", "trigger-debug", "cancel", "trigger"]}`)
+	if err != nil {
+		t.Fatalf("ParseRoles returned error: %v", err)
+	}
+	if want := []string{permLogs, permTrigger, permTriggerAll}; !slices.Equal(roles["developer"], want) {
+		t.Errorf("developer = %v, want %v", roles["developer"], want)
+	}
+	if want := []string{permCancel, permTrigger, permTriggerAll, permTriggerDebug}; !slices.Equal(roles["operator"], want) {
+		t.Errorf("operator = %v, want %v", roles["operator"], want)
+	}
+
+	if roles, err := ParseRoles(""); err != nil || roles != nil {
+		t.Errorf("expected no roles for an empty value, got %v, %v", roles, err)
+	}
+
+	invalid := map[string]string{
+		"not an object":         `["developer"]`,
+		"invalid name":          `{"Developer": ["view"]}`,
+		"built-in role":         `{"admin": ["view"]}`,
+		"no permission":         `{"developer": []}`,
+		"unknown permission":    `{"developer": ["deploy"]}`,
+		"debug without trigger": `{"developer": ["view", "trigger-debug"]}`,
+	}
+	for name, raw := range invalid {
+		if _, err := ParseRoles(raw); err == nil {
+			t.Errorf("%s: expected %s to be rejected", name, raw)
+		}
+	}
+}
+
+func TestResolveAccess_RoleBindings(t *testing.T) {
+	defaults := AccessDefaults{Roles: map[string][]string{
+		"developer":  {permLogs, permTrigger, permTriggerAll},
+		"viewer":     {},
+		"releaser":   {permCancel},
+		"everything": adminDecision().permissions(),
+	}}
+	job := func(bindings ...api.RenovateJobRoleBinding) *api.RenovateJob {
+		return &api.RenovateJob{
+			ObjectMeta: metav1.ObjectMeta{Name: "job1", Namespace: "default"},
+			Spec: api.RenovateJobSpec{Access: &api.RenovateJobAccess{
+				ReaderGroups: []string{"team-reader"},
+				RoleBindings: bindings,
+			}},
+		}
+	}
+
+	tests := []struct {
+		name            string
+		job             *api.RenovateJob
+		session         *sessionData
+		wantRole        accessRole
+		wantPermissions []string
+	}{
+		{
+			name:            "custom role grants its permissions",
+			job:             job(api.RenovateJobRoleBinding{Role: "developer", Groups: []string{"Team-Dev"}}),
+			session:         &sessionData{Groups: []string{"team-dev"}},
+			wantRole:        roleCustom,
+			wantPermissions: []string{permLogs, permTrigger, permTriggerAll},
+		},
+		{
+			name:            "view-only role makes the job readable without logs",
+			job:             job(api.RenovateJobRoleBinding{Role: "viewer", Users: []string{"me@example.com"}}),
+			session:         &sessionData{Email: "me@example.com", EmailVerified: true},
+			wantRole:        roleReader,
+			wantPermissions: []string{},
+		},
+		{
+			name: "bindings add up",
+			job: job(
+				api.RenovateJobRoleBinding{Role: "viewer", Groups: []string{"team-dev"}},
+				api.RenovateJobRoleBinding{Role: "releaser", Users: []string{"me@example.com"}},
+			),
+			session:         &sessionData{Email: "me@example.com", EmailVerified: true, Groups: []string{"team-dev"}},
+			wantRole:        roleCustom,
+			wantPermissions: []string{permCancel},
+		},
+		{
+			name:            "a binding adds to reader access",
+			job:             job(api.RenovateJobRoleBinding{Role: "releaser", Groups: []string{"team-reader"}}),
+			session:         &sessionData{Groups: []string{"team-reader"}},
+			wantRole:        roleCustom,
+			wantPermissions: []string{permLogs, permCancel},
+		},
+		{
+			name:            "built-in admin role",
+			job:             job(api.RenovateJobRoleBinding{Role: "admin", Groups: []string{"team-dev"}}),
+			session:         &sessionData{Groups: []string{"team-dev"}},
+			wantRole:        roleAdmin,
+			wantPermissions: adminDecision().permissions(),
+		},
+		{
+			name:            "custom role granting every permission is admin",
+			job:             job(api.RenovateJobRoleBinding{Role: "everything", Users: []string{"me@example.com"}}),
+			session:         &sessionData{Email: "me@example.com", EmailVerified: true},
+			wantRole:        roleAdmin,
+			wantPermissions: adminDecision().permissions(),
+		},
+		{
+			name:            "unknown role grants nothing",
+			job:             job(api.RenovateJobRoleBinding{Role: "release-manager", Groups: []string{"team-dev"}}),
+			session:         &sessionData{Groups: []string{"team-dev"}},
+			wantRole:        roleNone,
+			wantPermissions: []string{},
+		},
+		{
+			name:            "binding for someone else",
+			job:             job(api.RenovateJobRoleBinding{Role: "developer", Groups: []string{"team-dev"}}),
+			session:         &sessionData{Groups: []string{"team-other"}},
+			wantRole:        roleNone,
+			wantPermissions: []string{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			decision := resolveAccess(tt.job, tt.session, false, defaults, logr.Discard())
+			if decision.Role != tt.wantRole {
+				t.Errorf("role = %v, want %v", decision.Role, tt.wantRole)
+			}
+			if got := decision.permissions(); !slices.Equal(got, tt.wantPermissions) {
+				t.Errorf("permissions = %v, want %v", got, tt.wantPermissions)
+			}
+		})
+	}
+}
+
+func TestRequirePermission_DebugTriggerNeedsTriggerDebug(t *testing.T) {
+	job := &api.RenovateJob{
+		ObjectMeta: metav1.ObjectMeta{Name: "job1", Namespace: "default"},
+		Spec: api.RenovateJobSpec{Access: &api.RenovateJobAccess{RoleBindings: []api.RenovateJobRoleBinding{
+			{Role: "developer", Groups: []string{"team-dev"}},
+			{Role: "debugger", Groups: []string{"team-debug"}},
+		}}},
+	}
+	server := &Server{
+		manager: &mockRenovateJobManager{
+			getRenovateJobFunc: func(_ context.Context, _, _ string) (*api.RenovateJob, error) {
+				return job, nil
+			},
+		},
+		logger: logr.Discard(),
+		auth:   &OIDCAuth{},
+		accessDefaults: AccessDefaults{Roles: map[string][]string{
+			"developer": {permTrigger, permTriggerAll},
+			"debugger":  {permTrigger, permTriggerAll, permTriggerDebug},
+		}},
+	}
+
+	require := func(group string, options *api.RenovateExecutionOptions) int {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/renovate", nil)
+		req = req.WithContext(context.WithValue(req.Context(), sessionContextKey, &sessionData{Groups: []string{group}}))
+		w := httptest.NewRecorder()
+		if _, ok := server.requirePermission(w, req, "default", "job1", triggerPermissions(permTrigger, options)...); ok {
+			return http.StatusOK
+		}
+		return w.Code
+	}
+
+	if code := require("team-dev", nil); code != http.StatusOK {
+		t.Errorf("expected a plain trigger to be allowed, got %d", code)
+	}
+	if code := require("team-dev", &api.RenovateExecutionOptions{Debug: true}); code != http.StatusForbidden {
+		t.Errorf("expected a debug trigger without trigger-debug to be refused, got %d", code)
+	}
+	if code := require("team-debug", &api.RenovateExecutionOptions{Debug: true}); code != http.StatusOK {
+		t.Errorf("expected a debug trigger with trigger-debug to be allowed, got %d", code)
+	}
+}
+
+func TestApproveRenovateForProject_NeedsApprove(t *testing.T) {
+	job := &api.RenovateJob{
+		ObjectMeta: metav1.ObjectMeta{Name: "job1", Namespace: "default"},
+		Spec: api.RenovateJobSpec{Access: &api.RenovateJobAccess{RoleBindings: []api.RenovateJobRoleBinding{
+			{Role: "developer", Groups: []string{"team-dev"}},
+			{Role: "maintainer", Groups: []string{"team-maintainers"}},
+		}}},
+	}
+	roles, err := ParseRoles(`{"developer": ["view", "trigger", "trigger-debug"], "maintainer": ["view", "manage"]}`)
+	if err != nil {
+		t.Fatalf("ParseRoles returned error: %v", err)
+	}
+	var approved []string
+	server := &Server{
+		manager: &mockRenovateJobManager{
+			getRenovateJobFunc: func(_ context.Context, _, _ string) (*api.RenovateJob, error) {
+				return job, nil
+			},
+			approveProjectsFunc: func(_ context.Context, _ crdmanager.RenovateJobIdentifier, projects []string) error {
+				approved = append(approved, projects...)
+				return nil
+			},
+		},
+		logger:         logr.Discard(),
+		auth:           &OIDCAuth{},
+		accessDefaults: AccessDefaults{Roles: roles},
+	}
+
+	approve := func(group string) int {
+		body := strings.NewReader(`{"namespace": "default", "renovateJob": "job1", "project": "org/new"}`)
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/renovate/approve", body)
+		req.Header.Set("Content-Type", "application/json")
+		req = req.WithContext(context.WithValue(req.Context(), sessionContextKey, &sessionData{Groups: []string{group}}))
+		w := httptest.NewRecorder()
+		server.approveRenovateForProject(w, req)
+		return w.Code
+	}
+
+	if code := approve("team-dev"); code != http.StatusForbidden {
+		t.Errorf("expected a trigger-only role to be refused, got %d", code)
+	}
+	if len(approved) != 0 {
+		t.Errorf("expected nothing to be approved, got %v", approved)
+	}
+	if code := approve("team-maintainers"); code != http.StatusOK {
+		t.Errorf("expected manage to approve, got %d", code)
+	}
+	if !slices.Equal(approved, []string{"org/new"}) {
+		t.Errorf("expected org/new to be approved, got %v", approved)
+	}
+}
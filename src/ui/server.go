@@ -4,12 +4,16 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"time"
 
 	"renovate-operator/assert"
 	"renovate-operator/config"
 	"renovate-operator/health"
+	"renovate-operator/internal/apiTokens"
+	"renovate-operator/internal/audit"
 	crdmanager "renovate-operator/internal/crdManager"
 	"renovate-operator/internal/renovate"
+	"renovate-operator/internal/reports"
 	"renovate-operator/internal/telemetry"
 	"renovate-operator/scheduler"
 
@@ -28,7 +32,24 @@ type Server struct {
 	auth           AuthProvider
 	accessDefaults AccessDefaults
 	accessCheck    accessCheckCache
-	Router         *mux.Router
+	// deliveries serves the webhook delivery log; nil when it is disabled
+	deliveries WebhookDeliveries
+	// reports serves the digest reports; nil when the run history is disabled
+	reports reports.History
+	// statusEvents feeds /api/v1/events; nil until SetStatusInformer is called
+	statusEvents *statusBroker
+	// editing enables creating, editing and deleting jobs; nil when disabled
+	editing *JobEditing
+	// tokens keeps the API tokens; nil when they are disabled
+	tokens           apiTokens.Store
+	tokenMaxLifetime time.Duration
+	// rbac decides access with SubjectAccessReviews; nil when the access
+	// rules decide
+	rbac *RBACAuthorizer
+	// audit records the actions taken through the API; nil when the audit
+	// log is disabled
+	audit  audit.Sink
+	Router *mux.Router
 }
 
 func NewServer(manager crdmanager.RenovateJobManager, discovery renovate.DiscoveryAgent, scheduler scheduler.Scheduler, logger logr.Logger, health health.HealthCheck, version string, auth AuthProvider, accessDefaults AccessDefaults) *Server {
@@ -45,6 +66,16 @@ func NewServer(manager crdmanager.RenovateJobManager, discovery renovate.Discove
 	}
 }
 
+// SetWebhookDeliveries enables the webhook delivery log endpoints and page.
+func (s *Server) SetWebhookDeliveries(deliveries WebhookDeliveries) {
+	s.deliveries = deliveries
+}
+
+// SetReports enables the report endpoint, reporting from history.
+func (s *Server) SetReports(history reports.History) {
+	s.reports = history
+}
+
 func (s *Server) registerAuthRoutes(router *mux.Router) {
 	if s.auth != nil {
 		sub := router.PathPrefix("/auth").Subrouter()
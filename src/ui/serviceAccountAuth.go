@@ -0,0 +1,133 @@
+package ui
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	authenticationv1 "k8s.io/api/authentication/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	authenticationv1client "k8s.io/client-go/kubernetes/typed/authentication/v1"
+)
+
+// serviceAccountUsernamePrefix starts the username the API server reports for
+// a ServiceAccount token: system:serviceaccount:<namespace>:<name>.
+const serviceAccountUsernamePrefix = "system:serviceaccount:"
+
+// serviceAccountReviewTTL is how long an accepted token is trusted without
+// asking the API server again. A deleted ServiceAccount keeps access for at
+// most this long.
+const serviceAccountReviewTTL = time.Minute
+
+// errServiceAccountRejected is returned for a token the API server does not
+// accept, or accepts for something other than a ServiceAccount.
+var errServiceAccountRejected = errors.New("not an accepted ServiceAccount token")
+
+// ServiceAccountAuth authenticates API requests carrying a Kubernetes
+// ServiceAccount token, validated with the TokenReview API. The ServiceAccount
+// becomes the session's username, so access rules name it in readerUsers and
+// adminUsers as system:serviceaccount:<namespace>:<name>.
+type ServiceAccountAuth struct {
+	reviews authenticationv1client.TokenReviewInterface
+	// audiences the token must be issued for; empty accepts the API server's
+	// own audiences
+	audiences []string
+	// groups maps the groups of the ServiceAccount, such as
+	// system:serviceaccounts:<namespace>, into the session
+	groups bool
+
+	mu sync.Mutex
+	// accepted holds the sessions of recently reviewed tokens by token hash
+	accepted map[string]acceptedServiceAccount
+}
+
+type acceptedServiceAccount struct {
+	session sessionData
+	until   time.Time
+}
+
+// isServiceAccountRequest reports whether the request authenticated with a
+// ServiceAccount token.
+func isServiceAccountRequest(r *http.Request) bool {
+	serviceAccount, _ := r.Context().Value(serviceAccountContextKey).(bool)
+	return serviceAccount
+}
+
+// SetServiceAccountAuth makes the auth provider accept Kubernetes
+// ServiceAccount tokens as Bearer credentials on the API.
+func (s *Server) SetServiceAccountAuth(auth *ServiceAccountAuth) {
+	if provider, ok := s.auth.(interface{ setServiceAccountAuth(*ServiceAccountAuth) }); ok {
+		provider.setServiceAccountAuth(auth)
+	}
+}
+
+func NewServiceAccountAuth(reviews authenticationv1client.TokenReviewInterface, audiences []string, groups bool) *ServiceAccountAuth {
+	return &ServiceAccountAuth{
+		reviews:   reviews,
+		audiences: audiences,
+		groups:    groups,
+		accepted:  make(map[string]acceptedServiceAccount),
+	}
+}
+
+// authenticate returns the session of a ServiceAccount token, or
+// errServiceAccountRejected. Failing to reach the API server is returned as
+// it is.
+func (a *ServiceAccountAuth) authenticate(ctx context.Context, token string) (*sessionData, error) {
+	sum := sha256.Sum256([]byte(token))
+	key := hex.EncodeToString(sum[:])
+	now := time.Now()
+
+	a.mu.Lock()
+	cached, ok := a.accepted[key]
+	a.mu.Unlock()
+	if ok && now.Before(cached.until) {
+		return &cached.session, nil
+	}
+
+	review, err := a.reviews.Create(ctx, &authenticationv1.TokenReview{
+		Spec: authenticationv1.TokenReviewSpec{Token: token, Audiences: a.audiences},
+	}, metav1.CreateOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("token review failed: %w", err)
+	}
+	status := review.Status
+	if !status.Authenticated {
+		return nil, fmt.Errorf("%w: %s", errServiceAccountRejected, status.Error)
+	}
+	if len(a.audiences) > 0 && !hasIntersection(status.Audiences, a.audiences) {
+		return nil, fmt.Errorf("%w: issued for %v", errServiceAccountRejected, status.Audiences)
+	}
+	account, isServiceAccount := strings.CutPrefix(status.User.Username, serviceAccountUsernamePrefix)
+	if !isServiceAccount {
+		return nil, fmt.Errorf("%w: %s is not a ServiceAccount", errServiceAccountRejected, status.User.Username)
+	}
+
+	until := now.Add(serviceAccountReviewTTL)
+	session := sessionData{
+		Version:  currentSessionVersion,
+		Name:     strings.Replace(account, ":", "/", 1),
+		Username: status.User.Username,
+		Expiry:   until.Unix(),
+		Groups:   []string{},
+	}
+	if a.groups {
+		session.Groups = status.User.Groups
+	}
+
+	a.mu.Lock()
+	for k, entry := range a.accepted {
+		if !now.Before(entry.until) {
+			delete(a.accepted, k)
+		}
+	}
+	a.accepted[key] = acceptedServiceAccount{session: session, until: until}
+	a.mu.Unlock()
+	return &session, nil
+}
@@ -0,0 +1,229 @@
+package ui
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sync/atomic"
+	"testing"
+
+	api "renovate-operator/api/v1alpha1"
+	"renovate-operator/config"
+
+	"github.com/go-logr/logr"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	authenticationv1client "k8s.io/client-go/kubernetes/typed/authentication/v1"
+	"k8s.io/client-go/rest"
+)
+
+// fakeTokenReviews is the client of an API server answering TokenReviews with the
+// status registered for the token, and unauthenticated for any other token.
+// The counter reports the number of reviews it answered.
+func fakeTokenReviews(t *testing.T, statuses map[string]authenticationv1.TokenReviewStatus) (authenticationv1client.TokenReviewInterface, *atomic.Int32) {
+	t.Helper()
+	var reviews atomic.Int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/apis/authentication.k8s.io/v1/tokenreviews" {
+			http.NotFound(w, r)
+			return
+		}
+		reviews.Add(1)
+		var review authenticationv1.TokenReview
+		if err := json.NewDecoder(r.Body).Decode(&review); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		status, ok := statuses[review.Spec.Token]
+		if !ok {
+			status = authenticationv1.TokenReviewStatus{Error: "invalid bearer token"}
+		}
+		review.Status = status
+		review.APIVersion, review.Kind = "authentication.k8s.io/v1", "TokenReview"
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(review)
+	}))
+	t.Cleanup(ts.Close)
+
+	clientset, err := kubernetes.NewForConfig(&rest.Config{Host: ts.URL, ContentConfig: rest.ContentConfig{ContentType: "application/json"}})
+	if err != nil {
+		t.Fatalf("failed to create clientset: %v", err)
+	}
+	return clientset.AuthenticationV1().TokenReviews(), &reviews
+}
+
+func serviceAccountStatus(username string, audiences []string, groups ...string) authenticationv1.TokenReviewStatus {
+	return authenticationv1.TokenReviewStatus{
+		Authenticated: true,
+		Audiences:     audiences,
+		User:          authenticationv1.UserInfo{Username: username, Groups: groups},
+	}
+}
+
+func TestServiceAccountAuth_Authenticate(t *testing.T) {
+	statuses := map[string]authenticationv1.TokenReviewStatus{
+		"ci-token":     serviceAccountStatus("system:serviceaccount:ci:deployer", []string{"renovate-operator"}, "system:serviceaccounts", "system:serviceaccounts:ci"),
+		"user-token":   serviceAccountStatus("alice", []string{"renovate-operator"}),
+		"other-token":  serviceAccountStatus("system:serviceaccount:ci:deployer", []string{"https://kubernetes.default.svc"}),
+		"no-audiences": serviceAccountStatus("system:serviceaccount:ci:deployer", nil),
+	}
+	tokenReviews, reviews := fakeTokenReviews(t, statuses)
+	auth := NewServiceAccountAuth(tokenReviews, []string{"renovate-operator"}, false)
+	ctx := context.Background()
+
+	session, err := auth.authenticate(ctx, "ci-token")
+	if err != nil {
+		t.Fatalf("expected the ServiceAccount token to be accepted, got %v", err)
+	}
+	if session.Username != "system:serviceaccount:ci:deployer" || session.Name != "ci/deployer" || session.Email != "" {
+		t.Errorf("expected the ServiceAccount as the session, got %+v", session)
+	}
+	if len(session.Groups) != 0 {
+		t.Errorf("expected no groups unless enabled, got %v", session.Groups)
+	}
+	if _, err := auth.authenticate(ctx, "ci-token"); err != nil || reviews.Load() != 1 {
+		t.Errorf("expected an accepted token to be cached, got %d reviews, %v", reviews.Load(), err)
+	}
+
+	for _, token := range []string{"unknown-token", "user-token", "other-token", "no-audiences"} {
+		if _, err := auth.authenticate(ctx, token); !errors.Is(err, errServiceAccountRejected) {
+			t.Errorf("%s: expected errServiceAccountRejected, got %v", token, err)
+		}
+	}
+	if _, err := auth.authenticate(ctx, "unknown-token"); err == nil || reviews.Load() != 6 {
+		t.Errorf("expected rejected tokens to be reviewed again, got %d reviews, %v", reviews.Load(), err)
+	}
+
+	withGroups := NewServiceAccountAuth(tokenReviews, []string{"renovate-operator"}, true)
+	session, err = withGroups.authenticate(ctx, "ci-token")
+	if err != nil || !slices.Equal(session.Groups, []string{"system:serviceaccounts", "system:serviceaccounts:ci"}) {
+		t.Errorf("expected the ServiceAccount's groups, got %+v, %v", session, err)
+	}
+
+	anyAudience := NewServiceAccountAuth(tokenReviews, nil, false)
+	if _, err := anyAudience.authenticate(ctx, "no-audiences"); err != nil {
+		t.Errorf("expected any audience to be accepted when none is configured, got %v", err)
+	}
+}
+
+func TestServiceAccountAuth_UnreachableAPIServer(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	}))
+	defer ts.Close()
+	clientset, err := kubernetes.NewForConfig(&rest.Config{Host: ts.URL})
+	if err != nil {
+		t.Fatalf("failed to create clientset: %v", err)
+	}
+	auth := NewServiceAccountAuth(clientset.AuthenticationV1().TokenReviews(), nil, false)
+	if _, err := auth.authenticate(context.Background(), "ci-token"); err == nil || errors.Is(err, errServiceAccountRejected) {
+		t.Errorf("expected a failed review to be reported as an error, got %v", err)
+	}
+}
+
+func TestAuthMiddleware_ServiceAccounts(t *testing.T) {
+	defs := []config.ConfigItemDescription{
+		{Key: "WEBHOOK_SERVER_UNIFIED_HOST", Optional: true, Default: "false"},
+	}
+	if err := config.InitializeConfigModule(defs); err != nil {
+		t.Fatalf("failed to initialize config module: %v", err)
+	}
+
+	base, err := newBaseAuth(testEncryptionKey(t), logr.Discard(), NewMemorySessionStore())
+	if err != nil {
+		t.Fatalf("Failed to create baseAuth: %v", err)
+	}
+	tokenReviews, _ := fakeTokenReviews(t, map[string]authenticationv1.TokenReviewStatus{
+		"ci-token": serviceAccountStatus("system:serviceaccount:ci:deployer", []string{"renovate-operator"}),
+	})
+	base.setServiceAccountAuth(NewServiceAccountAuth(tokenReviews, []string{"renovate-operator"}, false))
+	base.setAPITokens(newTokenStore(t))
+
+	job := &api.RenovateJob{
+		ObjectMeta: metav1.ObjectMeta{Name: "job1", Namespace: "default"},
+		Spec: api.RenovateJobSpec{Access: &api.RenovateJobAccess{
+			ReaderUsers: []string{"system:serviceaccount:ci:deployer"},
+		}},
+	}
+
+	tests := []struct {
+		name          string
+		path          string
+		authorization string
+		wantHandler   bool
+		wantStatus    int
+	}{
+		{"ServiceAccount token authenticates the API", "/api/v1/renovate", "Bearer ci-token", true, http.StatusOK},
+		{"unknown token is rejected", "/api/v1/renovate", "Bearer forged", false, http.StatusUnauthorized},
+		{"API tokens are not reviewed", "/api/v1/renovate", "Bearer rop_unknown_secret", false, http.StatusUnauthorized},
+		{"ServiceAccount token does not open the UI", "/some-page", "Bearer ci-token", false, http.StatusFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reached := false
+			middleware := base.authMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				reached = true
+				if !isServiceAccountRequest(r) || getTokenFromContext(r) != nil {
+					t.Error("expected the request to be marked as authenticated by a ServiceAccount")
+				}
+				decision := resolveAccess(job, getSessionFromContext(r), isServiceAccountRequest(r), AccessDefaults{}, logr.Discard())
+				if decision.Role != roleReader {
+					t.Errorf("expected readerUsers to match the ServiceAccount, got %+v", decision)
+				}
+				w.WriteHeader(http.StatusOK)
+			}))
+
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			req.Header.Set("Authorization", tt.authorization)
+			w := httptest.NewRecorder()
+			middleware.ServeHTTP(w, req)
+
+			if reached != tt.wantHandler {
+				t.Errorf("handler reached = %v, want %v", reached, tt.wantHandler)
+			}
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestAPITokenEndpoints_RejectServiceAccounts(t *testing.T) {
+	server := tokenServer(t)
+	ctx := context.WithValue(context.Background(), sessionContextKey, &sessionData{Username: "system:serviceaccount:ci:deployer"})
+	ctx = context.WithValue(ctx, serviceAccountContextKey, true)
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/tokens", nil).WithContext(ctx)
+	w := httptest.NewRecorder()
+	server.Router.ServeHTTP(w, req)
+	if w.Code != http.StatusForbidden {
+		t.Errorf("expected a ServiceAccount to be refused API tokens, got %d", w.Code)
+	}
+}
+
+// A login whose username looks like a ServiceAccount must not pick up the
+// access granted to that ServiceAccount.
+func TestDecideJobAccess_ServiceAccountNameNeedsServiceAccountToken(t *testing.T) {
+	job := &api.RenovateJob{
+		ObjectMeta: metav1.ObjectMeta{Name: "job1", Namespace: "default"},
+		Spec: api.RenovateJobSpec{Access: &api.RenovateJobAccess{
+			AdminUsers: []string{"system:serviceaccount:ci:deployer"},
+		}},
+	}
+	server := &Server{logger: logr.Discard(), auth: &OIDCAuth{}}
+	session := &sessionData{Username: "System:ServiceAccount:ci:deployer", Email: "system:serviceaccount:ci:deployer", EmailVerified: true}
+
+	ctx := context.WithValue(context.Background(), sessionContextKey, session)
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/renovate", nil).WithContext(ctx)
+	if decision := server.decideJobAccess(req, job); decision.canRead() {
+		t.Errorf("expected a login named like a ServiceAccount to get no access, got %+v", decision)
+	}
+
+	req = req.WithContext(context.WithValue(ctx, serviceAccountContextKey, true))
+	if decision := server.decideJobAccess(req, job); decision.Role != roleAdmin {
+		t.Errorf("expected the ServiceAccount to be admin, got %+v", decision)
+	}
+}
@@ -0,0 +1,306 @@
+package ui
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	api "renovate-operator/api/v1alpha1"
+	crdmanager "renovate-operator/internal/crdManager"
+
+	"github.com/go-logr/logr"
+	"k8s.io/apimachinery/pkg/api/equality"
+	toolscache "k8s.io/client-go/tools/cache"
+	crcache "sigs.k8s.io/controller-runtime/pkg/cache"
+)
+
+// Types of the events streamed on /api/v1/events. A client that receives
+// statusEventReset, or an event for a job it does not know, reloads
+// /api/v1/renovatejobs.
+const (
+	statusEventProject        = "project"
+	statusEventProjectRemoved = "projectRemoved"
+	statusEventJobAdded       = "renovateJobAdded"
+	statusEventJobRemoved     = "renovateJobRemoved"
+	statusEventReset          = "reset"
+)
+
+const (
+	// statusEventBuffer is how many events are kept for clients resuming with
+	// Last-Event-ID. A client further behind is told to reload instead.
+	statusEventBuffer = 1024
+	// statusStreamMaxAge ends every stream after a while. The browser
+	// reconnects with Last-Event-ID, which passes the auth middleware again, so
+	// a logout or an expired session does not keep a stream open for good.
+	statusStreamMaxAge = 5 * time.Minute
+	// statusStreamKeepalive keeps idle streams from being closed by proxies.
+	statusStreamKeepalive = 25 * time.Second
+)
+
+// statusEventData is the payload of an event: the job it is about and, for
+// project events, the project.
+type statusEventData struct {
+	Namespace   string                            `json:"namespace"`
+	RenovateJob string                            `json:"renovateJob"`
+	Project     *crdmanager.RenovateProjectStatus `json:"project,omitempty"`
+}
+
+type statusEvent struct {
+	seq       uint64
+	eventType string
+	data      []byte
+	// job holds the access configuration of the job at the time of the event,
+	// which every subscriber is checked against.
+	job *api.RenovateJob
+}
+
+// statusBroker keeps the most recent status events in a ring buffer and wakes
+// the streams when a new one arrives. Sequence numbers start at 1 and are only
+// meaningful together with the epoch, which changes with every start of the
+// operator and differs between replicas.
+type statusBroker struct {
+	logger logr.Logger
+	epoch  string
+
+	mu     sync.RWMutex
+	events []statusEvent
+	// next is the sequence number of the next event
+	next uint64
+	// changed is closed and replaced whenever an event is published
+	changed chan struct{}
+}
+
+func newStatusBroker(logger logr.Logger, size int) *statusBroker {
+	return &statusBroker{
+		logger:  logger,
+		epoch:   rand.Text()[:8],
+		events:  make([]statusEvent, size),
+		next:    1,
+		changed: make(chan struct{}),
+	}
+}
+
+func (b *statusBroker) publish(eventType string, job *api.RenovateJob, project *crdmanager.RenovateProjectStatus) {
+	data, err := json.Marshal(statusEventData{Namespace: job.Namespace, RenovateJob: job.Name, Project: project})
+	if err != nil {
+		b.logger.Error(err, "failed to encode status event", "type", eventType, "renovateJob", job.Name, "namespace", job.Namespace)
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.events[b.next%uint64(len(b.events))] = statusEvent{seq: b.next, eventType: eventType, data: data, job: accessSubset(job)}
+	b.next++
+	close(b.changed)
+	b.changed = make(chan struct{})
+}
+
+// read returns the events after the sequence number after, and a channel
+// closed by the next publish. ok is false when some of them are no longer
+// buffered, in which case head is the sequence number to continue after.
+func (b *statusBroker) read(after uint64) (events []statusEvent, head uint64, ok bool, changed <-chan struct{}) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	head = b.next - 1
+	oldest := uint64(1)
+	if b.next > uint64(len(b.events)) {
+		oldest = b.next - uint64(len(b.events))
+	}
+	if after > head || after+1 < oldest {
+		return nil, head, false, b.changed
+	}
+	for seq := after + 1; seq <= head; seq++ {
+		events = append(events, b.events[seq%uint64(len(b.events))])
+	}
+	return events, head, true, b.changed
+}
+
+func (b *statusBroker) eventID(seq uint64) string {
+	return fmt.Sprintf("%s-%d", b.epoch, seq)
+}
+
+// resumePoint parses a Last-Event-ID. ok is false when it was issued by
+// another start or replica of the operator.
+func (b *statusBroker) resumePoint(lastEventID string) (after uint64, ok bool) {
+	epoch, seq, found := strings.Cut(lastEventID, "-")
+	if !found || epoch != b.epoch {
+		return 0, false
+	}
+	after, err := strconv.ParseUint(seq, 10, 64)
+	return after, err == nil
+}
+
+// accessSubset copies what resolveAccess reads from a job, so the buffer does
+// not hold on to the statuses of every project.
+func accessSubset(job *api.RenovateJob) *api.RenovateJob {
+	subset := &api.RenovateJob{}
+	subset.Name = job.Name
+	subset.Namespace = job.Namespace
+	subset.Spec.Access = job.Spec.Access
+	subset.Spec.AllowedGroups = job.Spec.AllowedGroups //nolint:staticcheck // deprecated field is intentionally still honoured
+	return subset
+}
+
+// handler turns the informer's notifications into status events. The initial
+// list of the informer is not an event: clients load it from
+// /api/v1/renovatejobs.
+func (b *statusBroker) handler() toolscache.ResourceEventHandler {
+	return toolscache.ResourceEventHandlerDetailedFuncs{
+		AddFunc: func(obj any, isInInitialList bool) {
+			if job, ok := obj.(*api.RenovateJob); ok && !isInInitialList {
+				b.publish(statusEventJobAdded, job, nil)
+			}
+		},
+		UpdateFunc: func(oldObj, newObj any) {
+			previous, ok := oldObj.(*api.RenovateJob)
+			if !ok {
+				return
+			}
+			if job, ok := newObj.(*api.RenovateJob); ok {
+				b.publishProjectChanges(previous, job)
+			}
+		},
+		DeleteFunc: func(obj any) {
+			if tombstone, ok := obj.(toolscache.DeletedFinalStateUnknown); ok {
+				obj = tombstone.Obj
+			}
+			if job, ok := obj.(*api.RenovateJob); ok {
+				b.publish(statusEventJobRemoved, job, nil)
+			}
+		},
+	}
+}
+
+func (b *statusBroker) publishProjectChanges(previous, job *api.RenovateJob) {
+	before := make(map[string]*api.ProjectStatus, len(previous.Status.Projects))
+	for i := range previous.Status.Projects {
+		before[previous.Status.Projects[i].Name] = &previous.Status.Projects[i]
+	}
+	current := make(map[string]bool, len(job.Status.Projects))
+	for i := range job.Status.Projects {
+		project := &job.Status.Projects[i]
+		current[project.Name] = true
+		if old, existed := before[project.Name]; existed && equality.Semantic.DeepEqual(old, project) {
+			continue
+		}
+		info := projectInfo(project)
+		b.publish(statusEventProject, job, &info)
+	}
+	for _, project := range previous.Status.Projects {
+		if !current[project.Name] {
+			b.publish(statusEventProjectRemoved, job, &crdmanager.RenovateProjectStatus{Name: project.Name})
+		}
+	}
+}
+
+// SetStatusInformer enables the /api/v1/events stream, fed by an informer on
+// RenovateJobs.
+func (s *Server) SetStatusInformer(informer crcache.Informer) error {
+	broker := newStatusBroker(s.logger.WithName("status-events"), statusEventBuffer)
+	if _, err := informer.AddEventHandler(broker.handler()); err != nil {
+		return err
+	}
+	s.statusEvents = broker
+	return nil
+}
+
+// streamEvents pushes the status changes of the jobs the request can read as
+// Server-Sent Events, resuming after the Last-Event-ID the browser sends when
+// it reconnects.
+func (s *Server) streamEvents(w http.ResponseWriter, r *http.Request) {
+	if s.statusEvents == nil {
+		http.Error(w, "not found", http.StatusNotFound)
+		return
+	}
+	broker := s.statusEvents
+	ctx := r.Context()
+
+	lastEventID := r.Header.Get("Last-Event-ID")
+	after, resumed := broker.resumePoint(lastEventID)
+	if !resumed {
+		_, after, _, _ = broker.read(0)
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	rc := http.NewResponseController(w)
+
+	write := func(id, eventType string, data []byte) bool {
+		if _, err := fmt.Fprintf(w, "id: %s\nevent: %s\ndata: %s\n\n", id, eventType, data); err != nil {
+			return false
+		}
+		return true
+	}
+
+	// A client resuming from another replica or from before a restart may
+	// have missed anything.
+	if lastEventID != "" && !resumed && !write(broker.eventID(after), statusEventReset, []byte("{}")) {
+		return
+	}
+	if err := rc.Flush(); err != nil {
+		return
+	}
+
+	maxAge := time.NewTimer(s.statusStreamDuration(r))
+	defer maxAge.Stop()
+	keepalive := time.NewTicker(statusStreamKeepalive)
+	defer keepalive.Stop()
+
+	for {
+		events, head, ok, changed := broker.read(after)
+		if !ok {
+			if !write(broker.eventID(head), statusEventReset, []byte("{}")) {
+				return
+			}
+			after = head
+		}
+		if len(events) > 0 {
+			readable := s.accessEnforceable(ctx) == nil
+			for _, event := range events {
+				after = event.seq
+				if !readable || !s.decideJobAccess(r, event.job).canRead() {
+					continue
+				}
+				if !write(broker.eventID(event.seq), event.eventType, event.data) {
+					return
+				}
+			}
+		}
+		if err := rc.Flush(); err != nil {
+			return
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-maxAge.C:
+			return
+		case <-keepalive.C:
+			if _, err := fmt.Fprint(w, ": keepalive\n\n"); err != nil {
+				return
+			}
+		case <-changed:
+		}
+	}
+}
+
+// statusStreamDuration is how long a stream may stay open: statusStreamMaxAge,
+// or less when the session expires earlier.
+func (s *Server) statusStreamDuration(r *http.Request) time.Duration {
+	duration := statusStreamMaxAge
+	if session := getSessionFromContext(r); session != nil && session.Expiry > 0 {
+		if untilExpiry := time.Until(time.Unix(session.Expiry, 0)); untilExpiry < duration {
+			duration = max(untilExpiry, 0)
+		}
+	}
+	return duration
+}
@@ -0,0 +1,209 @@
+package ui
+
+import (
+	"bufio"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	api "renovate-operator/api/v1alpha1"
+
+	"github.com/go-logr/logr"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	toolscache "k8s.io/client-go/tools/cache"
+)
+
+func statusJob(name, readerGroup string, projects ...api.ProjectStatus) *api.RenovateJob {
+	return &api.RenovateJob{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "renovate"},
+		Spec:       api.RenovateJobSpec{Access: &api.RenovateJobAccess{ReaderGroups: []string{readerGroup}}},
+		Status:     api.RenovateJobStatus{Projects: projects},
+	}
+}
+
+// drain returns the types and payloads of the buffered events after after.
+func drain(t *testing.T, broker *statusBroker, after uint64) []string {
+	t.Helper()
+	events, _, ok, _ := broker.read(after)
+	if !ok {
+		t.Fatalf("expected the events after %d to be buffered", after)
+	}
+	got := make([]string, 0, len(events))
+	for _, event := range events {
+		got = append(got, event.eventType+" "+string(event.data))
+	}
+	return got
+}
+
+func TestStatusBroker_Handler(t *testing.T) {
+	broker := newStatusBroker(logr.Discard(), 16)
+	handler := broker.handler()
+
+	before := statusJob("job1", "team-a",
+		api.ProjectStatus{Name: "org/a", Status: api.JobStatusScheduled},
+		api.ProjectStatus{Name: "org/b", Status: api.JobStatusCompleted},
+		api.ProjectStatus{Name: "org/c", Status: api.JobStatusCompleted},
+	)
+	after := statusJob("job1", "team-a",
+		api.ProjectStatus{Name: "org/a", Status: api.JobStatusRunning},
+		api.ProjectStatus{Name: "org/b", Status: api.JobStatusCompleted},
+		api.ProjectStatus{Name: "org/d", Status: api.JobStatusScheduled},
+	)
+
+	handler.OnAdd(before, true)
+	if got := drain(t, broker, 0); len(got) != 0 {
+		t.Fatalf("expected the initial list not to be streamed, got %v", got)
+	}
+
+	handler.OnUpdate(before, after)
+	handler.OnAdd(statusJob("job2", "team-b"), false)
+	handler.OnDelete(toolscache.DeletedFinalStateUnknown{Key: "renovate/job2", Obj: statusJob("job2", "team-b")})
+
+	got := drain(t, broker, 0)
+	want := []string{
+		`project {"namespace":"renovate","renovateJob":"job1","project":{"name":"org/a","status":"running"`,
+		`project {"namespace":"renovate","renovateJob":"job1","project":{"name":"org/d","status":"scheduled"`,
+		`projectRemoved {"namespace":"renovate","renovateJob":"job1","project":{"name":"org/c"`,
+		`renovateJobAdded {"namespace":"renovate","renovateJob":"job2"}`,
+		`renovateJobRemoved {"namespace":"renovate","renovateJob":"job2"}`,
+	}
+	if len(got) != len(want) {
+		t.Fatalf("expected %d events, got %d: %v", len(want), len(got), got)
+	}
+	for i := range want {
+		if !strings.HasPrefix(got[i], want[i]) {
+			t.Errorf("expected event %d to start with %s, got %s", i, want[i], got[i])
+		}
+	}
+}
+
+func TestStatusBroker_Read(t *testing.T) {
+	broker := newStatusBroker(logr.Discard(), 3)
+	for range 5 {
+		broker.publish(statusEventJobAdded, statusJob("job1", "team-a"), nil)
+	}
+
+	if _, head, ok, _ := broker.read(1); ok || head != 5 {
+		t.Errorf("expected events dropped from the buffer to be reported with the head 5, got ok=%v head=%d", ok, head)
+	}
+	if events, _, ok, _ := broker.read(2); !ok || len(events) != 3 || events[0].seq != 3 {
+		t.Errorf("expected the events 3 to 5, got ok=%v %+v", ok, events)
+	}
+	if events, _, ok, _ := broker.read(5); !ok || len(events) != 0 {
+		t.Errorf("expected no events after the head, got ok=%v %+v", ok, events)
+	}
+	if _, _, ok, _ := broker.read(6); ok {
+		t.Error("expected an id ahead of the head to be rejected")
+	}
+
+	if after, ok := broker.resumePoint(broker.eventID(4)); !ok || after != 4 {
+		t.Errorf("expected to resume after 4, got %d, %v", after, ok)
+	}
+	for _, id := range []string{"", "4", "other-4", broker.epoch + "-x"} {
+		if _, ok := broker.resumePoint(id); ok {
+			t.Errorf("expected %q not to be a resume point", id)
+		}
+	}
+}
+
+type sseEvent struct {
+	id, eventType, data string
+}
+
+func readSSE(t *testing.T, r *bufio.Reader) sseEvent {
+	t.Helper()
+	var event sseEvent
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			t.Fatalf("stream ended before the next event: %v", err)
+		}
+		line = strings.TrimRight(line, "\n")
+		switch {
+		case line == "":
+			if event.eventType != "" {
+				return event
+			}
+		case strings.HasPrefix(line, "id: "):
+			event.id = strings.TrimPrefix(line, "id: ")
+		case strings.HasPrefix(line, "event: "):
+			event.eventType = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			event.data = strings.TrimPrefix(line, "data: ")
+		}
+	}
+}
+
+func TestStreamEvents(t *testing.T) {
+	broker := newStatusBroker(logr.Discard(), 16)
+	server := &Server{logger: logr.Discard(), auth: &OIDCAuth{}, statusEvents: broker}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		session := &sessionData{Email: "someone@example.com", Groups: []string{"team-a"}, Expiry: time.Now().Add(time.Hour).Unix()}
+		server.streamEvents(w, r.WithContext(context.WithValue(r.Context(), sessionContextKey, session)))
+	}))
+	t.Cleanup(ts.Close)
+
+	open := func(t *testing.T, lastEventID string) *bufio.Reader {
+		t.Helper()
+		ctx, cancel := context.WithCancel(context.Background())
+		t.Cleanup(cancel)
+		req, _ := http.NewRequestWithContext(ctx, http.MethodGet, ts.URL, nil)
+		if lastEventID != "" {
+			req.Header.Set("Last-Event-ID", lastEventID)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("failed to open the stream: %v", err)
+		}
+		t.Cleanup(func() { _ = resp.Body.Close() })
+		if resp.StatusCode != http.StatusOK || resp.Header.Get("Content-Type") != "text/event-stream" {
+			t.Fatalf("expected an event stream, got %d %q", resp.StatusCode, resp.Header.Get("Content-Type"))
+		}
+		return bufio.NewReader(resp.Body)
+	}
+
+	readable := statusJob("job1", "team-a")
+	hidden := statusJob("job2", "team-b")
+	broker.publish(statusEventJobAdded, hidden, nil)
+	broker.publish(statusEventJobAdded, readable, nil)
+
+	t.Run("streams the changes of readable jobs", func(t *testing.T) {
+		stream := open(t, "")
+		broker.publish(statusEventJobRemoved, hidden, nil)
+		broker.publish(statusEventJobRemoved, readable, nil)
+
+		event := readSSE(t, stream)
+		if event.eventType != statusEventJobRemoved || event.id != broker.eventID(4) || !strings.Contains(event.data, `"job1"`) {
+			t.Errorf("expected only the removal of job1, got %+v", event)
+		}
+	})
+
+	t.Run("resumes after the last event id", func(t *testing.T) {
+		stream := open(t, broker.eventID(1))
+		event := readSSE(t, stream)
+		if event.eventType != statusEventJobAdded || event.id != broker.eventID(2) {
+			t.Errorf("expected the addition of job1 to be replayed first, got %+v", event)
+		}
+		if event = readSSE(t, stream); event.id != broker.eventID(4) {
+			t.Errorf("expected the removal of job1 next, got %+v", event)
+		}
+	})
+
+	t.Run("resets a client of another replica", func(t *testing.T) {
+		stream := open(t, "elsewhere-12")
+		if event := readSSE(t, stream); event.eventType != statusEventReset || event.id != broker.eventID(4) {
+			t.Errorf("expected a reset continuing after the head, got %+v", event)
+		}
+	})
+
+	t.Run("not found without informer", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		(&Server{logger: logr.Discard()}).streamEvents(w, httptest.NewRequest(http.MethodGet, "/api/v1/events", nil))
+		if w.Code != http.StatusNotFound {
+			t.Errorf("expected 404 without an informer, got %d", w.Code)
+		}
+	})
+}
@@ -13,6 +13,12 @@ func (s *Server) registerUiRoutes(router *mux.Router) {
 	router.HandleFunc("/logs", func(w http.ResponseWriter, r *http.Request) {
 		s.serveHTML(w, r, "./static/pages/logs.html")
 	}).Methods("GET")
+	router.HandleFunc("/webhook-deliveries", func(w http.ResponseWriter, r *http.Request) {
+		s.serveHTML(w, r, "./static/pages/webhook-deliveries.html")
+	}).Methods("GET")
+	router.HandleFunc("/api-tokens", func(w http.ResponseWriter, r *http.Request) {
+		s.serveHTML(w, r, "./static/pages/api-tokens.html")
+	}).Methods("GET")
 
 	fileServer := http.FileServer(http.Dir("./static/"))
 	base := BasePath()
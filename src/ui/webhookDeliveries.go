@@ -0,0 +1,94 @@
+package ui
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	crdmanager "renovate-operator/internal/crdManager"
+	"renovate-operator/internal/deliveryLog"
+)
+
+// WebhookDeliveries is the webhook server's delivery log, as browsed and
+// replayed from the UI.
+type WebhookDeliveries interface {
+	ListDeliveries(ctx context.Context, job crdmanager.RenovateJobIdentifier) ([]deliveryLog.Delivery, error)
+	ReplayDelivery(ctx context.Context, job crdmanager.RenovateJobIdentifier, id string) (*deliveryLog.Delivery, error)
+}
+
+// getWebhookDeliveries lists the recorded webhook deliveries of a job, newest
+// first.
+func (s *Server) getWebhookDeliveries(w http.ResponseWriter, r *http.Request) {
+	if s.deliveries == nil {
+		http.Error(w, "not found", http.StatusNotFound)
+		return
+	}
+
+	namespace := r.URL.Query().Get("namespace")
+	renovate := r.URL.Query().Get("renovate")
+	if _, ok := s.requirePermission(w, r, namespace, renovate, permWebhookDeliveries); !ok {
+		return
+	}
+
+	deliveries, err := s.deliveries.ListDeliveries(r.Context(), crdmanager.RenovateJobIdentifier{Name: renovate, Namespace: namespace})
+	if err != nil {
+		internalServerError(w, err, "failed to load webhook deliveries")
+		return
+	}
+	if deliveries == nil {
+		deliveries = []deliveryLog.Delivery{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(deliveries)
+}
+
+// replayWebhookDelivery sends a recorded delivery through the webhook handlers
+// again and returns the delivery the replay was recorded as.
+func (s *Server) replayWebhookDelivery(w http.ResponseWriter, r *http.Request) {
+	if s.deliveries == nil {
+		http.Error(w, "not found", http.StatusNotFound)
+		return
+	}
+
+	var body struct {
+		RenovateJob string `json:"renovateJob"`
+		Namespace   string `json:"namespace"`
+		ID          string `json:"id"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		badRequestError(w, err, "failed to parse request body")
+		return
+	}
+	if body.RenovateJob == "" || body.Namespace == "" || body.ID == "" {
+		badRequestError(w, nil, "Missing parameters")
+		return
+	}
+
+	auditTarget(r, body.Namespace, body.RenovateJob, "")
+	auditDetail(r, "delivery", body.ID)
+	if _, ok := s.requirePermission(w, r, body.Namespace, body.RenovateJob, permWebhookDeliveries, permTrigger); !ok {
+		return
+	}
+
+	job := crdmanager.RenovateJobIdentifier{Name: body.RenovateJob, Namespace: body.Namespace}
+	replayed, err := s.deliveries.ReplayDelivery(r.Context(), job, body.ID)
+	if errors.Is(err, deliveryLog.ErrDeliveryNotFound) {
+		writeError(w, HttpResultError{Message: "delivery not found", StatusCode: http.StatusNotFound, Error: err})
+		return
+	}
+	if errors.Is(err, deliveryLog.ErrDeliveryNotReplayable) {
+		writeError(w, HttpResultError{Message: err.Error(), StatusCode: http.StatusConflict, Error: err})
+		return
+	}
+	if err != nil {
+		s.logger.Error(err, "Failed to replay webhook delivery", "delivery", body.ID, "renovateJob", body.RenovateJob, "namespace", body.Namespace)
+		internalServerError(w, err, "failed to replay webhook delivery")
+		return
+	}
+
+	s.logger.Info("Replayed webhook delivery", "user", sessionEmail(r), "delivery", body.ID, "renovateJob", body.RenovateJob, "namespace", body.Namespace, "outcome", replayed.Outcome)
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(replayed)
+}
@@ -0,0 +1,158 @@
+package ui
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	api "renovate-operator/api/v1alpha1"
+	crdmanager "renovate-operator/internal/crdManager"
+	"renovate-operator/internal/deliveryLog"
+
+	"github.com/go-logr/logr"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+type mockWebhookDeliveries struct {
+	deliveries []deliveryLog.Delivery
+	replayed   []string
+}
+
+func (m *mockWebhookDeliveries) ListDeliveries(_ context.Context, _ crdmanager.RenovateJobIdentifier) ([]deliveryLog.Delivery, error) {
+	return m.deliveries, nil
+}
+
+func (m *mockWebhookDeliveries) ReplayDelivery(_ context.Context, _ crdmanager.RenovateJobIdentifier, id string) (*deliveryLog.Delivery, error) {
+	for _, delivery := range m.deliveries {
+		if delivery.ID != id {
+			continue
+		}
+		if !delivery.Replayable || !delivery.Authenticated {
+			return nil, deliveryLog.ErrDeliveryNotReplayable
+		}
+		m.replayed = append(m.replayed, id)
+		return &deliveryLog.Delivery{ID: "replay", ReplayOf: id, Outcome: deliveryLog.OutcomeAccepted}, nil
+	}
+	return nil, deliveryLog.ErrDeliveryNotFound
+}
+
+func TestWebhookDeliveries(t *testing.T) {
+	job := &api.RenovateJob{
+		ObjectMeta: metav1.ObjectMeta{Name: "job1", Namespace: "default"},
+		Spec: api.RenovateJobSpec{Access: &api.RenovateJobAccess{
+			ReaderGroups: []string{"readers"},
+			AdminGroups:  []string{"admins"},
+			RoleBindings: []api.RenovateJobRoleBinding{{Role: "maintainer", Groups: []string{"maintainers"}}},
+		}},
+	}
+	deliveries := &mockWebhookDeliveries{deliveries: []deliveryLog.Delivery{
+		{ID: "d1", Outcome: deliveryLog.OutcomeRejected, Reason: "unauthorized", Replayable: false},
+		{ID: "d2", Outcome: deliveryLog.OutcomeAccepted, Authenticated: true, Replayable: false},
+		{ID: "d3", Outcome: deliveryLog.OutcomeAccepted, Authenticated: true, Replayable: true},
+	}}
+	server := &Server{
+		manager: &mockRenovateJobManager{
+			getRenovateJobFunc: func(_ context.Context, _, _ string) (*api.RenovateJob, error) {
+				return job, nil
+			},
+		},
+		logger:         logr.Discard(),
+		auth:           &OIDCAuth{},
+		deliveries:     deliveries,
+		accessDefaults: AccessDefaults{Roles: map[string][]string{"maintainer": {permApprove, permWebhookDeliveries}}},
+	}
+
+	asGroup := func(req *http.Request, group string) *http.Request {
+		session := &sessionData{Email: "someone@example.com", Groups: []string{group}}
+		return req.WithContext(context.WithValue(req.Context(), sessionContextKey, session))
+	}
+	replay := func(group, id string) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(map[string]string{"namespace": "default", "renovateJob": "job1", "id": id})
+		req := asGroup(httptest.NewRequest(http.MethodPost, "/api/v1/webhook/deliveries/replay", bytes.NewReader(body)), group)
+		w := httptest.NewRecorder()
+		server.replayWebhookDelivery(w, req)
+		return w
+	}
+
+	t.Run("admins list deliveries", func(t *testing.T) {
+		req := asGroup(httptest.NewRequest(http.MethodGet, "/api/v1/webhook/deliveries?namespace=default&renovate=job1", nil), "admins")
+		w := httptest.NewRecorder()
+		server.getWebhookDeliveries(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("status = %d, want %d", w.Code, http.StatusOK)
+		}
+		var got []deliveryLog.Delivery
+		if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		if len(got) != 3 || got[0].Reason != "unauthorized" {
+			t.Errorf("unexpected deliveries %+v", got)
+		}
+	})
+
+	// payloads and headers of deliveries are not limited to what readers may
+	// see, so the log is an admin tool
+	t.Run("readers are forbidden", func(t *testing.T) {
+		req := asGroup(httptest.NewRequest(http.MethodGet, "/api/v1/webhook/deliveries?namespace=default&renovate=job1", nil), "readers")
+		w := httptest.NewRecorder()
+		server.getWebhookDeliveries(w, req)
+		if w.Code != http.StatusForbidden {
+			t.Errorf("status = %d, want %d", w.Code, http.StatusForbidden)
+		}
+		if w := replay("readers", "d3"); w.Code != http.StatusForbidden {
+			t.Errorf("replay status = %d, want %d", w.Code, http.StatusForbidden)
+		}
+	})
+
+	// a replay schedules runs like a trigger does
+	t.Run("replay needs trigger", func(t *testing.T) {
+		req := asGroup(httptest.NewRequest(http.MethodGet, "/api/v1/webhook/deliveries?namespace=default&renovate=job1", nil), "maintainers")
+		w := httptest.NewRecorder()
+		server.getWebhookDeliveries(w, req)
+		if w.Code != http.StatusOK {
+			t.Errorf("list status = %d, want %d", w.Code, http.StatusOK)
+		}
+		if w := replay("maintainers", "d3"); w.Code != http.StatusForbidden {
+			t.Errorf("replay status = %d, want %d", w.Code, http.StatusForbidden)
+		}
+		if len(deliveries.replayed) != 0 {
+			t.Errorf("expected nothing to be replayed, got %v", deliveries.replayed)
+		}
+	})
+
+	t.Run("admins replay deliveries", func(t *testing.T) {
+		w := replay("admins", "d3")
+		if w.Code != http.StatusOK {
+			t.Fatalf("status = %d, want %d: %s", w.Code, http.StatusOK, w.Body.String())
+		}
+		var got deliveryLog.Delivery
+		if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		if got.ReplayOf != "d3" || len(deliveries.replayed) != 1 {
+			t.Errorf("unexpected replay %+v, replayed %v", got, deliveries.replayed)
+		}
+		if w := replay("admins", "d1"); w.Code != http.StatusConflict {
+			t.Errorf("status for an unauthenticated delivery = %d, want %d", w.Code, http.StatusConflict)
+		}
+		if w := replay("admins", "d2"); w.Code != http.StatusConflict {
+			t.Errorf("status for a delivery without body = %d, want %d", w.Code, http.StatusConflict)
+		}
+		if w := replay("admins", "unknown"); w.Code != http.StatusNotFound {
+			t.Errorf("status for an unknown delivery = %d, want %d", w.Code, http.StatusNotFound)
+		}
+	})
+
+	t.Run("disabled log is not found", func(t *testing.T) {
+		disabled := &Server{manager: server.manager, logger: logr.Discard()}
+		w := httptest.NewRecorder()
+		disabled.getWebhookDeliveries(w, httptest.NewRequest(http.MethodGet, "/api/v1/webhook/deliveries?namespace=default&renovate=job1", nil))
+		if w.Code != http.StatusNotFound {
+			t.Errorf("status = %d, want %d", w.Code, http.StatusNotFound)
+		}
+	})
+}
@@ -0,0 +1,51 @@
+package webhook
+
+import (
+	"context"
+	"strconv"
+
+	"renovate-operator/internal/audit"
+	crdmanager "renovate-operator/internal/crdManager"
+)
+
+// SetAudit records the projects deliveries schedule and the discovery runs
+// they start in sink.
+func (s *Server) SetAudit(sink audit.Sink) {
+	s.audit = sink
+}
+
+// recordAudit records an action a delivery to the provider endpoint took on
+// a job. The provider is the actor: a delivery carries no verified identity
+// of whoever caused it on the platform.
+func (s *Server) recordAudit(ctx context.Context, provider, action string, jobId crdmanager.RenovateJobIdentifier, project string, err error, details map[string]string) {
+	if s.audit == nil {
+		return
+	}
+	entry := audit.Entry{
+		Actor:       provider,
+		Source:      audit.SourceWebhook,
+		Action:      action,
+		Namespace:   jobId.Namespace,
+		RenovateJob: jobId.Name,
+		Project:     project,
+		Result:      audit.ResultSuccess,
+		Details:     details,
+	}
+	if err != nil {
+		entry.Result = audit.ResultFailed
+		entry.Reason = err.Error()
+	}
+	if replay := replayFromContext(ctx); replay != nil {
+		if entry.Details == nil {
+			entry.Details = map[string]string{}
+		}
+		entry.Details["replayOf"] = replay.of
+	}
+	s.audit.Record(ctx, entry)
+}
+
+// recordDebounced records the scheduling call made for the debounced
+// deliveries of provider, with the number of deliveries it stands for.
+func (s *Server) recordDebounced(provider string, key debounceKey, events int, err error) {
+	s.recordAudit(context.Background(), provider, audit.ActionTrigger, key.job, key.project, err, map[string]string{"debounced": "true", "events": strconv.Itoa(events)})
+}
@@ -0,0 +1,121 @@
+package webhook
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	api "renovate-operator/api/v1alpha1"
+	"renovate-operator/internal/audit"
+	crdmanager "renovate-operator/internal/crdManager"
+	"renovate-operator/internal/kvstore"
+	"renovate-operator/internal/objectstore"
+	"renovate-operator/internal/types"
+
+	"github.com/go-logr/logr"
+	"github.com/gorilla/mux"
+)
+
+func newAuditTestSink(t *testing.T) audit.Sink {
+	t.Helper()
+	sink, err := audit.NewSink(logr.Discard(), "memory", kvstore.ValkeyConfig{}, objectstore.S3Config{}, "", time.Hour)
+	if err != nil {
+		t.Fatalf("NewSink returned error: %v", err)
+	}
+	return sink
+}
+
+func TestScheduleProject_Audit(t *testing.T) {
+	sink := newAuditTestSink(t)
+	server := NewWebookServer(&mockWebhookManager{
+		updateProjectStatusFunc: func(_ context.Context, project string, _ crdmanager.RenovateJobIdentifier, _ *types.RenovateStatusUpdate) error {
+			if project == "org/unknown" {
+				return crdmanager.ErrProjectNotFound
+			}
+			return nil
+		},
+	}, logr.Discard(), 0, nil)
+	server.SetAudit(sink)
+	jobId := crdmanager.RenovateJobIdentifier{Name: "job1", Namespace: "renovate"}
+
+	_ = server.scheduleProject(context.Background(), "github", "org/repo", jobId)
+	_ = server.scheduleProject(context.Background(), "gitlab", "org/unknown", jobId)
+
+	entries, _ := sink.List(context.Background(), audit.Filter{})
+	if len(entries) != 2 {
+		t.Fatalf("expected an entry per scheduling, got %+v", entries)
+	}
+	if e := entries[1]; e.Actor != "github" || e.Source != audit.SourceWebhook || e.Action != audit.ActionTrigger ||
+		e.Namespace != "renovate" || e.RenovateJob != "job1" || e.Project != "org/repo" || e.Result != audit.ResultSuccess {
+		t.Errorf("unexpected entry for the scheduled project: %+v", e)
+	}
+	if e := entries[0]; e.Actor != "gitlab" || e.Result != audit.ResultFailed || e.Reason != crdmanager.ErrProjectNotFound.Error() {
+		t.Errorf("expected the failed scheduling with its reason, got %+v", e)
+	}
+
+}
+
+func TestScheduleProject_DebouncedAudit(t *testing.T) {
+	sink := newAuditTestSink(t)
+	server := NewWebookServer(&mockWebhookManager{
+		updateProjectStatusFunc: func(_ context.Context, project string, _ crdmanager.RenovateJobIdentifier, _ *types.RenovateStatusUpdate) error {
+			if project == "org/unknown" {
+				return crdmanager.ErrProjectNotFound
+			}
+			return nil
+		},
+	}, logr.Discard(), 20*time.Millisecond, nil)
+	server.SetAudit(sink)
+	jobId := crdmanager.RenovateJobIdentifier{Name: "job1", Namespace: "renovate"}
+
+	_ = server.scheduleProject(context.Background(), "github", "org/repo", jobId)
+	_ = server.scheduleProject(context.Background(), "github", "org/repo", jobId)
+	_ = server.scheduleProject(context.Background(), "gitlab", "org/unknown", jobId)
+	if entries, _ := sink.List(context.Background(), audit.Filter{}); len(entries) != 0 {
+		t.Fatalf("expected nothing recorded before the scheduling call, got %+v", entries)
+	}
+
+	var entries []audit.Entry
+	waitFor(t, func() bool {
+		entries, _ = sink.List(context.Background(), audit.Filter{})
+		return len(entries) == 2
+	})
+	byProject := map[string]audit.Entry{}
+	for _, e := range entries {
+		byProject[e.Project] = e
+	}
+	if e := byProject["org/repo"]; e.Actor != "github" || e.Result != audit.ResultSuccess || e.Details["debounced"] != "true" || e.Details["events"] != "2" {
+		t.Errorf("expected one entry for the burst, got %+v", e)
+	}
+	if e := byProject["org/unknown"]; e.Actor != "gitlab" || e.Result != audit.ResultFailed || e.Reason != crdmanager.ErrProjectNotFound.Error() {
+		t.Errorf("expected the failed scheduling call with its reason, got %+v", e)
+	}
+}
+
+func TestCloudEvents_DiscoveryAudit(t *testing.T) {
+	sink := newAuditTestSink(t)
+	job := makeTestRenovateJob("renovate", "job1", "org/repo")
+	job.Spec.Webhook.Authentication = &api.RenovateWebhookAuth{Enabled: true}
+	server := NewWebookServer(&mockWebhookManager{
+		listRenovateJobsFullFunc: func(ctx context.Context) ([]api.RenovateJob, error) {
+			return []api.RenovateJob{job}, nil
+		},
+		isWebhookTokenValidFunc: func(ctx context.Context, _ crdmanager.RenovateJobIdentifier, token string) (bool, error) {
+			return token == "valid-token", nil
+		},
+	}, logr.Discard(), 0, nil)
+	server.SetDiscovery(&fakeDiscoveryAgent{})
+	server.SetAudit(sink)
+	router := mux.NewRouter()
+	RegisterWebhookRoutes(router, server)
+
+	w := send(router, "/webhook/v1/cloudevents?namespace=renovate&job=job1", `{}`, binaryEvent(CloudEventTypeDiscovery))
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusAccepted, w.Code, w.Body.String())
+	}
+	entries, _ := sink.List(context.Background(), audit.Filter{})
+	if len(entries) != 1 || entries[0].Action != audit.ActionDiscovery || entries[0].Actor != "cloudevents" || entries[0].RenovateJob != "job1" {
+		t.Errorf("expected the discovery run to be recorded, got %+v", entries)
+	}
+}
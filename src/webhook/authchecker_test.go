@@ -126,3 +126,30 @@ func TestBuildAuthCheckerFromRequest(t *testing.T) {
 		}
 	})
 }
+
+func TestSignatureWasUsed(t *testing.T) {
+	tests := []struct {
+		name    string
+		headers map[string]string
+		want    bool
+	}{
+		{name: "github", headers: map[string]string{"X-Hub-Signature-256": "sha256=abc"}, want: true},
+		{name: "bitbucket", headers: map[string]string{"X-Hub-Signature": "sha256=abc"}, want: true},
+		{name: "forgejo", headers: map[string]string{"X-Forgejo-Signature": "abc"}, want: true},
+		{name: "gitea", headers: map[string]string{"X-Gitea-Signature": "abc"}, want: true},
+		{name: "token takes precedence", headers: map[string]string{"Authorization": "Bearer t", "X-Hub-Signature": "sha256=abc"}, want: false},
+		{name: "no credentials", headers: nil, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/x", nil)
+			for k, v := range tt.headers {
+				r.Header.Set(k, v)
+			}
+			if got := signatureWasUsed(r); got != tt.want {
+				t.Errorf("signatureWasUsed() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
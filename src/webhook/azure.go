@@ -0,0 +1,205 @@
+package webhook
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+
+	"renovate-operator/metricStore"
+)
+
+// Azure DevOps service hook types and handler.
+//
+// Azure DevOps sends the event type in the payload rather than a header, and
+// each service hook subscription delivers exactly one event type. Azure Repos
+// has no issues and therefore no Dependency Dashboard, so only pull request
+// and push events are processed:
+//
+//   - git.pullrequest.updated: only if the PR is generated by Renovate and
+//     either its description contains a checked checkbox (e.g. the rebase
+//     checkbox) or the PR was abandoned
+//   - git.pullrequest.merged: a merge that succeeded (the event also fires for
+//     merge attempts that ended in conflicts)
+//   - git.push: handed to the push trigger (push.go)
+//
+// Azure DevOps cannot sign deliveries; the subscription sends the webhook
+// token as a custom Authorization header instead.
+
+type AzureEvent struct {
+	EventType string                    `json:"eventType"`
+	Resource  *AzurePullRequestResource `json:"resource,omitempty"`
+}
+
+type AzurePullRequestResource struct {
+	PullRequestID int             `json:"pullRequestId"`
+	Status        string          `json:"status"`
+	MergeStatus   string          `json:"mergeStatus"`
+	Title         string          `json:"title"`
+	Description   string          `json:"description"`
+	Repository    AzureRepository `json:"repository"`
+}
+
+type AzureRepository struct {
+	Name    string `json:"name"`
+	Project struct {
+		Name string `json:"name"`
+	} `json:"project"`
+	// DefaultBranch is the full ref, e.g. "refs/heads/main"
+	DefaultBranch string `json:"defaultBranch"`
+}
+
+// AzurePushEvent is the payload of "git.push". It carries the default branch
+// but not the changed files.
+type AzurePushEvent struct {
+	Resource *AzurePushResource `json:"resource,omitempty"`
+}
+
+type AzurePushResource struct {
+	RefUpdates []AzureRefUpdate `json:"refUpdates"`
+	Repository AzureRepository  `json:"repository"`
+}
+
+type AzureRefUpdate struct {
+	Name        string `json:"name"`
+	OldObjectID string `json:"oldObjectId"`
+	NewObjectID string `json:"newObjectId"`
+}
+
+// FullName returns the repository name as Renovate reports it on Azure DevOps:
+// "{project}/{repository}".
+func (r AzureRepository) FullName() string {
+	return r.Project.Name + "/" + r.Name
+}
+
+func (s *Server) azureWebhook(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	const provider = "azure"
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		metricStore.IncWebhookRequest(ctx, provider, "rejected")
+		s.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to read request body"})
+		return
+	}
+
+	var payload AzureEvent
+	if err := json.Unmarshal(body, &payload); err != nil {
+		metricStore.IncWebhookPayloadDecodeFailure(ctx, provider)
+		metricStore.IncWebhookRequest(ctx, provider, "rejected")
+		s.logger.Error(err, "failed to decode Azure DevOps webhook payload. Not processing.")
+		s.writeJSON(w, http.StatusBadRequest, map[string]string{"error": "failed to decode payload"})
+		return
+	}
+
+	if payload.EventType == "" {
+		metricStore.IncWebhookRequest(ctx, provider, "rejected")
+		s.writeJSON(w, http.StatusBadRequest, map[string]string{"error": "missing eventType"})
+		return
+	}
+
+	if payload.EventType == "git.push" {
+		s.azurePushWebhook(w, r, body)
+		return
+	}
+
+	valid, reason := isValidAzureEvent(&payload)
+	if !valid {
+		metricStore.IncWebhookRequest(ctx, provider, "ignored")
+		s.logger.Info("ignoring Azure DevOps webhook event", "event", payload.EventType, "reason", reason)
+		s.writeJSON(w, http.StatusOK, map[string]string{"message": "event ignored", "reason": reason})
+		return
+	}
+
+	namespace := r.URL.Query().Get("namespace")
+	jobName := r.URL.Query().Get("job")
+	project := payload.Resource.Repository.FullName()
+
+	checker := buildAuthCheckerFromRequest(r, body, s.manager)
+	jobId, err := FindAndAuthenticateJob(ctx, s.manager, namespace, jobName, project, checker)
+	if err != nil {
+		if s.ignoreUnmanagedProject(w, r, provider, err) {
+			return
+		}
+		s.recordResolverAuthFailure(ctx, provider, err, signatureWasUsed(r))
+		metricStore.IncWebhookRequest(ctx, provider, "rejected")
+		s.logger.Info("webhook resolve failed", "event", payload.EventType, "project", project, "error", err)
+		s.handleResolverError(w, err)
+		return
+	}
+
+	s.logger.Info("received Azure DevOps event", "event", payload.EventType, "repository", project)
+	err = s.scheduleProject(ctx, provider, project, jobId)
+	if s.handleUpdateProjectStatusError(w, err, project, jobId.Name, jobId.Namespace) {
+		metricStore.IncWebhookRequest(ctx, provider, "rejected")
+		return
+	}
+
+	metricStore.IncWebhookRequest(ctx, provider, "accepted")
+	s.writeJSON(w, http.StatusAccepted, map[string]string{"message": "renovate job scheduled", "repository": project})
+}
+
+func isValidAzureEvent(payload *AzureEvent) (bool, string) {
+	switch payload.EventType {
+	case "git.pullrequest.updated", "git.pullrequest.merged":
+		pr := payload.Resource
+		if pr == nil {
+			return false, "no pull request in payload"
+		}
+		if pr.Repository.Name == "" || pr.Repository.Project.Name == "" {
+			return false, "no repository in payload"
+		}
+		if payload.EventType == "git.pullrequest.merged" {
+			if pr.MergeStatus != "succeeded" {
+				return false, "merge did not succeed"
+			}
+			return true, ""
+		}
+		if !isRenovateContent(pr.Description) {
+			return false, "not a Renovate pull request"
+		}
+		// abandoning a Renovate PR always triggers; other updates only when a
+		// checkbox was checked
+		if pr.Status != "abandoned" && !hasCheckboxBeenChecked(pr.Description) {
+			return false, "no checked checkbox"
+		}
+		return true, ""
+
+	default:
+		return false, "unsupported event type: " + payload.EventType
+	}
+}
+
+func (s *Server) azurePushWebhook(w http.ResponseWriter, r *http.Request, body []byte) {
+	ctx := r.Context()
+	const provider = "azure"
+
+	var payload AzurePushEvent
+	if err := json.Unmarshal(body, &payload); err != nil {
+		metricStore.IncWebhookPayloadDecodeFailure(ctx, provider)
+		metricStore.IncWebhookRequest(ctx, provider, "rejected")
+		s.logger.Error(err, "failed to decode Azure DevOps push payload. Not processing.")
+		s.writeJSON(w, http.StatusBadRequest, map[string]string{"error": "failed to decode payload"})
+		return
+	}
+
+	s.handlePush(w, r, provider, body, payload.toPushEvent())
+}
+
+func (p *AzurePushEvent) toPushEvent() *pushEvent {
+	push := &pushEvent{}
+	if p.Resource == nil {
+		return push
+	}
+	repo := p.Resource.Repository
+	if repo.Name != "" && repo.Project.Name != "" {
+		push.Project = repo.FullName()
+	}
+	// the default branch is missing on repositories without commits
+	push.DefaultBranch, _ = branchFromRef(repo.DefaultBranch)
+	for _, update := range p.Resource.RefUpdates {
+		if branch, ok := branchFromRef(update.Name); ok {
+			push.Branches = append(push.Branches, branchUpdate{Branch: branch, Before: update.OldObjectID, After: update.NewObjectID})
+		}
+	}
+	return push
+}
@@ -0,0 +1,141 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	api "renovate-operator/api/v1alpha1"
+	crdmanager "renovate-operator/internal/crdManager"
+	"renovate-operator/internal/types"
+
+	"github.com/go-logr/logr"
+)
+
+func azurePullRequest(description string) *AzurePullRequestResource {
+	pr := &AzurePullRequestResource{PullRequestID: 1, Status: "active", Title: "Update dependency", Description: description}
+	pr.Repository.Name = "repo"
+	pr.Repository.Project.Name = "proj"
+	return pr
+}
+
+func TestAzureWebhook(t *testing.T) {
+	payload := AzureEvent{EventType: "git.pullrequest.updated", Resource: azurePullRequest(renovatePRBody)}
+
+	var scheduled string
+	mockManager := &mockWebhookManager{
+		listRenovateJobsFullFunc: func(ctx context.Context) ([]api.RenovateJob, error) {
+			return []api.RenovateJob{makeTestRenovateJob("renovate", "job1", "proj/repo")}, nil
+		},
+		updateProjectStatusFunc: func(ctx context.Context, project string, jobId crdmanager.RenovateJobIdentifier, status *types.RenovateStatusUpdate) error {
+			scheduled = project
+			return nil
+		},
+	}
+	server := &Server{manager: mockManager, logger: logr.Discard()}
+
+	body, _ := json.Marshal(payload)
+	req := httptest.NewRequest(http.MethodPost, "/webhook/v1/azure", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	w := httptest.NewRecorder()
+	server.azureWebhook(w, req)
+
+	if w.Code != http.StatusAccepted {
+		t.Errorf("expected status %d, got %d: %s", http.StatusAccepted, w.Code, w.Body.String())
+	}
+	if scheduled != "proj/repo" {
+		t.Errorf("expected project proj/repo to be scheduled, got %q", scheduled)
+	}
+}
+
+func TestAzureWebhookRequiresEventType(t *testing.T) {
+	server := &Server{manager: &mockWebhookManager{}, logger: logr.Discard()}
+
+	req := httptest.NewRequest(http.MethodPost, "/webhook/v1/azure", bytes.NewReader([]byte("{}")))
+
+	w := httptest.NewRecorder()
+	server.azureWebhook(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected status %d for missing eventType, got %d", http.StatusBadRequest, w.Code)
+	}
+}
+
+func TestAzureEventValidation(t *testing.T) {
+	unchecked := "This PR contains the following updates.\n - [ ] <!-- rebase-check -->If you want to rebase/retry this PR, check this box"
+
+	abandoned := azurePullRequest(unchecked)
+	abandoned.Status = "abandoned"
+	merged := azurePullRequest("")
+	merged.Status = "completed"
+	merged.MergeStatus = "succeeded"
+	conflicts := azurePullRequest("")
+	conflicts.MergeStatus = "conflicts"
+	noRepo := azurePullRequest(renovatePRBody)
+	noRepo.Repository = AzureRepository{}
+
+	tests := []struct {
+		name    string
+		payload AzureEvent
+		valid   bool
+	}{
+		{
+			name:    "renovate PR description edited with checked checkbox",
+			payload: AzureEvent{EventType: "git.pullrequest.updated", Resource: azurePullRequest(renovatePRBody)},
+			valid:   true,
+		},
+		{
+			name:    "renovate PR edited without checked checkbox",
+			payload: AzureEvent{EventType: "git.pullrequest.updated", Resource: azurePullRequest(unchecked)},
+			valid:   false,
+		},
+		{
+			name:    "non-renovate PR is ignored",
+			payload: AzureEvent{EventType: "git.pullrequest.updated", Resource: azurePullRequest("some human PR with - [x] a checkbox")},
+			valid:   false,
+		},
+		{
+			name:    "renovate PR abandoned",
+			payload: AzureEvent{EventType: "git.pullrequest.updated", Resource: abandoned},
+			valid:   true,
+		},
+		{
+			name:    "PR merged",
+			payload: AzureEvent{EventType: "git.pullrequest.merged", Resource: merged},
+			valid:   true,
+		},
+		{
+			name:    "merge attempt with conflicts",
+			payload: AzureEvent{EventType: "git.pullrequest.merged", Resource: conflicts},
+			valid:   false,
+		},
+		{
+			name:    "missing pull request payload",
+			payload: AzureEvent{EventType: "git.pullrequest.updated"},
+			valid:   false,
+		},
+		{
+			name:    "missing repository",
+			payload: AzureEvent{EventType: "git.pullrequest.updated", Resource: noRepo},
+			valid:   false,
+		},
+		{
+			name:    "unsupported event type",
+			payload: AzureEvent{EventType: "git.push"},
+			valid:   false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			valid, reason := isValidAzureEvent(&tt.payload)
+			if valid != tt.valid {
+				t.Errorf("expected valid=%v, got %v (reason: %s)", tt.valid, valid, reason)
+			}
+		})
+	}
+}
@@ -5,8 +5,6 @@ import (
 	"io"
 	"net/http"
 
-	api "renovate-operator/api/v1alpha1"
-	"renovate-operator/internal/types"
 	"renovate-operator/metricStore"
 )
 
@@ -20,6 +18,7 @@ import (
 //     description contains a checked checkbox (e.g. the rebase checkbox)
 //   - pullrequest:fulfilled / pullrequest:rejected: merge/decline of a
 //     Renovate PR (the equivalent of "closed" on the other platforms)
+//   - repo:push: handed to the push trigger (push.go)
 //
 // Deliveries are authenticated via the hook secret, which Bitbucket uses to
 // sign the payload into the X-Hub-Signature header (sha256=<hmac>).
@@ -39,6 +38,30 @@ type BitbucketRepository struct {
 	FullName string `json:"full_name"`
 }
 
+// BitbucketPushEvent is the payload of "repo:push". It carries neither the
+// default branch nor the changed files.
+type BitbucketPushEvent struct {
+	Push struct {
+		Changes []BitbucketPushChange `json:"changes"`
+	} `json:"push"`
+	Repository BitbucketRepository `json:"repository"`
+}
+
+// BitbucketPushChange is one ref moved by a push. Old is null for a created
+// ref, New for a deleted one.
+type BitbucketPushChange struct {
+	Old *BitbucketRef `json:"old"`
+	New *BitbucketRef `json:"new"`
+}
+
+type BitbucketRef struct {
+	Type   string `json:"type"`
+	Name   string `json:"name"`
+	Target struct {
+		Hash string `json:"hash"`
+	} `json:"target"`
+}
+
 func (s *Server) bitbucketWebhook(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	const provider = "bitbucket"
@@ -57,6 +80,11 @@ func (s *Server) bitbucketWebhook(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if event == "repo:push" {
+		s.bitbucketPushWebhook(w, r, body)
+		return
+	}
+
 	var payload BitbucketEvent
 	if err := json.Unmarshal(body, &payload); err != nil {
 		metricStore.IncWebhookPayloadDecodeFailure(ctx, provider)
@@ -81,6 +109,9 @@ func (s *Server) bitbucketWebhook(w http.ResponseWriter, r *http.Request) {
 	checker := buildAuthCheckerFromRequest(r, body, s.manager)
 	jobId, err := FindAndAuthenticateJob(ctx, s.manager, namespace, jobName, project, checker)
 	if err != nil {
+		if s.ignoreUnmanagedProject(w, r, provider, err) {
+			return
+		}
 		s.recordResolverAuthFailure(ctx, provider, err, signatureWasUsed(r))
 		metricStore.IncWebhookRequest(ctx, provider, "rejected")
 		s.logger.Info("webhook resolve failed", "event", event, "project", project, "error", err)
@@ -89,15 +120,7 @@ func (s *Server) bitbucketWebhook(w http.ResponseWriter, r *http.Request) {
 	}
 
 	s.logger.Info("received Bitbucket event", "event", event, "repository", project)
-	err = s.manager.UpdateProjectStatus(
-		ctx,
-		project,
-		jobId,
-		&types.RenovateStatusUpdate{
-			Status:   api.JobStatusScheduled,
-			Priority: 1,
-		},
-	)
+	err = s.scheduleProject(ctx, provider, project, jobId)
 	if s.handleUpdateProjectStatusError(w, err, project, jobId.Name, jobId.Namespace) {
 		metricStore.IncWebhookRequest(ctx, provider, "rejected")
 		return
@@ -130,3 +153,34 @@ func isValidBitbucketEvent(event string, payload *BitbucketEvent) (bool, string)
 		return false, "unsupported event type: " + event
 	}
 }
+
+func (s *Server) bitbucketPushWebhook(w http.ResponseWriter, r *http.Request, body []byte) {
+	ctx := r.Context()
+	const provider = "bitbucket"
+
+	var payload BitbucketPushEvent
+	if err := json.Unmarshal(body, &payload); err != nil {
+		metricStore.IncWebhookPayloadDecodeFailure(ctx, provider)
+		metricStore.IncWebhookRequest(ctx, provider, "rejected")
+		s.logger.Error(err, "failed to decode Bitbucket push payload. Not processing.")
+		s.writeJSON(w, http.StatusBadRequest, map[string]string{"error": "failed to decode payload"})
+		return
+	}
+
+	s.handlePush(w, r, provider, body, payload.toPushEvent())
+}
+
+func (p *BitbucketPushEvent) toPushEvent() *pushEvent {
+	push := &pushEvent{Project: p.Repository.FullName}
+	for _, change := range p.Push.Changes {
+		if change.New == nil || change.New.Type != "branch" {
+			continue
+		}
+		update := branchUpdate{Branch: change.New.Name, After: change.New.Target.Hash}
+		if change.Old != nil {
+			update.Before = change.Old.Target.Hash
+		}
+		push.Branches = append(push.Branches, update)
+	}
+	return push
+}
@@ -0,0 +1,201 @@
+package webhook
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+
+	"renovate-operator/metricStore"
+)
+
+// Bitbucket Server / Data Center webhook types and handler.
+//
+// Bitbucket Server dispatches by the X-Event-Key header (e.g. "pr:modified"),
+// with payloads that differ from Bitbucket Cloud's. It has no issues and
+// therefore no Dependency Dashboard, so only pull request events are
+// processed:
+//
+//   - pr:modified: only if the PR is generated by Renovate, its description
+//     changed and now contains a checked checkbox (e.g. the rebase checkbox)
+//   - pr:merged / pr:declined: merge/decline of a Renovate PR (the equivalent
+//     of "closed" on the other platforms)
+//   - repo:refs_changed: handed to the push trigger (push.go)
+//
+// Deliveries are authenticated via the hook secret, which Bitbucket Server
+// uses to sign the payload into the X-Hub-Signature header (sha256=<hmac>).
+
+type BitbucketServerEvent struct {
+	PullRequest         *BitbucketServerPullRequest `json:"pullRequest,omitempty"`
+	PreviousDescription *string                     `json:"previousDescription,omitempty"`
+}
+
+type BitbucketServerPullRequest struct {
+	ID          int    `json:"id"`
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	State       string `json:"state"`
+	ToRef       struct {
+		Repository BitbucketServerRepository `json:"repository"`
+	} `json:"toRef"`
+}
+
+type BitbucketServerRepository struct {
+	Slug    string `json:"slug"`
+	Project struct {
+		Key string `json:"key"`
+	} `json:"project"`
+}
+
+// BitbucketServerPushEvent is the payload of "repo:refs_changed". It carries
+// neither the default branch nor the changed files.
+type BitbucketServerPushEvent struct {
+	Repository BitbucketServerRepository `json:"repository"`
+	Changes    []struct {
+		Ref struct {
+			ID   string `json:"id"`
+			Type string `json:"type"`
+		} `json:"ref"`
+		FromHash string `json:"fromHash"`
+		ToHash   string `json:"toHash"`
+	} `json:"changes"`
+}
+
+// FullName returns the repository name as Renovate reports it on Bitbucket
+// Server: "{projectKey}/{repositorySlug}".
+func (r BitbucketServerRepository) FullName() string {
+	return r.Project.Key + "/" + r.Slug
+}
+
+func (s *Server) bitbucketServerWebhook(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	const provider = "bitbucket-server"
+
+	event := r.Header.Get("X-Event-Key")
+	if event == "" {
+		metricStore.IncWebhookRequest(ctx, provider, "rejected")
+		s.writeJSON(w, http.StatusBadRequest, map[string]string{"error": "missing X-Event-Key header"})
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		metricStore.IncWebhookRequest(ctx, provider, "rejected")
+		s.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to read request body"})
+		return
+	}
+
+	if event == "repo:refs_changed" {
+		s.bitbucketServerPushWebhook(w, r, body)
+		return
+	}
+
+	var payload BitbucketServerEvent
+	if err := json.Unmarshal(body, &payload); err != nil {
+		metricStore.IncWebhookPayloadDecodeFailure(ctx, provider)
+		metricStore.IncWebhookRequest(ctx, provider, "rejected")
+		s.logger.Error(err, "failed to decode Bitbucket Server webhook payload. Not processing.")
+		s.writeJSON(w, http.StatusBadRequest, map[string]string{"error": "failed to decode payload"})
+		return
+	}
+
+	valid, reason := isValidBitbucketServerEvent(event, &payload)
+	if !valid {
+		metricStore.IncWebhookRequest(ctx, provider, "ignored")
+		s.logger.Info("ignoring Bitbucket Server webhook event", "event", event, "reason", reason)
+		s.writeJSON(w, http.StatusOK, map[string]string{"message": "event ignored", "reason": reason})
+		return
+	}
+
+	namespace := r.URL.Query().Get("namespace")
+	jobName := r.URL.Query().Get("job")
+	project := payload.PullRequest.ToRef.Repository.FullName()
+
+	checker := buildAuthCheckerFromRequest(r, body, s.manager)
+	jobId, err := FindAndAuthenticateJob(ctx, s.manager, namespace, jobName, project, checker)
+	if err != nil {
+		if s.ignoreUnmanagedProject(w, r, provider, err) {
+			return
+		}
+		s.recordResolverAuthFailure(ctx, provider, err, signatureWasUsed(r))
+		metricStore.IncWebhookRequest(ctx, provider, "rejected")
+		s.logger.Info("webhook resolve failed", "event", event, "project", project, "error", err)
+		s.handleResolverError(w, err)
+		return
+	}
+
+	s.logger.Info("received Bitbucket Server event", "event", event, "repository", project)
+	err = s.scheduleProject(ctx, provider, project, jobId)
+	if s.handleUpdateProjectStatusError(w, err, project, jobId.Name, jobId.Namespace) {
+		metricStore.IncWebhookRequest(ctx, provider, "rejected")
+		return
+	}
+
+	metricStore.IncWebhookRequest(ctx, provider, "accepted")
+	s.writeJSON(w, http.StatusAccepted, map[string]string{"message": "renovate job scheduled", "repository": project})
+}
+
+func isValidBitbucketServerEvent(event string, payload *BitbucketServerEvent) (bool, string) {
+	switch event {
+	case "pr:modified", "pr:merged", "pr:declined":
+		pr := payload.PullRequest
+		if pr == nil {
+			return false, "no pull request in payload"
+		}
+		if pr.ToRef.Repository.Slug == "" || pr.ToRef.Repository.Project.Key == "" {
+			return false, "no repository in payload"
+		}
+		if event == "pr:merged" {
+			return true, ""
+		}
+		if !isRenovateContent(pr.Description) {
+			return false, "not a Renovate pull request"
+		}
+		if event == "pr:declined" {
+			return true, ""
+		}
+		// pr:modified also fires for title and target branch changes, which
+		// carry the unchanged description
+		if payload.PreviousDescription != nil && *payload.PreviousDescription == pr.Description {
+			return false, "description unchanged"
+		}
+		if !hasCheckboxBeenChecked(pr.Description) {
+			return false, "no checked checkbox"
+		}
+		return true, ""
+
+	default:
+		// includes diagnostics:ping, sent by the "Test connection" button
+		return false, "unsupported event type: " + event
+	}
+}
+
+func (s *Server) bitbucketServerPushWebhook(w http.ResponseWriter, r *http.Request, body []byte) {
+	ctx := r.Context()
+	const provider = "bitbucket-server"
+
+	var payload BitbucketServerPushEvent
+	if err := json.Unmarshal(body, &payload); err != nil {
+		metricStore.IncWebhookPayloadDecodeFailure(ctx, provider)
+		metricStore.IncWebhookRequest(ctx, provider, "rejected")
+		s.logger.Error(err, "failed to decode Bitbucket Server push payload. Not processing.")
+		s.writeJSON(w, http.StatusBadRequest, map[string]string{"error": "failed to decode payload"})
+		return
+	}
+
+	s.handlePush(w, r, provider, body, payload.toPushEvent())
+}
+
+func (p *BitbucketServerPushEvent) toPushEvent() *pushEvent {
+	push := &pushEvent{}
+	if p.Repository.Slug != "" && p.Repository.Project.Key != "" {
+		push.Project = p.Repository.FullName()
+	}
+	for _, change := range p.Changes {
+		branch, ok := branchFromRef(change.Ref.ID)
+		if !ok || change.Ref.Type != "BRANCH" {
+			continue
+		}
+		push.Branches = append(push.Branches, branchUpdate{Branch: branch, Before: change.FromHash, After: change.ToHash})
+	}
+	return push
+}
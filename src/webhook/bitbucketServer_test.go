@@ -0,0 +1,185 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	api "renovate-operator/api/v1alpha1"
+	crdmanager "renovate-operator/internal/crdManager"
+	"renovate-operator/internal/types"
+
+	"github.com/go-logr/logr"
+)
+
+func bitbucketServerPullRequest(description string) *BitbucketServerPullRequest {
+	pr := &BitbucketServerPullRequest{ID: 1, Title: "Update dependency", Description: description, State: "OPEN"}
+	pr.ToRef.Repository.Slug = "repo"
+	pr.ToRef.Repository.Project.Key = "PRJ"
+	return pr
+}
+
+func TestBitbucketServerWebhook_ValidatesSignature(t *testing.T) {
+	const secret = "webhook-secret"
+	payload := BitbucketServerEvent{PullRequest: bitbucketServerPullRequest(renovatePRBody)}
+	body, _ := json.Marshal(payload)
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	validSignature := "sha256=" + hex.EncodeToString(mac.Sum(nil))
+
+	tests := []struct {
+		name       string
+		signature  string
+		wantStatus int
+	}{
+		{name: "valid signature", signature: validSignature, wantStatus: http.StatusAccepted},
+		{name: "wrong signature", signature: "sha256=0000", wantStatus: http.StatusUnauthorized},
+		{name: "missing signature", signature: "", wantStatus: http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			job := makeTestRenovateJob("renovate", "job1", "PRJ/repo")
+			job.Spec.Webhook.Authentication = &api.RenovateWebhookAuth{Enabled: true}
+
+			var scheduled string
+			mockManager := &mockWebhookManager{
+				listRenovateJobsFullFunc: func(ctx context.Context) ([]api.RenovateJob, error) {
+					return []api.RenovateJob{job}, nil
+				},
+				isWebhookSignatureValidFunc: func(_ context.Context, _ crdmanager.RenovateJobIdentifier, signature string, b []byte) (bool, error) {
+					mac := hmac.New(sha256.New, []byte(secret))
+					mac.Write(b)
+					return hmac.Equal([]byte(signature), []byte("sha256="+hex.EncodeToString(mac.Sum(nil)))), nil
+				},
+				updateProjectStatusFunc: func(ctx context.Context, project string, jobId crdmanager.RenovateJobIdentifier, status *types.RenovateStatusUpdate) error {
+					scheduled = project
+					return nil
+				},
+			}
+			server := &Server{manager: mockManager, logger: logr.Discard()}
+
+			req := httptest.NewRequest(http.MethodPost, "/webhook/v1/bitbucket-server", bytes.NewReader(body))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("X-Event-Key", "pr:modified")
+			if tt.signature != "" {
+				req.Header.Set("X-Hub-Signature", tt.signature)
+			}
+
+			w := httptest.NewRecorder()
+			server.bitbucketServerWebhook(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("expected status %d, got %d: %s", tt.wantStatus, w.Code, w.Body.String())
+			}
+			if tt.wantStatus == http.StatusAccepted && scheduled != "PRJ/repo" {
+				t.Errorf("expected project PRJ/repo to be scheduled, got %q", scheduled)
+			}
+		})
+	}
+}
+
+func TestBitbucketServerWebhookRequiresEventHeader(t *testing.T) {
+	server := &Server{manager: &mockWebhookManager{}, logger: logr.Discard()}
+
+	req := httptest.NewRequest(http.MethodPost, "/webhook/v1/bitbucket-server", bytes.NewReader([]byte("{}")))
+
+	w := httptest.NewRecorder()
+	server.bitbucketServerWebhook(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected status %d for missing X-Event-Key header, got %d", http.StatusBadRequest, w.Code)
+	}
+}
+
+func TestBitbucketServerEventValidation(t *testing.T) {
+	unchecked := "This PR contains the following updates.\n - [ ] <!-- rebase-check -->If you want to rebase/retry this PR, check this box"
+	previous := unchecked
+	unchanged := renovatePRBody
+	noRepo := bitbucketServerPullRequest(renovatePRBody)
+	noRepo.ToRef.Repository = BitbucketServerRepository{}
+
+	tests := []struct {
+		name    string
+		event   string
+		payload BitbucketServerEvent
+		valid   bool
+	}{
+		{
+			name:    "renovate PR checkbox checked",
+			event:   "pr:modified",
+			payload: BitbucketServerEvent{PullRequest: bitbucketServerPullRequest(renovatePRBody), PreviousDescription: &previous},
+			valid:   true,
+		},
+		{
+			name:    "renovate PR retitled with the description unchanged",
+			event:   "pr:modified",
+			payload: BitbucketServerEvent{PullRequest: bitbucketServerPullRequest(renovatePRBody), PreviousDescription: &unchanged},
+			valid:   false,
+		},
+		{
+			name:    "renovate PR edited without checked checkbox",
+			event:   "pr:modified",
+			payload: BitbucketServerEvent{PullRequest: bitbucketServerPullRequest(unchecked)},
+			valid:   false,
+		},
+		{
+			name:    "non-renovate PR is ignored",
+			event:   "pr:modified",
+			payload: BitbucketServerEvent{PullRequest: bitbucketServerPullRequest("some human PR with - [x] a checkbox")},
+			valid:   false,
+		},
+		{
+			name:    "PR merged",
+			event:   "pr:merged",
+			payload: BitbucketServerEvent{PullRequest: bitbucketServerPullRequest("")},
+			valid:   true,
+		},
+		{
+			name:    "renovate PR declined",
+			event:   "pr:declined",
+			payload: BitbucketServerEvent{PullRequest: bitbucketServerPullRequest(renovatePRBody)},
+			valid:   true,
+		},
+		{
+			name:    "missing pull request payload",
+			event:   "pr:modified",
+			payload: BitbucketServerEvent{},
+			valid:   false,
+		},
+		{
+			name:    "missing repository",
+			event:   "pr:merged",
+			payload: BitbucketServerEvent{PullRequest: noRepo},
+			valid:   false,
+		},
+		{
+			name:    "connection test",
+			event:   "diagnostics:ping",
+			payload: BitbucketServerEvent{},
+			valid:   false,
+		},
+		{
+			name:    "cloud event key",
+			event:   "pullrequest:fulfilled",
+			payload: BitbucketServerEvent{PullRequest: bitbucketServerPullRequest("")},
+			valid:   false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			valid, reason := isValidBitbucketServerEvent(tt.event, &tt.payload)
+			if valid != tt.valid {
+				t.Errorf("expected valid=%v, got %v (reason: %s)", tt.valid, valid, reason)
+			}
+		})
+	}
+}
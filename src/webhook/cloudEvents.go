@@ -0,0 +1,259 @@
+package webhook
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+
+	api "renovate-operator/api/v1alpha1"
+	"renovate-operator/internal/audit"
+	crdmanager "renovate-operator/internal/crdManager"
+	"renovate-operator/internal/renovate"
+	"renovate-operator/metricStore"
+)
+
+// CloudEvents.
+//
+// The cloudevents endpoint accepts CloudEvents 1.0 over HTTP, so event routers
+// such as Argo Events or Knative Eventing can drive the operator without a
+// platform's payload. Both content modes of the HTTP binding are supported:
+//   - binary: the attributes in ce- headers, the data in the body,
+//   - structured: the whole event as application/cloudevents+json.
+//
+// Batched events are not. Requests authenticate like /schedule, with a bearer
+// token or a Standard Webhooks signature over the request body.
+
+const (
+	// CloudEventTypeSchedule schedules a project of a job.
+	CloudEventTypeSchedule = "com.renovate.schedule"
+	// CloudEventTypeDiscovery starts the discovery of a job.
+	CloudEventTypeDiscovery = "com.renovate.discovery"
+)
+
+const (
+	cloudEventsSpecVersion           = "1.0"
+	cloudEventsStructuredMedia       = "application/cloudevents+json"
+	cloudEventsBatchMedia            = "application/cloudevents-batch+json"
+	maxCloudEventPriority      int32 = 2
+)
+
+var (
+	errInvalidCloudEvent = errors.New("invalid cloud event")
+	errCloudEventBatch   = errors.New("batched cloud events are not supported")
+)
+
+// CloudEvent holds the context attributes and the data of an event.
+type CloudEvent struct {
+	SpecVersion     string          `json:"specversion"`
+	Type            string          `json:"type"`
+	Source          string          `json:"source"`
+	ID              string          `json:"id"`
+	DataContentType string          `json:"datacontenttype,omitempty"`
+	Data            json.RawMessage `json:"data,omitempty"`
+	DataBase64      string          `json:"data_base64,omitempty"`
+}
+
+// CloudEventData is the data of the operator's event types. Namespace and
+// job default to the query parameters of the same name; priority defaults to
+// the priority of webhooks.
+type CloudEventData struct {
+	Project   string `json:"project"`
+	Job       string `json:"job"`
+	Namespace string `json:"namespace"`
+	Priority  *int32 `json:"priority,omitempty"`
+}
+
+// parseCloudEvent reads the event of a request in binary or structured mode.
+func parseCloudEvent(r *http.Request, body []byte) (*CloudEvent, error) {
+	media, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+
+	var event CloudEvent
+	switch media {
+	case cloudEventsBatchMedia:
+		return nil, errCloudEventBatch
+	case cloudEventsStructuredMedia:
+		if err := json.Unmarshal(body, &event); err != nil {
+			return nil, fmt.Errorf("%w: %v", errInvalidCloudEvent, err)
+		}
+		if event.DataBase64 != "" {
+			data, err := base64.StdEncoding.DecodeString(event.DataBase64)
+			if err != nil {
+				return nil, fmt.Errorf("%w: data_base64: %v", errInvalidCloudEvent, err)
+			}
+			event.Data = data
+		}
+	default:
+		event = CloudEvent{
+			SpecVersion:     r.Header.Get("Ce-Specversion"),
+			Type:            r.Header.Get("Ce-Type"),
+			Source:          r.Header.Get("Ce-Source"),
+			ID:              r.Header.Get("Ce-Id"),
+			DataContentType: r.Header.Get("Content-Type"),
+			Data:            body,
+		}
+		if event.SpecVersion == "" {
+			return nil, fmt.Errorf("%w: neither the ce-specversion header nor a %s body", errInvalidCloudEvent, cloudEventsStructuredMedia)
+		}
+	}
+
+	if event.SpecVersion != cloudEventsSpecVersion {
+		return nil, fmt.Errorf("%w: unsupported specversion %q", errInvalidCloudEvent, event.SpecVersion)
+	}
+	if event.ID == "" || event.Source == "" || event.Type == "" {
+		return nil, fmt.Errorf("%w: id, source and type are required", errInvalidCloudEvent)
+	}
+	return &event, nil
+}
+
+// data decodes the data of the event, which must be JSON.
+func (e *CloudEvent) data() (CloudEventData, error) {
+	var data CloudEventData
+	if media, _, _ := mime.ParseMediaType(e.DataContentType); media != "" && media != "application/json" && !strings.HasSuffix(media, "+json") {
+		return data, fmt.Errorf("%w: unsupported datacontenttype %q", errInvalidCloudEvent, e.DataContentType)
+	}
+	if len(e.Data) == 0 {
+		return data, nil
+	}
+	if err := json.Unmarshal(e.Data, &data); err != nil {
+		return data, fmt.Errorf("%w: data: %v", errInvalidCloudEvent, err)
+	}
+	if data.Priority != nil && (*data.Priority < 0 || *data.Priority > maxCloudEventPriority) {
+		return data, fmt.Errorf("%w: priority must be between 0 and %d", errInvalidCloudEvent, maxCloudEventPriority)
+	}
+	return data, nil
+}
+
+func (s *Server) cloudEventsWebhook(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	const provider = "cloudevents"
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		metricStore.IncWebhookRequest(ctx, provider, "rejected")
+		s.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to read request body"})
+		return
+	}
+
+	event, err := parseCloudEvent(r, body)
+	if err == nil {
+		noteEvent(ctx, event.Type)
+	}
+	var data CloudEventData
+	if err == nil {
+		data, err = event.data()
+	}
+	if err != nil {
+		metricStore.IncWebhookPayloadDecodeFailure(ctx, provider)
+		metricStore.IncWebhookRequest(ctx, provider, "rejected")
+		s.logger.Info("rejecting cloud event", "reason", err.Error())
+		status := http.StatusBadRequest
+		if errors.Is(err, errCloudEventBatch) {
+			status = http.StatusUnsupportedMediaType
+		}
+		s.writeJSON(w, status, map[string]string{"error": err.Error()})
+		return
+	}
+	if data.Namespace == "" {
+		data.Namespace = r.URL.Query().Get("namespace")
+	}
+	if data.Job == "" {
+		data.Job = r.URL.Query().Get("job")
+	}
+
+	switch event.Type {
+	case CloudEventTypeSchedule:
+		s.scheduleCloudEvent(w, r, body, event, data)
+	case CloudEventTypeDiscovery:
+		s.discoveryCloudEvent(w, r, body, event, data)
+	default:
+		metricStore.IncWebhookRequest(ctx, provider, "ignored")
+		s.logger.Info("ignoring cloud event", "type", event.Type, "source", event.Source, "id", event.ID)
+		s.writeJSON(w, http.StatusOK, map[string]string{"message": "event ignored", "reason": fmt.Sprintf("unsupported event type %q", event.Type)})
+	}
+}
+
+func (s *Server) scheduleCloudEvent(w http.ResponseWriter, r *http.Request, body []byte, event *CloudEvent, data CloudEventData) {
+	ctx := r.Context()
+	const provider = "cloudevents"
+
+	if data.Project == "" {
+		metricStore.IncWebhookRequest(ctx, provider, "rejected")
+		s.writeJSON(w, http.StatusBadRequest, map[string]string{"error": "missing project in event data"})
+		return
+	}
+	priority := webhookPriority
+	if data.Priority != nil {
+		priority = *data.Priority
+	}
+
+	checker := buildAuthCheckerFromRequest(r, body, s.manager)
+	jobId, err := FindAndAuthenticateJob(ctx, s.manager, data.Namespace, data.Job, data.Project, checker)
+	if err != nil {
+		s.recordResolverAuthFailure(ctx, provider, err, signatureWasUsed(r))
+		metricStore.IncWebhookRequest(ctx, provider, "rejected")
+		s.logger.Info("failed to resolve job for cloud event", "project", data.Project, "id", event.ID, "error", err.Error())
+		s.handleResolverError(w, err)
+		return
+	}
+
+	err = s.scheduleProjectWithPriority(ctx, provider, data.Project, jobId, priority)
+	if s.handleUpdateProjectStatusError(w, err, data.Project, jobId.Name, jobId.Namespace) {
+		metricStore.IncWebhookRequest(ctx, provider, "rejected")
+		return
+	}
+
+	metricStore.IncWebhookRequest(ctx, provider, "accepted")
+	s.logger.V(2).Info("Successfully triggered Renovate for project", "project", data.Project, "renovateJob", jobId.Name, "namespace", jobId.Namespace, "priority", priority, "source", event.Source, "id", event.ID)
+	s.writeJSON(w, http.StatusAccepted, map[string]string{"message": "renovate job scheduled", "repository": data.Project})
+}
+
+func (s *Server) discoveryCloudEvent(w http.ResponseWriter, r *http.Request, body []byte, event *CloudEvent, data CloudEventData) {
+	ctx := r.Context()
+	const provider = "cloudevents"
+
+	if data.Namespace == "" || data.Job == "" {
+		metricStore.IncWebhookRequest(ctx, provider, "rejected")
+		s.writeJSON(w, http.StatusBadRequest, map[string]string{"error": "missing job or namespace in event data"})
+		return
+	}
+	if s.discovery == nil {
+		metricStore.IncWebhookRequest(ctx, provider, "rejected")
+		s.writeJSON(w, http.StatusNotImplemented, map[string]string{"error": "discovery is not available"})
+		return
+	}
+
+	checker := buildAuthCheckerFromRequest(r, body, s.manager)
+	job, err := AuthenticateJob(ctx, s.manager, data.Namespace, data.Job, checker)
+	if err != nil {
+		s.recordResolverAuthFailure(ctx, provider, err, signatureWasUsed(r))
+		metricStore.IncWebhookRequest(ctx, provider, "rejected")
+		s.logger.Info("failed to resolve job for cloud event", "renovateJob", data.Job, "namespace", data.Namespace, "id", event.ID, "error", err.Error())
+		s.handleResolverError(w, err)
+		return
+	}
+
+	// discovery must only run once
+	if status, err := s.discovery.GetDiscoveryJobStatus(ctx, job); err == nil && status == api.JobStatusRunning {
+		metricStore.IncWebhookRequest(ctx, provider, "ignored")
+		s.writeJSON(w, http.StatusOK, map[string]string{"message": "event ignored", "reason": "discovery job is already running"})
+		return
+	}
+	jobId := crdmanager.RenovateJobIdentifier{Name: job.Name, Namespace: job.Namespace}
+	if _, err := s.discovery.CreateDiscoveryJob(ctx, *job, renovate.DiscoveryJobOptions{}); err != nil {
+		s.recordAudit(ctx, provider, audit.ActionDiscovery, jobId, "", err, nil)
+		metricStore.IncWebhookRequest(ctx, provider, "rejected")
+		s.logger.Error(err, "Failed to start discovery for cloud event", "renovateJob", job.Name, "namespace", job.Namespace, "id", event.ID)
+		s.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to start discovery"})
+		return
+	}
+
+	s.recordAudit(ctx, provider, audit.ActionDiscovery, jobId, "", nil, nil)
+	metricStore.IncWebhookRequest(ctx, provider, "accepted")
+	s.logger.V(2).Info("Successfully started discovery for RenovateJob", "renovateJob", job.Name, "namespace", job.Namespace, "source", event.Source, "id", event.ID)
+	s.writeJSON(w, http.StatusAccepted, map[string]string{"message": "discovery job started"})
+}
@@ -0,0 +1,213 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	api "renovate-operator/api/v1alpha1"
+	crdmanager "renovate-operator/internal/crdManager"
+	"renovate-operator/internal/renovate"
+	"renovate-operator/internal/types"
+
+	"github.com/go-logr/logr"
+	"github.com/gorilla/mux"
+	batchv1 "k8s.io/api/batch/v1"
+)
+
+type fakeDiscoveryAgent struct {
+	status  api.RenovateProjectStatus
+	started []string
+}
+
+func (f *fakeDiscoveryAgent) CreateDiscoveryJob(_ context.Context, renovateJob api.RenovateJob, _ renovate.DiscoveryJobOptions) (string, error) {
+	f.started = append(f.started, renovateJob.Namespace+"/"+renovateJob.Name)
+	return renovateJob.Name + "-discovery", nil
+}
+
+func (f *fakeDiscoveryAgent) GetDiscoveryJobStatus(_ context.Context, _ *api.RenovateJob) (api.RenovateProjectStatus, error) {
+	return f.status, nil
+}
+
+func (f *fakeDiscoveryAgent) ProcessDiscoveryJobResult(_ context.Context, _ *batchv1.Job, _ crdmanager.RenovateJobIdentifier) error {
+	return nil
+}
+
+// newCloudEventsTestServer serves the webhook routes for a job1 in renovate
+// that authenticates webhooks with the token "valid-token" or a Standard
+// Webhooks signature with the id "msg_1".
+func newCloudEventsTestServer(t *testing.T, updates *[]*types.RenovateStatusUpdate, discovery *fakeDiscoveryAgent) http.Handler {
+	t.Helper()
+	job := makeTestRenovateJob("renovate", "job1", "org/repo")
+	job.Spec.Webhook.Authentication = &api.RenovateWebhookAuth{Enabled: true}
+
+	server := NewWebookServer(&mockWebhookManager{
+		listRenovateJobsFullFunc: func(ctx context.Context) ([]api.RenovateJob, error) {
+			return []api.RenovateJob{job}, nil
+		},
+		isWebhookTokenValidFunc: func(ctx context.Context, _ crdmanager.RenovateJobIdentifier, token string) (bool, error) {
+			return token == "valid-token", nil
+		},
+		isWebhookStandardSignatureValidFunc: func(ctx context.Context, _ crdmanager.RenovateJobIdentifier, msgID, _, signature string, _ []byte) (bool, error) {
+			return msgID == "msg_1" && signature == "v1,valid", nil
+		},
+		updateProjectStatusFunc: func(ctx context.Context, project string, _ crdmanager.RenovateJobIdentifier, status *types.RenovateStatusUpdate) error {
+			*updates = append(*updates, status)
+			return nil
+		},
+	}, logr.Discard(), 0, nil)
+	if discovery != nil {
+		server.SetDiscovery(discovery)
+	}
+	router := mux.NewRouter()
+	RegisterWebhookRoutes(router, server)
+	return router
+}
+
+func binaryEvent(eventType string) map[string]string {
+	return map[string]string{
+		"Ce-Specversion": "1.0",
+		"Ce-Type":        eventType,
+		"Ce-Source":      "argo-events",
+		"Ce-Id":          "evt-1",
+		"Content-Type":   "application/json",
+		"Authorization":  "Bearer valid-token",
+	}
+}
+
+func TestCloudEvents_ScheduleBinaryMode(t *testing.T) {
+	var updates []*types.RenovateStatusUpdate
+	router := newCloudEventsTestServer(t, &updates, nil)
+
+	w := send(router, "/webhook/v1/cloudevents", `{"project":"org/repo","job":"job1","namespace":"renovate","priority":2}`, binaryEvent(CloudEventTypeSchedule))
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusAccepted, w.Code, w.Body.String())
+	}
+	if len(updates) != 1 || updates[0].Status != api.JobStatusScheduled || updates[0].Priority != 2 || !updates[0].RerunIfRunning {
+		t.Fatalf("expected the project to be scheduled with priority 2, got %+v", updates)
+	}
+
+	// without a priority, the event is scheduled like a webhook
+	w = send(router, "/webhook/v1/cloudevents?namespace=renovate&job=job1", `{"project":"org/repo"}`, binaryEvent(CloudEventTypeSchedule))
+	if w.Code != http.StatusAccepted || len(updates) != 2 || updates[1].Priority != webhookPriority {
+		t.Fatalf("expected webhook priority, got %d and %+v", w.Code, updates)
+	}
+
+	headers := binaryEvent(CloudEventTypeSchedule)
+	headers["Authorization"] = "Bearer wrong"
+	if w := send(router, "/webhook/v1/cloudevents", `{"project":"org/repo"}`, headers); w.Code != http.StatusUnauthorized {
+		t.Errorf("expected status %d for a wrong token, got %d", http.StatusUnauthorized, w.Code)
+	}
+	if w := send(router, "/webhook/v1/cloudevents", `{"project":"org/unknown"}`, binaryEvent(CloudEventTypeSchedule)); w.Code != http.StatusNotFound {
+		t.Errorf("expected status %d for an unknown project, got %d", http.StatusNotFound, w.Code)
+	}
+}
+
+func TestCloudEvents_ScheduleStructuredModeWithSignature(t *testing.T) {
+	var updates []*types.RenovateStatusUpdate
+	router := newCloudEventsTestServer(t, &updates, nil)
+
+	event, _ := json.Marshal(CloudEvent{
+		SpecVersion: "1.0",
+		Type:        CloudEventTypeSchedule,
+		Source:      "knative://broker/default",
+		ID:          "evt-2",
+		Data:        json.RawMessage(`{"project":"org/repo"}`),
+	})
+	headers := map[string]string{
+		"Content-Type":      "application/cloudevents+json; charset=utf-8",
+		"Webhook-Id":        "msg_1",
+		"Webhook-Timestamp": "1700000000",
+		"Webhook-Signature": "v1,valid",
+	}
+	if w := send(router, "/webhook/v1/cloudevents", string(event), headers); w.Code != http.StatusAccepted {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusAccepted, w.Code, w.Body.String())
+	}
+	if len(updates) != 1 {
+		t.Fatalf("expected the project to be scheduled, got %+v", updates)
+	}
+
+	headers["Webhook-Signature"] = "v1,forged"
+	if w := send(router, "/webhook/v1/cloudevents", string(event), headers); w.Code != http.StatusUnauthorized {
+		t.Errorf("expected status %d for a wrong signature, got %d", http.StatusUnauthorized, w.Code)
+	}
+}
+
+func TestCloudEvents_Discovery(t *testing.T) {
+	var updates []*types.RenovateStatusUpdate
+	discovery := &fakeDiscoveryAgent{status: api.JobStatusCompleted}
+	router := newCloudEventsTestServer(t, &updates, discovery)
+	data := `{"job":"job1","namespace":"renovate"}`
+
+	if w := send(router, "/webhook/v1/cloudevents", data, binaryEvent(CloudEventTypeDiscovery)); w.Code != http.StatusAccepted {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusAccepted, w.Code, w.Body.String())
+	}
+	if len(discovery.started) != 1 || discovery.started[0] != "renovate/job1" {
+		t.Fatalf("expected the discovery of job1 to start, got %v", discovery.started)
+	}
+
+	discovery.status = api.JobStatusRunning
+	w := send(router, "/webhook/v1/cloudevents", data, binaryEvent(CloudEventTypeDiscovery))
+	if w.Code != http.StatusOK || len(discovery.started) != 1 {
+		t.Errorf("expected a running discovery to be left alone, got %d and %v", w.Code, discovery.started)
+	}
+
+	headers := binaryEvent(CloudEventTypeDiscovery)
+	delete(headers, "Authorization")
+	if w := send(router, "/webhook/v1/cloudevents", data, headers); w.Code != http.StatusUnauthorized {
+		t.Errorf("expected status %d without credentials, got %d", http.StatusUnauthorized, w.Code)
+	}
+	if w := send(router, "/webhook/v1/cloudevents", `{"job":"job2","namespace":"renovate"}`, binaryEvent(CloudEventTypeDiscovery)); w.Code != http.StatusNotFound {
+		t.Errorf("expected status %d for an unknown job, got %d", http.StatusNotFound, w.Code)
+	}
+	if w := send(router, "/webhook/v1/cloudevents", `{}`, binaryEvent(CloudEventTypeDiscovery)); w.Code != http.StatusBadRequest {
+		t.Errorf("expected status %d without a job, got %d", http.StatusBadRequest, w.Code)
+	}
+
+	withoutDiscovery := newCloudEventsTestServer(t, &updates, nil)
+	if w := send(withoutDiscovery, "/webhook/v1/cloudevents", data, binaryEvent(CloudEventTypeDiscovery)); w.Code != http.StatusNotImplemented {
+		t.Errorf("expected status %d without a discovery agent, got %d", http.StatusNotImplemented, w.Code)
+	}
+}
+
+func TestCloudEvents_RejectsInvalidEvents(t *testing.T) {
+	var updates []*types.RenovateStatusUpdate
+	router := newCloudEventsTestServer(t, &updates, nil)
+
+	withHeader := func(name, value string) map[string]string {
+		headers := binaryEvent(CloudEventTypeSchedule)
+		if value == "" {
+			delete(headers, name)
+		} else {
+			headers[name] = value
+		}
+		return headers
+	}
+	tests := []struct {
+		name    string
+		body    string
+		headers map[string]string
+		want    int
+	}{
+		{"no event", `{"project":"org/repo"}`, map[string]string{"Content-Type": "application/json"}, http.StatusBadRequest},
+		{"unsupported specversion", `{"project":"org/repo"}`, withHeader("Ce-Specversion", "0.3"), http.StatusBadRequest},
+		{"missing id", `{"project":"org/repo"}`, withHeader("Ce-Id", ""), http.StatusBadRequest},
+		{"non-JSON data", `org/repo`, withHeader("Content-Type", "text/plain"), http.StatusBadRequest},
+		{"priority out of range", `{"project":"org/repo","priority":5}`, binaryEvent(CloudEventTypeSchedule), http.StatusBadRequest},
+		{"missing project", `{}`, binaryEvent(CloudEventTypeSchedule), http.StatusBadRequest},
+		{"malformed structured event", `{"specversion":`, map[string]string{"Content-Type": "application/cloudevents+json"}, http.StatusBadRequest},
+		{"batch", `[]`, map[string]string{"Content-Type": "application/cloudevents-batch+json"}, http.StatusUnsupportedMediaType},
+		{"unknown type", `{"project":"org/repo"}`, binaryEvent("com.example.other"), http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if w := send(router, "/webhook/v1/cloudevents", tt.body, tt.headers); w.Code != tt.want {
+				t.Errorf("expected status %d, got %d: %s", tt.want, w.Code, w.Body.String())
+			}
+		})
+	}
+	if len(updates) != 0 {
+		t.Errorf("expected nothing to be scheduled, got %+v", updates)
+	}
+}
@@ -0,0 +1,116 @@
+package webhook
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+
+	crdmanager "renovate-operator/internal/crdManager"
+	"renovate-operator/metricStore"
+
+	"github.com/go-logr/logr"
+)
+
+// Debouncing of scheduling decisions.
+//
+// Merging a batch of Renovate PRs sends one delivery per PR, each asking to
+// schedule the same project. Deliveries for a project that arrive within the
+// debounce window of each other are coalesced into a single scheduling call,
+// made once the window passed without a further delivery. A steady stream of
+// deliveries delays that call by at most maxDebounceWindows windows, and
+// schedules the project with the highest priority any of them asked for.
+//
+// Pending decisions are held in memory, per replica. A restart within the
+// window drops them; the project's next scheduled run picks up the changes.
+
+// maxDebounceWindows caps how long a burst can hold back its scheduling call,
+// counted in debounce windows from the first delivery.
+const maxDebounceWindows = 5
+
+type scheduleFunc func(ctx context.Context, project string, jobId crdmanager.RenovateJobIdentifier, priority int32) error
+
+type scheduleDebouncer struct {
+	window   time.Duration
+	schedule scheduleFunc
+	logger   logr.Logger
+	// scheduled, if set, is told the outcome of each scheduling call, once
+	// per provider whose deliveries it was made for
+	scheduled func(provider string, key debounceKey, events int, err error)
+
+	mu      sync.Mutex
+	pending map[debounceKey]*pendingSchedule
+}
+
+type debounceKey struct {
+	job     crdmanager.RenovateJobIdentifier
+	project string
+}
+
+type pendingSchedule struct {
+	timer *time.Timer
+	// deadline is the latest time the scheduling call is made at
+	deadline time.Time
+	events   int
+	priority int32
+	// providers counts the deliveries of each provider
+	providers map[string]int
+}
+
+func newScheduleDebouncer(window time.Duration, schedule scheduleFunc, logger logr.Logger) *scheduleDebouncer {
+	return &scheduleDebouncer{
+		window:   window,
+		schedule: schedule,
+		logger:   logger,
+		pending:  make(map[debounceKey]*pendingSchedule),
+	}
+}
+
+// Add registers a delivery asking to schedule project with priority. The
+// scheduling call follows once the burst is over.
+func (d *scheduleDebouncer) Add(ctx context.Context, provider, project string, jobId crdmanager.RenovateJobIdentifier, priority int32) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	key := debounceKey{job: jobId, project: project}
+	if p, ok := d.pending[key]; ok {
+		p.events++
+		p.providers[provider]++
+		p.priority = max(p.priority, priority)
+		p.timer.Reset(max(min(d.window, time.Until(p.deadline)), 0))
+		metricStore.IncWebhookEventCoalesced(ctx, provider)
+		return
+	}
+
+	p := &pendingSchedule{deadline: time.Now().Add(maxDebounceWindows * d.window), events: 1, priority: priority, providers: map[string]int{provider: 1}}
+	p.timer = time.AfterFunc(d.window, func() { d.fire(key, p) })
+	d.pending[key] = p
+}
+
+// fire makes the scheduling call of a pending decision. A timer that fires
+// after its decision was already made (because it was reset while firing) is a
+// no-op.
+func (d *scheduleDebouncer) fire(key debounceKey, p *pendingSchedule) {
+	d.mu.Lock()
+	if d.pending[key] != p {
+		d.mu.Unlock()
+		return
+	}
+	delete(d.pending, key)
+	events, priority := p.events, p.priority
+	d.mu.Unlock()
+
+	// the deliveries have long been answered, so their contexts are gone
+	err := d.schedule(context.Background(), key.project, key.job, priority)
+	if d.scheduled != nil {
+		for _, provider := range slices.Sorted(maps.Keys(p.providers)) {
+			d.scheduled(provider, key, p.providers[provider], err)
+		}
+	}
+	if err != nil {
+		d.logger.Error(err, "Failed to schedule project for debounced webhook events", "project", key.project, "renovateJob", key.job.Name, "namespace", key.job.Namespace, "events", events)
+		return
+	}
+	d.logger.V(2).Info("Scheduled project for debounced webhook events", "project", key.project, "renovateJob", key.job.Name, "namespace", key.job.Namespace, "events", events)
+}
@@ -0,0 +1,160 @@
+package webhook
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	api "renovate-operator/api/v1alpha1"
+	crdmanager "renovate-operator/internal/crdManager"
+	"renovate-operator/internal/types"
+
+	"github.com/go-logr/logr"
+)
+
+// scheduleRecorder counts the scheduling calls per project.
+type scheduleRecorder struct {
+	mu    sync.Mutex
+	calls map[string]int
+}
+
+func (r *scheduleRecorder) schedule(_ context.Context, project string, _ crdmanager.RenovateJobIdentifier, _ int32) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.calls == nil {
+		r.calls = map[string]int{}
+	}
+	r.calls[project]++
+	return nil
+}
+
+func (r *scheduleRecorder) count(project string) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.calls[project]
+}
+
+// waitFor polls cond until it holds or a second has passed.
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met within a second")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestScheduleDebouncer_CoalescesBurst(t *testing.T) {
+	const window = 50 * time.Millisecond
+	recorder := &scheduleRecorder{}
+	d := newScheduleDebouncer(window, recorder.schedule, logr.Discard())
+	job := crdmanager.RenovateJobIdentifier{Name: "job1", Namespace: "renovate"}
+
+	for range 15 {
+		d.Add(context.Background(), "github", "org/repo", job, webhookPriority)
+	}
+	d.Add(context.Background(), "github", "org/other", job, webhookPriority)
+	if recorder.count("org/repo") != 0 {
+		t.Fatal("expected the scheduling call to wait for the window")
+	}
+
+	waitFor(t, func() bool { return recorder.count("org/repo") == 1 && recorder.count("org/other") == 1 })
+	time.Sleep(2 * window)
+	if n := recorder.count("org/repo"); n != 1 {
+		t.Errorf("expected the burst to be scheduled once, got %d calls", n)
+	}
+
+	// a delivery after the burst starts a new decision
+	d.Add(context.Background(), "github", "org/repo", job, webhookPriority)
+	waitFor(t, func() bool { return recorder.count("org/repo") == 2 })
+}
+
+func TestScheduleDebouncer_SteadyStreamIsNotHeldBackForever(t *testing.T) {
+	const window = 20 * time.Millisecond
+	recorder := &scheduleRecorder{}
+	d := newScheduleDebouncer(window, recorder.schedule, logr.Discard())
+	job := crdmanager.RenovateJobIdentifier{Name: "job1", Namespace: "renovate"}
+
+	// deliveries keep arriving within the window for twice the maximum delay
+	for end := time.Now().Add(2 * maxDebounceWindows * window); time.Now().Before(end); {
+		d.Add(context.Background(), "gitlab", "group/repo", job, webhookPriority)
+		time.Sleep(window / 4)
+	}
+	if recorder.count("group/repo") == 0 {
+		t.Error("expected the project to be scheduled while deliveries kept arriving")
+	}
+}
+
+func TestDebouncedWebhookSchedulesOnceWithRerun(t *testing.T) {
+	var mu sync.Mutex
+	var updates []*types.RenovateStatusUpdate
+	mockManager := &mockWebhookManager{
+		listRenovateJobsFullFunc: func(ctx context.Context) ([]api.RenovateJob, error) {
+			return []api.RenovateJob{makeTestRenovateJob("renovate", "job1", "org/repo")}, nil
+		},
+		updateProjectStatusFunc: func(ctx context.Context, project string, jobId crdmanager.RenovateJobIdentifier, status *types.RenovateStatusUpdate) error {
+			mu.Lock()
+			defer mu.Unlock()
+			updates = append(updates, status)
+			return nil
+		},
+	}
+	server := NewWebookServer(mockManager, logr.Discard(), 50*time.Millisecond, nil)
+
+	for range 3 {
+		req := httptest.NewRequest(http.MethodPost, "/webhook/v1/schedule?project=org%2Frepo", nil)
+		w := httptest.NewRecorder()
+		server.runRenovate(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+		}
+	}
+
+	waitFor(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(updates) > 0
+	})
+	time.Sleep(100 * time.Millisecond)
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(updates) != 1 {
+		t.Fatalf("expected one scheduling call for the burst, got %d", len(updates))
+	}
+	if updates[0].Status != api.JobStatusScheduled || updates[0].Priority != 1 || !updates[0].RerunIfRunning {
+		t.Errorf("unexpected status update: %+v", updates[0])
+	}
+}
+
+func TestScheduleDebouncer_KeepsHighestPriority(t *testing.T) {
+	const window = 20 * time.Millisecond
+	var mu sync.Mutex
+	var priorities []int32
+	d := newScheduleDebouncer(window, func(_ context.Context, _ string, _ crdmanager.RenovateJobIdentifier, priority int32) error {
+		mu.Lock()
+		defer mu.Unlock()
+		priorities = append(priorities, priority)
+		return nil
+	}, logr.Discard())
+	job := crdmanager.RenovateJobIdentifier{Name: "job1", Namespace: "renovate"}
+
+	d.Add(context.Background(), "cloudevents", "org/repo", job, 2)
+	d.Add(context.Background(), "github", "org/repo", job, webhookPriority)
+
+	waitFor(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(priorities) == 1
+	})
+	mu.Lock()
+	defer mu.Unlock()
+	if priorities[0] != 2 {
+		t.Errorf("expected the burst to be scheduled with priority 2, got %d", priorities[0])
+	}
+}
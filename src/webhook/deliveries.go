@@ -0,0 +1,348 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	crdmanager "renovate-operator/internal/crdManager"
+	"renovate-operator/internal/deliveryLog"
+
+	"github.com/gorilla/mux"
+)
+
+// Delivery log.
+//
+// With a delivery log configured, every request to the webhook routes is
+// recorded against the RenovateJob it concerns, so "why did my merge not
+// trigger Renovate" can be answered from the UI instead of the operator logs.
+// The job is, in this order:
+//   - the job FindAndAuthenticateJob resolved the delivery to,
+//   - the candidates it failed to authenticate against,
+//   - the job named by the namespace and job query parameters, for deliveries
+//     ignored before they were resolved.
+//
+// A delivery that matches none of them is not recorded: there is no job whose
+// admins could be shown it.
+
+// redacted replaces the value of a credential header in the log.
+const redacted = "[redacted]"
+
+// maxCapturedResponse bounds how much of a response is read back for the
+// outcome of a delivery. Responses of the handlers are small JSON objects.
+const maxCapturedResponse = 4 * 1024
+
+// eventHeaders carry the platform's event type.
+var eventHeaders = []string{"X-GitHub-Event", "X-Gitlab-Event", "X-Gitea-Event", "X-Forgejo-Event", "X-Event-Key", "Ce-Type"}
+
+// sensitiveHeaders carry credentials. Any header whose name contains
+// "token", "secret" or "signature" is redacted as well.
+var sensitiveHeaders = map[string]bool{
+	"Authorization":       true,
+	"Proxy-Authorization": true,
+	"Cookie":              true,
+}
+
+type deliveryRecordKey struct{}
+
+// deliveryRecord collects what the handler learned about a delivery, for the
+// middleware to record once the response is written.
+type deliveryRecord struct {
+	mu            sync.Mutex
+	event         string
+	project       string
+	job           *crdmanager.RenovateJobIdentifier
+	authenticated bool
+	candidates    []crdmanager.RenovateJobIdentifier
+}
+
+// noteResolution stores the outcome of FindAndAuthenticateJob on the delivery
+// record of ctx, if there is one.
+func noteResolution(ctx context.Context, project string, job *crdmanager.RenovateJobIdentifier, candidates []crdmanager.RenovateJobIdentifier) {
+	record, ok := ctx.Value(deliveryRecordKey{}).(*deliveryRecord)
+	if !ok {
+		return
+	}
+	record.mu.Lock()
+	defer record.mu.Unlock()
+	record.project = project
+	record.job = job
+	record.authenticated = job != nil
+	record.candidates = candidates
+}
+
+// noteEvent stores the event type of a delivery whose type is not in a header,
+// such as a structured CloudEvent, on the delivery record of ctx.
+func noteEvent(ctx context.Context, event string) {
+	record, ok := ctx.Value(deliveryRecordKey{}).(*deliveryRecord)
+	if !ok {
+		return
+	}
+	record.mu.Lock()
+	defer record.mu.Unlock()
+	record.event = event
+}
+
+type replayKey struct{}
+
+// replay marks a request built by ReplayDelivery.
+type replay struct {
+	job crdmanager.RenovateJobIdentifier
+	of  string
+	// result is the delivery the replay was recorded as
+	result *deliveryLog.Delivery
+}
+
+func replayFromContext(ctx context.Context) *replay {
+	r, _ := ctx.Value(replayKey{}).(*replay)
+	return r
+}
+
+// deliveryResponseWriter captures the status and the start of the body of a
+// response.
+type deliveryResponseWriter struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (w *deliveryResponseWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *deliveryResponseWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	if remaining := maxCapturedResponse - w.body.Len(); remaining > 0 {
+		w.body.Write(b[:min(len(b), remaining)])
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// deliveryLogMiddleware records the deliveries to the webhook routes in the
+// delivery log.
+func (s *Server) deliveryLogMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if s.deliveries == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		received := time.Now()
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			s.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to read request body"})
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		record := &deliveryRecord{}
+		r = r.WithContext(context.WithValue(r.Context(), deliveryRecordKey{}, record))
+		rw := &deliveryResponseWriter{ResponseWriter: w}
+		next.ServeHTTP(rw, r)
+
+		s.recordDelivery(r, received, body, record, rw)
+	})
+}
+
+func (s *Server) recordDelivery(r *http.Request, received time.Time, body []byte, record *deliveryRecord, rw *deliveryResponseWriter) {
+	ctx := r.Context()
+	delivery := deliveryLog.Delivery{
+		Time:       received,
+		Provider:   path.Base(r.URL.Path),
+		Query:      r.URL.RawQuery,
+		Headers:    redactHeaders(r.Header),
+		StatusCode: rw.status,
+	}
+	if delivery.StatusCode == 0 {
+		delivery.StatusCode = http.StatusOK
+	}
+	for _, header := range eventHeaders {
+		if event := r.Header.Get(header); event != "" {
+			delivery.Event = event
+			break
+		}
+	}
+	delivery.Outcome, delivery.Reason = deliveryOutcome(delivery.StatusCode, rw.body.Bytes())
+
+	record.mu.Lock()
+	if delivery.Event == "" {
+		delivery.Event = record.event
+	}
+	delivery.Project = record.project
+	delivery.Authenticated = record.authenticated
+	var jobs []crdmanager.RenovateJobIdentifier
+	switch {
+	case record.job != nil:
+		jobs = []crdmanager.RenovateJobIdentifier{*record.job}
+	case len(record.candidates) > 0:
+		jobs = record.candidates
+	}
+	record.mu.Unlock()
+
+	replay := replayFromContext(ctx)
+	if replay != nil {
+		// a replay is recorded against the job it was requested for, even
+		// when it no longer resolves to it
+		delivery.ReplayOf = replay.of
+		jobs = []crdmanager.RenovateJobIdentifier{replay.job}
+	}
+	if len(jobs) == 0 {
+		jobs = s.addressedJob(ctx, r.URL.Query())
+	}
+
+	for _, job := range jobs {
+		delivery.ID = rand.Text()
+		delivery.Namespace = job.Namespace
+		delivery.RenovateJob = job.Name
+		s.deliveries.Record(ctx, delivery, body)
+		if replay != nil {
+			result := delivery
+			replay.result = &result
+		}
+	}
+}
+
+// addressedJob returns the job named by the query parameters of a delivery,
+// if it exists and receives webhooks.
+func (s *Server) addressedJob(ctx context.Context, query url.Values) []crdmanager.RenovateJobIdentifier {
+	namespace, name := query.Get("namespace"), query.Get("job")
+	if namespace == "" || name == "" {
+		return nil
+	}
+	job, err := s.manager.GetRenovateJob(ctx, name, namespace)
+	if err != nil || job == nil || job.Spec.Webhook == nil || !job.Spec.Webhook.Enabled {
+		return nil
+	}
+	return []crdmanager.RenovateJobIdentifier{{Name: name, Namespace: namespace}}
+}
+
+// deliveryOutcome derives the outcome of a delivery from the handler's
+// response: a successful response with a reason is an ignored event.
+func deliveryOutcome(status int, response []byte) (string, string) {
+	var message struct {
+		Reason string `json:"reason"`
+		Error  string `json:"error"`
+	}
+	_ = json.Unmarshal(response, &message)
+
+	switch {
+	case status >= 200 && status < 300 && message.Reason != "":
+		return deliveryLog.OutcomeIgnored, message.Reason
+	case status >= 200 && status < 300:
+		return deliveryLog.OutcomeAccepted, ""
+	default:
+		return deliveryLog.OutcomeRejected, message.Error
+	}
+}
+
+func redactHeaders(header http.Header) map[string]string {
+	result := make(map[string]string, len(header))
+	for name, values := range header {
+		name = http.CanonicalHeaderKey(name)
+		if isSensitiveHeader(name) {
+			result[name] = redacted
+			continue
+		}
+		result[name] = strings.Join(values, ", ")
+	}
+	return result
+}
+
+func isSensitiveHeader(name string) bool {
+	if sensitiveHeaders[name] {
+		return true
+	}
+	lower := strings.ToLower(name)
+	return strings.Contains(lower, "token") || strings.Contains(lower, "secret") || strings.Contains(lower, "signature")
+}
+
+// ListDeliveries returns the recorded deliveries of a job, newest first. It
+// returns nil when the server has no delivery log.
+func (s *Server) ListDeliveries(ctx context.Context, job crdmanager.RenovateJobIdentifier) ([]deliveryLog.Delivery, error) {
+	if s.deliveries == nil {
+		return nil, nil
+	}
+	return s.deliveries.List(ctx, job.Namespace, job.Name)
+}
+
+// ReplayDelivery sends a recorded delivery through the webhook routes again
+// and returns the delivery the replay was recorded as.
+//
+// The stored headers have their credentials redacted, so the replay cannot
+// authenticate like the original did. It is trusted instead, as the caller
+// asked for it on behalf of the job, and it can only resolve to that job.
+// Only deliveries that passed authentication when they arrived are kept
+// for replay, so a replay never lets an unauthenticated payload through.
+func (s *Server) ReplayDelivery(ctx context.Context, job crdmanager.RenovateJobIdentifier, id string) (*deliveryLog.Delivery, error) {
+	if s.deliveries == nil {
+		return nil, deliveryLog.ErrDeliveryNotFound
+	}
+	delivery, body, err := s.deliveries.Get(ctx, job.Namespace, job.Name, id)
+	if err != nil {
+		return nil, err
+	}
+	if !delivery.Replayable || !delivery.Authenticated {
+		return nil, deliveryLog.ErrDeliveryNotReplayable
+	}
+
+	query, err := url.ParseQuery(delivery.Query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse the query of the delivery: %w", err)
+	}
+	query.Set("namespace", job.Namespace)
+	query.Set("job", job.Name)
+
+	marker := &replay{job: job, of: delivery.ID}
+	target := "/webhook/v1/" + url.PathEscape(delivery.Provider) + "?" + query.Encode()
+	req, err := http.NewRequestWithContext(context.WithValue(context.WithoutCancel(ctx), replayKey{}, marker), http.MethodPost, target, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	for name, value := range delivery.Headers {
+		if value == redacted || name == "Content-Length" {
+			continue
+		}
+		req.Header.Set(name, value)
+	}
+
+	s.replayHandler().ServeHTTP(&discardResponseWriter{header: http.Header{}}, req)
+	if marker.result == nil {
+		return nil, fmt.Errorf("the replay of delivery %s was not recorded", id)
+	}
+	return marker.result, nil
+}
+
+// replayHandler returns a router serving only the webhook routes, built on
+// first use.
+func (s *Server) replayHandler() http.Handler {
+	s.replayOnce.Do(func() {
+		router := mux.NewRouter()
+		RegisterWebhookRoutes(router, s)
+		s.replayRouter = router
+	})
+	return s.replayRouter
+}
+
+// discardResponseWriter is the response writer of a replay, whose response
+// is only read back from the delivery log.
+type discardResponseWriter struct {
+	header http.Header
+}
+
+func (w *discardResponseWriter) Header() http.Header         { return w.header }
+func (w *discardResponseWriter) Write(b []byte) (int, error) { return len(b), nil }
+func (w *discardResponseWriter) WriteHeader(int)             {}
@@ -0,0 +1,165 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	api "renovate-operator/api/v1alpha1"
+	crdmanager "renovate-operator/internal/crdManager"
+	"renovate-operator/internal/deliveryLog"
+	"renovate-operator/internal/kvstore"
+	"renovate-operator/internal/types"
+
+	"github.com/go-logr/logr"
+	"github.com/gorilla/mux"
+)
+
+// newDeliveryLogTestServer serves the webhook routes with an in-memory
+// delivery log, for a job1 with webhook authentication enabled and the token
+// "valid-token".
+func newDeliveryLogTestServer(t *testing.T, scheduled *[]string) (*Server, deliveryLog.DeliveryLog, http.Handler) {
+	t.Helper()
+	job := makeTestRenovateJob("renovate", "job1", "org/repo")
+	job.Spec.Webhook.Authentication = &api.RenovateWebhookAuth{Enabled: true}
+
+	mockManager := &mockWebhookManager{
+		listRenovateJobsFullFunc: func(ctx context.Context) ([]api.RenovateJob, error) {
+			return []api.RenovateJob{job}, nil
+		},
+		getRenovateJobFunc: func(ctx context.Context, name, namespace string) (*api.RenovateJob, error) {
+			if name != job.Name || namespace != job.Namespace {
+				return nil, errors.New("not found")
+			}
+			return &job, nil
+		},
+		isWebhookTokenValidFunc: func(ctx context.Context, _ crdmanager.RenovateJobIdentifier, token string) (bool, error) {
+			return token == "valid-token", nil
+		},
+		updateProjectStatusFunc: func(ctx context.Context, project string, _ crdmanager.RenovateJobIdentifier, _ *types.RenovateStatusUpdate) error {
+			*scheduled = append(*scheduled, project)
+			return nil
+		},
+	}
+
+	deliveries, err := deliveryLog.NewDeliveryLog(logr.Discard(), "memory", kvstore.ValkeyConfig{}, 10)
+	if err != nil {
+		t.Fatalf("failed to create delivery log: %v", err)
+	}
+	server := NewWebookServer(mockManager, logr.Discard(), 0, deliveries)
+	router := mux.NewRouter()
+	RegisterWebhookRoutes(router, server)
+	return server, deliveries, router
+}
+
+func send(handler http.Handler, target, body string, headers map[string]string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, target, strings.NewReader(body))
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	return w
+}
+
+func TestDeliveryLog_RecordsOutcomes(t *testing.T) {
+	var scheduled []string
+	_, deliveries, router := newDeliveryLogTestServer(t, &scheduled)
+	ctx := context.Background()
+
+	if w := send(router, "/webhook/v1/schedule?project=org%2Frepo", "", map[string]string{"Authorization": "Bearer valid-token"}); w.Code != http.StatusOK {
+		t.Fatalf("expected the delivery to be accepted, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := send(router, "/webhook/v1/schedule?project=org%2Frepo", "", map[string]string{"X-Gitlab-Token": "wrong"}); w.Code != http.StatusUnauthorized {
+		t.Fatalf("expected the delivery to be refused, got %d", w.Code)
+	}
+	opened := `{"action":"opened","repository":{"full_name":"org/repo"}}`
+	send(router, "/webhook/v1/github?namespace=renovate&job=job1", opened, map[string]string{"X-GitHub-Event": "pull_request"})
+	// neither resolved nor addressed to a job, so there is no log to put it in
+	send(router, "/webhook/v1/github", opened, map[string]string{"X-GitHub-Event": "pull_request"})
+
+	list, err := deliveries.List(ctx, "renovate", "job1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(list) != 3 {
+		t.Fatalf("expected 3 deliveries, got %d: %+v", len(list), list)
+	}
+
+	ignored, rejected, accepted := list[0], list[1], list[2]
+	if ignored.Outcome != deliveryLog.OutcomeIgnored || ignored.Reason == "" || ignored.Provider != "github" || ignored.Event != "pull_request" {
+		t.Errorf("unexpected ignored delivery: %+v", ignored)
+	}
+	if rejected.Outcome != deliveryLog.OutcomeRejected || rejected.Reason != "unauthorized" || rejected.Authenticated || rejected.StatusCode != http.StatusUnauthorized {
+		t.Errorf("unexpected rejected delivery: %+v", rejected)
+	}
+	if rejected.Headers["X-Gitlab-Token"] != "[redacted]" {
+		t.Errorf("expected the token header to be redacted, got %q", rejected.Headers["X-Gitlab-Token"])
+	}
+	if accepted.Outcome != deliveryLog.OutcomeAccepted || !accepted.Authenticated || accepted.Project != "org/repo" || accepted.Provider != "schedule" {
+		t.Errorf("unexpected accepted delivery: %+v", accepted)
+	}
+	if accepted.Headers["Authorization"] != "[redacted]" {
+		t.Errorf("expected the authorization header to be redacted, got %q", accepted.Headers["Authorization"])
+	}
+}
+
+func TestReplayDelivery(t *testing.T) {
+	var scheduled []string
+	server, deliveries, router := newDeliveryLogTestServer(t, &scheduled)
+	ctx := context.Background()
+	job := crdmanager.RenovateJobIdentifier{Name: "job1", Namespace: "renovate"}
+
+	send(router, "/webhook/v1/schedule?project=org%2Frepo", "", map[string]string{"Authorization": "Bearer valid-token"})
+	list, _ := deliveries.List(ctx, "renovate", "job1")
+	if len(list) != 1 || list[0].Outcome != deliveryLog.OutcomeAccepted || !list[0].Replayable {
+		t.Fatalf("expected one replayable accepted delivery, got %+v", list)
+	}
+
+	replayed, err := server.ReplayDelivery(ctx, job, list[0].ID)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if replayed.Outcome != deliveryLog.OutcomeAccepted || replayed.ReplayOf != list[0].ID || replayed.RenovateJob != "job1" {
+		t.Errorf("unexpected replayed delivery: %+v", replayed)
+	}
+	if len(scheduled) != 2 || scheduled[1] != "org/repo" {
+		t.Errorf("expected the replay to schedule org/repo again, got %v", scheduled)
+	}
+	if list, _ := deliveries.List(ctx, "renovate", "job1"); len(list) != 2 || list[0].ID != replayed.ID {
+		t.Errorf("expected the replay to be logged as the newest delivery, got %+v", list)
+	}
+
+	// the replay skips authentication, so it must not let a rejected
+	// delivery through
+	send(router, "/webhook/v1/schedule?project=org%2Frepo", "", map[string]string{"Authorization": "Bearer expired-token"})
+	rejected, _ := deliveries.List(ctx, "renovate", "job1")
+	if rejected[0].Outcome != deliveryLog.OutcomeRejected || rejected[0].Replayable {
+		t.Fatalf("expected an unreplayable rejected delivery, got %+v", rejected[0])
+	}
+	if _, err := server.ReplayDelivery(ctx, job, rejected[0].ID); !errors.Is(err, deliveryLog.ErrDeliveryNotReplayable) {
+		t.Errorf("expected ErrDeliveryNotReplayable, got %v", err)
+	}
+	if len(scheduled) != 2 {
+		t.Errorf("expected the rejected delivery not to schedule anything, got %v", scheduled)
+	}
+
+	if _, err := server.ReplayDelivery(ctx, job, "unknown"); !errors.Is(err, deliveryLog.ErrDeliveryNotFound) {
+		t.Errorf("expected ErrDeliveryNotFound, got %v", err)
+	}
+	// the replay of job1's delivery is only trusted for job1
+	other := crdmanager.RenovateJobIdentifier{Name: "job2", Namespace: "renovate"}
+	if _, err := server.ReplayDelivery(ctx, other, list[0].ID); !errors.Is(err, deliveryLog.ErrDeliveryNotFound) {
+		t.Errorf("expected deliveries of other jobs to be out of reach, got %v", err)
+	}
+}
+
+func TestDeliveryLog_DisabledRecordsNothing(t *testing.T) {
+	server := NewWebookServer(&mockWebhookManager{}, logr.Discard(), 0, nil)
+	if list, err := server.ListDeliveries(context.Background(), crdmanager.RenovateJobIdentifier{Name: "job1", Namespace: "renovate"}); list != nil || err != nil {
+		t.Errorf("expected no deliveries without a log, got %v, %v", list, err)
+	}
+}
@@ -5,8 +5,6 @@ import (
 	"io"
 	"net/http"
 
-	api "renovate-operator/api/v1alpha1"
-	"renovate-operator/internal/types"
 	"renovate-operator/metricStore"
 )
 
@@ -27,6 +25,7 @@ import (
 //     Dashboard and contains a checked checkbox
 //   - pull_request (edited/closed/reopened): only for PRs generated by
 //     Renovate, identified by body content rather than author username
+//   - push: handed to the push trigger (push.go)
 //
 // Additionally, Forgejo uses X-Forgejo-Signature for HMAC authentication
 // instead of X-Hub-Signature-256.
@@ -54,9 +53,21 @@ type ForgejoIssue struct {
 }
 
 type ForgejoRepository struct {
-	ID       int    `json:"id"`
-	Name     string `json:"name"`
-	FullName string `json:"full_name"`
+	ID            int    `json:"id"`
+	Name          string `json:"name"`
+	FullName      string `json:"full_name"`
+	DefaultBranch string `json:"default_branch"`
+}
+
+// ForgejoPushEvent is the payload of the "push" event. Forgejo caps the commits
+// it lists; total_commits tells whether the list is complete.
+type ForgejoPushEvent struct {
+	Ref          string            `json:"ref"`
+	Before       string            `json:"before"`
+	After        string            `json:"after"`
+	Commits      []PushCommit      `json:"commits"`
+	TotalCommits int               `json:"total_commits"`
+	Repository   ForgejoRepository `json:"repository"`
 }
 
 type ForgejoUser struct {
@@ -81,6 +92,11 @@ func (s *Server) forgejoWebhook(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if event == "push" {
+		s.forgejoPushWebhook(w, r, body)
+		return
+	}
+
 	var payload ForgejoEvent
 	if err := json.Unmarshal(body, &payload); err != nil {
 		metricStore.IncWebhookPayloadDecodeFailure(ctx, provider)
@@ -105,6 +121,9 @@ func (s *Server) forgejoWebhook(w http.ResponseWriter, r *http.Request) {
 	checker := buildAuthCheckerFromRequest(r, body, s.manager)
 	jobId, err := FindAndAuthenticateJob(ctx, s.manager, namespace, jobName, project, checker)
 	if err != nil {
+		if s.ignoreUnmanagedProject(w, r, provider, err) {
+			return
+		}
 		s.recordResolverAuthFailure(ctx, provider, err, signatureWasUsed(r))
 		metricStore.IncWebhookRequest(ctx, provider, "rejected")
 		s.logger.Info("webhook resolve failed", "event", event, "project", project, "error", err)
@@ -113,15 +132,7 @@ func (s *Server) forgejoWebhook(w http.ResponseWriter, r *http.Request) {
 	}
 
 	s.logger.Info("received Forgejo event", "event", event, "repository", project, "action", payload.Action)
-	err = s.manager.UpdateProjectStatus(
-		ctx,
-		project,
-		jobId,
-		&types.RenovateStatusUpdate{
-			Status:   api.JobStatusScheduled,
-			Priority: 1,
-		},
-	)
+	err = s.scheduleProject(ctx, provider, project, jobId)
 	if s.handleUpdateProjectStatusError(w, err, project, jobId.Name, jobId.Namespace) {
 		metricStore.IncWebhookRequest(ctx, provider, "rejected")
 		return
@@ -174,3 +185,31 @@ func isValidForgejoEvent(event string, payload *ForgejoEvent) (bool, string) {
 		return false, "unsupported event type: " + event
 	}
 }
+
+func (s *Server) forgejoPushWebhook(w http.ResponseWriter, r *http.Request, body []byte) {
+	ctx := r.Context()
+	const provider = "forgejo"
+
+	var payload ForgejoPushEvent
+	if err := json.Unmarshal(body, &payload); err != nil {
+		metricStore.IncWebhookPayloadDecodeFailure(ctx, provider)
+		metricStore.IncWebhookRequest(ctx, provider, "rejected")
+		s.logger.Error(err, "failed to decode Forgejo push payload. Not processing.")
+		s.writeJSON(w, http.StatusBadRequest, map[string]string{"error": "failed to decode payload"})
+		return
+	}
+
+	s.handlePush(w, r, provider, body, payload.toPushEvent())
+}
+
+func (p *ForgejoPushEvent) toPushEvent() *pushEvent {
+	push := &pushEvent{Project: p.Repository.FullName, DefaultBranch: p.Repository.DefaultBranch}
+	if branch, ok := branchFromRef(p.Ref); ok {
+		update := branchUpdate{Branch: branch, Before: p.Before, After: p.After}
+		if len(p.Commits) > 0 && p.TotalCommits <= len(p.Commits) {
+			update.Files = filesOfCommits(p.Commits)
+		}
+		push.Branches = append(push.Branches, update)
+	}
+	return push
+}
@@ -5,8 +5,6 @@ import (
 	"io"
 	"net/http"
 
-	api "renovate-operator/api/v1alpha1"
-	"renovate-operator/internal/types"
 	"renovate-operator/metricStore"
 )
 
@@ -24,6 +22,7 @@ import (
 //     Dashboard and contains a checked checkbox
 //   - pull_request (edited/closed/reopened): only for PRs generated by
 //     Renovate, identified by body content rather than author username
+//   - push: handed to the push trigger (push.go)
 //
 // Additionally, Gitea uses X-Gitea-Signature for HMAC authentication instead
 // of X-Hub-Signature-256.
@@ -51,9 +50,21 @@ type GiteaIssue struct {
 }
 
 type GiteaRepository struct {
-	ID       int    `json:"id"`
-	Name     string `json:"name"`
-	FullName string `json:"full_name"`
+	ID            int    `json:"id"`
+	Name          string `json:"name"`
+	FullName      string `json:"full_name"`
+	DefaultBranch string `json:"default_branch"`
+}
+
+// GiteaPushEvent is the payload of the "push" event. Gitea caps the commits
+// it lists; total_commits tells whether the list is complete.
+type GiteaPushEvent struct {
+	Ref          string          `json:"ref"`
+	Before       string          `json:"before"`
+	After        string          `json:"after"`
+	Commits      []PushCommit    `json:"commits"`
+	TotalCommits int             `json:"total_commits"`
+	Repository   GiteaRepository `json:"repository"`
 }
 
 type GiteaUser struct {
@@ -78,6 +89,11 @@ func (s *Server) giteaWebhook(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if event == "push" {
+		s.giteaPushWebhook(w, r, body)
+		return
+	}
+
 	var payload GiteaEvent
 	if err := json.Unmarshal(body, &payload); err != nil {
 		metricStore.IncWebhookPayloadDecodeFailure(ctx, provider)
@@ -102,6 +118,9 @@ func (s *Server) giteaWebhook(w http.ResponseWriter, r *http.Request) {
 	checker := buildAuthCheckerFromRequest(r, body, s.manager)
 	jobId, err := FindAndAuthenticateJob(ctx, s.manager, namespace, jobName, project, checker)
 	if err != nil {
+		if s.ignoreUnmanagedProject(w, r, provider, err) {
+			return
+		}
 		s.recordResolverAuthFailure(ctx, provider, err, signatureWasUsed(r))
 		metricStore.IncWebhookRequest(ctx, provider, "rejected")
 		s.logger.Info("webhook resolve failed", "event", event, "project", project, "error", err)
@@ -110,15 +129,7 @@ func (s *Server) giteaWebhook(w http.ResponseWriter, r *http.Request) {
 	}
 
 	s.logger.Info("received Gitea event", "event", event, "repository", project, "action", payload.Action)
-	err = s.manager.UpdateProjectStatus(
-		ctx,
-		project,
-		jobId,
-		&types.RenovateStatusUpdate{
-			Status:   api.JobStatusScheduled,
-			Priority: 1,
-		},
-	)
+	err = s.scheduleProject(ctx, provider, project, jobId)
 	if s.handleUpdateProjectStatusError(w, err, project, jobId.Name, jobId.Namespace) {
 		metricStore.IncWebhookRequest(ctx, provider, "rejected")
 		return
@@ -171,3 +182,31 @@ func isValidGiteaEvent(event string, payload *GiteaEvent) (bool, string) {
 		return false, "unsupported event type: " + event
 	}
 }
+
+func (s *Server) giteaPushWebhook(w http.ResponseWriter, r *http.Request, body []byte) {
+	ctx := r.Context()
+	const provider = "gitea"
+
+	var payload GiteaPushEvent
+	if err := json.Unmarshal(body, &payload); err != nil {
+		metricStore.IncWebhookPayloadDecodeFailure(ctx, provider)
+		metricStore.IncWebhookRequest(ctx, provider, "rejected")
+		s.logger.Error(err, "failed to decode Gitea push payload. Not processing.")
+		s.writeJSON(w, http.StatusBadRequest, map[string]string{"error": "failed to decode payload"})
+		return
+	}
+
+	s.handlePush(w, r, provider, body, payload.toPushEvent())
+}
+
+func (p *GiteaPushEvent) toPushEvent() *pushEvent {
+	push := &pushEvent{Project: p.Repository.FullName, DefaultBranch: p.Repository.DefaultBranch}
+	if branch, ok := branchFromRef(p.Ref); ok {
+		update := branchUpdate{Branch: branch, Before: p.Before, After: p.After}
+		if len(p.Commits) > 0 && p.TotalCommits <= len(p.Commits) {
+			update.Files = filesOfCommits(p.Commits)
+		}
+		push.Branches = append(push.Branches, update)
+	}
+	return push
+}
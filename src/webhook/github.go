@@ -5,8 +5,6 @@ import (
 	"io"
 	"net/http"
 
-	api "renovate-operator/api/v1alpha1"
-	"renovate-operator/internal/types"
 	"renovate-operator/metricStore"
 )
 
@@ -31,9 +29,20 @@ type GitHubIssue struct {
 }
 
 type GitHubRepository struct {
-	ID       int    `json:"id"`
-	Name     string `json:"name"`
-	FullName string `json:"full_name"`
+	ID            int    `json:"id"`
+	Name          string `json:"name"`
+	FullName      string `json:"full_name"`
+	DefaultBranch string `json:"default_branch"`
+}
+
+// GitHubPushEvent is the payload of the "push" event, which lists the files
+// touched by each pushed commit.
+type GitHubPushEvent struct {
+	Ref        string           `json:"ref"`
+	Before     string           `json:"before"`
+	After      string           `json:"after"`
+	Commits    []PushCommit     `json:"commits"`
+	Repository GitHubRepository `json:"repository"`
 }
 
 func (s *Server) githubWebhook(w http.ResponseWriter, r *http.Request) {
@@ -47,6 +56,11 @@ func (s *Server) githubWebhook(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if r.Header.Get("X-GitHub-Event") == "push" {
+		s.githubPushWebhook(w, r, body)
+		return
+	}
+
 	var payload GitHubEvent
 	if err := json.Unmarshal(body, &payload); err != nil {
 		metricStore.IncWebhookPayloadDecodeFailure(ctx, provider)
@@ -71,6 +85,9 @@ func (s *Server) githubWebhook(w http.ResponseWriter, r *http.Request) {
 	checker := buildAuthCheckerFromRequest(r, body, s.manager)
 	jobId, err := FindAndAuthenticateJob(ctx, s.manager, namespace, jobName, project, checker)
 	if err != nil {
+		if s.ignoreUnmanagedProject(w, r, provider, err) {
+			return
+		}
 		s.recordResolverAuthFailure(ctx, provider, err, signatureWasUsed(r))
 		metricStore.IncWebhookRequest(ctx, provider, "rejected")
 		s.logger.Info("webhook resolve failed", "project", project, "error", err)
@@ -79,15 +96,7 @@ func (s *Server) githubWebhook(w http.ResponseWriter, r *http.Request) {
 	}
 
 	s.logger.Info("received github event", "repository", project, "action", payload.Action, "priority", 1)
-	err = s.manager.UpdateProjectStatus(
-		ctx,
-		project,
-		jobId,
-		&types.RenovateStatusUpdate{
-			Status:   api.JobStatusScheduled,
-			Priority: 1,
-		},
-	)
+	err = s.scheduleProject(ctx, provider, project, jobId)
 	if s.handleUpdateProjectStatusError(w, err, project, jobId.Name, jobId.Namespace) {
 		metricStore.IncWebhookRequest(ctx, provider, "rejected")
 		return
@@ -127,3 +136,33 @@ func isValidGitHubEvent(payload *GitHubEvent) (bool, string) {
 	}
 	return true, ""
 }
+
+func (s *Server) githubPushWebhook(w http.ResponseWriter, r *http.Request, body []byte) {
+	ctx := r.Context()
+	const provider = "github"
+
+	var payload GitHubPushEvent
+	if err := json.Unmarshal(body, &payload); err != nil {
+		metricStore.IncWebhookPayloadDecodeFailure(ctx, provider)
+		metricStore.IncWebhookRequest(ctx, provider, "rejected")
+		s.logger.Error(err, "failed to decode github push payload. Not processing.")
+		s.writeJSON(w, http.StatusBadRequest, map[string]string{"error": "failed to decode payload"})
+		return
+	}
+
+	s.handlePush(w, r, provider, body, payload.toPushEvent())
+}
+
+func (p *GitHubPushEvent) toPushEvent() *pushEvent {
+	push := &pushEvent{Project: p.Repository.FullName, DefaultBranch: p.Repository.DefaultBranch}
+	if branch, ok := branchFromRef(p.Ref); ok {
+		update := branchUpdate{Branch: branch, Before: p.Before, After: p.After}
+		// a push that only moves the branch back lists no commits; the
+		// comparison then has to come from the API
+		if len(p.Commits) > 0 {
+			update.Files = filesOfCommits(p.Commits)
+		}
+		push.Branches = append(push.Branches, update)
+	}
+	return push
+}
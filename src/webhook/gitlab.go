@@ -5,8 +5,6 @@ import (
 	"io"
 	"net/http"
 
-	api "renovate-operator/api/v1alpha1"
-	"renovate-operator/internal/types"
 	"renovate-operator/metricStore"
 )
 
@@ -23,6 +21,18 @@ type Project struct {
 	Name              string `json:"name"`
 	Namespace         string `json:"namespace"`
 	PathWithNamespace string `json:"path_with_namespace"`
+	DefaultBranch     string `json:"default_branch"`
+}
+
+// GitLabPushEvent is the payload of a "Push Hook". GitLab lists at most 20
+// commits; total_commits_count tells whether the list is complete.
+type GitLabPushEvent struct {
+	Ref               string       `json:"ref"`
+	Before            string       `json:"before"`
+	After             string       `json:"after"`
+	Commits           []PushCommit `json:"commits"`
+	TotalCommitsCount int          `json:"total_commits_count"`
+	Project           Project      `json:"project"`
 }
 
 type ObjectAttributes struct {
@@ -59,6 +69,11 @@ func (s *Server) gitLabWebhook(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if payload.ObjectKind == "push" {
+		s.gitLabPushWebhook(w, r, body)
+		return
+	}
+
 	valid, reason := isValidGitLabEvent(&payload)
 	if !valid {
 		metricStore.IncWebhookRequest(ctx, provider, "ignored")
@@ -74,6 +89,9 @@ func (s *Server) gitLabWebhook(w http.ResponseWriter, r *http.Request) {
 	checker := buildAuthCheckerFromRequest(r, body, s.manager)
 	jobId, err := FindAndAuthenticateJob(ctx, s.manager, namespace, jobName, project, checker)
 	if err != nil {
+		if s.ignoreUnmanagedProject(w, r, provider, err) {
+			return
+		}
 		s.recordResolverAuthFailure(ctx, provider, err, signatureWasUsed(r))
 		metricStore.IncWebhookRequest(ctx, provider, "rejected")
 		s.logger.Info("webhook resolve failed", "project", project, "error", err)
@@ -82,15 +100,7 @@ func (s *Server) gitLabWebhook(w http.ResponseWriter, r *http.Request) {
 	}
 
 	s.logger.Info("received GitLab event", "repository", project, "action", payload.ObjectAttributes.Action, "priority", 1)
-	err = s.manager.UpdateProjectStatus(
-		ctx,
-		project,
-		jobId,
-		&types.RenovateStatusUpdate{
-			Status:   api.JobStatusScheduled,
-			Priority: 1,
-		},
-	)
+	err = s.scheduleProject(ctx, provider, project, jobId)
 	if s.handleUpdateProjectStatusError(w, err, project, jobId.Name, jobId.Namespace) {
 		metricStore.IncWebhookRequest(ctx, provider, "rejected")
 		return
@@ -122,3 +132,31 @@ func isValidGitLabEvent(payload *GitLabEvent) (bool, string) {
 	}
 	return true, ""
 }
+
+func (s *Server) gitLabPushWebhook(w http.ResponseWriter, r *http.Request, body []byte) {
+	ctx := r.Context()
+	const provider = "gitlab"
+
+	var payload GitLabPushEvent
+	if err := json.Unmarshal(body, &payload); err != nil {
+		metricStore.IncWebhookPayloadDecodeFailure(ctx, provider)
+		metricStore.IncWebhookRequest(ctx, provider, "rejected")
+		s.logger.Error(err, "failed to decode gitlab push payload. Not processing.")
+		s.writeJSON(w, http.StatusBadRequest, map[string]string{"error": "failed to decode payload"})
+		return
+	}
+
+	s.handlePush(w, r, provider, body, payload.toPushEvent())
+}
+
+func (p *GitLabPushEvent) toPushEvent() *pushEvent {
+	push := &pushEvent{Project: p.Project.PathWithNamespace, DefaultBranch: p.Project.DefaultBranch}
+	if branch, ok := branchFromRef(p.Ref); ok {
+		update := branchUpdate{Branch: branch, Before: p.Before, After: p.After}
+		if len(p.Commits) > 0 && p.TotalCommitsCount <= len(p.Commits) {
+			update.Files = filesOfCommits(p.Commits)
+		}
+		push.Branches = append(push.Branches, update)
+	}
+	return push
+}
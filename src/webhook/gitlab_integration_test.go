@@ -356,6 +356,54 @@ func TestGitLabWebhook_NoMatchingJob(t *testing.T) {
 	}
 }
 
+// A group hook receives the events of every project of the group, so events
+// of projects the job does not manage are ignored rather than rejected, which
+// would get the hook disabled. Authentication is still enforced.
+func TestGitLabWebhook_OrganizationHookIgnoresUnmanagedProjects(t *testing.T) {
+	job := makeTestRenovateJob("renovate", "job1", "group/managed")
+	job.Spec.Webhook.Authentication = &api.RenovateWebhookAuth{Enabled: true}
+	server := &Server{
+		manager: &mockWebhookManager{
+			listRenovateJobsFullFunc: func(ctx context.Context) ([]api.RenovateJob, error) {
+				return []api.RenovateJob{job}, nil
+			},
+			isWebhookTokenValidFunc: func(ctx context.Context, _ crdmanager.RenovateJobIdentifier, token string) (bool, error) {
+				return token == "valid-token", nil
+			},
+		},
+		logger: logr.Discard(),
+	}
+
+	deliver := func(project, target string) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(GitLabEvent{
+			ObjectKind:       "merge_request",
+			ObjectAttributes: ObjectAttributes{Action: "update"},
+			Project:          Project{PathWithNamespace: project},
+			Changes: Changes{Description: ChangeDescription{
+				Current: "- [x] <!-- rebase-check -->If you want to rebase/retry this MR, check this box",
+			}},
+		})
+		req := httptest.NewRequest(http.MethodPost, target, bytes.NewReader(body))
+		req.Header.Set("X-Gitlab-Token", "wrong-token")
+		w := httptest.NewRecorder()
+		server.gitLabWebhook(w, req)
+		return w
+	}
+
+	w := deliver("group/other", "/webhook/v1/gitlab?namespace=renovate&job=job1&scope=organization")
+	var response map[string]string
+	_ = json.Unmarshal(w.Body.Bytes(), &response)
+	if w.Code != http.StatusOK || response["reason"] == "" {
+		t.Errorf("expected the event to be ignored, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := deliver("group/other", "/webhook/v1/gitlab?namespace=renovate&job=job1"); w.Code != http.StatusNotFound {
+		t.Errorf("expected a project hook delivery to be rejected, got %d", w.Code)
+	}
+	if w := deliver("group/managed", "/webhook/v1/gitlab?namespace=renovate&job=job1&scope=organization"); w.Code != http.StatusUnauthorized {
+		t.Errorf("expected authentication to be enforced, got %d", w.Code)
+	}
+}
+
 func TestGitLabWebhook_InvalidJSON(t *testing.T) {
 	mockManager := &mockWebhookManager{}
 	server := &Server{
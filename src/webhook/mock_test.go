@@ -19,6 +19,9 @@ type mockWebhookManager struct {
 	isWebhookTokenValidFunc             func(ctx context.Context, job crdmanager.RenovateJobIdentifier, token string) (bool, error)
 	isWebhookSignatureValidFunc         func(ctx context.Context, job crdmanager.RenovateJobIdentifier, signature string, body []byte) (bool, error)
 	isWebhookStandardSignatureValidFunc func(ctx context.Context, job crdmanager.RenovateJobIdentifier, msgID, timestamp, signature string, body []byte) (bool, error)
+	getRenovateJobFunc                  func(ctx context.Context, name, namespace string) (*api.RenovateJob, error)
+	getDefaultBranchFunc                func(ctx context.Context, job *api.RenovateJob, project string) (string, error)
+	listChangedFilesFunc                func(ctx context.Context, job *api.RenovateJob, project, base, head string) ([]string, error)
 }
 
 func (m *mockWebhookManager) ListRenovateJobsFull(ctx context.Context) ([]api.RenovateJob, error) {
@@ -63,6 +66,28 @@ func (m *mockWebhookManager) CancelProjectJob(ctx context.Context, project strin
 	return nil
 }
 
+func (m *mockWebhookManager) ApproveProjects(ctx context.Context, jobId crdmanager.RenovateJobIdentifier, projects []string) error {
+	return nil
+}
+
+func (m *mockWebhookManager) DiscoverProjects(ctx context.Context, job *api.RenovateJob) ([]string, error) {
+	return nil, nil
+}
+
+func (m *mockWebhookManager) GetDefaultBranch(ctx context.Context, job *api.RenovateJob, project string) (string, error) {
+	if m.getDefaultBranchFunc != nil {
+		return m.getDefaultBranchFunc(ctx, job, project)
+	}
+	return "", nil
+}
+
+func (m *mockWebhookManager) ListChangedFiles(ctx context.Context, job *api.RenovateJob, project, base, head string) ([]string, error) {
+	if m.listChangedFilesFunc != nil {
+		return m.listChangedFilesFunc(ctx, job, project, base, head)
+	}
+	return nil, nil
+}
+
 func (m *mockWebhookManager) ListRenovateJobs(ctx context.Context) ([]crdmanager.RenovateJobIdentifier, error) {
 	return nil, nil
 }
@@ -75,6 +100,9 @@ func (m *mockWebhookManager) StreamLogsForProject(ctx context.Context, jobId crd
 }
 
 func (m *mockWebhookManager) GetRenovateJob(ctx context.Context, name, namespace string) (*api.RenovateJob, error) {
+	if m.getRenovateJobFunc != nil {
+		return m.getRenovateJobFunc(ctx, name, namespace)
+	}
 	return nil, nil
 }
 
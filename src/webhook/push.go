@@ -0,0 +1,264 @@
+package webhook
+
+import (
+	"context"
+	"net/http"
+	"path"
+	"slices"
+	"strings"
+
+	api "renovate-operator/api/v1alpha1"
+	"renovate-operator/internal/utils"
+	"renovate-operator/metricStore"
+)
+
+// Push trigger.
+//
+// A push to a project's default branch schedules the project when it touches
+// one of Renovate's config files or a file matching spec.webhook.push.paths,
+// so config changes and manual dependency bumps are picked up without waiting
+// for the next cron run. Each provider handler converts its push payload into
+// a pushEvent and passes it to handlePush.
+//
+// Not every payload is complete: Bitbucket Cloud and Bitbucket Server leave
+// out the default branch, Bitbucket and Azure DevOps leave out the changed
+// files, and GitLab and Gitea/Forgejo cap the commits they list. The missing
+// parts are looked up through the platform API, and only once the delivery is
+// authenticated and the job has opted in.
+
+// renovateConfigFileNames are the repository config files Renovate reads
+// (Renovate's configFileNames). A push touching one always triggers a run.
+var renovateConfigFileNames = []string{
+	"renovate.json",
+	"renovate.json5",
+	".github/renovate.json",
+	".github/renovate.json5",
+	".gitlab/renovate.json",
+	".gitlab/renovate.json5",
+	".gitea/renovate.json",
+	".gitea/renovate.json5",
+	".forgejo/renovate.json",
+	".forgejo/renovate.json5",
+	".renovaterc",
+	".renovaterc.json",
+	".renovaterc.json5",
+}
+
+// pushEvent is the platform-neutral form of a push delivery.
+type pushEvent struct {
+	Project string
+	// DefaultBranch of the project; empty when the payload does not carry it.
+	DefaultBranch string
+	Branches      []branchUpdate
+}
+
+// branchUpdate is one branch moved by a push. Tags are not included.
+type branchUpdate struct {
+	// Branch name without the refs/heads/ prefix.
+	Branch string
+	// Before and After are the commits the branch moved between. Before is
+	// empty or all zeros for a new branch, After for a deleted one.
+	Before string
+	After  string
+	// Files changed by the push; nil when the payload does not list them all.
+	Files []string
+}
+
+// PushCommit is a commit as listed in GitHub, GitLab, Gitea and Forgejo push
+// payloads.
+type PushCommit struct {
+	Added    []string `json:"added"`
+	Modified []string `json:"modified"`
+	Removed  []string `json:"removed"`
+}
+
+// filesOfCommits returns the files touched by the given commits.
+func filesOfCommits(commits []PushCommit) []string {
+	files := []string{}
+	for _, commit := range commits {
+		files = append(files, commit.Added...)
+		files = append(files, commit.Modified...)
+		files = append(files, commit.Removed...)
+	}
+	return files
+}
+
+// branchFromRef returns the branch name of a full ref, or false for tags and
+// other refs.
+func branchFromRef(ref string) (string, bool) {
+	branch, ok := strings.CutPrefix(ref, "refs/heads/")
+	return branch, ok && branch != ""
+}
+
+// isNullCommit reports whether a commit ID denotes "no commit", which the
+// platforms send as all zeros (or leave out) for created and deleted refs.
+func isNullCommit(sha string) bool {
+	return strings.Trim(sha, "0") == ""
+}
+
+// pushPathMatcher decides whether a changed file triggers a run.
+type pushPathMatcher struct {
+	// patterns containing a slash, matched against the full path
+	paths *utils.RepositoryMatcher
+	// patterns without one, matched against the file name
+	fileNames *utils.RepositoryMatcher
+}
+
+func newPushPathMatcher(patterns []string) (*pushPathMatcher, error) {
+	var paths, fileNames []string
+	for _, pattern := range patterns {
+		if strings.Contains(pattern, "/") {
+			paths = append(paths, pattern)
+		} else {
+			fileNames = append(fileNames, pattern)
+		}
+	}
+	pathMatcher, pathErr := utils.NewRepositoryMatcher(paths)
+	fileNameMatcher, fileNameErr := utils.NewRepositoryMatcher(fileNames)
+	m := &pushPathMatcher{paths: pathMatcher, fileNames: fileNameMatcher}
+	if pathErr != nil {
+		return m, pathErr
+	}
+	return m, fileNameErr
+}
+
+func (m *pushPathMatcher) Matches(file string) bool {
+	if slices.Contains(renovateConfigFileNames, file) {
+		return true
+	}
+	return m.paths.Matches(file) || m.fileNames.Matches(path.Base(file))
+}
+
+// handlePush runs the push trigger for a decoded push delivery and writes the
+// response.
+func (s *Server) handlePush(w http.ResponseWriter, r *http.Request, provider string, body []byte, push *pushEvent) {
+	ctx := r.Context()
+
+	valid, reason := isValidPushEvent(push)
+	if !valid {
+		metricStore.IncWebhookRequest(ctx, provider, "ignored")
+		s.logger.Info("ignoring push webhook event", "provider", provider, "repository", push.Project, "reason", reason)
+		s.writeJSON(w, http.StatusOK, map[string]string{"message": "event ignored", "reason": reason})
+		return
+	}
+
+	namespace := r.URL.Query().Get("namespace")
+	jobName := r.URL.Query().Get("job")
+	project := push.Project
+
+	checker := buildAuthCheckerFromRequest(r, body, s.manager)
+	jobId, err := FindAndAuthenticateJob(ctx, s.manager, namespace, jobName, project, checker)
+	if err != nil {
+		if s.ignoreUnmanagedProject(w, r, provider, err) {
+			return
+		}
+		s.recordResolverAuthFailure(ctx, provider, err, signatureWasUsed(r))
+		metricStore.IncWebhookRequest(ctx, provider, "rejected")
+		s.logger.Info("webhook resolve failed", "event", "push", "project", project, "error", err)
+		s.handleResolverError(w, err)
+		return
+	}
+
+	job, err := s.manager.GetRenovateJob(ctx, jobId.Name, jobId.Namespace)
+	if err != nil {
+		metricStore.IncWebhookRequest(ctx, provider, "rejected")
+		s.logger.Error(err, "failed to load renovate job for push webhook", "renovateJob", jobId.Name, "namespace", jobId.Namespace)
+		s.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to process webhook"})
+		return
+	}
+
+	triggered, reason, err := s.pushTriggersRun(ctx, job, push)
+	if err != nil {
+		metricStore.IncWebhookRequest(ctx, provider, "rejected")
+		s.logger.Error(err, "failed to look up push details on the platform", "project", project, "renovateJob", jobId.Name, "namespace", jobId.Namespace)
+		s.writeJSON(w, http.StatusBadGateway, map[string]string{"error": "failed to look up push details on the platform"})
+		return
+	}
+	if !triggered {
+		metricStore.IncWebhookRequest(ctx, provider, "ignored")
+		s.logger.Info("ignoring push webhook event", "provider", provider, "repository", project, "reason", reason)
+		s.writeJSON(w, http.StatusOK, map[string]string{"message": "event ignored", "reason": reason})
+		return
+	}
+
+	s.logger.Info("received push event", "provider", provider, "repository", project, "priority", 1)
+	err = s.scheduleProject(ctx, provider, project, jobId)
+	if s.handleUpdateProjectStatusError(w, err, project, jobId.Name, jobId.Namespace) {
+		metricStore.IncWebhookRequest(ctx, provider, "rejected")
+		return
+	}
+
+	metricStore.IncWebhookRequest(ctx, provider, "accepted")
+	s.writeJSON(w, http.StatusAccepted, map[string]string{"message": "renovate job scheduled", "repository": project})
+}
+
+// isValidPushEvent does the checks that need nothing but the payload, before
+// the delivery is authenticated. Deleted branches are dropped from push.
+func isValidPushEvent(push *pushEvent) (bool, string) {
+	if push.Project == "" {
+		return false, "no repository in payload"
+	}
+	push.Branches = slices.DeleteFunc(push.Branches, func(b branchUpdate) bool {
+		return isNullCommit(b.After)
+	})
+	if len(push.Branches) == 0 {
+		return false, "no branch updated"
+	}
+	if push.DefaultBranch != "" && defaultBranchUpdate(push) == nil {
+		return false, "not a push to the default branch"
+	}
+	return true, ""
+}
+
+// defaultBranchUpdate returns the update of the default branch, or nil.
+func defaultBranchUpdate(push *pushEvent) *branchUpdate {
+	for i := range push.Branches {
+		if push.Branches[i].Branch == push.DefaultBranch {
+			return &push.Branches[i]
+		}
+	}
+	return nil
+}
+
+// pushTriggersRun decides whether an authenticated push schedules the job's
+// project, completing the payload through the platform API where needed. The
+// returned error reports a failed platform lookup.
+func (s *Server) pushTriggersRun(ctx context.Context, job *api.RenovateJob, push *pushEvent) (bool, string, error) {
+	if job.Spec.Webhook == nil || job.Spec.Webhook.Push == nil || !job.Spec.Webhook.Push.Enabled {
+		return false, "push trigger is not enabled", nil
+	}
+
+	if push.DefaultBranch == "" {
+		defaultBranch, err := s.manager.GetDefaultBranch(ctx, job, push.Project)
+		if err != nil {
+			return false, "", err
+		}
+		push.DefaultBranch = defaultBranch
+	}
+	update := defaultBranchUpdate(push)
+	if update == nil {
+		return false, "not a push to the default branch", nil
+	}
+
+	files := update.Files
+	if files == nil {
+		if isNullCommit(update.Before) {
+			// a newly created default branch has nothing to compare to
+			return true, "", nil
+		}
+		changed, err := s.manager.ListChangedFiles(ctx, job, push.Project, update.Before, update.After)
+		if err != nil {
+			return false, "", err
+		}
+		files = changed
+	}
+
+	matcher, err := newPushPathMatcher(job.Spec.Webhook.Push.Paths)
+	if err != nil {
+		s.logger.Error(err, "Ignoring invalid webhook.push.paths entries", "job", job.Fullname())
+	}
+	if !slices.ContainsFunc(files, matcher.Matches) {
+		return false, "push does not touch the Renovate config or a package file", nil
+	}
+	return true, "", nil
+}
@@ -0,0 +1,322 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	api "renovate-operator/api/v1alpha1"
+	crdmanager "renovate-operator/internal/crdManager"
+	"renovate-operator/internal/types"
+
+	"github.com/go-logr/logr"
+)
+
+const (
+	commitA = "1111111111111111111111111111111111111111"
+	commitB = "2222222222222222222222222222222222222222"
+	nullSHA = "0000000000000000000000000000000000000000"
+)
+
+// pushTestManager serves one job owning project with the push trigger
+// enabled for the given paths, and records the scheduled project.
+func pushTestManager(project string, push *api.RenovateWebhookPush, scheduled *string) *mockWebhookManager {
+	job := makeTestRenovateJob("renovate", "job1", project)
+	job.Spec.Webhook.Push = push
+	return &mockWebhookManager{
+		listRenovateJobsFullFunc: func(ctx context.Context) ([]api.RenovateJob, error) {
+			return []api.RenovateJob{job}, nil
+		},
+		getRenovateJobFunc: func(ctx context.Context, name, namespace string) (*api.RenovateJob, error) {
+			return &job, nil
+		},
+		updateProjectStatusFunc: func(ctx context.Context, project string, jobId crdmanager.RenovateJobIdentifier, status *types.RenovateStatusUpdate) error {
+			*scheduled = project
+			return nil
+		},
+	}
+}
+
+func TestPushPathMatcher(t *testing.T) {
+	matcher, err := newPushPathMatcher([]string{"package.json", "**/go.mod", "/^charts/.*\\.lock$/"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		file  string
+		match bool
+	}{
+		{"renovate.json", true},
+		{".github/renovate.json5", true},
+		{".renovaterc", true},
+		{"docs/renovate.json", false},
+		// a pattern without a slash matches the file name in any directory
+		{"package.json", true},
+		{"apps/web/package.json", true},
+		{"apps/web/package-lock.json", false},
+		{"services/api/go.mod", true},
+		{"charts/app/Chart.lock", true},
+		{"README.md", false},
+	}
+	for _, tt := range tests {
+		if got := matcher.Matches(tt.file); got != tt.match {
+			t.Errorf("Matches(%q) = %v, want %v", tt.file, got, tt.match)
+		}
+	}
+
+	if _, err := newPushPathMatcher([]string{"/[/"}); err == nil {
+		t.Error("expected an error for an invalid regular expression")
+	}
+}
+
+func TestIsValidPushEvent(t *testing.T) {
+	tests := []struct {
+		name  string
+		push  pushEvent
+		valid bool
+	}{
+		{
+			name:  "push to the default branch",
+			push:  pushEvent{Project: "org/repo", DefaultBranch: "main", Branches: []branchUpdate{{Branch: "main", Before: commitA, After: commitB}}},
+			valid: true,
+		},
+		{
+			name:  "push to another branch",
+			push:  pushEvent{Project: "org/repo", DefaultBranch: "main", Branches: []branchUpdate{{Branch: "renovate/foo", Before: commitA, After: commitB}}},
+			valid: false,
+		},
+		{
+			name:  "default branch unknown until looked up",
+			push:  pushEvent{Project: "org/repo", Branches: []branchUpdate{{Branch: "develop", Before: commitA, After: commitB}}},
+			valid: true,
+		},
+		{
+			name:  "deleted branch",
+			push:  pushEvent{Project: "org/repo", DefaultBranch: "main", Branches: []branchUpdate{{Branch: "main", Before: commitA, After: nullSHA}}},
+			valid: false,
+		},
+		{
+			name:  "tag push",
+			push:  pushEvent{Project: "org/repo", DefaultBranch: "main"},
+			valid: false,
+		},
+		{
+			name:  "missing repository",
+			push:  pushEvent{DefaultBranch: "main", Branches: []branchUpdate{{Branch: "main", Before: commitA, After: commitB}}},
+			valid: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			valid, reason := isValidPushEvent(&tt.push)
+			if valid != tt.valid {
+				t.Errorf("expected valid=%v, got %v (reason: %s)", tt.valid, valid, reason)
+			}
+		})
+	}
+}
+
+func TestGitHubPushWebhook(t *testing.T) {
+	tests := []struct {
+		name     string
+		push     *api.RenovateWebhookPush
+		body     string
+		wantCode int
+	}{
+		{
+			name:     "renovate config changed",
+			push:     &api.RenovateWebhookPush{Enabled: true},
+			body:     `{"ref": "refs/heads/main", "before": "` + commitA + `", "after": "` + commitB + `", "repository": {"full_name": "org/repo", "default_branch": "main"}, "commits": [{"modified": ["renovate.json"]}]}`,
+			wantCode: http.StatusAccepted,
+		},
+		{
+			name:     "configured manifest changed",
+			push:     &api.RenovateWebhookPush{Enabled: true, Paths: []string{"go.mod"}},
+			body:     `{"ref": "refs/heads/main", "before": "` + commitA + `", "after": "` + commitB + `", "repository": {"full_name": "org/repo", "default_branch": "main"}, "commits": [{"added": ["README.md"]}, {"modified": ["tools/go.mod"]}]}`,
+			wantCode: http.StatusAccepted,
+		},
+		{
+			name:     "unrelated files changed",
+			push:     &api.RenovateWebhookPush{Enabled: true, Paths: []string{"go.mod"}},
+			body:     `{"ref": "refs/heads/main", "before": "` + commitA + `", "after": "` + commitB + `", "repository": {"full_name": "org/repo", "default_branch": "main"}, "commits": [{"modified": ["main.go"]}]}`,
+			wantCode: http.StatusOK,
+		},
+		{
+			name:     "push trigger not enabled",
+			push:     nil,
+			body:     `{"ref": "refs/heads/main", "before": "` + commitA + `", "after": "` + commitB + `", "repository": {"full_name": "org/repo", "default_branch": "main"}, "commits": [{"modified": ["renovate.json"]}]}`,
+			wantCode: http.StatusOK,
+		},
+		{
+			name:     "push to a feature branch",
+			push:     &api.RenovateWebhookPush{Enabled: true},
+			body:     `{"ref": "refs/heads/feature", "before": "` + commitA + `", "after": "` + commitB + `", "repository": {"full_name": "org/repo", "default_branch": "main"}, "commits": [{"modified": ["renovate.json"]}]}`,
+			wantCode: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var scheduled string
+			server := &Server{manager: pushTestManager("org/repo", tt.push, &scheduled), logger: logr.Discard()}
+
+			req := httptest.NewRequest(http.MethodPost, "/webhook/v1/github", bytes.NewReader([]byte(tt.body)))
+			req.Header.Set("X-GitHub-Event", "push")
+			w := httptest.NewRecorder()
+			server.githubWebhook(w, req)
+
+			if w.Code != tt.wantCode {
+				t.Fatalf("expected status %d, got %d: %s", tt.wantCode, w.Code, w.Body.String())
+			}
+			if wantScheduled := tt.wantCode == http.StatusAccepted; (scheduled != "") != wantScheduled {
+				t.Errorf("expected scheduled=%v, got %q", wantScheduled, scheduled)
+			}
+		})
+	}
+}
+
+func TestGitLabPushWebhook_TruncatedCommitsAreCompared(t *testing.T) {
+	var scheduled string
+	mockManager := pushTestManager("group/repo", &api.RenovateWebhookPush{Enabled: true}, &scheduled)
+	var compared []string
+	mockManager.listChangedFilesFunc = func(ctx context.Context, job *api.RenovateJob, project, base, head string) ([]string, error) {
+		compared = []string{project, base, head}
+		return []string{"renovate.json"}, nil
+	}
+	server := &Server{manager: mockManager, logger: logr.Discard()}
+
+	// GitLab lists at most 20 commits, which may miss the config change
+	body := `{"object_kind": "push", "ref": "refs/heads/main", "before": "` + commitA + `", "after": "` + commitB + `", "total_commits_count": 25, "commits": [{"modified": ["main.go"]}], "project": {"path_with_namespace": "group/repo", "default_branch": "main"}}`
+	req := httptest.NewRequest(http.MethodPost, "/webhook/v1/gitlab", bytes.NewReader([]byte(body)))
+	w := httptest.NewRecorder()
+	server.gitLabWebhook(w, req)
+
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusAccepted, w.Code, w.Body.String())
+	}
+	if !slices.Equal(compared, []string{"group/repo", commitA, commitB}) {
+		t.Errorf("expected the push to be compared through the API, got %v", compared)
+	}
+	if scheduled != "group/repo" {
+		t.Errorf("expected group/repo to be scheduled, got %q", scheduled)
+	}
+}
+
+func TestBitbucketServerPushWebhook_LooksUpDefaultBranchAndFiles(t *testing.T) {
+	var scheduled string
+	mockManager := pushTestManager("PRJ/repo", &api.RenovateWebhookPush{Enabled: true, Paths: []string{"pom.xml"}}, &scheduled)
+	mockManager.getDefaultBranchFunc = func(ctx context.Context, job *api.RenovateJob, project string) (string, error) {
+		return "develop", nil
+	}
+	mockManager.listChangedFilesFunc = func(ctx context.Context, job *api.RenovateJob, project, base, head string) ([]string, error) {
+		if base != commitA || head != commitB {
+			t.Errorf("expected the develop update to be compared, got %s..%s", base, head)
+		}
+		return []string{"service/pom.xml"}, nil
+	}
+	server := &Server{manager: mockManager, logger: logr.Discard()}
+
+	body := `{"repository": {"slug": "repo", "project": {"key": "PRJ"}}, "changes": [
+		{"ref": {"id": "refs/heads/feature", "type": "BRANCH"}, "fromHash": "` + commitB + `", "toHash": "` + commitA + `"},
+		{"ref": {"id": "refs/heads/develop", "type": "BRANCH"}, "fromHash": "` + commitA + `", "toHash": "` + commitB + `"}
+	]}`
+	req := httptest.NewRequest(http.MethodPost, "/webhook/v1/bitbucket-server", bytes.NewReader([]byte(body)))
+	req.Header.Set("X-Event-Key", "repo:refs_changed")
+	w := httptest.NewRecorder()
+	server.bitbucketServerWebhook(w, req)
+
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusAccepted, w.Code, w.Body.String())
+	}
+	if scheduled != "PRJ/repo" {
+		t.Errorf("expected PRJ/repo to be scheduled, got %q", scheduled)
+	}
+}
+
+func TestBitbucketPushWebhook_PlatformLookupFailure(t *testing.T) {
+	var scheduled string
+	mockManager := pushTestManager("ws/repo", &api.RenovateWebhookPush{Enabled: true}, &scheduled)
+	mockManager.getDefaultBranchFunc = func(ctx context.Context, job *api.RenovateJob, project string) (string, error) {
+		return "", errors.New("platform unavailable")
+	}
+	server := &Server{manager: mockManager, logger: logr.Discard()}
+
+	body := `{"repository": {"full_name": "ws/repo"}, "push": {"changes": [{"old": {"type": "branch", "name": "main", "target": {"hash": "` + commitA + `"}}, "new": {"type": "branch", "name": "main", "target": {"hash": "` + commitB + `"}}}]}}`
+	req := httptest.NewRequest(http.MethodPost, "/webhook/v1/bitbucket", bytes.NewReader([]byte(body)))
+	req.Header.Set("X-Event-Key", "repo:push")
+	w := httptest.NewRecorder()
+	server.bitbucketWebhook(w, req)
+
+	if w.Code != http.StatusBadGateway {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusBadGateway, w.Code, w.Body.String())
+	}
+	if scheduled != "" {
+		t.Errorf("expected nothing to be scheduled, got %q", scheduled)
+	}
+}
+
+func TestPushWebhook_IgnoredBeforeAuthentication(t *testing.T) {
+	listed := false
+	mockManager := &mockWebhookManager{
+		listRenovateJobsFullFunc: func(ctx context.Context) ([]api.RenovateJob, error) {
+			listed = true
+			return nil, nil
+		},
+	}
+	server := &Server{manager: mockManager, logger: logr.Discard()}
+
+	body := `{"ref": "refs/heads/feature", "before": "` + commitA + `", "after": "` + commitB + `", "repository": {"full_name": "org/repo", "default_branch": "main"}}`
+	req := httptest.NewRequest(http.MethodPost, "/webhook/v1/gitea", bytes.NewReader([]byte(body)))
+	req.Header.Set("X-Gitea-Event", "push")
+	w := httptest.NewRecorder()
+	server.giteaWebhook(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	if listed {
+		t.Error("expected a push to another branch to be ignored without resolving the job")
+	}
+}
+
+func TestPushEventConversion(t *testing.T) {
+	t.Run("forgejo new default branch", func(t *testing.T) {
+		payload := ForgejoPushEvent{Ref: "refs/heads/main", Before: nullSHA, After: commitB, TotalCommits: 1, Commits: []PushCommit{{Added: []string{"renovate.json"}}}}
+		payload.Repository.FullName = "org/repo"
+		payload.Repository.DefaultBranch = "main"
+
+		push := payload.toPushEvent()
+		if len(push.Branches) != 1 || !slices.Equal(push.Branches[0].Files, []string{"renovate.json"}) {
+			t.Errorf("unexpected push: %+v", push)
+		}
+	})
+
+	t.Run("azure tag push", func(t *testing.T) {
+		resource := &AzurePushResource{RefUpdates: []AzureRefUpdate{{Name: "refs/tags/v1.0.0", OldObjectID: nullSHA, NewObjectID: commitB}}}
+		resource.Repository.Name = "repo"
+		resource.Repository.Project.Name = "proj"
+		resource.Repository.DefaultBranch = "refs/heads/main"
+		payload := AzurePushEvent{Resource: resource}
+
+		push := payload.toPushEvent()
+		if push.Project != "proj/repo" || push.DefaultBranch != "main" || len(push.Branches) != 0 {
+			t.Errorf("unexpected push: %+v", push)
+		}
+	})
+
+	t.Run("bitbucket deleted branch", func(t *testing.T) {
+		payload := BitbucketPushEvent{}
+		payload.Repository.FullName = "ws/repo"
+		payload.Push.Changes = []BitbucketPushChange{{Old: &BitbucketRef{Type: "branch", Name: "old"}}}
+
+		if push := payload.toPushEvent(); len(push.Branches) != 0 {
+			t.Errorf("expected the deleted branch to be dropped, got %+v", push.Branches)
+		}
+	})
+}
@@ -53,13 +53,17 @@ func FindAndAuthenticateJob(
 
 	candidates := filterCandidates(jobs, namespace, jobName, project)
 	if len(candidates) == 0 {
+		noteResolution(ctx, project, nil, nil)
 		return crdmanager.RenovateJobIdentifier{}, ErrNoMatchingJob
 	}
 
+	ids := make([]crdmanager.RenovateJobIdentifier, 0, len(candidates))
 	for _, job := range candidates {
 		id := crdmanager.RenovateJobIdentifier{Name: job.Name, Namespace: job.Namespace}
+		ids = append(ids, id)
 
 		if job.Spec.Webhook.Authentication == nil || !job.Spec.Webhook.Authentication.Enabled {
+			noteResolution(ctx, project, &id, nil)
 			return id, nil
 		}
 		if checker == nil {
@@ -69,12 +73,55 @@ func FindAndAuthenticateJob(
 		if err != nil || !ok {
 			continue
 		}
+		noteResolution(ctx, project, &id, nil)
 		return id, nil
 	}
 
+	noteResolution(ctx, project, nil, ids)
 	return crdmanager.RenovateJobIdentifier{}, ErrAuthenticationFailed
 }
 
+// AuthenticateJob authenticates a request that addresses the RenovateJob
+// namespace/jobName itself rather than one of its projects.
+//
+// Returns ErrNoMatchingJob when the job does not exist or does not receive webhooks,
+// ErrAuthenticationFailed when it requires authentication and checker does not pass.
+func AuthenticateJob(
+	ctx context.Context,
+	manager jobLister,
+	namespace string,
+	jobName string,
+	checker AuthChecker,
+) (*api.RenovateJob, error) {
+	jobs, err := manager.ListRenovateJobsFull(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range jobs {
+		job := &jobs[i]
+		if job.Namespace != namespace || job.Name != jobName || job.Spec.Webhook == nil || !job.Spec.Webhook.Enabled {
+			continue
+		}
+		id := crdmanager.RenovateJobIdentifier{Name: job.Name, Namespace: job.Namespace}
+		if job.Spec.Webhook.Authentication == nil || !job.Spec.Webhook.Authentication.Enabled {
+			noteResolution(ctx, "", &id, nil)
+			return job, nil
+		}
+		if checker != nil {
+			if ok, err := checker(ctx, id); err == nil && ok {
+				noteResolution(ctx, "", &id, nil)
+				return job, nil
+			}
+		}
+		noteResolution(ctx, "", nil, []crdmanager.RenovateJobIdentifier{id})
+		return nil, ErrAuthenticationFailed
+	}
+
+	noteResolution(ctx, "", nil, nil)
+	return nil, ErrNoMatchingJob
+}
+
 func filterCandidates(jobs []api.RenovateJob, namespace, jobName, project string) []api.RenovateJob {
 	out := make([]api.RenovateJob, 0, len(jobs))
 	for _, job := range jobs {
@@ -113,12 +160,25 @@ func signatureWasUsed(r *http.Request) bool {
 	if r.Header.Get("Authorization") != "" || r.Header.Get("X-Gitlab-Token") != "" {
 		return false
 	}
-	return r.Header.Get("X-Hub-Signature-256") != "" || r.Header.Get("X-Forgejo-Signature") != ""
+	return r.Header.Get("X-Hub-Signature-256") != "" ||
+		r.Header.Get("X-Hub-Signature") != "" ||
+		r.Header.Get("X-Forgejo-Signature") != "" ||
+		r.Header.Get("X-Gitea-Signature") != ""
 }
 
 // buildAuthCheckerFromRequest extracts auth credentials from request headers and returns
 // an AuthChecker that validates them against a RenovateJob. Returns nil if no credential is present.
+//
+// A replayed delivery carries no credentials (they are redacted in the delivery log); it is
+// accepted for the job the replay was requested for and for no other.
 func buildAuthCheckerFromRequest(r *http.Request, body []byte, manager credentialValidator) AuthChecker {
+	if replay := replayFromContext(r.Context()); replay != nil {
+		job := replay.job
+		return func(ctx context.Context, jobId crdmanager.RenovateJobIdentifier) (bool, error) {
+			return jobId == job, nil
+		}
+	}
+
 	// Standard Webhooks signature (https://www.standardwebhooks.com/) — a vendor-neutral scheme used by
 	// GitLab signing tokens among others; prefer the cryptographic signature when present.
 	if sig := r.Header.Get("Webhook-Signature"); sig != "" {
@@ -7,30 +7,68 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"sync"
+	"time"
 
 	api "renovate-operator/api/v1alpha1"
 	"renovate-operator/assert"
 	"renovate-operator/config"
+	"renovate-operator/internal/audit"
 	crdmanager "renovate-operator/internal/crdManager"
+	"renovate-operator/internal/deliveryLog"
+	"renovate-operator/internal/renovate"
 	"renovate-operator/internal/telemetry"
 	"renovate-operator/internal/types"
+	"renovate-operator/internal/utils"
 	"renovate-operator/metricStore"
 
 	"github.com/go-logr/logr"
 	"github.com/gorilla/mux"
 )
 
+// webhookPriority is the priority of projects scheduled by webhooks: above
+// scheduled runs, below runs triggered from the UI.
+const webhookPriority int32 = 1
+
 type Server struct {
 	manager crdmanager.RenovateJobManager
 	logger  logr.Logger
 	server  *http.Server
+	// debouncer coalesces bursts of deliveries per project; nil schedules
+	// every delivery right away
+	debouncer *scheduleDebouncer
+	// deliveries records every delivery; nil disables the delivery log
+	deliveries deliveryLog.DeliveryLog
+	// discovery starts discovery runs for CloudEvents; nil answers them with
+	// 501 Not Implemented
+	discovery renovate.DiscoveryAgent
+	// audit records what deliveries did; nil disables it
+	audit audit.Sink
+
+	replayOnce   sync.Once
+	replayRouter http.Handler
 }
 
-func NewWebookServer(manager crdmanager.RenovateJobManager, logger logr.Logger) *Server {
-	return &Server{
-		manager: manager,
-		logger:  logger,
+// NewWebookServer creates the webhook server. A positive debounceWindow
+// coalesces the deliveries for a project that arrive within that window of
+// each other into one scheduling call (see debounce.go). A non-nil deliveries
+// records every delivery for the UI (see deliveries.go).
+func NewWebookServer(manager crdmanager.RenovateJobManager, logger logr.Logger, debounceWindow time.Duration, deliveries deliveryLog.DeliveryLog) *Server {
+	s := &Server{
+		manager:    manager,
+		logger:     logger,
+		deliveries: deliveries,
 	}
+	if debounceWindow > 0 {
+		s.debouncer = newScheduleDebouncer(debounceWindow, s.updateProjectSchedule, logger.WithName("debounce"))
+		s.debouncer.scheduled = s.recordDebounced
+	}
+	return s
+}
+
+// SetDiscovery lets CloudEvents start discovery runs (see cloudEvents.go).
+func (s *Server) SetDiscovery(discovery renovate.DiscoveryAgent) {
+	s.discovery = discovery
 }
 
 func RegisterWebhookRoutes(router *mux.Router, server *Server) {
@@ -38,12 +76,16 @@ func RegisterWebhookRoutes(router *mux.Router, server *Server) {
 
 	sub := router.PathPrefix("/webhook/v1").Subrouter()
 	sub.Use(telemetry.MuxMiddleware("renovate-operator-webhook"))
+	sub.Use(server.deliveryLogMiddleware)
 	sub.HandleFunc("/schedule", server.runRenovate).Methods("POST")
 	sub.HandleFunc("/gitlab", server.gitLabWebhook).Methods("POST")
 	sub.HandleFunc("/github", server.githubWebhook).Methods("POST")
 	sub.HandleFunc("/forgejo", server.forgejoWebhook).Methods("POST")
 	sub.HandleFunc("/gitea", server.giteaWebhook).Methods("POST")
 	sub.HandleFunc("/bitbucket", server.bitbucketWebhook).Methods("POST")
+	sub.HandleFunc("/bitbucket-server", server.bitbucketServerWebhook).Methods("POST")
+	sub.HandleFunc("/azure", server.azureWebhook).Methods("POST")
+	sub.HandleFunc("/cloudevents", server.cloudEventsWebhook).Methods("POST")
 }
 
 func (s *Server) Run() {
@@ -100,15 +142,7 @@ func (s *Server) runRenovate(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = s.manager.UpdateProjectStatus(
-		ctx,
-		project,
-		jobId,
-		&types.RenovateStatusUpdate{
-			Status:   api.JobStatusScheduled,
-			Priority: 1,
-		},
-	)
+	err = s.scheduleProject(ctx, provider, project, jobId)
 	if s.handleUpdateProjectStatusError(w, err, project, jobId.Name, jobId.Namespace) {
 		metricStore.IncWebhookRequest(ctx, provider, "rejected")
 		return
@@ -116,7 +150,7 @@ func (s *Server) runRenovate(w http.ResponseWriter, r *http.Request) {
 
 	metricStore.IncWebhookRequest(ctx, provider, "accepted")
 	w.WriteHeader(http.StatusOK)
-	s.logger.V(2).Info("Successfully triggered Renovate for project", "project", project, "renovateJob", jobId.Name, "namespace", jobId.Namespace, "priority", 1)
+	s.logger.V(2).Info("Successfully triggered Renovate for project", "project", project, "renovateJob", jobId.Name, "namespace", jobId.Namespace, "priority", webhookPriority)
 }
 
 // recordResolverAuthFailure emits the appropriate webhook auth-failure metric for an
@@ -157,6 +191,56 @@ func (s *Server) handleResolverError(w http.ResponseWriter, err error) {
 	s.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "internal server error"})
 }
 
+// ignoreUnmanagedProject answers a delivery of an organization hook about a
+// repository that is not a project of any job as an ignored event, and
+// reports whether it did. Organization hooks receive the events of every
+// repository of the organization, and rejecting those would get the hook
+// disabled on platforms that disable failing hooks, such as GitLab.
+func (s *Server) ignoreUnmanagedProject(w http.ResponseWriter, r *http.Request, provider string, err error) bool {
+	if !errors.Is(err, ErrNoMatchingJob) || r.URL.Query().Get(utils.WebhookScopeParameter) != string(api.WebhookSyncScopeOrganization) {
+		return false
+	}
+	metricStore.IncWebhookRequest(r.Context(), provider, "ignored")
+	s.writeJSON(w, http.StatusOK, map[string]string{"message": "event ignored", "reason": "repository is not a project of the RenovateJob"})
+	return true
+}
+
+// scheduleProject schedules a project on behalf of a webhook delivery, with
+// webhook priority.
+func (s *Server) scheduleProject(ctx context.Context, provider, project string, jobId crdmanager.RenovateJobIdentifier) error {
+	return s.scheduleProjectWithPriority(ctx, provider, project, jobId, webhookPriority)
+}
+
+// scheduleProjectWithPriority schedules a project on behalf of a delivery.
+// With a debounce window configured, the scheduling call is deferred until the
+// burst of deliveries is over, the returned error is always nil and the audit
+// entry is recorded once the call was made.
+func (s *Server) scheduleProjectWithPriority(ctx context.Context, provider, project string, jobId crdmanager.RenovateJobIdentifier, priority int32) error {
+	if s.debouncer != nil {
+		s.debouncer.Add(ctx, provider, project, jobId, priority)
+		return nil
+	}
+	err := s.updateProjectSchedule(ctx, project, jobId, priority)
+	s.recordAudit(ctx, provider, audit.ActionTrigger, jobId, project, err, nil)
+	return err
+}
+
+// updateProjectSchedule schedules a project with priority. A project that is
+// running is flagged to run again once the current run ends, so the state the
+// delivery reported is always processed.
+func (s *Server) updateProjectSchedule(ctx context.Context, project string, jobId crdmanager.RenovateJobIdentifier, priority int32) error {
+	return s.manager.UpdateProjectStatus(
+		ctx,
+		project,
+		jobId,
+		&types.RenovateStatusUpdate{
+			Status:         api.JobStatusScheduled,
+			Priority:       priority,
+			RerunIfRunning: true,
+		},
+	)
+}
+
 func (s *Server) handleUpdateProjectStatusError(w http.ResponseWriter, err error, project, job, namespace string) bool {
 	if err == nil {
 		return false